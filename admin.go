@@ -0,0 +1,88 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/insolar/network/store"
+
+	"github.com/jbenet/go-base58"
+)
+
+// LookupReport is outcome of lookup forced by operator
+type LookupReport struct {
+	// Whether value is held by this node
+	Local bool
+	// Whether value was found on other nodes
+	Found bool
+	// Nodes closest to key which responded, they are expected to hold the value
+	Closest []*node.Node
+}
+
+// ForceLookup looks key up in network even if value is held locally and reports what was found.
+// It is meant for diagnosing unreachable keys. Key is the base58 encoded identifier of the data.
+func (dht *DHT) ForceLookup(ctx Context, key string) (*LookupReport, error) {
+	keyBytes := base58.Decode(key)
+	if len(keyBytes) != store.KeySize {
+		return nil, errors.New("invalid key")
+	}
+
+	report := &LookupReport{}
+	_, report.Local = dht.retrieve(ctx, keyBytes)
+
+	value, closest, err := dht.iterate(ctx, routing.IterateFindValue, keyBytes, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	report.Found = value != nil
+	report.Closest = closest
+
+	return report, nil
+}
+
+// ForceRefresh refreshes given bucket of routing table right away regardless of RefreshTime.
+// Outcome is recorded along with regular refresh rounds.
+func (dht *DHT) ForceRefresh(ctx Context, bucket int) (RefreshRound, error) {
+	if bucket < 0 || bucket >= routing.KeyBitSize {
+		return RefreshRound{}, errors.New("invalid bucket")
+	}
+
+	ht := dht.htFromCtx(ctx)
+	counters := &refreshCounters{}
+	ctx = context.WithValue(ctx, ctxRefresh, counters)
+	round := RefreshRound{ID: ht.Origin.ID, Started: dht.options.Clock.Now(), Buckets: 1}
+
+	id := ht.GetRandomIDFromBucket(bucket)
+	_, _, err := dht.iterate(ctx, routing.IterateBootstrap, id, nil, nil)
+	if err != nil {
+		return RefreshRound{}, err
+	}
+
+	round.Learned = int(atomic.LoadInt64(&counters.learned))
+	round.Unreachable = int(atomic.LoadInt64(&counters.unreachable))
+	dht.refreshes.add(round)
+	if dht.options.OnRefresh != nil {
+		dht.options.OnRefresh(round)
+	}
+
+	return round, nil
+}
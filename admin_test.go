@@ -0,0 +1,89 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+
+	"github.com/jbenet/go-base58"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDHT_ForceLookup(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+	ctx := getDefaultCtx(dht2)
+
+	_, err = dht2.ForceLookup(ctx, "invalid")
+	assert.EqualError(t, err, "invalid key")
+
+	// Value is stored on the first node only
+	data := []byte("foo")
+	key := store.NewKey(data)
+	expiration := time.Now().Add(time.Hour)
+	assert.NoError(t, st1.Store(ctx, key, data, expiration, expiration, true))
+
+	report, err := dht2.ForceLookup(ctx, base58.Encode(key))
+	assert.NoError(t, err)
+	assert.False(t, report.Local)
+	assert.True(t, report.Found)
+
+	report, err = dht2.ForceLookup(ctx, base58.Encode(store.NewKey([]byte("bar"))))
+	assert.NoError(t, err)
+	assert.False(t, report.Found)
+	assert.Len(t, report.Closest, 1)
+	assert.Equal(t, id1[0], report.Closest[0].ID)
+
+	_, err = dht2.ForceRefresh(ctx, routing.KeyBitSize)
+	assert.EqualError(t, err, "invalid bucket")
+
+	round, err := dht2.ForceRefresh(ctx, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, round.Buckets)
+	assert.Equal(t, []RefreshRound{round}, dht2.RefreshRounds())
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
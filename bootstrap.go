@@ -0,0 +1,111 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/insolar/network/transport"
+)
+
+// bootstrapSeeds contacts bootstrap nodes of given table in waves until the table is healthy
+func (dht *DHT) bootstrapSeeds(ctx Context, ht *routing.HashTable) {
+	seeds := dht.prioritizeSeeds(dht.identity(ctx).BootstrapNodes)
+	waveSize := dht.options.BootstrapWaveSize
+
+	for start := 0; start < len(seeds); start += waveSize {
+		if dht.NumNodes(ctx) >= dht.options.BootstrapHealthyNodes {
+			return
+		}
+
+		end := start + waveSize
+		if end > len(seeds) {
+			end = len(seeds)
+		}
+		dht.bootstrapWave(ctx, ht, seeds[start:end])
+	}
+}
+
+// bootstrapWave adds given bootstrap nodes to routing table, nodes without ID are added once they respond to ping
+func (dht *DHT) bootstrapWave(ctx Context, ht *routing.HashTable, seeds []*node.Node) {
+	wg := &sync.WaitGroup{}
+	slots := make(chan bool, dht.options.BootstrapConcurrency)
+
+	for _, bn := range seeds {
+		if bn.ID != nil {
+			dht.addNode(ctx, routing.NewRouteNode(bn))
+			continue
+		}
+
+		slots <- true
+		future, err := dht.transport.SendRequest(newPingMessage(ht.Origin, bn))
+		if err != nil {
+			<-slots
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			dht.handleBootstrapPing(ctx, future, wg)
+			<-slots
+		}()
+	}
+
+	wg.Wait()
+}
+
+// handleBootstrapPing adds bootstrap node to routing table once it responds to ping
+func (dht *DHT) handleBootstrapPing(ctx Context, future transport.Future, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	result, err := dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+	if err != nil {
+		return
+	}
+	markReached(ctx)
+	dht.latenciesFor(ctx).observe(result.Sender, time.Since(future.StartTime()))
+	dht.versions.record(result)
+	dht.addNode(ctx, routing.NewRouteNode(result.Sender))
+}
+
+// prioritizeSeeds orders bootstrap nodes so that nodes with known ID go first and recently failed ones go last.
+// Nodes are shuffled within each group to spread load of nodes sharing the same seed list
+func (dht *DHT) prioritizeSeeds(seeds []*node.Node) []*node.Node {
+	var known, unknown, failed []*node.Node
+	for _, seed := range seeds {
+		switch {
+		case seed.ID == nil:
+			unknown = append(unknown, seed)
+		case dht.hints.isFailed(seed.ID):
+			failed = append(failed, seed)
+		default:
+			known = append(known, seed)
+		}
+	}
+
+	prioritized := make([]*node.Node, 0, len(seeds))
+	for _, group := range [][]*node.Node{known, unknown, failed} {
+		for _, i := range rand.Perm(len(group)) {
+			prioritized = append(prioritized, group[i])
+		}
+	}
+	return prioritized
+}
@@ -0,0 +1,91 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrioritizeSeeds(t *testing.T) {
+	st, s, tp, r, err := dhtParams([]node.ID{getIDWithValues(0)}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	dht, _ := NewDHT(st, s, tp, r, &Options{})
+
+	addr, _ := node.NewAddress("0.0.0.0:3001")
+	failed := &node.Node{ID: getZerodIDWithNthByte(1, byte(1)), Address: addr}
+	unknown := &node.Node{Address: addr}
+	known := &node.Node{ID: getZerodIDWithNthByte(1, byte(2)), Address: addr}
+	dht.hints.markFailed(failed)
+
+	seeds := dht.prioritizeSeeds([]*node.Node{failed, unknown, known})
+
+	assert.Equal(t, []*node.Node{known, unknown, failed}, seeds)
+}
+
+func TestBootstrapSeeds_Waves(t *testing.T) {
+	st, s, tp, r, err := dhtParams([]node.ID{getIDWithValues(0)}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+
+	var seeds []*node.Node
+	for i := 0; i < 10; i++ {
+		addr, _ := node.NewAddress("0.0.0.0:3001")
+		seeds = append(seeds, &node.Node{Address: addr})
+	}
+
+	dht, _ := NewDHT(st, s, tp, r, &Options{
+		BootstrapNodes:        seeds,
+		BootstrapWaveSize:     3,
+		BootstrapConcurrency:  2,
+		BootstrapHealthyNodes: 4,
+		MessageTimeout:        time.Second,
+	})
+	mockTp := tp.(*mockTransport)
+	ctx := getDefaultCtx(dht)
+
+	var pings, inFlight, maxInFlight int32
+	go func() {
+		for request := range mockTp.recv {
+			pings++
+			current := atomic.AddInt32(&inFlight, 1)
+			if current > atomic.LoadInt32(&maxInFlight) {
+				atomic.StoreInt32(&maxInFlight, current)
+			}
+
+			sender := &node.Node{ID: getZerodIDWithNthByte(2, byte(pings)), Address: request.Receiver.Address}
+			response := message.NewBuilder().Sender(sender).Receiver(request.Sender).Type(message.TypePing).Response(
+				&message.ResponseDataPing{}).Build()
+			go func() {
+				time.Sleep(time.Millisecond * 10)
+				atomic.AddInt32(&inFlight, -1)
+				mockTp.send <- response
+			}()
+		}
+	}()
+
+	dht.bootstrapSeeds(ctx, dht.tables[0])
+
+	// Second wave makes table healthy, the rest of seeds is not contacted
+	assert.Equal(t, 6, dht.NumNodes(ctx))
+	assert.Equal(t, int32(6), pings)
+	assert.True(t, atomic.LoadInt32(&maxInFlight) <= 2)
+}
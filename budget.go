@@ -0,0 +1,98 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/clock"
+)
+
+// maintenanceSlice is the time slice maintenance budget is granted for
+const maintenanceSlice = time.Second
+
+// maintenanceBudget limits number of messages and bytes sent by maintenance tasks
+// (refresh, replication) during each time slice, so they don't crowd out foreground queries.
+// Zero limit means unlimited. Slices are measured with DHT clock.
+type maintenanceBudget struct {
+	mutex    *sync.Mutex
+	clock    clock.Clock
+	messages int
+	bytes    int
+	slice    time.Duration
+
+	sliceStart   time.Time
+	usedMessages int
+	usedBytes    int
+}
+
+func newMaintenanceBudget(messages, bytes int, slice time.Duration, c clock.Clock) *maintenanceBudget {
+	return &maintenanceBudget{
+		mutex:    &sync.Mutex{},
+		clock:    c,
+		messages: messages,
+		bytes:    bytes,
+		slice:    slice,
+	}
+}
+
+// reserve blocks until budget for message of given size is available.
+// Message larger than the whole byte budget is sent alone in a slice.
+func (b *maintenanceBudget) reserve(size int) {
+	for {
+		wait := b.tryReserve(size)
+		if wait <= 0 {
+			return
+		}
+		b.clock.Sleep(wait)
+	}
+}
+
+// tryReserve reserves budget in current slice or returns time left until the next one
+func (b *maintenanceBudget) tryReserve(size int) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := b.clock.Now()
+	if now.Sub(b.sliceStart) >= b.slice {
+		b.sliceStart = now
+		b.usedMessages = 0
+		b.usedBytes = 0
+	}
+
+	fitsMessages := b.messages == 0 || b.usedMessages < b.messages
+	fitsBytes := b.bytes == 0 || b.usedBytes == 0 || b.usedBytes+size <= b.bytes
+	if fitsMessages && fitsBytes {
+		b.usedMessages++
+		b.usedBytes += size
+		return 0
+	}
+
+	return b.slice - now.Sub(b.sliceStart)
+}
+
+// withMaintenance marks ctx as used by maintenance task
+func withMaintenance(ctx Context) Context {
+	return context.WithValue(ctx, ctxMaintenance, true)
+}
+
+func isMaintenance(ctx Context) bool {
+	maintenance, _ := ctx.Value(ctxMaintenance).(bool)
+	return maintenance
+}
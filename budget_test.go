@@ -0,0 +1,87 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/clock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenanceBudget_Messages(t *testing.T) {
+	budget := newMaintenanceBudget(2, 0, time.Minute, clock.New())
+
+	assert.Zero(t, budget.tryReserve(100))
+	assert.Zero(t, budget.tryReserve(100))
+	assert.True(t, budget.tryReserve(100) > 0)
+}
+
+func TestMaintenanceBudget_Bytes(t *testing.T) {
+	budget := newMaintenanceBudget(0, 100, time.Minute, clock.New())
+
+	assert.Zero(t, budget.tryReserve(60))
+	assert.True(t, budget.tryReserve(60) > 0)
+	assert.Zero(t, budget.tryReserve(40))
+}
+
+func TestMaintenanceBudget_LargeMessage(t *testing.T) {
+	budget := newMaintenanceBudget(0, 100, time.Minute, clock.New())
+
+	assert.Zero(t, budget.tryReserve(1000))
+	assert.True(t, budget.tryReserve(1) > 0)
+}
+
+func TestMaintenanceBudget_Reserve(t *testing.T) {
+	budget := newMaintenanceBudget(1, 0, time.Millisecond*100, clock.New())
+
+	start := time.Now()
+	budget.reserve(1)
+	budget.reserve(1)
+	budget.reserve(1)
+
+	assert.True(t, time.Since(start) >= time.Millisecond*200)
+}
+
+func TestMaintenanceBudget_Reserve_VirtualClock(t *testing.T) {
+	c := clock.NewVirtual(time.Now())
+	budget := newMaintenanceBudget(1, 0, time.Hour, c)
+	budget.reserve(1)
+
+	reserved := make(chan bool)
+	go func() {
+		budget.reserve(1)
+		reserved <- true
+	}()
+
+	// Throttled task waits for virtual time only
+	for c.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.Advance(time.Hour)
+	<-reserved
+}
+
+func TestWithMaintenance(t *testing.T) {
+	ctx := context.Background()
+
+	assert.False(t, isMaintenance(ctx))
+	assert.True(t, isMaintenance(withMaintenance(ctx)))
+}
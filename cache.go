@@ -0,0 +1,79 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+)
+
+// missedNode is the closest node which answered value lookup without value
+type missedNode struct {
+	node  *node.Node
+	token []byte
+}
+
+// add replaces missed node with given one if it is closer to target
+func (mn *missedNode) add(target []byte, n *node.Node, token []byte) {
+	if mn.node == nil || closer(target, n.ID, mn.node.ID) {
+		mn.node, mn.token = n, token
+	}
+}
+
+// closer tells if id1 is closer to target than id2
+func closer(target, id1, id2 []byte) bool {
+	for i := range target {
+		d1, d2 := id1[i]^target[i], id2[i]^target[i]
+		if d1 != d2 {
+			return d1 < d2
+		}
+	}
+	return false
+}
+
+// cacheValue stores copy of value found by lookup on node which answered lookup without it,
+// so values looked up often are found closer to requesters. Copy expires after CacheTTL.
+func (dht *DHT) cacheValue(ctx Context, entry store.Entry, receiver *node.Node, token []byte) {
+	ht := dht.htFromCtx(ctx)
+	sent, compressed := entry.Data, false
+	if dht.options.ValueCompression > 0 {
+		sent, compressed = store.CompressValue(entry.Data, dht.options.ValueCompression)
+	}
+	msg := message.NewBuilder().Sender(ht.Origin).Receiver(receiver).Type(message.TypeStore).Request(
+		&message.RequestDataStore{
+			Data:       sent,
+			Token:      token,
+			Version:    entry.Version,
+			Compressed: compressed,
+			Namespace:  store.NamespaceOf(ctx),
+			TTL:        dht.options.CacheTTL,
+			Cached:     true,
+		}).Build()
+
+	future, err := dht.sendRequest(ctx, msg)
+	if err != nil {
+		dht.hints.markFailed(receiver)
+		return
+	}
+
+	_, err = dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+	if err == transport.ErrFutureTimeout {
+		dht.hints.markFailed(receiver)
+	}
+}
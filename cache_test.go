@@ -0,0 +1,103 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+	"github.com/jbenet/go-base58"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissedNode_Add(t *testing.T) {
+	target := []byte{0x0f, 0x00}
+	far := &node.Node{ID: []byte{0xf0, 0x00}}
+	near := &node.Node{ID: []byte{0x0f, 0x01}}
+
+	missed := &missedNode{}
+	missed.add(target, far, []byte("far"))
+	missed.add(target, near, []byte("near"))
+	missed.add(target, far, []byte("far"))
+	assert.Equal(t, near, missed.node)
+	assert.Equal(t, []byte("near"), missed.token)
+}
+
+func TestDHT_CacheValue(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	var dhts = []*DHT{dht1}
+	for _, address := range []string{"127.0.0.1:3001", "127.0.0.1:3002"} {
+		st, s, tp, r, err := inMemoryDhtParams(network, nil, address)
+		assert.NoError(t, err)
+		dht, _ := NewDHT(st, s, tp, r, &Options{
+			BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+			CacheTTL:       time.Minute,
+		})
+		dhts = append(dhts, dht)
+	}
+
+	for _, dht := range dhts {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+	for _, dht := range dhts[1:] {
+		assert.NoError(t, dht.Bootstrap())
+	}
+	dht2, dht3 := dhts[1], dhts[2]
+
+	// Only the first node holds value, the second one answers lookup without it
+	data := []byte("foo")
+	key := store.NewKey(data)
+	expiration := time.Now().Add(time.Hour)
+	_, err = dht1.storeVersion(getDefaultCtx(dht1), key, data, expiration, expiration, false, store.Version{})
+	assert.NoError(t, err)
+
+	value, found, err := dht3.Get(getDefaultCtx(dht3), base58.Encode(key))
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, data, value)
+
+	var cached []store.Entry
+	for i := 0; i < 50 && len(cached) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		cached, err = dht2.storeFor(getDefaultCtx(dht2)).RetrieveBatch(getDefaultCtx(dht2), []store.Key{key})
+		assert.NoError(t, err)
+	}
+	assert.Len(t, cached, 1)
+	if len(cached) == 1 {
+		assert.Equal(t, data, cached[0].Data)
+		assert.True(t, cached[0].Expiration.Before(time.Now().Add(2*time.Minute)))
+		assert.True(t, cached[0].Replication.After(cached[0].Expiration))
+	}
+
+	for _, dht := range dhts {
+		dht.Disconnect()
+		<-done
+	}
+}
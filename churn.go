@@ -0,0 +1,147 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"sync"
+	"time"
+)
+
+// churnSmoothing is weight of the latest refresh period in churn rate of bucket
+const churnSmoothing = 0.5
+
+// BucketChurn describes turnover of contacts in a single bucket of routing table
+type BucketChurn struct {
+	// Changes is number of contacts added to or removed from bucket since its last refresh
+	Changes int
+	// Rate is smoothed number of changes per hour measured over past refresh periods
+	Rate float64
+	// Measured tells if bucket was refreshed at least once, so Rate is known
+	Measured bool
+}
+
+// churnTracker counts contact changes of routing table buckets between their refreshes
+type churnTracker struct {
+	mutex   *sync.Mutex
+	started time.Time
+	buckets map[int]*bucketChurn
+}
+
+// bucketChurn is a state of churn measurement of one bucket
+type bucketChurn struct {
+	BucketChurn
+	since time.Time
+}
+
+func newChurnTracker(started time.Time) *churnTracker {
+	return &churnTracker{
+		mutex:   &sync.Mutex{},
+		started: started,
+		buckets: make(map[int]*bucketChurn),
+	}
+}
+
+func (ct *churnTracker) bucket(index int) *bucketChurn {
+	b, ok := ct.buckets[index]
+	if !ok {
+		b = &bucketChurn{since: ct.started}
+		ct.buckets[index] = b
+	}
+	return b
+}
+
+// changed counts contacts added to or removed from bucket
+func (ct *churnTracker) changed(index int, changes int) {
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+
+	ct.bucket(index).Changes += changes
+}
+
+// refreshed closes measurement period of bucket refreshed at now
+func (ct *churnTracker) refreshed(index int, now time.Time) {
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+
+	b := ct.bucket(index)
+	elapsed := now.Sub(b.since)
+	if elapsed <= 0 {
+		return
+	}
+	rate := float64(b.Changes) / elapsed.Hours()
+	if b.Measured {
+		rate = churnSmoothing*rate + (1-churnSmoothing)*b.Rate
+	}
+	b.Rate = rate
+	b.Measured = true
+	b.Changes = 0
+	b.since = now
+}
+
+// interval returns refresh interval of bucket. Bucket is refreshed about as often as one of its
+// contacts is expected to change, within min and max. Buckets not measured yet use base interval.
+func (ct *churnTracker) interval(index int, base, min, max time.Duration) time.Duration {
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+
+	b := ct.bucket(index)
+	if !b.Measured {
+		return base
+	}
+	if b.Rate <= 0 {
+		return max
+	}
+	interval := time.Duration(float64(time.Hour) / b.Rate)
+	if interval < min {
+		return min
+	}
+	if interval > max {
+		return max
+	}
+	return interval
+}
+
+// snapshot returns churn of buckets keyed by bucket index
+func (ct *churnTracker) snapshot() map[int]BucketChurn {
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+
+	churn := make(map[int]BucketChurn, len(ct.buckets))
+	for index, b := range ct.buckets {
+		churn[index] = b.BucketChurn
+	}
+	return churn
+}
+
+// Churn returns contact turnover of routing table buckets keyed by bucket index.
+// With AdaptiveRefresh option buckets are refreshed as often as their contacts change.
+// Churn is tracked separately for every ID, ctx selects one of them.
+func (dht *DHT) Churn(ctx Context) map[int]BucketChurn {
+	return dht.churnFor(ctx).snapshot()
+}
+
+func (dht *DHT) churnFor(ctx Context) *churnTracker {
+	return dht.churn[ctx.Value(ctxTableIndex).(int)]
+}
+
+// refreshInterval returns time after which unaccessed bucket of table is refreshed
+func (dht *DHT) refreshInterval(ctx Context, bucket int) time.Duration {
+	if !dht.options.AdaptiveRefresh {
+		return dht.options.RefreshTime
+	}
+	return dht.churnFor(ctx).interval(bucket, dht.options.RefreshTime, dht.options.MinRefreshTime, dht.options.MaxRefreshTime)
+}
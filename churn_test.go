@@ -0,0 +1,74 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChurnTracker_Interval(t *testing.T) {
+	start := time.Now()
+	ct := newChurnTracker(start)
+	base, min, max := time.Hour, time.Minute*15, time.Hour*4
+
+	// Bucket which was not refreshed yet uses base interval
+	assert.Equal(t, base, ct.interval(0, base, min, max))
+
+	// Two changes per hour make bucket refreshed every 30 minutes
+	ct.changed(0, 2)
+	ct.refreshed(0, start.Add(time.Hour))
+	assert.Equal(t, time.Minute*30, ct.interval(0, base, min, max))
+	assert.Equal(t, BucketChurn{Rate: 2, Measured: true}, ct.snapshot()[0])
+
+	// Rate is smoothed over refresh periods
+	ct.changed(0, 8)
+	ct.refreshed(0, start.Add(2*time.Hour))
+	assert.Equal(t, 5.0, ct.snapshot()[0].Rate)
+	assert.Equal(t, min, ct.interval(0, base, min, max))
+
+	// Stable bucket is refreshed rarely
+	ct.refreshed(1, start.Add(time.Hour))
+	assert.Equal(t, max, ct.interval(1, base, min, max))
+}
+
+func TestDHT_Churn(t *testing.T) {
+	id := getIDWithValues(0)
+	st, s, tp, r, err := dhtParams([]node.ID{id}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+
+	dht, _ := NewDHT(st, s, tp, r, &Options{AdaptiveRefresh: true})
+	ctx := getDefaultCtx(dht)
+	assert.Equal(t, time.Hour, dht.refreshInterval(ctx, 1))
+
+	addr, _ := node.NewAddress("0.0.0.0:3001")
+	contact := &node.Node{ID: getZerodIDWithNthByte(1, byte(1)), Address: addr}
+	dht.addNode(ctx, routing.NewRouteNode(contact))
+	index := dht.tables[0].BucketIndex(contact.ID)
+	assert.Equal(t, 1, dht.Churn(ctx)[index].Changes)
+
+	// Known contact is not a change
+	dht.addNode(ctx, routing.NewRouteNode(contact))
+	assert.Equal(t, 1, dht.Churn(ctx)[index].Changes)
+
+	dht.evictDeadPeer(addr.String())
+	assert.Equal(t, 2, dht.Churn(ctx)[index].Changes)
+}
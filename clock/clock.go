@@ -0,0 +1,83 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+// Package clock abstracts time so that timers of network can be driven by virtual time in simulations
+package clock
+
+import (
+	"time"
+)
+
+// Clock tells current time and creates timers
+type Clock interface {
+	// Now returns current time
+	Now() time.Time
+	// After waits for the duration to elapse and then sends current time on returned channel
+	After(d time.Duration) <-chan time.Time
+	// Sleep pauses current goroutine for at least the duration
+	Sleep(d time.Duration)
+	// NewTicker returns new Ticker sending current time every period
+	NewTicker(period time.Duration) Ticker
+}
+
+// Ticker delivers ticks of a clock at intervals
+type Ticker interface {
+	// C returns channel ticks are delivered on
+	C() <-chan time.Time
+	// Stop turns off ticker
+	Stop()
+}
+
+type realClock struct{}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+// New creates Clock backed by system time
+func New() Clock {
+	return realClock{}
+}
+
+// Now returns current time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to elapse and then sends current time on returned channel
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Sleep pauses current goroutine for at least the duration
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// NewTicker returns new Ticker sending current time every period
+func (realClock) NewTicker(period time.Duration) Ticker {
+	return &realTicker{ticker: time.NewTicker(period)}
+}
+
+// C returns channel ticks are delivered on
+func (t *realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+// Stop turns off ticker
+func (t *realTicker) Stop() {
+	t.ticker.Stop()
+}
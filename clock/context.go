@@ -0,0 +1,77 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// timeoutContext is a context which deadline is measured by a Clock
+type timeoutContext struct {
+	context.Context
+	deadline time.Time
+	mutex    sync.Mutex
+	expired  bool
+}
+
+// WithTimeout returns copy of parent which is done once d elapses on c, like context.WithTimeout does for system time.
+// Err of returned context is context.DeadlineExceeded then. Cancel should be called as soon as work is done
+func WithTimeout(parent context.Context, c Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := c.(realClock); ok {
+		return context.WithTimeout(parent, d)
+	}
+
+	cancelCtx, cancel := context.WithCancel(parent)
+	ctx := &timeoutContext{Context: cancelCtx, deadline: c.Now().Add(d)}
+	expired := c.After(d)
+	go func() {
+		select {
+		case <-expired:
+			ctx.expire()
+			cancel()
+		case <-cancelCtx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// Deadline returns time on clock when context is done
+func (ctx *timeoutContext) Deadline() (time.Time, bool) {
+	return ctx.deadline, true
+}
+
+// Err returns context.DeadlineExceeded if deadline passed, error of cancelled context otherwise
+func (ctx *timeoutContext) Err() error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.expired {
+		return context.DeadlineExceeded
+	}
+	return ctx.Context.Err()
+}
+
+func (ctx *timeoutContext) expire() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.Context.Err() == nil {
+		ctx.expired = true
+	}
+}
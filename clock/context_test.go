@@ -0,0 +1,61 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTimeout_Virtual(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	v := NewVirtual(start)
+
+	ctx, cancel := WithTimeout(context.Background(), v, time.Minute)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(time.Minute), deadline)
+	assert.NoError(t, ctx.Err())
+
+	v.Advance(time.Minute)
+	<-ctx.Done()
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+}
+
+func TestWithTimeout_Cancel(t *testing.T) {
+	v := NewVirtual(time.Now())
+
+	ctx, cancel := WithTimeout(context.Background(), v, time.Minute)
+	cancel()
+	<-ctx.Done()
+	assert.Equal(t, context.Canceled, ctx.Err())
+
+	v.Advance(time.Minute)
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
+
+func TestWithTimeout_Real(t *testing.T) {
+	ctx, cancel := WithTimeout(context.Background(), New(), time.Millisecond)
+	defer cancel()
+
+	<-ctx.Done()
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+}
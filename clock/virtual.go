@@ -0,0 +1,166 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Virtual is a Clock which time moves only when Advance is called.
+// It lets simulations of long refresh and replication cycles complete in seconds.
+type Virtual struct {
+	mutex  *sync.Mutex
+	now    time.Time
+	timers []*virtualTimer
+}
+
+type virtualTimer struct {
+	clock  *Virtual
+	at     time.Time
+	period time.Duration
+	c      chan time.Time
+}
+
+// NewVirtual creates Virtual clock starting at given time
+func NewVirtual(start time.Time) *Virtual {
+	return &Virtual{
+		mutex: &sync.Mutex{},
+		now:   start,
+	}
+}
+
+// Now returns current virtual time
+func (v *Virtual) Now() time.Time {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	return v.now
+}
+
+// After sends current virtual time on returned channel once clock is advanced by the duration
+func (v *Virtual) After(d time.Duration) <-chan time.Time {
+	return v.addTimer(d, 0).c
+}
+
+// Sleep blocks until clock is advanced by the duration
+func (v *Virtual) Sleep(d time.Duration) {
+	<-v.After(d)
+}
+
+// NewTicker returns new Ticker sending current virtual time every period.
+// Like time.Ticker, it drops ticks for slow receivers.
+func (v *Virtual) NewTicker(period time.Duration) Ticker {
+	if period <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return v.addTimer(period, period)
+}
+
+// Advance moves clock forward by the duration firing timers in order they are due
+func (v *Virtual) Advance(d time.Duration) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	target := v.now.Add(d)
+	for {
+		next := v.nextTimer(target)
+		if next == nil {
+			break
+		}
+
+		v.now = next.at
+		next.fire(v.now)
+		if next.period > 0 {
+			next.at = next.at.Add(next.period)
+		} else {
+			v.removeTimer(next)
+		}
+	}
+	v.now = target
+}
+
+// Timers returns number of pending timers and tickers. Simulations can use it
+// to wait until goroutines are blocked on clock before advancing it.
+func (v *Virtual) Timers() int {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	return len(v.timers)
+}
+
+func (v *Virtual) addTimer(d time.Duration, period time.Duration) *virtualTimer {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	timer := &virtualTimer{
+		clock:  v,
+		at:     v.now.Add(d),
+		period: period,
+		c:      make(chan time.Time, 1),
+	}
+	if d <= 0 {
+		timer.fire(v.now)
+		return timer
+	}
+
+	v.timers = append(v.timers, timer)
+	return timer
+}
+
+// nextTimer returns the earliest timer due not later than target
+func (v *Virtual) nextTimer(target time.Time) *virtualTimer {
+	var next *virtualTimer
+	for _, timer := range v.timers {
+		if timer.at.After(target) {
+			continue
+		}
+		if next == nil || timer.at.Before(next.at) {
+			next = timer
+		}
+	}
+	return next
+}
+
+func (v *Virtual) removeTimer(timer *virtualTimer) {
+	for i, t := range v.timers {
+		if t == timer {
+			v.timers = append(v.timers[:i], v.timers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (t *virtualTimer) fire(now time.Time) {
+	select {
+	case t.c <- now:
+	default:
+	}
+}
+
+// C returns channel ticks are delivered on
+func (t *virtualTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// Stop turns off ticker
+func (t *virtualTimer) Stop() {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	t.clock.removeTimer(t)
+}
@@ -0,0 +1,89 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVirtual_After(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	v := NewVirtual(start)
+
+	c := v.After(time.Hour)
+	assert.Equal(t, 1, v.Timers())
+
+	v.Advance(time.Minute * 59)
+	select {
+	case <-c:
+		t.Fatal("timer fired too early")
+	default:
+	}
+
+	v.Advance(time.Minute * 2)
+	assert.Equal(t, start.Add(time.Hour), <-c)
+	assert.Equal(t, start.Add(time.Minute*61), v.Now())
+	assert.Equal(t, 0, v.Timers())
+}
+
+func TestVirtual_After_NonPositive(t *testing.T) {
+	v := NewVirtual(time.Time{})
+
+	<-v.After(0)
+	assert.Equal(t, 0, v.Timers())
+}
+
+func TestVirtual_NewTicker(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	v := NewVirtual(start)
+
+	ticker := v.NewTicker(time.Second)
+	v.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), <-ticker.C())
+
+	// Ticks are dropped for slow receivers
+	v.Advance(time.Second * 10)
+	assert.Equal(t, start.Add(time.Second*2), <-ticker.C())
+
+	ticker.Stop()
+	assert.Equal(t, 0, v.Timers())
+	v.Advance(time.Second)
+	select {
+	case <-ticker.C():
+		t.Fatal("stopped ticker fired")
+	default:
+	}
+}
+
+func TestVirtual_Sleep(t *testing.T) {
+	v := NewVirtual(time.Time{})
+
+	done := make(chan bool)
+	go func() {
+		v.Sleep(time.Hour)
+		close(done)
+	}()
+
+	for v.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	v.Advance(time.Hour)
+	<-done
+}
@@ -17,17 +17,19 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"os"
-	"os/signal"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/insolar/network"
 	"github.com/insolar/network/connection"
+	"github.com/insolar/network/message"
 	"github.com/insolar/network/node"
 	"github.com/insolar/network/resolver"
 	"github.com/insolar/network/rpc"
@@ -35,6 +37,7 @@ import (
 	"github.com/insolar/network/transport"
 
 	"github.com/chzyer/readline"
+	"github.com/jbenet/go-base58"
 )
 
 func main() {
@@ -42,6 +45,8 @@ func main() {
 	var bootstrapAddress = flag.String("bootstrap", "", "IP Address and port to bootstrap against")
 	var help = flag.Bool("help", false, "Display Help")
 	var stun = flag.Bool("stun", true, "Use STUN")
+	var tcp = flag.Bool("tcp", false, "Use TCP transport instead of uTP")
+	var kcp = flag.Bool("kcp", false, "Use KCP transport instead of uTP")
 
 	flag.Parse()
 
@@ -55,13 +60,16 @@ func main() {
 	configuration := network.NewNetworkConfiguration(
 		createResolver(*stun),
 		connection.NewConnectionFactory(),
-		transport.NewUTPTransportFactory(),
+		createTransportFactory(*tcp, *kcp),
 		store.NewMemoryStoreFactory(),
 		rpc.NewRPCFactory(map[string]rpc.RemoteProcedure{
 			"s": send,
 		}))
 	dhtNetwork, err := configuration.CreateNetwork(*addr, &network.Options{
 		BootstrapNodes: bootstrapNodes,
+		OnListen: func(addr net.Addr) {
+			fmt.Println("Listening on", addr.String())
+		},
 	})
 	if err != nil {
 		log.Fatalln("Failed to create network:", err.Error())
@@ -71,21 +79,21 @@ func main() {
 
 	ctx := createContext(dhtNetwork)
 
-	go listen(dhtNetwork)
+	start(ctx, dhtNetwork)
 	bootstrap(bootstrapNodes, dhtNetwork)
 
-	handleSignals(configuration)
+	handleSignals(dhtNetwork, configuration)
 
 	repl(dhtNetwork, ctx)
 }
 
-func handleSignals(configuration *network.Configuration) {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
+func handleSignals(dhtNetwork *network.DHT, configuration *network.Configuration) {
 	go func() {
-		for range c {
-			closeNetwork(configuration)
+		err := network.RunUntilSignal(dhtNetwork, configuration)
+		if err != nil {
+			log.Fatalln("Failed to close network:", err.Error())
 		}
+		os.Exit(0)
 	}()
 }
 
@@ -106,13 +114,11 @@ func bootstrap(bootstrapNodes []*node.Node, dhtNetwork *network.DHT) {
 	}
 }
 
-func listen(dhtNetwork *network.DHT) {
-	func() {
-		err := dhtNetwork.Listen()
-		if err != nil {
-			log.Fatalln("Listen failed:", err.Error())
-		}
-	}()
+func start(ctx network.Context, dhtNetwork *network.DHT) {
+	err := dhtNetwork.Start(ctx)
+	if err != nil {
+		log.Fatalln("Failed to start network:", err.Error())
+	}
 }
 
 func closeNetwork(configuration *network.Configuration) {
@@ -149,6 +155,16 @@ func repl(dhtNetwork *network.DHT, ctx network.Context) {
 			doFindNode(input, dhtNetwork, ctx)
 		case "info":
 			doInfo(dhtNetwork, ctx)
+		case "requests":
+			doRequests(dhtNetwork)
+		case "cancel":
+			doCancel(input, dhtNetwork)
+		case "stats":
+			doStats(dhtNetwork)
+		case "lookup":
+			doLookup(input, dhtNetwork, ctx)
+		case "refresh":
+			doRefresh(input, dhtNetwork, ctx)
 		default:
 			doRPC(input, dhtNetwork, ctx)
 		}
@@ -178,6 +194,16 @@ func createResolver(stun bool) resolver.PublicAddressResolver {
 	return publicAddressResolver
 }
 
+func createTransportFactory(tcp, kcp bool) transport.Factory {
+	if tcp {
+		return transport.NewTCPTransportFactory()
+	}
+	if kcp {
+		return transport.NewKCPTransportFactory(nil)
+	}
+	return transport.NewUTPTransportFactory()
+}
+
 func doFindNode(input []string, dhtNetwork *network.DHT, ctx network.Context) {
 	if len(input) != 2 {
 		displayInteractiveHelp()
@@ -202,6 +228,64 @@ func doInfo(dhtNetwork *network.DHT, ctx network.Context) {
 	fmt.Println("Known nodes: " + strconv.Itoa(nodes))
 }
 
+func doRequests(dhtNetwork *network.DHT) {
+	for _, future := range dhtNetwork.PendingRequests() {
+		fmt.Printf("%d: type %d to %s, %s ago\n",
+			future.ID(), future.Request().Type, future.Actor(), time.Since(future.StartTime()))
+	}
+}
+
+func doCancel(input []string, dhtNetwork *network.DHT) {
+	if len(input) != 2 {
+		displayInteractiveHelp()
+		return
+	}
+	if requestID, err := strconv.ParseUint(input[1], 10, 64); err == nil {
+		if !dhtNetwork.CancelRequest(message.RequestID(requestID)) {
+			fmt.Println("..Request not found!")
+		}
+		return
+	}
+	cancelled := dhtNetwork.CancelRequestsTo(base58.Decode(input[1]))
+	fmt.Println("..Cancelled requests:", cancelled)
+}
+
+func doLookup(input []string, dhtNetwork *network.DHT, ctx network.Context) {
+	if len(input) != 2 {
+		displayInteractiveHelp()
+		return
+	}
+	report, err := dhtNetwork.ForceLookup(ctx, input[1])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Println("Held locally:", report.Local)
+	fmt.Println("Found in network:", report.Found)
+	fmt.Println("Closest nodes:")
+	for _, n := range report.Closest {
+		fmt.Println("..", n)
+	}
+}
+
+func doRefresh(input []string, dhtNetwork *network.DHT, ctx network.Context) {
+	if len(input) != 2 {
+		displayInteractiveHelp()
+		return
+	}
+	bucket, err := strconv.Atoi(input[1])
+	if err != nil {
+		displayInteractiveHelp()
+		return
+	}
+	round, err := dhtNetwork.ForceRefresh(ctx, bucket)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("Learned contacts: %d, unreachable: %d\n", round.Learned, round.Unreachable)
+}
+
 func doRPC(input []string, dhtNetwork *network.DHT, ctx network.Context) {
 	if len(input) < 2 || len(input[0]) == 0 || len(input[1]) == 0 {
 		if len(input) > 0 && len(input[0]) > 0 {
@@ -234,9 +318,22 @@ Usage:
 
 Options:
 	--help Show this screen.
-	--addr=<ip> Local IP and Port [default: 0.0.0.0]
+	--addr=<ip> Local IP and Port, use [::]:<port> to listen on IPv4 and IPv6 [default: 0.0.0.0]
 	--bootstrap=<ip> Bootstrap IP and Port
-	--stun=<bool> Use STUN protocol for public addr discovery [default: true]`)
+	--stun=<bool> Use STUN protocol for public addr discovery [default: true]
+	--tcp=<bool> Use TCP transport instead of uTP [default: false]
+	--kcp=<bool> Use KCP transport instead of uTP [default: false]`)
+}
+
+func doStats(dhtNetwork *network.DHT) {
+	stats := dhtNetwork.TransportStats()
+	fmt.Println("Sent messages:", stats.Sent)
+	fmt.Println("Received messages:", stats.Received)
+	fmt.Printf("Bytes in: %d, out: %d\n", stats.BytesIn, stats.BytesOut)
+	fmt.Printf("Send failures: %d, cancelled: %d, timeouts: %d\n", stats.SendFailures, stats.Cancelled, stats.Timeouts)
+	if len(stats.QueueDrops) > 0 {
+		fmt.Println("Dropped on full send queues:", stats.QueueDrops)
+	}
 }
 
 func displayInteractiveHelp() {
@@ -244,11 +341,16 @@ func displayInteractiveHelp() {
 help - This message
 findnode <key> - Find node's real network address
 info - Display information about this node
+requests - List outgoing requests waiting for response
+cancel <request id|key> - Cancel outgoing request or all requests to node
+stats - Display transport I/O counters
+lookup <key> - Look key up in network and show closest nodes
+refresh <bucket> - Refresh routing table bucket right away
 
 <method> <target> <args...> - Remote procedure call`)
 }
 
-func send(sender *node.Node, args [][]byte) ([]byte, error) {
+func send(ctx context.Context, sender *node.Node, args [][]byte) ([]byte, error) {
 	bs := append([]byte{}, []byte(time.Now().Format(time.Kitchen))...)
 	bs = append(bs, ' ')
 	bs = append(bs, sender.ID.String()...)
@@ -38,6 +38,7 @@ type Configuration struct {
 	rpcFactory        rpc.Factory
 
 	network *DHT
+	standby *Standby
 	conn    net.PacketConn
 }
 
@@ -60,9 +61,18 @@ func NewNetworkConfiguration(
 
 // CreateNetwork creates and returns DHT network with parameters stored in Configuration
 func (cfg *Configuration) CreateNetwork(address string, options *Options) (*DHT, error) {
+	if cfg.network != nil || cfg.standby != nil {
+		return nil, errors.New("already created")
+	}
+
+	return cfg.createNetwork(address, nil, options)
+}
+
+// CreateStandby creates Standby receiving state of active node on address, see Options.StandbyAddress
+func (cfg *Configuration) CreateStandby(address string) (*Standby, error) {
 	var err error
 
-	if cfg.network != nil {
+	if cfg.network != nil || cfg.standby != nil {
 		return nil, errors.New("already created")
 	}
 
@@ -71,6 +81,50 @@ func (cfg *Configuration) CreateNetwork(address string, options *Options) (*DHT,
 		return nil, err
 	}
 
+	tp, err := cfg.transportFactory.Create(cfg.conn)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.standby = NewStandby(tp)
+	return cfg.standby, nil
+}
+
+// TakeOver stops Standby and creates network on address of failed active node with its IDs and
+// routing tables and stores replicated to standby. Options should contain PrivateKey of active node.
+func (cfg *Configuration) TakeOver(address string, options *Options) (*DHT, error) {
+	standby := cfg.standby
+	if standby == nil {
+		return nil, errors.New("standby is not created")
+	}
+	ids := standby.IDs()
+	if len(ids) == 0 {
+		return nil, errors.New("standby is not synced")
+	}
+
+	standby.Stop()
+	err := cfg.closeConn()
+	if err != nil {
+		return nil, err
+	}
+	cfg.standby = nil
+
+	dht, err := cfg.createNetwork(address, ids, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return dht, standby.Restore(dht)
+}
+
+func (cfg *Configuration) createNetwork(address string, ids []node.ID, options *Options) (*DHT, error) {
+	var err error
+
+	cfg.conn, err = cfg.connectionFactory.Create(address)
+	if err != nil {
+		return nil, err
+	}
+
 	publicAddress, err := cfg.addressResolver.Resolve(cfg.conn)
 	if err != nil {
 		return nil, err
@@ -81,7 +135,7 @@ func (cfg *Configuration) CreateNetwork(address string, options *Options) (*DHT,
 		return nil, err
 	}
 
-	origin, err := node.NewOrigin(nil, originAddress)
+	origin, err := node.NewOrigin(ids, originAddress)
 	if err != nil {
 		return nil, err
 	}
@@ -91,6 +145,10 @@ func (cfg *Configuration) CreateNetwork(address string, options *Options) (*DHT,
 		return nil, err
 	}
 
+	if options.KeyHash == nil {
+		options.KeyHash = store.KeyHashOf(cfg.storeFactory)
+	}
+
 	cfg.network, err = NewDHT(
 		cfg.storeFactory.Create(),
 		origin,
@@ -104,8 +162,22 @@ func (cfg *Configuration) CreateNetwork(address string, options *Options) (*DHT,
 	return cfg.network, nil
 }
 
-// CloseNetwork stops networking
+// CloseNetwork stops networking or standby
 func (cfg *Configuration) CloseNetwork() error {
-	cfg.network.Disconnect()
-	return cfg.conn.Close()
+	if cfg.standby != nil {
+		cfg.standby.Stop()
+	} else {
+		cfg.network.Disconnect()
+	}
+	return cfg.closeConn()
+}
+
+// closeConn closes packet connection network was created on. Some transports close it themselves,
+// e.g. TCP one once listener is opened, so connection which is closed already is not an error.
+func (cfg *Configuration) closeConn() error {
+	err := cfg.conn.Close()
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
 }
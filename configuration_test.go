@@ -21,6 +21,7 @@ import (
 	"net"
 	"testing"
 
+	"github.com/insolar/network/connection"
 	"github.com/insolar/network/rpc"
 	"github.com/insolar/network/store"
 	"github.com/insolar/network/transport"
@@ -106,6 +107,42 @@ func TestConfiguration_CreateNetwork(t *testing.T) {
 	assert.Equal(t, cfg.network, network)
 }
 
+func TestConfiguration_CloseNetwork_TCP(t *testing.T) {
+	cfg := NewNetworkConfiguration(
+		&mockResolverOk{},
+		connection.NewConnectionFactory(),
+		transport.NewTCPTransportFactory(),
+		store.NewMemoryStoreFactory(),
+		rpc.NewRPCFactory(map[string]rpc.RemoteProcedure{}),
+	)
+
+	dht, err := cfg.CreateNetwork("127.0.0.1:8161", &Options{})
+	assert.NoError(t, err)
+	done := make(chan bool)
+	go func() {
+		dht.Listen()
+		done <- true
+	}()
+
+	// TCP transport closes packet connection itself
+	assert.NoError(t, cfg.CloseNetwork())
+	<-done
+}
+
+func TestConfiguration_CreateNetwork_KeyHash(t *testing.T) {
+	cfg := NewNetworkConfiguration(
+		&mockResolverOk{},
+		&mockConnFactoryOk{},
+		&mockTransportFactoryOk{},
+		store.NewHashedStoreFactory(store.NewMemoryStoreFactory(), store.SHA256),
+		rpc.NewRPCFactory(map[string]rpc.RemoteProcedure{}),
+	)
+
+	network, err := cfg.CreateNetwork("127.0.0.1:31337", &Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, store.SHA256([]byte("data")), network.options.KeyHash([]byte("data")))
+}
+
 func TestConfiguration_CreateNetwork_AlreadyCreated(t *testing.T) {
 	cfg := NewNetworkConfiguration(
 		&mockResolverOk{},
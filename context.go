@@ -20,7 +20,9 @@ import (
 	"context"
 	"errors"
 
+	"github.com/insolar/network/message"
 	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
 )
 
 // Context type is localized for future purposes
@@ -31,8 +33,12 @@ type Context context.Context
 type ctxKey string
 
 const (
-	ctxTableIndex = ctxKey("table_index")
-	defaultNodeID = 0
+	ctxTableIndex  = ctxKey("table_index")
+	ctxMaintenance = ctxKey("maintenance")
+	ctxRefresh     = ctxKey("refresh")
+	ctxBootstrap   = ctxKey("bootstrap")
+	ctxHeaders     = ctxKey("headers")
+	defaultNodeID  = 0
 )
 
 // ContextBuilder allows to lazy configure and build new Context
@@ -80,3 +86,37 @@ func (cb ContextBuilder) SetDefaultNode() ContextBuilder {
 	})
 	return cb
 }
+
+// SetHeaders sets headers attached to requests made with Context, procedures can read them with rpc.CallFromContext.
+// Headers must not be larger than message.MaxHeadersSize.
+func (cb ContextBuilder) SetHeaders(headers map[string]string) ContextBuilder {
+	cb.actions = append(cb.actions, func(ctx Context) (Context, error) {
+		if message.HeadersSize(headers) > message.MaxHeadersSize {
+			return nil, errors.New("headers are too large")
+		}
+		copied := make(map[string]string, len(headers))
+		for key, value := range headers {
+			copied[key] = value
+		}
+		return context.WithValue(ctx, ctxHeaders, copied), nil
+	})
+	return cb
+}
+
+// SetNamespace sets namespace values stored with Context are kept in, see store.WithNamespace.
+// Namespace must not be longer than store.MaxNamespaceLength.
+func (cb ContextBuilder) SetNamespace(namespace string) ContextBuilder {
+	cb.actions = append(cb.actions, func(ctx Context) (Context, error) {
+		if len(namespace) > store.MaxNamespaceLength {
+			return nil, errors.New("namespace is too long")
+		}
+		return store.WithNamespace(ctx, namespace), nil
+	})
+	return cb
+}
+
+// headersFromCtx returns headers attached to requests made with ctx
+func headersFromCtx(ctx Context) map[string]string {
+	headers, _ := ctx.Value(ctxHeaders).(map[string]string)
+	return headers
+}
@@ -0,0 +1,235 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+	"github.com/jbenet/go-base58"
+)
+
+// deletionLifetime is time holders accept deletion for, it limits replays of deletion
+const deletionLifetime = time.Minute
+
+// owner is publisher of value node holds
+type owner struct {
+	key        store.Key
+	publicKey  ed25519.PublicKey
+	ownership  *store.Ownership
+	publisher  node.ID
+	expiration time.Time
+}
+
+// owners keeps public keys of publishers of values node holds, only they can delete values
+type owners struct {
+	mutex  *sync.Mutex
+	owners map[string]*owner
+}
+
+func newOwners() *owners {
+	return &owners{
+		mutex:  &sync.Mutex{},
+		owners: make(map[string]*owner),
+	}
+}
+
+// claim records publisher proving ownership of value until expiration unless value is owned by other publisher already.
+// It returns false if value is owned by other publisher. Ownership must be verified by caller.
+func (o *owners) claim(ownership *store.Ownership, publisher node.ID, expiration, now time.Time) bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	key, publicKey := ownership.Key, ownership.PublicKey
+	current, ok := o.owners[key.String()]
+	if ok && now.Before(current.expiration) && !bytes.Equal(current.publicKey, publicKey) {
+		return false
+	}
+	if ok && bytes.Equal(current.publicKey, publicKey) && current.expiration.After(expiration) {
+		if ownership.Expiration.After(current.ownership.Expiration) {
+			current.ownership = ownership
+		}
+		return true
+	}
+	o.owners[key.String()] = &owner{key: key, publicKey: publicKey, ownership: ownership, publisher: publisher, expiration: expiration}
+	return true
+}
+
+// owner returns public key of publisher of value, nil if it is unknown
+func (o *owners) owner(key store.Key, now time.Time) ed25519.PublicKey {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	current, ok := o.owners[key.String()]
+	if !ok || !now.Before(current.expiration) {
+		return nil
+	}
+	return current.publicKey
+}
+
+// ownership returns ownership of value to pass to other holders, nil if it is unknown or its signature expired
+func (o *owners) ownership(key store.Key, now time.Time) *store.Ownership {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	current, ok := o.owners[key.String()]
+	if !ok || !now.Before(current.expiration) || !now.Before(current.ownership.Expiration) {
+		return nil
+	}
+	return current.ownership
+}
+
+// expire forgets publishers of expired values and returns them
+func (o *owners) expire(now time.Time) []*owner {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	var expired []*owner
+	for key, current := range o.owners {
+		if !now.Before(current.expiration) {
+			delete(o.owners, key)
+			expired = append(expired, current)
+		}
+	}
+	return expired
+}
+
+// forget forgets publisher of deleted value
+func (o *owners) forget(key store.Key) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	delete(o.owners, key.String())
+}
+
+// Delete retracts value published by node from the closest nodes, so it doesn't have to wait for expiration.
+// Holders only delete value if deletion is signed with the key it was published with.
+func (dht *DHT) Delete(ctx Context, key string) error {
+	keyBytes := base58.Decode(key)
+	if len(keyBytes) != store.KeySize {
+		return errors.New("invalid key")
+	}
+
+	deletion := store.NewDeletion(keyBytes, dht.options.Clock.Now().Add(deletionLifetime), dht.options.PrivateKey)
+	deleted := dht.deleteOwned(ctx, deletion)
+	dht.publicationsFor(ctx).forget(keyBytes)
+
+	_, closest, err := dht.iterate(ctx, routing.IterateFindNode, keyBytes, nil, nil)
+	if err != nil {
+		return err
+	}
+	if dht.sendDelete(ctx, deletion, closest) == 0 && !deleted {
+		return errors.New("value is not deleted")
+	}
+	return nil
+}
+
+// validOwnership checks that ownership is signed by publisher for key
+func validOwnership(ownership *store.Ownership, key store.Key) bool {
+	return bytes.Equal(ownership.Key, key) && ownership.Verify()
+}
+
+// deleteOwned deletes local value if deletion is valid and signed by its publisher
+func (dht *DHT) deleteOwned(ctx Context, deletion *store.Deletion) bool {
+	now := dht.options.Clock.Now()
+	if !now.Before(deletion.Expiration) || !deletion.Verify() {
+		return false
+	}
+	if !bytes.Equal(dht.owners.owner(deletion.Key, now), deletion.PublicKey) {
+		return false
+	}
+
+	err := dht.storeFor(ctx).Delete(ctx, deletion.Key)
+	if err != nil {
+		log.Println("Failed to delete data:", err.Error())
+		return false
+	}
+	dht.owners.forget(deletion.Key)
+	dht.expiriesFor(ctx).forget(deletion.Key)
+	return true
+}
+
+// sendDelete sends deletion to nodes and returns number of nodes which deleted value
+func (dht *DHT) sendDelete(ctx Context, deletion *store.Deletion, nodes []*node.Node) int {
+	ht := dht.htFromCtx(ctx)
+	results := make(chan bool, len(nodes))
+	wg := &sync.WaitGroup{}
+
+	for _, receiver := range nodes {
+		request := message.NewBuilder().Sender(ht.Origin).Receiver(receiver).Type(message.TypeDelete).Request(
+			&message.RequestDataDelete{
+				Deletion: deletion,
+			}).Build()
+
+		future, err := dht.sendRequest(ctx, request)
+		if err != nil {
+			log.Println("Failed to send deletion:", err.Error())
+			continue
+		}
+
+		wg.Add(1)
+		go func(future transport.Future) {
+			defer wg.Done()
+			result, err := dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+			if err != nil {
+				return
+			}
+			response, ok := result.Data.(*message.ResponseDataDelete)
+			results <- ok && response.Success
+		}(future)
+	}
+
+	wg.Wait()
+	close(results)
+
+	deleted := 0
+	for success := range results {
+		if success {
+			deleted++
+		}
+	}
+	return deleted
+}
+
+func (dht *DHT) processDelete(ctx Context, msg *message.Message, messageBuilder message.Builder) {
+	data := msg.Data.(*message.RequestDataDelete)
+	dht.addNode(ctx, routing.NewRouteNode(msg.Sender))
+	response := &message.ResponseDataDelete{}
+
+	switch {
+	case dht.IsReadOnly():
+		log.Println("Rejected deletion in read-only mode from", msg.Sender)
+	case data.Deletion == nil:
+		log.Println("Rejected invalid deletion from", msg.Sender)
+	default:
+		response.Success = dht.deleteOwned(ctx, data.Deletion)
+	}
+
+	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
+	if err != nil {
+		log.Println("Failed to send response:", err.Error())
+	}
+}
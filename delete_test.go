@@ -0,0 +1,155 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwners_Claim(t *testing.T) {
+	o := newOwners()
+	first, firstKey, _ := ed25519.GenerateKey(rand.Reader)
+	second, secondKey, _ := ed25519.GenerateKey(rand.Reader)
+	key := store.NewKey([]byte("data"))
+	now := time.Now()
+
+	assert.True(t, o.claim(store.NewOwnership(key, now.Add(time.Minute), firstKey), nil, now.Add(time.Minute), now))
+	assert.False(t, o.claim(store.NewOwnership(key, now.Add(time.Hour), secondKey), nil, now.Add(time.Hour), now))
+	// Publisher extends its claim, later ownership is passed on
+	extended := store.NewOwnership(key, now.Add(time.Hour), firstKey)
+	assert.True(t, o.claim(extended, nil, now.Add(time.Hour), now))
+	assert.Equal(t, first, o.owner(key, now.Add(time.Minute)))
+	assert.Equal(t, extended, o.ownership(key, now.Add(time.Minute)))
+
+	// Expired claim is forgotten
+	assert.Nil(t, o.owner(key, now.Add(time.Hour)))
+	assert.Nil(t, o.ownership(key, now.Add(time.Hour)))
+	assert.True(t, o.claim(store.NewOwnership(key, now.Add(2*time.Hour), secondKey), nil, now.Add(2*time.Hour), now.Add(time.Hour)))
+	assert.Equal(t, second, o.owner(key, now.Add(time.Hour)))
+}
+
+func TestDHT_Delete(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+
+	assert.EqualError(t, dht2.Delete(getDefaultCtx(dht2), "key"), "invalid key")
+
+	key, err := dht2.Store(getDefaultCtx(dht2), []byte("foo"))
+	assert.NoError(t, err)
+	_, found, _ := st1.Retrieve(context.Background(), store.NewKey([]byte("foo")))
+	assert.True(t, found)
+	// Holder keeps publisher's signed ownership to pass it on with replicas
+	ownership := dht1.owners.ownership(store.NewKey([]byte("foo")), time.Now())
+	assert.NotNil(t, ownership)
+	assert.Equal(t, dht2.options.PrivateKey.Public(), ownership.PublicKey)
+
+	// Only publisher can delete value
+	assert.EqualError(t, dht1.Delete(getDefaultCtx(dht1), key), "value is not deleted")
+	_, found, _ = st1.Retrieve(context.Background(), store.NewKey([]byte("foo")))
+	assert.True(t, found)
+
+	assert.NoError(t, dht2.Delete(getDefaultCtx(dht2), key))
+	for _, st := range []store.Store{st1, st2} {
+		_, found, _ = st.Retrieve(context.Background(), store.NewKey([]byte("foo")))
+		assert.False(t, found)
+	}
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
+
+func TestProcessStore_Ownership(t *testing.T) {
+	st, s, tp, r, err := dhtParams(nil, "0.0.0.0:3000")
+	assert.NoError(t, err)
+
+	dht, _ := NewDHT(st, s, tp, r, &Options{})
+	ctx := getDefaultCtx(dht)
+
+	addr, _ := node.NewAddress("127.0.0.1:3001")
+	sender := &node.Node{ID: getIDWithValues(1), Address: addr}
+	receiver := dht.tables[0].Origin
+	data := []byte("foo")
+	key := store.NewKey(data)
+	publisher, publisherKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	expiration := time.Now().Add(time.Hour)
+
+	process := func(request *message.RequestDataStore) bool {
+		request.Data, request.Token = data, dht.tokens.issue(addr.IP)
+		msg := message.NewBuilder().Sender(sender).Receiver(receiver).Type(message.TypeStore).Request(request).Build()
+		msg.SetRemoteAddress("127.0.0.1:3001")
+		dht.processStore(ctx, msg, message.NewBuilder())
+		_, exists, _ := st.Retrieve(ctx, key)
+		return exists
+	}
+
+	// Bare public key is not trusted
+	assert.True(t, process(&message.RequestDataStore{PublicKey: otherKey.Public().(ed25519.PublicKey)}))
+	assert.Nil(t, dht.owners.owner(key, time.Now()))
+	assert.NoError(t, st.Delete(ctx, key))
+
+	// Forged ownership is rejected
+	forged := store.NewOwnership(key, expiration, publisherKey)
+	forged.Expiration = expiration.Add(time.Hour)
+	assert.False(t, process(&message.RequestDataStore{Ownership: forged}))
+	other := store.NewOwnership(store.NewKey([]byte("bar")), expiration, publisherKey)
+	assert.False(t, process(&message.RequestDataStore{Ownership: other}))
+	assert.Nil(t, dht.owners.owner(key, time.Now()))
+
+	ownership := store.NewOwnership(key, expiration, publisherKey)
+	assert.True(t, process(&message.RequestDataStore{Ownership: ownership}))
+	assert.Equal(t, publisher, dht.owners.owner(key, time.Now()))
+	assert.Equal(t, ownership, dht.owners.ownership(key, time.Now()))
+
+	// Store of value owned by other publisher is rejected
+	assert.NoError(t, st.Delete(ctx, key))
+	assert.False(t, process(&message.RequestDataStore{Ownership: store.NewOwnership(key, expiration, otherKey)}))
+	assert.Equal(t, publisher, dht.owners.owner(key, time.Now()))
+}
@@ -17,15 +17,21 @@
 package network
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"log"
 	"math"
+	"math/big"
+	"net"
 	"sort"
 	"sync"
 	"time"
 
+	"github.com/insolar/network/clock"
 	"github.com/insolar/network/message"
 	"github.com/insolar/network/node"
 	"github.com/insolar/network/routing"
@@ -44,8 +50,34 @@ type DHT struct {
 	origin *node.Origin
 
 	transport transport.Transport
-	store     store.Store
 	rpc       rpc.RPC
+
+	hints     *livenessHints
+	tokens    *writeTokens
+	budget    *maintenanceBudget
+	refreshes *refreshHistory
+	versions  *peerVersions
+	watches   *watches
+	services  *services
+	providers *providers
+	mutables  *mutables
+	owners    *owners
+	lifetimes *lifetimes
+
+	ready     chan bool
+	readyOnce *sync.Once
+
+	unknownReceivers *unknownReceivers
+	identities       []IdentityOptions
+	incoming         *incomingRequests
+	latencies        []*latencyHistograms
+	churn            []*churnTracker
+	partitions       []*partitionTracker
+	publications     []*publications
+	expiries         []*expiries
+	lookups          *lookupLimiter
+	watchdog         *watchdog
+	readOnly         int32
 }
 
 // Options contains configuration options for the local node
@@ -55,27 +87,342 @@ type Options struct {
 	// initialized via network.NewNode()
 	BootstrapNodes []*node.Node
 
+	// The number of bootstrap nodes contacted at once during bootstrap.
+	// Next wave is contacted only if routing table is not healthy yet
+	BootstrapWaveSize int
+
+	// The maximum number of bootstrap nodes waiting for ping response at once
+	BootstrapConcurrency int
+
+	// The number of known nodes at which routing table is considered healthy
+	// and no more bootstrap nodes are contacted
+	BootstrapHealthyNodes int
+
+	// KeyHash derives keys of stored values, all nodes of network must use the same one.
+	// Configuration takes it from store factory, see store.NewHashedStoreFactory. store.NewKey if not set
+	KeyHash store.KeyHash
+
 	// The time after which a key/value pair expires;
 	// this is a time-to-live (TTL) from the original publication date
 	ExpirationTime time.Duration
 
+	// ExpirationInterval is the time between scans for expired values, checked every second. One second if not set
+	ExpirationInterval time.Duration
+
+	// ExpirationBatch limits number of values expired by one scan of stores implementing store.BatchExpirer,
+	// the rest of them are expired by the next scans a second apart. All expired values are purged at once if not set
+	ExpirationBatch int
+
+	// Validator checks values published by node, received in Store requests and returned by lookups,
+	// invalid values are rejected. All values are accepted if not set
+	Validator store.Validator
+
+	// ConflictResolver decides which of conflicting replicas of value is kept when node gets value
+	// from other node and which one is returned when lookup finds several of them.
+	// store.LastWriteWins if not set
+	ConflictResolver store.ConflictResolver
+
+	// The maximum TTL of values stored with StoreWithTTL, longer TTLs requested by publishers
+	// are shortened to it. ExpirationTime if not set
+	MaxTTL time.Duration
+
+	// Identities override options for some of node IDs, keyed by ID string
+	Identities map[string]*IdentityOptions
+
 	// Seconds after which an otherwise unaccessed bucket must be refreshed
 	RefreshTime time.Duration
 
+	// AdaptiveRefresh makes buckets refreshed as often as their contacts are observed to change,
+	// between MinRefreshTime and MaxRefreshTime, instead of every RefreshTime. Buckets which
+	// were not refreshed yet use RefreshTime
+	AdaptiveRefresh bool
+
+	// Bounds of refresh interval of AdaptiveRefresh. RefreshTime / 4 and RefreshTime * 4 if not set
+	MinRefreshTime time.Duration
+	MaxRefreshTime time.Duration
+
 	// The interval between Kademlia replication events, when a node is
 	// required to publish its entire database
 	ReplicateTime time.Duration
 
+	// CacheTTL is lifetime of copies of values found by Get, which are cached at the closest node
+	// which answered lookup without value. Cached copies are not replicated. Caching is disabled if not set
+	CacheTTL time.Duration
+
+	// ReplicationFactor is the number of closest nodes values are stored at when they are
+	// published and replicated. Higher factor makes values survive more churn at cost of bandwidth
+	// and storage. MaxContactsInBucket if not set
+	ReplicationFactor int
+
 	// The time after which the original publisher must
-	// republish a key/value pair. Currently not implemented.
+	// republish a key/value pair it still holds
 	RepublishTime time.Duration
 
 	// The maximum time to wait for a response from a node before discarding
 	// it from the bucket
 	PingTimeout time.Duration
 
+	// Clock drives timers of DHT, virtual clock.Virtual lets simulations of refresh
+	// and replication cycles run faster than real time. System time is used by default
+	Clock clock.Clock
+
+	// Locality of this node advertised to other nodes
+	Locality node.Locality
+
+	// PreferSameRegion makes lookups contact nodes of the same Locality region first
+	// among nodes which distances to target tie, reducing cross-region traffic
+	PreferSameRegion bool
+
+	// Address of relay node forwarding requests to this node if it is not reachable
+	// directly, e.g. behind symmetric NAT. Relay address is advertised to other nodes
+	Relay *node.Address
+
+	// The maximum number of nodes behind NAT this node relays requests to, zero disables relaying
+	RelayCircuits int
+
+	// HolePunching makes node try to connect directly to nodes which advertise relay.
+	// If direct connection fails, relay coordinates hole punching before messages are relayed
+	HolePunching bool
+
+	// The policy consulted when a bucket is full. Node it allows to evict is replaced
+	// by new node right away, otherwise the least recently seen node is pinged and
+	// replaced only if it does not respond
+	EvictionPolicy routing.EvictionPolicy
+
+	// The mapping of node IDs to buckets of routing tables. Standard Kademlia
+	// differing-bit mapping is used if not set
+	BucketMapper routing.BucketMapper
+
 	// The maximum time to wait for a response to any message
 	MessageTimeout time.Duration
+
+	// The maximum time to read a single message from connection once it started
+	// to arrive. Connection is dropped if peer stalls longer. Unlimited if not set
+	ReadTimeout time.Duration
+
+	// The maximum time to write a single message to connection. Unlimited if not set
+	WriteTimeout time.Duration
+
+	// The interval between keepalive probes sent to peers which recently got messages.
+	// Peers which can't be reached are removed from routing tables. Disabled if not set
+	KeepaliveInterval time.Duration
+
+	// The maximum number of times request is sent when it fails to send or does not get
+	// response in MessageTimeout divided by number of attempts. Requests are not retried if not set
+	RetryAttempts int
+
+	// The delay before the first retry of request, it is doubled for every next retry
+	RetryDelay time.Duration
+
+	// The fraction of retry delay which is randomized, from 0 to 1
+	RetryJitter float64
+
+	// The maximum number of network lookups running at once, excess lookups wait
+	// for running ones to finish. Unlimited if not set
+	MaxConcurrentLookups int
+
+	// FailExcessLookups makes lookups over MaxConcurrentLookups fail with
+	// ErrTooManyLookups instead of waiting
+	FailExcessLookups bool
+
+	// The number of goroutines, bytes of allocated heap, sent requests waiting for response
+	// and incoming requests being processed above which watchdog reports overload.
+	// Resources without ceiling are not watched
+	WatchdogGoroutines       int
+	WatchdogHeapBytes        int
+	WatchdogPendingRequests  int
+	WatchdogIncomingRequests int
+
+	// The interval between watchdog checks of resource usage. 10 seconds if not set
+	WatchdogInterval time.Duration
+
+	// OnWatchdog is called when usage of watched resource crosses its ceiling in either direction
+	OnWatchdog func(event WatchdogEvent)
+
+	// ShedLoad makes lookups fail with ErrOverloaded while any watched resource is over its ceiling,
+	// so long-running node sheds load before it runs out of memory
+	ShedLoad bool
+
+	// Directory to which snapshots of internal stats are written periodically,
+	// see ReadSnapshots. Snapshots are not written if not set
+	SnapshotDirectory string
+
+	// The interval between snapshots
+	SnapshotInterval time.Duration
+
+	// The number of snapshot files kept, the oldest one is overwritten by the next snapshot
+	SnapshotFiles int
+
+	// Address of Standby to which changes of routing tables and stores are streamed,
+	// so it can take over identity of this node on failover. Nothing is streamed if not set
+	StandbyAddress string
+
+	// The interval between state deltas sent to standby
+	StandbySyncInterval time.Duration
+
+	// The maximum time Disconnect waits for sent requests to be resolved
+	// and for responses to processed requests to be sent
+	DrainTimeout time.Duration
+
+	// The maximum time RunUntilSignal waits for handoff and close of network
+	ShutdownTimeout time.Duration
+
+	// Whether RunUntilSignal stores values held by the node on the closest nodes before closing network
+	HandoffOnShutdown bool
+
+	// The time during which a failed node is advertised to other nodes
+	// in liveness hints
+	FailedNodeHintTime time.Duration
+
+	// The key used to sign storage receipts and challenge responses.
+	// Random key is generated if not set
+	PrivateKey ed25519.PrivateKey
+
+	// The interval between rotations of the secret used to issue write tokens.
+	// Token is accepted for Store until the secret is rotated twice
+	WriteTokenTime time.Duration
+
+	// The maximum number of messages per second sent by maintenance tasks
+	// such as bucket refresh and replication. Unlimited if not set
+	MaintenanceMessages int
+
+	// The maximum number of bytes per second sent by maintenance tasks.
+	// Unlimited if not set
+	MaintenanceBytes int
+
+	// The maximum number of bytes per second sent by transport in total.
+	// Unlimited if not set
+	RateLimit int
+
+	// The maximum number of bytes per second sent by transport to a single node.
+	// Unlimited if not set
+	PeerRateLimit int
+
+	// The maximum number of sent requests waiting for response. Once reached, new requests
+	// wait up to PendingRequestsWait and fail with transport.ErrTooManyRequests. Unlimited if not set
+	MaxPendingRequests int
+
+	// The maximum time request waits for a slot when MaxPendingRequests is reached.
+	// Requests fail at once if not set
+	PendingRequestsWait time.Duration
+
+	// The time after which transport times out request still waiting for response, so requests
+	// nobody waits for anymore free their slots. Twice the longest request timeout if not set
+	PendingRequestsExpiry time.Duration
+
+	// The maximum number of messages being sent to one peer at once. Further messages to the peer
+	// fail with transport.ErrQueueFull and are counted in TransportStats. Unlimited if not set
+	SendQueueSize int
+
+	// OnSendQueueSaturated is called with address of peer which send queue stays full
+	OnSendQueueSaturated func(address string)
+
+	// OnConnectionFault is called with every connection error of transport: unreachable peers,
+	// failed handshakes, read and write errors
+	OnConnectionFault func(fault transport.Fault)
+
+	// Messages larger than this number of bytes are compressed if receiver
+	// enabled compression too. Compression is disabled if not set
+	CompressionThreshold int
+
+	// The maximum time small messages are held to be sent to the same peer together with other
+	// messages sent meanwhile, reducing number of packets. Messages are sent at once if not set
+	CoalesceDelay time.Duration
+
+	// Messages smaller than this number of bytes are coalesced, batch of coalesced messages
+	// does not exceed it either. 1200 bytes if not set, so batch fits in a single datagram
+	CoalesceSize int
+
+	// The maximum size of value in bytes. Larger values are rejected by Store with store.ErrTooLarge
+	// and are not accepted from other nodes. Unlimited if not set
+	MaxValueSize int
+
+	// Values of at least this number of bytes are compressed in Store requests if it makes them
+	// smaller. Receivers must run release which decompresses them. Disabled if not set
+	ValueCompression int
+
+	// Name of codec other nodes are asked to send messages in, codec must be registered
+	// with message.RegisterCodec. Messages are sent in gob if not set
+	Codec string
+
+	// OnListen is called with the address transport is actually bound to
+	// when DHT starts listening. Useful when listening on ephemeral port
+	OnListen func(addr net.Addr)
+
+	// ProxyLookups allows other nodes to ask this node to perform
+	// lookups on their behalf, see DHT.ProxyGet and DHT.ProxyFindNode
+	ProxyLookups bool
+
+	// The lease of watches, see DHT.Watch. Watches registered on this node
+	// are granted at most this lease, own watches are renewed every half of it
+	WatchLease time.Duration
+
+	// The maximum number of watches other nodes can register on this node.
+	// Unlimited if not set
+	MaxWatches int
+
+	// The maximum number of service records other nodes can register on this node.
+	// Unlimited if not set
+	MaxServiceRecords int
+
+	// The time provider records are kept for, see DHT.Provide. Providers announce themselves
+	// for this time and holders keep records of others at most for it. 24 hours if not set
+	ProviderTTL time.Duration
+
+	// The maximum number of provider records other nodes can announce on this node.
+	// Unlimited if not set
+	MaxProviderRecords int
+
+	// The maximum number of mutable records other nodes can publish on this node.
+	// Unlimited if not set
+	MaxMutableRecords int
+
+	// UnknownReceiver defines how messages without receiver ID or addressed to unknown ID
+	// are handled, see UnknownReceiverStats. They are handled with the first ID if not set
+	UnknownReceiver UnknownReceiverPolicy
+
+	// OnRefresh is called with outcome of every bucket refresh round,
+	// recent rounds are also available with DHT.RefreshRounds
+	OnRefresh func(round RefreshRound)
+
+	// OnExpiringSoon is called once with key of value published by this node which expires
+	// within ExpiryWarning and was not stored on other nodes again since
+	OnExpiringSoon func(key store.Key, expiration time.Time)
+
+	// How long before expiration of published value OnExpiringSoon is called. One hour if not set
+	ExpiryWarning time.Duration
+
+	// NotifyExpiry makes node send signed proof of expiry to publishers of values it expired,
+	// so they learn replication degraded and can publish values again early
+	NotifyExpiry bool
+
+	// OnReplicaExpired is called when other node proves that value published by this node expired on it.
+	// Value is published again at once if node still holds it
+	OnReplicaExpired func(key store.Key, holder node.ID)
+
+	// OnStored is called with every value stored on this node, either published by it
+	// or received from other nodes. It is called synchronously, so it must not block
+	OnStored func(key store.Key, data []byte)
+
+	// OnExpired is called with key of value stored on this node since it started
+	// once store collects it after expiration
+	OnExpired func(key store.Key)
+
+	// OnReplicated is called after value held by this node is replicated with IDs of nodes
+	// which confirmed its replica
+	OnReplicated func(key store.Key, holders []node.ID)
+
+	// OnPartitionChange is called when partition state of routing table changes,
+	// see DHT.PartitionStatus
+	OnPartitionChange func(status PartitionStatus)
+
+	// The fraction of recent lookups which must fail for lookups to be considered
+	// a signal of partition. 0.75 if not set
+	PartitionFailureRate float64
+
+	// The fraction of peak contacts routing table must lose to be considered
+	// a signal of partition. 0.75 if not set
+	PartitionShrinkage float64
 }
 
 // NewDHT initializes a new DHT node.
@@ -91,25 +438,103 @@ func NewDHT(store store.Store, origin *node.Origin, transport transport.Transpor
 		rpc:       rpc,
 		transport: transport,
 		tables:    tables,
-		store:     store,
+		hints:     newLivenessHints(),
+		refreshes: newRefreshHistory(),
+		versions:  newPeerVersions(),
+		watches:   newWatches(),
+		services:  newServices(),
+		providers: newProviders(),
+		mutables:  newMutables(),
+		owners:    newOwners(),
+		lifetimes: newLifetimes(),
+		ready:     make(chan bool),
+		readyOnce: &sync.Once{},
+
+		unknownReceivers: newUnknownReceivers(),
+		incoming:         newIncomingRequests(),
+		lookups:          newLookupLimiter(options.MaxConcurrentLookups, options.FailExcessLookups),
+		watchdog: newWatchdog(map[WatchdogResource]int{
+			ResourceGoroutines:       options.WatchdogGoroutines,
+			ResourceHeap:             options.WatchdogHeapBytes,
+			ResourcePendingRequests:  options.WatchdogPendingRequests,
+			ResourceIncomingRequests: options.WatchdogIncomingRequests,
+		}, options.ShedLoad),
+	}
+
+	for _, ht := range tables {
+		ht.Origin.Locality = options.Locality
+		ht.Origin.Relay = options.Relay
+		ht.SetBucketMapper(options.BucketMapper)
+		dht.latencies = append(dht.latencies, newLatencyHistograms(ht.Origin.ID))
+		dht.publications = append(dht.publications, newPublications())
+		dht.expiries = append(dht.expiries, newExpiries())
+	}
+
+	if options.Clock == nil {
+		options.Clock = clock.New()
+	}
+	dht.budget = newMaintenanceBudget(options.MaintenanceMessages, options.MaintenanceBytes, maintenanceSlice, options.Clock)
+
+	for _, ht := range tables {
+		for i := 0; i < routing.KeyBitSize; i++ {
+			ht.SetRefreshTimeForBucket(i, options.Clock.Now())
+		}
+		dht.churn = append(dht.churn, newChurnTracker(options.Clock.Now()))
+		dht.partitions = append(dht.partitions, newPartitionTracker(options.Clock.Now()))
 	}
 
 	if options.ExpirationTime == 0 {
 		options.ExpirationTime = time.Second * 86410
 	}
 
+	if options.ExpirationInterval == 0 {
+		options.ExpirationInterval = time.Second
+	}
+
+	if options.MaxTTL == 0 {
+		options.MaxTTL = options.ExpirationTime
+	}
+
+	if options.ProviderTTL == 0 {
+		options.ProviderTTL = time.Hour * 24
+	}
+
 	if options.RefreshTime == 0 {
 		options.RefreshTime = time.Second * 3600
 	}
 
+	if options.MinRefreshTime == 0 {
+		options.MinRefreshTime = options.RefreshTime / 4
+	}
+
+	if options.MaxRefreshTime == 0 {
+		options.MaxRefreshTime = options.RefreshTime * 4
+	}
+
 	if options.ReplicateTime == 0 {
 		options.ReplicateTime = time.Second * 3600
 	}
 
+	if options.ReplicationFactor == 0 {
+		options.ReplicationFactor = routing.MaxContactsInBucket
+	}
+
 	if options.RepublishTime == 0 {
 		options.RepublishTime = time.Second * 86400
 	}
 
+	if options.ExpiryWarning == 0 {
+		options.ExpiryWarning = time.Second * 3600
+	}
+
+	if options.PartitionFailureRate == 0 {
+		options.PartitionFailureRate = 0.75
+	}
+
+	if options.PartitionShrinkage == 0 {
+		options.PartitionShrinkage = 0.75
+	}
+
 	if options.PingTimeout == 0 {
 		options.PingTimeout = time.Second * 1
 	}
@@ -118,6 +543,88 @@ func NewDHT(store store.Store, origin *node.Origin, transport transport.Transpor
 		options.MessageTimeout = time.Second * 10
 	}
 
+	if options.FailedNodeHintTime == 0 {
+		options.FailedNodeHintTime = time.Second * 600
+	}
+
+	if options.WriteTokenTime == 0 {
+		options.WriteTokenTime = time.Second * 300
+	}
+
+	if options.WatchLease == 0 {
+		options.WatchLease = time.Second * 600
+	}
+
+	if options.DrainTimeout == 0 {
+		options.DrainTimeout = time.Second * 5
+	}
+
+	if options.ShutdownTimeout == 0 {
+		options.ShutdownTimeout = time.Second * 30
+	}
+
+	if options.RetryDelay == 0 {
+		options.RetryDelay = time.Millisecond * 100
+	}
+
+	if options.SnapshotInterval == 0 {
+		options.SnapshotInterval = time.Second * 60
+	}
+
+	if options.WatchdogInterval == 0 {
+		options.WatchdogInterval = time.Second * 10
+	}
+
+	if options.SnapshotFiles == 0 {
+		options.SnapshotFiles = 10
+	}
+
+	if options.StandbySyncInterval == 0 {
+		options.StandbySyncInterval = time.Second
+	}
+
+	if options.CoalesceSize == 0 {
+		options.CoalesceSize = 1200
+	}
+
+	if options.BootstrapWaveSize == 0 {
+		options.BootstrapWaveSize = 16
+	}
+
+	if options.BootstrapConcurrency == 0 {
+		options.BootstrapConcurrency = 8
+	}
+
+	if options.BootstrapHealthyNodes == 0 {
+		options.BootstrapHealthyNodes = routing.MaxContactsInBucket
+	}
+
+	dht.identities, err = newIdentities(origin, store, options)
+	if err != nil {
+		return nil, err
+	}
+
+	dht.tokens, err = newWriteTokens(options.WriteTokenTime)
+	if err != nil {
+		return nil, err
+	}
+
+	err = dht.configureTransport()
+	if err != nil {
+		return nil, err
+	}
+
+	if options.PrivateKey == nil {
+		_, options.PrivateKey, err = ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.ProxyLookups {
+		dht.registerProxyLookups()
+	}
+
 	return dht, nil
 }
 
@@ -129,6 +636,7 @@ func newTables(origin *node.Origin) ([]*routing.HashTable, error) {
 		if err != nil {
 			return nil, err
 		}
+		ht.Origin.Addresses = origin.Addresses
 
 		tables[i] = ht
 	}
@@ -139,7 +647,7 @@ func newTables(origin *node.Origin) ([]*routing.HashTable, error) {
 func (dht *DHT) getExpirationTime(ctx context.Context, key []byte) time.Time {
 	ht := dht.htFromCtx(ctx)
 
-	bucket := routing.GetBucketIndexFromDifferingBit(key, ht.Origin.ID)
+	bucket := ht.BucketIndex(key)
 	var total int
 	for i := 0; i < bucket; i++ {
 		total += ht.GetTotalNodesInBucket(i)
@@ -152,80 +660,232 @@ func (dht *DHT) getExpirationTime(ctx context.Context, key []byte) time.Time {
 	}
 
 	if score > routing.MaxContactsInBucket {
-		return time.Now().Add(dht.options.ExpirationTime)
+		return dht.options.Clock.Now().Add(dht.options.ExpirationTime)
 	}
 
 	day := dht.options.ExpirationTime
 	seconds := day.Nanoseconds() * int64(math.Exp(float64(routing.MaxContactsInBucket/score)))
 	dur := time.Second * time.Duration(seconds)
-	return time.Now().Add(dur)
+	return dht.options.Clock.Now().Add(dur)
+}
+
+// tooLarge tells if data exceeds MaxValueSize
+func (dht *DHT) tooLarge(data []byte) bool {
+	return dht.options.MaxValueSize > 0 && len(data) > dht.options.MaxValueSize
+}
+
+// keyHash returns KeyHash option, store.NewKey if not set
+func (dht *DHT) keyHash() store.KeyHash {
+	if dht.options.KeyHash == nil {
+		return store.NewKey
+	}
+	return dht.options.KeyHash
+}
+
+// keyOf returns key of data in namespace of ctx derived with KeyHash option
+func (dht *DHT) keyOf(ctx Context, data []byte) store.Key {
+	return store.NamespacedKey(dht.keyHash(), store.NamespaceOf(ctx), data)
+}
+
+// keyNamespace returns namespace key is derived from data in, namespace of ctx or the one reported by its holder.
+// Returns false if key is not derived from data in any of them.
+func (dht *DHT) keyNamespace(ctx Context, key store.Key, data []byte, namespace string) (string, bool) {
+	if bytes.Equal(dht.keyOf(ctx, data), key) {
+		return store.NamespaceOf(ctx), true
+	}
+	if namespace != "" && bytes.Equal(dht.keyOf(store.WithNamespace(ctx, namespace), data), key) {
+		return namespace, true
+	}
+	return "", false
+}
+
+// validate checks value of key with Validator option
+func (dht *DHT) validate(ctx Context, key store.Key, data []byte) error {
+	if dht.options.Validator == nil {
+		return nil
+	}
+	return dht.options.Validator.Validate(ctx, key, data)
+}
+
+// resolve checks if candidate replica of value wins over current one
+func (dht *DHT) resolve(key store.Key, current, candidate store.Entry) bool {
+	if dht.options.ConflictResolver == nil {
+		return store.LastWriteWins(key, current, candidate)
+	}
+	return dht.options.ConflictResolver(key, current, candidate)
+}
+
+// withNamespaceOf returns ctx with namespace key was stored under if store keeps track of namespaces
+func (dht *DHT) withNamespaceOf(ctx Context, key store.Key) Context {
+	if namespaced, ok := dht.storeFor(ctx).(store.Namespaced); ok {
+		if namespace, ok := namespaced.Namespace(key); ok {
+			return store.WithNamespace(ctx, namespace)
+		}
+	}
+	return ctx
 }
 
 // Store stores data on the network. This will trigger an iterateStore loop.
 // The base58 encoded identifier will be returned if the store is successful.
 func (dht *DHT) Store(ctx Context, data []byte) (id string, err error) {
-	key := store.NewKey(data)
+	id, _, err = dht.StoreWithReceipts(ctx, data)
+	return id, err
+}
+
+// StoreWithReceipts stores data on the network the same way Store does.
+// It also returns signed receipts of the nodes which accepted the value,
+// publisher can retain them as a proof of placement.
+func (dht *DHT) StoreWithReceipts(ctx Context, data []byte) (id string, receipts []*store.Receipt, err error) {
+	return dht.storeWithTTL(ctx, data, 0)
+}
+
+// storeWithTTL stores data on the network, value expires after ttl or at default expiration time if ttl is not set
+func (dht *DHT) storeWithTTL(ctx Context, data []byte, ttl time.Duration) (id string, receipts []*store.Receipt, err error) {
+	if dht.tooLarge(data) {
+		return "", nil, store.ErrTooLarge
+	}
+	key := dht.keyOf(ctx, data)
+	if err := dht.validate(ctx, key, data); err != nil {
+		return "", nil, err
+	}
 	expiration := dht.getExpirationTime(ctx, key)
-	replication := time.Now().Add(dht.options.ReplicateTime)
-	err = dht.store.Store(key, data, replication, expiration, true)
-	if err != nil {
-		return "", err
+	if ttl > 0 {
+		ttl = dht.limitTTL(ttl)
+		expiration = dht.options.Clock.Now().Add(ttl)
+		dht.lifetimes.set(key, expiration, dht.options.Clock.Now())
+	}
+	replication := dht.options.Clock.Now().Add(dht.options.ReplicateTime)
+	version := store.NewVersion(dht.options.Clock.Now(), dht.htFromCtx(ctx).Origin.ID)
+	_, err = dht.storeVersion(ctx, key, data, replication, expiration, true, version)
+	if err == store.ErrFull {
+		// Value is still stored on other nodes
+		log.Println("Failed to store data locally:", err.Error())
+	} else if err != nil {
+		return "", nil, err
 	}
-	_, _, err = dht.iterate(ctx, routing.IterateStore, key, data)
+	ownership := store.NewOwnership(key, expiration, dht.options.PrivateKey)
+	dht.owners.claim(ownership, version.Publisher, expiration, dht.options.Clock.Now())
+	tokens := make(map[string][]byte)
+	_, closest, err := dht.iterate(ctx, routing.IterateStore, key, nil, tokens)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
+	receipts = dht.storeOnNodes(ctx, key, data, version, ownership, ttl, closest, tokens)
+	dht.publicationsFor(ctx).published(key, expiration, len(receipts) > 0, dht.options.Clock.Now())
 	str := base58.Encode(key)
-	return str, nil
+	return str, receipts, nil
+}
+
+// Challenge asks receipt holder whether it still holds the value.
+// Holder's answer must be signed with the same key as the receipt.
+func (dht *DHT) Challenge(ctx Context, receipt *store.Receipt) (holds bool, err error) {
+	if !receipt.Verify() {
+		return false, errors.New("invalid receipt")
+	}
+
+	holder, exists, err := dht.FindNode(ctx, receipt.Holder.String())
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, errors.New("holder not found")
+	}
+
+	nonce := make([]byte, 20)
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return false, err
+	}
+
+	ht := dht.htFromCtx(ctx)
+	request := message.NewBuilder().Sender(ht.Origin).Receiver(holder).Type(message.TypeChallenge).Request(
+		&message.RequestDataChallenge{
+			Key:   receipt.Key,
+			Nonce: nonce,
+		}).Build()
+
+	future, err := dht.transport.SendRequest(request)
+	if err != nil {
+		return false, err
+	}
+
+	rsp, err := dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+	if err != nil {
+		return false, err
+	}
+	response, ok := rsp.Data.(*message.ResponseDataChallenge)
+	if !ok || !receipt.VerifyChallenge(nonce, response.Holds, response.Signature) {
+		return false, errors.New("invalid challenge response")
+	}
+	return response.Holds, nil
 }
 
 // Get retrieves data from the transport using key. Key is the base58 encoded
 // identifier of the data.
 func (dht *DHT) Get(ctx Context, key string) ([]byte, bool, error) {
+	value, exists, _, err := dht.GetWithExclusion(ctx, key, nil)
+	return value, exists, err
+}
+
+// GetWithExclusion retrieves data the same way Get does, but never contacts nodes
+// from exclude list. It also returns the route set of the lookup, so caller can
+// retry with different peers.
+func (dht *DHT) GetWithExclusion(ctx Context, key string, exclude []node.ID) ([]byte, bool, []*node.Node, error) {
 	keyBytes := base58.Decode(key)
-	if len(keyBytes) != routing.MaxContactsInBucket {
-		return nil, false, errors.New("invalid key")
+	if len(keyBytes) != store.KeySize {
+		return nil, false, nil, errors.New("invalid key")
 	}
 
-	value, exists := dht.store.Retrieve(keyBytes)
+	var routeSet []*node.Node
+	value, exists := dht.retrieve(ctx, keyBytes)
 	if !exists {
 		var err error
-		value, _, err = dht.iterate(ctx, routing.IterateFindValue, keyBytes, nil)
+		value, routeSet, err = dht.iterate(ctx, routing.IterateFindValue, keyBytes, excludedNodes(exclude), nil)
 		if err != nil {
-			return nil, false, err
+			return nil, false, nil, err
 		}
 		if value != nil {
 			exists = true
 		}
 	}
 
-	return value, exists, nil
+	return value, exists, routeSet, nil
 }
 
 // FindNode returns target node's real network address
 func (dht *DHT) FindNode(ctx Context, key string) (*node.Node, bool, error) {
+	targetNode, exists, _, err := dht.FindNodeWithExclusion(ctx, key, nil)
+	return targetNode, exists, err
+}
+
+// FindNodeWithExclusion finds target node the same way FindNode does, but never
+// contacts nodes from exclude list. It also returns the route set of the lookup.
+func (dht *DHT) FindNodeWithExclusion(ctx Context, key string, exclude []node.ID) (*node.Node, bool, []*node.Node, error) {
 	keyBytes := base58.Decode(key)
-	if len(keyBytes) != routing.MaxContactsInBucket {
-		return nil, false, errors.New("invalid key")
+	if len(keyBytes) != store.KeySize {
+		return nil, false, nil, errors.New("invalid key")
 	}
 	ht := dht.htFromCtx(ctx)
 
 	if ht.Origin.ID.Equal(keyBytes) {
-		return ht.Origin, true, nil
+		return ht.Origin, true, nil, nil
 	}
 
 	var targetNode *node.Node
 	var exists = false
-	routeSet := ht.GetClosestContacts(1, keyBytes, nil)
+	ignored := excludedNodes(exclude)
+	routeSet := ht.GetClosestContacts(1, keyBytes, ignored)
+	closest := routeSet.Nodes()
 
 	if routeSet.Len() > 0 && routeSet.FirstNode().ID.Equal(keyBytes) {
 		targetNode = routeSet.FirstNode()
 		exists = true
 	} else {
 		fmt.Println("Node not found in routing table. Iterating through network...")
-		_, closest, err := dht.iterate(ctx, routing.IterateFindNode, keyBytes, nil)
+		var err error
+		_, closest, err = dht.iterate(ctx, routing.IterateFindNode, keyBytes, ignored, nil)
 		if err != nil {
-			return nil, false, err
+			return nil, false, nil, err
 		}
 		if len(closest) > 0 && closest[0].ID.Equal(keyBytes) {
 			targetNode = closest[0]
@@ -233,7 +893,7 @@ func (dht *DHT) FindNode(ctx Context, key string) (*node.Node, bool, error) {
 		}
 	}
 
-	return targetNode, exists, nil
+	return targetNode, exists, closest, nil
 }
 
 // NumNodes returns the total number of nodes stored in the local routing table
@@ -250,83 +910,103 @@ func (dht *DHT) GetOriginID(ctx Context) string {
 
 // Listen begins listening on the socket for incoming Messages
 func (dht *DHT) Listen() error {
+	if dht.options.Relay != nil {
+		err := transport.RegisterRelay(dht.transport, dht.tables[0].Origin, dht.options.Relay.String())
+		if err != nil {
+			return err
+		}
+	}
+
 	start := make(chan bool)
 	stop := make(chan bool)
 
 	go dht.handleDisconnect(start, stop)
 	go dht.handleMessages(start, stop)
 	go dht.handleStoreTimers(start, stop)
+	if dht.options.SnapshotDirectory != "" {
+		go dht.handleSnapshots(start, stop)
+	}
+	if dht.options.StandbyAddress != "" {
+		go dht.handleStandbySync(start, stop)
+	}
+	if dht.watchdog.enabled() {
+		go dht.handleWatchdog(start, stop)
+	}
+
+	if dht.options.OnListen != nil {
+		dht.options.OnListen(dht.ListenAddr())
+	}
+
+	// Transport socket is bound already, incoming messages wait for handlers started above
+	dht.readyOnce.Do(func() {
+		close(dht.ready)
+	})
 
 	return dht.transport.Start()
 }
 
+// Start starts listening in background and returns once DHT is ready to accept messages.
+// Listening is stopped with Disconnect. If ctx is done before DHT is ready, DHT is disconnected.
+func (dht *DHT) Start(ctx Context) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- dht.Listen()
+	}()
+
+	select {
+	case <-dht.ready:
+		return nil
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		dht.Disconnect()
+		return ctx.Err()
+	}
+}
+
+// Ready returns channel which is closed once DHT is ready to accept messages
+func (dht *DHT) Ready() <-chan bool {
+	return dht.ready
+}
+
+// ListenAddr returns the address DHT transport is actually bound to
+func (dht *DHT) ListenAddr() net.Addr {
+	return dht.transport.LocalAddr()
+}
+
 // Bootstrap attempts to bootstrap the network using the BootstrapNodes provided
 // to the Options struct. This will trigger an iterateBootstrap to the provided
-// BootstrapNodes.
+// BootstrapNodes. Every ID is bootstrapped with its own BootstrapNodes if they
+// are set in IdentityOptions.
 func (dht *DHT) Bootstrap() error {
-	if len(dht.options.BootstrapNodes) == 0 {
-		return nil
-	}
-	var futures []transport.Future
-	wg := &sync.WaitGroup{}
 	cb := NewContextBuilder(dht)
+	reaches := make([]*bootstrapReach, len(dht.tables))
 
-	for _, ht := range dht.tables {
+	for i, ht := range dht.tables {
 		ctx, err := cb.SetNodeByID(ht.Origin.ID).Build()
 		if err != nil {
 			return err
 		}
-		for _, bn := range dht.options.BootstrapNodes {
-			request := message.NewPingMessage(ht.Origin, bn)
-
-			if bn.ID == nil {
-				res, err := dht.transport.SendRequest(request)
-				if err != nil {
-					continue
-				}
-				wg.Add(1)
-				futures = append(futures, res)
-			} else {
-				routeNode := routing.NewRouteNode(bn)
-				dht.addNode(ctx, routeNode)
-			}
-		}
+		reaches[i] = &bootstrapReach{}
+		dht.bootstrapSeeds(context.WithValue(ctx, ctxBootstrap, reaches[i]), ht)
 	}
 
-	for _, f := range futures {
-		go func(future transport.Future) {
-			select {
-			case result := <-future.Result():
-				// If result is nil, channel was closed
-				if result != nil {
-					ctx, err := cb.SetNodeByID(result.Receiver.ID).Build()
-					// TODO: must return error here
-					if err != nil {
-						log.Fatal(err)
-					}
-					dht.addNode(ctx, routing.NewRouteNode(result.Sender))
-				}
-				wg.Done()
-				return
-			case <-time.After(dht.options.MessageTimeout):
-				future.Cancel()
-				wg.Done()
-				return
-			}
-		}(f)
-	}
-
-	wg.Wait()
-
-	for _, ht := range dht.tables {
+	for i, ht := range dht.tables {
 		ctx, err := cb.SetNodeByID(ht.Origin.ID).Build()
 		if err != nil {
 			return err
 		}
 
 		if dht.NumNodes(ctx) > 0 {
-			_, _, err = dht.iterate(ctx, routing.IterateBootstrap, ht.Origin.ID, nil)
-			return err
+			_, _, err = dht.iterate(context.WithValue(ctx, ctxBootstrap, reaches[i]), routing.IterateBootstrap, ht.Origin.ID, nil, nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(dht.identity(ctx).BootstrapNodes) > 0 {
+			dht.partitionFor(ctx).bootstrapped(reaches[i].isReached())
+			dht.checkPartition(ctx)
 		}
 	}
 
@@ -334,24 +1014,87 @@ func (dht *DHT) Bootstrap() error {
 }
 
 // Disconnect will trigger a Stop from the network.
+// New incoming requests are refused and transport is stopped once DHT is drained, see DrainTimeout.
 func (dht *DHT) Disconnect() {
+	dht.watches.closeAll()
+	dht.drain(dht.options.DrainTimeout)
 	dht.transport.Stop()
 }
 
+// PendingRequests returns outgoing requests which are still waiting for response
+func (dht *DHT) PendingRequests() []transport.Future {
+	return dht.transport.PendingRequests()
+}
+
+// TransportStats returns I/O counters of transport
+func (dht *DHT) TransportStats() transport.Stats {
+	return dht.transport.Stats()
+}
+
+// CancelRequest cancels outgoing request with given id.
+// Returns false if there is no such request.
+func (dht *DHT) CancelRequest(id message.RequestID) bool {
+	for _, future := range dht.transport.PendingRequests() {
+		if future.ID() == id {
+			future.Cancel()
+			return true
+		}
+	}
+	return false
+}
+
+// CancelRequestsTo cancels all outgoing requests addressed to node with given id.
+// Returns number of cancelled requests.
+func (dht *DHT) CancelRequestsTo(target node.ID) int {
+	cancelled := 0
+	for _, future := range dht.transport.PendingRequests() {
+		if future.Actor() != nil && future.Actor().ID.Equal(target) {
+			future.Cancel()
+			cancelled++
+		}
+	}
+	return cancelled
+}
+
 // Iterate does an iterative search through the network. This can be done
 // for multiple reasons. These reasons include:
 //     iterateStore - Used to store new information in the network.
 //     iterateFindNode - Used to find node in the network given node abstract address.
 //     iterateFindValue - Used to find a value among the network given a key.
 //     iterateBootstrap - Used to bootstrap the network.
-func (dht *DHT) iterate(ctx Context, t routing.IterateType, target []byte, data []byte) (value []byte, closest []*node.Node, err error) {
+// Nodes from exclude list are never contacted nor added to the route set.
+// If tokens is not nil, write tokens returned by contacted nodes are collected into it.
+// Number of iterations running at once is limited with MaxConcurrentLookups option,
+// lookups other than maintenance ones are rejected while watchdog sheds load.
+func (dht *DHT) iterate(ctx Context, t routing.IterateType, target []byte, exclude []*node.Node, tokens map[string][]byte) (value []byte, closest []*node.Node, err error) {
+	if dht.watchdog.overloaded() && !isMaintenance(ctx) {
+		return nil, nil, ErrOverloaded
+	}
+	err = dht.lookups.acquire()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer dht.lookups.release()
+
 	ht := dht.htFromCtx(ctx)
-	routeSet := ht.GetClosestContacts(routing.ParallelCalls, target, []*node.Node{})
+	routeSet := ht.GetClosestContacts(routing.ParallelCalls, target, exclude)
+	if dht.options.PreferSameRegion {
+		routeSet.PreferRegion(dht.options.Locality.Region)
+		sort.Sort(routeSet)
+	}
 
 	// We keep track of nodes contacted so far. We don't contact the same node
 	// twice.
 	var contacted = make(map[string]bool)
 
+	// Lookup which contacted nodes and got no response from any of them is a signal of partition
+	responded := false
+	defer func() {
+		if len(contacted) > 0 {
+			dht.partitionFor(ctx).lookupDone(responded)
+		}
+	}()
+
 	// According to the Kademlia white paper, after a round of FIND_NODE RPCs
 	// fails to provide a node closer than closestNode, we should send a
 	// FIND_NODE RPC to all remaining nodes in the route set that have not
@@ -367,11 +1110,12 @@ func (dht *DHT) iterate(ctx Context, t routing.IterateType, target []byte, data
 	closestNode := routeSet.FirstNode()
 
 	if t == routing.IterateBootstrap {
-		bucket := routing.GetBucketIndexFromDifferingBit(target, ht.Origin.ID)
-		ht.ResetRefreshTimeForBucket(bucket)
+		bucket := ht.BucketIndex(target)
+		ht.SetRefreshTimeForBucket(bucket, dht.options.Clock.Now())
 	}
 
 	var removeFromRouteSet []*node.Node
+	missed := &missedNode{}
 
 	for {
 		var futures []transport.Future
@@ -415,12 +1159,14 @@ func (dht *DHT) iterate(ctx Context, t routing.IterateType, target []byte, data
 			msg := messageBuilder.Build()
 
 			// Send the async queries and wait for a response
-			res, err := dht.transport.SendRequest(msg)
+			res, err := dht.sendRequest(ctx, msg)
 			if err != nil {
 				// Node was unreachable for some reason. We will have to remove
 				// it from the route set, but we will keep it in our routing
 				// table in hopes that it might come back online in the f.
 				removeFromRouteSet = append(removeFromRouteSet, msg.Receiver)
+				dht.hints.markFailed(msg.Receiver)
+				countUnreachable(ctx)
 				continue
 			}
 
@@ -436,107 +1182,448 @@ func (dht *DHT) iterate(ctx Context, t routing.IterateType, target []byte, data
 		resultChan := make(chan *message.Message)
 		for _, f := range futures {
 			go func(future transport.Future) {
-				select {
-				case result := <-future.Result():
-					if result == nil {
-						// Channel was closed
-						return
-					}
-					dht.addNode(ctx, routing.NewRouteNode(result.Sender))
-					resultChan <- result
-					return
-				case <-time.After(dht.options.MessageTimeout):
-					future.Cancel()
+				result, err := dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+				if err == transport.ErrFutureTimeout {
+					dht.hints.markFailed(future.Actor())
+					dht.latenciesFor(ctx).timeout(future.Actor())
+					countUnreachable(ctx)
+				}
+				if err != nil {
 					return
 				}
+				dht.latenciesFor(ctx).observe(result.Sender, time.Since(future.StartTime()))
+				dht.addNode(ctx, routing.NewRouteNode(result.Sender))
+				resultChan <- result
 			}(f)
 		}
 
-		var results []*message.Message
-		if futuresCount > 0 {
-		Loop:
-			for {
-				select {
-				case result := <-resultChan:
-					if result != nil {
-						results = append(results, result)
-					} else {
-						futuresCount--
-					}
-					if len(results) == futuresCount {
-						close(resultChan)
-						break Loop
-					}
-				case <-time.After(dht.options.MessageTimeout):
-					close(resultChan)
-					break Loop
-				}
-			}
+		var results []*message.Message
+		// Replicas returned in the same round are reconciled with ConflictResolver
+		var found *store.Entry
+		var foundNamespace string
+		if futuresCount > 0 {
+		Loop:
+			for {
+				select {
+				case result := <-resultChan:
+					if result != nil {
+						results = append(results, result)
+					} else {
+						futuresCount--
+					}
+					if len(results) == futuresCount {
+						close(resultChan)
+						break Loop
+					}
+				case <-dht.options.Clock.After(dht.messageTimeout(ctx)):
+					close(resultChan)
+					break Loop
+				}
+			}
+
+			if len(results) > 0 {
+				responded = true
+				markReached(ctx)
+			}
+
+			for _, result := range results {
+				if result.Error != nil {
+					routeSet.Remove(routing.NewRouteNode(result.Sender))
+					continue
+				}
+				switch t {
+				case routing.IterateBootstrap, routing.IterateFindNode, routing.IterateStore:
+					responseData := result.Data.(*message.ResponseDataFindNode)
+					go dht.verifyLivenessHints(ctx, responseData.Failed)
+					if tokens != nil && responseData.Token != nil {
+						tokens[string(result.Sender.ID)] = responseData.Token
+					}
+					closest := excludeNodes(responseData.Closest, exclude)
+					if len(closest) > 0 && closest[0].ID.Equal(target) {
+						return nil, closest, nil
+					}
+					routeSet.Extend(routing.RouteNodesFrom(closest))
+				case routing.IterateFindValue:
+					responseData := result.Data.(*message.ResponseDataFindValue)
+					namespace, matches := "", true
+					if responseData.Value != nil {
+						namespace, matches = dht.keyNamespace(ctx, target, responseData.Value, responseData.Namespace)
+					}
+					if !matches {
+						// Node returning arbitrary data for key is not trusted with contacts either
+						log.Println("Ignored value not matching key from", result.Sender)
+						routeSet.Remove(routing.NewRouteNode(result.Sender))
+						continue
+					}
+					if responseData.Value != nil {
+						if err := dht.validate(store.WithNamespace(ctx, namespace), target, responseData.Value); err != nil {
+							log.Println("Ignored invalid record from", result.Sender, ":", err.Error())
+							routeSet.Remove(routing.NewRouteNode(result.Sender))
+							continue
+						}
+					}
+					go dht.verifyLivenessHints(ctx, responseData.Failed)
+					routeSet.Extend(routing.RouteNodesFrom(excludeNodes(responseData.Closest, exclude)))
+					if responseData.Value != nil {
+						candidate := store.Entry{Key: target, Data: responseData.Value, Version: responseData.Version}
+						if found == nil || dht.resolve(target, *found, candidate) {
+							found = &candidate
+							foundNamespace = namespace
+						}
+					} else if responseData.Token != nil {
+						missed.add(target, result.Sender, responseData.Token)
+					}
+				}
+			}
+		}
+
+		if found != nil {
+			// Value is cached at the closest node seen which did not return it
+			if missed.node != nil && dht.options.CacheTTL > 0 {
+				go dht.cacheValue(store.WithNamespace(ctx, foundNamespace), *found, missed.node, missed.token)
+			}
+			sort.Sort(routeSet)
+			return found.Data, routeSet.Nodes(), nil
+		}
+
+		if !queryRest && routeSet.Len() == 0 {
+			return nil, nil, nil
+		}
+
+		sort.Sort(routeSet)
+
+		// If closestNode is unchanged then we are done
+		if routeSet.FirstNode().ID.Equal(closestNode.ID) || queryRest {
+			// We are done
+			switch t {
+			case routing.IterateBootstrap:
+				if !queryRest {
+					queryRest = true
+					continue
+				}
+				return nil, routeSet.Nodes(), nil
+			case routing.IterateFindNode, routing.IterateFindValue:
+				return nil, routeSet.Nodes(), nil
+			case routing.IterateStore:
+				// Store requests are sent to ReplicationFactor closest nodes by storeOnNodes
+				closest := routeSet.Nodes()
+				if len(closest) > dht.options.ReplicationFactor {
+					closest = closest[:dht.options.ReplicationFactor]
+				}
+				return nil, closest, nil
+			}
+		} else {
+			closestNode = routeSet.FirstNode()
+		}
+	}
+}
+
+// Audit asks holder to prove it retains given value by returning a hash
+// of a random slice of the value. Returns true if holder passed the audit.
+func (dht *DHT) Audit(ctx Context, holder string, value []byte) (passed bool, err error) {
+	holderNode, exists, err := dht.FindNode(ctx, holder)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, errors.New("holder not found")
+	}
+
+	request, err := newAuditRequest(dht.keyOf(ctx, value), value)
+	if err != nil {
+		return false, err
+	}
+	expected, err := store.AuditHash(value, request.Offset, request.Length, request.Nonce)
+	if err != nil {
+		return false, err
+	}
+
+	ht := dht.htFromCtx(ctx)
+	msg := message.NewBuilder().Sender(ht.Origin).Receiver(holderNode).Type(message.TypeAudit).Request(request).Build()
+
+	future, err := dht.transport.SendRequest(msg)
+	if err != nil {
+		return false, err
+	}
+
+	rsp, err := dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+	if err != nil {
+		return false, err
+	}
+	response, ok := rsp.Data.(*message.ResponseDataAudit)
+	if !ok {
+		return false, errors.New("invalid audit response")
+	}
+	return response.Found && bytes.Equal(expected, response.Hash), nil
+}
+
+// newAuditRequest creates audit request for random slice of value stored under key
+func newAuditRequest(key store.Key, value []byte) (*message.RequestDataAudit, error) {
+	request := &message.RequestDataAudit{
+		Key:   key,
+		Nonce: make([]byte, 20),
+	}
+
+	_, err := rand.Read(request.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(value) == 0 {
+		return request, nil
+	}
+
+	offset, err := rand.Int(rand.Reader, big.NewInt(int64(len(value))))
+	if err != nil {
+		return nil, err
+	}
+	request.Offset = int(offset.Int64())
+
+	length, err := rand.Int(rand.Reader, big.NewInt(int64(len(value)-request.Offset)))
+	if err != nil {
+		return nil, err
+	}
+	request.Length = int(length.Int64()) + 1
+
+	return request, nil
+}
+
+// storeOnNodes sends Store requests to given nodes and collects receipts
+// from the nodes which accepted the value. Nodes which did not issue
+// a write token are skipped. If node holds version of value preferred by ConflictResolver, it is adopted locally.
+// Nodes record key of ownership signed by publisher as the only one which can delete value.
+func (dht *DHT) storeOnNodes(ctx Context, key store.Key, data []byte, version store.Version, ownership *store.Ownership, ttl time.Duration, nodes []*node.Node, tokens map[string][]byte) []*store.Receipt {
+	ht := dht.htFromCtx(ctx)
+	results := make(chan *store.Receipt, len(nodes))
+	wg := &sync.WaitGroup{}
+	sent, compressed := data, false
+	if dht.options.ValueCompression > 0 {
+		sent, compressed = store.CompressValue(data, dht.options.ValueCompression)
+	}
+	var publicKey ed25519.PublicKey
+	if ownership != nil {
+		publicKey = ownership.PublicKey
+	}
+
+	for _, receiver := range nodes {
+		token, ok := tokens[string(receiver.ID)]
+		if !ok {
+			continue
+		}
+
+		msg := message.NewBuilder().Sender(ht.Origin).Receiver(receiver).Type(message.TypeStore).Request(
+			&message.RequestDataStore{
+				Data:       sent,
+				Token:      token,
+				Version:    version,
+				Compressed: compressed,
+				PublicKey:  publicKey,
+				Namespace:  store.NamespaceOf(ctx),
+				TTL:        ttl,
+				Ownership:  ownership,
+			}).Build()
+
+		future, err := dht.sendRequest(ctx, msg)
+		if err != nil {
+			dht.hints.markFailed(receiver)
+			continue
+		}
+
+		wg.Add(1)
+		go func(future transport.Future) {
+			defer wg.Done()
+			result, err := dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+			if err == transport.ErrFutureTimeout {
+				dht.hints.markFailed(future.Actor())
+			}
+			if err != nil {
+				return
+			}
+			response, ok := result.Data.(*message.ResponseDataStore)
+			if !ok || !response.Success || response.Receipt == nil {
+				return
+			}
+			local, remote := store.Entry{Key: key, Data: data, Version: version}, store.Entry{Key: key, Data: data, Version: response.Version}
+			if !response.Version.IsZero() && dht.resolve(key, local, remote) {
+				dht.adoptVersion(ctx, key, data, response.Version)
+			}
+			receipt := response.Receipt
+			if receipt.Verify() && receipt.Holder.Equal(future.Actor().ID) && bytes.Equal(receipt.Key, key) {
+				results <- receipt
+			}
+		}(future)
+	}
+
+	wg.Wait()
+	close(results)
+
+	var receipts []*store.Receipt
+	for receipt := range results {
+		receipts = append(receipts, receipt)
+	}
+	return receipts
+}
+
+// sendRequest sends request with headers of ctx, maintenance requests are sent within maintenance budget
+func (dht *DHT) sendRequest(ctx Context, msg *message.Message) (transport.Future, error) {
+	if msg.Headers == nil {
+		msg.Headers = headersFromCtx(ctx)
+	}
+	if isMaintenance(ctx) {
+		data, err := message.SerializeMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		dht.budget.reserve(len(data))
+	}
+
+	return dht.transport.SendRequest(msg)
+}
+
+// waitResponse waits up to timeout measured by DHT clock for response to request sent with future,
+// future is timed out if response did not come. It returns transport.ErrFutureTimeout then and
+// transport.ErrFutureCancelled if future was cancelled
+func (dht *DHT) waitResponse(ctx Context, future transport.Future, timeout time.Duration) (*message.Message, error) {
+	waitCtx, cancel := clock.WithTimeout(ctx, dht.options.Clock, timeout)
+	defer cancel()
+
+	msg, err := future.Wait(waitCtx)
+	if err == context.DeadlineExceeded {
+		return nil, transport.ErrFutureTimeout
+	}
+	return msg, err
+}
+
+// configureTransport applies rate limits, deadlines, retries, keepalive, codec and compression options to transport
+func (dht *DHT) configureTransport() error {
+	if dht.options.RateLimit != 0 || dht.options.PeerRateLimit != 0 {
+		err := transport.SetRateLimit(dht.transport, dht.options.RateLimit, dht.options.PeerRateLimit)
+		if err != nil {
+			return err
+		}
+	}
+
+	if dht.options.MaxPendingRequests != 0 {
+		expiry := dht.options.PendingRequestsExpiry
+		if expiry == 0 {
+			expiry = 2 * dht.longestTimeout()
+		}
+		err := transport.SetMaxPendingRequests(dht.transport, dht.options.MaxPendingRequests, dht.options.PendingRequestsWait, expiry)
+		if err != nil {
+			return err
+		}
+	}
+
+	if dht.options.SendQueueSize != 0 {
+		err := transport.SetSendQueue(dht.transport, dht.options.SendQueueSize, dht.sendQueueSaturated)
+		if err != nil {
+			return err
+		}
+	}
+
+	if dht.options.CoalesceDelay != 0 {
+		err := transport.SetCoalescing(dht.transport, dht.options.CoalesceDelay, dht.options.CoalesceSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	if dht.options.OnConnectionFault != nil {
+		err := transport.SetFaultHandler(dht.transport, dht.options.OnConnectionFault)
+		if err != nil {
+			return err
+		}
+	}
+
+	if dht.options.ReadTimeout != 0 || dht.options.WriteTimeout != 0 {
+		err := transport.SetDeadlines(dht.transport, dht.options.ReadTimeout, dht.options.WriteTimeout)
+		if err != nil {
+			return err
+		}
+	}
+
+	if dht.options.RetryAttempts > 1 {
+		err := transport.SetRetryPolicy(dht.transport, transport.RetryPolicy{
+			Attempts:  dht.options.RetryAttempts,
+			BaseDelay: dht.options.RetryDelay,
+			Jitter:    dht.options.RetryJitter,
+			Timeout:   dht.options.MessageTimeout / time.Duration(dht.options.RetryAttempts),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if dht.options.KeepaliveInterval != 0 {
+		err := transport.SetKeepalive(dht.transport, dht.options.KeepaliveInterval, dht.evictDeadPeer)
+		if err != nil {
+			return err
+		}
+	}
+
+	if dht.options.RelayCircuits != 0 {
+		err := transport.SetRelay(dht.transport, dht.options.RelayCircuits)
+		if err != nil {
+			return err
+		}
+	}
 
-			for _, result := range results {
-				if result.Error != nil {
-					routeSet.Remove(routing.NewRouteNode(result.Sender))
-					continue
-				}
-				switch t {
-				case routing.IterateBootstrap, routing.IterateFindNode, routing.IterateStore:
-					responseData := result.Data.(*message.ResponseDataFindNode)
-					if len(responseData.Closest) > 0 && responseData.Closest[0].ID.Equal(target) {
-						return nil, responseData.Closest, nil
-					}
-					routeSet.Extend(routing.RouteNodesFrom(responseData.Closest))
-				case routing.IterateFindValue:
-					responseData := result.Data.(*message.ResponseDataFindValue)
-					routeSet.Extend(routing.RouteNodesFrom(responseData.Closest))
-					if responseData.Value != nil {
-						// TODO When an iterateFindValue succeeds, the initiator must
-						// store the key/value pair at the closest receiver seen which did
-						// not return the value.
-						return responseData.Value, nil, nil
-					}
-				}
-			}
+	if dht.options.HolePunching {
+		err := transport.SetHolePunching(dht.transport)
+		if err != nil {
+			return err
 		}
+	}
 
-		if !queryRest && routeSet.Len() == 0 {
-			return nil, nil, nil
+	if dht.options.Codec != "" {
+		err := transport.SetCodec(dht.transport, dht.options.Codec)
+		if err != nil {
+			return err
 		}
+	}
 
-		sort.Sort(routeSet)
+	if dht.options.CompressionThreshold != 0 {
+		return transport.SetCompression(dht.transport, dht.options.CompressionThreshold)
+	}
+	return nil
+}
 
-		// If closestNode is unchanged then we are done
-		if routeSet.FirstNode().ID.Equal(closestNode.ID) || queryRest {
-			// We are done
-			switch t {
-			case routing.IterateBootstrap:
-				if !queryRest {
-					queryRest = true
-					continue
-				}
-				return nil, routeSet.Nodes(), nil
-			case routing.IterateFindNode, routing.IterateFindValue:
-				return nil, routeSet.Nodes(), nil
-			case routing.IterateStore:
-				for i, receiver := range routeSet.Nodes() {
-					if i >= routing.MaxContactsInBucket {
-						return nil, nil, nil
-					}
+// evictDeadPeer removes nodes at address which failed keepalive probe from routing tables
+func (dht *DHT) evictDeadPeer(address string) {
+	for i, ht := range dht.tables {
+		for _, n := range ht.RemoveNodesWithAddress(address) {
+			dht.hints.markFailed(n)
+			dht.churn[i].changed(ht.BucketIndex(n.ID), 1)
+		}
+	}
+}
 
-					msg := message.NewBuilder().Sender(ht.Origin).Receiver(receiver).Type(message.TypeStore).Request(
-						&message.RequestDataStore{
-							Data: data,
-						}).Build()
+// sendQueueSaturated reports peer which does not keep up with messages sent to it
+func (dht *DHT) sendQueueSaturated(address string) {
+	log.Println("Send queue of peer is saturated:", address)
+	if dht.options.OnSendQueueSaturated != nil {
+		dht.options.OnSendQueueSaturated(address)
+	}
+}
 
-					future, _ := dht.transport.SendRequest(msg)
-					// We do not need to handle result of this message
-					future.Cancel()
-				}
-				return nil, nil, nil
-			}
-		} else {
-			closestNode = routeSet.FirstNode()
+// retrieve returns value from local store. Failures are reported as missing value,
+// corrupted values are removed from store.
+func (dht *DHT) retrieve(ctx Context, key store.Key) ([]byte, bool) {
+	value, exists, err := dht.storeFor(ctx).Retrieve(ctx, key)
+	if err == store.ErrCorrupted {
+		log.Println("Removing corrupted data:", key.String())
+		err = dht.storeFor(ctx).Delete(ctx, key)
+		if err != nil {
+			log.Println("Failed to delete data:", err.Error())
 		}
+		dht.expiriesFor(ctx).forget(key)
+		return nil, false
+	}
+	if err != nil {
+		log.Println("Failed to retrieve data:", err.Error())
+		return nil, false
 	}
+	return value, exists
 }
 
 // addNode adds a node into the appropriate k bucket
@@ -544,7 +1631,8 @@ func (dht *DHT) iterate(ctx Context, t routing.IterateType, target []byte, data
 // from right to left in order to find the appropriate bucket
 func (dht *DHT) addNode(ctx Context, node *routing.RouteNode) {
 	ht := dht.htFromCtx(ctx)
-	index := routing.GetBucketIndexFromDifferingBit(ht.Origin.ID, node.ID)
+	index := ht.BucketIndex(node.ID)
+	dht.hints.markAlive(node.ID)
 
 	// Make sure node doesn't already exist
 	// If it does, mark it as seen
@@ -559,29 +1647,87 @@ func (dht *DHT) addNode(ctx Context, node *routing.RouteNode) {
 	bucket := ht.RoutingTable[index]
 
 	if len(bucket) == routing.MaxContactsInBucket {
+		if i := routing.EvictionCandidate(dht.options.EvictionPolicy, bucket, node); i >= 0 {
+			bucket = append(append(append([]*routing.RouteNode{}, bucket[:i]...), bucket[i+1:]...), node)
+			ht.RoutingTable[index] = bucket
+			countLearned(ctx)
+			dht.churnFor(ctx).changed(index, 2)
+			return
+		}
+
 		// If the bucket is full we need to ping the first node to find out
 		// if it responds back in a reasonable amount of time. If not -
 		// we may remove it
 		n := bucket[0].Node
-		request := message.NewPingMessage(ht.Origin, n)
+		request := newPingMessage(ht.Origin, n)
 		future, err := dht.transport.SendRequest(request)
 		if err != nil {
 			bucket = append(bucket, node)
 			bucket = bucket[1:]
 		} else {
-			select {
-			case <-future.Result():
+			result, err := dht.waitResponse(ctx, future, dht.pingTimeout(ctx))
+			if err != transport.ErrFutureTimeout {
+				dht.versions.record(result)
 				return
-			case <-time.After(dht.options.PingTimeout):
-				bucket = bucket[1:]
-				bucket = append(bucket, node)
 			}
+			dht.hints.markFailed(n)
+			bucket = bucket[1:]
+			bucket = append(bucket, node)
 		}
+		// Replaced contact is counted too
+		dht.churnFor(ctx).changed(index, 1)
 	} else {
 		bucket = append(bucket, node)
 	}
 
 	ht.RoutingTable[index] = bucket
+	countLearned(ctx)
+	dht.churnFor(ctx).changed(index, 1)
+}
+
+// verifyLivenessHints pings hinted failed nodes which are present in our routing table
+// and removes ones that do not respond
+func (dht *DHT) verifyLivenessHints(ctx Context, hints []*node.Node) {
+	ht := dht.htFromCtx(ctx)
+
+	for _, hint := range hints {
+		if hint == nil || hint.ID == nil || ht.Origin.ID.Equal(hint.ID) {
+			continue
+		}
+
+		// Use our own record of the node, hinted address may be wrong
+		routeSet := ht.GetClosestContacts(1, hint.ID, nil)
+		if routeSet.Len() == 0 || !routeSet.FirstNode().ID.Equal(hint.ID) {
+			continue
+		}
+		n := routeSet.FirstNode()
+
+		if !dht.ping(ctx, n) {
+			ht.RemoveNode(n.ID)
+			dht.hints.markFailed(n)
+			dht.churnFor(ctx).changed(ht.BucketIndex(n.ID), 1)
+		}
+	}
+}
+
+// ping sends ping message to receiver and reports if it responded in PingTimeout
+func (dht *DHT) ping(ctx Context, receiver *node.Node) bool {
+	sender := dht.htFromCtx(ctx).Origin
+	future, err := dht.transport.SendRequest(newPingMessage(sender, receiver))
+	if err != nil {
+		return false
+	}
+
+	result, err := dht.waitResponse(ctx, future, dht.pingTimeout(ctx))
+	if err == transport.ErrFutureTimeout {
+		dht.latenciesFor(ctx).timeout(receiver)
+	}
+	if err != nil {
+		return false
+	}
+	dht.latenciesFor(ctx).observe(receiver, time.Since(future.StartTime()))
+	dht.versions.record(result)
+	return true
 }
 
 func (dht *DHT) handleDisconnect(start, stop chan bool) {
@@ -604,48 +1750,137 @@ func (dht *DHT) handleDisconnect(start, stop chan bool) {
 func (dht *DHT) handleStoreTimers(start, stop chan bool) {
 	start <- true
 
-	ticker := time.NewTicker(time.Second)
+	ticker := dht.options.Clock.NewTicker(time.Second)
 	cb := NewContextBuilder(dht)
+	stopNotifications := make([]context.CancelFunc, 0, len(dht.tables))
+	for _, ht := range dht.tables {
+		ctx, err := cb.SetNodeByID(ht.Origin.ID).Build()
+		// TODO: do something sane with error
+		if err != nil {
+			log.Fatal(err)
+		}
+		if notifier, ok := dht.storeFor(ctx).(store.ReplicationNotifier); ok {
+			notifyCtx, cancel := context.WithCancel(ctx)
+			stopNotifications = append(stopNotifications, cancel)
+			go dht.handleReplicationNotifications(withMaintenance(ctx), notifier.NotifyReplication(notifyCtx))
+		}
+	}
+	nextExpiration := make([]time.Time, len(dht.tables))
+
 	for {
 		select {
-		case <-ticker.C:
-			keys := dht.store.GetKeysReadyToReplicate()
-			for _, ht := range dht.tables {
+		case <-ticker.C():
+			for i, ht := range dht.tables {
 				ctx, err := cb.SetNodeByID(ht.Origin.ID).Build()
 				// TODO: do something sane with error
 				if err != nil {
 					log.Fatal(err)
 				}
+				ctx = withMaintenance(ctx)
 				// Refresh
-				for i := 0; i < routing.KeyBitSize; i++ {
-					if time.Since(ht.GetRefreshTimeForBucket(i)) > dht.options.RefreshTime {
-						id := ht.GetRandomIDFromBucket(routing.MaxContactsInBucket)
-						_, _, err = dht.iterate(ctx, routing.IterateBootstrap, id, nil)
-						if err != nil {
-							continue
-						}
+				dht.refresh(ctx, ht)
+
+				// Replication, stores able to notify about ready keys aren't polled
+				if _, ok := dht.storeFor(ctx).(store.ReplicationNotifier); !ok {
+					keys, err := dht.storeFor(ctx).GetKeysReadyToReplicate(ctx)
+					if err != nil {
+						log.Println("Failed to get keys to replicate:", err.Error())
 					}
+					dht.replicate(ctx, keys)
 				}
 
-				// Replication
-				for _, key := range keys {
-					value, _ := dht.store.Retrieve(key)
-					_, _, err2 := dht.iterate(ctx, routing.IterateStore, key, value)
-					if err2 != nil {
-						continue
+				// Expiration, scan is repeated at the next tick while expired values remain
+				now := dht.options.Clock.Now()
+				if !now.Before(nextExpiration[i]) {
+					nextExpiration[i] = now.Add(dht.options.ExpirationInterval)
+					if dht.expire(ctx) {
+						nextExpiration[i] = now
 					}
 				}
+				dht.republish(ctx)
+				dht.warnExpiring(ctx)
+				dht.notifyExpired(ctx)
+				dht.reportExpired(ctx)
+				dht.checkPartition(ctx)
 			}
-
-			// Expiration
-			dht.store.ExpireKeys()
 		case <-stop:
+			for _, cancel := range stopNotifications {
+				cancel()
+			}
 			ticker.Stop()
 			return
 		}
 	}
 }
 
+// expire purges expired values from store, at most ExpirationBatch of them if store supports batches.
+// Returns true if more expired values may remain.
+func (dht *DHT) expire(ctx Context) bool {
+	st := dht.storeFor(ctx)
+	if expirer, ok := st.(store.BatchExpirer); ok && dht.options.ExpirationBatch > 0 {
+		more, err := expirer.ExpireKeysBatch(ctx, dht.options.ExpirationBatch)
+		if err != nil {
+			log.Println("Failed to expire keys:", err.Error())
+			return false
+		}
+		return more
+	}
+	if err := st.ExpireKeys(ctx); err != nil {
+		log.Println("Failed to expire keys:", err.Error())
+	}
+	return false
+}
+
+func (dht *DHT) handleReplicationNotifications(ctx Context, notifications <-chan []store.Key) {
+	for keys := range notifications {
+		dht.replicate(ctx, keys)
+	}
+}
+
+// replicate stores values of keys on the closest nodes. Values are read from store at once
+// and their next replication times are written back in one batch.
+func (dht *DHT) replicate(ctx Context, keys []store.Key) {
+	if len(keys) == 0 {
+		return
+	}
+	entries, err := dht.storeFor(ctx).RetrieveBatch(ctx, keys)
+	if err != nil {
+		log.Println("Failed to retrieve data to replicate:", err.Error())
+		return
+	}
+
+	replicated := make([]store.Entry, 0, len(entries))
+	for _, entry := range entries {
+		tokens := make(map[string][]byte)
+		_, closest, err := dht.iterate(ctx, routing.IterateStore, entry.Key, nil, tokens)
+		if err != nil {
+			continue
+		}
+		ownership := dht.owners.ownership(entry.Key, dht.options.Clock.Now())
+		// Replicas of value stored with TTL expire together with it
+		ttl, limited := dht.lifetimes.remaining(entry.Key, dht.options.Clock.Now())
+		if limited && ttl <= 0 {
+			continue
+		}
+		receipts := dht.storeOnNodes(dht.withNamespaceOf(ctx, entry.Key), entry.Key, entry.Data, entry.Version, ownership, ttl, closest, tokens)
+		dht.replicated(entry.Key, receipts)
+		if current := dht.versionOf(ctx, entry.Key); current.Newer(entry.Version) || entry.Version.Newer(current) {
+			// Version preferred by other node was adopted and is scheduled already
+			continue
+		}
+		entry.Replication = dht.options.Clock.Now().Add(dht.options.ReplicateTime)
+		replicated = append(replicated, entry)
+	}
+	if len(replicated) == 0 {
+		return
+	}
+
+	err = dht.storeFor(ctx).StoreBatch(ctx, replicated, false)
+	if err != nil {
+		log.Println("Failed to schedule replication:", err.Error())
+	}
+}
+
 func (dht *DHT) handleMessages(start, stop chan bool) {
 	start <- true
 
@@ -657,19 +1892,17 @@ func (dht *DHT) handleMessages(start, stop chan bool) {
 				continue
 			}
 
-			var ctx Context
-			var err error
-			if msg.Receiver.ID == nil {
-				ctx, err = cb.SetDefaultNode().Build()
-			} else {
-				ctx, err = cb.SetNodeByID(msg.Receiver.ID).Build()
-			}
-			if err != nil {
-				// TODO: Do something sane with error!
-				log.Println(err)
+			ctx, ok := dht.receiverContext(cb, msg)
+			if !ok {
+				continue
 			}
 			ht := dht.htFromCtx(ctx)
 
+			if !dht.incoming.acquire() {
+				// DHT is draining
+				continue
+			}
+
 			messageBuilder := message.NewBuilder().Sender(ht.Origin).Receiver(msg.Sender).Type(msg.Type)
 
 			switch msg.Type {
@@ -682,8 +1915,38 @@ func (dht *DHT) handleMessages(start, stop chan bool) {
 			case message.TypePing:
 				dht.processPing(ctx, msg, messageBuilder)
 			case message.TypeRPC:
-				dht.processRPC(ctx, msg, messageBuilder)
+				// Remote procedures may take long, e.g. proxy lookups
+				go func() {
+					dht.processRPC(ctx, msg, messageBuilder)
+					dht.incoming.release()
+				}()
+				continue
+			case message.TypeChallenge:
+				dht.processChallenge(ctx, msg, messageBuilder)
+			case message.TypeAudit:
+				dht.processAudit(ctx, msg, messageBuilder)
+			case message.TypeWatch:
+				dht.processWatch(ctx, msg, messageBuilder)
+			case message.TypeNotify:
+				dht.processNotify(ctx, msg, messageBuilder)
+			case message.TypeRegisterService:
+				dht.processRegisterService(ctx, msg, messageBuilder)
+			case message.TypeLookupService:
+				dht.processLookupService(ctx, msg, messageBuilder)
+			case message.TypeAddProvider:
+				dht.processAddProvider(ctx, msg, messageBuilder)
+			case message.TypeGetProviders:
+				dht.processGetProviders(ctx, msg, messageBuilder)
+			case message.TypePutMutable:
+				dht.processPutMutable(ctx, msg, messageBuilder)
+			case message.TypeGetMutable:
+				dht.processGetMutable(ctx, msg, messageBuilder)
+			case message.TypeDelete:
+				dht.processDelete(ctx, msg, messageBuilder)
+			case message.TypeExpired:
+				dht.processExpired(ctx, msg, messageBuilder)
 			}
+			dht.incoming.release()
 		case <-stop:
 			return
 		}
@@ -694,9 +1957,11 @@ func (dht *DHT) processFindNode(ctx Context, msg *message.Message, messageBuilde
 	ht := dht.htFromCtx(ctx)
 	data := msg.Data.(*message.RequestDataFindNode)
 	dht.addNode(ctx, routing.NewRouteNode(msg.Sender))
-	closest := ht.GetClosestContacts(routing.MaxContactsInBucket, data.Target, []*node.Node{msg.Sender})
+	closest := ht.GetClosestRecentContacts(routing.MaxContactsInBucket, data.Target, []*node.Node{msg.Sender})
 	response := &message.ResponseDataFindNode{
 		Closest: closest.Nodes(),
+		Failed:  dht.hints.recent(maxLivenessHints, dht.options.FailedNodeHintTime),
+		Token:   dht.tokens.issue(remoteIP(msg)),
 	}
 	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
 	if err != nil {
@@ -708,13 +1973,18 @@ func (dht *DHT) processFindValue(ctx Context, msg *message.Message, messageBuild
 	ht := dht.htFromCtx(ctx)
 	data := msg.Data.(*message.RequestDataFindValue)
 	dht.addNode(ctx, routing.NewRouteNode(msg.Sender))
-	value, exists := dht.store.Retrieve(data.Target)
-	response := &message.ResponseDataFindValue{}
+	value, exists := dht.retrieve(ctx, data.Target)
+	response := &message.ResponseDataFindValue{
+		Failed: dht.hints.recent(maxLivenessHints, dht.options.FailedNodeHintTime),
+	}
 	if exists {
 		response.Value = value
+		response.Version = dht.versionOf(ctx, data.Target)
+		response.Namespace = store.NamespaceOf(dht.withNamespaceOf(ctx, data.Target))
 	} else {
-		closest := ht.GetClosestContacts(routing.MaxContactsInBucket, data.Target, []*node.Node{msg.Sender})
+		closest := ht.GetClosestRecentContacts(routing.MaxContactsInBucket, data.Target, []*node.Node{msg.Sender})
 		response.Closest = closest.Nodes()
+		response.Token = dht.tokens.issue(remoteIP(msg))
 	}
 	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
 	if err != nil {
@@ -723,19 +1993,215 @@ func (dht *DHT) processFindValue(ctx Context, msg *message.Message, messageBuild
 }
 
 func (dht *DHT) processStore(ctx Context, msg *message.Message, messageBuilder message.Builder) {
+	ht := dht.htFromCtx(ctx)
 	data := msg.Data.(*message.RequestDataStore)
 	dht.addNode(ctx, routing.NewRouteNode(msg.Sender))
-	key := store.NewKey(data.Data)
+	response := &message.ResponseDataStore{}
+	if data.Compressed {
+		value, err := store.DecompressValue(data.Data)
+		if err != nil {
+			log.Println("Rejected store with corrupted value from", msg.Sender)
+			dht.sendStoreResponse(msg, messageBuilder, response)
+			return
+		}
+		data.Data = value
+	}
+	if dht.tooLarge(data.Data) {
+		log.Println("Rejected store of too large value from", msg.Sender)
+		response.Code = message.ErrorTooLarge
+		dht.sendStoreResponse(msg, messageBuilder, response)
+		return
+	}
+	if len(data.Namespace) > store.MaxNamespaceLength {
+		log.Println("Rejected store with too long namespace from", msg.Sender)
+		dht.sendStoreResponse(msg, messageBuilder, response)
+		return
+	}
+	ctx = store.WithNamespace(ctx, data.Namespace)
+	key := dht.keyOf(ctx, data.Data)
+	if err := dht.validate(ctx, key, data.Data); err != nil {
+		log.Println("Rejected invalid record from", msg.Sender, ":", err.Error())
+		response.Code = message.ErrorInvalidRecord
+		dht.sendStoreResponse(msg, messageBuilder, response)
+		return
+	}
 	expiration := dht.getExpirationTime(ctx, key)
-	replication := time.Now().Add(dht.options.ReplicateTime)
-	err := dht.store.Store(key, data.Data, replication, expiration, false)
-	if err != nil {
+	if data.TTL > 0 {
+		expiration = dht.options.Clock.Now().Add(dht.limitTTL(data.TTL))
+	}
+	replication := dht.options.Clock.Now().Add(dht.options.ReplicateTime)
+	if dht.IsReadOnly() {
+		log.Println("Rejected store in read-only mode from", msg.Sender)
+		response.Code = message.ErrorReadOnly
+		dht.sendStoreResponse(msg, messageBuilder, response)
+		return
+	}
+	if !dht.tokens.valid(remoteIP(msg), data.Token) {
+		log.Println("Rejected store with invalid write token from", msg.Sender)
+		dht.sendStoreResponse(msg, messageBuilder, response)
+		return
+	}
+	// Value is owned by publisher which signed ownership, ownership which expired is not passed on
+	ownership := data.Ownership
+	if ownership != nil && !validOwnership(ownership, key) {
+		log.Println("Rejected store with invalid ownership from", msg.Sender)
+		dht.sendStoreResponse(msg, messageBuilder, response)
+		return
+	}
+	if ownership != nil && dht.options.Clock.Now().Before(ownership.Expiration) && !data.Cached &&
+		!dht.owners.claim(ownership, data.Version.Publisher, expiration, dht.options.Clock.Now()) {
+		log.Println("Rejected store of value owned by other publisher from", msg.Sender)
+		dht.sendStoreResponse(msg, messageBuilder, response)
+		return
+	}
+	if data.Cached {
+		// Cached copy never replaces value held already
+		if _, exists := dht.retrieve(ctx, key); exists {
+			dht.sendStoreResponse(msg, messageBuilder, response)
+			return
+		}
+		// Cached copy expires before it is due for replication, so it doesn't shorten lifetime of replicas
+		replication = expiration.Add(dht.options.ReplicateTime)
+	}
+	// Newer version of value is kept, it is returned to sender to resolve the conflict on its side too
+	_, err := dht.storeVersion(ctx, key, data.Data, replication, expiration, false, data.Version)
+	if err == store.ErrFull || err == store.ErrTooLarge {
+		log.Println("Rejected store from", msg.Sender, ":", err.Error())
+		if err == store.ErrTooLarge {
+			response.Code = message.ErrorTooLarge
+		}
+	} else if err != nil {
 		log.Println("Failed to store data:", err.Error())
+	} else {
+		if data.TTL > 0 {
+			dht.lifetimes.set(key, expiration, dht.options.Clock.Now())
+		}
+		response.Success = true
+		response.Receipt = store.NewReceipt(key, ht.Origin.ID, expiration, dht.options.PrivateKey)
+		response.Version = dht.versionOf(ctx, key)
+	}
+	dht.sendStoreResponse(msg, messageBuilder, response)
+}
+
+// storeVersion stores value locally. Stores which keep versions resolve conflicting replicas
+// by version, it returns false if newer version is stored already. With ConflictResolver option
+// stored replica is replaced only if resolver prefers the new one. Watchers of key are notified
+// if value or its version changed.
+func (dht *DHT) storeVersion(ctx Context, key store.Key, data []byte, replication time.Time, expiration time.Time, publisher bool, version store.Version) (bool, error) {
+	watched := dht.watches.watched(base58.Encode(key), dht.options.Clock.Now())
+	var previous store.Version
+	var existed bool
+	if watched {
+		_, existed = dht.retrieve(ctx, key)
+		previous = dht.versionOf(ctx, key)
+	}
+
+	stored := true
+	var err error
+	st := dht.storeFor(ctx)
+	if dht.options.ConflictResolver != nil {
+		stored, err = dht.storeResolved(ctx, st, store.Entry{
+			Key: key, Data: data, Replication: replication, Expiration: expiration, Version: version,
+		}, publisher)
+	} else if versioned, ok := st.(store.Versioned); ok {
+		stored, err = versioned.StoreVersion(ctx, key, data, replication, expiration, version)
+	} else {
+		err = st.Store(ctx, key, data, replication, expiration, publisher)
+	}
+
+	if err == nil && stored {
+		dht.stored(ctx, key, data, expiration)
+	}
+	if err == nil && stored && watched {
+		current := dht.versionOf(ctx, key)
+		if !existed || current.Newer(previous) || previous.Newer(current) {
+			dht.notifyWatchers(ctx, key, data, current)
+		}
+	}
+	return stored, err
+}
+
+// storeResolved stores entry unless ConflictResolver prefers replica stored already
+func (dht *DHT) storeResolved(ctx Context, st store.Store, entry store.Entry, publisher bool) (bool, error) {
+	current, err := st.RetrieveBatch(ctx, []store.Key{entry.Key})
+	if err != nil {
+		return false, err
+	}
+	if len(current) > 0 && !dht.options.ConflictResolver(entry.Key, current[0], entry) {
+		return false, nil
+	}
+	return true, st.StoreBatch(ctx, []store.Entry{entry}, publisher)
+}
+
+// versionOf returns version of locally stored value, zero Version if store does not keep versions
+func (dht *DHT) versionOf(ctx Context, key store.Key) store.Version {
+	versioned, ok := dht.storeFor(ctx).(store.Versioned)
+	if !ok {
+		return store.Version{}
+	}
+
+	version, _, err := versioned.Version(ctx, key)
+	if err != nil {
+		log.Println("Failed to get version of value:", err.Error())
+	}
+	return version
+}
+
+// adoptVersion replaces local replica of value with newer version found on other node
+func (dht *DHT) adoptVersion(ctx Context, key store.Key, data []byte, version store.Version) {
+	expiration := dht.getExpirationTime(ctx, key)
+	replication := dht.options.Clock.Now().Add(dht.options.ReplicateTime)
+	_, err := dht.storeVersion(ctx, key, data, replication, expiration, false, version)
+	if err != nil {
+		log.Println("Failed to store newer version of value:", err.Error())
+	}
+}
+
+func (dht *DHT) sendStoreResponse(msg *message.Message, messageBuilder message.Builder, response *message.ResponseDataStore) {
+	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
+	if err != nil {
+		log.Println("Failed to send response:", err.Error())
+	}
+}
+
+func (dht *DHT) processChallenge(ctx Context, msg *message.Message, messageBuilder message.Builder) {
+	data := msg.Data.(*message.RequestDataChallenge)
+	dht.addNode(ctx, routing.NewRouteNode(msg.Sender))
+	_, holds := dht.retrieve(ctx, data.Key)
+	response := &message.ResponseDataChallenge{
+		Holds:     holds,
+		Signature: store.SignChallenge(data.Key, data.Nonce, holds, dht.options.PrivateKey),
+	}
+	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
+	if err != nil {
+		log.Println("Failed to send response:", err.Error())
 	}
 }
 
 func (dht *DHT) processPing(ctx Context, msg *message.Message, messageBuilder message.Builder) {
-	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(nil).Build())
+	dht.versions.record(msg)
+	response := &message.ResponseDataPing{Version: Version, Build: Build}
+	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
+	if err != nil {
+		log.Println("Failed to send response:", err.Error())
+	}
+}
+
+func (dht *DHT) processAudit(ctx Context, msg *message.Message, messageBuilder message.Builder) {
+	data := msg.Data.(*message.RequestDataAudit)
+	dht.addNode(ctx, routing.NewRouteNode(msg.Sender))
+	response := &message.ResponseDataAudit{}
+	value, exists := dht.retrieve(ctx, data.Key)
+	if exists {
+		hash, err := store.AuditHash(value, data.Offset, data.Length, data.Nonce)
+		if err != nil {
+			log.Println("Failed to audit data:", err.Error())
+		} else {
+			response.Found = true
+			response.Hash = hash
+		}
+	}
+	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
 	if err != nil {
 		log.Println("Failed to send response:", err.Error())
 	}
@@ -744,7 +2210,15 @@ func (dht *DHT) processPing(ctx Context, msg *message.Message, messageBuilder me
 func (dht *DHT) processRPC(ctx Context, msg *message.Message, messageBuilder message.Builder) {
 	data := msg.Data.(*message.RequestDataRPC)
 	dht.addNode(ctx, routing.NewRouteNode(msg.Sender))
-	result, err := dht.rpc.Invoke(msg.Sender, data.Method, data.Args)
+	if dht.IsReadOnly() {
+		response := &message.ResponseDataRPC{Error: ErrReadOnly.Error(), Code: message.ErrorReadOnly}
+		err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
+		if err != nil {
+			log.Println("Failed to send response:", err.Error())
+		}
+		return
+	}
+	result, err := dht.rpc.Invoke(rpc.NewCallContextFromMessage(context.Background(), msg), msg.Sender, data.Method, data.Args)
 	response := &message.ResponseDataRPC{
 		Success: true,
 		Result:  result,
@@ -784,33 +2258,67 @@ func (dht *DHT) RemoteProcedureCall(ctx Context, target string, method string, a
 	}
 
 	if target == dht.GetOriginID(ctx) {
-		return dht.rpc.Invoke(request.Sender, method, args)
+		return dht.rpc.Invoke(context.Background(), request.Sender, method, args)
 	}
 
 	// Send the async queries and wait for a future
-	future, err := dht.transport.SendRequest(request)
+	future, err := dht.sendRequest(ctx, request)
 	if err != nil {
 		return nil, err
 	}
 
-	select {
-	case rsp := <-future.Result():
-		if rsp == nil {
-			// Channel was closed
-			return nil, errors.New("chanel closed unexpectedly")
-		}
-		dht.addNode(ctx, routing.NewRouteNode(rsp.Sender))
+	rsp, err := dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+	if err != nil {
+		return nil, err
+	}
+	dht.addNode(ctx, routing.NewRouteNode(rsp.Sender))
+
+	response := rsp.Data.(*message.ResponseDataRPC)
+	if response.Success {
+		return response.Result, nil
+	}
+	if response.Code == message.ErrorReadOnly {
+		return nil, ErrReadOnly
+	}
+	return nil, errors.New(response.Error)
+}
+
+// remoteIP returns IP address message was received from.
+// Sender's own address is used if transport does not report it.
+func excludedNodes(ids []node.ID) []*node.Node {
+	nodes := make([]*node.Node, 0, len(ids))
+	for _, id := range ids {
+		nodes = append(nodes, &node.Node{ID: id})
+	}
+	return nodes
+}
 
-		response := rsp.Data.(*message.ResponseDataRPC)
-		if response.Success {
-			return response.Result, nil
+func excludeNodes(nodes []*node.Node, exclude []*node.Node) []*node.Node {
+	if len(exclude) == 0 {
+		return nodes
+	}
+	result := make([]*node.Node, 0, len(nodes))
+	for _, n := range nodes {
+		excluded := false
+		for _, e := range exclude {
+			if n.ID.Equal(e.ID) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			result = append(result, n)
 		}
-		return nil, errors.New(response.Error)
-	case <-time.After(dht.options.MessageTimeout):
-		future.Cancel()
-		return nil, errors.New("timeout")
 	}
+	return result
+}
 
+func remoteIP(msg *message.Message) net.IP {
+	host, _, err := net.SplitHostPort(msg.RemoteAddress())
+	if err == nil {
+		return net.ParseIP(host)
+	}
+	return msg.Sender.Address.IP
 }
 
 func (dht *DHT) htFromCtx(ctx Context) *routing.HashTable {
@@ -18,12 +18,16 @@ package network
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"math"
+	"net"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/insolar/network/clock"
 	"github.com/insolar/network/connection"
 	"github.com/insolar/network/message"
 	"github.com/insolar/network/node"
@@ -32,6 +36,7 @@ import (
 	"github.com/insolar/network/store"
 	"github.com/insolar/network/transport"
 
+	"github.com/jbenet/go-base58"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -67,8 +72,31 @@ func (f *mockFuture) SetResult(msg *message.Message) {
 	f.result <- msg
 }
 
+func (f *mockFuture) StartTime() time.Time {
+	return time.Time{}
+}
+
+func (f *mockFuture) Wait(ctx context.Context) (*message.Message, error) {
+	select {
+	case msg := <-f.result:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f *mockFuture) Err() error {
+	return nil
+}
+
 func (f *mockFuture) Cancel() {}
 
+func (f *mockFuture) Timeout() {}
+
+func (f *mockFuture) TimedOut() bool {
+	return false
+}
+
 type mockTransport struct {
 	recv     chan *message.Message
 	send     chan *message.Message
@@ -76,6 +104,7 @@ type mockTransport struct {
 	msgChan  chan *message.Message
 	failNext bool
 	sequence *uint64
+	pending  []transport.Future
 }
 
 func newMockTransport() *mockTransport {
@@ -112,6 +141,18 @@ func (t *mockTransport) Messages() chan *message.Message {
 	return t.msgChan
 }
 
+func (t *mockTransport) PendingRequests() []transport.Future {
+	return t.pending
+}
+
+func (t *mockTransport) LocalAddr() net.Addr {
+	return nil
+}
+
+func (t *mockTransport) Stats() transport.Stats {
+	return transport.Stats{}
+}
+
 func (t *mockTransport) failNextSendMessage() {
 	t.failNext = true
 }
@@ -164,10 +205,16 @@ func mockFindNodeResponseEmpty(request *message.Message) *message.Message {
 	r.IsResponse = true
 	responseData := &message.ResponseDataFindNode{}
 	responseData.Closest = []*node.Node{}
+	responseData.Token = []byte("token")
 	r.Data = responseData
 	return r
 }
 
+func mockStoreResponse(request *message.Message) *message.Message {
+	return message.NewBuilder().Sender(request.Receiver).Receiver(request.Sender).Type(request.Type).Response(
+		&message.ResponseDataStore{Success: true}).Build()
+}
+
 func dhtParams(ids []node.ID, address string) (store.Store, *node.Origin, transport.Transport, rpc.RPC, error) {
 	st := store.NewMemoryStore()
 	addr, _ := node.NewAddress(address)
@@ -187,6 +234,15 @@ func realDhtParams(ids []node.ID, address string) (store.Store, *node.Origin, tr
 	return st, origin, tp, r, err
 }
 
+func inMemoryDhtParams(network *transport.InMemoryNetwork, ids []node.ID, address string) (store.Store, *node.Origin, transport.Transport, rpc.RPC, error) {
+	st := store.NewMemoryStore()
+	addr, _ := node.NewAddress(address)
+	origin, _ := node.NewOrigin(ids, addr)
+	tp, err := transport.NewInMemoryTransport(network, address)
+	r := rpc.NewRPC()
+	return st, origin, tp, r, err
+}
+
 // Creates twenty DHTs and bootstraps each with the previous
 // at the end all should know about each other
 func TestBootstrapTwentyNodes(t *testing.T) {
@@ -518,6 +574,234 @@ func TestStoreAndFindLargeValue(t *testing.T) {
 	<-done
 }
 
+func TestStoreAndGetInMemory(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{
+			{
+				ID:      id1[0],
+				Address: dht1.origin.Address,
+			},
+		},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			err := dht.Listen()
+			assert.Equal(t, "closed", err.Error())
+			done <- true
+		}(dht)
+	}
+
+	err = dht2.Bootstrap()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, dht1.NumNodes(getDefaultCtx(dht1)))
+
+	key, receipts, err := dht1.StoreWithReceipts(getDefaultCtx(dht1), []byte("foo"))
+	assert.NoError(t, err)
+	assert.Len(t, receipts, 1)
+
+	value, exists, err := dht2.Get(getDefaultCtx(dht2), key)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, []byte("foo"), value)
+
+	dht1.Disconnect()
+	dht2.Disconnect()
+
+	<-done
+	<-done
+}
+
+func TestStoreAndGet_KeyHash(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{KeyHash: store.SHA256})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		KeyHash: store.SHA256,
+		BootstrapNodes: []*node.Node{
+			{
+				ID:      id1[0],
+				Address: dht1.origin.Address,
+			},
+		},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			err := dht.Listen()
+			assert.Equal(t, "closed", err.Error())
+			done <- true
+		}(dht)
+	}
+
+	err = dht2.Bootstrap()
+	assert.NoError(t, err)
+
+	key, err := dht1.Store(getDefaultCtx(dht1), []byte("foo"))
+	assert.NoError(t, err)
+	assert.Equal(t, base58.Encode(store.SHA256([]byte("foo"))), key)
+
+	// Holder derived the same key
+	value, exists, err := dht2.storeFor(getDefaultCtx(dht2)).Retrieve(getDefaultCtx(dht2), store.SHA256([]byte("foo")))
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, []byte("foo"), value)
+
+	value, exists, err = dht2.Get(getDefaultCtx(dht2), key)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, []byte("foo"), value)
+
+	dht1.Disconnect()
+	dht2.Disconnect()
+
+	<-done
+	<-done
+}
+
+// Stores value on the network, checks holder's receipt and challenges holder
+// before and after the value was deleted.
+func TestStoreReceiptAndChallenge(t *testing.T) {
+	done := make(chan bool)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, _ := realDhtParams(id1, "127.0.0.1:3000")
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, _ := realDhtParams(nil, "127.0.0.1:3001")
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{
+			{
+				ID:      id1[0],
+				Address: dht1.origin.Address,
+			},
+		},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			err := dht.Listen()
+			assert.Equal(t, "closed", err.Error())
+			done <- true
+		}(dht)
+	}
+
+	time.Sleep(1 * time.Second)
+
+	dht2.Bootstrap()
+
+	ctx := getDefaultCtx(dht2)
+	_, receipts, err := dht2.StoreWithReceipts(ctx, []byte("foo"))
+	assert.NoError(t, err)
+	assert.Len(t, receipts, 1)
+
+	receipt := receipts[0]
+	assert.True(t, receipt.Verify())
+	assert.Equal(t, id1[0], receipt.Holder)
+	assert.Equal(t, store.NewKey([]byte("foo")), receipt.Key)
+
+	holds, err := dht2.Challenge(ctx, receipt)
+	assert.NoError(t, err)
+	assert.True(t, holds)
+
+	st1.Delete(context.Background(), receipt.Key)
+
+	holds, err = dht2.Challenge(ctx, receipt)
+	assert.NoError(t, err)
+	assert.False(t, holds)
+
+	dht1.Disconnect()
+	dht2.Disconnect()
+
+	<-done
+	<-done
+}
+
+// Stores value on the network and audits holder before and after the value
+// was deleted.
+func TestAudit(t *testing.T) {
+	done := make(chan bool)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, _ := realDhtParams(id1, "127.0.0.1:3000")
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, _ := realDhtParams(nil, "127.0.0.1:3001")
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{
+			{
+				ID:      id1[0],
+				Address: dht1.origin.Address,
+			},
+		},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			err := dht.Listen()
+			assert.Equal(t, "closed", err.Error())
+			done <- true
+		}(dht)
+	}
+
+	time.Sleep(1 * time.Second)
+
+	dht2.Bootstrap()
+
+	ctx := getDefaultCtx(dht2)
+	value := []byte("some value to audit")
+	_, err := dht2.Store(ctx, value)
+	assert.NoError(t, err)
+
+	passed, err := dht2.Audit(ctx, id1[0].String(), value)
+	assert.NoError(t, err)
+	assert.True(t, passed)
+
+	st1.Delete(context.Background(), store.NewKey(value))
+
+	passed, err = dht2.Audit(ctx, id1[0].String(), value)
+	assert.NoError(t, err)
+	assert.False(t, passed)
+
+	dht1.Disconnect()
+	dht2.Disconnect()
+
+	<-done
+	<-done
+}
+
+func TestNewAuditRequest(t *testing.T) {
+	value := []byte("value")
+	for i := 0; i < 100; i++ {
+		request, err := newAuditRequest(store.NewKey(value), value)
+		assert.NoError(t, err)
+		assert.True(t, request.Length > 0)
+		assert.True(t, request.Offset+request.Length <= len(value))
+	}
+
+	request, err := newAuditRequest(store.NewKey(nil), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, request.Length)
+	assert.Len(t, request.Nonce, 20)
+}
+
 // Tests sending a message which results in an error when attempting to
 // send over uTP
 func TestNetworkingSendError(t *testing.T) {
@@ -702,7 +986,8 @@ func TestStoreReplication(t *testing.T) {
 				stores++
 				d := request.Data.(*message.RequestDataStore)
 				assert.Equal(t, []byte("foo"), d.Data)
-				if stores >= 2 {
+				mockTp.send <- mockStoreResponse(request)
+				if stores == 2 {
 					close(replicate)
 				}
 			}
@@ -753,6 +1038,28 @@ func TestStoreExpiration(t *testing.T) {
 	dht.Disconnect()
 }
 
+func TestDHT_ExpirationBatch(t *testing.T) {
+	id := getIDWithValues(0)
+	st, s, tp, r, err := dhtParams([]node.ID{id}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+
+	dht, _ := NewDHT(st, s, tp, r, &Options{ExpirationBatch: 1})
+	assert.Equal(t, time.Second, dht.options.ExpirationInterval)
+	ctx := getDefaultCtx(dht)
+
+	now := time.Now()
+	for _, data := range [][]byte{[]byte("foo"), []byte("bar")} {
+		st.Store(ctx, store.NewKey(data), data, now, now.Add(-time.Second), true)
+	}
+
+	assert.True(t, dht.expire(ctx))
+	assert.False(t, dht.expire(ctx))
+	_, found, _ := st.Retrieve(ctx, store.NewKey([]byte("foo")))
+	assert.False(t, found)
+	_, found, _ = st.Retrieve(ctx, store.NewKey([]byte("bar")))
+	assert.False(t, found)
+}
+
 // Create a new node and bootstrap it. All nodes in the network know of a
 // single node closer to the original node. This continues until every MaxContactsInBucket bucket
 // is occupied.
@@ -909,6 +1216,1008 @@ func TestGetRandomIDFromBucket(t *testing.T) {
 	dht.Disconnect()
 }
 
+func TestOriginAddresses(t *testing.T) {
+	st, s, tp, r, err := dhtParams(nil, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	tcpAddr, _ := node.NewAddress("127.0.0.1:3001")
+	s.Addresses = map[string]*node.Address{"tcp": tcpAddr}
+
+	dht, _ := NewDHT(st, s, tp, r, &Options{})
+
+	assert.Equal(t, tcpAddr, dht.tables[0].Origin.AddressFor("tcp"))
+}
+
+// backingStore allows to embed store.Store, whose Store method conflicts with embedded field name
+type backingStore = store.Store
+
+type corruptedStore struct {
+	backingStore
+}
+
+func (s *corruptedStore) Retrieve(ctx context.Context, key store.Key) ([]byte, bool, error) {
+	return nil, false, store.ErrCorrupted
+}
+
+func TestRetrieve_Corrupted(t *testing.T) {
+	st, s, tp, r, err := dhtParams(nil, "127.0.0.1:3000")
+	assert.NoError(t, err)
+
+	data := []byte("foo")
+	key := store.NewKey(data)
+	ctx := context.Background()
+	st.Store(ctx, key, data, time.Now(), time.Now().Add(time.Hour), true)
+
+	dht, _ := NewDHT(&corruptedStore{st}, s, tp, r, &Options{})
+
+	_, exists := dht.retrieve(getDefaultCtx(dht), key)
+	assert.False(t, exists)
+
+	_, exists, err = st.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestListenAddr(t *testing.T) {
+	st, s, tp, r, err := realDhtParams(nil, "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	listening := make(chan net.Addr, 1)
+	dht, _ := NewDHT(st, s, tp, r, &Options{
+		OnListen: func(addr net.Addr) {
+			listening <- addr
+		},
+	})
+
+	done := make(chan bool)
+	go func() {
+		dht.Listen()
+		done <- true
+	}()
+
+	addr := <-listening
+	assert.Equal(t, dht.ListenAddr(), addr)
+	_, port, _ := net.SplitHostPort(addr.String())
+	assert.NotEqual(t, "0", port)
+
+	dht.Disconnect()
+	<-done
+}
+
+func TestStart(t *testing.T) {
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{Address: dht1.origin.Address}},
+	})
+
+	select {
+	case <-dht1.Ready():
+		t.Fatal("DHT is ready before start")
+	default:
+	}
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		err = dht.Start(getDefaultCtx(dht))
+		assert.NoError(t, err)
+		<-dht.Ready()
+	}
+
+	// Bootstrap right after start must not race with listening
+	err = dht2.Bootstrap()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, dht2.NumNodes(getDefaultCtx(dht2)))
+
+	dht1.Disconnect()
+	dht2.Disconnect()
+}
+
+func TestCancelRequests(t *testing.T) {
+	st, s, tp, r, err := dhtParams(nil, "0.0.0.0:3000")
+	assert.NoError(t, err)
+
+	dht, _ := NewDHT(st, s, tp, r, &Options{})
+	mockTp := tp.(*mockTransport)
+
+	addr, _ := node.NewAddress("127.0.0.1:3001")
+	first := &node.Node{ID: getIDWithValues(1), Address: addr}
+	second := &node.Node{ID: getIDWithValues(2), Address: addr}
+
+	var cancelled []message.RequestID
+	cb := func(f transport.Future) {
+		cancelled = append(cancelled, f.ID())
+	}
+	mockTp.pending = []transport.Future{
+		transport.NewFuture(message.RequestID(1), first, &message.Message{}, cb),
+		transport.NewFuture(message.RequestID(2), second, &message.Message{}, cb),
+		transport.NewFuture(message.RequestID(3), second, &message.Message{}, cb),
+	}
+
+	assert.Len(t, dht.PendingRequests(), 3)
+
+	assert.True(t, dht.CancelRequest(message.RequestID(1)))
+	assert.False(t, dht.CancelRequest(message.RequestID(4)))
+	assert.Equal(t, []message.RequestID{1}, cancelled)
+
+	assert.Equal(t, 2, dht.CancelRequestsTo(second.ID))
+	assert.Equal(t, []message.RequestID{1, 2, 3}, cancelled)
+}
+
+func TestVerifyLivenessHints(t *testing.T) {
+	id := getIDWithValues(0)
+	st, s, tp, r, err := dhtParams([]node.ID{id}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+
+	dht, _ := NewDHT(st, s, tp, r, &Options{
+		PingTimeout: time.Millisecond * 100,
+	})
+	mockTp := tp.(*mockTransport)
+	ctx := getDefaultCtx(dht)
+
+	addr, _ := node.NewAddress("0.0.0.0:3001")
+	alive := &node.Node{ID: getZerodIDWithNthByte(1, byte(1)), Address: addr}
+	dead := &node.Node{ID: getZerodIDWithNthByte(1, byte(2)), Address: addr}
+	unknown := &node.Node{ID: getZerodIDWithNthByte(1, byte(3)), Address: addr}
+	dht.addNode(ctx, routing.NewRouteNode(alive))
+	dht.addNode(ctx, routing.NewRouteNode(dead))
+
+	go func() {
+		for request := range mockTp.recv {
+			assert.Equal(t, message.TypePing, request.Type)
+			assert.NotEqual(t, unknown.ID, request.Receiver.ID)
+			if request.Receiver.ID.Equal(alive.ID) {
+				mockTp.send <- &message.Message{Sender: alive, Receiver: request.Sender, Type: message.TypePing, IsResponse: true}
+			}
+		}
+	}()
+
+	dht.verifyLivenessHints(ctx, []*node.Node{alive, dead, unknown})
+
+	assert.Equal(t, 1, dht.NumNodes(ctx))
+	assert.Equal(t, []*node.Node{dead}, dht.hints.recent(maxLivenessHints, time.Minute))
+}
+
+func TestFindNodeWithExclusion(t *testing.T) {
+	id := getIDWithValues(0)
+	st, s, tp, r, err := dhtParams([]node.ID{id}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+
+	dht, _ := NewDHT(st, s, tp, r, &Options{})
+	mockTp := tp.(*mockTransport)
+	ctx := getDefaultCtx(dht)
+
+	addr, _ := node.NewAddress("0.0.0.0:3001")
+	included := &node.Node{ID: getZerodIDWithNthByte(1, byte(1)), Address: addr}
+	excluded := &node.Node{ID: getZerodIDWithNthByte(1, byte(2)), Address: addr}
+	target := getZerodIDWithNthByte(1, byte(3))
+	dht.addNode(ctx, routing.NewRouteNode(included))
+	dht.addNode(ctx, routing.NewRouteNode(excluded))
+
+	go func() {
+		for request := range mockTp.recv {
+			assert.NotEqual(t, excluded.ID, request.Receiver.ID)
+			mockTp.send <- mockFindNodeResponse(request, excluded.ID)
+		}
+	}()
+
+	_, exists, routeSet, err := dht.FindNodeWithExclusion(ctx, target.String(), []node.ID{excluded.ID})
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, []*node.Node{included}, routeSet)
+}
+
+func TestProcessStore_WriteToken(t *testing.T) {
+	st, s, tp, r, err := dhtParams(nil, "0.0.0.0:3000")
+	assert.NoError(t, err)
+
+	dht, _ := NewDHT(st, s, tp, r, &Options{})
+	ctx := getDefaultCtx(dht)
+
+	addr, _ := node.NewAddress("127.0.0.1:3001")
+	sender := &node.Node{ID: getIDWithValues(1), Address: addr}
+	receiver := dht.tables[0].Origin
+	data := []byte("foo")
+
+	request := message.NewBuilder().Sender(sender).Receiver(receiver).Type(message.TypeStore).Request(
+		&message.RequestDataStore{Data: data, Token: []byte("token")}).Build()
+	dht.processStore(ctx, request, message.NewBuilder())
+	_, exists, _ := st.Retrieve(ctx, store.NewKey(data))
+	assert.False(t, exists)
+
+	// Token is bound to the address request was received from
+	request.SetRemoteAddress("127.0.0.2:3001")
+	request.Data.(*message.RequestDataStore).Token = dht.tokens.issue(addr.IP)
+	dht.processStore(ctx, request, message.NewBuilder())
+	_, exists, _ = st.Retrieve(ctx, store.NewKey(data))
+	assert.False(t, exists)
+
+	request.SetRemoteAddress("127.0.0.1:3001")
+	dht.processStore(ctx, request, message.NewBuilder())
+	_, exists, _ = st.Retrieve(ctx, store.NewKey(data))
+	assert.True(t, exists)
+}
+
+func TestProcessStore_Compressed(t *testing.T) {
+	st, s, tp, r, err := dhtParams(nil, "0.0.0.0:3000")
+	assert.NoError(t, err)
+
+	dht, _ := NewDHT(st, s, tp, r, &Options{})
+	ctx := getDefaultCtx(dht)
+
+	addr, _ := node.NewAddress("127.0.0.1:3001")
+	sender := &node.Node{ID: getIDWithValues(1), Address: addr}
+	receiver := dht.tables[0].Origin
+	data := bytes.Repeat([]byte("foo"), 100)
+	compressed, ok := store.CompressValue(data, 1)
+	assert.True(t, ok)
+
+	request := message.NewBuilder().Sender(sender).Receiver(receiver).Type(message.TypeStore).Request(
+		&message.RequestDataStore{Data: compressed, Token: dht.tokens.issue(addr.IP), Compressed: true}).Build()
+	request.SetRemoteAddress("127.0.0.1:3001")
+	dht.processStore(ctx, request, message.NewBuilder())
+	value, exists, _ := st.Retrieve(ctx, store.NewKey(data))
+	assert.True(t, exists)
+	assert.Equal(t, data, value)
+}
+
+func TestNewDHT_RateLimit(t *testing.T) {
+	st, s, tp, r, err := dhtParams(nil, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	_, err = NewDHT(st, s, tp, r, &Options{RateLimit: 1000})
+	assert.EqualError(t, err, "transport does not support rate limiting")
+
+	network := transport.NewInMemoryNetwork(0, 0)
+	st, s, tp, r, err = inMemoryDhtParams(network, nil, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	_, err = NewDHT(st, s, tp, r, &Options{RateLimit: 1000, PeerRateLimit: 100})
+	assert.NoError(t, err)
+}
+
+func TestNewDHT_Retries(t *testing.T) {
+	st, s, tp, r, err := dhtParams(nil, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	_, err = NewDHT(st, s, tp, r, &Options{RetryAttempts: 3})
+	assert.EqualError(t, err, "transport does not support retries")
+
+	network := transport.NewInMemoryNetwork(0, 0)
+	st, s, tp, r, err = inMemoryDhtParams(network, nil, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	_, err = NewDHT(st, s, tp, r, &Options{RetryAttempts: 3, RetryJitter: 0.5})
+	assert.NoError(t, err)
+}
+
+func TestNewDHT_MaxPendingRequests(t *testing.T) {
+	st, s, tp, r, err := dhtParams(nil, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	_, err = NewDHT(st, s, tp, r, &Options{MaxPendingRequests: 10})
+	assert.EqualError(t, err, "transport does not support limiting pending requests")
+
+	network := transport.NewInMemoryNetwork(0, 0)
+	st, s, tp, r, err = inMemoryDhtParams(network, nil, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	_, err = NewDHT(st, s, tp, r, &Options{MaxPendingRequests: 10, PendingRequestsWait: time.Second})
+	assert.NoError(t, err)
+}
+
+func TestNewDHT_SendQueueSize(t *testing.T) {
+	st, s, tp, r, err := dhtParams(nil, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	_, err = NewDHT(st, s, tp, r, &Options{SendQueueSize: 10})
+	assert.EqualError(t, err, "transport does not support send queues")
+
+	network := transport.NewInMemoryNetwork(0, 0)
+	st, s, tp, r, err = inMemoryDhtParams(network, nil, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	_, err = NewDHT(st, s, tp, r, &Options{SendQueueSize: 10})
+	assert.NoError(t, err)
+}
+
+func TestNewDHT_Codec(t *testing.T) {
+	network := transport.NewInMemoryNetwork(0, 0)
+	st, s, tp, r, err := inMemoryDhtParams(network, nil, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	_, err = NewDHT(st, s, tp, r, &Options{Codec: "unknown"})
+	assert.EqualError(t, err, "unknown codec")
+
+	st, s, tp, r, err = inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	_, err = NewDHT(st, s, tp, r, &Options{Codec: "gob"})
+	assert.NoError(t, err)
+}
+
+func TestRemoteProcedureCall_CallContext(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+	})
+
+	calls := make(chan bool, 2)
+	r1.RegisterMethod("call", func(ctx context.Context, sender *node.Node, args [][]byte) ([]byte, error) {
+		call, ok := rpc.CallFromContext(ctx)
+		if ok {
+			assert.Equal(t, sender, call.Sender)
+		}
+		calls <- ok
+		return nil, nil
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+
+	_, err = dht2.RemoteProcedureCall(getDefaultCtx(dht2), dht1.GetOriginID(getDefaultCtx(dht1)), "call", nil)
+	assert.NoError(t, err)
+	assert.True(t, <-calls)
+
+	// Calls to own node are not sent over network
+	_, err = dht1.RemoteProcedureCall(getDefaultCtx(dht1), dht1.GetOriginID(getDefaultCtx(dht1)), "call", nil)
+	assert.NoError(t, err)
+	assert.False(t, <-calls)
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
+
+func TestRemoteProcedureCall_Headers(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+	})
+
+	headers := make(chan map[string]string, 1)
+	r1.RegisterMethod("call", func(ctx context.Context, sender *node.Node, args [][]byte) ([]byte, error) {
+		call, _ := rpc.CallFromContext(ctx)
+		headers <- call.Headers
+		return nil, nil
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+
+	_, err = NewContextBuilder(dht2).SetDefaultNode().SetHeaders(map[string]string{
+		"trace": strings.Repeat("x", message.MaxHeadersSize),
+	}).Build()
+	assert.EqualError(t, err, "headers are too large")
+
+	ctx, err := NewContextBuilder(dht2).SetDefaultNode().SetHeaders(map[string]string{"tenant": "acme"}).Build()
+	assert.NoError(t, err)
+	_, err = dht2.RemoteProcedureCall(ctx, dht1.GetOriginID(getDefaultCtx(dht1)), "call", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, <-headers)
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
+
+func TestDHT_ReadOnly(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+	})
+
+	calls := 0
+	r1.RegisterMethod("call", func(ctx context.Context, sender *node.Node, args [][]byte) ([]byte, error) {
+		calls++
+		return nil, nil
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+
+	dht1.SetReadOnly(true)
+	assert.True(t, dht1.IsReadOnly())
+
+	ctx := getDefaultCtx(dht2)
+	target := dht1.GetOriginID(getDefaultCtx(dht1))
+
+	_, err = dht2.RemoteProcedureCall(ctx, target, "call", nil)
+	assert.Equal(t, ErrReadOnly, err)
+	assert.Equal(t, 0, calls)
+
+	_, receipts, err := dht2.StoreWithReceipts(ctx, []byte("foo"))
+	assert.NoError(t, err)
+	for _, receipt := range receipts {
+		assert.NotEqual(t, id1[0], receipt.Holder)
+	}
+	_, stored, _ := st1.Retrieve(context.Background(), store.NewKey([]byte("foo")))
+	assert.False(t, stored)
+
+	// Routing keeps working
+	found, exists, err := dht2.FindNode(ctx, target)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, id1[0], found.ID)
+
+	dht1.SetReadOnly(false)
+	_, err = dht2.RemoteProcedureCall(ctx, target, "call", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
+
+func TestDHT_MaxValueSize(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{MaxValueSize: 3})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+
+	_, err = dht1.Store(getDefaultCtx(dht1), []byte("large"))
+	assert.Equal(t, store.ErrTooLarge, err)
+	_, stored, _ := st1.Retrieve(context.Background(), store.NewKey([]byte("large")))
+	assert.False(t, stored)
+
+	// Node accepts values within limit only
+	_, receipts, err := dht2.StoreWithReceipts(getDefaultCtx(dht2), []byte("large"))
+	assert.NoError(t, err)
+	assert.Empty(t, receipts)
+	_, stored, _ = st1.Retrieve(context.Background(), store.NewKey([]byte("large")))
+	assert.False(t, stored)
+
+	_, receipts, err = dht2.StoreWithReceipts(getDefaultCtx(dht2), []byte("foo"))
+	assert.NoError(t, err)
+	assert.Len(t, receipts, 1)
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
+
+func TestDHT_Namespace(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	namespaced := store.NewNamespacedStore(st1, nil)
+	dht1, _ := NewDHT(namespaced, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+
+	_, err = NewContextBuilder(dht2).SetDefaultNode().SetNamespace(strings.Repeat("a", store.MaxNamespaceLength+1)).Build()
+	assert.EqualError(t, err, "namespace is too long")
+	ctx, err := NewContextBuilder(dht2).SetDefaultNode().SetNamespace("app").Build()
+	assert.NoError(t, err)
+
+	data := []byte("foo")
+	key, err := dht2.Store(ctx, data)
+	assert.NoError(t, err)
+	expected := store.NamespacedKey(store.NewKey, "app", data)
+	assert.Equal(t, base58.Encode(expected), key)
+
+	// Holder keeps value in namespace of publisher
+	assert.Equal(t, []store.Key{expected}, namespaced.(store.Namespaced).Keys("app"))
+	value, exists, err := dht2.Get(getDefaultCtx(dht2), key)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, data, value)
+
+	// Replicas are stored in the same namespace
+	assert.NoError(t, st2.Delete(ctx, expected))
+	dht1.replicate(getDefaultCtx(dht1), []store.Key{expected})
+	_, exists, _ = st2.Retrieve(ctx, expected)
+	assert.True(t, exists)
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
+
+func TestDHT_StoreVersion(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+
+	// First node already holds newer version of value
+	data := []byte("foo")
+	key := store.NewKey(data)
+	newer := store.NewVersion(time.Now().Add(time.Hour), id1[0])
+	expiration := time.Now().Add(time.Hour)
+	stored, err := st1.(store.Versioned).StoreVersion(context.Background(), key, data, expiration, expiration, newer)
+	assert.NoError(t, err)
+	assert.True(t, stored)
+
+	_, receipts, err := dht2.StoreWithReceipts(getDefaultCtx(dht2), data)
+	assert.NoError(t, err)
+	assert.Len(t, receipts, 1)
+
+	version, _, err := st1.(store.Versioned).Version(context.Background(), key)
+	assert.NoError(t, err)
+	assert.True(t, newer.Timestamp.Equal(version.Timestamp))
+
+	// Sender adopts the newer version
+	version, _, err = st2.(store.Versioned).Version(context.Background(), key)
+	assert.NoError(t, err)
+	assert.True(t, newer.Timestamp.Equal(version.Timestamp))
+	assert.Equal(t, id1[0], version.Publisher)
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
+
+func TestDHT_ConflictResolver(t *testing.T) {
+	st, s, tp, r, err := dhtParams([]node.ID{getIDWithValues(0)}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+
+	// The first written replica is kept
+	firstWriteWins := func(key store.Key, current, candidate store.Entry) bool {
+		return current.Version.Newer(candidate.Version)
+	}
+	dht, _ := NewDHT(st, s, tp, r, &Options{ConflictResolver: firstWriteWins})
+	ctx := getDefaultCtx(dht)
+
+	key := store.NewKey([]byte("foo"))
+	expiration := time.Now().Add(time.Hour)
+	older := store.NewVersion(time.Now(), getIDWithValues(1))
+	newer := store.NewVersion(time.Now().Add(time.Minute), getIDWithValues(1))
+	stored, err := dht.storeVersion(ctx, key, []byte("older"), expiration, expiration, false, older)
+	assert.NoError(t, err)
+	assert.True(t, stored)
+	stored, err = dht.storeVersion(ctx, key, []byte("newer"), expiration, expiration, false, newer)
+	assert.NoError(t, err)
+	assert.False(t, stored)
+
+	data, found := dht.retrieve(ctx, key)
+	assert.True(t, found)
+	assert.Equal(t, []byte("older"), data)
+	assert.Equal(t, older, dht.versionOf(ctx, key))
+}
+
+func TestDHT_GetResolvesReplicas(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	// Keys are derived from the first three bytes of values, so replicas with different data can conflict
+	keyHash := func(data []byte) store.Key {
+		return store.NewKey(data[:3])
+	}
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{KeyHash: keyHash})
+
+	var dhts = []*DHT{dht1}
+	for _, address := range []string{"127.0.0.1:3001", "127.0.0.1:3002"} {
+		st, s, tp, r, err := inMemoryDhtParams(network, nil, address)
+		assert.NoError(t, err)
+		dht, _ := NewDHT(st, s, tp, r, &Options{
+			BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+			KeyHash:        keyHash,
+		})
+		dhts = append(dhts, dht)
+	}
+
+	for _, dht := range dhts {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+	for _, dht := range dhts[1:] {
+		assert.NoError(t, dht.Bootstrap())
+	}
+	dht2, dht3 := dhts[1], dhts[2]
+
+	// Replicas of the first and the second node conflict
+	key := store.NewKey([]byte("foo"))
+	expiration := time.Now().Add(time.Hour)
+	older := store.NewVersion(time.Now(), id1[0])
+	newer := store.NewVersion(time.Now().Add(time.Minute), id1[0])
+	_, err = dht1.storeVersion(getDefaultCtx(dht1), key, []byte("foo older"), expiration, expiration, false, older)
+	assert.NoError(t, err)
+	_, err = dht2.storeVersion(getDefaultCtx(dht2), key, []byte("foo newer"), expiration, expiration, false, newer)
+	assert.NoError(t, err)
+
+	// Both of them answer the first round of lookup and the newer one wins
+	data, found, err := dht3.Get(getDefaultCtx(dht3), base58.Encode(key))
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("foo newer"), data)
+
+	for _, dht := range dhts {
+		dht.Disconnect()
+		<-done
+	}
+}
+
+func TestDHT_GetVerifiesValue(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	namespaced := store.NewNamespacedStore(st1, nil)
+	dht1, _ := NewDHT(namespaced, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+	assert.NoError(t, dht2.Bootstrap())
+
+	// Holder returns data which is not value of requested key
+	expiration := time.Now().Add(time.Hour)
+	forged := store.NewKey([]byte("foo"))
+	assert.NoError(t, namespaced.Store(getDefaultCtx(dht1), forged, []byte("bar"), expiration, expiration, false))
+	_, found, err := dht2.Get(getDefaultCtx(dht2), base58.Encode(forged))
+	assert.NoError(t, err)
+	assert.False(t, found)
+	_, matches := dht1.keyNamespace(getDefaultCtx(dht1), forged, []byte("bar"), "")
+	assert.False(t, matches)
+
+	// Value of namespace is checked with namespace reported by holder
+	ctx := store.WithNamespace(getDefaultCtx(dht1), "app")
+	key := store.NamespacedKey(store.NewKey, "app", []byte("foo"))
+	assert.NoError(t, namespaced.Store(ctx, key, []byte("foo"), expiration, expiration, false))
+	data, found, err := dht2.Get(getDefaultCtx(dht2), base58.Encode(key))
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("foo"), data)
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
+
+func TestDHT_Validator(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	validator := store.ValidatorFunc(func(ctx context.Context, key store.Key, data []byte) error {
+		if !bytes.HasPrefix(data, []byte("{")) {
+			return store.ErrInvalidRecord
+		}
+		return nil
+	})
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{Validator: validator})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+	assert.NoError(t, dht2.Bootstrap())
+
+	// Node doesn't publish invalid records
+	_, err = dht1.Store(getDefaultCtx(dht1), []byte("foo"))
+	assert.Equal(t, store.ErrInvalidRecord, err)
+
+	// Valid record is accepted from other node
+	key, err := dht2.Store(getDefaultCtx(dht2), []byte("{}"))
+	assert.NoError(t, err)
+	_, exists, _ := st1.Retrieve(getDefaultCtx(dht1), base58.Decode(key))
+	assert.True(t, exists)
+
+	// Invalid record is neither stored nor returned by lookup
+	key, err = dht2.Store(getDefaultCtx(dht2), []byte("bar"))
+	assert.NoError(t, err)
+	_, exists, _ = st1.Retrieve(getDefaultCtx(dht1), base58.Decode(key))
+	assert.False(t, exists)
+	_, exists, err = dht1.Get(getDefaultCtx(dht1), key)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
+
+func TestDHT_ReplicationFactor(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+	assert.Equal(t, routing.MaxContactsInBucket, dht1.options.ReplicationFactor)
+
+	var dhts = []*DHT{dht1}
+	for _, address := range []string{"127.0.0.1:3001", "127.0.0.1:3002", "127.0.0.1:3003"} {
+		st, s, tp, r, err := inMemoryDhtParams(network, nil, address)
+		assert.NoError(t, err)
+		dht, _ := NewDHT(st, s, tp, r, &Options{
+			BootstrapNodes:    []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+			ReplicationFactor: 2,
+		})
+		dhts = append(dhts, dht)
+	}
+
+	for _, dht := range dhts {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+	for _, dht := range dhts[1:] {
+		assert.NoError(t, dht.Bootstrap())
+	}
+
+	// Value is stored at two closest nodes only
+	dht4 := dhts[3]
+	ctx := getDefaultCtx(dht4)
+	_, closest, err := dht4.iterate(ctx, routing.IterateStore, store.NewKey([]byte("foo")), nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, closest, 2)
+
+	for _, dht := range dhts {
+		dht.Disconnect()
+		<-done
+	}
+}
+
+type evictByID struct {
+	id node.ID
+}
+
+func (p *evictByID) ShouldEvict(old, new *routing.RouteNode) bool {
+	return old.ID.Equal(p.id)
+}
+
+func TestAddNode_EvictionPolicy(t *testing.T) {
+	st, s, tp, r, err := dhtParams([]node.ID{getIDWithValues(0)}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+
+	evicted := getZerodIDWithNthByte(1, byte(255-5))
+	dht, _ := NewDHT(st, s, tp, r, &Options{EvictionPolicy: &evictByID{id: evicted}})
+	ctx := getDefaultCtx(dht)
+
+	ht := dht.tables[0]
+	index := routing.GetBucketIndexFromDifferingBit(ht.Origin.ID, evicted)
+	for i := 0; i < routing.MaxContactsInBucket; i++ {
+		id := getZerodIDWithNthByte(1, byte(255-i))
+		ht.RoutingTable[index] = append(ht.RoutingTable[index], routing.NewRouteNode(&node.Node{ID: id}))
+	}
+
+	// Node allowed by policy is replaced without ping, mock transport would block on it
+	newNode := routing.NewRouteNode(&node.Node{ID: getZerodIDWithNthByte(1, byte(255-routing.MaxContactsInBucket))})
+	dht.addNode(ctx, newNode)
+
+	bucket := ht.RoutingTable[index]
+	assert.Len(t, bucket, routing.MaxContactsInBucket)
+	assert.False(t, ht.DoesNodeExistInBucket(index, evicted))
+	assert.Equal(t, newNode, bucket[len(bucket)-1])
+	assert.Equal(t, getZerodIDWithNthByte(1, byte(255-6)), bucket[5].ID)
+}
+
+func TestNewDHT_Locality(t *testing.T) {
+	ids, _ := node.NewIDs(2)
+	st, s, tp, r, err := dhtParams(ids, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	locality := node.Locality{Region: "eu-west", Zone: "a"}
+	dht, _ := NewDHT(st, s, tp, r, &Options{Locality: locality})
+
+	// Locality is advertised with every ID
+	for _, ht := range dht.tables {
+		assert.Equal(t, locality, ht.Origin.Locality)
+	}
+}
+
+func TestNewDHT_Relay(t *testing.T) {
+	ids, _ := node.NewIDs(2)
+	st, s, tp, r, err := dhtParams(ids, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	relay, _ := node.NewAddress("127.0.0.1:3001")
+	dht, _ := NewDHT(st, s, tp, r, &Options{Relay: relay})
+
+	// Relay is advertised with every ID
+	for _, ht := range dht.tables {
+		assert.Equal(t, relay, ht.Origin.Relay)
+	}
+	assert.EqualError(t, dht.Listen(), "transport does not support relay")
+
+	st, s, tp, r, err = dhtParams(nil, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	_, err = NewDHT(st, s, tp, r, &Options{RelayCircuits: 10})
+	assert.EqualError(t, err, "transport does not support relay")
+
+	st, s, tp, r, err = dhtParams(nil, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	_, err = NewDHT(st, s, tp, r, &Options{HolePunching: true})
+	assert.EqualError(t, err, "transport does not support hole punching")
+}
+
+func TestDHT_VirtualClock(t *testing.T) {
+	v := clock.NewVirtual(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+	network.SetClock(v)
+
+	ids, _ := node.NewIDs(1)
+	_, s, tp, r, err := inMemoryDhtParams(network, ids, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	rounds := make(chan RefreshRound, 1)
+	dht, _ := NewDHT(store.NewMemoryStoreWithClock(v), s, tp, r, &Options{
+		Clock:       v,
+		RefreshTime: time.Hour,
+		OnRefresh: func(round RefreshRound) {
+			rounds <- round
+		},
+	})
+
+	done := make(chan bool)
+	go func() {
+		dht.Listen()
+		done <- true
+	}()
+
+	// Wait for store timers ticker
+	for v.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Hours of virtual time pass at once
+	v.Advance(time.Hour * 2)
+	round := <-rounds
+	assert.Equal(t, v.Now(), round.Started)
+	assert.Equal(t, routing.KeyBitSize, round.Buckets)
+
+	dht.Disconnect()
+	<-done
+}
+
+func TestNewDHT_Keepalive(t *testing.T) {
+	st, s, tp, r, err := dhtParams(nil, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	_, err = NewDHT(st, s, tp, r, &Options{KeepaliveInterval: time.Second})
+	assert.EqualError(t, err, "transport does not support keepalive")
+}
+
+func TestDHT_EvictDeadPeer(t *testing.T) {
+	st, s, tp, r, err := dhtParams([]node.ID{getIDWithValues(0)}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	dht, _ := NewDHT(st, s, tp, r, &Options{})
+
+	deadAddr, _ := node.NewAddress("127.0.0.1:3001")
+	aliveAddr, _ := node.NewAddress("127.0.0.1:3002")
+	dead := &node.Node{ID: getZerodIDWithNthByte(19, 4), Address: deadAddr}
+	alive := &node.Node{ID: getZerodIDWithNthByte(19, 5), Address: aliveAddr}
+	ht := dht.tables[0]
+	index := routing.GetBucketIndexFromDifferingBit(ht.Origin.ID, dead.ID)
+	ht.RoutingTable[index] = []*routing.RouteNode{routing.NewRouteNode(dead), routing.NewRouteNode(alive)}
+
+	dht.evictDeadPeer("127.0.0.1:3001")
+
+	assert.Len(t, ht.RoutingTable[index], 1)
+	assert.Equal(t, alive, ht.RoutingTable[index][0].Node)
+	assert.True(t, dht.hints.isFailed(dead.ID))
+	assert.False(t, dht.hints.isFailed(alive.ID))
+}
+
 func getZerodIDWithNthByte(n int, v byte) node.ID {
 	id := getIDWithValues(0)
 	id[n] = v
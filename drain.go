@@ -0,0 +1,96 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// drainInterval is how often drain checks whether DHT is idle
+const drainInterval = time.Millisecond * 10
+
+// incomingRequests counts incoming requests being processed and refuses new ones once draining started
+type incomingRequests struct {
+	mutex    *sync.Mutex
+	draining bool
+	active   int
+}
+
+func newIncomingRequests() *incomingRequests {
+	return &incomingRequests{
+		mutex: &sync.Mutex{},
+	}
+}
+
+// acquire registers new request, returns false if DHT is draining
+func (ir *incomingRequests) acquire() bool {
+	ir.mutex.Lock()
+	defer ir.mutex.Unlock()
+
+	if ir.draining {
+		return false
+	}
+	ir.active++
+	return true
+}
+
+// release marks request as processed
+func (ir *incomingRequests) release() {
+	ir.mutex.Lock()
+	defer ir.mutex.Unlock()
+
+	ir.active--
+}
+
+// count returns number of requests being processed
+func (ir *incomingRequests) count() int {
+	ir.mutex.Lock()
+	defer ir.mutex.Unlock()
+
+	return ir.active
+}
+
+func (ir *incomingRequests) startDraining() {
+	ir.mutex.Lock()
+	defer ir.mutex.Unlock()
+
+	ir.draining = true
+}
+
+func (ir *incomingRequests) idle() bool {
+	ir.mutex.Lock()
+	defer ir.mutex.Unlock()
+
+	return ir.active == 0
+}
+
+// drain refuses new incoming requests and waits until processed requests are responded
+// and sent requests are resolved or timeout expires
+func (dht *DHT) drain(timeout time.Duration) {
+	dht.incoming.startDraining()
+
+	deadline := time.Now().Add(timeout)
+	for !dht.incoming.idle() || len(dht.transport.PendingRequests()) > 0 {
+		if time.Now().After(deadline) {
+			log.Println("Failed to drain transport: timeout exceeded")
+			return
+		}
+		time.Sleep(drainInterval)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncomingRequests(t *testing.T) {
+	ir := newIncomingRequests()
+	assert.True(t, ir.idle())
+
+	assert.True(t, ir.acquire())
+	assert.False(t, ir.idle())
+
+	ir.startDraining()
+	assert.False(t, ir.acquire())
+
+	ir.release()
+	assert.True(t, ir.idle())
+}
+
+func TestDisconnect_Drain(t *testing.T) {
+	st, s, tp, r, err := dhtParams([]node.ID{getIDWithValues(0)}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	dht, _ := NewDHT(st, s, tp, r, &Options{DrainTimeout: time.Second})
+	mockTp := tp.(*mockTransport)
+
+	// Request is being processed
+	assert.True(t, dht.incoming.acquire())
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		dht.incoming.release()
+	}()
+
+	started := time.Now()
+	dht.Disconnect()
+
+	assert.True(t, time.Since(started) >= time.Millisecond*100)
+	assert.True(t, time.Since(started) < time.Second)
+	assert.False(t, dht.incoming.acquire())
+	<-mockTp.Stopped()
+}
+
+func TestDisconnect_DrainTimeout(t *testing.T) {
+	st, s, tp, r, err := dhtParams([]node.ID{getIDWithValues(0)}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	dht, _ := NewDHT(st, s, tp, r, &Options{DrainTimeout: time.Millisecond * 100})
+	mockTp := tp.(*mockTransport)
+
+	// Sent request never gets response
+	mockTp.pending = []transport.Future{&mockFuture{request: &message.Message{}}}
+
+	started := time.Now()
+	dht.Disconnect()
+
+	assert.True(t, time.Since(started) >= time.Millisecond*100)
+	<-mockTp.Stopped()
+}
@@ -0,0 +1,258 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/insolar/network/store"
+	"github.com/jbenet/go-base58"
+)
+
+// ExpiryStats counts values held by the node ctx is bound to by time left to their expiration,
+// expired values which were not collected yet are reported as garbage
+func (dht *DHT) ExpiryStats(ctx Context) (store.ExpiryStats, error) {
+	enumerator, ok := dht.storeFor(ctx).(store.Enumerator)
+	if !ok {
+		return store.ExpiryStats{}, errors.New("store does not support enumeration")
+	}
+	return store.CountExpiry(enumerator.Entries(), dht.options.Clock.Now()), nil
+}
+
+// expiryRepublishInterval is minimal interval between publications of value caused by expiry notifications
+const expiryRepublishInterval = time.Minute
+
+// republishRetryInterval is interval between attempts to republish value which other nodes did not confirm
+const republishRetryInterval = time.Minute
+
+// publication is value published by node itself
+type publication struct {
+	key         store.Key
+	expiration  time.Time
+	warned      bool
+	republished time.Time
+	publishedAt time.Time
+	attempted   time.Time
+}
+
+// publications tracks expiration of values published by node, so application can be warned
+// about ones going to expire without being published again
+type publications struct {
+	mutex        *sync.Mutex
+	publications map[string]*publication
+}
+
+func newPublications() *publications {
+	return &publications{
+		mutex:        &sync.Mutex{},
+		publications: make(map[string]*publication),
+	}
+}
+
+// published records publication of value at now. Expiration and publication time of value
+// which was published before are only updated if other nodes confirmed they store it.
+func (p *publications) published(key store.Key, expiration time.Time, confirmed bool, now time.Time) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	current, ok := p.publications[key.String()]
+	if ok && !confirmed {
+		return
+	}
+	pub := &publication{key: key, expiration: expiration, publishedAt: now}
+	if ok {
+		pub.republished = current.republished
+	}
+	p.publications[key.String()] = pub
+}
+
+// replicaExpired reports if value expired on other node was published by node and if it should be
+// published again. It is published again at most once per expiryRepublishInterval however many holders report it.
+func (p *publications) replicaExpired(key store.Key, now time.Time) (published bool, republish bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	pub, ok := p.publications[key.String()]
+	if !ok {
+		return false, false
+	}
+	if now.Before(pub.republished.Add(expiryRepublishInterval)) {
+		return true, false
+	}
+	pub.republished = now
+	return true, true
+}
+
+// due returns keys of values published at least interval ago which did not expire yet.
+// Value which was not published again is returned once per republishRetryInterval.
+func (p *publications) due(now time.Time, interval time.Duration) []store.Key {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var keys []store.Key
+	for _, pub := range p.publications {
+		if now.After(pub.expiration) || now.Before(pub.publishedAt.Add(interval)) ||
+			now.Before(pub.attempted.Add(republishRetryInterval)) {
+			continue
+		}
+		pub.attempted = now
+		keys = append(keys, pub.key)
+	}
+	return keys
+}
+
+// forget forgets publication of deleted value
+func (p *publications) forget(key store.Key) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.publications, key.String())
+}
+
+// expiring returns publications expiring before deadline which were not reported yet,
+// expired publications are forgotten
+func (p *publications) expiring(now, deadline time.Time) []publication {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var expiring []publication
+	for k, pub := range p.publications {
+		if now.After(pub.expiration) {
+			delete(p.publications, k)
+			continue
+		}
+		if !pub.warned && pub.expiration.Before(deadline) {
+			pub.warned = true
+			expiring = append(expiring, *pub)
+		}
+	}
+	return expiring
+}
+
+func (dht *DHT) publicationsFor(ctx Context) *publications {
+	return dht.publications[ctx.Value(ctxTableIndex).(int)]
+}
+
+// republish publishes values published by node again every RepublishTime,
+// so they outlive churn of their holders and their expiration
+func (dht *DHT) republish(ctx Context) {
+	for _, key := range dht.publicationsFor(ctx).due(dht.options.Clock.Now(), dht.options.RepublishTime) {
+		data, held := dht.retrieve(ctx, key)
+		if !held {
+			continue
+		}
+		if err := dht.storeAgain(ctx, key, data); err != nil {
+			log.Println("Failed to republish value:", err.Error())
+		}
+	}
+}
+
+// warnExpiring reports values published by node which expire within ExpiryWarning
+func (dht *DHT) warnExpiring(ctx Context) {
+	now := dht.options.Clock.Now()
+	for _, pub := range dht.publicationsFor(ctx).expiring(now, now.Add(dht.options.ExpiryWarning)) {
+		log.Println("Published value expires without being published again:", base58.Encode(pub.key))
+		if dht.options.OnExpiringSoon != nil {
+			dht.options.OnExpiringSoon(pub.key, pub.expiration)
+		}
+	}
+}
+
+// notifyExpired sends proof of expiry to publishers of values node expired if NotifyExpiry option is set
+func (dht *DHT) notifyExpired(ctx Context) {
+	expired := dht.owners.expire(dht.options.Clock.Now())
+	if !dht.options.NotifyExpiry {
+		return
+	}
+
+	ht := dht.htFromCtx(ctx)
+	for _, owner := range expired {
+		if len(owner.publisher) == 0 || owner.publisher.Equal(ht.Origin.ID) {
+			continue
+		}
+		if _, held := dht.retrieve(ctx, owner.key); held {
+			// Value was stored again without publisher
+			continue
+		}
+
+		publisher, exists, err := dht.FindNode(ctx, owner.publisher.String())
+		if err != nil || !exists {
+			continue
+		}
+		request := message.NewBuilder().Sender(ht.Origin).Receiver(publisher).Type(message.TypeExpired).Request(
+			&message.RequestDataExpired{
+				Receipt: store.NewReceipt(owner.key, ht.Origin.ID, owner.expiration, dht.options.PrivateKey),
+			}).Build()
+
+		future, err := dht.sendRequest(ctx, request)
+		if err != nil {
+			log.Println("Failed to send expiry notification:", err.Error())
+			continue
+		}
+		go dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+	}
+}
+
+func (dht *DHT) processExpired(ctx Context, msg *message.Message, messageBuilder message.Builder) {
+	data := msg.Data.(*message.RequestDataExpired)
+	dht.addNode(ctx, routing.NewRouteNode(msg.Sender))
+	response := &message.ResponseDataExpired{}
+
+	receipt := data.Receipt
+	now := dht.options.Clock.Now()
+	if receipt == nil || !receipt.Verify() || !receipt.Holder.Equal(msg.Sender.ID) || now.Before(receipt.Expiration) {
+		log.Println("Rejected invalid expiry notification from", msg.Sender)
+	} else {
+		response.Success = dht.replicaExpired(ctx, receipt.Key, receipt.Holder)
+	}
+
+	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
+	if err != nil {
+		log.Println("Failed to send response:", err.Error())
+	}
+}
+
+// replicaExpired publishes value expired on holder again if node published it and still holds it.
+// It returns false if value was not published by node.
+func (dht *DHT) replicaExpired(ctx Context, key store.Key, holder node.ID) bool {
+	published, republish := dht.publicationsFor(ctx).replicaExpired(key, dht.options.Clock.Now())
+	if !published {
+		return false
+	}
+	if dht.options.OnReplicaExpired != nil {
+		dht.options.OnReplicaExpired(key, holder)
+	}
+	if !republish {
+		return true
+	}
+	data, held := dht.retrieve(ctx, key)
+	if !held {
+		return true
+	}
+	go func() {
+		if err := dht.storeAgain(ctx, key, data); err != nil {
+			log.Println("Failed to publish expired value again:", err.Error())
+		}
+	}()
+	return true
+}
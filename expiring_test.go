@@ -0,0 +1,249 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/clock"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublications(t *testing.T) {
+	p := newPublications()
+	now := time.Now()
+	key := store.NewKey([]byte("foo"))
+
+	p.published(key, now.Add(time.Minute), false, now)
+	// Unconfirmed publication doesn't extend expiration
+	p.published(key, now.Add(time.Hour), false, now)
+	assert.Empty(t, p.expiring(now, now.Add(time.Second)))
+
+	expiring := p.expiring(now, now.Add(2*time.Minute))
+	assert.Len(t, expiring, 1)
+	assert.Equal(t, key, expiring[0].key)
+	// Publication is reported once
+	assert.Empty(t, p.expiring(now, now.Add(2*time.Minute)))
+
+	p.published(key, now.Add(time.Hour), true, now)
+	assert.Empty(t, p.expiring(now, now.Add(2*time.Minute)))
+	assert.Empty(t, p.expiring(now.Add(2*time.Hour), now.Add(3*time.Hour)))
+	assert.Empty(t, p.publications)
+}
+
+func TestPublications_ReplicaExpired(t *testing.T) {
+	p := newPublications()
+	now := time.Now()
+	key := store.NewKey([]byte("foo"))
+
+	published, _ := p.replicaExpired(key, now)
+	assert.False(t, published)
+
+	p.published(key, now.Add(time.Hour), true, now)
+	published, republish := p.replicaExpired(key, now)
+	assert.True(t, published)
+	assert.True(t, republish)
+
+	// Value is published again once however many holders report expiry
+	p.published(key, now.Add(time.Hour), true, now)
+	_, republish = p.replicaExpired(key, now.Add(time.Second))
+	assert.False(t, republish)
+	_, republish = p.replicaExpired(key, now.Add(expiryRepublishInterval))
+	assert.True(t, republish)
+}
+
+func TestPublications_Due(t *testing.T) {
+	p := newPublications()
+	now := time.Now()
+	key := store.NewKey([]byte("foo"))
+
+	p.published(key, now.Add(2*time.Hour), true, now)
+	assert.Empty(t, p.due(now.Add(time.Minute), time.Hour))
+	assert.Equal(t, []store.Key{key}, p.due(now.Add(time.Hour), time.Hour))
+
+	// Value which was not published again is retried later
+	assert.Empty(t, p.due(now.Add(time.Hour+time.Second), time.Hour))
+	assert.Equal(t, []store.Key{key}, p.due(now.Add(time.Hour+republishRetryInterval), time.Hour))
+
+	p.published(key, now.Add(3*time.Hour), true, now.Add(time.Hour+republishRetryInterval))
+	assert.Empty(t, p.due(now.Add(2*time.Hour), time.Hour))
+
+	// Expired value is not published again
+	assert.Empty(t, p.due(now.Add(4*time.Hour), time.Hour))
+}
+
+func TestDHT_Republish(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+		RepublishTime:  time.Millisecond * 50,
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+
+	data := []byte("foo")
+	key := store.NewKey(data)
+	ctx := getDefaultCtx(dht2)
+	_, err = dht2.Store(ctx, data)
+	assert.NoError(t, err)
+
+	// Holder lost the value
+	assert.NoError(t, st1.Delete(getDefaultCtx(dht1), key))
+	dht2.republish(ctx)
+	_, found, _ := st1.Retrieve(getDefaultCtx(dht1), key)
+	assert.False(t, found)
+
+	time.Sleep(time.Millisecond * 60)
+	dht2.republish(ctx)
+	_, found, _ = st1.Retrieve(getDefaultCtx(dht1), key)
+	assert.True(t, found)
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
+
+func TestDHT_NotifyExpiry(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{NotifyExpiry: true})
+
+	expired := make(chan node.ID, 1)
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+		OnReplicaExpired: func(key store.Key, holder node.ID) {
+			expired <- holder
+		},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+
+	data := []byte("foo")
+	key := store.NewKey(data)
+	_, err = dht2.Store(getDefaultCtx(dht2), data)
+	assert.NoError(t, err)
+
+	// Value expires on holder
+	ctx := getDefaultCtx(dht1)
+	ownership := store.NewOwnership(key, time.Now(), dht2.options.PrivateKey)
+	assert.NoError(t, st1.Delete(ctx, key))
+	dht1.owners.forget(key)
+	dht1.owners.claim(ownership, dht2.origin.IDs[0], time.Now(), time.Now())
+	dht1.notifyExpired(ctx)
+
+	select {
+	case holder := <-expired:
+		assert.Equal(t, id1[0], holder)
+	case <-time.After(time.Second):
+		assert.Fail(t, "expiry is not reported")
+	}
+	// Publisher stores value again
+	found := false
+	for i := 0; i < 100 && !found; i++ {
+		time.Sleep(10 * time.Millisecond)
+		_, found, _ = st1.Retrieve(ctx, key)
+	}
+	assert.True(t, found)
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
+
+func TestDHT_ExpiryStats(t *testing.T) {
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+	virtual := clock.NewVirtual(time.Now())
+	st, s, tp, r, err := inMemoryDhtParams(network, nil, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht, _ := NewDHT(st, s, tp, r, &Options{Clock: virtual})
+	ctx := getDefaultCtx(dht)
+
+	data := []byte("foo")
+	assert.NoError(t, st.Store(ctx, store.NewKey(data), data, virtual.Now(), virtual.Now().Add(time.Minute), true))
+	stats, err := dht.ExpiryStats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.Keys[0])
+	assert.Equal(t, 0, stats.Garbage)
+
+	virtual.Advance(time.Hour)
+	stats, err = dht.ExpiryStats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.Garbage)
+	assert.Equal(t, len(data), stats.GarbageBytes)
+}
+
+func TestDHT_OnExpiringSoon(t *testing.T) {
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+	virtual := clock.NewVirtual(time.Now())
+	var warned []store.Key
+	st, s, tp, r, err := inMemoryDhtParams(network, nil, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht, _ := NewDHT(st, s, tp, r, &Options{
+		Clock:         virtual,
+		ExpiryWarning: time.Minute,
+		OnExpiringSoon: func(key store.Key, expiration time.Time) {
+			warned = append(warned, key)
+		},
+	})
+	ctx := getDefaultCtx(dht)
+
+	key := store.NewKey([]byte("foo"))
+	dht.publicationsFor(ctx).published(key, virtual.Now().Add(time.Hour), false, virtual.Now())
+	dht.warnExpiring(ctx)
+	assert.Empty(t, warned)
+
+	virtual.Advance(time.Hour - time.Second)
+	dht.warnExpiring(ctx)
+	dht.warnExpiring(ctx)
+	assert.Equal(t, []store.Key{key}, warned)
+}
@@ -0,0 +1,102 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"sync"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+)
+
+// identityLookup is a result of lookup performed with one of local IDs
+type identityLookup struct {
+	value    []byte
+	node     *node.Node
+	found    bool
+	routeSet []*node.Node
+	err      error
+}
+
+// GetFromAllIdentities retrieves data the same way Get does, but with all local IDs concurrently.
+// Value found with any of IDs is returned along with merged route sets of all lookups.
+// Error is returned only if lookups with all IDs failed.
+func (dht *DHT) GetFromAllIdentities(key string) ([]byte, bool, []*node.Node, error) {
+	result := dht.lookupWithAllIdentities(func(ctx Context) identityLookup {
+		value, found, routeSet, err := dht.GetWithExclusion(ctx, key, nil)
+		return identityLookup{value: value, found: found, routeSet: routeSet, err: err}
+	})
+	return result.value, result.found, result.routeSet, result.err
+}
+
+// FindNodeFromAllIdentities finds target node the same way FindNode does, but with all local IDs
+// concurrently. Node found with any of IDs is returned along with merged route sets of all lookups.
+// Error is returned only if lookups with all IDs failed.
+func (dht *DHT) FindNodeFromAllIdentities(key string) (*node.Node, bool, []*node.Node, error) {
+	result := dht.lookupWithAllIdentities(func(ctx Context) identityLookup {
+		targetNode, found, routeSet, err := dht.FindNodeWithExclusion(ctx, key, nil)
+		return identityLookup{node: targetNode, found: found, routeSet: routeSet, err: err}
+	})
+	return result.node, result.found, result.routeSet, result.err
+}
+
+// lookupWithAllIdentities runs lookup with every local ID concurrently and merges results.
+// The first successful result in IDs order is returned with route sets of all lookups merged.
+func (dht *DHT) lookupWithAllIdentities(lookup func(ctx Context) identityLookup) identityLookup {
+	results := make([]identityLookup, len(dht.tables))
+	wg := &sync.WaitGroup{}
+	cb := NewContextBuilder(dht)
+
+	for i, ht := range dht.tables {
+		ctx, err := cb.SetNodeByID(ht.Origin.ID).Build()
+		if err != nil {
+			results[i] = identityLookup{err: err}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, ctx Context) {
+			defer wg.Done()
+			results[i] = lookup(ctx)
+		}(i, ctx)
+	}
+	wg.Wait()
+
+	var merged *identityLookup
+	routeSet := routing.NewRouteSet()
+	for i := range results {
+		result := &results[i]
+		if result.err != nil {
+			continue
+		}
+		routeSet.Extend(routing.RouteNodesFrom(result.routeSet))
+		if merged == nil || result.found && !merged.found {
+			merged = result
+		}
+	}
+
+	if merged == nil {
+		return identityLookup{err: results[0].err}
+	}
+
+	return identityLookup{
+		value:    merged.value,
+		node:     merged.node,
+		found:    merged.found,
+		routeSet: routeSet.Nodes(),
+	}
+}
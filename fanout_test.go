@@ -0,0 +1,77 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/insolar/network/store"
+	"github.com/jbenet/go-base58"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupWithAllIdentities(t *testing.T) {
+	ids := []node.ID{getIDWithValues(1), getIDWithValues(2)}
+	st, s, tp, r, err := dhtParams(ids, "127.0.0.1:3000")
+	assert.NoError(t, err)
+
+	own := store.NewMemoryStore()
+	dht, err := NewDHT(st, s, tp, r, &Options{
+		Identities: map[string]*IdentityOptions{
+			ids[1].String(): {Store: own},
+		},
+	})
+	assert.NoError(t, err)
+	cb := NewContextBuilder(dht)
+	first, _ := cb.SetNodeByID(ids[0]).Build()
+	second, _ := cb.SetNodeByID(ids[1]).Build()
+
+	// Value and node are known to the second ID only
+	data := []byte("foo")
+	key := store.NewKey(data)
+	err = own.Store(second, key, data, time.Now().Add(time.Hour), time.Now().Add(time.Hour), true)
+	assert.NoError(t, err)
+
+	addr, _ := node.NewAddress("127.0.0.1:3001")
+	target := &node.Node{ID: getZerodIDWithNthByte(1, byte(1)), Address: addr}
+	dht.addNode(second, routing.NewRouteNode(target))
+
+	_, found, err := dht.Get(first, base58.Encode(key))
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	value, found, _, err := dht.GetFromAllIdentities(base58.Encode(key))
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, data, value)
+
+	_, found, err = dht.FindNode(first, target.ID.String())
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	foundNode, found, routeSet, err := dht.FindNodeFromAllIdentities(target.ID.String())
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, target, foundNode)
+	assert.Equal(t, []*node.Node{target}, routeSet)
+
+	_, _, _, err = dht.GetFromAllIdentities("invalid")
+	assert.EqualError(t, err, "invalid key")
+}
@@ -0,0 +1,116 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"sync"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
+)
+
+// expiries keeps expiration times of values stored on node, so their expiration can be reported with OnExpired
+type expiries struct {
+	mutex       *sync.Mutex
+	expirations map[string]time.Time
+	keys        map[string]store.Key
+}
+
+func newExpiries() *expiries {
+	return &expiries{
+		mutex:       &sync.Mutex{},
+		expirations: make(map[string]time.Time),
+		keys:        make(map[string]store.Key),
+	}
+}
+
+// set records expiration of stored value
+func (e *expiries) set(key store.Key, expiration time.Time) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.expirations[key.String()] = expiration
+	e.keys[key.String()] = key
+}
+
+// forget stops tracking value removed from store before its expiration
+func (e *expiries) forget(key store.Key) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	delete(e.expirations, key.String())
+	delete(e.keys, key.String())
+}
+
+// due returns and forgets keys of values expired at now
+func (e *expiries) due(now time.Time) []store.Key {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var keys []store.Key
+	for k, expiration := range e.expirations {
+		if now.After(expiration) {
+			keys = append(keys, e.keys[k])
+			delete(e.expirations, k)
+			delete(e.keys, k)
+		}
+	}
+	return keys
+}
+
+func (dht *DHT) expiriesFor(ctx Context) *expiries {
+	return dht.expiries[ctx.Value(ctxTableIndex).(int)]
+}
+
+// stored reports value which landed on node to OnStored and tracks its expiration for OnExpired
+func (dht *DHT) stored(ctx Context, key store.Key, data []byte, expiration time.Time) {
+	if dht.options.OnExpired != nil {
+		dht.expiriesFor(ctx).set(key, expiration)
+	}
+	if dht.options.OnStored != nil {
+		dht.options.OnStored(key, data)
+	}
+}
+
+// reportExpired calls OnExpired with keys of values which expired and were collected by store
+func (dht *DHT) reportExpired(ctx Context) {
+	if dht.options.OnExpired == nil {
+		return
+	}
+	now := dht.options.Clock.Now()
+	for _, key := range dht.expiriesFor(ctx).due(now) {
+		if _, held := dht.retrieve(ctx, key); held {
+			// Store did not collect value yet, e.g. it checks expiration with its own clock
+			dht.expiriesFor(ctx).set(key, now)
+			continue
+		}
+		dht.options.OnExpired(key)
+	}
+}
+
+// replicated reports nodes which confirmed replica of value to OnReplicated
+func (dht *DHT) replicated(key store.Key, receipts []*store.Receipt) {
+	if dht.options.OnReplicated == nil {
+		return
+	}
+	holders := make([]node.ID, 0, len(receipts))
+	for _, receipt := range receipts {
+		holders = append(holders, receipt.Holder)
+	}
+	dht.options.OnReplicated(key, holders)
+}
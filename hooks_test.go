@@ -0,0 +1,114 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpiries_Due(t *testing.T) {
+	e := newExpiries()
+	now := time.Now()
+	first, second := store.NewKey([]byte("first")), store.NewKey([]byte("second"))
+	e.set(first, now)
+	e.set(second, now.Add(time.Minute))
+	e.set(store.NewKey([]byte("deleted")), now)
+	e.forget(store.NewKey([]byte("deleted")))
+
+	assert.Empty(t, e.due(now))
+	assert.Equal(t, []store.Key{first}, e.due(now.Add(time.Second)))
+	// Reported keys are forgotten
+	assert.Empty(t, e.due(now.Add(time.Second)))
+	assert.Equal(t, []store.Key{second}, e.due(now.Add(time.Hour)))
+}
+
+func TestDHT_Hooks(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	replicated := make(chan []node.ID, 1)
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{
+		OnReplicated: func(key store.Key, holders []node.ID) {
+			replicated <- holders
+		},
+	})
+
+	stored, expired := make(chan []byte, 2), make(chan store.Key, 1)
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+		OnStored: func(key store.Key, data []byte) {
+			stored <- data
+		},
+		OnExpired: func(key store.Key) {
+			expired <- key
+		},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+
+	// Value landing on the second node is reported
+	ctx1 := getDefaultCtx(dht1)
+	_, receipts, err := dht1.StoreWithReceipts(ctx1, []byte("foo"))
+	assert.NoError(t, err)
+	assert.Len(t, receipts, 1)
+	assert.Equal(t, []byte("foo"), <-stored)
+
+	dht1.replicate(ctx1, []store.Key{store.NewKey([]byte("foo"))})
+	assert.Equal(t, []node.ID{dht2.htFromCtx(getDefaultCtx(dht2)).Origin.ID}, <-replicated)
+	assert.Equal(t, []byte("foo"), <-stored)
+
+	// Expired value is reported once store collects it
+	ctx2 := getDefaultCtx(dht2)
+	key := store.NewKey([]byte("bar"))
+	expiration := time.Now().Add(-time.Second)
+	_, err = dht2.storeVersion(ctx2, key, []byte("bar"), expiration, expiration, false, store.Version{})
+	assert.NoError(t, err)
+	<-stored
+	assert.NoError(t, st2.ExpireKeys(context.Background()))
+	dht2.reportExpired(ctx2)
+	select {
+	case k := <-expired:
+		assert.Equal(t, key, k)
+	default:
+		assert.Fail(t, "expiration is not reported")
+	}
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"errors"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
+)
+
+// IdentityOptions overrides Options for one of node IDs. Fields which are not set are taken from Options
+type IdentityOptions struct {
+	// Store keeps values stored on this ID separately from other IDs
+	Store store.Store
+
+	// The nodes being used to bootstrap routing table of this ID
+	BootstrapNodes []*node.Node
+
+	// The maximum time to wait for a response from a node before discarding
+	// it from the bucket
+	PingTimeout time.Duration
+
+	// The maximum time to wait for a response to any message
+	MessageTimeout time.Duration
+}
+
+// newIdentities returns options for every origin ID with Options defaults applied
+func newIdentities(origin *node.Origin, st store.Store, options *Options) ([]IdentityOptions, error) {
+	for key := range options.Identities {
+		found := false
+		for _, id := range origin.IDs {
+			found = found || id.String() == key
+		}
+		if !found {
+			return nil, errors.New("options for unknown identity " + key)
+		}
+	}
+
+	identities := make([]IdentityOptions, len(origin.IDs))
+	for i, id := range origin.IDs {
+		identity := IdentityOptions{}
+		if override, ok := options.Identities[id.String()]; ok && override != nil {
+			identity = *override
+		}
+
+		if identity.Store == nil {
+			identity.Store = st
+		}
+		if identity.BootstrapNodes == nil {
+			identity.BootstrapNodes = options.BootstrapNodes
+		}
+		if identity.PingTimeout == 0 {
+			identity.PingTimeout = options.PingTimeout
+		}
+		if identity.MessageTimeout == 0 {
+			identity.MessageTimeout = options.MessageTimeout
+		}
+
+		identities[i] = identity
+	}
+
+	return identities, nil
+}
+
+func (dht *DHT) identity(ctx Context) IdentityOptions {
+	return dht.identities[ctx.Value(ctxTableIndex).(int)]
+}
+
+// storeFor returns store of ID ctx is bound to
+func (dht *DHT) storeFor(ctx Context) store.Store {
+	return dht.identity(ctx).Store
+}
+
+func (dht *DHT) pingTimeout(ctx Context) time.Duration {
+	return dht.identity(ctx).PingTimeout
+}
+
+func (dht *DHT) messageTimeout(ctx Context) time.Duration {
+	return dht.identity(ctx).MessageTimeout
+}
+
+// longestTimeout returns the longest time any identity waits for response
+func (dht *DHT) longestTimeout() time.Duration {
+	var longest time.Duration
+	for _, identity := range dht.identities {
+		if identity.PingTimeout > longest {
+			longest = identity.PingTimeout
+		}
+		if identity.MessageTimeout > longest {
+			longest = identity.MessageTimeout
+		}
+	}
+	return longest
+}
@@ -0,0 +1,85 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIdentities(t *testing.T) {
+	ids := []node.ID{getIDWithValues(1), getIDWithValues(2)}
+	addr, _ := node.NewAddress("127.0.0.1:3000")
+	origin, _ := node.NewOrigin(ids, addr)
+	shared := store.NewMemoryStore()
+	own := store.NewMemoryStore()
+	bootstrap := []*node.Node{{Address: addr}}
+
+	identities, err := newIdentities(origin, shared, &Options{
+		BootstrapNodes: bootstrap,
+		PingTimeout:    time.Second,
+		MessageTimeout: time.Second * 10,
+		Identities: map[string]*IdentityOptions{
+			ids[1].String(): {Store: own, BootstrapNodes: []*node.Node{}, MessageTimeout: time.Second * 5},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []IdentityOptions{
+		{Store: shared, BootstrapNodes: bootstrap, PingTimeout: time.Second, MessageTimeout: time.Second * 10},
+		{Store: own, BootstrapNodes: []*node.Node{}, PingTimeout: time.Second, MessageTimeout: time.Second * 5},
+	}, identities)
+	assert.Equal(t, time.Second*10, (&DHT{identities: identities}).longestTimeout())
+
+	_, err = newIdentities(origin, shared, &Options{
+		Identities: map[string]*IdentityOptions{getIDWithValues(3).String(): {}},
+	})
+	assert.EqualError(t, err, "options for unknown identity "+getIDWithValues(3).String())
+}
+
+func TestIdentityStore(t *testing.T) {
+	ids := []node.ID{getIDWithValues(1), getIDWithValues(2)}
+	st, s, tp, r, err := dhtParams(ids, "127.0.0.1:3000")
+	assert.NoError(t, err)
+
+	own := store.NewMemoryStore()
+	dht, err := NewDHT(st, s, tp, r, &Options{
+		Identities: map[string]*IdentityOptions{
+			ids[1].String(): {Store: own},
+		},
+	})
+	assert.NoError(t, err)
+
+	addr, _ := node.NewAddress("127.0.0.1:3001")
+	sender := &node.Node{ID: getIDWithValues(3), Address: addr}
+	data := []byte("foo")
+
+	ctx, err := NewContextBuilder(dht).SetNodeByID(ids[1]).Build()
+	assert.NoError(t, err)
+	request := message.NewBuilder().Sender(sender).Receiver(dht.tables[1].Origin).Type(message.TypeStore).Request(
+		&message.RequestDataStore{Data: data, Token: dht.tokens.issue(addr.IP)}).Build()
+	dht.processStore(ctx, request, message.NewBuilder())
+
+	_, exists, _ := own.Retrieve(ctx, store.NewKey(data))
+	assert.True(t, exists)
+	_, exists, _ = st.Retrieve(ctx, store.NewKey(data))
+	assert.False(t, exists)
+}
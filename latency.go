@@ -0,0 +1,151 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"sync"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+)
+
+// latencyBounds are upper bounds of latency histogram buckets, the last one is unbounded
+var latencyBounds = []time.Duration{
+	time.Millisecond * 10,
+	time.Millisecond * 25,
+	time.Millisecond * 50,
+	time.Millisecond * 100,
+	time.Millisecond * 250,
+	time.Millisecond * 500,
+	time.Second,
+	time.Millisecond * 2500,
+	time.Second * 5,
+}
+
+// LatencyHistogram contains response latencies of nodes from a single bucket of routing table
+type LatencyHistogram struct {
+	// Bounds are upper bounds of Counts, the last count is for responses slower than all of them
+	Bounds []time.Duration
+	Counts []int
+
+	// Count is number of responses and Sum is their total latency
+	Count int
+	Sum   time.Duration
+
+	// Timeouts counts requests which did not get response in time
+	Timeouts int
+}
+
+func newLatencyHistogram() *LatencyHistogram {
+	return &LatencyHistogram{
+		Bounds: latencyBounds,
+		Counts: make([]int, len(latencyBounds)+1),
+	}
+}
+
+// Average returns average response latency
+func (h LatencyHistogram) Average() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+func (h *LatencyHistogram) observe(latency time.Duration) {
+	i := 0
+	for i < len(h.Bounds) && latency > h.Bounds[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Count++
+	h.Sum += latency
+}
+
+// latencyHistograms keeps latency histograms of routing table buckets
+type latencyHistograms struct {
+	mutex   *sync.Mutex
+	origin  node.ID
+	buckets map[int]*LatencyHistogram
+}
+
+func newLatencyHistograms(origin node.ID) *latencyHistograms {
+	return &latencyHistograms{
+		mutex:   &sync.Mutex{},
+		origin:  origin,
+		buckets: make(map[int]*LatencyHistogram),
+	}
+}
+
+// observe records latency of response from given node
+func (lh *latencyHistograms) observe(n *node.Node, latency time.Duration) {
+	if n == nil || n.ID == nil {
+		return
+	}
+
+	lh.mutex.Lock()
+	defer lh.mutex.Unlock()
+
+	lh.bucket(n.ID).observe(latency)
+}
+
+// timeout records request to given node which did not get response in time
+func (lh *latencyHistograms) timeout(n *node.Node) {
+	if n == nil || n.ID == nil {
+		return
+	}
+
+	lh.mutex.Lock()
+	defer lh.mutex.Unlock()
+
+	lh.bucket(n.ID).Timeouts++
+}
+
+func (lh *latencyHistograms) bucket(id node.ID) *LatencyHistogram {
+	index := routing.GetBucketIndexFromDifferingBit(lh.origin, id)
+	h, ok := lh.buckets[index]
+	if !ok {
+		h = newLatencyHistogram()
+		lh.buckets[index] = h
+	}
+	return h
+}
+
+// snapshot returns copies of histograms keyed by bucket index
+func (lh *latencyHistograms) snapshot() map[int]LatencyHistogram {
+	lh.mutex.Lock()
+	defer lh.mutex.Unlock()
+
+	histograms := make(map[int]LatencyHistogram, len(lh.buckets))
+	for index, h := range lh.buckets {
+		copied := *h
+		copied.Counts = append([]int{}, h.Counts...)
+		histograms[index] = copied
+	}
+	return histograms
+}
+
+// Latencies returns histograms of ping and lookup response latencies keyed by index of routing table bucket
+// of responding nodes, so that degradation of a whole region of keyspace can be detected.
+// Histograms are collected separately for every ID, ctx selects one of them.
+func (dht *DHT) Latencies(ctx Context) map[int]LatencyHistogram {
+	return dht.latenciesFor(ctx).snapshot()
+}
+
+func (dht *DHT) latenciesFor(ctx Context) *latencyHistograms {
+	return dht.latencies[ctx.Value(ctxTableIndex).(int)]
+}
@@ -0,0 +1,90 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyHistogram_Observe(t *testing.T) {
+	h := newLatencyHistogram()
+	h.observe(time.Millisecond * 5)
+	h.observe(time.Millisecond * 10)
+	h.observe(time.Millisecond * 30)
+	h.observe(time.Second * 10)
+
+	assert.Equal(t, 2, h.Counts[0])
+	assert.Equal(t, 1, h.Counts[2])
+	assert.Equal(t, 1, h.Counts[len(latencyBounds)])
+	assert.Equal(t, 4, h.Count)
+	assert.Equal(t, time.Millisecond*45+time.Second*10, h.Sum)
+	assert.Equal(t, h.Sum/4, h.Average())
+	assert.Equal(t, time.Duration(0), LatencyHistogram{}.Average())
+}
+
+func TestLatencyHistograms(t *testing.T) {
+	lh := newLatencyHistograms(getIDWithValues(0))
+	near := &node.Node{ID: getZerodIDWithNthByte(19, 1)}
+	far := &node.Node{ID: getZerodIDWithNthByte(0, 128)}
+
+	lh.observe(near, time.Millisecond)
+	lh.observe(far, time.Second)
+	lh.timeout(far)
+	lh.observe(&node.Node{}, time.Millisecond)
+
+	histograms := lh.snapshot()
+	assert.Len(t, histograms, 2)
+	assert.Equal(t, 1, histograms[0].Count)
+	assert.Equal(t, 1, histograms[routing.KeyBitSize-1].Count)
+	assert.Equal(t, 1, histograms[routing.KeyBitSize-1].Timeouts)
+
+	// Snapshot is not changed by further observations
+	lh.observe(near, time.Millisecond)
+	assert.Equal(t, 1, histograms[0].Counts[0])
+}
+
+func TestLatencies_Ping(t *testing.T) {
+	st, s, tp, r, err := dhtParams([]node.ID{getIDWithValues(0)}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	dht, _ := NewDHT(st, s, tp, r, &Options{PingTimeout: time.Millisecond * 100})
+	mockTp := tp.(*mockTransport)
+	ctx := getDefaultCtx(dht)
+
+	addr, _ := node.NewAddress("0.0.0.0:3001")
+	alive := &node.Node{ID: getZerodIDWithNthByte(19, 1), Address: addr}
+	dead := &node.Node{ID: getZerodIDWithNthByte(19, 2), Address: addr}
+
+	go func() {
+		request := <-mockTp.recv
+		mockTp.send <- message.NewBuilder().Sender(request.Receiver).Receiver(request.Sender).Type(message.TypePing).Response(
+			&message.ResponseDataPing{}).Build()
+		<-mockTp.recv
+	}()
+
+	assert.True(t, dht.ping(ctx, alive))
+	assert.False(t, dht.ping(ctx, dead))
+
+	histograms := dht.Latencies(ctx)
+	assert.Equal(t, 1, histograms[0].Count)
+	assert.Equal(t, 1, histograms[1].Timeouts)
+}
@@ -0,0 +1,104 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/node"
+)
+
+// maxLivenessHints is the maximum number of failed nodes piggybacked on a single response
+const maxLivenessHints = 10
+
+type failedNode struct {
+	node     *node.Node
+	failedAt time.Time
+}
+
+// livenessHints keeps track of recently failed nodes so they can be shared with other nodes
+type livenessHints struct {
+	mutex  *sync.Mutex
+	failed map[string]failedNode
+}
+
+func newLivenessHints() *livenessHints {
+	return &livenessHints{
+		mutex:  &sync.Mutex{},
+		failed: make(map[string]failedNode),
+	}
+}
+
+// markFailed remembers node as failed
+func (lh *livenessHints) markFailed(n *node.Node) {
+	if n == nil || n.ID == nil {
+		return
+	}
+
+	lh.mutex.Lock()
+	defer lh.mutex.Unlock()
+
+	lh.failed[string(n.ID)] = failedNode{node: n, failedAt: time.Now()}
+}
+
+// markAlive forgets node failure
+func (lh *livenessHints) markAlive(id node.ID) {
+	lh.mutex.Lock()
+	defer lh.mutex.Unlock()
+
+	delete(lh.failed, string(id))
+}
+
+// isFailed checks if node failed and was not seen alive since then
+func (lh *livenessHints) isFailed(id node.ID) bool {
+	lh.mutex.Lock()
+	defer lh.mutex.Unlock()
+
+	_, ok := lh.failed[string(id)]
+	return ok
+}
+
+// recent returns up to num nodes failed during given period, most recent first
+func (lh *livenessHints) recent(num int, period time.Duration) []*node.Node {
+	lh.mutex.Lock()
+	defer lh.mutex.Unlock()
+
+	var failed []failedNode
+	for id, f := range lh.failed {
+		if time.Since(f.failedAt) > period {
+			delete(lh.failed, id)
+			continue
+		}
+		failed = append(failed, f)
+	}
+
+	sort.Slice(failed, func(i, j int) bool {
+		return failed[i].failedAt.After(failed[j].failedAt)
+	})
+
+	if len(failed) > num {
+		failed = failed[:num]
+	}
+
+	nodes := make([]*node.Node, len(failed))
+	for i, f := range failed {
+		nodes[i] = f.node
+	}
+	return nodes
+}
@@ -0,0 +1,63 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLivenessHints_MarkFailed(t *testing.T) {
+	lh := newLivenessHints()
+	addr, _ := node.NewAddress("127.0.0.1:3000")
+	first := &node.Node{ID: getIDWithValues(1), Address: addr}
+	second := &node.Node{ID: getIDWithValues(2), Address: addr}
+
+	lh.markFailed(first)
+	lh.markFailed(second)
+	lh.markFailed(&node.Node{Address: addr})
+
+	assert.Equal(t, []*node.Node{second, first}, lh.recent(maxLivenessHints, time.Minute))
+	assert.Equal(t, []*node.Node{second}, lh.recent(1, time.Minute))
+}
+
+func TestLivenessHints_MarkAlive(t *testing.T) {
+	lh := newLivenessHints()
+	addr, _ := node.NewAddress("127.0.0.1:3000")
+	n := &node.Node{ID: getIDWithValues(1), Address: addr}
+
+	lh.markFailed(n)
+	lh.markAlive(n.ID)
+
+	assert.Empty(t, lh.recent(maxLivenessHints, time.Minute))
+}
+
+func TestLivenessHints_Recent_Expired(t *testing.T) {
+	lh := newLivenessHints()
+	addr, _ := node.NewAddress("127.0.0.1:3000")
+	n := &node.Node{ID: getIDWithValues(1), Address: addr}
+
+	lh.markFailed(n)
+	time.Sleep(time.Millisecond * 10)
+
+	assert.Empty(t, lh.recent(maxLivenessHints, time.Millisecond))
+	assert.Empty(t, lh.failed)
+}
@@ -0,0 +1,65 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"errors"
+)
+
+// ErrTooManyLookups is returned when lookup is started while MaxConcurrentLookups are running
+// and FailExcessLookups option is set
+var ErrTooManyLookups = errors.New("too many concurrent lookups")
+
+// lookupLimiter limits number of concurrent network lookups
+type lookupLimiter struct {
+	slots    chan bool
+	failFast bool
+}
+
+func newLookupLimiter(max int, failFast bool) *lookupLimiter {
+	limiter := &lookupLimiter{failFast: failFast}
+	if max > 0 {
+		limiter.slots = make(chan bool, max)
+	}
+	return limiter
+}
+
+// acquire waits for free lookup slot or fails if there is none and limiter fails fast
+func (l *lookupLimiter) acquire() error {
+	if l.slots == nil {
+		return nil
+	}
+
+	if !l.failFast {
+		l.slots <- true
+		return nil
+	}
+
+	select {
+	case l.slots <- true:
+		return nil
+	default:
+		return ErrTooManyLookups
+	}
+}
+
+// release frees lookup slot
+func (l *lookupLimiter) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
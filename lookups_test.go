@@ -0,0 +1,76 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/jbenet/go-base58"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupLimiter_Unlimited(t *testing.T) {
+	l := newLookupLimiter(0, true)
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, l.acquire())
+	}
+}
+
+func TestLookupLimiter_FailFast(t *testing.T) {
+	l := newLookupLimiter(2, true)
+	assert.NoError(t, l.acquire())
+	assert.NoError(t, l.acquire())
+	assert.Equal(t, ErrTooManyLookups, l.acquire())
+
+	l.release()
+	assert.NoError(t, l.acquire())
+}
+
+func TestLookupLimiter_Queue(t *testing.T) {
+	l := newLookupLimiter(1, false)
+	assert.NoError(t, l.acquire())
+
+	acquired := make(chan bool)
+	go func() {
+		l.acquire()
+		acquired <- true
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lookup must wait for free slot")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	l.release()
+	<-acquired
+}
+
+func TestGet_TooManyLookups(t *testing.T) {
+	st, s, tp, r, err := dhtParams([]node.ID{getIDWithValues(0)}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	dht, _ := NewDHT(st, s, tp, r, &Options{MaxConcurrentLookups: 1, FailExcessLookups: true})
+	ctx := getDefaultCtx(dht)
+
+	// Another lookup is running
+	assert.NoError(t, dht.lookups.acquire())
+
+	_, _, err = dht.Get(ctx, base58.Encode(getZerodIDWithNthByte(1, byte(1))))
+	assert.Equal(t, ErrTooManyLookups, err)
+}
@@ -0,0 +1,122 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package message
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+// maxPooledBufferSize is capacity above which buffers are dropped instead of being pooled,
+// so a single large message does not pin its memory forever
+const maxPooledBufferSize = 1 << 20
+
+var bufferPool = &sync.Pool{
+	New: func() interface{} {
+		return &bytes.Buffer{}
+	},
+}
+
+// GetBuffer returns empty buffer from pool
+func GetBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// PutBuffer returns buffer to pool, data it holds must not be used afterwards
+func PutBuffer(buffer *bytes.Buffer) {
+	if buffer.Cap() > maxPooledBufferSize {
+		return
+	}
+	buffer.Reset()
+	bufferPool.Put(buffer)
+}
+
+// EncodeFrame writes frame of message encoded with gob to buffer. Header is written with zero flags,
+// use SetFrameFlags to change them.
+func EncodeFrame(buffer *bytes.Buffer, q *Message) error {
+	return EncodeFrameWith(buffer, q, gobCodec{})
+}
+
+// EncodeFrameWith writes frame of message encoded with given codec to buffer.
+// Header flags carry ID of codec, use SetFrameFlags to change them.
+func EncodeFrameWith(buffer *bytes.Buffer, q *Message, codec Codec) error {
+	var header [FrameHeaderSize]byte
+	buffer.Write(header[:])
+
+	err := codec.Encode(buffer, q)
+	if err != nil {
+		return err
+	}
+
+	return putFrameHeader(buffer.Bytes(), FrameFlags(0).WithCodecs(codec.ID(), CodecGob))
+}
+
+// SetFrameFlags sets flags of encoded frame
+func SetFrameFlags(frame []byte, flags FrameFlags) {
+	frame[FrameHeaderSize-1] = byte(flags)
+}
+
+// FrameBody returns body of encoded frame without copying it
+func FrameBody(frame []byte) []byte {
+	return frame[FrameHeaderSize:]
+}
+
+// ReadFrameTo reads length prefixed body and its flags from io.Reader into buffer.
+// Returned body shares memory with buffer and is valid until buffer is reused.
+func ReadFrameTo(conn io.Reader, buffer *bytes.Buffer) ([]byte, FrameFlags, error) {
+	header := grow(buffer, FrameHeaderSize)
+	_, err := io.ReadFull(conn, header)
+	if err != nil {
+		return nil, 0, err
+	}
+	flags := FrameFlags(header[FrameHeaderSize-1])
+
+	length, n := binary.Uvarint(header[:FrameHeaderSize-1])
+	if n <= 0 {
+		return nil, 0, errors.New("invalid frame length")
+	}
+
+	body := grow(buffer, int(length))
+	_, err = io.ReadFull(conn, body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return body, flags, nil
+}
+
+// putFrameHeader writes length of frame body and flags to frame header
+func putFrameHeader(frame []byte, flags FrameFlags) error {
+	length := len(frame) - FrameHeaderSize
+	if uint64(length) >= 1<<((FrameHeaderSize-1)*7) {
+		return errors.New("message is too large")
+	}
+
+	binary.PutUvarint(frame[:FrameHeaderSize-1], uint64(length))
+	frame[FrameHeaderSize-1] = byte(flags)
+	return nil
+}
+
+// grow returns slice of n bytes of buffer memory, previous contents of buffer are dropped
+func grow(buffer *bytes.Buffer, n int) []byte {
+	buffer.Reset()
+	buffer.Grow(n)
+	return buffer.Bytes()[:n]
+}
@@ -0,0 +1,86 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package message
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeFrame(t *testing.T) {
+	senderAddress, _ := node.NewAddress("127.0.0.1:31337")
+	sender := node.NewNode(senderAddress)
+	sender.ID, _ = node.NewID()
+	msg := NewBuilder().Sender(sender).Receiver(sender).Type(TypeFindNode).Request(&RequestDataFindNode{sender.ID}).Build()
+
+	buffer := GetBuffer()
+	defer PutBuffer(buffer)
+
+	err := EncodeFrame(buffer, msg)
+	assert.NoError(t, err)
+
+	// Frame is the same as one built by SerializeMessage
+	serialized, err := SerializeMessage(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, serialized, buffer.Bytes())
+
+	SetFrameFlags(buffer.Bytes(), FlagAcceptsCompressed)
+	body, flags, err := ReadFrame(bytes.NewBuffer(buffer.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, FlagAcceptsCompressed, flags)
+	assert.Equal(t, FrameBody(buffer.Bytes()), body)
+}
+
+func TestReadFrameTo(t *testing.T) {
+	msg := NewBuilder().Type(TypePing).Build()
+	body, err := EncodeMessage(msg)
+	assert.NoError(t, err)
+
+	stream := bytes.NewBuffer(nil)
+	stream.Write(NewFrame(body, FlagCompressed))
+	stream.Write(NewFrame(body, 0))
+
+	buffer := &bytes.Buffer{}
+	first, flags, err := ReadFrameTo(stream, buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, body, first)
+	assert.Equal(t, FlagCompressed, flags)
+
+	// Buffer memory is reused by the next frame
+	second, flags, err := ReadFrameTo(stream, buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, body, second)
+	assert.Equal(t, FrameFlags(0), flags)
+	assert.Equal(t, &first[0], &second[0])
+
+	decoded, err := DecodeMessage(second)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, decoded)
+}
+
+func TestPutBuffer_Large(t *testing.T) {
+	buffer := GetBuffer()
+	buffer.Write(make([]byte, maxPooledBufferSize+1))
+	PutBuffer(buffer)
+
+	// Large buffer is not reset as it is dropped
+	assert.Equal(t, maxPooledBufferSize+1, buffer.Len())
+}
@@ -63,6 +63,14 @@ func (cb Builder) Type(messageType messageType) Builder {
 	return cb
 }
 
+// Headers sets message headers
+func (cb Builder) Headers(headers map[string]string) Builder {
+	cb.actions = append(cb.actions, func(message *Message) {
+		message.Headers = headers
+	})
+	return cb
+}
+
 // Request adds request data to message
 func (cb Builder) Request(request interface{}) Builder {
 	cb.actions = append(cb.actions, func(message *Message) {
@@ -68,13 +68,13 @@ func TestBuilder_Build_ResponseMessage(t *testing.T) {
 	receiver := node.NewNode(receiverAddress)
 	receiver.ID, _ = node.NewID()
 
-	m := builder.Sender(sender).Receiver(receiver).Type(TypeRPC).Response(&ResponseDataRPC{true, []byte("ok"), ""}).Build()
+	m := builder.Sender(sender).Receiver(receiver).Type(TypeRPC).Response(&ResponseDataRPC{true, []byte("ok"), "", ErrorNone}).Build()
 
 	expectedMessage := &Message{
 		Sender:     sender,
 		Receiver:   receiver,
 		Type:       TypeRPC,
-		Data:       &ResponseDataRPC{true, []byte("ok"), ""},
+		Data:       &ResponseDataRPC{true, []byte("ok"), "", ErrorNone},
 		IsResponse: true,
 		Error:      nil,
 	}
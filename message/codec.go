@@ -0,0 +1,150 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package message
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"sync"
+)
+
+// CodecID identifies codec of message in frame flags
+type CodecID byte
+
+const (
+	// CodecGob is ID of built-in codec based on encoding/gob, it is used by peers which did not choose other codec
+	CodecGob = CodecID(iota)
+	// CodecProtobuf is ID reserved for protobuf codec
+	CodecProtobuf
+	// CodecCBOR is ID reserved for CBOR codec
+	CodecCBOR
+
+	maxCodecs = 4
+)
+
+// Codec encodes messages sent over the wire. Frame flags carry ID of codec message is encoded with,
+// so peers can use different codecs side by side.
+type Codec interface {
+	ID() CodecID
+	Name() string
+	Encode(buffer *bytes.Buffer, q *Message) error
+	Decode(body []byte) (*Message, error)
+}
+
+type codecRegistry struct {
+	mutex  *sync.RWMutex
+	codecs [maxCodecs]Codec
+}
+
+var codecs = &codecRegistry{
+	mutex: &sync.RWMutex{},
+}
+
+// RegisterCodec makes codec available for encoding and decoding of messages
+func RegisterCodec(codec Codec) error {
+	if codec.ID() >= maxCodecs {
+		return errors.New("invalid codec ID")
+	}
+
+	codecs.mutex.Lock()
+	defer codecs.mutex.Unlock()
+
+	if codecs.codecs[codec.ID()] != nil {
+		return errors.New("codec ID is already registered")
+	}
+	for _, other := range codecs.codecs {
+		if other != nil && other.Name() == codec.Name() {
+			return errors.New("codec name is already registered")
+		}
+	}
+
+	codecs.codecs[codec.ID()] = codec
+	return nil
+}
+
+// GetCodec returns registered codec with given ID
+func GetCodec(id CodecID) (Codec, bool) {
+	if id >= maxCodecs {
+		return nil, false
+	}
+
+	codecs.mutex.RLock()
+	defer codecs.mutex.RUnlock()
+
+	codec := codecs.codecs[id]
+	return codec, codec != nil
+}
+
+// GetCodecByName returns registered codec with given name
+func GetCodecByName(name string) (Codec, bool) {
+	codecs.mutex.RLock()
+	defer codecs.mutex.RUnlock()
+
+	for _, codec := range codecs.codecs {
+		if codec != nil && codec.Name() == name {
+			return codec, true
+		}
+	}
+	return nil, false
+}
+
+// CodecNames returns names of registered codecs
+func CodecNames() []string {
+	codecs.mutex.RLock()
+	defer codecs.mutex.RUnlock()
+
+	var names []string
+	for _, codec := range codecs.codecs {
+		if codec != nil {
+			names = append(names, codec.Name())
+		}
+	}
+	return names
+}
+
+type gobCodec struct{}
+
+func (gobCodec) ID() CodecID {
+	return CodecGob
+}
+
+func (gobCodec) Name() string {
+	return "gob"
+}
+
+func (gobCodec) Encode(buffer *bytes.Buffer, q *Message) error {
+	return gob.NewEncoder(buffer).Encode(q)
+}
+
+func (gobCodec) Decode(body []byte) (*Message, error) {
+	msg := &Message{}
+	err := gob.NewDecoder(bytes.NewReader(body)).Decode(msg)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// DecodeFrame decodes body of frame with codec set in its flags
+func DecodeFrame(body []byte, flags FrameFlags) (*Message, error) {
+	codec, ok := GetCodec(flags.Codec())
+	if !ok {
+		return nil, errors.New("unknown codec")
+	}
+	return codec.Decode(body)
+}
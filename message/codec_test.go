@@ -0,0 +1,114 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package message
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// markedCodec is gob prefixed with marker byte
+type markedCodec struct {
+	id   CodecID
+	name string
+}
+
+func (c markedCodec) ID() CodecID {
+	return c.id
+}
+
+func (c markedCodec) Name() string {
+	return c.name
+}
+
+func (c markedCodec) Encode(buffer *bytes.Buffer, q *Message) error {
+	buffer.WriteByte(byte(c.id))
+	return gobCodec{}.Encode(buffer, q)
+}
+
+func (c markedCodec) Decode(body []byte) (*Message, error) {
+	if len(body) == 0 || body[0] != byte(c.id) {
+		return nil, errors.New("marker not found")
+	}
+	return gobCodec{}.Decode(body[1:])
+}
+
+func TestRegisterCodec(t *testing.T) {
+	codec, ok := GetCodec(CodecGob)
+	assert.True(t, ok)
+	assert.Equal(t, "gob", codec.Name())
+
+	err := RegisterCodec(markedCodec{id: maxCodecs, name: "invalid"})
+	assert.EqualError(t, err, "invalid codec ID")
+	err = RegisterCodec(markedCodec{id: CodecGob, name: "other"})
+	assert.EqualError(t, err, "codec ID is already registered")
+	err = RegisterCodec(markedCodec{id: CodecCBOR, name: "gob"})
+	assert.EqualError(t, err, "codec name is already registered")
+
+	err = RegisterCodec(markedCodec{id: CodecCBOR, name: "marked"})
+	assert.NoError(t, err)
+	codec, ok = GetCodecByName("marked")
+	assert.True(t, ok)
+	assert.Equal(t, CodecCBOR, codec.ID())
+	assert.Equal(t, []string{"gob", "marked"}, CodecNames())
+
+	_, ok = GetCodec(CodecProtobuf)
+	assert.False(t, ok)
+	_, ok = GetCodecByName("protobuf")
+	assert.False(t, ok)
+}
+
+func TestFrameFlags_Codecs(t *testing.T) {
+	flags := FlagCompressed.WithCodecs(CodecCBOR, CodecProtobuf)
+	assert.Equal(t, CodecCBOR, flags.Codec())
+	assert.Equal(t, CodecProtobuf, flags.PreferredCodec())
+	assert.NotZero(t, flags&FlagCompressed)
+
+	flags = flags.WithCodecs(CodecGob, CodecGob)
+	assert.Equal(t, FlagCompressed, flags)
+}
+
+func TestEncodeFrameWith(t *testing.T) {
+	senderAddress, _ := node.NewAddress("127.0.0.1:31337")
+	sender := node.NewNode(senderAddress)
+	sender.ID, _ = node.NewID()
+	msg := NewBuilder().Sender(sender).Receiver(sender).Type(TypeFindNode).Request(&RequestDataFindNode{sender.ID}).Build()
+
+	codec := markedCodec{id: CodecProtobuf, name: "frame"}
+	buffer := GetBuffer()
+	defer PutBuffer(buffer)
+
+	err := EncodeFrameWith(buffer, msg, codec)
+	assert.NoError(t, err)
+
+	body, flags, err := ReadFrame(bytes.NewReader(buffer.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, CodecProtobuf, flags.Codec())
+
+	// Codec must be registered to decode frame
+	_, err = DecodeFrame(body, flags)
+	assert.EqualError(t, err, "unknown codec")
+
+	decoded, err := codec.Decode(body)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, decoded)
+}
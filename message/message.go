@@ -38,11 +38,88 @@ const (
 	TypeFindValue
 	// TypeRPC is message type for RPC method
 	TypeRPC
+	// TypeChallenge is message type for storage receipt challenge
+	TypeChallenge
+	// TypeAudit is message type for storage audit
+	TypeAudit
+	// TypeRelay is message type for registration on relay
+	TypeRelay
+	// TypePunch is message type for hole punching coordination
+	TypePunch
+	// TypeWatch is message type for registration of interest in value
+	TypeWatch
+	// TypeNotify is message type for update of watched value
+	TypeNotify
+	// TypeRegisterService is message type for registration of service instance
+	TypeRegisterService
+	// TypeLookupService is message type for lookup of service instances
+	TypeLookupService
+	// TypePutMutable is message type for publication of mutable record
+	TypePutMutable
+	// TypeGetMutable is message type for lookup of mutable record
+	TypeGetMutable
+	// TypeDelete is message type for deletion of value by its publisher
+	TypeDelete
+	// TypeExpired is message type for proof of value expiry sent to publisher
+	TypeExpired
+	// TypeAddProvider is message type for announcement of content provider
+	TypeAddProvider
+	// TypeGetProviders is message type for lookup of content providers
+	TypeGetProviders
 )
 
+// String returns name of message type
+func (mt messageType) String() string {
+	switch mt {
+	case TypePing:
+		return "ping"
+	case TypeStore:
+		return "store"
+	case TypeFindNode:
+		return "findnode"
+	case TypeFindValue:
+		return "findvalue"
+	case TypeRPC:
+		return "rpc"
+	case TypeChallenge:
+		return "challenge"
+	case TypeAudit:
+		return "audit"
+	case TypeRelay:
+		return "relay"
+	case TypePunch:
+		return "punch"
+	case TypeWatch:
+		return "watch"
+	case TypeNotify:
+		return "notify"
+	case TypeRegisterService:
+		return "registerservice"
+	case TypeLookupService:
+		return "lookupservice"
+	case TypePutMutable:
+		return "putmutable"
+	case TypeGetMutable:
+		return "getmutable"
+	case TypeDelete:
+		return "delete"
+	case TypeExpired:
+		return "expired"
+	case TypeAddProvider:
+		return "addprovider"
+	case TypeGetProviders:
+		return "getproviders"
+	default:
+		return "unknown"
+	}
+}
+
 // RequestID is 64 bit unsigned int request id
 type RequestID uint64
 
+// MaxHeadersSize is the maximum total size of keys and values of message headers
+const MaxHeadersSize = 1024
+
 // Message is DHT message object
 type Message struct {
 	Sender    *node.Node
@@ -53,6 +130,12 @@ type Message struct {
 	Data       interface{}
 	Error      error
 	IsResponse bool
+
+	// Headers is opaque application metadata, e.g. tenant ID or trace context, see MaxHeadersSize
+	Headers map[string]string
+
+	// remoteAddress is set by transport on receive and is never serialized
+	remoteAddress string
 }
 
 // NewPingMessage can be used as a shortcut for creating ping messages instead of message Builder
@@ -65,10 +148,15 @@ func NewPingMessage(sender, receiver *node.Node) *Message {
 }
 
 // IsValid checks if message data is a valid structure for current message type
+// and headers are not larger than MaxHeadersSize
 func (m *Message) IsValid() (valid bool) {
+	if HeadersSize(m.Headers) > MaxHeadersSize {
+		return false
+	}
 	switch m.Type {
 	case TypePing:
-		valid = true
+		_, valid = m.Data.(*RequestDataPing)
+		valid = valid || m.Data == nil
 	case TypeFindNode:
 		_, valid = m.Data.(*RequestDataFindNode)
 	case TypeFindValue:
@@ -77,6 +165,34 @@ func (m *Message) IsValid() (valid bool) {
 		_, valid = m.Data.(*RequestDataStore)
 	case TypeRPC:
 		_, valid = m.Data.(*RequestDataRPC)
+	case TypeChallenge:
+		_, valid = m.Data.(*RequestDataChallenge)
+	case TypeAudit:
+		_, valid = m.Data.(*RequestDataAudit)
+	case TypeRelay:
+		_, valid = m.Data.(*RequestDataRelay)
+	case TypePunch:
+		_, valid = m.Data.(*RequestDataPunch)
+	case TypeWatch:
+		_, valid = m.Data.(*RequestDataWatch)
+	case TypeNotify:
+		_, valid = m.Data.(*RequestDataNotify)
+	case TypeRegisterService:
+		_, valid = m.Data.(*RequestDataRegisterService)
+	case TypeLookupService:
+		_, valid = m.Data.(*RequestDataLookupService)
+	case TypePutMutable:
+		_, valid = m.Data.(*RequestDataPutMutable)
+	case TypeGetMutable:
+		_, valid = m.Data.(*RequestDataGetMutable)
+	case TypeDelete:
+		_, valid = m.Data.(*RequestDataDelete)
+	case TypeExpired:
+		_, valid = m.Data.(*RequestDataExpired)
+	case TypeAddProvider:
+		_, valid = m.Data.(*RequestDataAddProvider)
+	case TypeGetProviders:
+		_, valid = m.Data.(*RequestDataGetProviders)
 	default:
 		valid = false
 	}
@@ -84,72 +200,172 @@ func (m *Message) IsValid() (valid bool) {
 	return valid
 }
 
+// HeadersSize returns total size of keys and values of headers
+func HeadersSize(headers map[string]string) int {
+	size := 0
+	for key, value := range headers {
+		size += len(key) + len(value)
+	}
+	return size
+}
+
+// RemoteAddress returns network address message was received from.
+// It is empty if transport does not report it.
+func (m *Message) RemoteAddress() string {
+	return m.remoteAddress
+}
+
+// SetRemoteAddress sets network address message was received from
+func (m *Message) SetRemoteAddress(address string) {
+	m.remoteAddress = address
+}
+
 // IsForMe checks if message is addressed to our node
 func (m *Message) IsForMe(origin node.Origin) bool {
 	return origin.Contains(m.Receiver) || m.Type == TypePing && origin.Address.Equal(*m.Receiver.Address)
 }
 
+// FrameHeaderSize is size of message length prefix. Last byte of it is never used by length
+// and carries frame flags, older nodes ignore it.
+const FrameHeaderSize = 8
+
+// FrameFlags are transport flags carried in message length prefix
+type FrameFlags byte
+
+const (
+	// FlagAcceptsCompressed tells receiver that sender accepts compressed messages
+	FlagAcceptsCompressed = FrameFlags(1 << iota)
+	// FlagCompressed marks compressed message
+	FlagCompressed
+	// FlagKeepalive marks empty frame which only keeps connection open
+	FlagKeepalive
+)
+
+// Bits 3-4 of frame flags carry codec of frame body, bits 5-6 carry codec sender prefers to receive
+const (
+	codecShift          = 3
+	preferredCodecShift = 5
+	codecMask           = maxCodecs - 1
+)
+
+// Codec returns ID of codec frame body is encoded with
+func (f FrameFlags) Codec() CodecID {
+	return CodecID(f>>codecShift) & codecMask
+}
+
+// PreferredCodec returns ID of codec sender of frame prefers to receive messages in
+func (f FrameFlags) PreferredCodec() CodecID {
+	return CodecID(f>>preferredCodecShift) & codecMask
+}
+
+// WithCodecs returns flags with given codec of frame body and codec preferred by sender
+func (f FrameFlags) WithCodecs(codec, preferred CodecID) FrameFlags {
+	f &^= codecMask<<codecShift | codecMask<<preferredCodecShift
+	return f | FrameFlags(codec&codecMask)<<codecShift | FrameFlags(preferred&codecMask)<<preferredCodecShift
+}
+
 // SerializeMessage converts message to byte slice
 func SerializeMessage(q *Message) ([]byte, error) {
-	var msgBuffer bytes.Buffer
-	enc := gob.NewEncoder(&msgBuffer)
-	err := enc.Encode(q)
+	body, err := EncodeMessage(q)
 	if err != nil {
 		return nil, err
 	}
 
-	length := msgBuffer.Len()
-
-	var lengthBytes [8]byte
-	binary.PutUvarint(lengthBytes[:], uint64(length))
-
-	var result []byte
-	result = append(result, lengthBytes[:]...)
-	result = append(result, msgBuffer.Bytes()...)
-
-	return result, nil
+	return NewFrame(body, 0), nil
 }
 
 // DeserializeMessage reads message from io.Reader
 func DeserializeMessage(conn io.Reader) (*Message, error) {
-	lengthBytes := make([]byte, 8)
-	_, err := conn.Read(lengthBytes)
+	body, _, err := ReadFrame(conn)
 	if err != nil {
 		return nil, err
 	}
 
-	lengthReader := bytes.NewBuffer(lengthBytes)
-	length, err := binary.ReadUvarint(lengthReader)
-	if err != nil {
-		return nil, err
-	}
+	return DecodeMessage(body)
+}
 
-	msgBytes := make([]byte, length)
-	_, err = conn.Read(msgBytes)
+// EncodeMessage converts message to byte slice without length prefix
+func EncodeMessage(q *Message) ([]byte, error) {
+	var msgBuffer bytes.Buffer
+	err := EncodeMessageTo(&msgBuffer, q)
 	if err != nil {
 		return nil, err
 	}
 
-	reader := bytes.NewBuffer(msgBytes)
-	msg := &Message{}
-	dec := gob.NewDecoder(reader)
+	return msgBuffer.Bytes(), nil
+}
+
+// EncodeMessageTo appends message without length prefix to buffer
+func EncodeMessageTo(buffer *bytes.Buffer, q *Message) error {
+	return gobCodec{}.Encode(buffer, q)
+}
 
-	err = dec.Decode(msg)
-	if err != nil {
-		return nil, err
-	}
+// DecodeMessage converts byte slice without length prefix to message
+func DecodeMessage(body []byte) (*Message, error) {
+	return gobCodec{}.Decode(body)
+}
 
-	return msg, nil
+// NewFrame prefixes body with its length and flags
+func NewFrame(body []byte, flags FrameFlags) []byte {
+	var lengthBytes [FrameHeaderSize]byte
+	binary.PutUvarint(lengthBytes[:], uint64(len(body)))
+	lengthBytes[FrameHeaderSize-1] = byte(flags)
+
+	var result []byte
+	result = append(result, lengthBytes[:]...)
+	result = append(result, body...)
+
+	return result
+}
+
+// ReadFrame reads length prefixed body and its flags from io.Reader
+func ReadFrame(conn io.Reader) ([]byte, FrameFlags, error) {
+	return ReadFrameTo(conn, &bytes.Buffer{})
 }
 
 func init() {
+	gob.Register(&RequestDataPing{})
 	gob.Register(&RequestDataFindNode{})
 	gob.Register(&RequestDataFindValue{})
 	gob.Register(&RequestDataStore{})
 	gob.Register(&RequestDataRPC{})
+	gob.Register(&RequestDataChallenge{})
+	gob.Register(&RequestDataAudit{})
+	gob.Register(&RequestDataRelay{})
+	gob.Register(&RequestDataPunch{})
+	gob.Register(&RequestDataWatch{})
+	gob.Register(&RequestDataNotify{})
+	gob.Register(&RequestDataRegisterService{})
+	gob.Register(&RequestDataLookupService{})
+	gob.Register(&RequestDataPutMutable{})
+	gob.Register(&RequestDataGetMutable{})
+	gob.Register(&RequestDataDelete{})
+	gob.Register(&RequestDataExpired{})
+	gob.Register(&RequestDataAddProvider{})
+	gob.Register(&RequestDataGetProviders{})
 
+	gob.Register(&ResponseDataPing{})
 	gob.Register(&ResponseDataFindNode{})
 	gob.Register(&ResponseDataFindValue{})
 	gob.Register(&ResponseDataStore{})
 	gob.Register(&ResponseDataRPC{})
+	gob.Register(&ResponseDataChallenge{})
+	gob.Register(&ResponseDataAudit{})
+	gob.Register(&ResponseDataRelay{})
+	gob.Register(&ResponseDataPunch{})
+	gob.Register(&ResponseDataWatch{})
+	gob.Register(&ResponseDataNotify{})
+	gob.Register(&ResponseDataRegisterService{})
+	gob.Register(&ResponseDataLookupService{})
+	gob.Register(&ResponseDataPutMutable{})
+	gob.Register(&ResponseDataGetMutable{})
+	gob.Register(&ResponseDataDelete{})
+	gob.Register(&ResponseDataExpired{})
+	gob.Register(&ResponseDataAddProvider{})
+	gob.Register(&ResponseDataGetProviders{})
+
+	err := RegisterCodec(gobCodec{})
+	if err != nil {
+		panic(err)
+	}
 }
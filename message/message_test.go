@@ -18,6 +18,7 @@ package message
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/insolar/network/node"
@@ -59,10 +60,25 @@ func TestMessage_IsValid_Ok(t *testing.T) {
 		data        interface{}
 	}{
 		{"TypePing", TypePing, nil},
+		{"TypePing with version", TypePing, &RequestDataPing{Version: "0.1.0"}},
 		{"TypeFindNode", TypeFindNode, &RequestDataFindNode{}},
 		{"TypeFindValue", TypeFindValue, &RequestDataFindValue{}},
 		{"TypeStore", TypeStore, &RequestDataStore{}},
 		{"TypeRPC", TypeRPC, &RequestDataRPC{"test", [][]byte{}}},
+		{"TypeChallenge", TypeChallenge, &RequestDataChallenge{}},
+		{"TypeAudit", TypeAudit, &RequestDataAudit{}},
+		{"TypeRelay", TypeRelay, &RequestDataRelay{}},
+		{"TypePunch", TypePunch, &RequestDataPunch{}},
+		{"TypeWatch", TypeWatch, &RequestDataWatch{}},
+		{"TypeNotify", TypeNotify, &RequestDataNotify{}},
+		{"TypeRegisterService", TypeRegisterService, &RequestDataRegisterService{}},
+		{"TypeLookupService", TypeLookupService, &RequestDataLookupService{}},
+		{"TypePutMutable", TypePutMutable, &RequestDataPutMutable{}},
+		{"TypeGetMutable", TypeGetMutable, &RequestDataGetMutable{}},
+		{"TypeDelete", TypeDelete, &RequestDataDelete{}},
+		{"TypeExpired", TypeExpired, &RequestDataExpired{}},
+		{"TypeAddProvider", TypeAddProvider, &RequestDataAddProvider{}},
+		{"TypeGetProviders", TypeGetProviders, &RequestDataGetProviders{}},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -81,6 +97,7 @@ func TestMessage_IsValid_Fail(t *testing.T) {
 	}{
 		{"incorrect request", TypeStore, &RequestDataRPC{"test", [][]byte{}}},
 		{"incorrect type", messageType(1337), &RequestDataFindNode{}},
+		{"incorrect ping", TypePing, &RequestDataFindNode{}},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -144,3 +161,52 @@ func TestDeserializeMessage(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, deserialized, msg)
 }
+
+func TestMessage_RemoteAddress(t *testing.T) {
+	msg := NewBuilder().Type(TypePing).Build()
+	assert.Equal(t, "", msg.RemoteAddress())
+
+	msg.SetRemoteAddress("127.0.0.1:31337")
+	assert.Equal(t, "127.0.0.1:31337", msg.RemoteAddress())
+
+	serialized, _ := SerializeMessage(msg)
+	deserialized, err := DeserializeMessage(bytes.NewBuffer(serialized))
+	assert.NoError(t, err)
+	assert.Equal(t, "", deserialized.RemoteAddress())
+}
+
+func TestMessage_Headers(t *testing.T) {
+	msg := NewBuilder().Type(TypePing).Headers(map[string]string{"tenant": "acme"}).Build()
+	assert.True(t, msg.IsValid())
+
+	serialized, _ := SerializeMessage(msg)
+	deserialized, err := DeserializeMessage(bytes.NewBuffer(serialized))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, deserialized.Headers)
+
+	msg.Headers["trace"] = strings.Repeat("x", MaxHeadersSize)
+	assert.False(t, msg.IsValid())
+}
+
+func TestFrame_Flags(t *testing.T) {
+	msg := NewBuilder().Type(TypePing).Build()
+	body, err := EncodeMessage(msg)
+	assert.NoError(t, err)
+
+	frame := NewFrame(body, FlagAcceptsCompressed|FlagCompressed)
+	readBody, flags, err := ReadFrame(bytes.NewBuffer(frame))
+	assert.NoError(t, err)
+	assert.Equal(t, body, readBody)
+	assert.Equal(t, FlagAcceptsCompressed|FlagCompressed, flags)
+
+	// Flags are ignored by plain deserialization
+	deserialized, err := DeserializeMessage(bytes.NewBuffer(NewFrame(body, FlagAcceptsCompressed)))
+	assert.NoError(t, err)
+	assert.Equal(t, msg, deserialized)
+}
+
+func TestMessageType_String(t *testing.T) {
+	assert.Equal(t, "ping", TypePing.String())
+	assert.Equal(t, "findvalue", TypeFindValue.String())
+	assert.Equal(t, "unknown", messageType(0).String())
+}
@@ -16,6 +16,19 @@
 
 package message
 
+import (
+	"crypto/ed25519"
+	"time"
+
+	"github.com/insolar/network/store"
+)
+
+// RequestDataPing is data for Ping request, older nodes send ping without data
+type RequestDataPing struct {
+	Version string
+	Build   string
+}
+
 // RequestDataFindNode is data for FindNode request
 type RequestDataFindNode struct {
 	Target []byte
@@ -29,7 +42,15 @@ type RequestDataFindValue struct {
 // RequestDataStore is data for Store request
 type RequestDataStore struct {
 	Data       []byte
-	Publishing bool // Whether or not we are the original publisher
+	Publishing bool   // Whether or not we are the original publisher
+	Token      []byte // Write token issued by receiver in FindNode response
+	Version    store.Version
+	Compressed bool              // Whether Data is compressed with store.CompressValue
+	PublicKey  ed25519.PublicKey // Key of publisher, nodes of previous releases let it delete value without proof
+	Namespace  string            // Namespace key of value is derived in, see store.NamespacedKey
+	TTL        time.Duration     // Lifetime of value chosen by publisher, default expiration is used if not set
+	Cached     bool              // Whether Data is a copy cached along lookup path, which expires after TTL without replication
+	Ownership  *store.Ownership  // Publisher's signed claim of value, holders let only its key delete value
 }
 
 // RequestDataRPC is data for RPC request
@@ -37,3 +58,84 @@ type RequestDataRPC struct {
 	Method string
 	Args   [][]byte
 }
+
+// RequestDataChallenge is data for storage receipt challenge request
+type RequestDataChallenge struct {
+	Key   []byte
+	Nonce []byte
+}
+
+// RequestDataAudit is data for storage audit request
+type RequestDataAudit struct {
+	Key    []byte
+	Offset int
+	Length int
+	Nonce  []byte
+}
+
+// RequestDataRelay is data for relay registration request
+type RequestDataRelay struct {
+	Address string // Address requests to which are forwarded over circuit
+}
+
+// RequestDataPunch is data for hole punching request sent to relay of peer,
+// relay introduces requester to peer with the same request carrying requester's endpoint
+type RequestDataPunch struct {
+	Address  string // Advertised address of peer
+	Endpoint string // Endpoint of requester observed by relay
+}
+
+// RequestDataWatch is data for watch request, zero lease cancels the watch
+type RequestDataWatch struct {
+	Key   []byte
+	Lease time.Duration
+}
+
+// RequestDataNotify is data for update of watched value pushed by its holder
+type RequestDataNotify struct {
+	Key       []byte
+	Value     []byte
+	Version   store.Version
+	Namespace string
+}
+
+// RequestDataRegisterService is data for service registration request
+type RequestDataRegisterService struct {
+	Record *store.ServiceRecord
+}
+
+// RequestDataLookupService is data for service lookup request
+type RequestDataLookupService struct {
+	Name string
+}
+
+// RequestDataPutMutable is data for mutable record publication request
+type RequestDataPutMutable struct {
+	Record *store.MutableRecord
+}
+
+// RequestDataDelete is data for value deletion request
+type RequestDataDelete struct {
+	Deletion *store.Deletion
+}
+
+// RequestDataExpired is data for expiry notification request, receipt signed by holder
+// proves that value expired on it
+type RequestDataExpired struct {
+	Receipt *store.Receipt
+}
+
+// RequestDataGetMutable is data for mutable record lookup request
+type RequestDataGetMutable struct {
+	Key []byte
+}
+
+// RequestDataAddProvider is data for provider announcement request
+type RequestDataAddProvider struct {
+	Record *store.ProviderRecord
+}
+
+// RequestDataGetProviders is data for providers lookup request
+type RequestDataGetProviders struct {
+	Key []byte
+}
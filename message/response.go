@@ -16,22 +16,57 @@
 
 package message
 
-import "github.com/insolar/network/node"
+import (
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
+)
+
+// ErrorCode tells why request was rejected by remote node
+type ErrorCode int
+
+const (
+	// ErrorNone means request was not rejected
+	ErrorNone = ErrorCode(iota)
+	// ErrorReadOnly means remote node is in read-only maintenance mode
+	ErrorReadOnly
+	// ErrorTooLarge means value exceeds maximum size accepted by remote node
+	ErrorTooLarge
+	// ErrorInvalidRecord means value was rejected by validator of remote node
+	ErrorInvalidRecord
+)
+
+// ResponseDataPing is data for Ping response
+type ResponseDataPing struct {
+	Version string
+	Build   string
+}
 
 // ResponseDataFindNode is data for FindNode response
 type ResponseDataFindNode struct {
 	Closest []*node.Node
+	Failed  []*node.Node // Recently failed nodes known to responder
+	Token   []byte       // Write token required to Store on responder
 }
 
 // ResponseDataFindValue is data for FindValue response
 type ResponseDataFindValue struct {
 	Closest []*node.Node
 	Value   []byte
+	Failed  []*node.Node  // Recently failed nodes known to responder
+	Version store.Version // Version of Value, replicas found by lookup are reconciled by it
+	// Namespace Value was stored under if responder keeps track of it, requester checks key of Value with it
+	Namespace string
+	Token     []byte // Write token issued if Value is not held, so value found elsewhere can be cached on responder
 }
 
 // ResponseDataStore is data for Store response
 type ResponseDataStore struct {
 	Success bool
+	Receipt *store.Receipt
+	Code    ErrorCode
+	Version store.Version // Version of value held by receiver
 }
 
 // ResponseDataRPC is data for RPC response
@@ -39,4 +74,81 @@ type ResponseDataRPC struct {
 	Success bool
 	Result  []byte
 	Error   string
+	Code    ErrorCode
+}
+
+// ResponseDataChallenge is data for storage receipt challenge response
+type ResponseDataChallenge struct {
+	Holds     bool
+	Signature []byte
+}
+
+// ResponseDataAudit is data for storage audit response
+type ResponseDataAudit struct {
+	Found bool
+	Hash  []byte
+}
+
+// ResponseDataRelay is data for relay registration response
+type ResponseDataRelay struct {
+	Success bool
+}
+
+// ResponseDataPunch is data for hole punching response
+type ResponseDataPunch struct {
+	Success  bool
+	Endpoint string // Endpoint of peer observed by relay
+}
+
+// ResponseDataWatch is data for watch response
+type ResponseDataWatch struct {
+	Success bool
+	Lease   time.Duration // Lease granted by holder, watch must be renewed before it ends
+}
+
+// ResponseDataNotify is data for notify response
+type ResponseDataNotify struct {
+	Success bool
+}
+
+// ResponseDataRegisterService is data for service registration response
+type ResponseDataRegisterService struct {
+	Success bool
+}
+
+// ResponseDataLookupService is data for service lookup response
+type ResponseDataLookupService struct {
+	Records []*store.ServiceRecord
+}
+
+// ResponseDataPutMutable is data for mutable record publication response.
+// Record node holds is returned if it is kept instead of published one.
+type ResponseDataPutMutable struct {
+	Success bool
+	Record  *store.MutableRecord
+}
+
+// ResponseDataDelete is data for value deletion response
+type ResponseDataDelete struct {
+	Success bool
+}
+
+// ResponseDataExpired is data for expiry notification response
+type ResponseDataExpired struct {
+	Success bool
+}
+
+// ResponseDataGetMutable is data for mutable record lookup response
+type ResponseDataGetMutable struct {
+	Record *store.MutableRecord
+}
+
+// ResponseDataAddProvider is data for provider announcement response
+type ResponseDataAddProvider struct {
+	Success bool
+}
+
+// ResponseDataGetProviders is data for providers lookup response
+type ResponseDataGetProviders struct {
+	Records []*store.ProviderRecord
 }
@@ -0,0 +1,80 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package testvectors
+
+// Code generated by go test -update. DO NOT EDIT.
+
+// golden are hex encoded frames of vectors
+var golden = map[string]string{
+	"ping-legacy-request":      "ce03000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c0000006fff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000102012a00",
+	"ping-request":             "af04000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffbbff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000102012a01182a6d6573736167652e526571756573744461746150696e67ff8b0301010f526571756573744461746150696e6701ff8c000102010756657273696f6e010c0001054275696c64010c00000013ff8c0f0105312e302e3001056275696c640000",
+	"ping-response":            "b304000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffbdff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000102012a01192a6d6573736167652e526573706f6e73654461746150696e67ff8d03010110526573706f6e73654461746150696e6701ff8e000102010756657273696f6e010c0001054275696c64010c00000015ff8e0f0105312e302e3001056275696c6400020100",
+	"store-request":            "b805000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffd6ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000104012a01192a6d6573736167652e526571756573744461746153746f7265ff8f03010110526571756573744461746153746f726501ff90000104010444617461010a00010a5075626c697368696e670102000105546f6b656e010a00010756657273696f6e01ff9200000032ff910301010756657273696f6e01ff92000102010954696d657374616d7001ff940001095075626c6973686572010a00000010ff930501010454696d6501ff940000003dff903901046461746101010105746f6b656e01010f010000000ed0fa260000000000ffff01140101010101010101010101010101010101010101000000",
+	"store-response":           "a907000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffd8ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000104012a011a2a6d6573736167652e526573706f6e73654461746153746f7265ff9503010111526573706f6e73654461746153746f726501ff9600010401075375636365737301020001075265636569707401ff98000104436f6465010400010756657273696f6e01ff9200000054ff97030101075265636569707401ff9800010501034b6579010a000106486f6c646572010a00010a45787069726174696f6e01ff940001095075626c69634b6579010a0001095369676e6174757265010a00000010ff930501010454696d6501ff9400000032ff910301010756657273696f6e01ff92000102010954696d657374616d7001ff940001095075626c6973686572010a000000ffd6ff96ffcf0101010114030303030303030303030303030303030303030301140202020202020202020202020202020202020202010f010000000ed0fa260000000000ffff012004040404040404040404040404040404040404040404040404040404040404040140050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050002010f010000000ed0fa260000000000ffff011401010101010101010101010101010101010101010000020100",
+	"findnode-request":         "b404000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffb8ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000106012a011c2a6d6573736167652e526571756573744461746146696e644e6f6465ff9903010113526571756573744461746146696e644e6f646501ff9a0001010106546172676574010a0000001bff9a17011403030303030303030303030303030303030303030000",
+	"findnode-response":        "c705000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffd2ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000106012a011d2a6d6573736167652e526573706f6e73654461746146696e644e6f6465ff9b03010114526573706f6e73654461746146696e644e6f646501ff9c0001030107436c6f7365737401ff9e0001064661696c656401ff9e000105546f6b656e010a0000001bff9d0201010c5b5d2a6e6f64652e4e6f646501ff9e0001ff82000078ff9c720101011406060606060606060606060606060606060606060101011000000000000000000000ffff7f00000101fef4d600000200000101011407070707070707070707070707070707070707070101011000000000000000000000ffff7f00000101fef4d800000200000105746f6b656e00020100",
+	"findvalue-request":        "b604000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffbaff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000108012a011d2a6d6573736167652e526571756573744461746146696e6456616c7565ff9f03010114526571756573744461746146696e6456616c756501ffa00001010106546172676574010a0000001bffa017011403030303030303030303030303030303030303030000",
+	"findvalue-response":       "c805000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffd4ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000108012a011e2a6d6573736167652e526573706f6e73654461746146696e6456616c7565ffa103010115526573706f6e73654461746146696e6456616c756501ffa20001030107436c6f7365737401ff9e00010556616c7565010a0001064661696c656401ff9e0000001bff9d0201010c5b5d2a6e6f64652e4e6f646501ff9e0001ff82000077ffa2710101011406060606060606060606060606060606060606060101011000000000000000000000ffff7f00000101fef4d600000200000104646174610101011407070707070707070707070707070707070707070101011000000000000000000000ffff7f00000101fef4d8000002000000020100",
+	"rpc-request":              "c404000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffb8ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d40000020000010a012a01172a6d6573736167652e5265717565737444617461525043ffa30301010e526571756573744461746152504301ffa400010201064d6574686f64010c0001044172677301ffa600000017ffa5020101095b5d5b5d75696e743801ffa600010a000013ffa40f01066d6574686f640101036172670000",
+	"rpc-response":             "c804000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffcfff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d40000020000010a012a01182a6d6573736167652e526573706f6e736544617461525043ffa70301010f526573706f6e73654461746152504301ffa80001040107537563636573730102000106526573756c74010a0001054572726f72010c000104436f6465010400000018ffa8120206726573756c7401056572726f72010200020100",
+	"challenge-request":        "c404000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffc1ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d40000020000010c012a011d2a6d6573736167652e52657175657374446174614368616c6c656e6765ffa90301011452657175657374446174614368616c6c656e676501ffaa00010201034b6579010a0001054e6f6e6365010a00000022ffaa1e0114030303030303030303030303030303030303030301056e6f6e63650000",
+	"challenge-response":       "f504000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffc9ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d40000020000010c012a011e2a6d6573736167652e526573706f6e7365446174614368616c6c656e6765ffab03010115526573706f6e7365446174614368616c6c656e676501ffac0001020105486f6c647301020001095369676e6174757265010a0000004bffac45010101400505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050500020100",
+	"audit-request":            "d604000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffcfff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d40000020000010e012a01192a6d6573736167652e52657175657374446174614175646974ffad030101105265717565737444617461417564697401ffae00010401034b6579010a0001064f666673657401040001064c656e67746801040001054e6f6e6365010a00000026ffae22011403030303030303030303030303030303030303030102010401056e6f6e63650000",
+	"audit-response":           "ac04000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffbcff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d40000020000010e012a011a2a6d6573736167652e526573706f6e7365446174614175646974ffaf03010111526573706f6e736544617461417564697401ffb00001020105466f756e64010200010448617368010a0000000fffb009010101046861736800020100",
+	"relay-request":            "aa04000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffb3ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000110012a01192a6d6573736167652e526571756573744461746152656c6179ffb103010110526571756573744461746152656c617901ffb2000101010741646472657373010c00000016ffb212010f3132372e302e302e313a33313334310000",
+	"relay-response":           "9f04000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffb5ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000110012a011a2a6d6573736167652e526573706f6e73654461746152656c6179ffb303010111526573706f6e73654461746152656c617901ffb4000101010753756363657373010200000009ffb403010100020100",
+	"punch-request":            "c704000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffc0ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000112012a01192a6d6573736167652e526571756573744461746150756e6368ffb503010110526571756573744461746150756e636801ffb6000102010741646472657373010c000108456e64706f696e74010c00000026ffb622010f3132372e302e302e313a3331333431010e31302e302e302e313a33313334320000",
+	"punch-response":           "bc04000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffc2ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000112012a011a2a6d6573736167652e526573706f6e73654461746150756e6368ffb703010111526573706f6e73654461746150756e636801ffb80001020107537563636573730102000108456e64706f696e74010c00000019ffb8130101010e31302e302e302e323a333133343300020100",
+	"watch-request":            "bc04000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffb9ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000114012a01192a6d6573736167652e52657175657374446174615761746368ffb9030101105265717565737444617461576174636801ffba00010201034b6579010a0001054c65617365010400000022ffba1e0114030303030303030303030303030303030303030301fb1bf08eb0000000",
+	"watch-response":           "b004000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffbfff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000114012a011a2a6d6573736167652e526573706f6e7365446174615761746368ffbb03010111526573706f6e736544617461576174636801ffbc00010201075375636365737301020001054c65617365010400000010ffbc0a010101fb1bf08eb00000020100",
+	"notify-request":           "b705000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffc8ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000116012a011a2a6d6573736167652e52657175657374446174614e6f74696679ffbd0301011152657175657374446174614e6f7469667901ffbe00010301034b6579010a00010556616c7565010a00010756657273696f6e01ff9200000032ff910301010756657273696f6e01ff92000102010954696d657374616d7001ff940001095075626c6973686572010a00000010ff930501010454696d6501ff940000004affbe460114030303030303030303030303030303030303030301046461746101010f010000000ed0fa260000000000ffff01140101010101010101010101010101010101010101000000",
+	"notify-response":          "a104000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffb7ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000116012a011b2a6d6573736167652e526573706f6e7365446174614e6f74696679ffbf03010112526573706f6e7365446174614e6f7469667901ffc0000101010753756363657373010200000009ffc003010100020100",
+	"registerservice-request":  "d106000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffc7ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000118012a01232a6d6573736167652e5265717565737444617461526567697374657253657276696365ffc10301011a526571756573744461746152656769737465725365727669636501ffc200010101065265636f726401ffc40000006affc30301010d536572766963655265636f726401ffc400010601044e616d65010c00010741646472657373010c0001095075626c6973686572010a00010a45787069726174696f6e01ff940001095075626c69634b6579010a0001095369676e6174757265010a00000010ff930501010454696d6501ff94000000ffacffc2ffa701010773657276696365010e3132372e302e302e313a3830383001140101010101010101010101010101010101010101010f010000000ed0fa260000000000ffff01200404040404040404040404040404040404040404040404040404040404040404014005050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505000000",
+	"registerservice-response": "b304000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffc9ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000118012a01242a6d6573736167652e526573706f6e736544617461526567697374657253657276696365ffc50301011b526573706f6e73654461746152656769737465725365727669636501ffc6000101010753756363657373010200000009ffc603010100020100",
+	"lookupservice-request":    "af04000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffc0ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d40000020000011a012a01212a6d6573736167652e52657175657374446174614c6f6f6b757053657276696365ffc70301011852657175657374446174614c6f6f6b75705365727669636501ffc800010101044e616d65010c0000000effc80a0107736572766963650000",
+	"lookupservice-response":   "f906000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffc6ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d40000020000011a012a01222a6d6573736167652e526573706f6e7365446174614c6f6f6b757053657276696365ffc903010119526573706f6e7365446174614c6f6f6b75705365727669636501ffca00010101075265636f72647301ffcc00000025ffcb020101165b5d2a73746f72652e536572766963655265636f726401ffcc0001ffc400006affc30301010d536572766963655265636f726401ffc400010601044e616d65010c00010741646472657373010c0001095075626c6973686572010a00010a45787069726174696f6e01ff940001095075626c69634b6579010a0001095369676e6174757265010a00000010ff930501010454696d6501ff94000000ffafffcaffa80101010773657276696365010e3132372e302e302e313a3830383001140101010101010101010101010101010101010101010f010000000ed0fa260000000000ffff012004040404040404040404040404040404040404040404040404040404040404040140050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050000020100",
+
+	"putmutable-request":  "ea05000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffbdff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d40000020000011c012a011e2a6d6573736167652e52657175657374446174615075744d757461626c65ffcd0301011552657175657374446174615075744d757461626c6501ffce00010101065265636f726401ffd000000052ffcf0301010d4d757461626c655265636f726401ffd000010501095075626c69634b6579010a00010453616c74010a000103536571010400010556616c7565010a0001095369676e6174757265010a00000079ffce750101200404040404040404040404040404040404040404040404040404040404040404010473616c740102010464617461014005050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505000000",
+	"putmutable-response": "fa05000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffcbff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d40000020000011c012a011f2a6d6573736167652e526573706f6e7365446174615075744d757461626c65ffd103010116526573706f6e7365446174615075744d757461626c6501ffd200010201075375636365737301020001065265636f726401ffd000000052ffcf0301010d4d757461626c655265636f726401ffd000010501095075626c69634b6579010a00010453616c74010a000103536571010400010556616c7565010a0001095369676e6174757265010a0000007bffd2750201200404040404040404040404040404040404040404040404040404040404040404010473616c7401020104646174610140050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050000020100",
+	"getmutable-request":  "b504000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffb9ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d40000020000011e012a011e2a6d6573736167652e52657175657374446174614765744d757461626c65ffd30301011552657175657374446174614765744d757461626c6501ffd400010101034b6579010a0000001bffd417011403030303030303030303030303030303030303030000",
+	"getmutable-response": "ee05000000000000677f030101074d65737361676501ff80000107010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c000000ffbfff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d40000020000011e012a011f2a6d6573736167652e526573706f6e7365446174614765744d757461626c65ffd503010116526573706f6e7365446174614765744d757461626c6501ffd600010101065265636f726401ffd000000052ffcf0301010d4d757461626c655265636f726401ffd000010501095075626c69634b6579010a00010453616c74010a000103536571010400010556616c7565010a0001095369676e6174757265010a0000007bffd6750101200404040404040404040404040404040404040404040404040404040404040404010473616c7401020104646174610140050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050000020100",
+
+	"delete-request":  "b706000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000ffb7ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000120012a011a2a6d6573736167652e526571756573744461746144656c657465ffd903010111526571756573744461746144656c65746501ffda000101010844656c6574696f6e01ffdc0000004affdb0301010844656c6574696f6e01ffdc00010401034b6579010a00010a45787069726174696f6e01ff960001095075626c69634b6579010a0001095369676e6174757265010a00000010ff950501010454696d6501ff96000000ff93ffdaff8e0101140303030303030303030303030303030303030303010f010000000ed0fa260000000000ffff01200404040404040404040404040404040404040404040404040404040404040404014005050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505000000",
+	"delete-response": "d004000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000ffb7ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000120012a011b2a6d6573736167652e526573706f6e73654461746144656c657465ffdd03010112526573706f6e73654461746144656c65746501ffde000101010753756363657373010200000009ffde03010100020100",
+
+	"expired-request":  "d806000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000ffb8ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000122012a011b2a6d6573736167652e526571756573744461746145787069726564ffdf0301011252657175657374446174614578706972656401ffe000010101075265636569707401ff9a00000054ff99030101075265636569707401ff9a00010501034b6579010a000106486f6c646572010a00010a45787069726174696f6e01ff960001095075626c69634b6579010a0001095369676e6174757265010a00000010ff950501010454696d6501ff96000000ffa9ffe0ffa4010114030303030303030303030303030303030303030301140202020202020202020202020202020202020202010f010000000ed0fa260000000000ffff01200404040404040404040404040404040404040404040404040404040404040404014005050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505000000",
+	"expired-response": "d204000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000ffb9ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000122012a011c2a6d6573736167652e526573706f6e73654461746145787069726564ffe103010113526573706f6e7365446174614578706972656401ffe2000101010753756363657373010200000009ffe203010100020100",
+
+	"namespaced-store-request":  "b806000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000fe0101ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000104012a01192a6d6573736167652e526571756573744461746153746f7265ff9103010110526571756573744461746153746f726501ff92000107010444617461010a00010a5075626c697368696e670102000105546f6b656e010a00010756657273696f6e01ff9400010a436f6d7072657373656401020001095075626c69634b6579010a0001094e616d657370616365010c00000032ff930301010756657273696f6e01ff94000102010954696d657374616d7001ff960001095075626c6973686572010a00000010ff950501010454696d6501ff9600000062ff925e0104646174610205746f6b656e01010f010000000ed0fa260000000000ffff01140101010101010101010101010101010101010101000220040404040404040404040404040404040404040404040404040404040404040401036170700000",
+	"namespaced-notify-request": "f905000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000ffd6ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000116012a011a2a6d6573736167652e52657175657374446174614e6f74696679ffbf0301011152657175657374446174614e6f7469667901ffc000010401034b6579010a00010556616c7565010a00010756657273696f6e01ff940001094e616d657370616365010c00000032ff930301010756657273696f6e01ff94000102010954696d657374616d7001ff960001095075626c6973686572010a00000010ff950501010454696d6501ff960000004fffc04b0114030303030303030303030303030303030303030301046461746101010f010000000ed0fa260000000000ffff011401010101010101010101010101010101010101010001036170700000",
+
+	"ttl-store-request": "a006000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000fe0109ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000104012a01192a6d6573736167652e526571756573744461746153746f7265ff9103010110526571756573744461746153746f726501ff92000108010444617461010a00010a5075626c697368696e670102000105546f6b656e010a00010756657273696f6e01ff9400010a436f6d7072657373656401020001095075626c69634b6579010a0001094e616d657370616365010c00010354544c010400000032ff930301010756657273696f6e01ff94000102010954696d657374616d7001ff960001095075626c6973686572010a00000010ff950501010454696d6501ff9600000042ff923e0104646174610205746f6b656e01010f010000000ed0fa260000000000ffff011401010101010101010101010101010101010101010004fb1bf08eb0000000",
+
+	"addprovider-request":   "8507000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000ffbfff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000124012a011f2a6d6573736167652e526571756573744461746141646450726f7669646572ffe303010116526571756573744461746141646450726f766964657201ffe400010101065265636f726401ffe600000069ffe50301010e50726f76696465725265636f726401ffe600010601034b6579010a00010850726f7669646572010a00010741646472657373010c00010a45787069726174696f6e01ff960001095075626c69634b6579010a0001095369676e6174757265010a00000010ff950501010454696d6501ff96000000ffbaffe4ffb5010114030303030303030303030303030303030303030301140101010101010101010101010101010101010101010f3132372e302e302e313a3331333339010f010000000ed0fa260000000000ffff01200404040404040404040404040404040404040404040404040404040404040404014005050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505000000",
+	"addprovider-response":  "da04000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000ffc1ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000124012a01202a6d6573736167652e526573706f6e73654461746141646450726f7669646572ffe703010117526573706f6e73654461746141646450726f766964657201ffe8000101010753756363657373010200000009ffe803010100020100",
+	"getproviders-request":  "e804000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000ffbdff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000126012a01202a6d6573736167652e526571756573744461746147657450726f766964657273ffe903010117526571756573744461746147657450726f76696465727301ffea00010101034b6579010a0000001bffea17011403030303030303030303030303030303030303030000",
+	"getproviders-response": "b407000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000ffc4ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000126012a01212a6d6573736167652e526573706f6e73654461746147657450726f766964657273ffeb03010118526573706f6e73654461746147657450726f76696465727301ffec00010101075265636f72647301ffee00000026ffed020101175b5d2a73746f72652e50726f76696465725265636f726401ffee0001ffe6000069ffe50301010e50726f76696465725265636f726401ffe600010601034b6579010a00010850726f7669646572010a00010741646472657373010c00010a45787069726174696f6e01ff960001095075626c69634b6579010a0001095369676e6174757265010a00000010ff950501010454696d6501ff96000000ffbdffecffb601010114030303030303030303030303030303030303030301140101010101010101010101010101010101010101010f3132372e302e302e313a3331333339010f010000000ed0fa260000000000ffff012004040404040404040404040404040404040404040404040404040404040404040140050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050000020100",
+
+	"versioned-findvalue-response": "8706000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000ffe1ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000108012a011e2a6d6573736167652e526573706f6e73654461746146696e6456616c7565ffa303010115526573706f6e73654461746146696e6456616c756501ffa40001040107436c6f7365737401ffa000010556616c7565010a0001064661696c656401ffa000010756657273696f6e01ff940000001bff9f0201010c5b5d2a6e6f64652e4e6f646501ffa00001ff82000032ff930301010756657273696f6e01ff94000102010954696d657374616d7001ff960001095075626c6973686572010a00000010ff950501010454696d6501ff9600000036ffa43002046461746102010f010000000ed0fa260000000000ffff011401010101010101010101010101010101010101010000020100",
+
+	"namespaced-findvalue-response": "f305000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000ffefff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000108012a011e2a6d6573736167652e526573706f6e73654461746146696e6456616c7565ffa303010115526573706f6e73654461746146696e6456616c756501ffa40001050107436c6f7365737401ffa000010556616c7565010a0001064661696c656401ffa000010756657273696f6e01ff940001094e616d657370616365010c0000001bff9f0201010c5b5d2a6e6f64652e4e6f646501ffa00001ff82000032ff930301010756657273696f6e01ff94000102010954696d657374616d7001ff960001095075626c6973686572010a00000010ff950501010454696d6501ff9600000014ffa40e0204646174610200010361707000020100",
+
+	"cached-store-request":     "ad06000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000fe0114ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000104012a01192a6d6573736167652e526571756573744461746153746f7265ff9103010110526571756573744461746153746f726501ff92000109010444617461010a00010a5075626c697368696e670102000105546f6b656e010a00010756657273696f6e01ff9400010a436f6d7072657373656401020001095075626c69634b6579010a0001094e616d657370616365010c00010354544c0104000106436163686564010200000032ff930301010756657273696f6e01ff94000102010954696d657374616d7001ff960001095075626c6973686572010a00000010ff950501010454696d6501ff9600000044ff92400104646174610205746f6b656e01010f010000000ed0fa260000000000ffff011401010101010101010101010101010101010101010004fb1bf08eb00001010000",
+	"token-findvalue-response": "ae06000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000fff9ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000108012a011e2a6d6573736167652e526573706f6e73654461746146696e6456616c7565ffa303010115526573706f6e73654461746146696e6456616c756501ffa40001060107436c6f7365737401ffa000010556616c7565010a0001064661696c656401ffa000010756657273696f6e01ff940001094e616d657370616365010c000105546f6b656e010a0000001bff9f0201010c5b5d2a6e6f64652e4e6f646501ffa00001ff82000032ff930301010756657273696f6e01ff94000102010954696d657374616d7001ff960001095075626c6973686572010a00000010ff950501010454696d6501ff9600000045ffa43f0101011406060606060606060606060606060606060606060101011000000000000000000000ffff7f00000101fef4d6000002000003000205746f6b656e00020100",
+
+	"owned-store-request": "b008000000000000747f030101074d65737361676501ff80000108010653656e64657201ff82000108526563656976657201ff8200010454797065010400010952657175657374494401060001044461746101100001054572726f72011000010a4973526573706f6e736501020001074865616465727301ff8c0000004eff81030101044e6f646501ff8200010501024944010a0001074164647265737301ff8400010941646472657373657301ff880001084c6f63616c69747901ff8a00010552656c617901ff8400000022ff83030101074164647265737301ff8400010101075544504164647201ff860000002eff85030101075544504164647201ff8600010301024950010a000104506f727401040001045a6f6e65010c00000029ff87040101186d61705b737472696e675d2a6e6f64652e4164647265737301ff8800010c01ff8400002aff89030101084c6f63616c69747901ff8a0001020106526567696f6e010c0001045a6f6e65010c00000021ff8b040101116d61705b737472696e675d737472696e6701ff8c00010c010c0000fe0123ff8001011401010101010101010101010101010101010101010101011000000000000000000000ffff7f00000101fef4d2000002000001011402020202020202020202020202020202020202020101011000000000000000000000ffff7f00000101fef4d400000200000104012a01192a6d6573736167652e526571756573744461746153746f7265ff9103010110526571756573744461746153746f726501ff9200010a010444617461010a00010a5075626c697368696e670102000105546f6b656e010a00010756657273696f6e01ff9400010a436f6d7072657373656401020001095075626c69634b6579010a0001094e616d657370616365010c00010354544c010400010643616368656401020001094f776e65727368697001ff9800000032ff930301010756657273696f6e01ff94000102010954696d657374616d7001ff960001095075626c6973686572010a00000010ff950501010454696d6501ff960000004bff97030101094f776e65727368697001ff9800010401034b6579010a00010a45787069726174696f6e01ff960001095075626c69634b6579010a0001095369676e6174757265010a000000ffebff92ffe60104646174610205746f6b656e01010f010000000ed0fa260000000000ffff0114010101010101010101010101010101010101010100022004040404040404040404040404040404040404040404040404040404040404040401140303030303030303030303030303030303030303010f010000000ed0fa260000000000ffff01200404040404040404040404040404040404040404040404040404040404040404014005050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505050505000000",
+}
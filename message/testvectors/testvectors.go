@@ -0,0 +1,250 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+// Package testvectors provides canonical encoded messages of every type, so alternative implementations
+// and future codecs can check they stay wire compatible.
+//
+// Gob assigns type IDs in order types are first encoded, so encoded bytes are not stable across
+// processes. Frames are compared by decoded messages instead of bytes.
+package testvectors
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
+)
+
+// ErrUnknownVector is returned when there is no test vector with given name
+var ErrUnknownVector = errors.New("unknown test vector")
+
+// Vector is canonical message of one type with frame it is sent in by gob codec
+type Vector struct {
+	Name    string
+	Message *message.Message
+	Frame   []byte
+}
+
+// Vectors returns test vectors of requests and responses of every message type
+func Vectors() []Vector {
+	vectors := messages()
+	for i := range vectors {
+		vectors[i].Frame, _ = hex.DecodeString(golden[vectors[i].Name])
+	}
+	return vectors
+}
+
+// Validate checks that every vector survives round-trip through codec
+func Validate(codec message.Codec) error {
+	for _, vector := range messages() {
+		var buffer bytes.Buffer
+		if err := codec.Encode(&buffer, vector.Message); err != nil {
+			return errors.New(vector.Name + ": " + err.Error())
+		}
+		decoded, err := codec.Decode(buffer.Bytes())
+		if err != nil {
+			return errors.New(vector.Name + ": " + err.Error())
+		}
+		if !reflect.DeepEqual(vector.Message, decoded) {
+			return errors.New(vector.Name + ": decoded message differs")
+		}
+	}
+	return nil
+}
+
+// ValidateFrame checks that frame, e.g. produced by other implementation, carries message of named vector
+func ValidateFrame(name string, frame []byte) error {
+	for _, vector := range messages() {
+		if vector.Name != name {
+			continue
+		}
+		body, flags, err := message.ReadFrame(bytes.NewReader(frame))
+		if err != nil {
+			return err
+		}
+		decoded, err := message.DecodeFrame(body, flags)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(vector.Message, decoded) {
+			return errors.New(name + ": decoded message differs")
+		}
+		return nil
+	}
+	return ErrUnknownVector
+}
+
+// WriteCorpus writes frame of every vector to file named after it in directory
+func WriteCorpus(directory string) error {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return err
+	}
+	for _, vector := range Vectors() {
+		if err := ioutil.WriteFile(filepath.Join(directory, vector.Name+".frame"), vector.Frame, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func testNode(b byte, port int) *node.Node {
+	return &node.Node{
+		ID:      bytes.Repeat([]byte{b}, 20),
+		Address: &node.Address{UDPAddr: net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}},
+	}
+}
+
+func newVector(name string, builder message.Builder) Vector {
+	msg := builder.
+		Sender(testNode(1, 31337)).
+		Receiver(testNode(2, 31338)).
+		Build()
+	msg.RequestID = 42
+	return Vector{Name: name, Message: msg}
+}
+
+// messages returns vectors without frames
+func messages() []Vector {
+	key := bytes.Repeat([]byte{3}, 20)
+	publicKey := bytes.Repeat([]byte{4}, 32)
+	signature := bytes.Repeat([]byte{5}, 64)
+	timestamp := time.Unix(1500000000, 0).UTC()
+	version := store.NewVersion(timestamp, bytes.Repeat([]byte{1}, 20))
+	record := &store.ServiceRecord{
+		Name:       "service",
+		Address:    "127.0.0.1:8080",
+		Publisher:  bytes.Repeat([]byte{1}, 20),
+		Expiration: timestamp,
+		PublicKey:  publicKey,
+		Signature:  signature,
+	}
+	mutable := &store.MutableRecord{
+		PublicKey: publicKey,
+		Salt:      []byte("salt"),
+		Seq:       1,
+		Value:     []byte("data"),
+		Signature: signature,
+	}
+	provider := &store.ProviderRecord{
+		Key:        key,
+		Provider:   bytes.Repeat([]byte{1}, 20),
+		Address:    "127.0.0.1:31339",
+		Expiration: timestamp,
+		PublicKey:  publicKey,
+		Signature:  signature,
+	}
+	closest := []*node.Node{testNode(6, 31339)}
+	failed := []*node.Node{testNode(7, 31340)}
+
+	ping := message.NewBuilder().Type(message.TypePing)
+	storeValue := message.NewBuilder().Type(message.TypeStore)
+	findNode := message.NewBuilder().Type(message.TypeFindNode)
+	findValue := message.NewBuilder().Type(message.TypeFindValue)
+	rpc := message.NewBuilder().Type(message.TypeRPC)
+	challenge := message.NewBuilder().Type(message.TypeChallenge)
+	audit := message.NewBuilder().Type(message.TypeAudit)
+	relay := message.NewBuilder().Type(message.TypeRelay)
+	punch := message.NewBuilder().Type(message.TypePunch)
+	watch := message.NewBuilder().Type(message.TypeWatch)
+	notify := message.NewBuilder().Type(message.TypeNotify)
+	registerService := message.NewBuilder().Type(message.TypeRegisterService)
+	lookupService := message.NewBuilder().Type(message.TypeLookupService)
+	putMutable := message.NewBuilder().Type(message.TypePutMutable)
+	getMutable := message.NewBuilder().Type(message.TypeGetMutable)
+	deleteValue := message.NewBuilder().Type(message.TypeDelete)
+	expired := message.NewBuilder().Type(message.TypeExpired)
+	addProvider := message.NewBuilder().Type(message.TypeAddProvider)
+	getProviders := message.NewBuilder().Type(message.TypeGetProviders)
+
+	return []Vector{
+		newVector("ping-legacy-request", ping),
+		newVector("ping-request", ping.Request(&message.RequestDataPing{Version: "1.0.0", Build: "build"})),
+		newVector("ping-response", ping.Response(&message.ResponseDataPing{Version: "1.0.0", Build: "build"})),
+		newVector("store-request", storeValue.Request(&message.RequestDataStore{
+			Data: []byte("data"), Publishing: true, Token: []byte("token"), Version: version,
+		})),
+		newVector("namespaced-store-request", storeValue.Request(&message.RequestDataStore{
+			Data: []byte("data"), Token: []byte("token"), Version: version, PublicKey: publicKey, Namespace: "app",
+		})),
+		newVector("ttl-store-request", storeValue.Request(&message.RequestDataStore{
+			Data: []byte("data"), Token: []byte("token"), Version: version, TTL: time.Minute,
+		})),
+		newVector("cached-store-request", storeValue.Request(&message.RequestDataStore{
+			Data: []byte("data"), Token: []byte("token"), Version: version, TTL: time.Minute, Cached: true,
+		})),
+		newVector("owned-store-request", storeValue.Request(&message.RequestDataStore{
+			Data: []byte("data"), Token: []byte("token"), Version: version, PublicKey: publicKey,
+			Ownership: &store.Ownership{Key: key, Expiration: timestamp, PublicKey: publicKey, Signature: signature},
+		})),
+		newVector("store-response", storeValue.Response(&message.ResponseDataStore{
+			Success: true,
+			Receipt: &store.Receipt{Key: key, Holder: bytes.Repeat([]byte{2}, 20), Expiration: timestamp, PublicKey: publicKey, Signature: signature},
+			Code:    message.ErrorNone,
+			Version: version,
+		})),
+		newVector("findnode-request", findNode.Request(&message.RequestDataFindNode{Target: key})),
+		newVector("findnode-response", findNode.Response(&message.ResponseDataFindNode{Closest: closest, Failed: failed, Token: []byte("token")})),
+		newVector("findvalue-request", findValue.Request(&message.RequestDataFindValue{Target: key})),
+		newVector("findvalue-response", findValue.Response(&message.ResponseDataFindValue{Closest: closest, Value: []byte("data"), Failed: failed})),
+		newVector("versioned-findvalue-response", findValue.Response(&message.ResponseDataFindValue{Value: []byte("data"), Version: version})),
+		newVector("namespaced-findvalue-response", findValue.Response(&message.ResponseDataFindValue{Value: []byte("data"), Namespace: "app"})),
+		newVector("token-findvalue-response", findValue.Response(&message.ResponseDataFindValue{Closest: closest, Token: []byte("token")})),
+		newVector("rpc-request", rpc.Request(&message.RequestDataRPC{Method: "method", Args: [][]byte{[]byte("arg")}})),
+		newVector("rpc-response", rpc.Response(&message.ResponseDataRPC{Success: false, Result: []byte("result"), Error: "error", Code: message.ErrorReadOnly})),
+		newVector("challenge-request", challenge.Request(&message.RequestDataChallenge{Key: key, Nonce: []byte("nonce")})),
+		newVector("challenge-response", challenge.Response(&message.ResponseDataChallenge{Holds: true, Signature: signature})),
+		newVector("audit-request", audit.Request(&message.RequestDataAudit{Key: key, Offset: 1, Length: 2, Nonce: []byte("nonce")})),
+		newVector("audit-response", audit.Response(&message.ResponseDataAudit{Found: true, Hash: []byte("hash")})),
+		newVector("relay-request", relay.Request(&message.RequestDataRelay{Address: "127.0.0.1:31341"})),
+		newVector("relay-response", relay.Response(&message.ResponseDataRelay{Success: true})),
+		newVector("punch-request", punch.Request(&message.RequestDataPunch{Address: "127.0.0.1:31341", Endpoint: "10.0.0.1:31342"})),
+		newVector("punch-response", punch.Response(&message.ResponseDataPunch{Success: true, Endpoint: "10.0.0.2:31343"})),
+		newVector("watch-request", watch.Request(&message.RequestDataWatch{Key: key, Lease: time.Minute})),
+		newVector("watch-response", watch.Response(&message.ResponseDataWatch{Success: true, Lease: time.Minute})),
+		newVector("notify-request", notify.Request(&message.RequestDataNotify{Key: key, Value: []byte("data"), Version: version})),
+		newVector("namespaced-notify-request", notify.Request(&message.RequestDataNotify{Key: key, Value: []byte("data"), Version: version, Namespace: "app"})),
+		newVector("notify-response", notify.Response(&message.ResponseDataNotify{Success: true})),
+		newVector("registerservice-request", registerService.Request(&message.RequestDataRegisterService{Record: record})),
+		newVector("registerservice-response", registerService.Response(&message.ResponseDataRegisterService{Success: true})),
+		newVector("lookupservice-request", lookupService.Request(&message.RequestDataLookupService{Name: "service"})),
+		newVector("lookupservice-response", lookupService.Response(&message.ResponseDataLookupService{Records: []*store.ServiceRecord{record}})),
+		newVector("putmutable-request", putMutable.Request(&message.RequestDataPutMutable{Record: mutable})),
+		newVector("putmutable-response", putMutable.Response(&message.ResponseDataPutMutable{Success: false, Record: mutable})),
+		newVector("getmutable-request", getMutable.Request(&message.RequestDataGetMutable{Key: key})),
+		newVector("getmutable-response", getMutable.Response(&message.ResponseDataGetMutable{Record: mutable})),
+		newVector("delete-request", deleteValue.Request(&message.RequestDataDelete{Deletion: &store.Deletion{
+			Key: key, Expiration: timestamp, PublicKey: publicKey, Signature: signature,
+		}})),
+		newVector("delete-response", deleteValue.Response(&message.ResponseDataDelete{Success: true})),
+		newVector("expired-request", expired.Request(&message.RequestDataExpired{Receipt: &store.Receipt{
+			Key: key, Holder: bytes.Repeat([]byte{2}, 20), Expiration: timestamp, PublicKey: publicKey, Signature: signature,
+		}})),
+		newVector("expired-response", expired.Response(&message.ResponseDataExpired{Success: true})),
+		newVector("addprovider-request", addProvider.Request(&message.RequestDataAddProvider{Record: provider})),
+		newVector("addprovider-response", addProvider.Response(&message.ResponseDataAddProvider{Success: true})),
+		newVector("getproviders-request", getProviders.Request(&message.RequestDataGetProviders{Key: key})),
+		newVector("getproviders-response", getProviders.Response(&message.ResponseDataGetProviders{Records: []*store.ProviderRecord{provider}})),
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package testvectors
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/insolar/network/message"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "append golden frames of new vectors")
+
+func TestGolden(t *testing.T) {
+	if *update {
+		writeGolden(t)
+	}
+
+	for _, vector := range Vectors() {
+		assert.NotEmpty(t, vector.Frame, vector.Name)
+		assert.NoError(t, ValidateFrame(vector.Name, vector.Frame), vector.Name)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	codec, ok := message.GetCodec(message.CodecGob)
+	assert.True(t, ok)
+	assert.NoError(t, Validate(codec))
+}
+
+func TestValidateFrame(t *testing.T) {
+	vectors := Vectors()
+	assert.Equal(t, ErrUnknownVector, ValidateFrame("unknown", vectors[0].Frame))
+	assert.Error(t, ValidateFrame(vectors[0].Name, vectors[1].Frame))
+}
+
+func TestWriteCorpus(t *testing.T) {
+	directory, err := ioutil.TempDir("", "corpus")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	assert.NoError(t, WriteCorpus(directory))
+	for _, vector := range Vectors() {
+		frame, err := ioutil.ReadFile(filepath.Join(directory, vector.Name+".frame"))
+		assert.NoError(t, err)
+		assert.Equal(t, vector.Frame, frame)
+	}
+}
+
+// writeGolden appends frames of vectors missing in golden.go as a new block,
+// frames already there are never rewritten so that changes of wire format fail TestGolden.
+// All vectors are serialized in order anyway, as gob type IDs depend on types encoded before.
+func writeGolden(t *testing.T) {
+	var block bytes.Buffer
+	for _, vector := range messages() {
+		frame, err := message.SerializeMessage(vector.Message)
+		assert.NoError(t, err)
+		if _, ok := golden[vector.Name]; ok {
+			continue
+		}
+
+		golden[vector.Name] = hex.EncodeToString(frame)
+		block.WriteString("\t\"" + vector.Name + "\": \"" + hex.EncodeToString(frame) + "\",\n")
+	}
+	if block.Len() == 0 {
+		return
+	}
+
+	source, err := ioutil.ReadFile("golden.go")
+	assert.NoError(t, err)
+	end := bytes.LastIndex(source, []byte("}"))
+	assert.NotEqual(t, -1, end)
+
+	var updated bytes.Buffer
+	updated.Write(source[:end])
+	updated.WriteString("\n")
+	updated.Write(block.Bytes())
+	updated.WriteString("}\n")
+
+	formatted, err := format.Source(updated.Bytes())
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile("golden.go", formatted, 0644))
+}
@@ -0,0 +1,287 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+)
+
+var (
+	// ErrMutableNotFound is returned by GetMutable when no node holds record of the key
+	ErrMutableNotFound = errors.New("mutable record not found")
+	// ErrStaleRecord is returned by PutMutable when its sequence number is already used by owner
+	ErrStaleRecord = errors.New("mutable record is stale")
+)
+
+// mutableRecord is mutable record node holds until expiration
+type mutableRecord struct {
+	record     *store.MutableRecord
+	expiration time.Time
+}
+
+// mutables keeps mutable records node holds for their owners
+type mutables struct {
+	mutex   *sync.Mutex
+	records map[string]*mutableRecord
+}
+
+func newMutables() *mutables {
+	return &mutables{
+		mutex:   &sync.Mutex{},
+		records: make(map[string]*mutableRecord),
+	}
+}
+
+// put keeps record of key until expiration if it is newer than held one or renews held one if it is the same.
+// Held record is returned if it is kept instead of given one.
+func (m *mutables) put(key store.Key, record *store.MutableRecord, max int, expiration, now time.Time) (bool, *store.MutableRecord) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.expire(now)
+
+	held, ok := m.records[key.String()]
+	switch {
+	case !ok && max > 0 && len(m.records) >= max:
+		return false, nil
+	case ok && held.record.Seq > record.Seq:
+		return false, held.record
+	case ok && held.record.Seq == record.Seq && !bytes.Equal(held.record.Value, record.Value):
+		// Owner signed different values with the same sequence number, the first one wins
+		return false, held.record
+	}
+	m.records[key.String()] = &mutableRecord{record: record, expiration: expiration}
+	return true, nil
+}
+
+// get returns held record of key
+func (m *mutables) get(key store.Key, now time.Time) *store.MutableRecord {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.expire(now)
+
+	if held, ok := m.records[key.String()]; ok {
+		return held.record
+	}
+	return nil
+}
+
+// expire forgets expired records, must be called under mutex
+func (m *mutables) expire(now time.Time) {
+	for key, held := range m.records {
+		if !now.Before(held.expiration) {
+			delete(m.records, key)
+		}
+	}
+}
+
+// PutMutable publishes record signed by its owner, see store.NewMutableRecord. Record is kept by nodes
+// closest to its key for ExpirationTime and replaces records of the same key with lower sequence numbers.
+// ErrStaleRecord is returned if record with the same or higher sequence number is already published.
+func (dht *DHT) PutMutable(ctx Context, record *store.MutableRecord) error {
+	if record == nil || !record.Verify() {
+		return errors.New("invalid mutable record")
+	}
+
+	key := record.Key(dht.keyHash())
+	now := dht.options.Clock.Now()
+	stored, newer := dht.mutables.put(key, record, dht.options.MaxMutableRecords, now.Add(dht.options.ExpirationTime), now)
+	if newer != nil {
+		return ErrStaleRecord
+	}
+
+	_, closest, err := dht.iterate(ctx, routing.IterateFindNode, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	accepted, newer := dht.sendPutMutable(ctx, record, closest)
+	if newer != nil {
+		return ErrStaleRecord
+	}
+	if accepted == 0 && !stored {
+		return errors.New("mutable record is not stored")
+	}
+	return nil
+}
+
+// GetMutable returns record with the highest sequence number published under public key and salt,
+// ErrMutableNotFound is returned if there is none
+func (dht *DHT) GetMutable(ctx Context, publicKey ed25519.PublicKey, salt []byte) (*store.MutableRecord, error) {
+	key := store.MutableKey(dht.keyHash(), publicKey, salt)
+	_, closest, err := dht.iterate(ctx, routing.IterateFindNode, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	records := dht.sendGetMutable(ctx, key, closest)
+	if held := dht.mutables.get(key, dht.options.Clock.Now()); held != nil {
+		records = append(records, held)
+	}
+
+	var latest *store.MutableRecord
+	for _, record := range records {
+		if latest != nil && record.Seq <= latest.Seq {
+			continue
+		}
+		if bytes.Equal(record.Key(dht.keyHash()), key) && record.Verify() {
+			latest = record
+		}
+	}
+	if latest == nil {
+		return nil, ErrMutableNotFound
+	}
+	return latest, nil
+}
+
+// sendPutMutable sends record to nodes and returns number of nodes which accepted it
+// and valid record of the same key which any node holds instead of it
+func (dht *DHT) sendPutMutable(ctx Context, record *store.MutableRecord, nodes []*node.Node) (int, *store.MutableRecord) {
+	ht := dht.htFromCtx(ctx)
+	results := make(chan *message.ResponseDataPutMutable, len(nodes))
+	wg := &sync.WaitGroup{}
+
+	for _, receiver := range nodes {
+		request := message.NewBuilder().Sender(ht.Origin).Receiver(receiver).Type(message.TypePutMutable).Request(
+			&message.RequestDataPutMutable{
+				Record: record,
+			}).Build()
+
+		future, err := dht.sendRequest(ctx, request)
+		if err != nil {
+			log.Println("Failed to send mutable record:", err.Error())
+			continue
+		}
+
+		wg.Add(1)
+		go func(future transport.Future) {
+			defer wg.Done()
+			result, err := dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+			if err != nil {
+				return
+			}
+			if response, ok := result.Data.(*message.ResponseDataPutMutable); ok {
+				results <- response
+			}
+		}(future)
+	}
+
+	wg.Wait()
+	close(results)
+
+	accepted := 0
+	var newer *store.MutableRecord
+	for response := range results {
+		if response.Success {
+			accepted++
+			continue
+		}
+		held := response.Record
+		if held != nil && held.Seq >= record.Seq && bytes.Equal(held.Key(dht.keyHash()), record.Key(dht.keyHash())) && held.Verify() {
+			newer = held
+		}
+	}
+	return accepted, newer
+}
+
+// sendGetMutable asks nodes for record of key
+func (dht *DHT) sendGetMutable(ctx Context, key store.Key, nodes []*node.Node) []*store.MutableRecord {
+	ht := dht.htFromCtx(ctx)
+	results := make(chan *store.MutableRecord, len(nodes))
+	wg := &sync.WaitGroup{}
+
+	for _, receiver := range nodes {
+		request := message.NewBuilder().Sender(ht.Origin).Receiver(receiver).Type(message.TypeGetMutable).Request(
+			&message.RequestDataGetMutable{
+				Key: key,
+			}).Build()
+
+		future, err := dht.sendRequest(ctx, request)
+		if err != nil {
+			log.Println("Failed to send mutable record lookup:", err.Error())
+			continue
+		}
+
+		wg.Add(1)
+		go func(future transport.Future) {
+			defer wg.Done()
+			result, err := dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+			if err != nil {
+				return
+			}
+			response, ok := result.Data.(*message.ResponseDataGetMutable)
+			if ok && response.Record != nil {
+				results <- response.Record
+			}
+		}(future)
+	}
+
+	wg.Wait()
+	close(results)
+
+	var records []*store.MutableRecord
+	for record := range results {
+		records = append(records, record)
+	}
+	return records
+}
+
+func (dht *DHT) processPutMutable(ctx Context, msg *message.Message, messageBuilder message.Builder) {
+	data := msg.Data.(*message.RequestDataPutMutable)
+	dht.addNode(ctx, routing.NewRouteNode(msg.Sender))
+	response := &message.ResponseDataPutMutable{}
+
+	record := data.Record
+	switch {
+	case dht.IsReadOnly():
+		log.Println("Rejected mutable record in read-only mode from", msg.Sender)
+	case record == nil || !record.Verify():
+		log.Println("Rejected invalid mutable record from", msg.Sender)
+	default:
+		now := dht.options.Clock.Now()
+		response.Success, response.Record = dht.mutables.put(record.Key(dht.keyHash()), record, dht.options.MaxMutableRecords, now.Add(dht.options.ExpirationTime), now)
+	}
+
+	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
+	if err != nil {
+		log.Println("Failed to send response:", err.Error())
+	}
+}
+
+func (dht *DHT) processGetMutable(ctx Context, msg *message.Message, messageBuilder message.Builder) {
+	data := msg.Data.(*message.RequestDataGetMutable)
+	dht.addNode(ctx, routing.NewRouteNode(msg.Sender))
+	response := &message.ResponseDataGetMutable{
+		Record: dht.mutables.get(data.Key, dht.options.Clock.Now()),
+	}
+
+	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
+	if err != nil {
+		log.Println("Failed to send response:", err.Error())
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutables_Put(t *testing.T) {
+	m := newMutables()
+	_, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	now := time.Now()
+	expiration := now.Add(time.Minute)
+
+	first := store.NewMutableRecord([]byte("first"), nil, 1, privateKey)
+	second := store.NewMutableRecord([]byte("second"), nil, 2, privateKey)
+	stored, held := m.put(second.Key(store.NewKey), second, 2, expiration, now)
+	assert.True(t, stored)
+	assert.Nil(t, held)
+
+	// Lower sequence number and other value under used one are rejected
+	stored, held = m.put(first.Key(store.NewKey), first, 2, expiration, now)
+	assert.False(t, stored)
+	assert.Equal(t, second, held)
+	other := store.NewMutableRecord([]byte("other"), nil, 2, privateKey)
+	stored, held = m.put(other.Key(store.NewKey), other, 2, expiration, now)
+	assert.False(t, stored)
+	assert.Equal(t, second, held)
+
+	// The same record is renewed
+	stored, _ = m.put(second.Key(store.NewKey), second, 2, now.Add(time.Hour), now)
+	assert.True(t, stored)
+	assert.Equal(t, second, m.get(second.Key(store.NewKey), expiration))
+
+	salted := store.NewMutableRecord([]byte("salted"), []byte("salt"), 1, privateKey)
+	stored, _ = m.put(salted.Key(store.NewKey), salted, 2, expiration, now)
+	assert.True(t, stored)
+	limited := store.NewMutableRecord(nil, []byte("limit"), 1, privateKey)
+	stored, _ = m.put(limited.Key(store.NewKey), limited, 2, expiration, now)
+	assert.False(t, stored)
+
+	// Expired records are forgotten
+	assert.Nil(t, m.get(salted.Key(store.NewKey), expiration))
+}
+
+func TestDHT_Mutable(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	ctx := getDefaultCtx(dht2)
+	forged := store.NewMutableRecord([]byte("value"), []byte("name"), 1, privateKey)
+	forged.Value = []byte("forged")
+	assert.EqualError(t, dht2.PutMutable(ctx, forged), "invalid mutable record")
+
+	assert.NoError(t, dht2.PutMutable(ctx, store.NewMutableRecord([]byte("first"), []byte("name"), 1, privateKey)))
+	assert.NoError(t, dht2.PutMutable(ctx, store.NewMutableRecord([]byte("second"), []byte("name"), 2, privateKey)))
+
+	record, err := dht1.GetMutable(getDefaultCtx(dht1), publicKey, []byte("name"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("second"), record.Value)
+	assert.Equal(t, int64(2), record.Seq)
+
+	// Older record is rejected
+	err = dht1.PutMutable(getDefaultCtx(dht1), store.NewMutableRecord([]byte("first"), []byte("name"), 1, privateKey))
+	assert.Equal(t, ErrStaleRecord, err)
+
+	_, err = dht1.GetMutable(getDefaultCtx(dht1), publicKey, []byte("other"))
+	assert.Equal(t, ErrMutableNotFound, err)
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
@@ -25,7 +25,7 @@ type Address struct {
 	net.UDPAddr
 }
 
-// NewAddress is constructor
+// NewAddress is constructor. IPv6 literals must be enclosed in brackets, e.g. "[::1]:31337"
 func NewAddress(address string) (*Address, error) {
 	udpAddr, err := net.ResolveUDPAddr("udp", address)
 	if err != nil {
@@ -34,7 +34,8 @@ func NewAddress(address string) (*Address, error) {
 	return &Address{*udpAddr}, nil
 }
 
-// Equal checks if address is equal to another
+// Equal checks if address is equal to another.
+// IPv4 address is equal to the same address mapped to IPv6.
 func (address Address) Equal(other Address) bool {
-	return address.IP.Equal(other.IP) && address.Port == other.Port
+	return address.IP.Equal(other.IP) && address.Port == other.Port && address.Zone == other.Zone
 }
@@ -44,3 +44,27 @@ func TestAddress_Equal(t *testing.T) {
 	assert.False(t, addr1.Equal(*addr3))
 	assert.False(t, addr3.Equal(*addr1))
 }
+
+func TestNewAddress_IPv6(t *testing.T) {
+	addr, err := NewAddress("[2001:db8::1]:31337")
+	assert.NoError(t, err)
+	assert.Equal(t, "[2001:db8::1]:31337", addr.String())
+
+	addr, err = NewAddress("[fe80::1%eth0]:31337")
+	assert.NoError(t, err)
+	assert.Equal(t, "eth0", addr.Zone)
+	assert.Equal(t, "[fe80::1%eth0]:31337", addr.String())
+
+	_, err = NewAddress("2001:db8::1:31337")
+	assert.Error(t, err)
+}
+
+func TestAddress_EqualIPv6(t *testing.T) {
+	mapped, _ := NewAddress("[::ffff:127.0.0.1]:31337")
+	ipv4, _ := NewAddress("127.0.0.1:31337")
+	assert.True(t, mapped.Equal(*ipv4))
+
+	eth0, _ := NewAddress("[fe80::1%eth0]:31337")
+	eth1, _ := NewAddress("[fe80::1%eth1]:31337")
+	assert.False(t, eth0.Equal(*eth1))
+}
@@ -27,6 +27,29 @@ type Node struct {
 
 	// Address is IP and port
 	Address *Address
+
+	// Addresses are additional addresses node is reachable at, keyed by transport name
+	Addresses map[string]*Address
+
+	// Locality is where node is located, it is advertised by node itself
+	Locality Locality
+
+	// Relay is address of relay forwarding requests to node which is not reachable directly, e.g. behind NAT
+	Relay *Address
+}
+
+// Locality tells where node is located, e.g. region and availability zone of datacenter
+type Locality struct {
+	Region string
+	Zone   string
+}
+
+// String representation of Locality
+func (l Locality) String() string {
+	if l.Zone == "" {
+		return l.Region
+	}
+	return l.Region + "/" + l.Zone
 }
 
 // NewNode creates a new Node for bootstrapping
@@ -36,6 +59,28 @@ func NewNode(address *Address) *Node {
 	}
 }
 
+// AddressFor returns node's address for given transport, primary address is returned
+// if node didn't advertise one for this transport
+func (node Node) AddressFor(transport string) *Address {
+	if address, ok := node.Addresses[transport]; ok {
+		return address
+	}
+	return node.Address
+}
+
+// HasAddress checks if node is reachable at given address with any of transports
+func (node *Node) HasAddress(address string) bool {
+	if node.Address != nil && node.Address.String() == address {
+		return true
+	}
+	for _, a := range node.Addresses {
+		if a.String() == address {
+			return true
+		}
+	}
+	return false
+}
+
 // String representation of Node
 func (node Node) String() string {
 	return fmt.Sprintf("%s (%s)", node.ID.String(), node.Address.String())
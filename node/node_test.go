@@ -66,7 +66,34 @@ func TestNode_Equal(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			assert.Equal(t, test.equal, Node{test.id1, test.addr1}.Equal(Node{test.id2, test.addr2}))
+			assert.Equal(t, test.equal, Node{ID: test.id1, Address: test.addr1}.Equal(Node{ID: test.id2, Address: test.addr2}))
 		})
 	}
 }
+
+func TestNode_AddressFor(t *testing.T) {
+	addr1, _ := NewAddress("127.0.0.1:31337")
+	addr2, _ := NewAddress("127.0.0.1:31338")
+	nd := &Node{Address: addr1, Addresses: map[string]*Address{"tcp": addr2}}
+
+	assert.Equal(t, addr2, nd.AddressFor("tcp"))
+	assert.Equal(t, addr1, nd.AddressFor("utp"))
+	assert.Equal(t, addr1, nd.AddressFor(""))
+}
+
+func TestNode_HasAddress(t *testing.T) {
+	addr1, _ := NewAddress("127.0.0.1:31337")
+	addr2, _ := NewAddress("127.0.0.1:31338")
+	nd := &Node{Address: addr1, Addresses: map[string]*Address{"tcp": addr2}}
+
+	assert.True(t, nd.HasAddress("127.0.0.1:31337"))
+	assert.True(t, nd.HasAddress("127.0.0.1:31338"))
+	assert.False(t, nd.HasAddress("127.0.0.1:31339"))
+	assert.False(t, (&Node{}).HasAddress("127.0.0.1:31337"))
+}
+
+func TestLocality_String(t *testing.T) {
+	assert.Equal(t, "", Locality{}.String())
+	assert.Equal(t, "eu-west", Locality{Region: "eu-west"}.String())
+	assert.Equal(t, "eu-west/a", Locality{Region: "eu-west", Zone: "a"}.String())
+}
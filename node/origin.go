@@ -21,6 +21,9 @@ package node
 type Origin struct {
 	IDs     []ID
 	Address *Address
+
+	// Addresses are additional addresses advertised for other transports, see Node.Addresses
+	Addresses map[string]*Address
 }
 
 // NewOrigin creates origin node from list of ids and network address
@@ -27,7 +27,7 @@ func TestNewOrigin(t *testing.T) {
 	addr, _ := NewAddress("127.0.0.1:31337")
 	ids, _ := NewIDs(10)
 
-	expectedOrigin := &Origin{ids, addr}
+	expectedOrigin := &Origin{IDs: ids, Address: addr}
 	actualOrigin, err := NewOrigin(ids, addr)
 
 	assert.NoError(t, err)
@@ -52,7 +52,7 @@ func TestOrigin_Contains(t *testing.T) {
 		if i < 10 {
 			contains = true
 		}
-		assert.Equal(t, contains, origin.Contains(&Node{ids[i], addr}))
-		assert.False(t, origin.Contains(&Node{ids[i], addr2}))
+		assert.Equal(t, contains, origin.Contains(&Node{ID: ids[i], Address: addr}))
+		assert.False(t, origin.Contains(&Node{ID: ids[i], Address: addr2}))
 	}
 }
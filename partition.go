@@ -0,0 +1,225 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/insolar/network/node"
+)
+
+const (
+	// partitionLookupWindow is the number of recent lookups failure rate is measured over
+	partitionLookupWindow = 32
+	// partitionMinLookups is the number of recent lookups needed to consider failure rate
+	partitionMinLookups = 4
+)
+
+// PartitionState is assessment of node connectivity to the rest of network
+type PartitionState int
+
+const (
+	// PartitionConnected means no signal of partition is observed
+	PartitionConnected = PartitionState(iota)
+	// PartitionDegraded means one signal of partition is observed
+	PartitionDegraded
+	// PartitionIsolated means node has no contacts left or several signals of partition are observed
+	PartitionIsolated
+)
+
+// String returns name of partition state
+func (s PartitionState) String() string {
+	switch s {
+	case PartitionConnected:
+		return "connected"
+	case PartitionDegraded:
+		return "degraded"
+	case PartitionIsolated:
+		return "isolated"
+	default:
+		return "unknown"
+	}
+}
+
+// PartitionStatus combines signals of network partition observed by one routing table
+type PartitionStatus struct {
+	// ID of the node which routing table is assessed
+	ID    node.ID
+	State PartitionState
+	// Time State was entered at
+	Since time.Time
+	// BootstrapUnreachable tells if the last bootstrap reached none of bootstrap nodes
+	// and no lookup succeeded since
+	BootstrapUnreachable bool
+	// Number of contacts in routing table and the largest number seen since lookups last succeeded
+	Contacts     int
+	PeakContacts int
+	// TableShrunk tells if routing table lost PartitionShrinkage of its peak contacts
+	TableShrunk bool
+	// Fraction of recent lookups which got no response from any contacted node
+	LookupFailureRate float64
+	// Number of recent lookups LookupFailureRate is measured over
+	Lookups int
+	// LookupsFailing tells if LookupFailureRate reached PartitionFailureRate
+	LookupsFailing bool
+}
+
+// partitionTracker collects signals of partition of one routing table
+type partitionTracker struct {
+	mutex                *sync.Mutex
+	lookups              []bool
+	next                 int
+	bootstrapUnreachable bool
+	peak                 int
+	state                PartitionState
+	since                time.Time
+}
+
+func newPartitionTracker(started time.Time) *partitionTracker {
+	return &partitionTracker{
+		mutex: &sync.Mutex{},
+		since: started,
+	}
+}
+
+// lookupDone records outcome of lookup, lookup failed if none of contacted nodes responded
+func (pt *partitionTracker) lookupDone(responded bool) {
+	pt.mutex.Lock()
+	defer pt.mutex.Unlock()
+
+	if len(pt.lookups) < partitionLookupWindow {
+		pt.lookups = append(pt.lookups, responded)
+	} else {
+		pt.lookups[pt.next] = responded
+		pt.next = (pt.next + 1) % partitionLookupWindow
+	}
+	if responded {
+		pt.bootstrapUnreachable = false
+	}
+}
+
+// bootstrapped records if bootstrap reached any of bootstrap nodes
+func (pt *partitionTracker) bootstrapped(reached bool) {
+	pt.mutex.Lock()
+	defer pt.mutex.Unlock()
+
+	pt.bootstrapUnreachable = !reached
+}
+
+// assess combines signals into partition status given current number of contacts.
+// Changed tells if state differs from the previous assessment.
+func (pt *partitionTracker) assess(contacts int, failureRate, shrinkage float64, now time.Time) (status PartitionStatus, changed bool) {
+	pt.mutex.Lock()
+	defer pt.mutex.Unlock()
+
+	status.Contacts = contacts
+	status.BootstrapUnreachable = pt.bootstrapUnreachable
+	status.Lookups = len(pt.lookups)
+	failed := 0
+	for _, responded := range pt.lookups {
+		if !responded {
+			failed++
+		}
+	}
+	if status.Lookups > 0 {
+		status.LookupFailureRate = float64(failed) / float64(status.Lookups)
+	}
+	status.LookupsFailing = status.Lookups >= partitionMinLookups && status.LookupFailureRate >= failureRate
+
+	// Table which shrinks while lookups succeed follows the network, not a partition
+	if contacts > pt.peak || status.Lookups >= partitionMinLookups && !status.LookupsFailing {
+		pt.peak = contacts
+	}
+	status.PeakContacts = pt.peak
+	status.TableShrunk = pt.peak > 0 && float64(pt.peak-contacts) >= float64(pt.peak)*shrinkage
+
+	signals := 0
+	for _, signal := range []bool{status.BootstrapUnreachable, status.TableShrunk, status.LookupsFailing} {
+		if signal {
+			signals++
+		}
+	}
+	switch {
+	case contacts == 0 && (status.BootstrapUnreachable || pt.peak > 0), signals >= 2:
+		status.State = PartitionIsolated
+	case signals == 1:
+		status.State = PartitionDegraded
+	default:
+		status.State = PartitionConnected
+	}
+
+	if status.State != pt.state {
+		pt.state = status.State
+		pt.since = now
+		changed = true
+	}
+	status.Since = pt.since
+	return status, changed
+}
+
+// PartitionStatus assesses if node ctx is bound to is cut off from the rest of network. It combines
+// failed bootstrap, shrinkage of routing table and failure rate of recent lookups, so application
+// can pause writes or alert users while node is isolated. Changes of state are reported to OnPartitionChange.
+func (dht *DHT) PartitionStatus(ctx Context) PartitionStatus {
+	return dht.checkPartition(ctx)
+}
+
+func (dht *DHT) partitionFor(ctx Context) *partitionTracker {
+	return dht.partitions[ctx.Value(ctxTableIndex).(int)]
+}
+
+func (dht *DHT) assessPartition(ctx Context) (PartitionStatus, bool) {
+	ht := dht.htFromCtx(ctx)
+	status, changed := dht.partitionFor(ctx).assess(ht.TotalNodes(), dht.options.PartitionFailureRate,
+		dht.options.PartitionShrinkage, dht.options.Clock.Now())
+	status.ID = ht.Origin.ID
+	return status, changed
+}
+
+// checkPartition assesses partition status of node ctx is bound to and reports its change
+func (dht *DHT) checkPartition(ctx Context) PartitionStatus {
+	status, changed := dht.assessPartition(ctx)
+	if !changed {
+		return status
+	}
+	if status.State == PartitionIsolated {
+		log.Println("Node seems to be isolated from network:", status.ID.String())
+	}
+	if dht.options.OnPartitionChange != nil {
+		dht.options.OnPartitionChange(status)
+	}
+	return status
+}
+
+// bootstrapReach tells if bootstrap of routing table got response from any node
+type bootstrapReach struct {
+	reached int32
+}
+
+func (r *bootstrapReach) isReached() bool {
+	return atomic.LoadInt32(&r.reached) == 1
+}
+
+// markReached records response to bootstrap if ctx is used by bootstrap
+func markReached(ctx Context) {
+	if reach, ok := ctx.Value(ctxBootstrap).(*bootstrapReach); ok {
+		atomic.StoreInt32(&reach.reached, 1)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionTracker_Assess(t *testing.T) {
+	now := time.Now()
+	pt := newPartitionTracker(now)
+
+	// Node alone in network is not isolated
+	status, changed := pt.assess(0, 0.75, 0.75, now)
+	assert.Equal(t, PartitionConnected, status.State)
+	assert.False(t, changed)
+
+	for i := 0; i < partitionMinLookups; i++ {
+		pt.lookupDone(true)
+	}
+	status, _ = pt.assess(20, 0.75, 0.75, now)
+	assert.Equal(t, PartitionConnected, status.State)
+	assert.Equal(t, 20, status.PeakContacts)
+
+	// Failing lookups alone degrade node
+	for i := 0; i < partitionLookupWindow; i++ {
+		pt.lookupDone(false)
+	}
+	status, changed = pt.assess(20, 0.75, 0.75, now.Add(time.Second))
+	assert.Equal(t, PartitionDegraded, status.State)
+	assert.True(t, changed)
+	assert.True(t, status.LookupsFailing)
+	assert.Equal(t, 1.0, status.LookupFailureRate)
+	assert.Equal(t, partitionLookupWindow, status.Lookups)
+
+	// Together with shrunk table they isolate it
+	status, changed = pt.assess(4, 0.75, 0.75, now.Add(2*time.Second))
+	assert.Equal(t, PartitionIsolated, status.State)
+	assert.True(t, changed)
+	assert.True(t, status.TableShrunk)
+	assert.Equal(t, now.Add(2*time.Second), status.Since)
+
+	// Successful lookups reconnect node and reset peak of shrunk table
+	for i := 0; i < partitionLookupWindow; i++ {
+		pt.lookupDone(true)
+	}
+	status, _ = pt.assess(4, 0.75, 0.75, now.Add(3*time.Second))
+	assert.Equal(t, PartitionConnected, status.State)
+	assert.Equal(t, 4, status.PeakContacts)
+}
+
+func TestDHT_PartitionStatus(t *testing.T) {
+	id := getIDWithValues(0)
+	st, s, tp, r, err := dhtParams([]node.ID{id}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+
+	var statuses []PartitionStatus
+	dht, _ := NewDHT(st, s, tp, r, &Options{OnPartitionChange: func(status PartitionStatus) {
+		statuses = append(statuses, status)
+	}})
+	ctx := getDefaultCtx(dht)
+	assert.Equal(t, PartitionConnected, dht.PartitionStatus(ctx).State)
+
+	addr, _ := node.NewAddress("0.0.0.0:3001")
+	contact := &node.Node{ID: getZerodIDWithNthByte(1, byte(1)), Address: addr}
+	dht.addNode(ctx, routing.NewRouteNode(contact))
+	dht.checkPartition(ctx)
+	assert.Empty(t, statuses)
+
+	// Losing the last contact isolates node
+	dht.evictDeadPeer(addr.String())
+	dht.checkPartition(ctx)
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, PartitionIsolated, statuses[0].State)
+	assert.Equal(t, id, statuses[0].ID)
+
+	// Unreachable bootstrap keeps it isolated
+	dht.partitionFor(ctx).bootstrapped(false)
+	status := dht.PartitionStatus(ctx)
+	assert.Equal(t, PartitionIsolated, status.State)
+	assert.True(t, status.BootstrapUnreachable)
+}
@@ -0,0 +1,303 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+
+	"github.com/jbenet/go-base58"
+)
+
+// ErrProvidersNotFound is returned by FindProviders when no live provider of key is known
+var ErrProvidersNotFound = errors.New("providers not found")
+
+// providerEntry is provider record kept until its expiration or holder's ProviderTTL passes
+type providerEntry struct {
+	record     *store.ProviderRecord
+	expiration time.Time
+}
+
+// providers keeps provider records node holds for others
+type providers struct {
+	mutex   *sync.Mutex
+	records map[string]map[string]*providerEntry
+	count   int
+}
+
+func newProviders() *providers {
+	return &providers{
+		mutex:   &sync.Mutex{},
+		records: make(map[string]map[string]*providerEntry),
+	}
+}
+
+// add keeps record for at most ttl unless limit of records is reached, record of the same provider is replaced
+func (p *providers) add(record *store.ProviderRecord, max int, ttl time.Duration, now time.Time) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.expire(now)
+
+	key := record.Key.String()
+	entries, ok := p.records[key]
+	if !ok {
+		entries = make(map[string]*providerEntry)
+		p.records[key] = entries
+	}
+	provider := record.Provider.String()
+	if _, exists := entries[provider]; !exists {
+		if max > 0 && p.count >= max {
+			if len(entries) == 0 {
+				delete(p.records, key)
+			}
+			return false
+		}
+		p.count++
+	}
+	expiration := record.Expiration
+	if limit := now.Add(ttl); expiration.After(limit) {
+		expiration = limit
+	}
+	entries[provider] = &providerEntry{record: record, expiration: expiration}
+	return true
+}
+
+// lookup returns live records of key
+func (p *providers) lookup(key store.Key, now time.Time) []*store.ProviderRecord {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.expire(now)
+
+	records := make([]*store.ProviderRecord, 0, len(p.records[key.String()]))
+	for _, entry := range p.records[key.String()] {
+		records = append(records, entry.record)
+	}
+	return records
+}
+
+// expire forgets expired records, must be called under mutex
+func (p *providers) expire(now time.Time) {
+	for key, entries := range p.records {
+		for provider, entry := range entries {
+			if !now.Before(entry.expiration) {
+				delete(entries, provider)
+				p.count--
+			}
+		}
+		if len(entries) == 0 {
+			delete(p.records, key)
+		}
+	}
+}
+
+// Provide announces that node can serve content of key for ProviderTTL. Signed record is kept by nodes
+// closest to the key, node should provide key again before ProviderTTL passes to stay discoverable.
+// Key is the base58 encoded identifier of the data.
+func (dht *DHT) Provide(ctx Context, key string) error {
+	keyBytes := base58.Decode(key)
+	if len(keyBytes) != store.KeySize {
+		return errors.New("invalid key")
+	}
+
+	ht := dht.htFromCtx(ctx)
+	now := dht.options.Clock.Now()
+	record := store.NewProviderRecord(keyBytes, ht.Origin.ID, ht.Origin.Address.String(), now.Add(dht.options.ProviderTTL), dht.options.PrivateKey)
+	stored := dht.providers.add(record, dht.options.MaxProviderRecords, dht.options.ProviderTTL, now)
+
+	_, closest, err := dht.iterate(ctx, routing.IterateFindNode, keyBytes, nil, nil)
+	if err != nil {
+		return err
+	}
+	if dht.sendAddProvider(ctx, record, closest) == 0 && !stored {
+		return errors.New("provider is not announced")
+	}
+	return nil
+}
+
+// FindProviders returns at most n live nodes which announced they can serve content of key,
+// all of them if n is not positive. ErrProvidersNotFound is returned if there are none.
+// Key is the base58 encoded identifier of the data.
+func (dht *DHT) FindProviders(ctx Context, key string, n int) ([]*node.Node, error) {
+	keyBytes := base58.Decode(key)
+	if len(keyBytes) != store.KeySize {
+		return nil, errors.New("invalid key")
+	}
+
+	_, closest, err := dht.iterate(ctx, routing.IterateFindNode, keyBytes, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := dht.options.Clock.Now()
+	records := append(dht.providers.lookup(keyBytes, now), dht.sendGetProviders(ctx, keyBytes, closest)...)
+
+	latest := make(map[string]*store.ProviderRecord)
+	for _, record := range records {
+		if !bytes.Equal(record.Key, keyBytes) || !now.Before(record.Expiration) || !record.Verify() || record.Node() == nil {
+			continue
+		}
+		if known, ok := latest[record.Provider.String()]; !ok || record.Expiration.After(known.Expiration) {
+			latest[record.Provider.String()] = record
+		}
+	}
+	if len(latest) == 0 {
+		return nil, ErrProvidersNotFound
+	}
+
+	nodes := make([]*node.Node, 0, len(latest))
+	for _, record := range latest {
+		nodes = append(nodes, record.Node())
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return bytes.Compare(nodes[i].ID, nodes[j].ID) < 0
+	})
+	if n > 0 && len(nodes) > n {
+		nodes = nodes[:n]
+	}
+	return nodes, nil
+}
+
+// sendAddProvider sends record to nodes and returns number of nodes which accepted it
+func (dht *DHT) sendAddProvider(ctx Context, record *store.ProviderRecord, nodes []*node.Node) int {
+	ht := dht.htFromCtx(ctx)
+	results := make(chan bool, len(nodes))
+	wg := &sync.WaitGroup{}
+
+	for _, receiver := range nodes {
+		request := message.NewBuilder().Sender(ht.Origin).Receiver(receiver).Type(message.TypeAddProvider).Request(
+			&message.RequestDataAddProvider{
+				Record: record,
+			}).Build()
+
+		future, err := dht.sendRequest(ctx, request)
+		if err != nil {
+			log.Println("Failed to send provider announcement:", err.Error())
+			continue
+		}
+
+		wg.Add(1)
+		go func(future transport.Future) {
+			defer wg.Done()
+			result, err := dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+			if err != nil {
+				return
+			}
+			response, ok := result.Data.(*message.ResponseDataAddProvider)
+			results <- ok && response.Success
+		}(future)
+	}
+
+	wg.Wait()
+	close(results)
+
+	accepted := 0
+	for success := range results {
+		if success {
+			accepted++
+		}
+	}
+	return accepted
+}
+
+// sendGetProviders asks nodes for provider records of key
+func (dht *DHT) sendGetProviders(ctx Context, key store.Key, nodes []*node.Node) []*store.ProviderRecord {
+	ht := dht.htFromCtx(ctx)
+	results := make(chan []*store.ProviderRecord, len(nodes))
+	wg := &sync.WaitGroup{}
+
+	for _, receiver := range nodes {
+		request := message.NewBuilder().Sender(ht.Origin).Receiver(receiver).Type(message.TypeGetProviders).Request(
+			&message.RequestDataGetProviders{
+				Key: key,
+			}).Build()
+
+		future, err := dht.sendRequest(ctx, request)
+		if err != nil {
+			log.Println("Failed to send providers lookup:", err.Error())
+			continue
+		}
+
+		wg.Add(1)
+		go func(future transport.Future) {
+			defer wg.Done()
+			result, err := dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+			if err != nil {
+				return
+			}
+			response, ok := result.Data.(*message.ResponseDataGetProviders)
+			if ok {
+				results <- response.Records
+			}
+		}(future)
+	}
+
+	wg.Wait()
+	close(results)
+
+	var records []*store.ProviderRecord
+	for found := range results {
+		records = append(records, found...)
+	}
+	return records
+}
+
+func (dht *DHT) processAddProvider(ctx Context, msg *message.Message, messageBuilder message.Builder) {
+	data := msg.Data.(*message.RequestDataAddProvider)
+	dht.addNode(ctx, routing.NewRouteNode(msg.Sender))
+	response := &message.ResponseDataAddProvider{}
+
+	record := data.Record
+	now := dht.options.Clock.Now()
+	switch {
+	case dht.IsReadOnly():
+		log.Println("Rejected provider announcement in read-only mode from", msg.Sender)
+	case record == nil || len(record.Key) != store.KeySize || !now.Before(record.Expiration) || !record.Verify():
+		log.Println("Rejected invalid provider record from", msg.Sender)
+	default:
+		response.Success = dht.providers.add(record, dht.options.MaxProviderRecords, dht.options.ProviderTTL, now)
+	}
+
+	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
+	if err != nil {
+		log.Println("Failed to send response:", err.Error())
+	}
+}
+
+func (dht *DHT) processGetProviders(ctx Context, msg *message.Message, messageBuilder message.Builder) {
+	data := msg.Data.(*message.RequestDataGetProviders)
+	dht.addNode(ctx, routing.NewRouteNode(msg.Sender))
+	response := &message.ResponseDataGetProviders{
+		Records: dht.providers.lookup(data.Key, dht.options.Clock.Now()),
+	}
+
+	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
+	if err != nil {
+		log.Println("Failed to send response:", err.Error())
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+
+	"github.com/jbenet/go-base58"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviders_Add(t *testing.T) {
+	p := newProviders()
+	_, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	now := time.Now()
+	key, other := store.NewKey([]byte("content")), store.NewKey([]byte("other"))
+
+	first := store.NewProviderRecord(key, []byte("a"), "127.0.0.1:3000", now.Add(time.Hour), privateKey)
+	second := store.NewProviderRecord(key, []byte("b"), "127.0.0.1:3001", now.Add(time.Second), privateKey)
+	assert.True(t, p.add(first, 2, time.Minute, now))
+	assert.True(t, p.add(second, 2, time.Minute, now))
+	// Renewal of provider is not limited
+	assert.True(t, p.add(first, 2, time.Minute, now))
+	assert.False(t, p.add(store.NewProviderRecord(other, []byte("a"), "127.0.0.1:3000", now.Add(time.Hour), privateKey), 2, time.Minute, now))
+	assert.Len(t, p.lookup(key, now), 2)
+	assert.Empty(t, p.lookup(other, now))
+
+	// Expired records are forgotten
+	assert.Equal(t, []*store.ProviderRecord{first}, p.lookup(key, now.Add(time.Second)))
+	// Records are kept at most for holder's ttl
+	assert.Empty(t, p.lookup(key, now.Add(time.Minute)))
+	assert.Equal(t, 0, p.count)
+}
+
+func TestDHT_Providers(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+
+	ctx := getDefaultCtx(dht2)
+	key := base58.Encode(store.NewKey([]byte("content")))
+	assert.EqualError(t, dht2.Provide(ctx, "invalid"), "invalid key")
+
+	assert.NoError(t, dht2.Provide(ctx, key))
+	assert.Len(t, dht1.providers.lookup(base58.Decode(key), time.Now()), 1)
+
+	providers, err := dht1.FindProviders(getDefaultCtx(dht1), key, 1)
+	assert.NoError(t, err)
+	assert.Len(t, providers, 1)
+	assert.Equal(t, dht2.htFromCtx(ctx).Origin.ID, providers[0].ID)
+	assert.Equal(t, "127.0.0.1:3001", providers[0].Address.String())
+
+	_, err = dht1.FindProviders(getDefaultCtx(dht1), base58.Encode(store.NewKey([]byte("other"))), 0)
+	assert.Equal(t, ErrProvidersNotFound, err)
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
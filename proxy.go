@@ -0,0 +1,115 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+
+	"github.com/insolar/network/node"
+)
+
+// RPC methods served by nodes with ProxyLookups option enabled
+const (
+	proxyGetMethod      = "network.proxyGet"
+	proxyFindNodeMethod = "network.proxyFindNode"
+)
+
+// proxyLookupResult is a result of lookup performed on behalf of other node
+type proxyLookupResult struct {
+	Found bool
+	Value []byte
+	Node  *node.Node
+}
+
+// ProxyGet asks proxy node to retrieve value by key on our behalf.
+// Proxy is the base58 encoded identifier of a node serving proxy lookups.
+func (dht *DHT) ProxyGet(ctx Context, proxy string, key string) ([]byte, bool, error) {
+	result, err := dht.proxyLookup(ctx, proxy, proxyGetMethod, key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return result.Value, result.Found, nil
+}
+
+// ProxyFindNode asks proxy node to find node by its base58 encoded identifier on our behalf.
+func (dht *DHT) ProxyFindNode(ctx Context, proxy string, key string) (*node.Node, bool, error) {
+	result, err := dht.proxyLookup(ctx, proxy, proxyFindNodeMethod, key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return result.Node, result.Found, nil
+}
+
+func (dht *DHT) proxyLookup(ctx Context, proxy string, method string, key string) (*proxyLookupResult, error) {
+	data, err := dht.RemoteProcedureCall(ctx, proxy, method, [][]byte{[]byte(key)})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &proxyLookupResult{}
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (dht *DHT) registerProxyLookups() {
+	dht.rpc.RegisterMethod(proxyGetMethod, func(_ context.Context, sender *node.Node, args [][]byte) ([]byte, error) {
+		return dht.serveProxyLookup(args, func(ctx Context, key string) (*proxyLookupResult, error) {
+			value, exists, err := dht.Get(ctx, key)
+			return &proxyLookupResult{Found: exists, Value: value}, err
+		})
+	})
+
+	dht.rpc.RegisterMethod(proxyFindNodeMethod, func(_ context.Context, sender *node.Node, args [][]byte) ([]byte, error) {
+		return dht.serveProxyLookup(args, func(ctx Context, key string) (*proxyLookupResult, error) {
+			target, exists, err := dht.FindNode(ctx, key)
+			return &proxyLookupResult{Found: exists, Node: target}, err
+		})
+	})
+}
+
+func (dht *DHT) serveProxyLookup(args [][]byte, lookup func(ctx Context, key string) (*proxyLookupResult, error)) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.New("key required")
+	}
+
+	ctx, err := NewContextBuilder(dht).SetDefaultNode().Build()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := lookup(ctx, string(args[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	err = gob.NewEncoder(&buffer).Encode(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
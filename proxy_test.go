@@ -0,0 +1,80 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/transport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyLookups(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, nil, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	proxy, _ := NewDHT(st1, s1, tp1, r1, &Options{ProxyLookups: true})
+	bootstrapNodes := []*node.Node{{ID: s1.IDs[0], Address: s1.Address}}
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	client, _ := NewDHT(st2, s2, tp2, r2, &Options{BootstrapNodes: bootstrapNodes})
+
+	st3, s3, tp3, r3, err := inMemoryDhtParams(network, nil, "127.0.0.1:3002")
+	assert.NoError(t, err)
+	other, _ := NewDHT(st3, s3, tp3, r3, &Options{BootstrapNodes: bootstrapNodes})
+
+	for _, dht := range []*DHT{proxy, client, other} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, client.Bootstrap())
+	assert.NoError(t, other.Bootstrap())
+
+	key, err := other.Store(getDefaultCtx(other), []byte("foo"))
+	assert.NoError(t, err)
+
+	ctx := getDefaultCtx(client)
+	proxyID := proxy.GetOriginID(getDefaultCtx(proxy))
+
+	value, exists, err := client.ProxyGet(ctx, proxyID, key)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, []byte("foo"), value)
+
+	target, exists, err := client.ProxyFindNode(ctx, proxyID, other.GetOriginID(getDefaultCtx(other)))
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, s3.IDs[0], target.ID)
+
+	// Lookups are served only by nodes with ProxyLookups option
+	_, _, err = client.ProxyGet(ctx, other.GetOriginID(getDefaultCtx(other)), key)
+	assert.EqualError(t, err, "method does not exist")
+
+	for _, dht := range []*DHT{proxy, client, other} {
+		dht.Disconnect()
+		<-done
+	}
+}
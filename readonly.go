@@ -0,0 +1,41 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrReadOnly is returned when remote node rejects request because it is in read-only maintenance mode
+var ErrReadOnly = errors.New("node is in read-only mode")
+
+// SetReadOnly toggles read-only maintenance mode. In this mode node keeps answering
+// FindNode and FindValue requests, but rejects incoming Store and RPC requests,
+// so node can be drained before maintenance without breaking routing.
+func (dht *DHT) SetReadOnly(readOnly bool) {
+	var value int32
+	if readOnly {
+		value = 1
+	}
+	atomic.StoreInt32(&dht.readOnly, value)
+}
+
+// IsReadOnly tells if node is in read-only maintenance mode
+func (dht *DHT) IsReadOnly() bool {
+	return atomic.LoadInt32(&dht.readOnly) == 1
+}
@@ -0,0 +1,134 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+)
+
+// UnknownReceiverPolicy defines how messages without receiver ID or addressed to unknown ID
+// (e.g. pings sent to bootstrap node address) are handled by node with multiple IDs
+type UnknownReceiverPolicy int
+
+const (
+	// UnknownReceiverDefault handles such messages with the first ID
+	UnknownReceiverDefault = UnknownReceiverPolicy(iota)
+	// UnknownReceiverReject drops such messages
+	UnknownReceiverReject
+	// UnknownReceiverByAddress handles such messages with ID selected by sender address,
+	// so the same ID always serves the same sender
+	UnknownReceiverByAddress
+)
+
+// UnknownReceiverStats contains numbers of messages addressed to unknown receiver
+type UnknownReceiverStats struct {
+	// Number of rejected messages
+	Rejected int
+	// Number of messages handled by each of IDs, keyed by ID string
+	Routed map[string]int
+}
+
+// unknownReceivers counts messages addressed to unknown receiver
+type unknownReceivers struct {
+	mutex    *sync.Mutex
+	rejected int
+	routed   map[string]int
+}
+
+func newUnknownReceivers() *unknownReceivers {
+	return &unknownReceivers{
+		mutex:  &sync.Mutex{},
+		routed: make(map[string]int),
+	}
+}
+
+func (ur *unknownReceivers) reject() {
+	ur.mutex.Lock()
+	defer ur.mutex.Unlock()
+
+	ur.rejected++
+}
+
+func (ur *unknownReceivers) route(id node.ID) {
+	ur.mutex.Lock()
+	defer ur.mutex.Unlock()
+
+	ur.routed[id.String()]++
+}
+
+func (ur *unknownReceivers) stats() UnknownReceiverStats {
+	ur.mutex.Lock()
+	defer ur.mutex.Unlock()
+
+	stats := UnknownReceiverStats{Rejected: ur.rejected, Routed: make(map[string]int)}
+	for id, count := range ur.routed {
+		stats.Routed[id] = count
+	}
+	return stats
+}
+
+// UnknownReceiverStats returns numbers of messages without receiver ID or addressed to unknown ID
+func (dht *DHT) UnknownReceiverStats() UnknownReceiverStats {
+	return dht.unknownReceivers.stats()
+}
+
+// receiverContext builds context for ID message is addressed to. Messages addressed
+// to unknown receiver are handled according to UnknownReceiver option.
+func (dht *DHT) receiverContext(cb ContextBuilder, msg *message.Message) (Context, bool) {
+	if msg.Receiver.ID != nil {
+		ctx, err := cb.SetNodeByID(msg.Receiver.ID).Build()
+		if err == nil {
+			return ctx, true
+		}
+	}
+
+	var index int
+	switch dht.options.UnknownReceiver {
+	case UnknownReceiverReject:
+		dht.unknownReceivers.reject()
+		return nil, false
+	case UnknownReceiverByAddress:
+		index = addressIndex(msg, len(dht.origin.IDs))
+	default:
+		index = defaultNodeID
+	}
+
+	id := dht.origin.IDs[index]
+	dht.unknownReceivers.route(id)
+	ctx, err := cb.SetNodeByID(id).Build()
+	if err != nil {
+		return nil, false
+	}
+	return ctx, true
+}
+
+// addressIndex selects one of n IDs by message sender address.
+// Advertised address is preferred as remote port changes between connections.
+func addressIndex(msg *message.Message, n int) int {
+	address := msg.RemoteAddress()
+	if msg.Sender != nil && msg.Sender.Address != nil {
+		address = msg.Sender.Address.String()
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(address))
+	return int(hash.Sum32() % uint32(n))
+}
@@ -0,0 +1,97 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func createUnknownReceiverDHT(t *testing.T, policy UnknownReceiverPolicy) *DHT {
+	ids := []node.ID{getIDWithValues(1), getIDWithValues(2)}
+	st, s, tp, r, err := dhtParams(ids, "127.0.0.1:3000")
+	assert.NoError(t, err)
+
+	dht, err := NewDHT(st, s, tp, r, &Options{UnknownReceiver: policy})
+	assert.NoError(t, err)
+	return dht
+}
+
+func pingFrom(dht *DHT, senderAddress string, receiverID node.ID) *message.Message {
+	addr, _ := node.NewAddress(senderAddress)
+	sender := &node.Node{ID: getIDWithValues(3), Address: addr}
+	receiver := &node.Node{ID: receiverID, Address: dht.origin.Address}
+	return message.NewPingMessage(sender, receiver)
+}
+
+func TestReceiverContext_Known(t *testing.T) {
+	dht := createUnknownReceiverDHT(t, UnknownReceiverReject)
+
+	ctx, ok := dht.receiverContext(NewContextBuilder(dht), pingFrom(dht, "127.0.0.1:3001", getIDWithValues(2)))
+	assert.True(t, ok)
+	assert.Equal(t, getIDWithValues(2), dht.htFromCtx(ctx).Origin.ID)
+	assert.Equal(t, UnknownReceiverStats{Routed: map[string]int{}}, dht.UnknownReceiverStats())
+}
+
+func TestReceiverContext_Default(t *testing.T) {
+	dht := createUnknownReceiverDHT(t, UnknownReceiverDefault)
+	cb := NewContextBuilder(dht)
+
+	for _, id := range []node.ID{nil, getIDWithValues(4)} {
+		ctx, ok := dht.receiverContext(cb, pingFrom(dht, "127.0.0.1:3001", id))
+		assert.True(t, ok)
+		assert.Equal(t, getIDWithValues(1), dht.htFromCtx(ctx).Origin.ID)
+	}
+
+	assert.Equal(t, UnknownReceiverStats{Routed: map[string]int{getIDWithValues(1).String(): 2}}, dht.UnknownReceiverStats())
+}
+
+func TestReceiverContext_Reject(t *testing.T) {
+	dht := createUnknownReceiverDHT(t, UnknownReceiverReject)
+
+	_, ok := dht.receiverContext(NewContextBuilder(dht), pingFrom(dht, "127.0.0.1:3001", nil))
+	assert.False(t, ok)
+	assert.Equal(t, 1, dht.UnknownReceiverStats().Rejected)
+}
+
+func TestReceiverContext_ByAddress(t *testing.T) {
+	dht := createUnknownReceiverDHT(t, UnknownReceiverByAddress)
+	cb := NewContextBuilder(dht)
+
+	// The same sender is always served by the same ID
+	served := make(map[string]string)
+	for i := 0; i < 3; i++ {
+		for _, address := range []string{"127.0.0.1:3001", "127.0.0.2:3001", "127.0.0.3:3001", "127.0.0.4:3001"} {
+			ctx, ok := dht.receiverContext(cb, pingFrom(dht, address, nil))
+			assert.True(t, ok)
+			id := dht.htFromCtx(ctx).Origin.ID.String()
+			if previous, exists := served[address]; exists {
+				assert.Equal(t, previous, id)
+			}
+			served[address] = id
+		}
+	}
+
+	total := 0
+	for _, count := range dht.UnknownReceiverStats().Routed {
+		total += count
+	}
+	assert.Equal(t, 12, total)
+}
@@ -0,0 +1,130 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+)
+
+// maxRefreshRounds is the number of recent refresh rounds kept by DHT
+const maxRefreshRounds = 32
+
+// RefreshRound contains outcome of a single bucket refresh round of one routing table
+type RefreshRound struct {
+	// ID of the node which routing table was refreshed
+	ID node.ID
+	// Time refresh round started at
+	Started time.Time
+	// Number of buckets refreshed
+	Buckets int
+	// Number of contacts added to routing table
+	Learned int
+	// Number of contacts which did not respond
+	Unreachable int
+}
+
+// refreshCounters collects outcome of refresh lookups running concurrently
+type refreshCounters struct {
+	learned     int64
+	unreachable int64
+}
+
+// refreshHistory keeps recent refresh rounds, oldest first
+type refreshHistory struct {
+	mutex  *sync.Mutex
+	rounds []RefreshRound
+}
+
+func newRefreshHistory() *refreshHistory {
+	return &refreshHistory{
+		mutex: &sync.Mutex{},
+	}
+}
+
+func (rh *refreshHistory) add(round RefreshRound) {
+	rh.mutex.Lock()
+	defer rh.mutex.Unlock()
+
+	rh.rounds = append(rh.rounds, round)
+	if len(rh.rounds) > maxRefreshRounds {
+		rh.rounds = rh.rounds[len(rh.rounds)-maxRefreshRounds:]
+	}
+}
+
+func (rh *refreshHistory) all() []RefreshRound {
+	rh.mutex.Lock()
+	defer rh.mutex.Unlock()
+
+	return append([]RefreshRound{}, rh.rounds...)
+}
+
+// RefreshRounds returns outcomes of recent bucket refresh rounds, oldest first.
+// It helps to check if RefreshTime is tuned correctly for network churn rate.
+func (dht *DHT) RefreshRounds() []RefreshRound {
+	return dht.refreshes.all()
+}
+
+// refresh looks up random IDs in buckets not accessed for their refresh interval and records outcome
+func (dht *DHT) refresh(ctx Context, ht *routing.HashTable) {
+	counters := &refreshCounters{}
+	ctx = context.WithValue(ctx, ctxRefresh, counters)
+	round := RefreshRound{ID: ht.Origin.ID, Started: dht.options.Clock.Now()}
+
+	for i := 0; i < routing.KeyBitSize; i++ {
+		now := dht.options.Clock.Now()
+		if now.Sub(ht.GetRefreshTimeForBucket(i)) > dht.refreshInterval(ctx, i) {
+			dht.churnFor(ctx).refreshed(i, now)
+			round.Buckets++
+			id := ht.GetRandomIDFromBucket(routing.MaxContactsInBucket)
+			_, _, err := dht.iterate(ctx, routing.IterateBootstrap, id, nil, nil)
+			if err != nil {
+				continue
+			}
+		}
+	}
+
+	if round.Buckets == 0 {
+		return
+	}
+
+	round.Learned = int(atomic.LoadInt64(&counters.learned))
+	round.Unreachable = int(atomic.LoadInt64(&counters.unreachable))
+	dht.refreshes.add(round)
+	if dht.options.OnRefresh != nil {
+		dht.options.OnRefresh(round)
+	}
+}
+
+// countLearned counts contact added to routing table if ctx is used by refresh
+func countLearned(ctx Context) {
+	if counters, ok := ctx.Value(ctxRefresh).(*refreshCounters); ok {
+		atomic.AddInt64(&counters.learned, 1)
+	}
+}
+
+// countUnreachable counts contact which did not respond if ctx is used by refresh
+func countUnreachable(ctx Context) {
+	if counters, ok := ctx.Value(ctxRefresh).(*refreshCounters); ok {
+		atomic.AddInt64(&counters.unreachable, 1)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshHistory(t *testing.T) {
+	rh := newRefreshHistory()
+	for i := 0; i < maxRefreshRounds+2; i++ {
+		rh.add(RefreshRound{Buckets: i})
+	}
+
+	rounds := rh.all()
+	assert.Len(t, rounds, maxRefreshRounds)
+	assert.Equal(t, 2, rounds[0].Buckets)
+	assert.Equal(t, maxRefreshRounds+1, rounds[maxRefreshRounds-1].Buckets)
+}
+
+func TestRefresh_Outcome(t *testing.T) {
+	id := getIDWithValues(0)
+	st, s, tp, r, err := dhtParams([]node.ID{id}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+
+	var reported []RefreshRound
+	dht, _ := NewDHT(st, s, tp, r, &Options{
+		RefreshTime:    time.Nanosecond,
+		MessageTimeout: time.Second,
+		OnRefresh: func(round RefreshRound) {
+			reported = append(reported, round)
+		},
+	})
+	mockTp := tp.(*mockTransport)
+	ctx := getDefaultCtx(dht)
+
+	addr, _ := node.NewAddress("0.0.0.0:3001")
+	known := &node.Node{ID: getZerodIDWithNthByte(1, byte(1)), Address: addr}
+	learned := getZerodIDWithNthByte(1, byte(2))
+	unreachable := getZerodIDWithNthByte(1, byte(3))
+	dht.addNode(ctx, routing.NewRouteNode(known))
+
+	go func() {
+		requests := 0
+		for request := range mockTp.recv {
+			requests++
+			var response *message.Message
+			switch requests {
+			case 1:
+				response = mockFindNodeResponse(request, learned)
+			case 2:
+				// Next request to unreachable node fails
+				mockTp.failNextSendMessage()
+				response = mockFindNodeResponse(request, unreachable)
+			default:
+				response = mockFindNodeResponseEmpty(request)
+			}
+			// Requests to several nodes are sent before responses are awaited
+			go func() {
+				mockTp.send <- response
+			}()
+		}
+	}()
+
+	dht.refresh(ctx, dht.tables[0])
+
+	rounds := dht.RefreshRounds()
+	assert.Len(t, rounds, 1)
+	assert.Equal(t, reported, rounds)
+	assert.Equal(t, id, rounds[0].ID)
+	assert.Equal(t, routing.KeyBitSize, rounds[0].Buckets)
+	assert.Equal(t, 1, rounds[0].Learned)
+	assert.Equal(t, 1, rounds[0].Unreachable)
+}
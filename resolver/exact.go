@@ -21,6 +21,7 @@ import (
 )
 
 type exactResolver struct {
+	interfaceAddrs func() ([]net.Addr, error)
 }
 
 // NewExactResolver returns new exact address resolver
@@ -29,10 +30,49 @@ func NewExactResolver() PublicAddressResolver {
 }
 
 func newExactResolver() *exactResolver {
-	return &exactResolver{}
+	return &exactResolver{
+		interfaceAddrs: net.InterfaceAddrs,
+	}
 }
 
-// Resolve returns node's current network address
+// Resolve returns node's current network address. If conn listens on all interfaces,
+// address of one of them is returned, IPv6 one is preferred for dual-stack conn.
 func (er *exactResolver) Resolve(conn net.PacketConn) (string, error) {
-	return conn.LocalAddr().String(), nil
+	addr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok || !addr.IP.IsUnspecified() {
+		return conn.LocalAddr().String(), nil
+	}
+
+	interfaceAddrs, err := er.interfaceAddrs()
+	if err != nil {
+		return "", err
+	}
+
+	ip := interfaceIP(addr.IP, interfaceAddrs)
+	return (&net.UDPAddr{IP: ip, Port: addr.Port}).String(), nil
+}
+
+// interfaceIP selects global unicast address of the same family as unspecified ip,
+// any family for IPv6 ip as dual-stack conn accepts both. Unspecified ip is returned if none found.
+func interfaceIP(unspecified net.IP, interfaceAddrs []net.Addr) net.IP {
+	var ipv4 net.IP
+	for _, interfaceAddr := range interfaceAddrs {
+		ipNet, ok := interfaceAddr.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+
+		if ipNet.IP.To4() == nil {
+			if unspecified.To4() == nil {
+				return ipNet.IP
+			}
+		} else if ipv4 == nil {
+			ipv4 = ipNet.IP
+		}
+	}
+
+	if ipv4 != nil {
+		return ipv4
+	}
+	return unspecified
 }
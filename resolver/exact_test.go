@@ -42,3 +42,43 @@ func TestExactResolver_Resolve(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, strAddr, addr)
 }
+
+func TestExactResolver_ResolveUnspecified(t *testing.T) {
+	_, ipv4, _ := net.ParseCIDR("10.0.0.1/8")
+	_, ipv6, _ := net.ParseCIDR("2001:db8::1/64")
+	_, loopback, _ := net.ParseCIDR("127.0.0.1/8")
+	_, linkLocal, _ := net.ParseCIDR("fe80::1/64")
+	ipv4.IP = net.ParseIP("10.0.0.1")
+	ipv6.IP = net.ParseIP("2001:db8::1")
+	loopback.IP = net.ParseIP("127.0.0.1")
+	linkLocal.IP = net.ParseIP("fe80::1")
+
+	resolver := newExactResolver()
+	resolver.interfaceAddrs = func() ([]net.Addr, error) {
+		return []net.Addr{loopback, linkLocal, ipv4, ipv6}, nil
+	}
+
+	tests := []struct {
+		name     string
+		local    string
+		expected string
+	}{
+		{"IPv4", "0.0.0.0:31337", "10.0.0.1:31337"},
+		{"dual-stack", "[::]:31337", "[2001:db8::1]:31337"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn := &MockPacketConn{}
+			conn.On("LocalAddr").Return(net.ResolveUDPAddr("udp", test.local))
+
+			addr, err := resolver.Resolve(conn)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, addr)
+		})
+	}
+}
+
+func TestInterfaceIP_NotFound(t *testing.T) {
+	_, loopback, _ := net.ParseCIDR("127.0.0.1/8")
+	assert.Equal(t, net.IPv6zero, interfaceIP(net.IPv6zero, []net.Addr{loopback}))
+}
@@ -0,0 +1,32 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package routing
+
+// BucketMapper decides which bucket of routing table node belongs to.
+// It allows experimental deployments, e.g. with prefix-partitioned keyspaces, to customize placement.
+type BucketMapper interface {
+	// BucketIndex returns index of bucket in range [0, KeyBitSize) for id in routing table of origin
+	BucketIndex(origin, id []byte) int
+}
+
+// DifferingBitMapper is the standard Kademlia mapping by the first bit in which IDs differ
+type DifferingBitMapper struct{}
+
+// BucketIndex returns bucket index computed by GetBucketIndexFromDifferingBit
+func (DifferingBitMapper) BucketIndex(origin, id []byte) int {
+	return GetBucketIndexFromDifferingBit(origin, id)
+}
@@ -0,0 +1,38 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package routing
+
+// EvictionPolicy decides which nodes of a full bucket give way to newly discovered ones.
+// It allows to prefer nodes by uptime, reputation or address diversity.
+type EvictionPolicy interface {
+	// ShouldEvict reports whether old node should be removed from full bucket in favour of new one
+	ShouldEvict(old, new *RouteNode) bool
+}
+
+// EvictionCandidate returns index of the least recently seen node in bucket which policy
+// allows to evict in favour of new node, or -1 if there is no such node
+func EvictionCandidate(policy EvictionPolicy, bucket []*RouteNode, new *RouteNode) int {
+	if policy == nil {
+		return -1
+	}
+	for i, old := range bucket {
+		if policy.ShouldEvict(old, new) {
+			return i
+		}
+	}
+	return -1
+}
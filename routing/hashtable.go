@@ -75,6 +75,8 @@ type HashTable struct {
 	refreshMap [KeyBitSize]time.Time
 
 	rand *rand.Rand
+
+	mapper BucketMapper
 }
 
 // NewHashTable creates new HashTable
@@ -89,6 +91,7 @@ func NewHashTable(id node.ID, address *node.Address) (*HashTable, error) {
 			ID:      id,
 			Address: address,
 		},
+		mapper: DifferingBitMapper{},
 	}
 
 	ht.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -104,6 +107,20 @@ func NewHashTable(id node.ID, address *node.Address) (*HashTable, error) {
 	return ht, nil
 }
 
+// SetBucketMapper replaces mapping of node IDs to buckets, nil restores DifferingBitMapper.
+// It must be set before any node is added to HashTable.
+func (ht *HashTable) SetBucketMapper(mapper BucketMapper) {
+	if mapper == nil {
+		mapper = DifferingBitMapper{}
+	}
+	ht.mapper = mapper
+}
+
+// BucketIndex returns index of bucket given ID belongs to
+func (ht *HashTable) BucketIndex(id []byte) int {
+	return ht.mapper.BucketIndex(ht.Origin.ID, id)
+}
+
 // Lock locks internal table mutex
 func (ht *HashTable) Lock() {
 	ht.mutex.Lock()
@@ -116,10 +133,15 @@ func (ht *HashTable) Unlock() {
 
 // ResetRefreshTimeForBucket resets refresh timer for given bucket
 func (ht *HashTable) ResetRefreshTimeForBucket(bucket int) {
+	ht.SetRefreshTimeForBucket(bucket, time.Now())
+}
+
+// SetRefreshTimeForBucket sets time given bucket was refreshed at
+func (ht *HashTable) SetRefreshTimeForBucket(bucket int, refreshed time.Time) {
 	ht.Lock()
 	defer ht.Unlock()
 
-	ht.refreshMap[bucket] = time.Now()
+	ht.refreshMap[bucket] = refreshed
 }
 
 // GetRefreshTimeForBucket returns Time when given bucket must be refreshed
@@ -135,7 +157,7 @@ func (ht *HashTable) MarkNodeAsSeen(node []byte) {
 	ht.Lock()
 	defer ht.Unlock()
 
-	index := GetBucketIndexFromDifferingBit(ht.Origin.ID, node)
+	index := ht.BucketIndex(node)
 	bucket := ht.RoutingTable[index]
 	nodeIndex := -1
 	for i, v := range bucket {
@@ -169,11 +191,21 @@ func (ht *HashTable) DoesNodeExistInBucket(bucket int, node []byte) bool {
 
 // GetClosestContacts returns RouteSet with num closest Nodes to target
 func (ht *HashTable) GetClosestContacts(num int, target []byte, ignoredNodes []*node.Node) *RouteSet {
+	return ht.getClosestContacts(num, target, ignoredNodes, false)
+}
+
+// GetClosestRecentContacts returns RouteSet with num closest Nodes to target.
+// Unlike GetClosestContacts it prefers recently seen Nodes over stale ones from the same bucket
+func (ht *HashTable) GetClosestRecentContacts(num int, target []byte, ignoredNodes []*node.Node) *RouteSet {
+	return ht.getClosestContacts(num, target, ignoredNodes, true)
+}
+
+func (ht *HashTable) getClosestContacts(num int, target []byte, ignoredNodes []*node.Node, recentFirst bool) *RouteSet {
 	ht.Lock()
 	defer ht.Unlock()
 	// First we need to build the list of adjacent indices to our target
 	// in order
-	index := GetBucketIndexFromDifferingBit(ht.Origin.ID, target)
+	index := ht.BucketIndex(target)
 	indexList := []int{index}
 	i := index - 1
 	j := index + 1
@@ -196,7 +228,12 @@ func (ht *HashTable) GetClosestContacts(num int, target []byte, ignoredNodes []*
 	for leftToAdd > 0 && len(indexList) > 0 {
 		index, indexList = indexList[0], indexList[1:]
 		bucketContacts := len(ht.RoutingTable[index])
-		for i := 0; i < bucketContacts; i++ {
+		for k := 0; k < bucketContacts; k++ {
+			i := k
+			if recentFirst {
+				// Bucket is sorted by least recently seen
+				i = bucketContacts - 1 - k
+			}
 			ignored := false
 			for j := 0; j < len(ignoredNodes); j++ {
 				if ht.RoutingTable[index][i].ID.Equal(ignoredNodes[j].ID) {
@@ -218,22 +255,60 @@ func (ht *HashTable) GetClosestContacts(num int, target []byte, ignoredNodes []*
 	return routeSet
 }
 
-func (ht *HashTable) removeNode(ID []byte) { // nolint: deadcode, megacheck
+// RemoveNode removes node with given ID from HashTable
+func (ht *HashTable) RemoveNode(ID []byte) {
 	ht.Lock()
 	defer ht.Unlock()
 
-	index := GetBucketIndexFromDifferingBit(ht.Origin.ID, ID)
+	index := ht.BucketIndex(ID)
 	bucket := ht.RoutingTable[index]
 
 	for i, v := range bucket {
 		if bytes.Equal(v.ID, ID) {
 			bucket = append(bucket[:i], bucket[i+1:]...)
+			break
 		}
 	}
 
 	ht.RoutingTable[index] = bucket
 }
 
+// Nodes returns all nodes of HashTable, the least recently seen first in every bucket
+func (ht *HashTable) Nodes() []*node.Node {
+	ht.Lock()
+	defer ht.Unlock()
+
+	var nodes []*node.Node
+	for _, bucket := range ht.RoutingTable {
+		for _, v := range bucket {
+			nodes = append(nodes, v.Node)
+		}
+	}
+	return nodes
+}
+
+// RemoveNodesWithAddress removes nodes reachable at given address from HashTable and returns them
+func (ht *HashTable) RemoveNodesWithAddress(address string) []*node.Node {
+	ht.Lock()
+	defer ht.Unlock()
+
+	var removed []*node.Node
+	for index, bucket := range ht.RoutingTable {
+		var kept []*RouteNode
+		for _, v := range bucket {
+			if v.HasAddress(address) {
+				removed = append(removed, v.Node)
+			} else {
+				kept = append(kept, v)
+			}
+		}
+		if len(kept) != len(bucket) {
+			ht.RoutingTable[index] = kept
+		}
+	}
+	return removed
+}
+
 // GetAllNodesInBucketCloserThan returns all nodes from given bucket that are closer to id then our node
 func (ht *HashTable) GetAllNodesInBucketCloserThan(bucket int, id []byte) [][]byte {
 	b := ht.RoutingTable[bucket]
@@ -267,7 +342,8 @@ func (ht *HashTable) getDistance(id1, id2 []byte) *big.Int {
 	return ret.SetBytes(dst[:])
 }
 
-// GetRandomIDFromBucket returns random node ID from given bucket
+// GetRandomIDFromBucket returns random node ID from given bucket.
+// ID is generated for differing-bit mapping, so with other BucketMapper it may fall into other bucket
 func (ht *HashTable) GetRandomIDFromBucket(bucket int) []byte {
 	ht.Lock()
 	defer ht.Unlock()
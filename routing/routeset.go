@@ -28,6 +28,9 @@ type RouteSet struct {
 
 	// comparator is the requestID to compare to
 	comparator []byte
+
+	// region is locality region of nodes which go first when distances tie
+	region string
 }
 
 // NewRouteSet creates new RouteSet
@@ -92,10 +95,24 @@ func (rs *RouteSet) Swap(i, j int) {
 	rs.nodes[i], rs.nodes[j] = rs.nodes[j], rs.nodes[i]
 }
 
+// PreferRegion makes nodes of given locality region go first among nodes which distances tie,
+// i.e. differ from comparator in the same highest bit
+func (rs *RouteSet) PreferRegion(region string) {
+	rs.region = region
+}
+
 // Less is a sorting function for RouteSet
 func (rs *RouteSet) Less(i, j int) bool {
 	iDist := getDistance(rs.nodes[i].ID, rs.comparator)
 	jDist := getDistance(rs.nodes[j].ID, rs.comparator)
 
+	if rs.region != "" && iDist.BitLen() == jDist.BitLen() {
+		iLocal := rs.nodes[i].Locality.Region == rs.region
+		jLocal := rs.nodes[j].Locality.Region == rs.region
+		if iLocal != jLocal {
+			return iLocal
+		}
+	}
+
 	return iDist.Cmp(jDist) == -1
 }
@@ -180,3 +180,26 @@ func TestRouteSet_Less(t *testing.T) {
 	assert.True(t, rs.Less(0, 1))
 	assert.False(t, rs.Less(1, 0))
 }
+
+func TestRouteSet_Less_PreferRegion(t *testing.T) {
+	addr, _ := node.NewAddress("127.0.0.1:31337")
+	far := node.NewNode(addr)
+	far.ID = getIDWithValues(5)
+	local := node.NewNode(addr)
+	local.ID = getIDWithValues(7)
+	local.Locality = node.Locality{Region: "eu-west"}
+	other := node.NewNode(addr)
+	other.ID = getIDWithValues(10)
+	other.Locality = node.Locality{Region: "eu-west"}
+	rs := NewRouteSet()
+	rs.Append(NewRouteNode(far))
+	rs.Append(NewRouteNode(local))
+	rs.Append(NewRouteNode(other))
+	rs.PreferRegion("eu-west")
+
+	// Distances of the first two nodes tie, the third one is farther
+	assert.False(t, rs.Less(0, 1))
+	assert.True(t, rs.Less(1, 0))
+	assert.True(t, rs.Less(0, 2))
+	assert.True(t, rs.Less(1, 2))
+}
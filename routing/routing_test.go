@@ -85,6 +85,104 @@ func TestRouteSet(t *testing.T) {
 	assert.Equal(t, n4, nl.nodes[3])
 }
 
+func TestHashTable_GetClosestRecentContacts(t *testing.T) {
+	ht, err := NewHashTable(getIDWithValues(0), nil)
+	assert.NoError(t, err)
+
+	// All nodes fall into the same bucket
+	stale := NewRouteNode(&node.Node{ID: getZerodIDWithNthByte(19, 4)})
+	seen := NewRouteNode(&node.Node{ID: getZerodIDWithNthByte(19, 5)})
+	added := NewRouteNode(&node.Node{ID: getZerodIDWithNthByte(19, 6)})
+	index := GetBucketIndexFromDifferingBit(ht.Origin.ID, stale.ID)
+	ht.RoutingTable[index] = []*RouteNode{stale, seen, added}
+	ht.MarkNodeAsSeen(seen.ID)
+
+	target := getZerodIDWithNthByte(19, 7)
+	assert.Equal(t, []*node.Node{stale.Node, added.Node}, ht.GetClosestContacts(2, target, nil).Nodes())
+
+	closest := ht.GetClosestRecentContacts(2, target, nil).Nodes()
+	assert.Len(t, closest, 2)
+	assert.Contains(t, closest, seen.Node)
+	assert.Contains(t, closest, added.Node)
+}
+
+func TestHashTable_RemoveNodesWithAddress(t *testing.T) {
+	ht, err := NewHashTable(getIDWithValues(0), nil)
+	assert.NoError(t, err)
+
+	addr1, _ := node.NewAddress("127.0.0.1:31337")
+	addr2, _ := node.NewAddress("127.0.0.1:31338")
+	dead := NewRouteNode(&node.Node{ID: getZerodIDWithNthByte(19, 4), Address: addr1})
+	alive := NewRouteNode(&node.Node{ID: getZerodIDWithNthByte(19, 5), Address: addr2})
+	index := GetBucketIndexFromDifferingBit(ht.Origin.ID, dead.ID)
+	ht.RoutingTable[index] = []*RouteNode{dead, alive}
+
+	assert.Equal(t, []*node.Node{dead.Node}, ht.RemoveNodesWithAddress("127.0.0.1:31337"))
+	assert.Equal(t, []*RouteNode{alive}, ht.RoutingTable[index])
+	assert.Empty(t, ht.RemoveNodesWithAddress("127.0.0.1:31337"))
+}
+
+func TestHashTable_Nodes(t *testing.T) {
+	ht, err := NewHashTable(getIDWithValues(0), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, ht.Nodes())
+
+	first := NewRouteNode(&node.Node{ID: getZerodIDWithNthByte(19, 4)})
+	second := NewRouteNode(&node.Node{ID: getZerodIDWithNthByte(0, 4)})
+	ht.RoutingTable[GetBucketIndexFromDifferingBit(ht.Origin.ID, first.ID)] = []*RouteNode{first}
+	ht.RoutingTable[GetBucketIndexFromDifferingBit(ht.Origin.ID, second.ID)] = []*RouteNode{second}
+
+	nodes := ht.Nodes()
+	assert.Len(t, nodes, 2)
+	assert.Contains(t, nodes, first.Node)
+	assert.Contains(t, nodes, second.Node)
+}
+
+// prefixMapper places nodes into buckets by first byte of their IDs
+type prefixMapper struct{}
+
+func (prefixMapper) BucketIndex(origin, id []byte) int {
+	return int(id[0]) % KeyBitSize
+}
+
+func TestHashTable_SetBucketMapper(t *testing.T) {
+	ht, err := NewHashTable(getIDWithValues(0), nil)
+	assert.NoError(t, err)
+
+	n := NewRouteNode(&node.Node{ID: getZerodIDWithNthByte(0, 7)})
+	assert.Equal(t, GetBucketIndexFromDifferingBit(ht.Origin.ID, n.ID), ht.BucketIndex(n.ID))
+
+	ht.SetBucketMapper(prefixMapper{})
+	assert.Equal(t, 7, ht.BucketIndex(n.ID))
+	ht.RoutingTable[7] = []*RouteNode{n}
+	assert.Equal(t, []*node.Node{n.Node}, ht.GetClosestContacts(1, n.ID, nil).Nodes())
+	ht.RemoveNode(n.ID)
+	assert.Empty(t, ht.RoutingTable[7])
+
+	ht.SetBucketMapper(nil)
+	assert.Equal(t, GetBucketIndexFromDifferingBit(ht.Origin.ID, n.ID), ht.BucketIndex(n.ID))
+}
+
+type evictByID struct {
+	id node.ID
+}
+
+func (p *evictByID) ShouldEvict(old, new *RouteNode) bool {
+	return old.ID.Equal(p.id)
+}
+
+func TestEvictionCandidate(t *testing.T) {
+	bucket := []*RouteNode{
+		NewRouteNode(&node.Node{ID: getZerodIDWithNthByte(19, 4)}),
+		NewRouteNode(&node.Node{ID: getZerodIDWithNthByte(19, 5)}),
+	}
+	newNode := NewRouteNode(&node.Node{ID: getZerodIDWithNthByte(19, 6)})
+
+	assert.Equal(t, -1, EvictionCandidate(nil, bucket, newNode))
+	assert.Equal(t, 1, EvictionCandidate(&evictByID{id: getZerodIDWithNthByte(19, 5)}, bucket, newNode))
+	assert.Equal(t, -1, EvictionCandidate(&evictByID{id: getZerodIDWithNthByte(19, 7)}, bucket, newNode))
+}
+
 func getZerodIDWithNthByte(n int, v byte) node.ID {
 	id := getIDWithValues(0)
 	id[n] = v
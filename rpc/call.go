@@ -0,0 +1,60 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package rpc
+
+import (
+	"context"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+)
+
+type callKey struct{}
+
+// Call is metadata of incoming remote procedure call which handlers can use to correlate their logs with network traces
+type Call struct {
+	// RequestID is ID of request message carrying the call
+	RequestID message.RequestID
+	// Sender is node which made the call
+	Sender *node.Node
+	// RemoteAddress is network address request was received from, it is empty if transport does not report it
+	RemoteAddress string
+	// Headers is application metadata caller attached to request, e.g. tenant ID or trace context
+	Headers map[string]string
+}
+
+// NewCallContext returns context carrying metadata of incoming call
+func NewCallContext(parent context.Context, call Call) context.Context {
+	return context.WithValue(parent, callKey{}, call)
+}
+
+// NewCallContextFromMessage returns context carrying metadata of call received in request message
+func NewCallContextFromMessage(parent context.Context, msg *message.Message) context.Context {
+	return NewCallContext(parent, Call{
+		RequestID:     msg.RequestID,
+		Sender:        msg.Sender,
+		RemoteAddress: msg.RemoteAddress(),
+		Headers:       msg.Headers,
+	})
+}
+
+// CallFromContext returns metadata of call handled with ctx.
+// It returns false for calls which node made to itself as they are not sent over network.
+func CallFromContext(ctx context.Context) (Call, bool) {
+	call, ok := ctx.Value(callKey{}).(Call)
+	return call, ok
+}
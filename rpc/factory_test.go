@@ -17,6 +17,7 @@
 package rpc
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -25,11 +26,11 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func rpcTestMethod1(sender *node.Node, args [][]byte) ([]byte, error) {
+func rpcTestMethod1(ctx context.Context, sender *node.Node, args [][]byte) ([]byte, error) {
 	return []byte("testMethod1"), nil
 }
 
-func rpcTestMethod2(sender *node.Node, args [][]byte) ([]byte, error) {
+func rpcTestMethod2(ctx context.Context, sender *node.Node, args [][]byte) ([]byte, error) {
 	return []byte("testMethod2"), nil
 }
 
@@ -64,7 +65,7 @@ func TestRpcFactory_Create(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			res, err := actualRPC.Invoke(node.NewNode(address), test.name, [][]byte{})
+			res, err := actualRPC.Invoke(context.Background(), node.NewNode(address), test.name, [][]byte{})
 			assert.Equal(t, test.result, res)
 			assert.Equal(t, test.err, err)
 		})
@@ -17,19 +17,20 @@
 package rpc
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
 	"github.com/insolar/network/node"
 )
 
-// RemoteProcedure is remote procedure call function
-type RemoteProcedure func(sender *node.Node, args [][]byte) ([]byte, error)
+// RemoteProcedure is remote procedure call function, metadata of call is available with CallFromContext(ctx)
+type RemoteProcedure func(ctx context.Context, sender *node.Node, args [][]byte) ([]byte, error)
 
 // RPC is remote procedure call module
 type RPC interface {
 	// Invoke is used to actually call remote procedure
-	Invoke(sender *node.Node, method string, args [][]byte) ([]byte, error)
+	Invoke(ctx context.Context, sender *node.Node, method string, args [][]byte) ([]byte, error)
 	// RegisterMethod allows to register new function in RPC module
 	RegisterMethod(name string, method RemoteProcedure)
 }
@@ -46,7 +47,7 @@ func NewRPC() RPC {
 }
 
 // Invoke calls registered function or returns error
-func (rpc *rpc) Invoke(sender *node.Node, methodName string, args [][]byte) (result []byte, err error) {
+func (rpc *rpc) Invoke(ctx context.Context, sender *node.Node, methodName string, args [][]byte) (result []byte, err error) {
 	method, exist := rpc.methodTable[methodName]
 	if !exist {
 		return nil, errors.New("method does not exist")
@@ -58,7 +59,7 @@ func (rpc *rpc) Invoke(sender *node.Node, methodName string, args [][]byte) (res
 		}
 	}()
 
-	result, err = method(sender, args)
+	result, err = method(ctx, sender, args)
 	return
 }
 
@@ -17,9 +17,11 @@
 package rpc
 
 import (
+	"context"
 	"errors"
 	"testing"
 
+	"github.com/insolar/network/message"
 	"github.com/insolar/network/node"
 
 	"github.com/stretchr/testify/assert"
@@ -35,43 +37,70 @@ func TestNewRPC(t *testing.T) {
 
 func TestRPC_Invoke_ReturnsErrorForNonExistingMethod(t *testing.T) {
 	r := NewRPC()
-	_, err := r.Invoke(nil, "test_method", nil)
+	_, err := r.Invoke(context.Background(), nil, "test_method", nil)
 
 	assert.EqualError(t, err, "method does not exist")
 }
 
 func TestRPC_RegisterMethod(t *testing.T) {
 	r := NewRPC()
-	_, err := r.Invoke(nil, "test_method", nil)
+	_, err := r.Invoke(context.Background(), nil, "test_method", nil)
 	assert.Error(t, err)
 
-	r.RegisterMethod("test_method", func(sender *node.Node, args [][]byte) ([]byte, error) {
+	r.RegisterMethod("test_method", func(ctx context.Context, sender *node.Node, args [][]byte) ([]byte, error) {
 		return []byte("hello world"), nil
 	})
 
-	res, err := r.Invoke(nil, "test_method", nil)
+	res, err := r.Invoke(context.Background(), nil, "test_method", nil)
 	assert.NoError(t, err)
 	assert.Equal(t, res, []byte("hello world"))
 }
 
 func TestRPC_Invoke_RecoversFromPanic(t *testing.T) {
 	r := NewRPC()
-	r.RegisterMethod("panic_method", func(sender *node.Node, args [][]byte) ([]byte, error) {
+	r.RegisterMethod("panic_method", func(ctx context.Context, sender *node.Node, args [][]byte) ([]byte, error) {
 		panic("test_panic")
 	})
 
-	res, err := r.Invoke(nil, "panic_method", nil)
+	res, err := r.Invoke(context.Background(), nil, "panic_method", nil)
 	assert.Nil(t, res)
 	assert.EqualError(t, err, "panic: test_panic")
 }
 
 func TestRPC_Invoke_ReturnsErrorFromMethod(t *testing.T) {
 	r := NewRPC()
-	r.RegisterMethod("error_method", func(sender *node.Node, args [][]byte) ([]byte, error) {
+	r.RegisterMethod("error_method", func(ctx context.Context, sender *node.Node, args [][]byte) ([]byte, error) {
 		return nil, errors.New("example error")
 	})
 
-	res, err := r.Invoke(nil, "error_method", nil)
+	res, err := r.Invoke(context.Background(), nil, "error_method", nil)
 	assert.Nil(t, res)
 	assert.EqualError(t, err, "example error")
 }
+
+func TestRPC_Invoke_PassesCallContext(t *testing.T) {
+	r := NewRPC()
+	r.RegisterMethod("call_method", func(ctx context.Context, sender *node.Node, args [][]byte) ([]byte, error) {
+		call, ok := CallFromContext(ctx)
+		assert.True(t, ok)
+		return []byte(call.RemoteAddress), nil
+	})
+
+	address, _ := node.NewAddress("127.0.0.1:31337")
+	msg := message.NewPingMessage(node.NewNode(address), node.NewNode(address))
+	msg.RequestID = 42
+	msg.SetRemoteAddress("127.0.0.1:31338")
+	msg.Headers = map[string]string{"tenant": "acme"}
+	ctx := NewCallContextFromMessage(context.Background(), msg)
+
+	res, err := r.Invoke(ctx, msg.Sender, "call_method", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("127.0.0.1:31338"), res)
+
+	call, ok := CallFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, Call{RequestID: 42, Sender: msg.Sender, RemoteAddress: "127.0.0.1:31338", Headers: msg.Headers}, call)
+
+	_, ok = CallFromContext(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,277 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+)
+
+// ErrServiceNotFound is returned by LookupService when there is no live instance of service
+var ErrServiceNotFound = errors.New("service not found")
+
+// services keeps service records node holds for publishers
+type services struct {
+	mutex   *sync.Mutex
+	records map[string]map[string]*store.ServiceRecord
+	count   int
+}
+
+func newServices() *services {
+	return &services{
+		mutex:   &sync.Mutex{},
+		records: make(map[string]map[string]*store.ServiceRecord),
+	}
+}
+
+// add keeps record unless limit of records is reached, record of the same instance is replaced
+func (s *services) add(record *store.ServiceRecord, max int, now time.Time) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.expire(now)
+
+	instances, ok := s.records[record.Name]
+	if !ok {
+		instances = make(map[string]*store.ServiceRecord)
+		s.records[record.Name] = instances
+	}
+	if _, exists := instances[record.Instance()]; !exists {
+		if max > 0 && s.count >= max {
+			return false
+		}
+		s.count++
+	}
+	instances[record.Instance()] = record
+	return true
+}
+
+// lookup returns live records of service
+func (s *services) lookup(name string, now time.Time) []*store.ServiceRecord {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.expire(now)
+
+	records := make([]*store.ServiceRecord, 0, len(s.records[name]))
+	for _, record := range s.records[name] {
+		records = append(records, record)
+	}
+	return records
+}
+
+// expire forgets expired records, must be called under mutex
+func (s *services) expire(now time.Time) {
+	for name, instances := range s.records {
+		for instance, record := range instances {
+			if !now.Before(record.Expiration) {
+				delete(instances, instance)
+				s.count--
+			}
+		}
+		if len(instances) == 0 {
+			delete(s.records, name)
+		}
+	}
+}
+
+// RegisterService announces address of service instance for ttl. Signed record is kept by nodes
+// closest to the service name, instance should be registered again before ttl passes to stay discoverable.
+func (dht *DHT) RegisterService(ctx Context, name string, address string, ttl time.Duration) error {
+	if name == "" {
+		return errors.New("invalid service name")
+	}
+	if ttl <= 0 {
+		return errors.New("invalid ttl")
+	}
+
+	ht := dht.htFromCtx(ctx)
+	record := store.NewServiceRecord(name, address, ht.Origin.ID, dht.options.Clock.Now().Add(ttl), dht.options.PrivateKey)
+	stored := dht.services.add(record, dht.options.MaxServiceRecords, dht.options.Clock.Now())
+
+	_, closest, err := dht.iterate(ctx, routing.IterateFindNode, store.ServiceKey(dht.keyHash(), name), nil, nil)
+	if err != nil {
+		return err
+	}
+	if dht.sendRegisterService(ctx, record, closest) == 0 && !stored {
+		return errors.New("service is not registered")
+	}
+	return nil
+}
+
+// LookupService returns addresses of live instances of service, ErrServiceNotFound is returned if there are none
+func (dht *DHT) LookupService(ctx Context, name string) ([]string, error) {
+	_, closest, err := dht.iterate(ctx, routing.IterateFindNode, store.ServiceKey(dht.keyHash(), name), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := dht.options.Clock.Now()
+	records := append(dht.services.lookup(name, now), dht.sendLookupService(ctx, name, closest)...)
+
+	instances := make(map[string]*store.ServiceRecord)
+	for _, record := range records {
+		if record.Name != name || !now.Before(record.Expiration) || !record.Verify() {
+			continue
+		}
+		if known, ok := instances[record.Instance()]; !ok || record.Expiration.After(known.Expiration) {
+			instances[record.Instance()] = record
+		}
+	}
+	if len(instances) == 0 {
+		return nil, ErrServiceNotFound
+	}
+
+	addresses := make([]string, 0, len(instances))
+	seen := make(map[string]bool)
+	for _, record := range instances {
+		if !seen[record.Address] {
+			seen[record.Address] = true
+			addresses = append(addresses, record.Address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses, nil
+}
+
+// sendRegisterService sends record to nodes and returns number of nodes which accepted it
+func (dht *DHT) sendRegisterService(ctx Context, record *store.ServiceRecord, nodes []*node.Node) int {
+	ht := dht.htFromCtx(ctx)
+	results := make(chan bool, len(nodes))
+	wg := &sync.WaitGroup{}
+
+	for _, receiver := range nodes {
+		request := message.NewBuilder().Sender(ht.Origin).Receiver(receiver).Type(message.TypeRegisterService).Request(
+			&message.RequestDataRegisterService{
+				Record: record,
+			}).Build()
+
+		future, err := dht.sendRequest(ctx, request)
+		if err != nil {
+			log.Println("Failed to send service registration:", err.Error())
+			continue
+		}
+
+		wg.Add(1)
+		go func(future transport.Future) {
+			defer wg.Done()
+			result, err := dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+			if err != nil {
+				return
+			}
+			response, ok := result.Data.(*message.ResponseDataRegisterService)
+			results <- ok && response.Success
+		}(future)
+	}
+
+	wg.Wait()
+	close(results)
+
+	accepted := 0
+	for success := range results {
+		if success {
+			accepted++
+		}
+	}
+	return accepted
+}
+
+// sendLookupService asks nodes for records of service
+func (dht *DHT) sendLookupService(ctx Context, name string, nodes []*node.Node) []*store.ServiceRecord {
+	ht := dht.htFromCtx(ctx)
+	results := make(chan []*store.ServiceRecord, len(nodes))
+	wg := &sync.WaitGroup{}
+
+	for _, receiver := range nodes {
+		request := message.NewBuilder().Sender(ht.Origin).Receiver(receiver).Type(message.TypeLookupService).Request(
+			&message.RequestDataLookupService{
+				Name: name,
+			}).Build()
+
+		future, err := dht.sendRequest(ctx, request)
+		if err != nil {
+			log.Println("Failed to send service lookup:", err.Error())
+			continue
+		}
+
+		wg.Add(1)
+		go func(future transport.Future) {
+			defer wg.Done()
+			result, err := dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+			if err != nil {
+				return
+			}
+			response, ok := result.Data.(*message.ResponseDataLookupService)
+			if ok {
+				results <- response.Records
+			}
+		}(future)
+	}
+
+	wg.Wait()
+	close(results)
+
+	var records []*store.ServiceRecord
+	for found := range results {
+		records = append(records, found...)
+	}
+	return records
+}
+
+func (dht *DHT) processRegisterService(ctx Context, msg *message.Message, messageBuilder message.Builder) {
+	data := msg.Data.(*message.RequestDataRegisterService)
+	dht.addNode(ctx, routing.NewRouteNode(msg.Sender))
+	response := &message.ResponseDataRegisterService{}
+
+	record := data.Record
+	now := dht.options.Clock.Now()
+	switch {
+	case dht.IsReadOnly():
+		log.Println("Rejected service registration in read-only mode from", msg.Sender)
+	case record == nil || record.Name == "" || !now.Before(record.Expiration) || !record.Verify():
+		log.Println("Rejected invalid service record from", msg.Sender)
+	default:
+		response.Success = dht.services.add(record, dht.options.MaxServiceRecords, now)
+	}
+
+	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
+	if err != nil {
+		log.Println("Failed to send response:", err.Error())
+	}
+}
+
+func (dht *DHT) processLookupService(ctx Context, msg *message.Message, messageBuilder message.Builder) {
+	data := msg.Data.(*message.RequestDataLookupService)
+	dht.addNode(ctx, routing.NewRouteNode(msg.Sender))
+	response := &message.ResponseDataLookupService{
+		Records: dht.services.lookup(data.Name, dht.options.Clock.Now()),
+	}
+
+	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
+	if err != nil {
+		log.Println("Failed to send response:", err.Error())
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServices_Add(t *testing.T) {
+	s := newServices()
+	_, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	now := time.Now()
+
+	first := store.NewServiceRecord("db", "127.0.0.1:5432", []byte("a"), now.Add(time.Minute), privateKey)
+	second := store.NewServiceRecord("db", "127.0.0.2:5432", []byte("a"), now.Add(time.Second), privateKey)
+	assert.True(t, s.add(first, 2, now))
+	assert.True(t, s.add(second, 2, now))
+	// Renewal of instance is not limited
+	assert.True(t, s.add(first, 2, now))
+	assert.False(t, s.add(store.NewServiceRecord("cache", "127.0.0.1:6379", []byte("a"), now.Add(time.Minute), privateKey), 2, now))
+	assert.Len(t, s.lookup("db", now), 2)
+
+	// Expired records are forgotten
+	assert.Equal(t, []*store.ServiceRecord{first}, s.lookup("db", now.Add(time.Second)))
+	assert.Equal(t, 1, s.count)
+}
+
+func TestDHT_Services(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+
+	ctx := getDefaultCtx(dht2)
+	err = dht2.RegisterService(ctx, "", "127.0.0.1:5432", time.Minute)
+	assert.EqualError(t, err, "invalid service name")
+	err = dht2.RegisterService(ctx, "db", "127.0.0.1:5432", 0)
+	assert.EqualError(t, err, "invalid ttl")
+
+	assert.NoError(t, dht2.RegisterService(ctx, "db", "127.0.0.1:5432", time.Minute))
+	assert.Len(t, dht1.services.lookup("db", time.Now()), 1)
+
+	addresses, err := dht1.LookupService(getDefaultCtx(dht1), "db")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:5432"}, addresses)
+
+	_, err = dht1.LookupService(getDefaultCtx(dht1), "cache")
+	assert.Equal(t, ErrServiceNotFound, err)
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
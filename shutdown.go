@@ -0,0 +1,103 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/insolar/network/store"
+)
+
+// ErrShutdownTimeout is returned when network is not closed within ShutdownTimeout
+var ErrShutdownTimeout = errors.New("shutdown timeout exceeded")
+
+// Handoff stores all values held by the node ctx is bound to on the closest nodes,
+// so they stay available once the node leaves. It stops once ctx is done.
+func (dht *DHT) Handoff(ctx Context) error {
+	enumerator, ok := dht.storeFor(ctx).(store.Enumerator)
+	if !ok {
+		return errors.New("store does not support enumeration")
+	}
+
+	for _, entry := range enumerator.Entries() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		dht.replicate(ctx, []store.Key{entry.Key})
+	}
+	return nil
+}
+
+// RunUntilSignal blocks until process gets SIGINT or SIGTERM, or until network is stopped otherwise.
+// On signal values are handed off to the closest nodes if HandoffOnShutdown option is set
+// and network is closed with cfg. It gives up after ShutdownTimeout option.
+func RunUntilSignal(dht *DHT, cfg *Configuration) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	return runUntilSignal(dht, cfg, signals)
+}
+
+func runUntilSignal(dht *DHT, cfg *Configuration, signals <-chan os.Signal) error {
+	select {
+	case sig := <-signals:
+		log.Println("Shutting down on", sig.String())
+	case <-dht.transport.Stopped():
+		return nil
+	}
+
+	deadline := time.Now().Add(dht.options.ShutdownTimeout)
+	closed := make(chan error, 1)
+	go func() {
+		if dht.options.HandoffOnShutdown {
+			dht.handoffAll(deadline)
+		}
+		closed <- cfg.CloseNetwork()
+	}()
+
+	select {
+	case err := <-closed:
+		return err
+	case <-time.After(time.Until(deadline)):
+		return ErrShutdownTimeout
+	}
+}
+
+// handoffAll hands off values of all node IDs until deadline
+func (dht *DHT) handoffAll(deadline time.Time) {
+	cb := NewContextBuilder(dht)
+	for _, ht := range dht.tables {
+		ctx, err := cb.SetNodeByID(ht.Origin.ID).Build()
+		if err != nil {
+			log.Println("Failed to create context:", err.Error())
+			continue
+		}
+		ctx, cancel := context.WithDeadline(ctx, deadline)
+		err = dht.Handoff(ctx)
+		cancel()
+		if err != nil {
+			log.Println("Failed to hand off values:", err.Error())
+		}
+	}
+}
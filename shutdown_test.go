@@ -0,0 +1,93 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/connection"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/rpc"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunUntilSignal(t *testing.T) {
+	cfg := NewNetworkConfiguration(
+		&mockResolverOk{},
+		connection.NewConnectionFactory(),
+		&mockTransportFactoryOk{},
+		store.NewMemoryStoreFactory(),
+		rpc.NewRPCFactory(map[string]rpc.RemoteProcedure{}),
+	)
+	dht, err := cfg.CreateNetwork("127.0.0.1:8151", &Options{DrainTimeout: time.Millisecond})
+	assert.NoError(t, err)
+
+	signals := make(chan os.Signal, 1)
+	signals <- syscall.SIGTERM
+	assert.NoError(t, runUntilSignal(dht, cfg, signals))
+
+	// Network is closed already
+	assert.NoError(t, runUntilSignal(dht, cfg, make(chan os.Signal)))
+}
+
+func TestDHT_Handoff(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+
+	// Value is held by the leaving node only
+	data := []byte("foo")
+	key := store.NewKey(data)
+	ctx := getDefaultCtx(dht2)
+	err = st2.Store(ctx, key, data, time.Now().Add(time.Hour), time.Now().Add(time.Hour), true)
+	assert.NoError(t, err)
+
+	assert.NoError(t, dht2.Handoff(ctx))
+	_, found, err := st1.Retrieve(getDefaultCtx(dht1), key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
@@ -0,0 +1,195 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+)
+
+const (
+	snapshotPrefix = "snapshot-"
+	snapshotSuffix = ".json"
+)
+
+// Snapshot is a compact record of internal stats, see SnapshotDirectory option
+type Snapshot struct {
+	Time       time.Time
+	Identities []IdentitySnapshot
+	Transport  transport.Stats
+
+	Versions         map[string]int
+	UnknownReceivers UnknownReceiverStats
+}
+
+// IdentitySnapshot contains stats of a single ID
+type IdentitySnapshot struct {
+	ID    string
+	Nodes int
+	// Store is nil if store does not report its size
+	Store     *store.Stats
+	Latencies map[int]LatencyHistogram
+}
+
+// Snapshot returns current stats of DHT
+func (dht *DHT) Snapshot() Snapshot {
+	snapshot := Snapshot{
+		Time:             time.Now(),
+		Transport:        dht.transport.Stats(),
+		Versions:         dht.VersionStats(),
+		UnknownReceivers: dht.UnknownReceiverStats(),
+	}
+
+	cb := NewContextBuilder(dht)
+	for _, ht := range dht.tables {
+		ctx, err := cb.SetNodeByID(ht.Origin.ID).Build()
+		if err != nil {
+			continue
+		}
+
+		identity := IdentitySnapshot{
+			ID:        ht.Origin.ID.String(),
+			Nodes:     dht.NumNodes(ctx),
+			Latencies: dht.Latencies(ctx),
+		}
+		if reporter, ok := dht.storeFor(ctx).(store.StatsReporter); ok {
+			stats := reporter.Stats()
+			identity.Store = &stats
+		}
+		snapshot.Identities = append(snapshot.Identities, identity)
+	}
+
+	return snapshot
+}
+
+// ReadSnapshots reads snapshots written to given directory, oldest first
+func ReadSnapshots(directory string) ([]Snapshot, error) {
+	paths, err := filepath.Glob(filepath.Join(directory, snapshotPrefix+"*"+snapshotSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var snapshot Snapshot
+		err = json.Unmarshal(data, &snapshot)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.Before(snapshots[j].Time)
+	})
+	return snapshots, nil
+}
+
+// snapshotRing writes snapshots to a fixed number of files overwriting the oldest one
+type snapshotRing struct {
+	directory string
+	size      int
+	next      int
+}
+
+// newSnapshotRing creates ring continuing after the latest snapshot in directory,
+// so that snapshots written before restart are overwritten last
+func newSnapshotRing(directory string, size int) *snapshotRing {
+	ring := &snapshotRing{directory: directory, size: size}
+
+	var latest time.Time
+	paths, _ := filepath.Glob(filepath.Join(directory, snapshotPrefix+"*"+snapshotSuffix))
+	for _, path := range paths {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), snapshotPrefix), snapshotSuffix)
+		index, err := strconv.Atoi(name)
+		if err != nil || index >= size {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+			ring.next = (index + 1) % size
+		}
+	}
+
+	return ring
+}
+
+// write writes snapshot to the next file of ring
+func (ring *snapshotRing) write(snapshot Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(ring.directory, snapshotPrefix+strconv.Itoa(ring.next)+snapshotSuffix)
+
+	// Snapshot is renamed once written, so crash while writing does not leave broken file
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return err
+	}
+
+	ring.next = (ring.next + 1) % ring.size
+	return nil
+}
+
+func (dht *DHT) handleSnapshots(start, stop chan bool) {
+	start <- true
+
+	ring := newSnapshotRing(dht.options.SnapshotDirectory, dht.options.SnapshotFiles)
+	write := func() {
+		err := ring.write(dht.Snapshot())
+		if err != nil {
+			log.Println("Failed to write snapshot:", err.Error())
+		}
+	}
+
+	ticker := time.NewTicker(dht.options.SnapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			write()
+		case <-stop:
+			// Final state is recorded as well
+			write()
+			return
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/insolar/network/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotRing(t *testing.T) {
+	directory, err := ioutil.TempDir("", "network")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	ring := newSnapshotRing(directory, 2)
+	started := time.Now()
+	for i := 0; i < 3; i++ {
+		err = ring.write(Snapshot{Time: started.Add(time.Duration(i) * time.Second)})
+		assert.NoError(t, err)
+	}
+
+	snapshots, err := ReadSnapshots(directory)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+	assert.True(t, snapshots[0].Time.Equal(started.Add(time.Second)))
+	assert.True(t, snapshots[1].Time.Equal(started.Add(time.Second*2)))
+}
+
+func TestNewSnapshotRing_ContinuesAfterLatest(t *testing.T) {
+	directory, err := ioutil.TempDir("", "network")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	for i, age := range []time.Duration{time.Minute, time.Hour, time.Second} {
+		path := filepath.Join(directory, snapshotPrefix+strconv.Itoa(i)+snapshotSuffix)
+		assert.NoError(t, ioutil.WriteFile(path, []byte("{}"), 0644))
+		modified := time.Now().Add(-age)
+		assert.NoError(t, os.Chtimes(path, modified, modified))
+	}
+
+	assert.Equal(t, 0, newSnapshotRing(directory, 3).next)
+	assert.Equal(t, 0, newSnapshotRing(t.Name(), 3).next)
+
+	// Files beyond ring size are ignored
+	assert.Equal(t, 1, newSnapshotRing(directory, 2).next)
+}
+
+func TestDHT_Snapshot(t *testing.T) {
+	st, s, tp, r, err := dhtParams([]node.ID{getIDWithValues(0)}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	dht, _ := NewDHT(st, s, tp, r, &Options{})
+	ctx := getDefaultCtx(dht)
+
+	addr, _ := node.NewAddress("0.0.0.0:3001")
+	dht.addNode(ctx, routing.NewRouteNode(&node.Node{ID: getZerodIDWithNthByte(1, byte(1)), Address: addr}))
+	data := []byte("data")
+	err = st.Store(ctx, store.NewKey(data), data, time.Now(), time.Now().Add(time.Hour), true)
+	assert.NoError(t, err)
+
+	snapshot := dht.Snapshot()
+	assert.Len(t, snapshot.Identities, 1)
+	assert.Equal(t, getIDWithValues(0).String(), snapshot.Identities[0].ID)
+	assert.Equal(t, 1, snapshot.Identities[0].Nodes)
+	assert.Equal(t, &store.Stats{Keys: 1, Bytes: 4, PendingReplication: 1}, snapshot.Identities[0].Store)
+}
+
+func TestDHT_WritesSnapshots(t *testing.T) {
+	directory, err := ioutil.TempDir("", "network")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	st, s, tp, r, err := dhtParams([]node.ID{getIDWithValues(0)}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	dht, _ := NewDHT(st, s, tp, r, &Options{
+		SnapshotDirectory: directory,
+		SnapshotInterval:  time.Millisecond * 20,
+		SnapshotFiles:     3,
+	})
+
+	assert.NoError(t, dht.Listen())
+	time.Sleep(time.Millisecond * 100)
+	dht.Disconnect()
+	time.Sleep(time.Millisecond * 50)
+
+	snapshots, err := ReadSnapshots(directory)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 3)
+}
@@ -0,0 +1,371 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+)
+
+// standbySyncMethod is RPC method carrying state deltas from active node to its standby
+const standbySyncMethod = "network.standbySync"
+
+// errStandbyOutOfSync is returned by standby if delta is not based on state it has, full state is sent then
+var errStandbyOutOfSync = errors.New("standby is out of sync")
+
+// standbyDelta is a change of routing tables and stores of active node since delta Base
+type standbyDelta struct {
+	// Base is sequence number of delta this one follows, zero means full state
+	Base   uint64
+	Seq    uint64
+	Tables []standbyTableDelta
+}
+
+// standbyTableDelta is a change of routing table and store of one ID
+type standbyTableDelta struct {
+	ID      node.ID
+	Added   []*node.Node
+	Removed []node.ID
+	Stored  []store.Entry
+	Deleted []store.Key
+}
+
+// standbyBaseline is state of active node acknowledged by standby, kept as fingerprints of nodes and entries
+type standbyBaseline struct {
+	seq     uint64
+	nodes   []map[string]string
+	entries []map[string]string
+}
+
+func nodeFingerprint(n *node.Node) string {
+	return fmt.Sprint(n.Address, n.Addresses)
+}
+
+func entryFingerprint(e store.Entry) string {
+	return fmt.Sprintf("%x %d %d %d %x", sha1.Sum(e.Data), e.Replication.UnixNano(), e.Expiration.UnixNano(),
+		e.Version.Timestamp.UnixNano(), e.Version.Publisher)
+}
+
+// standbyDelta returns change of state since baseline and new baseline once delta is acknowledged.
+// Stores which are not store.Enumerator are not replicated.
+func (dht *DHT) standbyDelta(baseline *standbyBaseline) (*standbyDelta, *standbyBaseline) {
+	delta := &standbyDelta{Base: baseline.seq, Seq: baseline.seq + 1}
+	next := &standbyBaseline{seq: delta.Seq}
+
+	for i, ht := range dht.tables {
+		var oldNodes, oldEntries map[string]string
+		if i < len(baseline.nodes) {
+			oldNodes, oldEntries = baseline.nodes[i], baseline.entries[i]
+		}
+		table := standbyTableDelta{ID: ht.Origin.ID}
+
+		nodes := make(map[string]string)
+		for _, n := range ht.Nodes() {
+			fingerprint := nodeFingerprint(n)
+			nodes[string(n.ID)] = fingerprint
+			if oldNodes[string(n.ID)] != fingerprint {
+				table.Added = append(table.Added, n)
+			}
+		}
+		for id := range oldNodes {
+			if _, ok := nodes[id]; !ok {
+				table.Removed = append(table.Removed, node.ID(id))
+			}
+		}
+
+		entries := make(map[string]string)
+		if enumerator, ok := dht.identities[i].Store.(store.Enumerator); ok {
+			for _, entry := range enumerator.Entries() {
+				fingerprint := entryFingerprint(entry)
+				entries[string(entry.Key)] = fingerprint
+				if oldEntries[string(entry.Key)] != fingerprint {
+					table.Stored = append(table.Stored, entry)
+				}
+			}
+		}
+		for key := range oldEntries {
+			if _, ok := entries[key]; !ok {
+				table.Deleted = append(table.Deleted, store.Key(key))
+			}
+		}
+
+		delta.Tables = append(delta.Tables, table)
+		next.nodes = append(next.nodes, nodes)
+		next.entries = append(next.entries, entries)
+	}
+
+	return delta, next
+}
+
+func (dht *DHT) handleStandbySync(start, stop chan bool) {
+	start <- true
+
+	ticker := time.NewTicker(dht.options.StandbySyncInterval)
+	defer ticker.Stop()
+
+	baseline := &standbyBaseline{}
+	for {
+		select {
+		case <-ticker.C:
+			baseline = dht.syncStandby(baseline)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// syncStandby sends state changes to standby and returns state it acknowledged
+func (dht *DHT) syncStandby(baseline *standbyBaseline) *standbyBaseline {
+	delta, next := dht.standbyDelta(baseline)
+
+	err := dht.sendStandbyDelta(delta)
+	if err != nil && err.Error() == errStandbyOutOfSync.Error() {
+		return &standbyBaseline{}
+	}
+	if err != nil {
+		log.Println("Failed to sync standby:", err.Error())
+		return baseline
+	}
+	return next
+}
+
+func (dht *DHT) sendStandbyDelta(delta *standbyDelta) error {
+	address, err := node.NewAddress(dht.options.StandbyAddress)
+	if err != nil {
+		return err
+	}
+
+	var data bytes.Buffer
+	err = gob.NewEncoder(&data).Encode(delta)
+	if err != nil {
+		return err
+	}
+
+	origin := dht.tables[0].Origin
+	request := message.NewBuilder().Sender(origin).Receiver(&node.Node{ID: origin.ID, Address: address}).
+		Type(message.TypeRPC).Request(&message.RequestDataRPC{
+		Method: standbySyncMethod,
+		Args:   [][]byte{data.Bytes()},
+	}).Build()
+
+	future, err := dht.transport.SendRequest(request)
+	if err != nil {
+		return err
+	}
+
+	rsp, err := dht.waitResponse(context.Background(), future, dht.options.MessageTimeout)
+	if err != nil {
+		return err
+	}
+	response, ok := rsp.Data.(*message.ResponseDataRPC)
+	if !ok {
+		return errors.New("unexpected response")
+	}
+	if !response.Success {
+		return errors.New(response.Error)
+	}
+	return nil
+}
+
+// Standby keeps replica of routing tables and stores of active node which streams
+// their changes to it, see Options.StandbyAddress. On failover standby takes over
+// identity of active node with Restore or Configuration.TakeOver.
+type Standby struct {
+	transport transport.Transport
+
+	mutex    *sync.Mutex
+	seq      uint64
+	lastSync time.Time
+	ids      []node.ID
+	nodes    []map[string]*node.Node
+	entries  []map[string]store.Entry
+}
+
+// NewStandby creates Standby receiving state deltas with given transport
+func NewStandby(tp transport.Transport) *Standby {
+	return &Standby{
+		transport: tp,
+		mutex:     &sync.Mutex{},
+	}
+}
+
+// Listen receives state deltas until Stop is called
+func (s *Standby) Listen() error {
+	go s.handleMessages()
+	return s.transport.Start()
+}
+
+// Stop stops receiving state deltas, Listen must be running
+func (s *Standby) Stop() {
+	s.transport.Stop()
+}
+
+// ListenAddr returns the address standby transport is actually bound to
+func (s *Standby) ListenAddr() net.Addr {
+	return s.transport.LocalAddr()
+}
+
+// LastSync returns time when the last state delta was received, it is zero if standby is not synced yet
+func (s *Standby) LastSync() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.lastSync
+}
+
+// IDs returns IDs of active node
+func (s *Standby) IDs() []node.ID {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.ids
+}
+
+// Restore loads replicated routing tables and stores into dht created with IDs of active node
+func (s *Standby) Restore(dht *DHT) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.seq == 0 {
+		return errors.New("standby is not synced")
+	}
+	if len(dht.tables) != len(s.ids) {
+		return errors.New("identity of active node differs")
+	}
+
+	cb := NewContextBuilder(dht)
+	for i, ht := range dht.tables {
+		if !ht.Origin.ID.Equal(s.ids[i]) {
+			return errors.New("identity of active node differs")
+		}
+
+		ht.Lock()
+		for _, n := range s.nodes[i] {
+			index := ht.BucketIndex(n.ID)
+			if len(ht.RoutingTable[index]) < routing.MaxContactsInBucket {
+				ht.RoutingTable[index] = append(ht.RoutingTable[index], routing.NewRouteNode(n))
+			}
+		}
+		ht.Unlock()
+
+		ctx, err := cb.SetNodeByID(ht.Origin.ID).Build()
+		if err != nil {
+			return err
+		}
+		for _, entry := range s.entries[i] {
+			_, err = dht.storeVersion(ctx, entry.Key, entry.Data, entry.Replication, entry.Expiration, false, entry.Version)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Standby) handleMessages() {
+	for {
+		select {
+		case msg := <-s.transport.Messages():
+			if msg != nil {
+				s.handleMessage(msg)
+			}
+		case <-s.transport.Stopped():
+			s.transport.Close()
+			return
+		}
+	}
+}
+
+func (s *Standby) handleMessage(msg *message.Message) {
+	data, ok := msg.Data.(*message.RequestDataRPC)
+	if msg.Type != message.TypeRPC || !ok || data.Method != standbySyncMethod || len(data.Args) != 1 {
+		return
+	}
+
+	response := &message.ResponseDataRPC{Success: true}
+	err := s.apply(data.Args[0])
+	if err != nil {
+		response.Success = false
+		response.Error = err.Error()
+	}
+
+	err = s.transport.SendResponse(msg.RequestID,
+		message.NewBuilder().Sender(msg.Receiver).Receiver(msg.Sender).Type(message.TypeRPC).Response(response).Build())
+	if err != nil {
+		log.Println("Failed to send response:", err.Error())
+	}
+}
+
+// apply applies state delta, full state replaces the one standby has
+func (s *Standby) apply(data []byte) error {
+	delta := &standbyDelta{}
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(delta)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if delta.Base == 0 {
+		s.ids, s.nodes, s.entries = nil, nil, nil
+		for _, table := range delta.Tables {
+			s.ids = append(s.ids, table.ID)
+			s.nodes = append(s.nodes, make(map[string]*node.Node))
+			s.entries = append(s.entries, make(map[string]store.Entry))
+		}
+	} else if delta.Base != s.seq || len(delta.Tables) != len(s.ids) {
+		return errStandbyOutOfSync
+	}
+
+	for i, table := range delta.Tables {
+		if !table.ID.Equal(s.ids[i]) {
+			return errStandbyOutOfSync
+		}
+		for _, n := range table.Added {
+			s.nodes[i][string(n.ID)] = n
+		}
+		for _, id := range table.Removed {
+			delete(s.nodes[i], string(id))
+		}
+		for _, entry := range table.Stored {
+			s.entries[i][string(entry.Key)] = entry
+		}
+		for _, key := range table.Deleted {
+			delete(s.entries[i], string(key))
+		}
+	}
+
+	s.seq = delta.Seq
+	s.lastSync = time.Now()
+	return nil
+}
@@ -0,0 +1,224 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/clock"
+	"github.com/insolar/network/connection"
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/insolar/network/rpc"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeStandbyDelta(t *testing.T, delta *standbyDelta) []byte {
+	var data bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&data).Encode(delta))
+	return data.Bytes()
+}
+
+func TestStandby_Apply(t *testing.T) {
+	st, s, tp, r, err := dhtParams([]node.ID{getIDWithValues(0)}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	dht, _ := NewDHT(st, s, tp, r, &Options{})
+	ctx := getDefaultCtx(dht)
+
+	addr, _ := node.NewAddress("127.0.0.1:3001")
+	peer := &node.Node{ID: getZerodIDWithNthByte(19, 4), Address: addr}
+	ht := dht.tables[0]
+	index := routing.GetBucketIndexFromDifferingBit(ht.Origin.ID, peer.ID)
+	ht.RoutingTable[index] = []*routing.RouteNode{routing.NewRouteNode(peer)}
+	key := store.NewKey([]byte("foo"))
+	err = st.Store(ctx, key, []byte("foo"), time.Now(), time.Now().Add(time.Hour), true)
+	assert.NoError(t, err)
+
+	standby := NewStandby(newMockTransport())
+	delta, baseline := dht.standbyDelta(&standbyBaseline{})
+	assert.Equal(t, uint64(0), delta.Base)
+	assert.NoError(t, standby.apply(encodeStandbyDelta(t, delta)))
+	assert.Equal(t, []node.ID{getIDWithValues(0)}, standby.IDs())
+	assert.Len(t, standby.nodes[0], 1)
+	assert.Equal(t, []byte("foo"), standby.entries[0][string(key)].Data)
+
+	// Only changes are sent
+	ht.RoutingTable[index] = nil
+	delta, baseline = dht.standbyDelta(baseline)
+	assert.Equal(t, []node.ID{peer.ID}, delta.Tables[0].Removed)
+	assert.Empty(t, delta.Tables[0].Added)
+	assert.Empty(t, delta.Tables[0].Stored)
+	assert.NoError(t, standby.apply(encodeStandbyDelta(t, delta)))
+	assert.Empty(t, standby.nodes[0])
+	assert.Len(t, standby.entries[0], 1)
+
+	// Delta not following the last one is rejected
+	delta, _ = dht.standbyDelta(&standbyBaseline{seq: baseline.seq + 1})
+	assert.Equal(t, errStandbyOutOfSync, standby.apply(encodeStandbyDelta(t, delta)))
+}
+
+func TestStandby_Restore(t *testing.T) {
+	st, s, tp, r, err := dhtParams([]node.ID{getIDWithValues(0)}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	dht, _ := NewDHT(st, s, tp, r, &Options{})
+
+	standby := NewStandby(newMockTransport())
+	assert.EqualError(t, standby.Restore(dht), "standby is not synced")
+
+	st, s, tp, r, err = dhtParams([]node.ID{getIDWithValues(1)}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	other, _ := NewDHT(st, s, tp, r, &Options{})
+	delta, _ := other.standbyDelta(&standbyBaseline{})
+	assert.NoError(t, standby.apply(encodeStandbyDelta(t, delta)))
+	assert.EqualError(t, standby.Restore(dht), "identity of active node differs")
+}
+
+func TestConfiguration_TakeOver(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, nil, "127.0.0.1:8126")
+	assert.NoError(t, err)
+	active, _ := NewDHT(st1, s1, tp1, r1, &Options{
+		StandbyAddress:      "127.0.0.1:8127",
+		StandbySyncInterval: time.Millisecond * 20,
+	})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	peer, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: s1.IDs[0], Address: s1.Address}},
+	})
+
+	cfg := NewNetworkConfiguration(
+		&mockResolverOk{},
+		connection.NewConnectionFactory(),
+		transport.NewInMemoryTransportFactory(network),
+		store.NewMemoryStoreFactory(),
+		rpc.NewRPCFactory(map[string]rpc.RemoteProcedure{}),
+	)
+	_, err = cfg.TakeOver("127.0.0.1:8126", &Options{})
+	assert.EqualError(t, err, "standby is not created")
+	standby, err := cfg.CreateStandby("127.0.0.1:8127")
+	assert.NoError(t, err)
+	go func() {
+		standby.Listen()
+		done <- true
+	}()
+
+	for _, dht := range []*DHT{active, peer} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+	assert.NoError(t, peer.Bootstrap())
+
+	_, err = active.Store(getDefaultCtx(active), []byte("foo"))
+	assert.NoError(t, err)
+
+	// Wait for standby to get the latest state
+	synced := time.Now()
+	for standby.LastSync().Before(synced.Add(time.Millisecond * 50)) {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	active.Disconnect()
+	<-done
+
+	dht, err := cfg.TakeOver("127.0.0.1:8126", &Options{})
+	assert.NoError(t, err)
+	<-done
+	go func() {
+		dht.Listen()
+		done <- true
+	}()
+
+	ctx := getDefaultCtx(dht)
+	assert.Equal(t, active.GetOriginID(getDefaultCtx(active)), dht.GetOriginID(ctx))
+	assert.Equal(t, 1, dht.NumNodes(ctx))
+	value, exists := dht.retrieve(ctx, store.NewKey([]byte("foo")))
+	assert.True(t, exists)
+	assert.Equal(t, []byte("foo"), value)
+
+	// Peers reach the new node at address of the failed one
+	target, exists, err := peer.FindNode(getDefaultCtx(peer), dht.GetOriginID(ctx))
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "127.0.0.1:8126", target.Address.String())
+
+	assert.NoError(t, cfg.CloseNetwork())
+	<-done
+	peer.Disconnect()
+	<-done
+}
+
+func TestDHT_SendStandbyDelta(t *testing.T) {
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+	virtual := clock.NewVirtual(time.Now())
+
+	st, s, tp, r, err := inMemoryDhtParams(network, nil, "127.0.0.1:8126")
+	assert.NoError(t, err)
+	active, _ := NewDHT(st, s, tp, r, &Options{StandbyAddress: "127.0.0.1:8127", Clock: virtual})
+	standby, err := transport.NewInMemoryTransport(network, "127.0.0.1:8127")
+	assert.NoError(t, err)
+	done := make(chan bool)
+	for _, tp := range []transport.Transport{tp, standby} {
+		go func(tp transport.Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+	}
+
+	// Malformed reply is reported as error
+	result := make(chan error)
+	go func() {
+		result <- active.sendStandbyDelta(&standbyDelta{})
+	}()
+	request := <-standby.Messages()
+	response := message.NewBuilder().Sender(request.Receiver).Receiver(request.Sender).Type(message.TypeRPC).
+		Response(&message.ResponseDataPing{}).Build()
+	assert.NoError(t, standby.SendResponse(request.RequestID, response))
+	assert.EqualError(t, <-result, "unexpected response")
+
+	// Unanswered request times out with clock of DHT
+	go func() {
+		result <- active.sendStandbyDelta(&standbyDelta{})
+	}()
+	<-standby.Messages()
+	for virtual.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	virtual.Advance(active.options.MessageTimeout)
+	assert.Equal(t, transport.ErrFutureTimeout, <-result)
+
+	for _, tp := range []transport.Transport{tp, standby} {
+		go func(tp transport.Transport) {
+			<-tp.Stopped()
+			tp.Close()
+		}(tp)
+		tp.Stop()
+		<-done
+	}
+}
@@ -0,0 +1,35 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"crypto/sha256"
+	"errors"
+)
+
+// AuditHash returns hash of the value slice salted with nonce.
+// Holder can compute it only if it actually retains the value.
+func AuditHash(value []byte, offset, length int, nonce []byte) ([]byte, error) {
+	if offset < 0 || length < 0 || offset+length > len(value) {
+		return nil, errors.New("audit range out of bounds")
+	}
+
+	hash := sha256.New()
+	hash.Write(nonce)
+	hash.Write(value[offset : offset+length])
+	return hash.Sum(nil), nil
+}
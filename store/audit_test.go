@@ -0,0 +1,54 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditHash(t *testing.T) {
+	value := []byte("some stored value")
+	nonce := []byte("nonce")
+
+	hash, err := AuditHash(value, 5, 6, nonce)
+	assert.NoError(t, err)
+	assert.Len(t, hash, 32)
+
+	same, _ := AuditHash([]byte("xxxxxstoredxxxxx"), 5, 6, nonce)
+	assert.Equal(t, hash, same)
+
+	otherNonce, _ := AuditHash(value, 5, 6, []byte("other"))
+	assert.NotEqual(t, hash, otherNonce)
+
+	otherSlice, _ := AuditHash(value, 4, 6, nonce)
+	assert.NotEqual(t, hash, otherSlice)
+}
+
+func TestAuditHash_OutOfBounds(t *testing.T) {
+	value := []byte("value")
+
+	_, err := AuditHash(value, 3, 3, nil)
+	assert.EqualError(t, err, "audit range out of bounds")
+
+	_, err = AuditHash(value, -1, 1, nil)
+	assert.Error(t, err)
+
+	_, err = AuditHash(value, 0, len(value), nil)
+	assert.NoError(t, err)
+}
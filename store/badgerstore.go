@@ -0,0 +1,446 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgraph-io/badger"
+	"github.com/insolar/network/clock"
+)
+
+// Key layout of Badger store. Metadata records are small and stay in LSM tree,
+// so replication and expiration scans don't read values, which go to value log.
+const (
+	prefixMeta = 'm'
+	prefixData = 'd'
+)
+
+// badgerValueThreshold is a size of values kept in LSM tree, larger ones are separated to value log
+const badgerValueThreshold = 1024
+
+// badgerGCDiscardRatio is a share of stale data in value log file which makes it rewritten
+const badgerGCDiscardRatio = 0.5
+
+// badgerStore is a key/value store persisted in Badger, suited for nodes holding large values.
+// Reads, including replication scans, run in their own transactions concurrently with writes.
+type badgerStore struct {
+	db          *badger.DB
+	clock       clock.Clock
+	expirations *expirationMeter
+}
+
+// NewBadgerStore opens Badger database in directory at path, creating it if needed, and returns store kept in it
+func NewBadgerStore(path string) (Store, error) {
+	db, err := openBadger(path)
+	if err != nil {
+		return nil, err
+	}
+	return newBadgerStore(db), nil
+}
+
+func openBadger(path string) (*badger.DB, error) {
+	options := badger.DefaultOptions(path)
+	options.ValueThreshold = badgerValueThreshold
+	return badger.Open(options)
+}
+
+func newBadgerStore(db *badger.DB) *badgerStore {
+	return &badgerStore{
+		db:          db,
+		clock:       clock.New(),
+		expirations: newExpirationMeter(),
+	}
+}
+
+func metaKey(key Key) []byte {
+	return append([]byte{prefixMeta}, key...)
+}
+
+func dataKey(key Key) []byte {
+	return append([]byte{prefixData}, key...)
+}
+
+// update runs fn in read-write transaction, retrying it if it conflicts with concurrent one
+func (bs *badgerStore) update(fn func(txn *badger.Txn) error) error {
+	for {
+		err := bs.db.Update(fn)
+		if err != badger.ErrConflict {
+			return err
+		}
+	}
+}
+
+// meta returns metadata of key, nil if there is none
+func (bs *badgerStore) meta(txn *badger.Txn, key Key) (*storedRecord, error) {
+	item, err := txn.Get(metaKey(key))
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record *storedRecord
+	err = item.Value(func(raw []byte) error {
+		record, err = decodeRecord(raw)
+		return err
+	})
+	return record, err
+}
+
+func (bs *badgerStore) put(txn *badger.Txn, key Key, data []byte, record *storedRecord) error {
+	raw, err := encodeRecord(record)
+	if err != nil {
+		return err
+	}
+	if err := txn.Set(metaKey(key), raw); err != nil {
+		return err
+	}
+	return txn.Set(dataKey(key), data)
+}
+
+// Store will store a key/value pair for the local node with the given
+// replication and expiration times.
+func (bs *badgerStore) Store(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, publisher bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return bs.update(func(txn *badger.Txn) error {
+		return bs.put(txn, key, data, &storedRecord{
+			Replication: replication,
+			Expiration:  expiration,
+		})
+	})
+}
+
+// StoreVersion stores key/value pair unless newer version of it is stored already
+func (bs *badgerStore) StoreVersion(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, version Version) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	stored := false
+	err := bs.update(func(txn *badger.Txn) error {
+		stored = false
+		record, err := bs.meta(txn, key)
+		if err != nil && err != ErrCorrupted {
+			return err
+		}
+		if record != nil && record.Version.Newer(version) {
+			return nil
+		}
+		stored = true
+		return bs.put(txn, key, data, &storedRecord{
+			Replication: replication,
+			Expiration:  expiration,
+			Version:     version,
+		})
+	})
+	return stored && err == nil, err
+}
+
+// Version returns version of stored value
+func (bs *badgerStore) Version(ctx context.Context, key Key) (Version, bool, error) {
+	if ctx.Err() != nil {
+		return Version{}, false, ctx.Err()
+	}
+
+	var record *storedRecord
+	err := bs.db.View(func(txn *badger.Txn) error {
+		var err error
+		record, err = bs.meta(txn, key)
+		return err
+	})
+	if err != nil || record == nil {
+		return Version{}, false, err
+	}
+	return record.Version, true, nil
+}
+
+// Retrieve will return the local key/value if it exists
+func (bs *badgerStore) Retrieve(ctx context.Context, key Key) ([]byte, bool, error) {
+	if ctx.Err() != nil {
+		return nil, false, ctx.Err()
+	}
+
+	var data []byte
+	err := bs.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(dataKey(key))
+		if err != nil {
+			return err
+		}
+		data, err = item.ValueCopy(nil)
+		return err
+	})
+	if err == badger.ErrKeyNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// StoreBatch stores entries with their replication and expiration times and versions,
+// up to maxBatchSize entries are written in one transaction
+func (bs *badgerStore) StoreBatch(ctx context.Context, entries []Entry, publisher bool) error {
+	for len(entries) > 0 {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		batch := entries
+		if len(batch) > maxBatchSize {
+			batch = batch[:maxBatchSize]
+		}
+		entries = entries[len(batch):]
+
+		err := bs.update(func(txn *badger.Txn) error {
+			for _, entry := range batch {
+				record := recordOf(entry)
+				record.Data = nil
+				if err := bs.put(txn, entry.Key, entry.Data, record); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RetrieveBatch returns entries of keys which exist in the badgerStore, records which can't be read are skipped
+func (bs *badgerStore) RetrieveBatch(ctx context.Context, keys []Key) ([]Entry, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	entries := make([]Entry, 0, len(keys))
+	err := bs.db.View(func(txn *badger.Txn) error {
+		for _, key := range keys {
+			record, err := bs.meta(txn, key)
+			if err != nil && err != ErrCorrupted {
+				return err
+			}
+			if record == nil {
+				continue
+			}
+			item, err := txn.Get(dataKey(key))
+			if err == badger.ErrKeyNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if record.Data, err = item.ValueCopy(nil); err != nil {
+				return err
+			}
+			entries = append(entries, entryOf(key, record))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Delete deletes a key/value pair from the badgerStore
+func (bs *badgerStore) Delete(ctx context.Context, key Key) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return bs.update(func(txn *badger.Txn) error {
+		if err := txn.Delete(metaKey(key)); err != nil {
+			return err
+		}
+		return txn.Delete(dataKey(key))
+	})
+}
+
+// scan calls fn with key and metadata of every stored value, records which can't be read are passed as nil
+func (bs *badgerStore) scan(fn func(key Key, record *storedRecord)) error {
+	return bs.db.View(func(txn *badger.Txn) error {
+		prefix := []byte{prefixMeta}
+		iter := txn.NewIterator(badger.IteratorOptions{
+			PrefetchValues: true,
+			PrefetchSize:   100,
+			Prefix:         prefix,
+		})
+		defer iter.Close()
+
+		for iter.Seek(prefix); iter.ValidForPrefix(prefix); iter.Next() {
+			item := iter.Item()
+			var record *storedRecord
+			err := item.Value(func(raw []byte) error {
+				record, _ = decodeRecord(raw)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			fn(Key(item.KeyCopy(nil)[1:]), record)
+		}
+		return nil
+	})
+}
+
+// GetKeysReadyToReplicate should return the keys of all data to be
+// replicated across the network. Typically all data should be
+// replicated every tReplicate seconds.
+func (bs *badgerStore) GetKeysReadyToReplicate(ctx context.Context) ([]Key, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	now := bs.clock.Now()
+	var keys []Key
+	err := bs.scan(func(key Key, record *storedRecord) {
+		if record != nil && now.After(record.Replication) {
+			keys = append(keys, key)
+		}
+	})
+	return keys, err
+}
+
+// ExpireKeys should expire all key/values due for expiration.
+// Value log is garbage collected once keys are expired.
+func (bs *badgerStore) ExpireKeys(ctx context.Context) error {
+	_, err := bs.ExpireKeysBatch(ctx, 0)
+	return err
+}
+
+// ExpireKeysBatch expires at most max key/values, all of them if max is not positive.
+// Value log is garbage collected once no more keys are due.
+func (bs *badgerStore) ExpireKeysBatch(ctx context.Context, max int) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	now := bs.clock.Now()
+	var expired []Key
+	err := bs.scan(func(key Key, record *storedRecord) {
+		if record == nil || now.After(record.Expiration) {
+			expired = append(expired, key)
+		}
+	})
+	if err != nil || len(expired) == 0 {
+		return false, err
+	}
+	more := max > 0 && len(expired) > max
+	if more {
+		expired = expired[:max]
+	}
+
+	for len(expired) > 0 {
+		batch := expired
+		if len(batch) > maxBatchSize {
+			batch = batch[:maxBatchSize]
+		}
+		expired = expired[len(batch):]
+
+		deleted := 0
+		err := bs.update(func(txn *badger.Txn) error {
+			deleted = 0
+			for _, key := range batch {
+				// Value could be stored again after scan
+				record, err := bs.meta(txn, key)
+				if err == nil && record != nil && !now.After(record.Expiration) {
+					continue
+				}
+				if err := txn.Delete(metaKey(key)); err != nil {
+					return err
+				}
+				if err := txn.Delete(dataKey(key)); err != nil {
+					return err
+				}
+				if record != nil {
+					deleted++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return false, err
+		}
+		bs.expirations.expired(now, deleted)
+	}
+	if more {
+		return true, nil
+	}
+
+	// Each successful run rewrites one value log file, repeat until there is nothing to collect
+	for bs.db.RunValueLogGC(badgerGCDiscardRatio) == nil {
+	}
+	return false, nil
+}
+
+// Stats returns number of stored keys, total size of values, expirations and keys pending replication
+func (bs *badgerStore) Stats() Stats {
+	stats := Stats{
+		Expirations:        bs.expirations.count(bs.clock.Now()),
+		PendingReplication: pendingReplication(bs),
+	}
+	bs.db.View(func(txn *badger.Txn) error {
+		prefix := []byte{prefixData}
+		iter := txn.NewIterator(badger.IteratorOptions{Prefix: prefix})
+		defer iter.Close()
+
+		for iter.Seek(prefix); iter.ValidForPrefix(prefix); iter.Next() {
+			stats.Keys++
+			stats.Bytes += int(iter.Item().ValueSize())
+		}
+		return nil
+	})
+	return stats
+}
+
+// Entries returns all stored key/value pairs
+func (bs *badgerStore) Entries() []Entry {
+	var entries []Entry
+	err := bs.scan(func(key Key, record *storedRecord) {
+		if record != nil {
+			entries = append(entries, Entry{
+				Key:         key,
+				Replication: record.Replication,
+				Expiration:  record.Expiration,
+				Version:     record.Version,
+			})
+		}
+	})
+	if err != nil {
+		return nil
+	}
+
+	// Values are read after scan so value log isn't touched while iterating metadata
+	result := entries[:0]
+	for _, entry := range entries {
+		data, found, err := bs.Retrieve(context.Background(), entry.Key)
+		if err == nil && found {
+			entry.Data = data
+			result = append(result, entry)
+		}
+	}
+	return result
+}
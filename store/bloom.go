@@ -0,0 +1,314 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"hash/fnv"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// bloomFilter is a set of keys which may report absent key as present but never misses added one
+type bloomFilter struct {
+	bits     []uint64
+	hashes   int
+	added    int
+	capacity int
+}
+
+// newBloomFilter creates filter sized to keep false positive rate for capacity keys
+func newBloomFilter(capacity int, falsePositiveRate float64) *bloomFilter {
+	if capacity < 1 {
+		capacity = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+	size := int(math.Ceil(-float64(capacity) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	hashes := int(math.Round(float64(size) / float64(capacity) * math.Ln2))
+	if hashes < 1 {
+		hashes = 1
+	}
+	return &bloomFilter{
+		bits:     make([]uint64, (size+63)/64),
+		hashes:   hashes,
+		capacity: capacity,
+	}
+}
+
+// positions returns bits of key, they are derived from two halves of its hash
+func (bf *bloomFilter) positions(key Key) []uint64 {
+	h := fnv.New64a()
+	h.Write(key)
+	sum := h.Sum64()
+	first, second := sum&math.MaxUint32, sum>>32
+	size := uint64(len(bf.bits) * 64)
+
+	positions := make([]uint64, bf.hashes)
+	for i := range positions {
+		positions[i] = (first + uint64(i)*second) % size
+	}
+	return positions
+}
+
+func (bf *bloomFilter) add(key Key) {
+	for _, position := range bf.positions(key) {
+		bf.bits[position/64] |= 1 << (position % 64)
+	}
+	bf.added++
+}
+
+func (bf *bloomFilter) contains(key Key) bool {
+	for _, position := range bf.positions(key) {
+		if bf.bits[position/64]&(1<<(position%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// saturated checks if more keys were added than filter was sized for
+func (bf *bloomFilter) saturated() bool {
+	return bf.added > bf.capacity
+}
+
+// bloomStore wraps store answering lookups of absent keys from in-memory Bloom filter
+type bloomStore struct {
+	store             Store
+	enumerator        Enumerator
+	falsePositiveRate float64
+	mutex             *sync.Mutex
+	filter            *bloomFilter
+}
+
+// notifyingBloomStore is bloomStore around store which notifies about keys ready to replicate
+type notifyingBloomStore struct {
+	*bloomStore
+	notifier ReplicationNotifier
+}
+
+// NotifyReplication returns channel receiving keys as their replication times pass
+func (bs *notifyingBloomStore) NotifyReplication(ctx context.Context) <-chan []Key {
+	return bs.notifier.NotifyReplication(ctx)
+}
+
+// NewBloomStore wraps store to keep Bloom filter of its keys in memory, so Retrieve of absent key,
+// e.g. by FindValue request for value node doesn't hold, is answered without reading store.
+// Filter is sized for capacity keys with given false positive rate. Deleted and expired keys stay
+// in filter until it is rebuilt from keys of store, which happens on expiration once more keys were
+// added than filter was sized for. Store must implement Enumerator, other stores are not filtered.
+func NewBloomStore(store Store, capacity int, falsePositiveRate float64) Store {
+	bs := &bloomStore{
+		store:             store,
+		falsePositiveRate: falsePositiveRate,
+		mutex:             &sync.Mutex{},
+	}
+	if enumerator, ok := store.(Enumerator); ok {
+		bs.enumerator = enumerator
+		bs.rebuild(capacity)
+	}
+	if notifier, ok := store.(ReplicationNotifier); ok {
+		return &notifyingBloomStore{bloomStore: bs, notifier: notifier}
+	}
+	return bs
+}
+
+// rebuild replaces filter with one holding keys of store, it is sized for at least twice as many keys
+// as store holds. Must be called under mutex.
+func (bs *bloomStore) rebuild(capacity int) {
+	entries := bs.enumerator.Entries()
+	if capacity < 2*len(entries) {
+		capacity = 2 * len(entries)
+	}
+	filter := newBloomFilter(capacity, bs.falsePositiveRate)
+	for _, entry := range entries {
+		filter.add(entry.Key)
+	}
+	bs.filter = filter
+}
+
+// mayHold checks if key can be kept in store
+func (bs *bloomStore) mayHold(key Key) bool {
+	if bs.filter == nil {
+		return true
+	}
+
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+
+	return bs.filter.contains(key)
+}
+
+// added adds keys to filter, must be called under mutex
+func (bs *bloomStore) added(keys ...Key) {
+	if bs.filter == nil {
+		return
+	}
+	for _, key := range keys {
+		bs.filter.add(key)
+	}
+}
+
+// Store will store a key/value pair for the local node with the given
+// replication and expiration times.
+func (bs *bloomStore) Store(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, publisher bool) error {
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+
+	// Key is added first, so it is never missed by concurrent lookup
+	bs.added(key)
+	return bs.store.Store(ctx, key, data, replication, expiration, publisher)
+}
+
+// StoreVersion stores key/value pair unless newer version of it is stored already.
+// Version is ignored if wrapped store doesn't keep versions.
+func (bs *bloomStore) StoreVersion(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, version Version) (bool, error) {
+	versioned, ok := bs.store.(Versioned)
+	if !ok {
+		return true, bs.Store(ctx, key, data, replication, expiration, true)
+	}
+
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+
+	bs.added(key)
+	return versioned.StoreVersion(ctx, key, data, replication, expiration, version)
+}
+
+// Version returns version of stored value
+func (bs *bloomStore) Version(ctx context.Context, key Key) (Version, bool, error) {
+	if !bs.mayHold(key) {
+		return Version{}, false, ctx.Err()
+	}
+	if versioned, ok := bs.store.(Versioned); ok {
+		return versioned.Version(ctx, key)
+	}
+	_, found, err := bs.store.Retrieve(ctx, key)
+	return Version{}, found, err
+}
+
+// Retrieve will return the local key/value if it exists, absent keys are mostly reported without reading store
+func (bs *bloomStore) Retrieve(ctx context.Context, key Key) ([]byte, bool, error) {
+	if !bs.mayHold(key) {
+		return nil, false, ctx.Err()
+	}
+	return bs.store.Retrieve(ctx, key)
+}
+
+// StoreBatch stores entries with their replication and expiration times and versions
+func (bs *bloomStore) StoreBatch(ctx context.Context, entries []Entry, publisher bool) error {
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+
+	for _, entry := range entries {
+		bs.added(entry.Key)
+	}
+	return bs.store.StoreBatch(ctx, entries, publisher)
+}
+
+// RetrieveBatch returns entries of keys which exist in wrapped store, only keys filter may hold are read
+func (bs *bloomStore) RetrieveBatch(ctx context.Context, keys []Key) ([]Entry, error) {
+	held := make([]Key, 0, len(keys))
+	for _, key := range keys {
+		if bs.mayHold(key) {
+			held = append(held, key)
+		}
+	}
+	if len(held) == 0 {
+		return nil, ctx.Err()
+	}
+	return bs.store.RetrieveBatch(ctx, held)
+}
+
+// Delete deletes a key/value pair from wrapped store, key stays in filter until it is rebuilt
+func (bs *bloomStore) Delete(ctx context.Context, key Key) error {
+	return bs.store.Delete(ctx, key)
+}
+
+// GetKeysReadyToReplicate should return the keys of all data to be
+// replicated across the network. Typically all data should be
+// replicated every tReplicate seconds.
+func (bs *bloomStore) GetKeysReadyToReplicate(ctx context.Context) ([]Key, error) {
+	return bs.store.GetKeysReadyToReplicate(ctx)
+}
+
+// ExpireKeys should expire all key/values due for expiration.
+// Saturated filter is rebuilt afterwards.
+func (bs *bloomStore) ExpireKeys(ctx context.Context) error {
+	if err := bs.store.ExpireKeys(ctx); err != nil {
+		return err
+	}
+	if bs.filter == nil {
+		return nil
+	}
+
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+
+	if bs.filter.saturated() {
+		bs.rebuild(bs.filter.capacity)
+	}
+	return nil
+}
+
+// Stats returns stats of wrapped store, zero Stats if it can't report them
+func (bs *bloomStore) Stats() Stats {
+	if reporter, ok := bs.store.(StatsReporter); ok {
+		return reporter.Stats()
+	}
+	return Stats{}
+}
+
+// Entries returns all stored key/value pairs, nil if wrapped store can't list them
+func (bs *bloomStore) Entries() []Entry {
+	if bs.enumerator == nil {
+		return nil
+	}
+	return bs.enumerator.Entries()
+}
+
+type bloomStoreFactory struct {
+	Factory
+	capacity          int
+	falsePositiveRate float64
+}
+
+// NewBloomStoreFactory creates factory of storages created by given factory and filtered by Bloom filter
+func NewBloomStoreFactory(factory Factory, capacity int, falsePositiveRate float64) Factory {
+	return &bloomStoreFactory{Factory: factory, capacity: capacity, falsePositiveRate: falsePositiveRate}
+}
+
+// Create returns new storage filtered by Bloom filter
+func (bloomStoreFactory *bloomStoreFactory) Create() Store {
+	return NewBloomStore(bloomStoreFactory.Factory.Create(), bloomStoreFactory.capacity, bloomStoreFactory.falsePositiveRate)
+}
+
+// Close closes wrapped factory if it holds resources
+func (bloomStoreFactory *bloomStoreFactory) Close() error {
+	if closer, ok := bloomStoreFactory.Factory.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// KeyHash returns hash keys of wrapped factory are derived with
+func (bloomStoreFactory *bloomStoreFactory) KeyHash() KeyHash {
+	return KeyHashOf(bloomStoreFactory.Factory)
+}
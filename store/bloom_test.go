@@ -0,0 +1,127 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingStore counts reads of wrapped memory store
+type countingStore struct {
+	plain
+	reads int
+}
+
+func (cs *countingStore) Retrieve(ctx context.Context, key Key) ([]byte, bool, error) {
+	cs.reads++
+	return cs.plain.Retrieve(ctx, key)
+}
+
+func (cs *countingStore) Entries() []Entry {
+	return cs.plain.(Enumerator).Entries()
+}
+
+func TestBloomFilter(t *testing.T) {
+	bf := newBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		bf.add(NewKey([]byte(fmt.Sprint(i))))
+	}
+	for i := 0; i < 1000; i++ {
+		assert.True(t, bf.contains(NewKey([]byte(fmt.Sprint(i)))))
+	}
+	assert.False(t, bf.saturated())
+
+	positives := 0
+	for i := 1000; i < 11000; i++ {
+		if bf.contains(NewKey([]byte(fmt.Sprint(i)))) {
+			positives++
+		}
+	}
+	assert.True(t, positives < 300, "false positives: %d", positives)
+}
+
+func TestBloomStore_Retrieve(t *testing.T) {
+	ctx := context.Background()
+	expiration := time.Now().Add(time.Hour)
+	memory := NewMemoryStore()
+	kept := []byte("kept")
+	assert.NoError(t, memory.Store(ctx, NewKey(kept), kept, expiration, expiration, true))
+
+	backend := &countingStore{plain: memory}
+	s := NewBloomStore(backend, 100, 0.01)
+
+	// Keys held before wrapping are known to filter
+	data, found, err := s.Retrieve(ctx, NewKey(kept))
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, kept, data)
+	assert.Equal(t, 1, backend.reads)
+
+	stored := []byte("stored")
+	assert.NoError(t, s.Store(ctx, NewKey(stored), stored, expiration, expiration, true))
+	_, found, _ = s.Retrieve(ctx, NewKey(stored))
+	assert.True(t, found)
+	assert.Equal(t, 2, backend.reads)
+
+	// Absent key is answered without reading store
+	_, found, err = s.Retrieve(ctx, NewKey([]byte("absent")))
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, 2, backend.reads)
+
+	entries, err := s.RetrieveBatch(ctx, []Key{NewKey(kept), NewKey([]byte("absent"))})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestBloomStore_Rebuild(t *testing.T) {
+	ctx := context.Background()
+	expiration := time.Now().Add(time.Hour)
+	s := NewBloomStore(NewMemoryStore(), 1, 0.01)
+	bs := s.(*notifyingBloomStore).bloomStore
+
+	first, second := []byte("first"), []byte("second")
+	assert.NoError(t, s.Store(ctx, NewKey(first), first, expiration, expiration, true))
+	assert.NoError(t, s.Store(ctx, NewKey(second), second, expiration, expiration, true))
+	assert.NoError(t, s.Delete(ctx, NewKey(first)))
+	assert.True(t, bs.filter.saturated())
+
+	// Saturated filter is rebuilt from keys store holds
+	assert.NoError(t, s.ExpireKeys(ctx))
+	assert.False(t, bs.filter.saturated())
+	assert.Equal(t, 1, bs.filter.added)
+	assert.True(t, bs.filter.contains(NewKey(second)))
+}
+
+func TestBloomStore_NotEnumerable(t *testing.T) {
+	ctx := context.Background()
+	expiration := time.Now().Add(time.Hour)
+	memory := NewMemoryStore()
+	data := []byte("data")
+	assert.NoError(t, memory.Store(ctx, NewKey(data), data, expiration, expiration, true))
+
+	// Keys of store which can't list them are unknown, so lookups are not filtered
+	s := NewBloomStore(&opaqueStore{plain: memory}, 100, 0.01)
+	_, found, err := s.Retrieve(ctx, NewKey(data))
+	assert.NoError(t, err)
+	assert.True(t, found)
+}
@@ -0,0 +1,370 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/clock"
+	bolt "go.etcd.io/bbolt"
+)
+
+var entriesBucket = []byte("entries")
+
+// boltStore is a key/value store persisted in BoltDB file, so data survives restarts of the node.
+// Replication times are indexed in memory to notify about keys ready to replicate without scanning the file.
+type boltStore struct {
+	db           *bolt.DB
+	mutex        *sync.RWMutex
+	replicateMap map[string]time.Time
+	clock        clock.Clock
+	notifier     replicationNotifier
+	expirations  *expirationMeter
+}
+
+// NewBoltStore opens BoltDB file at path, creating it if needed, and returns store kept in it
+func NewBoltStore(path string) (Store, error) {
+	db, err := openBolt(path)
+	if err != nil {
+		return nil, err
+	}
+	return newBoltStore(db), nil
+}
+
+func openBolt(path string) (*bolt.DB, error) {
+	db, err := bolt.Open(path, os.FileMode(0600), &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(entriesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func newBoltStore(db *bolt.DB) *boltStore {
+	bs := &boltStore{
+		db:           db,
+		mutex:        &sync.RWMutex{},
+		replicateMap: make(map[string]time.Time),
+		clock:        clock.New(),
+		expirations:  newExpirationMeter(),
+	}
+	for _, entry := range bs.Entries() {
+		bs.replicateMap[string(entry.Key)] = entry.Replication
+	}
+	return bs
+}
+
+// scheduled updates replication time of key in index, zero time removes key
+func (bs *boltStore) scheduled(key Key, replication time.Time) {
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+
+	if replication.IsZero() {
+		delete(bs.replicateMap, string(key))
+		return
+	}
+	bs.replicateMap[string(key)] = replication
+	bs.notifier.stored(replication)
+}
+
+func (bs *boltStore) put(bucket *bolt.Bucket, key Key, record *storedRecord) error {
+	raw, err := encodeRecord(record)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, raw)
+}
+
+func (bs *boltStore) get(bucket *bolt.Bucket, key Key) (*storedRecord, error) {
+	raw := bucket.Get(key)
+	if raw == nil {
+		return nil, nil
+	}
+	return decodeRecord(raw)
+}
+
+// Store will store a key/value pair for the local node with the given
+// replication and expiration times.
+func (bs *boltStore) Store(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, publisher bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	err := bs.db.Update(func(tx *bolt.Tx) error {
+		return bs.put(tx.Bucket(entriesBucket), key, &storedRecord{
+			Data:        data,
+			Replication: replication,
+			Expiration:  expiration,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	bs.scheduled(key, replication)
+	return nil
+}
+
+// StoreVersion stores key/value pair unless newer version of it is stored already
+func (bs *boltStore) StoreVersion(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, version Version) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	stored := false
+	err := bs.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(entriesBucket)
+		record, err := bs.get(bucket, key)
+		if err != nil && err != ErrCorrupted {
+			return err
+		}
+		if record != nil && record.Version.Newer(version) {
+			return nil
+		}
+		stored = true
+		return bs.put(bucket, key, &storedRecord{
+			Data:        data,
+			Replication: replication,
+			Expiration:  expiration,
+			Version:     version,
+		})
+	})
+	if stored && err == nil {
+		bs.scheduled(key, replication)
+	}
+	return stored, err
+}
+
+// Version returns version of stored value
+func (bs *boltStore) Version(ctx context.Context, key Key) (Version, bool, error) {
+	if ctx.Err() != nil {
+		return Version{}, false, ctx.Err()
+	}
+
+	var record *storedRecord
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		var err error
+		record, err = bs.get(tx.Bucket(entriesBucket), key)
+		return err
+	})
+	if err != nil || record == nil {
+		return Version{}, false, err
+	}
+	return record.Version, true, nil
+}
+
+// Retrieve will return the local key/value if it exists
+func (bs *boltStore) Retrieve(ctx context.Context, key Key) ([]byte, bool, error) {
+	if ctx.Err() != nil {
+		return nil, false, ctx.Err()
+	}
+
+	var record *storedRecord
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		var err error
+		record, err = bs.get(tx.Bucket(entriesBucket), key)
+		return err
+	})
+	if err != nil || record == nil {
+		return nil, false, err
+	}
+	return record.Data, true, nil
+}
+
+// StoreBatch stores entries with their replication and expiration times and versions in one transaction
+func (bs *boltStore) StoreBatch(ctx context.Context, entries []Entry, publisher bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	err := bs.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(entriesBucket)
+		for _, entry := range entries {
+			if err := bs.put(bucket, entry.Key, recordOf(entry)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		bs.scheduled(entry.Key, entry.Replication)
+	}
+	return nil
+}
+
+// RetrieveBatch returns entries of keys which exist in the boltStore, records which can't be read are skipped
+func (bs *boltStore) RetrieveBatch(ctx context.Context, keys []Key) ([]Entry, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	entries := make([]Entry, 0, len(keys))
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(entriesBucket)
+		for _, key := range keys {
+			record, err := bs.get(bucket, key)
+			if err == nil && record != nil {
+				entries = append(entries, entryOf(key, record))
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// Delete deletes a key/value pair from the boltStore
+func (bs *boltStore) Delete(ctx context.Context, key Key) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	err := bs.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(entriesBucket).Delete(key)
+	})
+	if err != nil {
+		return err
+	}
+	bs.scheduled(key, time.Time{})
+	return nil
+}
+
+// GetKeysReadyToReplicate should return the keys of all data to be
+// replicated across the network. Typically all data should be
+// replicated every tReplicate seconds.
+func (bs *boltStore) GetKeysReadyToReplicate(ctx context.Context) ([]Key, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var keys []Key
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(entriesBucket).ForEach(func(k, v []byte) error {
+			record, err := decodeRecord(v)
+			if err == nil && bs.clock.Now().After(record.Replication) {
+				keys = append(keys, append(Key{}, k...))
+			}
+			return nil
+		})
+	})
+	return keys, err
+}
+
+// ExpireKeys should expire all key/values due for expiration.
+func (bs *boltStore) ExpireKeys(ctx context.Context) error {
+	_, err := bs.ExpireKeysBatch(ctx, 0)
+	return err
+}
+
+// ExpireKeysBatch expires at most max key/values due for expiration in one transaction,
+// all of them if max is not positive
+func (bs *boltStore) ExpireKeysBatch(ctx context.Context, max int) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	var expired [][]byte
+	more := false
+	err := bs.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(entriesBucket)
+		cursor := bucket.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			record, err := decodeRecord(v)
+			if err != nil || bs.clock.Now().After(record.Expiration) {
+				if max > 0 && len(expired) == max {
+					more = true
+					break
+				}
+				expired = append(expired, append([]byte{}, k...))
+			}
+		}
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, k := range expired {
+		bs.scheduled(k, time.Time{})
+	}
+	bs.expirations.expired(bs.clock.Now(), len(expired))
+	return more, nil
+}
+
+// Stats returns number of stored keys, total size of values, expirations and keys pending replication
+func (bs *boltStore) Stats() Stats {
+	now := bs.clock.Now()
+	stats := Stats{Expirations: bs.expirations.count(now)}
+	for _, entry := range bs.Entries() {
+		stats.Keys++
+		stats.Bytes += len(entry.Data)
+		if now.After(entry.Replication) {
+			stats.PendingReplication++
+		}
+	}
+	return stats
+}
+
+// Entries returns all stored key/value pairs
+func (bs *boltStore) Entries() []Entry {
+	var entries []Entry
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(entriesBucket).ForEach(func(k, v []byte) error {
+			record, err := decodeRecord(v)
+			if err != nil {
+				return nil
+			}
+			entries = append(entries, Entry{
+				Key:         append(Key{}, k...),
+				Data:        record.Data,
+				Replication: record.Replication,
+				Expiration:  record.Expiration,
+				Version:     record.Version,
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil
+	}
+	return entries
+}
+
+// NotifyReplication returns channel receiving keys as their replication times pass
+func (bs *boltStore) NotifyReplication(ctx context.Context) <-chan []Key {
+	return bs.notifier.notify(ctx, bs.clock, func(w *replicationWaiter) ([]Key, <-chan struct{}, time.Time) {
+		bs.mutex.RLock()
+		defer bs.mutex.RUnlock()
+
+		return bs.notifier.due(w, bs.replicateMap, bs.clock.Now())
+	})
+}
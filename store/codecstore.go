@@ -0,0 +1,178 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// valueCodec transforms values before they are passed to wrapped store and after they are read from it
+type valueCodec interface {
+	encode(key Key, data []byte) ([]byte, error)
+	decode(key Key, encoded []byte) ([]byte, error)
+}
+
+// codecStore wraps store keeping values encoded with codec
+type codecStore struct {
+	store Store
+	codec valueCodec
+}
+
+// notifyingCodecStore is codecStore around store which notifies about keys ready to replicate
+type notifyingCodecStore struct {
+	*codecStore
+	notifier ReplicationNotifier
+}
+
+// NotifyReplication returns channel receiving keys as their replication times pass
+func (ns *notifyingCodecStore) NotifyReplication(ctx context.Context) <-chan []Key {
+	return ns.notifier.NotifyReplication(ctx)
+}
+
+func newCodecStore(store Store, codec valueCodec) Store {
+	cs := &codecStore{store: store, codec: codec}
+	if notifier, ok := store.(ReplicationNotifier); ok {
+		return &notifyingCodecStore{codecStore: cs, notifier: notifier}
+	}
+	return cs
+}
+
+// Store will store a key/value pair for the local node with the given
+// replication and expiration times.
+func (cs *codecStore) Store(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, publisher bool) error {
+	encoded, err := cs.codec.encode(key, data)
+	if err != nil {
+		return err
+	}
+	return cs.store.Store(ctx, key, encoded, replication, expiration, publisher)
+}
+
+// StoreVersion stores key/value pair unless newer version of it is stored already.
+// Version is ignored if wrapped store doesn't keep versions.
+func (cs *codecStore) StoreVersion(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, version Version) (bool, error) {
+	versioned, ok := cs.store.(Versioned)
+	if !ok {
+		return true, cs.Store(ctx, key, data, replication, expiration, true)
+	}
+
+	encoded, err := cs.codec.encode(key, data)
+	if err != nil {
+		return false, err
+	}
+	return versioned.StoreVersion(ctx, key, encoded, replication, expiration, version)
+}
+
+// Version returns version of stored value
+func (cs *codecStore) Version(ctx context.Context, key Key) (Version, bool, error) {
+	if versioned, ok := cs.store.(Versioned); ok {
+		return versioned.Version(ctx, key)
+	}
+	_, found, err := cs.store.Retrieve(ctx, key)
+	return Version{}, found, err
+}
+
+// Retrieve will return the local key/value if it exists
+func (cs *codecStore) Retrieve(ctx context.Context, key Key) ([]byte, bool, error) {
+	encoded, found, err := cs.store.Retrieve(ctx, key)
+	if err != nil || !found {
+		return nil, found, err
+	}
+	data, err := cs.codec.decode(key, encoded)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// StoreBatch stores entries with their values encoded
+func (cs *codecStore) StoreBatch(ctx context.Context, entries []Entry, publisher bool) error {
+	encoded := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		data, err := cs.codec.encode(entry.Key, entry.Data)
+		if err != nil {
+			return err
+		}
+		entry.Data = data
+		encoded = append(encoded, entry)
+	}
+	return cs.store.StoreBatch(ctx, encoded, publisher)
+}
+
+// RetrieveBatch returns entries of keys which exist in wrapped store, values which can't be decoded are skipped
+func (cs *codecStore) RetrieveBatch(ctx context.Context, keys []Key) ([]Entry, error) {
+	entries, err := cs.store.RetrieveBatch(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	return cs.decodeEntries(entries), nil
+}
+
+// decodeEntries decodes values of entries, entries which can't be decoded are skipped
+func (cs *codecStore) decodeEntries(entries []Entry) []Entry {
+	decoded := entries[:0]
+	for _, entry := range entries {
+		data, err := cs.codec.decode(entry.Key, entry.Data)
+		if err != nil {
+			continue
+		}
+		entry.Data = data
+		decoded = append(decoded, entry)
+	}
+	return decoded
+}
+
+// Delete deletes a key/value pair from wrapped store
+func (cs *codecStore) Delete(ctx context.Context, key Key) error {
+	return cs.store.Delete(ctx, key)
+}
+
+// GetKeysReadyToReplicate should return the keys of all data to be
+// replicated across the network. Typically all data should be
+// replicated every tReplicate seconds.
+func (cs *codecStore) GetKeysReadyToReplicate(ctx context.Context) ([]Key, error) {
+	return cs.store.GetKeysReadyToReplicate(ctx)
+}
+
+// ExpireKeys should expire all key/values due for expiration.
+func (cs *codecStore) ExpireKeys(ctx context.Context) error {
+	return cs.store.ExpireKeys(ctx)
+}
+
+// ExpireKeysBatch expires key/values in batches if wrapped store supports it, all of them at once otherwise
+func (cs *codecStore) ExpireKeysBatch(ctx context.Context, max int) (bool, error) {
+	if expirer, ok := cs.store.(BatchExpirer); ok {
+		return expirer.ExpireKeysBatch(ctx, max)
+	}
+	return false, cs.store.ExpireKeys(ctx)
+}
+
+// Stats returns number of stored keys and total size of encoded values, zero Stats if wrapped store can't report them
+func (cs *codecStore) Stats() Stats {
+	if reporter, ok := cs.store.(StatsReporter); ok {
+		return reporter.Stats()
+	}
+	return Stats{}
+}
+
+// Entries returns all stored key/value pairs with values decoded, nil if wrapped store can't list them
+func (cs *codecStore) Entries() []Entry {
+	if enumerator, ok := cs.store.(Enumerator); ok {
+		return cs.decodeEntries(enumerator.Entries())
+	}
+	return nil
+}
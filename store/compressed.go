@@ -0,0 +1,117 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"io"
+
+	"github.com/golang/snappy"
+)
+
+// Header byte of values kept by compressed store
+const (
+	valueRaw = byte(iota)
+	valueSnappy
+)
+
+// snappyCodec compresses values of at least threshold bytes with snappy,
+// header byte tells if value is compressed
+type snappyCodec struct {
+	threshold int
+}
+
+// NewCompressedStore wraps store to keep values of at least threshold bytes compressed with snappy
+// if it makes them smaller. Every value is prefixed with header telling if it is compressed,
+// so store must not hold values written without the wrapper. To keep values both compressed
+// and encrypted, compressed store must wrap encrypted one.
+func NewCompressedStore(store Store, threshold int) Store {
+	return newCodecStore(store, &snappyCodec{threshold: threshold})
+}
+
+// CompressValue compresses data with snappy if it is at least threshold bytes long
+// and gets smaller. It returns data as is and false otherwise.
+func CompressValue(data []byte, threshold int) ([]byte, bool) {
+	if len(data) < threshold {
+		return data, false
+	}
+	compressed := snappy.Encode(nil, data)
+	if len(compressed) >= len(data) {
+		return data, false
+	}
+	return compressed, true
+}
+
+// DecompressValue decompresses data compressed with CompressValue, broken data is reported as ErrCorrupted
+func DecompressValue(compressed []byte) ([]byte, error) {
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, ErrCorrupted
+	}
+	return data, nil
+}
+
+func (c *snappyCodec) encode(key Key, data []byte) ([]byte, error) {
+	compressed, ok := CompressValue(data, c.threshold)
+	header := valueRaw
+	if ok {
+		header = valueSnappy
+	}
+	return append([]byte{header}, compressed...), nil
+}
+
+func (c *snappyCodec) decode(key Key, encoded []byte) ([]byte, error) {
+	if len(encoded) == 0 {
+		return nil, ErrCorrupted
+	}
+	switch encoded[0] {
+	case valueRaw:
+		return encoded[1:], nil
+	case valueSnappy:
+		return DecompressValue(encoded[1:])
+	default:
+		return nil, ErrCorrupted
+	}
+}
+
+type compressedStoreFactory struct {
+	Factory
+	threshold int
+}
+
+// NewCompressedStoreFactory creates factory of storages created by given factory
+// which keep values of at least threshold bytes compressed
+func NewCompressedStoreFactory(factory Factory, threshold int) Factory {
+	return &compressedStoreFactory{Factory: factory, threshold: threshold}
+}
+
+// Create returns new storage compressing values
+func (compressedStoreFactory *compressedStoreFactory) Create() Store {
+	return NewCompressedStore(compressedStoreFactory.Factory.Create(), compressedStoreFactory.threshold)
+}
+
+// Close closes wrapped factory if it holds resources
+func (compressedStoreFactory *compressedStoreFactory) Close() error {
+	if closer, ok := compressedStoreFactory.Factory.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// KeyHash returns hash keys of wrapped factory are derived with
+func (compressedStoreFactory *compressedStoreFactory) KeyHash() KeyHash {
+	return KeyHashOf(compressedStoreFactory.Factory)
+}
@@ -0,0 +1,73 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressedStore(t *testing.T) {
+	ms := newMemoryStore()
+	s := NewCompressedStore(ms, 16)
+	ctx := context.Background()
+	expiration := time.Now().Add(time.Hour)
+
+	small := []byte("small")
+	large := bytes.Repeat([]byte(`{"field":"value"}`), 64)
+	assert.NoError(t, s.Store(ctx, NewKey(small), small, expiration, expiration, true))
+	assert.NoError(t, s.StoreBatch(ctx, []Entry{
+		{Key: NewKey(large), Data: large, Replication: expiration, Expiration: expiration},
+	}, true))
+
+	// Large value is kept compressed, small one as is
+	raw, _, _ := ms.Retrieve(ctx, NewKey(large))
+	assert.Equal(t, valueSnappy, raw[0])
+	assert.True(t, len(raw) < len(large)/5)
+	raw, _, _ = ms.Retrieve(ctx, NewKey(small))
+	assert.Equal(t, append([]byte{valueRaw}, small...), raw)
+
+	for _, data := range [][]byte{small, large} {
+		retrieved, found, err := s.Retrieve(ctx, NewKey(data))
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, data, retrieved)
+	}
+	assert.Len(t, s.(Enumerator).Entries(), 2)
+	assert.Implements(t, (*ReplicationNotifier)(nil), s)
+
+	assert.NoError(t, ms.Store(ctx, NewKey(nil), []byte{valueSnappy, 0xff}, expiration, expiration, true))
+	_, _, err := s.Retrieve(ctx, NewKey(nil))
+	assert.Equal(t, ErrCorrupted, err)
+}
+
+func TestCompressValue(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 100)
+	compressed, ok := CompressValue(data, 10)
+	assert.True(t, ok)
+	decompressed, err := DecompressValue(compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, data, decompressed)
+
+	same, ok := CompressValue(data, 200)
+	assert.False(t, ok)
+	assert.Equal(t, data, same)
+}
@@ -0,0 +1,62 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"time"
+)
+
+// Deletion is publisher's signed request to delete value, holders accept it until expiration
+// if it is signed with the key value was published with
+type Deletion struct {
+	Key        Key
+	Expiration time.Time
+	PublicKey  ed25519.PublicKey
+	Signature  []byte
+}
+
+// NewDeletion creates deletion of key signed with publisher's private key
+func NewDeletion(key Key, expiration time.Time, privateKey ed25519.PrivateKey) *Deletion {
+	deletion := &Deletion{
+		Key:        key,
+		Expiration: expiration,
+		PublicKey:  privateKey.Public().(ed25519.PublicKey),
+	}
+	deletion.Signature = ed25519.Sign(privateKey, deletion.payload())
+	return deletion
+}
+
+// Verify checks deletion signature
+func (d *Deletion) Verify() bool {
+	if len(d.PublicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(d.PublicKey, d.payload(), d.Signature)
+}
+
+func (d *Deletion) payload() []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("delete")
+	writeChunk(&buffer, d.Key)
+	var expiration [8]byte
+	binary.BigEndian.PutUint64(expiration[:], uint64(d.Expiration.UnixNano()))
+	buffer.Write(expiration[:])
+	return buffer.Bytes()
+}
@@ -0,0 +1,42 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeletion_Verify(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	deletion := NewDeletion(NewKey([]byte("data")), time.Now().Add(time.Minute), privateKey)
+	assert.True(t, deletion.Verify())
+
+	forged := *deletion
+	forged.Key = NewKey([]byte("other"))
+	assert.False(t, forged.Verify())
+
+	forged = *deletion
+	forged.Expiration = deletion.Expiration.Add(time.Hour)
+	assert.False(t, forged.Verify())
+}
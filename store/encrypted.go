@@ -0,0 +1,100 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+)
+
+// aesCodec seals values with AES-GCM. Key of value is used as additional data,
+// so sealed values can't be swapped between keys.
+type aesCodec struct {
+	aead cipher.AEAD
+}
+
+// NewEncryptedStore wraps store to keep values encrypted with AES-GCM using node-local key,
+// which must be 16, 24 or 32 bytes long. Values which can't be decrypted are reported as ErrCorrupted.
+// Keys, replication and expiration times and versions are not encrypted.
+func NewEncryptedStore(store Store, key []byte) (Store, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return newCodecStore(store, &aesCodec{aead: aead}), nil
+}
+
+// encode seals data of key, random nonce is prepended to sealed data
+func (c *aesCodec) encode(key Key, data []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(data)+c.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, data, key), nil
+}
+
+// decode opens data of key sealed with encode
+func (c *aesCodec) decode(key Key, sealed []byte) ([]byte, error) {
+	if len(sealed) < c.aead.NonceSize() {
+		return nil, ErrCorrupted
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	data, err := c.aead.Open(nil, nonce, ciphertext, key)
+	if err != nil {
+		return nil, ErrCorrupted
+	}
+	return data, nil
+}
+
+type encryptedStoreFactory struct {
+	Factory
+	key []byte
+}
+
+// NewEncryptedStoreFactory creates factory of storages created by given factory and encrypted with key.
+// Key is checked at once, so Create doesn't fail.
+func NewEncryptedStoreFactory(factory Factory, key []byte) (Factory, error) {
+	if _, err := aes.NewCipher(key); err != nil {
+		return nil, err
+	}
+	return &encryptedStoreFactory{Factory: factory, key: key}, nil
+}
+
+// Create returns new storage encrypted with key
+func (encryptedStoreFactory *encryptedStoreFactory) Create() Store {
+	store, _ := NewEncryptedStore(encryptedStoreFactory.Factory.Create(), encryptedStoreFactory.key)
+	return store
+}
+
+// Close closes wrapped factory if it holds resources
+func (encryptedStoreFactory *encryptedStoreFactory) Close() error {
+	if closer, ok := encryptedStoreFactory.Factory.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// KeyHash returns hash keys of wrapped factory are derived with
+func (encryptedStoreFactory *encryptedStoreFactory) KeyHash() KeyHash {
+	return KeyHashOf(encryptedStoreFactory.Factory)
+}
@@ -0,0 +1,82 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptedStore_SealsValues(t *testing.T) {
+	ms := newMemoryStore()
+	s, err := NewEncryptedStore(ms, bytes.Repeat([]byte{1}, 32))
+	assert.NoError(t, err)
+	ctx := context.Background()
+	expiration := time.Now().Add(time.Hour)
+
+	data := []byte("some data")
+	key := NewKey(data)
+	assert.NoError(t, s.Store(ctx, key, data, expiration, expiration, true))
+
+	// Wrapped store doesn't see plain value
+	sealed, found, err := ms.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.False(t, bytes.Contains(sealed, data))
+
+	retrieved, found, err := s.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, data, retrieved)
+
+	entries, err := s.RetrieveBatch(ctx, []Key{key})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, data, entries[0].Data)
+	assert.Implements(t, (*ReplicationNotifier)(nil), s)
+
+	// Sealed value can't be moved to other key
+	other := NewKey([]byte("other"))
+	assert.NoError(t, ms.Store(ctx, other, sealed, expiration, expiration, true))
+	_, found, err = s.Retrieve(ctx, other)
+	assert.Equal(t, ErrCorrupted, err)
+	assert.False(t, found)
+}
+
+func TestEncryptedStore_WrongKey(t *testing.T) {
+	ms := newMemoryStore()
+	ctx := context.Background()
+	expiration := time.Now().Add(time.Hour)
+
+	s, err := NewEncryptedStore(ms, bytes.Repeat([]byte{1}, 16))
+	assert.NoError(t, err)
+	data := []byte("some data")
+	assert.NoError(t, s.Store(ctx, NewKey(data), data, expiration, expiration, true))
+
+	s, err = NewEncryptedStore(ms, bytes.Repeat([]byte{2}, 16))
+	assert.NoError(t, err)
+	_, _, err = s.Retrieve(ctx, NewKey(data))
+	assert.Equal(t, ErrCorrupted, err)
+	assert.Empty(t, s.(Enumerator).Entries())
+
+	_, err = NewEncryptedStore(ms, []byte("short key"))
+	assert.Error(t, err)
+}
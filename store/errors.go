@@ -0,0 +1,35 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"errors"
+)
+
+var (
+	// ErrFull is returned when store has no space left for the value
+	ErrFull = errors.New("store is full")
+
+	// ErrTooLarge is returned when value exceeds maximum size accepted by store
+	ErrTooLarge = errors.New("value is too large")
+
+	// ErrCorrupted is returned when stored value can't be read back intact
+	ErrCorrupted = errors.New("value is corrupted")
+
+	// ErrInvalidRecord is returned when value is rejected by Validator
+	ErrInvalidRecord = errors.New("record is invalid")
+)
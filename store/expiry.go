@@ -0,0 +1,60 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"time"
+)
+
+// ExpiryBounds are upper bounds of time left to expiration ExpiryStats count keys by, the last bucket is unbounded
+var ExpiryBounds = []time.Duration{
+	time.Minute,
+	time.Minute * 10,
+	time.Hour,
+	time.Hour * 6,
+	time.Hour * 24,
+}
+
+// ExpiryStats counts stored keys by time left to their expiration
+type ExpiryStats struct {
+	// Keys counts live keys, Keys[i] is number of keys expiring within ExpiryBounds[i]
+	// but after ExpiryBounds[i-1], the last one counts keys expiring later than all bounds
+	Keys []int
+
+	// Garbage is number of expired keys which were not collected yet, GarbageBytes is their total size
+	Garbage      int
+	GarbageBytes int
+}
+
+// CountExpiry counts entries by time left to their expiration at now
+func CountExpiry(entries []Entry, now time.Time) ExpiryStats {
+	stats := ExpiryStats{Keys: make([]int, len(ExpiryBounds)+1)}
+	for _, entry := range entries {
+		left := entry.Expiration.Sub(now)
+		if left < 0 {
+			stats.Garbage++
+			stats.GarbageBytes += len(entry.Data)
+			continue
+		}
+		i := 0
+		for i < len(ExpiryBounds) && left > ExpiryBounds[i] {
+			i++
+		}
+		stats.Keys[i]++
+	}
+	return stats
+}
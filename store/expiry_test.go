@@ -0,0 +1,39 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountExpiry(t *testing.T) {
+	now := time.Now()
+	entries := []Entry{
+		{Data: []byte("expired"), Expiration: now.Add(-time.Second)},
+		{Data: []byte("soon"), Expiration: now.Add(time.Second)},
+		{Data: []byte("hour"), Expiration: now.Add(time.Hour)},
+		{Data: []byte("later"), Expiration: now.Add(48 * time.Hour)},
+	}
+
+	stats := CountExpiry(entries, now)
+	assert.Equal(t, []int{1, 0, 1, 0, 0, 1}, stats.Keys)
+	assert.Equal(t, 1, stats.Garbage)
+	assert.Equal(t, len("expired"), stats.GarbageBytes)
+}
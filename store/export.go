@@ -0,0 +1,97 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"io"
+)
+
+// exportFormat identifies snapshots written by Export
+const exportFormat = "insolar-dht-store/1"
+
+// importBatch is number of entries Import writes to store at once
+const importBatch = 1000
+
+// ErrNotEnumerable is returned by Export for stores which can't list their entries, see Enumerator
+var ErrNotEnumerable = errors.New("store can't list its entries")
+
+// exportHeader starts every snapshot
+type exportHeader struct {
+	Format  string
+	Entries int
+}
+
+// Export writes portable snapshot of all entries of store with their replication and expiration times
+// and versions to w. Snapshot can be read back to any store with Import, so data can be migrated
+// between backends or used to seed new replicas. Store must implement Enumerator.
+func Export(store Store, w io.Writer) error {
+	enumerator, ok := store.(Enumerator)
+	if !ok {
+		return ErrNotEnumerable
+	}
+
+	entries := enumerator.Entries()
+	encoder := gob.NewEncoder(w)
+	err := encoder.Encode(&exportHeader{Format: exportFormat, Entries: len(entries)})
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		err = encoder.Encode(&entries[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Import reads snapshot written by Export from r and stores its entries in store as held for other nodes.
+// Entries are stored in batches, ones stored before an error are kept. It returns number of imported entries.
+func Import(ctx context.Context, store Store, r io.Reader) (int, error) {
+	decoder := gob.NewDecoder(r)
+	header := &exportHeader{}
+	err := decoder.Decode(header)
+	if err != nil {
+		return 0, err
+	}
+	if header.Format != exportFormat {
+		return 0, errors.New("unknown snapshot format")
+	}
+
+	imported := 0
+	batch := make([]Entry, 0, importBatch)
+	for i := 0; i < header.Entries; i++ {
+		entry := Entry{}
+		err = decoder.Decode(&entry)
+		if err != nil {
+			return imported, err
+		}
+		batch = append(batch, entry)
+		if len(batch) == importBatch || i == header.Entries-1 {
+			err = store.StoreBatch(ctx, batch, false)
+			if err != nil {
+				return imported, err
+			}
+			imported += len(batch)
+			batch = batch[:0]
+		}
+	}
+	return imported, nil
+}
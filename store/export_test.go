@@ -0,0 +1,85 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(time.Now().Unix(), 0)
+	publisher, _ := node.NewIDs(1)
+	entries := []Entry{
+		{Key: NewKey([]byte("first")), Data: []byte("first"), Replication: now.Add(time.Minute), Expiration: now.Add(time.Hour), Version: NewVersion(now, publisher[0])},
+		{Key: NewKey([]byte("second")), Data: []byte("second"), Replication: now.Add(time.Minute), Expiration: now.Add(time.Hour * 2)},
+	}
+	source := NewMemoryStore()
+	assert.NoError(t, source.StoreBatch(ctx, entries, true))
+
+	var snapshot bytes.Buffer
+	assert.NoError(t, Export(source, &snapshot))
+
+	// Data is migrated to other backend
+	directory, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+	factory, err := NewBoltStoreFactory(filepath.Join(directory, "store.db"))
+	assert.NoError(t, err)
+	defer factory.(*boltStoreFactory).Close()
+	target := factory.Create()
+
+	imported, err := Import(ctx, target, &snapshot)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, imported)
+
+	for _, entry := range entries {
+		restored, err := target.RetrieveBatch(ctx, []Key{entry.Key})
+		assert.NoError(t, err)
+		assert.Len(t, restored, 1)
+		assert.Equal(t, entry.Data, restored[0].Data)
+		assert.True(t, entry.Replication.Equal(restored[0].Replication))
+		assert.True(t, entry.Expiration.Equal(restored[0].Expiration))
+		assert.True(t, entry.Version.Timestamp.Equal(restored[0].Version.Timestamp))
+		assert.Equal(t, entry.Version.Publisher, restored[0].Version.Publisher)
+	}
+}
+
+// opaqueStore hides all methods of store except Store ones
+type opaqueStore struct {
+	plain
+}
+
+type plain interface {
+	Store
+}
+
+func TestExportImport_Errors(t *testing.T) {
+	assert.Equal(t, ErrNotEnumerable, Export(&opaqueStore{NewMemoryStore()}, &bytes.Buffer{}))
+
+	_, err := Import(context.Background(), NewMemoryStore(), bytes.NewReader([]byte("garbage")))
+	assert.Error(t, err)
+}
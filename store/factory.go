@@ -16,6 +16,16 @@
 
 package store
 
+import (
+	"database/sql"
+	"io"
+
+	"github.com/dgraph-io/badger"
+	"github.com/go-redis/redis"
+	"github.com/syndtr/goleveldb/leveldb"
+	bolt "go.etcd.io/bbolt"
+)
+
 // Factory allows to create new storages
 type Factory interface {
 	Create() Store
@@ -32,3 +42,149 @@ func NewMemoryStoreFactory() Factory {
 func (memoryStoreFactory *memoryStoreFactory) Create() Store {
 	return NewMemoryStore()
 }
+
+type hashedStoreFactory struct {
+	Factory
+	hash KeyHash
+}
+
+// NewHashedStoreFactory creates factory of storages created by given factory which keys are derived with hash,
+// see KeyHashOf. It allows to interoperate with networks using other digest than SHA-1.
+// Other factory wrappers forward KeyHash of factories they wrap, so it can be applied at any level.
+func NewHashedStoreFactory(factory Factory, hash KeyHash) Factory {
+	return &hashedStoreFactory{Factory: factory, hash: hash}
+}
+
+// KeyHash returns hash keys are derived with
+func (hashedStoreFactory *hashedStoreFactory) KeyHash() KeyHash {
+	return hashedStoreFactory.hash
+}
+
+// Close closes wrapped factory if it holds resources
+func (hashedStoreFactory *hashedStoreFactory) Close() error {
+	if closer, ok := hashedStoreFactory.Factory.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+type boltStoreFactory struct {
+	db *bolt.DB
+}
+
+// NewBoltStoreFactory opens BoltDB file at path and creates factory of storages kept in it.
+// Stores created by factory share the file, it should be closed with Close once network is closed.
+func NewBoltStoreFactory(path string) (Factory, error) {
+	db, err := openBolt(path)
+	if err != nil {
+		return nil, err
+	}
+	return &boltStoreFactory{db: db}, nil
+}
+
+// Create returns new storage persisted in BoltDB
+func (boltStoreFactory *boltStoreFactory) Create() Store {
+	return newBoltStore(boltStoreFactory.db)
+}
+
+// Close closes BoltDB file
+func (boltStoreFactory *boltStoreFactory) Close() error {
+	return boltStoreFactory.db.Close()
+}
+
+type levelDBStoreFactory struct {
+	db *leveldb.DB
+}
+
+// NewLevelDBStoreFactory opens LevelDB database in directory at path and creates factory of storages kept in it.
+// Stores created by factory share the database, it should be closed with Close once network is closed.
+func NewLevelDBStoreFactory(path string) (Factory, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &levelDBStoreFactory{db: db}, nil
+}
+
+// Create returns new storage persisted in LevelDB
+func (levelDBStoreFactory *levelDBStoreFactory) Create() Store {
+	return newLevelDBStore(levelDBStoreFactory.db)
+}
+
+// Close closes LevelDB database
+func (levelDBStoreFactory *levelDBStoreFactory) Close() error {
+	return levelDBStoreFactory.db.Close()
+}
+
+type badgerStoreFactory struct {
+	db *badger.DB
+}
+
+// NewBadgerStoreFactory opens Badger database in directory at path and creates factory of storages kept in it.
+// Stores created by factory share the database, it should be closed with Close once network is closed.
+func NewBadgerStoreFactory(path string) (Factory, error) {
+	db, err := openBadger(path)
+	if err != nil {
+		return nil, err
+	}
+	return &badgerStoreFactory{db: db}, nil
+}
+
+// Create returns new storage persisted in Badger
+func (badgerStoreFactory *badgerStoreFactory) Create() Store {
+	return newBadgerStore(badgerStoreFactory.db)
+}
+
+// Close closes Badger database
+func (badgerStoreFactory *badgerStoreFactory) Close() error {
+	return badgerStoreFactory.db.Close()
+}
+
+type redisStoreFactory struct {
+	client    *redis.Client
+	namespace string
+}
+
+// NewRedisStoreFactory connects to Redis server and creates factory of storages kept in it.
+// Stores created by factory share the connection, it should be closed with Close once network is closed.
+func NewRedisStoreFactory(options *RedisOptions) (Factory, error) {
+	client, err := openRedis(options)
+	if err != nil {
+		return nil, err
+	}
+	return &redisStoreFactory{client: client, namespace: options.Namespace}, nil
+}
+
+// Create returns new storage kept in Redis
+func (redisStoreFactory *redisStoreFactory) Create() Store {
+	return newRedisStore(redisStoreFactory.client, redisStoreFactory.namespace)
+}
+
+// Close closes connection to Redis server
+func (redisStoreFactory *redisStoreFactory) Close() error {
+	return redisStoreFactory.client.Close()
+}
+
+type sqliteStoreFactory struct {
+	db *sql.DB
+}
+
+// NewSQLiteStoreFactory opens SQLite database file at path and creates factory of storages kept in it.
+// Stores created by factory share the database, it should be closed with Close once network is closed.
+func NewSQLiteStoreFactory(path string) (Factory, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteStoreFactory{db: db}, nil
+}
+
+// Create returns new storage kept in SQLite
+func (sqliteStoreFactory *sqliteStoreFactory) Create() Store {
+	return newSQLiteStore(sqliteStoreFactory.db)
+}
+
+// Close closes SQLite database
+func (sqliteStoreFactory *sqliteStoreFactory) Close() error {
+	return sqliteStoreFactory.db.Close()
+}
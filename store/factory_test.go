@@ -17,8 +17,13 @@
 package store
 
 import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/alicebob/miniredis"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -34,3 +39,85 @@ func TestMemoryStoreFactory_Create(t *testing.T) {
 
 	assert.Implements(t, (*Store)(nil), store)
 }
+
+func TestBoltStoreFactory_Create(t *testing.T) {
+	directory, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	factory, err := NewBoltStoreFactory(filepath.Join(directory, "store.db"))
+	assert.NoError(t, err)
+	store := factory.Create()
+	assert.Implements(t, (*Store)(nil), store)
+	assert.Implements(t, (*Versioned)(nil), store)
+	assert.NoError(t, factory.(io.Closer).Close())
+}
+
+func TestLevelDBStoreFactory_Create(t *testing.T) {
+	directory, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	factory, err := NewLevelDBStoreFactory(directory)
+	assert.NoError(t, err)
+	store := factory.Create()
+	assert.Implements(t, (*Store)(nil), store)
+	assert.Implements(t, (*Versioned)(nil), store)
+	assert.NoError(t, factory.(io.Closer).Close())
+}
+
+func TestBadgerStoreFactory_Create(t *testing.T) {
+	directory, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	factory, err := NewBadgerStoreFactory(directory)
+	assert.NoError(t, err)
+	store := factory.Create()
+	assert.Implements(t, (*Store)(nil), store)
+	assert.Implements(t, (*Versioned)(nil), store)
+	assert.NoError(t, factory.(io.Closer).Close())
+}
+
+func TestRedisStoreFactory_Create(t *testing.T) {
+	server, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer server.Close()
+
+	factory, err := NewRedisStoreFactory(&RedisOptions{Address: server.Addr()})
+	assert.NoError(t, err)
+	store := factory.Create()
+	assert.Implements(t, (*Store)(nil), store)
+	assert.Implements(t, (*Versioned)(nil), store)
+	assert.NoError(t, factory.(io.Closer).Close())
+}
+
+func TestSQLiteStoreFactory_Create(t *testing.T) {
+	directory, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	factory, err := NewSQLiteStoreFactory(filepath.Join(directory, "store.sqlite"))
+	assert.NoError(t, err)
+	store := factory.Create()
+	assert.Implements(t, (*Store)(nil), store)
+	assert.Implements(t, (*Versioned)(nil), store)
+	assert.NoError(t, factory.(io.Closer).Close())
+}
+
+func TestHashedStoreFactory(t *testing.T) {
+	factory := NewMemoryStoreFactory()
+	assert.Equal(t, NewKey([]byte("data")), KeyHashOf(factory)([]byte("data")))
+
+	hashed := NewHashedStoreFactory(factory, SHA256)
+	assert.Implements(t, (*Store)(nil), hashed.Create())
+	assert.Equal(t, SHA256([]byte("data")), KeyHashOf(hashed)([]byte("data")))
+	assert.NoError(t, hashed.(io.Closer).Close())
+
+	// Wrappers keep hash of factory they wrap
+	wrapped := NewCompressedStoreFactory(NewBloomStoreFactory(NewNamespacedStoreFactory(hashed, nil), 100, 0.01), 10)
+	wrapped = NewQuotaStoreFactory(NewTieredStoreFactory(wrapped, Quota{}), Quota{})
+	wrapped, err := NewEncryptedStoreFactory(wrapped, make([]byte, 32))
+	assert.NoError(t, err)
+	assert.Equal(t, SHA256([]byte("data")), KeyHashOf(wrapped)([]byte("data")))
+}
@@ -18,8 +18,13 @@ package store
 
 import (
 	"crypto/sha1"
+	"crypto/sha256"
+	"hash"
 )
 
+// KeySize is size of key in bytes, it matches size of node IDs
+const KeySize = 20
+
 // Key is storage key. For now it is 20-byte slice
 type Key []byte
 
@@ -29,6 +34,40 @@ func NewKey(data []byte) Key {
 	return Key(sum[:])
 }
 
+// KeyHash derives key from data
+type KeyHash func(data []byte) Key
+
+// KeyHashes which can be used instead of NewKey
+var (
+	SHA1   KeyHash = NewKey
+	SHA256         = NewKeyHash(sha256.New)
+)
+
+// NewKeyHash creates KeyHash deriving keys from digests of hash, like NewKeyHash(blake2b.New256).
+// Digests longer than KeySize are truncated, shorter ones are padded with zeroes.
+func NewKeyHash(newHash func() hash.Hash) KeyHash {
+	return func(data []byte) Key {
+		h := newHash()
+		h.Write(data)
+		key := make(Key, KeySize)
+		copy(key, h.Sum(nil))
+		return key
+	}
+}
+
+// KeyHasher is implemented by store factories which stores expect keys derived with other hash than NewKey
+type KeyHasher interface {
+	KeyHash() KeyHash
+}
+
+// KeyHashOf returns KeyHash of stores created by factory, NewKey if factory doesn't set one
+func KeyHashOf(factory Factory) KeyHash {
+	if hasher, ok := factory.(KeyHasher); ok {
+		return hasher.KeyHash()
+	}
+	return NewKey
+}
+
 // String is a string representation of key
 func (k Key) String() string {
 	return string(k)
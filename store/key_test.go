@@ -17,7 +17,9 @@
 package store
 
 import (
+	"crypto/md5"
 	"crypto/sha1"
+	"crypto/sha256"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -29,3 +31,16 @@ func TestNewKey(t *testing.T) {
 
 	assert.Equal(t, NewKey(data), Key(sha[:]))
 }
+
+func TestNewKeyHash(t *testing.T) {
+	data := []byte("some data")
+	sha := sha256.Sum256(data)
+	assert.Equal(t, Key(sha[:KeySize]), SHA256(data))
+	assert.Equal(t, NewKey(data), SHA1(data))
+
+	// Short digests are padded
+	sum := md5.Sum(data)
+	key := NewKeyHash(md5.New)(data)
+	assert.Len(t, key, KeySize)
+	assert.Equal(t, sum[:], []byte(key[:md5.Size]))
+}
@@ -0,0 +1,459 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/clock"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// Key layout of LevelDB store. Values are kept under prefixValue, replication and
+// expiration indexes are ordered by time so due keys are read with short range scans
+// and index entries are appended in time order, which keeps compaction cheap.
+const (
+	prefixValue       = 'v'
+	prefixReplication = 'r'
+	prefixExpiration  = 'e'
+)
+
+// maxBatchSize is a maximum number of writes grouped into one batch when keys are expired
+const maxBatchSize = 1000
+
+// levelDBStore is a key/value store persisted in LevelDB, suited for nodes holding millions of values
+type levelDBStore struct {
+	db          *leveldb.DB
+	mutex       *sync.Mutex
+	stats       Stats
+	clock       clock.Clock
+	expirations *expirationMeter
+}
+
+// NewLevelDBStore opens LevelDB database in directory at path, creating it if needed, and returns store kept in it
+func NewLevelDBStore(path string) (Store, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newLevelDBStore(db), nil
+}
+
+func newLevelDBStore(db *leveldb.DB) *levelDBStore {
+	ls := &levelDBStore{
+		db:          db,
+		mutex:       &sync.Mutex{},
+		clock:       clock.New(),
+		expirations: newExpirationMeter(),
+	}
+	for _, entry := range ls.Entries() {
+		ls.stats.Keys++
+		ls.stats.Bytes += len(entry.Data)
+	}
+	return ls
+}
+
+func valueKey(key Key) []byte {
+	return append([]byte{prefixValue}, key...)
+}
+
+func timeKey(prefix byte, t time.Time, key Key) []byte {
+	buffer := make([]byte, 9, 9+len(key))
+	buffer[0] = prefix
+	if t.After(time.Unix(0, 0)) {
+		binary.BigEndian.PutUint64(buffer[1:], uint64(t.UnixNano()))
+	}
+	return append(buffer, key...)
+}
+
+// get returns stored record of key, nil if there is none
+func (ls *levelDBStore) get(key Key) (*storedRecord, error) {
+	raw, err := ls.db.Get(valueKey(key), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeRecord(raw)
+}
+
+// put replaces record of key and its index entries in one batch, must be called under mutex
+func (ls *levelDBStore) put(key Key, old *storedRecord, record *storedRecord) error {
+	batch := new(leveldb.Batch)
+	added, err := ls.replace(batch, key, old, record)
+	if err != nil {
+		return err
+	}
+	return ls.apply(batch, added)
+}
+
+// replace adds writes replacing record of key and its index entries to batch,
+// it returns change of stats the batch makes
+func (ls *levelDBStore) replace(batch *leveldb.Batch, key Key, old *storedRecord, record *storedRecord) (Stats, error) {
+	raw, err := encodeRecord(record)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	added := Stats{Keys: 1, Bytes: len(record.Data)}
+	if old != nil {
+		batch.Delete(timeKey(prefixReplication, old.Replication, key))
+		batch.Delete(timeKey(prefixExpiration, old.Expiration, key))
+		added.Keys--
+		added.Bytes -= len(old.Data)
+	}
+	batch.Put(valueKey(key), raw)
+	batch.Put(timeKey(prefixReplication, record.Replication, key), nil)
+	batch.Put(timeKey(prefixExpiration, record.Expiration, key), nil)
+	return added, nil
+}
+
+// apply writes batch adding values, must be called under mutex
+func (ls *levelDBStore) apply(batch *leveldb.Batch, added Stats) error {
+	if err := ls.db.Write(batch, nil); err != nil {
+		return err
+	}
+	ls.stats.Keys += added.Keys
+	ls.stats.Bytes += added.Bytes
+	return nil
+}
+
+// Store will store a key/value pair for the local node with the given
+// replication and expiration times.
+func (ls *levelDBStore) Store(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, publisher bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	old, err := ls.get(key)
+	if err != nil && err != ErrCorrupted {
+		return err
+	}
+	return ls.put(key, old, &storedRecord{
+		Data:        data,
+		Replication: replication,
+		Expiration:  expiration,
+	})
+}
+
+// StoreVersion stores key/value pair unless newer version of it is stored already
+func (ls *levelDBStore) StoreVersion(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, version Version) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	old, err := ls.get(key)
+	if err != nil && err != ErrCorrupted {
+		return false, err
+	}
+	if old != nil && old.Version.Newer(version) {
+		return false, nil
+	}
+	err = ls.put(key, old, &storedRecord{
+		Data:        data,
+		Replication: replication,
+		Expiration:  expiration,
+		Version:     version,
+	})
+	return err == nil, err
+}
+
+// Version returns version of stored value
+func (ls *levelDBStore) Version(ctx context.Context, key Key) (Version, bool, error) {
+	if ctx.Err() != nil {
+		return Version{}, false, ctx.Err()
+	}
+
+	record, err := ls.get(key)
+	if err != nil || record == nil {
+		return Version{}, false, err
+	}
+	return record.Version, true, nil
+}
+
+// Retrieve will return the local key/value if it exists
+func (ls *levelDBStore) Retrieve(ctx context.Context, key Key) ([]byte, bool, error) {
+	if ctx.Err() != nil {
+		return nil, false, ctx.Err()
+	}
+
+	record, err := ls.get(key)
+	if err != nil || record == nil {
+		return nil, false, err
+	}
+	return record.Data, true, nil
+}
+
+// StoreBatch stores entries with their replication and expiration times and versions,
+// writes of up to maxBatchSize entries are grouped into one batch
+func (ls *levelDBStore) StoreBatch(ctx context.Context, entries []Entry, publisher bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	batch := new(leveldb.Batch)
+	added := Stats{}
+	// Records put into batch which is not written yet
+	pending := make(map[string]*storedRecord)
+	for _, entry := range entries {
+		old, ok := pending[entry.Key.String()]
+		if !ok {
+			var err error
+			old, err = ls.get(entry.Key)
+			if err != nil && err != ErrCorrupted {
+				return err
+			}
+		}
+		record := recordOf(entry)
+		change, err := ls.replace(batch, entry.Key, old, record)
+		if err != nil {
+			return err
+		}
+		pending[entry.Key.String()] = record
+		added.Keys += change.Keys
+		added.Bytes += change.Bytes
+
+		if batch.Len() >= maxBatchSize {
+			if err := ls.apply(batch, added); err != nil {
+				return err
+			}
+			batch.Reset()
+			added = Stats{}
+			pending = make(map[string]*storedRecord)
+		}
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+	return ls.apply(batch, added)
+}
+
+// RetrieveBatch returns entries of keys which exist in the levelDBStore, records which can't be read are skipped
+func (ls *levelDBStore) RetrieveBatch(ctx context.Context, keys []Key) ([]Entry, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	snapshot, err := ls.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer snapshot.Release()
+
+	entries := make([]Entry, 0, len(keys))
+	for _, key := range keys {
+		raw, err := snapshot.Get(valueKey(key), nil)
+		if err == leveldb.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		record, err := decodeRecord(raw)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entryOf(key, record))
+	}
+	return entries, nil
+}
+
+// Delete deletes a key/value pair from the levelDBStore
+func (ls *levelDBStore) Delete(ctx context.Context, key Key) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	old, err := ls.get(key)
+	if err != nil && err != ErrCorrupted {
+		return err
+	}
+
+	batch := new(leveldb.Batch)
+	batch.Delete(valueKey(key))
+	if old != nil {
+		batch.Delete(timeKey(prefixReplication, old.Replication, key))
+		batch.Delete(timeKey(prefixExpiration, old.Expiration, key))
+	}
+	if err := ls.db.Write(batch, nil); err != nil {
+		return err
+	}
+
+	if old != nil {
+		ls.stats.Keys--
+		ls.stats.Bytes -= len(old.Data)
+	}
+	return nil
+}
+
+// due returns at most limit index entries with times before now, all of them if limit is not positive
+func (ls *levelDBStore) due(prefix byte, now time.Time, limit int) ([][]byte, error) {
+	iter := ls.db.NewIterator(&util.Range{
+		Start: []byte{prefix},
+		Limit: timeKey(prefix, now, nil),
+	}, nil)
+	defer iter.Release()
+
+	var entries [][]byte
+	for (limit <= 0 || len(entries) < limit) && iter.Next() {
+		entries = append(entries, append([]byte{}, iter.Key()...))
+	}
+	return entries, iter.Error()
+}
+
+// GetKeysReadyToReplicate should return the keys of all data to be
+// replicated across the network. Typically all data should be
+// replicated every tReplicate seconds.
+func (ls *levelDBStore) GetKeysReadyToReplicate(ctx context.Context) ([]Key, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	entries, err := ls.due(prefixReplication, ls.clock.Now(), 0)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]Key, 0, len(entries))
+	for _, entry := range entries {
+		keys = append(keys, Key(entry[9:]))
+	}
+	return keys, nil
+}
+
+// ExpireKeys should expire all key/values due for expiration.
+func (ls *levelDBStore) ExpireKeys(ctx context.Context) error {
+	_, err := ls.ExpireKeysBatch(ctx, 0)
+	return err
+}
+
+// ExpireKeysBatch expires key/values of at most max due expiration index entries,
+// all of them if max is not positive
+func (ls *levelDBStore) ExpireKeysBatch(ctx context.Context, max int) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	now := ls.clock.Now()
+	limit := 0
+	if max > 0 {
+		limit = max + 1
+	}
+	entries, err := ls.due(prefixExpiration, now, limit)
+	if err != nil {
+		return false, err
+	}
+	more := max > 0 && len(entries) > max
+	if more {
+		entries = entries[:max]
+	}
+
+	batch := new(leveldb.Batch)
+	removed := Stats{}
+	for _, entry := range entries {
+		key := Key(entry[9:])
+		record, err := ls.get(key)
+		if err != nil && err != ErrCorrupted {
+			return false, err
+		}
+		// Entry is stale if value was stored again with later expiration
+		batch.Delete(entry)
+		if record == nil {
+			batch.Delete(valueKey(key))
+		} else if now.After(record.Expiration) {
+			batch.Delete(valueKey(key))
+			batch.Delete(timeKey(prefixReplication, record.Replication, key))
+			removed.Keys++
+			removed.Bytes += len(record.Data)
+		}
+
+		if batch.Len() >= maxBatchSize {
+			if err := ls.flush(batch, removed); err != nil {
+				return false, err
+			}
+			batch.Reset()
+			removed = Stats{}
+		}
+	}
+	return more, ls.flush(batch, removed)
+}
+
+// flush writes batch removing values, must be called under mutex
+func (ls *levelDBStore) flush(batch *leveldb.Batch, removed Stats) error {
+	if batch.Len() == 0 {
+		return nil
+	}
+	if err := ls.db.Write(batch, nil); err != nil {
+		return err
+	}
+	ls.stats.Keys -= removed.Keys
+	ls.stats.Bytes -= removed.Bytes
+	ls.expirations.expired(ls.clock.Now(), removed.Keys)
+	return nil
+}
+
+// Stats returns number of stored keys, total size of values, expirations and keys pending replication
+func (ls *levelDBStore) Stats() Stats {
+	ls.mutex.Lock()
+	stats := ls.stats
+	ls.mutex.Unlock()
+
+	stats.Expirations = ls.expirations.count(ls.clock.Now())
+	stats.PendingReplication = pendingReplication(ls)
+	return stats
+}
+
+// Entries returns all stored key/value pairs
+func (ls *levelDBStore) Entries() []Entry {
+	iter := ls.db.NewIterator(util.BytesPrefix([]byte{prefixValue}), nil)
+	defer iter.Release()
+
+	var entries []Entry
+	for iter.Next() {
+		record, err := decodeRecord(iter.Value())
+		if err != nil {
+			continue
+		}
+		entries = append(entries, Entry{
+			Key:         append(Key{}, iter.Key()[1:]...),
+			Data:        record.Data,
+			Replication: record.Replication,
+			Expiration:  record.Expiration,
+			Version:     record.Version,
+		})
+	}
+	return entries
+}
@@ -0,0 +1,236 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/clock"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createLevelDBStore(t *testing.T, directory string) (*levelDBStore, func()) {
+	db, err := leveldb.OpenFile(directory, nil)
+	assert.NoError(t, err)
+	return newLevelDBStore(db), func() { db.Close() }
+}
+
+func TestLevelDBStore_StoreRetrieve(t *testing.T) {
+	directory, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	s, closeStore := createLevelDBStore(t, directory)
+	defer closeStore()
+	ctx := context.Background()
+
+	data := []byte("some data")
+	key := NewKey(data)
+
+	err = s.Store(ctx, key, data, time.Now().Add(time.Hour), time.Now().Add(time.Hour), true)
+	assert.NoError(t, err)
+	// Storing again replaces value and its index entries
+	err = s.Store(ctx, key, data, time.Now().Add(2*time.Hour), time.Now().Add(2*time.Hour), true)
+	assert.NoError(t, err)
+
+	retrieved, found, err := s.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, data, retrieved)
+	assert.Equal(t, Stats{Keys: 1, Bytes: len(data)}, s.Stats())
+
+	assert.NoError(t, s.Delete(ctx, key))
+	_, found, err = s.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, Stats{}, s.Stats())
+
+	iter := s.db.NewIterator(nil, nil)
+	defer iter.Release()
+	assert.False(t, iter.Next())
+}
+
+func TestLevelDBStore_StoreBatch(t *testing.T) {
+	directory, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	s, closeStore := createLevelDBStore(t, directory)
+	defer closeStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	data := []byte("some data")
+	key := NewKey(data)
+	assert.NoError(t, s.Store(ctx, key, data, now, now.Add(time.Hour), true))
+
+	// The same key twice in batch replaces its index entries once
+	entries := []Entry{
+		{Key: key, Data: data, Replication: now.Add(time.Hour), Expiration: now.Add(time.Hour)},
+		{Key: key, Data: data, Replication: now.Add(2 * time.Hour), Expiration: now.Add(2 * time.Hour)},
+	}
+	assert.NoError(t, s.StoreBatch(ctx, entries, true))
+	assert.Equal(t, Stats{Keys: 1, Bytes: len(data)}, s.Stats())
+
+	retrieved, err := s.RetrieveBatch(ctx, []Key{key})
+	assert.NoError(t, err)
+	assert.Len(t, retrieved, 1)
+	assert.True(t, now.Add(2*time.Hour).Equal(retrieved[0].Replication))
+
+	entriesOf := func(prefix byte) int {
+		iter := s.db.NewIterator(util.BytesPrefix([]byte{prefix}), nil)
+		defer iter.Release()
+		count := 0
+		for iter.Next() {
+			count++
+		}
+		return count
+	}
+	assert.Equal(t, 1, entriesOf(prefixReplication))
+	assert.Equal(t, 1, entriesOf(prefixExpiration))
+}
+
+func TestLevelDBStore_Reopen(t *testing.T) {
+	directory, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+	ctx := context.Background()
+
+	data := []byte("some data")
+	key := NewKey(data)
+	replication := time.Now().Add(time.Hour).Round(0)
+	expiration := time.Now().Add(2 * time.Hour).Round(0)
+	version := NewVersion(time.Now().Round(0), []byte("publisher"))
+
+	s, closeStore := createLevelDBStore(t, directory)
+	_, err = s.StoreVersion(ctx, key, data, replication, expiration, version)
+	assert.NoError(t, err)
+	closeStore()
+
+	s, closeStore = createLevelDBStore(t, directory)
+	defer closeStore()
+
+	entries := s.Entries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, key, entries[0].Key)
+	assert.Equal(t, data, entries[0].Data)
+	assert.True(t, replication.Equal(entries[0].Replication))
+	assert.True(t, expiration.Equal(entries[0].Expiration))
+	assert.True(t, version.Timestamp.Equal(entries[0].Version.Timestamp))
+	assert.Equal(t, Stats{Keys: 1, Bytes: len(data)}, s.Stats())
+}
+
+func TestLevelDBStore_StoreVersion(t *testing.T) {
+	directory, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	s, closeStore := createLevelDBStore(t, directory)
+	defer closeStore()
+	ctx := context.Background()
+
+	data := []byte("some data")
+	key := NewKey(data)
+	older := NewVersion(time.Now(), []byte("a"))
+	newer := NewVersion(older.Timestamp.Add(time.Second), []byte("a"))
+
+	stored, err := s.StoreVersion(ctx, key, data, time.Now(), time.Now().Add(time.Hour), newer)
+	assert.NoError(t, err)
+	assert.True(t, stored)
+
+	stored, err = s.StoreVersion(ctx, key, data, time.Now(), time.Now().Add(time.Hour), older)
+	assert.NoError(t, err)
+	assert.False(t, stored)
+
+	version, found, err := s.Version(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.True(t, newer.Timestamp.Equal(version.Timestamp))
+}
+
+func TestLevelDBStore_ExpireKeys(t *testing.T) {
+	directory, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	s, closeStore := createLevelDBStore(t, directory)
+	defer closeStore()
+	virtual := clock.NewVirtual(time.Now())
+	s.clock = virtual
+	ctx := context.Background()
+
+	data := []byte("some data")
+	key := NewKey(data)
+	other := []byte("other data")
+	otherKey := NewKey(other)
+	s.Store(ctx, key, data, virtual.Now().Add(time.Minute), virtual.Now().Add(time.Hour), true)
+	s.Store(ctx, otherKey, other, virtual.Now().Add(time.Hour), virtual.Now().Add(3*time.Hour), true)
+
+	keys, err := s.GetKeysReadyToReplicate(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	virtual.Advance(2 * time.Minute)
+	keys, err = s.GetKeysReadyToReplicate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []Key{key}, keys)
+
+	virtual.Advance(2 * time.Hour)
+	assert.NoError(t, s.ExpireKeys(ctx))
+	_, found, err := s.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+	_, found, err = s.Retrieve(ctx, otherKey)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, Stats{Keys: 1, Bytes: len(other), Expirations: 1, PendingReplication: 1}, s.Stats())
+}
+
+func TestLevelDBStore_ExpireKeysBatch(t *testing.T) {
+	directory, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	s, closeStore := createLevelDBStore(t, directory)
+	defer closeStore()
+	virtual := clock.NewVirtual(time.Now())
+	s.clock = virtual
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		data := []byte{byte(i)}
+		s.Store(ctx, NewKey(data), data, virtual.Now().Add(time.Hour), virtual.Now().Add(time.Minute), true)
+	}
+	virtual.Advance(2 * time.Minute)
+
+	more, err := s.ExpireKeysBatch(ctx, 2)
+	assert.NoError(t, err)
+	assert.True(t, more)
+	assert.Equal(t, 1, s.Stats().Keys)
+
+	more, err = s.ExpireKeysBatch(ctx, 2)
+	assert.NoError(t, err)
+	assert.False(t, more)
+	assert.Equal(t, 0, s.Stats().Keys)
+}
@@ -17,8 +17,11 @@
 package store
 
 import (
+	"context"
 	"sync"
 	"time"
+
+	"github.com/insolar/network/clock"
 )
 
 // memoryStore is a simple in-memory key/value store used for unit testing, and
@@ -28,6 +31,10 @@ type memoryStore struct {
 	data         map[string][]byte
 	replicateMap map[string]time.Time
 	expireMap    map[string]time.Time
+	versionMap   map[string]Version
+	clock        clock.Clock
+	notifier     replicationNotifier
+	expirations  *expirationMeter
 }
 
 // NewMemoryStore creates new memory store
@@ -35,18 +42,32 @@ func NewMemoryStore() Store {
 	return newMemoryStore()
 }
 
+// NewMemoryStoreWithClock creates new memory store which checks replication and expiration times with given clock
+func NewMemoryStoreWithClock(c clock.Clock) Store {
+	ms := newMemoryStore()
+	ms.clock = c
+	return ms
+}
+
 func newMemoryStore() *memoryStore {
 	return &memoryStore{
 		mutex:        &sync.RWMutex{},
 		data:         make(map[string][]byte),
 		replicateMap: make(map[string]time.Time),
 		expireMap:    make(map[string]time.Time),
+		versionMap:   make(map[string]Version),
+		clock:        clock.New(),
+		expirations:  newExpirationMeter(),
 	}
 }
 
 // Store will store a key/value pair for the local node with the given
 // replication and expiration times.
-func (ms *memoryStore) Store(key Key, data []byte, replication time.Time, expiration time.Time, publisher bool) error {
+func (ms *memoryStore) Store(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, publisher bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
 	ms.mutex.Lock()
 	defer ms.mutex.Unlock()
 
@@ -55,20 +76,113 @@ func (ms *memoryStore) Store(key Key, data []byte, replication time.Time, expira
 	ms.replicateMap[keyStr] = replication
 	ms.expireMap[keyStr] = expiration
 	ms.data[keyStr] = data
+	ms.notifier.stored(replication)
+	delete(ms.versionMap, keyStr)
 	return nil
 }
 
+// StoreVersion stores key/value pair unless newer version of it is stored already
+func (ms *memoryStore) StoreVersion(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, version Version) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	keyStr := key.String()
+	if _, exists := ms.data[keyStr]; exists && ms.versionMap[keyStr].Newer(version) {
+		return false, nil
+	}
+
+	ms.replicateMap[keyStr] = replication
+	ms.expireMap[keyStr] = expiration
+	ms.data[keyStr] = data
+	ms.notifier.stored(replication)
+	ms.versionMap[keyStr] = version
+	return true, nil
+}
+
+// Version returns version of stored value
+func (ms *memoryStore) Version(ctx context.Context, key Key) (Version, bool, error) {
+	if ctx.Err() != nil {
+		return Version{}, false, ctx.Err()
+	}
+
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	keyStr := key.String()
+	_, found := ms.data[keyStr]
+	return ms.versionMap[keyStr], found, nil
+}
+
 // Retrieve will return the local key/value if it exists
-func (ms *memoryStore) Retrieve(key Key) ([]byte, bool) {
+func (ms *memoryStore) Retrieve(ctx context.Context, key Key) ([]byte, bool, error) {
+	if ctx.Err() != nil {
+		return nil, false, ctx.Err()
+	}
+
 	ms.mutex.RLock()
 	defer ms.mutex.RUnlock()
 
 	data, found := ms.data[key.String()]
-	return data, found
+	return data, found, nil
+}
+
+// StoreBatch stores entries with their replication and expiration times and versions
+func (ms *memoryStore) StoreBatch(ctx context.Context, entries []Entry, publisher bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	for _, entry := range entries {
+		keyStr := entry.Key.String()
+		ms.replicateMap[keyStr] = entry.Replication
+		ms.expireMap[keyStr] = entry.Expiration
+		ms.data[keyStr] = entry.Data
+		ms.versionMap[keyStr] = entry.Version
+		ms.notifier.stored(entry.Replication)
+	}
+	return nil
+}
+
+// RetrieveBatch returns entries of keys which exist in the memoryStore
+func (ms *memoryStore) RetrieveBatch(ctx context.Context, keys []Key) ([]Entry, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	entries := make([]Entry, 0, len(keys))
+	for _, key := range keys {
+		keyStr := key.String()
+		data, found := ms.data[keyStr]
+		if !found {
+			continue
+		}
+		entries = append(entries, Entry{
+			Key:         key,
+			Data:        data,
+			Replication: ms.replicateMap[keyStr],
+			Expiration:  ms.expireMap[keyStr],
+			Version:     ms.versionMap[keyStr],
+		})
+	}
+	return entries, nil
 }
 
 // Delete deletes a key/value pair from the memoryStore
-func (ms *memoryStore) Delete(key Key) {
+func (ms *memoryStore) Delete(ctx context.Context, key Key) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
 	ms.mutex.Lock()
 	defer ms.mutex.Unlock()
 
@@ -76,35 +190,105 @@ func (ms *memoryStore) Delete(key Key) {
 
 	delete(ms.replicateMap, keyStr)
 	delete(ms.expireMap, keyStr)
+	delete(ms.versionMap, keyStr)
 	delete(ms.data, keyStr)
+	return nil
 }
 
 // GetKeysReadyToReplicate should return the keys of all data to be
 // replicated across the network. Typically all data should be
 // replicated every tReplicate seconds.
-func (ms *memoryStore) GetKeysReadyToReplicate() []Key {
+func (ms *memoryStore) GetKeysReadyToReplicate(ctx context.Context) ([]Key, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
 	ms.mutex.RLock()
 	defer ms.mutex.RUnlock()
 
 	var keys []Key
 	for k := range ms.data {
-		if time.Now().After(ms.replicateMap[k]) {
+		if ms.clock.Now().After(ms.replicateMap[k]) {
 			keys = append(keys, []byte(k))
 		}
 	}
-	return keys
+	return keys, nil
 }
 
 // ExpireKeys should expire all key/values due for expiration.
-func (ms *memoryStore) ExpireKeys() {
+func (ms *memoryStore) ExpireKeys(ctx context.Context) error {
+	_, err := ms.ExpireKeysBatch(ctx, 0)
+	return err
+}
+
+// ExpireKeysBatch expires at most max key/values due for expiration, all of them if max is not positive
+func (ms *memoryStore) ExpireKeysBatch(ctx context.Context, max int) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
 	ms.mutex.Lock()
 	defer ms.mutex.Unlock()
 
+	expired := 0
+	more := false
 	for k, v := range ms.expireMap {
-		if time.Now().After(v) {
+		if ms.clock.Now().After(v) {
+			if max > 0 && expired == max {
+				more = true
+				break
+			}
 			delete(ms.replicateMap, k)
 			delete(ms.expireMap, k)
+			delete(ms.versionMap, k)
 			delete(ms.data, k)
+			expired++
 		}
 	}
+	ms.expirations.expired(ms.clock.Now(), expired)
+	return more, nil
+}
+
+// Stats returns number of stored keys, total size of values, expirations and keys pending replication
+func (ms *memoryStore) Stats() Stats {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	now := ms.clock.Now()
+	stats := Stats{Keys: len(ms.data), Expirations: ms.expirations.count(now)}
+	for k, v := range ms.data {
+		stats.Bytes += len(v)
+		if now.After(ms.replicateMap[k]) {
+			stats.PendingReplication++
+		}
+	}
+	return stats
+}
+
+// Entries returns all stored key/value pairs
+func (ms *memoryStore) Entries() []Entry {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	entries := make([]Entry, 0, len(ms.data))
+	for k, v := range ms.data {
+		entries = append(entries, Entry{
+			Key:         Key(k),
+			Data:        v,
+			Replication: ms.replicateMap[k],
+			Expiration:  ms.expireMap[k],
+			Version:     ms.versionMap[k],
+		})
+	}
+	return entries
+}
+
+// NotifyReplication returns channel receiving keys as their replication times pass
+func (ms *memoryStore) NotifyReplication(ctx context.Context) <-chan []Key {
+	return ms.notifier.notify(ctx, ms.clock, func(w *replicationWaiter) ([]Key, <-chan struct{}, time.Time) {
+		ms.mutex.RLock()
+		defer ms.mutex.RUnlock()
+
+		return ms.notifier.due(w, ms.replicateMap, ms.clock.Now())
+	})
 }
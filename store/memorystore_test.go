@@ -17,10 +17,13 @@
 package store
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/insolar/network/clock"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,11 +35,15 @@ func TestNewMemoryStore(t *testing.T) {
 		data:         make(map[string][]byte),
 		replicateMap: make(map[string]time.Time),
 		expireMap:    make(map[string]time.Time),
+		versionMap:   make(map[string]Version),
+		clock:        clock.New(),
+		expirations:  newExpirationMeter(),
 	})
 }
 
 func TestMemoryStore_Store(t *testing.T) {
 	s := newMemoryStore()
+	ctx := context.Background()
 
 	data := []byte("some data")
 	key := NewKey(data)
@@ -44,7 +51,7 @@ func TestMemoryStore_Store(t *testing.T) {
 	replicationTime := time.Now().Add(time.Second * 1337)
 	expirationTime := time.Now().Add(time.Second * 42)
 
-	s.Store(key, data, replicationTime, expirationTime, true)
+	s.Store(ctx, key, data, replicationTime, expirationTime, true)
 
 	assert.Len(t, s.data, 1)
 	assert.Equal(t, s.data[key.String()], data)
@@ -58,30 +65,35 @@ func TestMemoryStore_Store(t *testing.T) {
 
 func TestMemoryStore_Retrieve(t *testing.T) {
 	s := NewMemoryStore()
+	ctx := context.Background()
 
 	data := []byte("some data")
 	key := NewKey(data)
 
-	res, found := s.Retrieve(key)
+	res, found, err := s.Retrieve(ctx, key)
+	assert.NoError(t, err)
 	assert.Nil(t, res)
 	assert.False(t, found)
 
-	s.Store(key, data, time.Now(), time.Now(), true)
+	s.Store(ctx, key, data, time.Now(), time.Now(), true)
 
-	res, found = s.Retrieve(key)
+	res, found, err = s.Retrieve(ctx, key)
+	assert.NoError(t, err)
 	assert.Equal(t, res, data)
 	assert.True(t, found)
 }
 
 func TestMemoryStore_Delete(t *testing.T) {
 	s := newMemoryStore()
+	ctx := context.Background()
 
 	data := []byte("some data")
 	key := NewKey(data)
 
-	s.Store(key, data, time.Now(), time.Now(), true)
+	s.Store(ctx, key, data, time.Now(), time.Now(), true)
 
-	s.Delete(key)
+	err := s.Delete(ctx, key)
+	assert.NoError(t, err)
 
 	assert.Len(t, s.data, 0)
 	assert.Len(t, s.replicateMap, 0)
@@ -90,26 +102,28 @@ func TestMemoryStore_Delete(t *testing.T) {
 
 func TestMemoryStore_GetKeysReadyToReplicate(t *testing.T) {
 	s := newMemoryStore()
+	ctx := context.Background()
 
 	now := time.Now()
 
 	data1 := []byte("some data1")
 	key1 := NewKey(data1)
-	s.Store(key1, data1, now.Add(-20*time.Second), now, true)
+	s.Store(ctx, key1, data1, now.Add(-20*time.Second), now, true)
 
 	data2 := []byte("some data2")
 	key2 := NewKey(data2)
-	s.Store(key2, data2, now.Add(-1*time.Nanosecond), now, true)
+	s.Store(ctx, key2, data2, now.Add(-1*time.Nanosecond), now, true)
 
 	data3 := []byte("some data3")
 	key3 := NewKey(data3)
-	s.Store(key3, data3, now.Add(10*time.Minute), now, true)
+	s.Store(ctx, key3, data3, now.Add(10*time.Minute), now, true)
 
 	data4 := []byte("some data4")
 	key4 := NewKey(data4)
-	s.Store(key4, data4, now.Add(20*time.Second), now, true)
+	s.Store(ctx, key4, data4, now.Add(20*time.Second), now, true)
 
-	keys := s.GetKeysReadyToReplicate()
+	keys, err := s.GetKeysReadyToReplicate(ctx)
+	assert.NoError(t, err)
 
 	assert.Len(t, keys, 2)
 	assert.Equal(t, keys, []Key{key1, key2})
@@ -117,26 +131,28 @@ func TestMemoryStore_GetKeysReadyToReplicate(t *testing.T) {
 
 func TestMemoryStore_ExpireKeys(t *testing.T) {
 	s := newMemoryStore()
+	ctx := context.Background()
 
 	now := time.Now()
 
 	data1 := []byte("some data1")
 	key1 := NewKey(data1)
-	s.Store(key1, data1, now, now.Add(-20*time.Second), true)
+	s.Store(ctx, key1, data1, now, now.Add(-20*time.Second), true)
 
 	data2 := []byte("some data2")
 	key2 := NewKey(data2)
-	s.Store(key2, data2, now, now.Add(-1*time.Nanosecond), true)
+	s.Store(ctx, key2, data2, now, now.Add(-1*time.Nanosecond), true)
 
 	data3 := []byte("some data3")
 	key3 := NewKey(data3)
-	s.Store(key3, data3, now, now.Add(10*time.Minute), true)
+	s.Store(ctx, key3, data3, now, now.Add(10*time.Minute), true)
 
 	data4 := []byte("some data4")
 	key4 := NewKey(data4)
-	s.Store(key4, data4, now, now.Add(20*time.Second), true)
+	s.Store(ctx, key4, data4, now, now.Add(20*time.Second), true)
 
-	s.ExpireKeys()
+	err := s.ExpireKeys(ctx)
+	assert.NoError(t, err)
 
 	assert.Len(t, s.data, 2)
 	assert.Len(t, s.replicateMap, 2)
@@ -147,3 +163,199 @@ func TestMemoryStore_ExpireKeys(t *testing.T) {
 		key4.String(): data4,
 	})
 }
+
+func TestMemoryStore_ExpireKeysBatch(t *testing.T) {
+	s := newMemoryStore()
+	ctx := context.Background()
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		data := []byte{byte(i)}
+		s.Store(ctx, NewKey(data), data, now, now.Add(-time.Second), true)
+	}
+
+	more, err := s.ExpireKeysBatch(ctx, 2)
+	assert.NoError(t, err)
+	assert.True(t, more)
+	assert.Len(t, s.data, 1)
+
+	more, err = s.ExpireKeysBatch(ctx, 2)
+	assert.NoError(t, err)
+	assert.False(t, more)
+	assert.Empty(t, s.data)
+}
+
+func TestMemoryStore_Cancelled(t *testing.T) {
+	s := newMemoryStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	data := []byte("some data")
+	key := NewKey(data)
+
+	err := s.Store(ctx, key, data, time.Now(), time.Now(), true)
+	assert.Equal(t, context.Canceled, err)
+	assert.Len(t, s.data, 0)
+
+	_, _, err = s.Retrieve(ctx, key)
+	assert.Equal(t, context.Canceled, err)
+
+	assert.Equal(t, context.Canceled, s.Delete(ctx, key))
+	assert.Equal(t, context.Canceled, s.ExpireKeys(ctx))
+
+	_, err = s.GetKeysReadyToReplicate(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestMemoryStore_Stats(t *testing.T) {
+	s := newMemoryStore()
+	virtual := clock.NewVirtual(time.Now())
+	s.clock = virtual
+	ctx := context.Background()
+
+	data, other := []byte("some data"), []byte("other data")
+	assert.NoError(t, s.Store(ctx, NewKey(data), data, virtual.Now(), virtual.Now().Add(time.Hour), true))
+	assert.NoError(t, s.Store(ctx, NewKey(other), other, virtual.Now().Add(time.Hour), virtual.Now().Add(2*time.Hour), true))
+	assert.Equal(t, Stats{Keys: 2, Bytes: 19}, s.Stats())
+
+	virtual.Advance(time.Minute)
+	assert.Equal(t, Stats{Keys: 2, Bytes: 19, PendingReplication: 1}, s.Stats())
+
+	// Expirations are counted for a minute
+	virtual.Advance(time.Hour)
+	assert.NoError(t, s.ExpireKeys(ctx))
+	assert.Equal(t, Stats{Keys: 1, Bytes: 10, Expirations: 1, PendingReplication: 1}, s.Stats())
+	virtual.Advance(time.Minute)
+	assert.Equal(t, Stats{Keys: 1, Bytes: 10, PendingReplication: 1}, s.Stats())
+}
+
+func TestMemoryStore_Entries(t *testing.T) {
+	s := newMemoryStore()
+	ctx := context.Background()
+	data := []byte("some data")
+	replication := time.Now()
+	expiration := replication.Add(time.Hour)
+
+	assert.Empty(t, s.Entries())
+
+	err := s.Store(ctx, NewKey(data), data, replication, expiration, true)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Entry{{Key: NewKey(data), Data: data, Replication: replication, Expiration: expiration}}, s.Entries())
+}
+
+func TestMemoryStore_ExpireKeys_Clock(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	v := clock.NewVirtual(start)
+	s := NewMemoryStoreWithClock(v)
+	ctx := context.Background()
+
+	data := []byte("some data")
+	key := NewKey(data)
+	s.Store(ctx, key, data, start.Add(time.Hour), start.Add(time.Hour*24), true)
+
+	keys, err := s.GetKeysReadyToReplicate(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	v.Advance(time.Hour * 2)
+	keys, err = s.GetKeysReadyToReplicate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []Key{key}, keys)
+
+	v.Advance(time.Hour * 23)
+	err = s.ExpireKeys(ctx)
+	assert.NoError(t, err)
+	_, found, _ := s.Retrieve(ctx, key)
+	assert.False(t, found)
+}
+
+func TestMemoryStore_StoreVersion(t *testing.T) {
+	s := newMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	data := []byte("some data")
+	key := NewKey(data)
+	older := NewVersion(now, []byte{1})
+	newer := NewVersion(now.Add(time.Second), []byte{1})
+
+	stored, err := s.StoreVersion(ctx, key, data, now, now.Add(time.Hour), newer)
+	assert.NoError(t, err)
+	assert.True(t, stored)
+
+	// Older replica does not override newer one
+	stored, err = s.StoreVersion(ctx, key, data, now, now.Add(time.Minute), older)
+	assert.NoError(t, err)
+	assert.False(t, stored)
+	assert.Equal(t, now.Add(time.Hour), s.expireMap[key.String()])
+
+	version, found, err := s.Version(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, newer, version)
+
+	// The same version is stored again
+	stored, err = s.StoreVersion(ctx, key, data, now, now.Add(time.Hour*2), newer)
+	assert.NoError(t, err)
+	assert.True(t, stored)
+
+	err = s.Delete(ctx, key)
+	assert.NoError(t, err)
+	_, found, err = s.Version(ctx, key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Empty(t, s.versionMap)
+}
+
+func TestMemoryStore_StoreBatch(t *testing.T) {
+	s := newMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	first, second := []byte("first"), []byte("second")
+	version := NewVersion(now, []byte{1})
+	entries := []Entry{
+		{Key: NewKey(first), Data: first, Replication: now, Expiration: now.Add(time.Hour), Version: version},
+		{Key: NewKey(second), Data: second, Replication: now, Expiration: now.Add(time.Hour)},
+	}
+	assert.NoError(t, s.StoreBatch(ctx, entries, true))
+
+	retrieved, err := s.RetrieveBatch(ctx, []Key{NewKey(first), NewKey([]byte("missing")), NewKey(second)})
+	assert.NoError(t, err)
+	assert.Equal(t, entries, retrieved)
+}
+
+func TestMemoryStore_NotifyReplication(t *testing.T) {
+	virtual := clock.NewVirtual(time.Now())
+	s := NewMemoryStoreWithClock(virtual)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	data := []byte("some data")
+	key := NewKey(data)
+	s.Store(ctx, key, data, virtual.Now().Add(time.Minute), virtual.Now().Add(time.Hour), true)
+
+	notifications := s.(ReplicationNotifier).NotifyReplication(ctx)
+	for virtual.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	virtual.Advance(time.Minute)
+	assert.Equal(t, []Key{key}, <-notifications)
+
+	// Key is sent again only after it is stored with new replication time
+	s.Store(ctx, key, data, virtual.Now().Add(time.Minute), virtual.Now().Add(time.Hour), true)
+	select {
+	case <-notifications:
+		t.Fatal("key is sent before its replication time")
+	case <-time.After(time.Millisecond * 50):
+	}
+	for virtual.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	virtual.Advance(time.Minute)
+	assert.Equal(t, []Key{key}, <-notifications)
+
+	cancel()
+	_, open := <-notifications
+	assert.False(t, open)
+}
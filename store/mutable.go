@@ -0,0 +1,75 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+)
+
+// MutableRecord is a value signed by owner of key pair. It is stored under key derived from
+// public key and salt, so owner can replace value by publishing record with higher sequence number.
+type MutableRecord struct {
+	PublicKey ed25519.PublicKey
+	Salt      []byte
+	Seq       int64
+	Value     []byte
+	Signature []byte
+}
+
+// NewMutableRecord creates mutable record signed with owner's private key
+func NewMutableRecord(value, salt []byte, seq int64, privateKey ed25519.PrivateKey) *MutableRecord {
+	record := &MutableRecord{
+		PublicKey: privateKey.Public().(ed25519.PublicKey),
+		Salt:      salt,
+		Seq:       seq,
+		Value:     value,
+	}
+	record.Signature = ed25519.Sign(privateKey, record.payload())
+	return record
+}
+
+// Verify checks record signature
+func (r *MutableRecord) Verify() bool {
+	if len(r.PublicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(r.PublicKey, r.payload(), r.Signature)
+}
+
+// Key returns key record is stored under in network deriving keys with hash
+func (r *MutableRecord) Key(hash KeyHash) Key {
+	return MutableKey(hash, r.PublicKey, r.Salt)
+}
+
+// MutableKey returns key records of public key and salt are stored under in network deriving keys with hash
+func MutableKey(hash KeyHash, publicKey ed25519.PublicKey, salt []byte) Key {
+	data := make([]byte, 0, len(publicKey)+len(salt))
+	return hash(append(append(data, publicKey...), salt...))
+}
+
+func (r *MutableRecord) payload() []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("mutable")
+	writeChunk(&buffer, r.Salt)
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], uint64(r.Seq))
+	buffer.Write(seq[:])
+	writeChunk(&buffer, r.Value)
+	return buffer.Bytes()
+}
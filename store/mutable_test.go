@@ -0,0 +1,54 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutableRecord_Verify(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	record := NewMutableRecord([]byte("value"), []byte("salt"), 1, privateKey)
+	assert.True(t, record.Verify())
+
+	forged := *record
+	forged.Seq = 2
+	assert.False(t, forged.Verify())
+
+	forged = *record
+	forged.Value = []byte("forged")
+	assert.False(t, forged.Verify())
+
+	forged = *record
+	forged.PublicKey = nil
+	assert.False(t, forged.Verify())
+}
+
+func TestMutableKey(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	record := NewMutableRecord([]byte("value"), []byte("salt"), 1, privateKey)
+
+	assert.Equal(t, MutableKey(NewKey, publicKey, []byte("salt")), record.Key(NewKey))
+	assert.NotEqual(t, MutableKey(NewKey, publicKey, nil), record.Key(NewKey))
+	assert.Equal(t, SHA256(append(append([]byte{}, publicKey...), "salt"...)), record.Key(SHA256))
+}
@@ -0,0 +1,445 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/clock"
+)
+
+// MaxNamespaceLength is the maximum length of namespace in bytes
+const MaxNamespaceLength = 64
+
+type namespaceKey struct{}
+
+// WithNamespace returns ctx which stores values under namespace, so applications sharing
+// one network keep their values in separate keyspaces. Empty namespace is the default one.
+func WithNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, namespace)
+}
+
+// NamespaceOf returns namespace of ctx
+func NamespaceOf(ctx context.Context) string {
+	namespace, _ := ctx.Value(namespaceKey{}).(string)
+	return namespace
+}
+
+// NamespacedKey derives key of data stored under namespace with hash. Keys of default namespace
+// are hashes of data, keys of other namespaces are hashes of namespace followed by data.
+func NamespacedKey(hash KeyHash, namespace string, data []byte) Key {
+	if namespace == "" {
+		return hash(data)
+	}
+	var buffer bytes.Buffer
+	writeChunk(&buffer, []byte(namespace))
+	buffer.Write(data)
+	return hash(buffer.Bytes())
+}
+
+// Namespaced is implemented by stores keeping track of namespaces keys were stored under
+type Namespaced interface {
+	// Namespace returns namespace key was stored under
+	Namespace(key Key) (string, bool)
+
+	// Keys returns keys stored under namespace
+	Keys(namespace string) []Key
+
+	// NamespaceStats returns number of keys and bytes stored under namespace with limits of its quota
+	NamespaceStats(namespace string) Stats
+}
+
+// namespacedEntry is namespace, size and expiration of stored value
+type namespacedEntry struct {
+	namespace  string
+	size       int
+	expiration time.Time
+}
+
+// namespacedStore wraps store recording namespace of every value and limiting size of namespaces
+type namespacedStore struct {
+	store   Store
+	quotas  map[string]Quota
+	mutex   *sync.Mutex
+	entries map[string]*namespacedEntry
+	stats   map[string]*Stats
+	clock   clock.Clock
+}
+
+// notifyingNamespacedStore is namespacedStore around store which notifies about keys ready to replicate
+type notifyingNamespacedStore struct {
+	*namespacedStore
+	notifier ReplicationNotifier
+}
+
+// NotifyReplication returns channel receiving keys as their replication times pass
+func (ns *notifyingNamespacedStore) NotifyReplication(ctx context.Context) <-chan []Key {
+	return ns.notifier.NotifyReplication(ctx)
+}
+
+// NewNamespacedStore wraps store to record namespace values are stored under, see WithNamespace.
+// Namespace of key is set when key is stored first and is kept in wrapped store together with value.
+// Size of namespaces is limited with quotas keyed by namespace, values exceeding quota are rejected
+// with ErrFull or ErrTooLarge, Eviction of quota is ignored. Wrapped store should be empty or
+// contain only values stored through namespaced store.
+func NewNamespacedStore(store Store, quotas map[string]Quota) Store {
+	ns := &namespacedStore{
+		store:   store,
+		quotas:  quotas,
+		mutex:   &sync.Mutex{},
+		entries: make(map[string]*namespacedEntry),
+		stats:   make(map[string]*Stats),
+		clock:   clock.New(),
+	}
+	if enumerator, ok := store.(Enumerator); ok {
+		for _, entry := range enumerator.Entries() {
+			namespace, data, err := decodeNamespaced(entry.Data)
+			if err == nil {
+				ns.add(entry.Key, namespace, len(data), entry.Expiration)
+			}
+		}
+	}
+	if notifier, ok := store.(ReplicationNotifier); ok {
+		return &notifyingNamespacedStore{namespacedStore: ns, notifier: notifier}
+	}
+	return ns
+}
+
+// encodeNamespaced prepends namespace to data
+func encodeNamespaced(namespace string, data []byte) []byte {
+	var buffer bytes.Buffer
+	writeChunk(&buffer, []byte(namespace))
+	buffer.Write(data)
+	return buffer.Bytes()
+}
+
+// decodeNamespaced splits value encoded with encodeNamespaced
+func decodeNamespaced(encoded []byte) (string, []byte, error) {
+	if len(encoded) < 4 {
+		return "", nil, ErrCorrupted
+	}
+	length := binary.BigEndian.Uint32(encoded)
+	if length > MaxNamespaceLength || int(length) > len(encoded)-4 {
+		return "", nil, ErrCorrupted
+	}
+	return string(encoded[4 : 4+length]), encoded[4+length:], nil
+}
+
+// namespaceFor returns namespace value of key is stored under, must be called under mutex
+func (ns *namespacedStore) namespaceFor(ctx context.Context, key Key) string {
+	if entry, ok := ns.entries[key.String()]; ok {
+		return entry.namespace
+	}
+	return NamespaceOf(ctx)
+}
+
+// add records value stored under namespace, must be called under mutex
+func (ns *namespacedStore) add(key Key, namespace string, size int, expiration time.Time) {
+	ns.remove(key)
+	ns.entries[key.String()] = &namespacedEntry{namespace: namespace, size: size, expiration: expiration}
+	stats, ok := ns.stats[namespace]
+	if !ok {
+		stats = &Stats{}
+		ns.stats[namespace] = stats
+	}
+	stats.Keys++
+	stats.Bytes += size
+}
+
+// remove forgets value, must be called under mutex
+func (ns *namespacedStore) remove(key Key) {
+	entry, ok := ns.entries[key.String()]
+	if !ok {
+		return
+	}
+	delete(ns.entries, key.String())
+	stats := ns.stats[entry.namespace]
+	stats.Keys--
+	stats.Bytes -= entry.size
+	if stats.Keys == 0 {
+		delete(ns.stats, entry.namespace)
+	}
+}
+
+// admit checks if values fit into quotas of their namespaces, must be called under mutex
+func (ns *namespacedStore) admit(ctx context.Context, entries []Entry) error {
+	projected := make(map[string]Stats)
+	for _, entry := range entries {
+		namespace := ns.namespaceFor(ctx, entry.Key)
+		quota := ns.quotas[namespace]
+		if quota.MaxBytes > 0 && len(entry.Data) > quota.MaxBytes {
+			return ErrTooLarge
+		}
+		stats, ok := projected[namespace]
+		if !ok && ns.stats[namespace] != nil {
+			stats = *ns.stats[namespace]
+		}
+		stats.Keys++
+		stats.Bytes += len(entry.Data)
+		if current, ok := ns.entries[entry.Key.String()]; ok {
+			stats.Keys--
+			stats.Bytes -= current.size
+		}
+		if (quota.MaxKeys > 0 && stats.Keys > quota.MaxKeys) || (quota.MaxBytes > 0 && stats.Bytes > quota.MaxBytes) {
+			return ErrFull
+		}
+		projected[namespace] = stats
+	}
+	return nil
+}
+
+// Store will store a key/value pair under namespace of ctx with the given
+// replication and expiration times.
+func (ns *namespacedStore) Store(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, publisher bool) error {
+	ns.mutex.Lock()
+	defer ns.mutex.Unlock()
+
+	if err := ns.admit(ctx, []Entry{{Key: key, Data: data}}); err != nil {
+		return err
+	}
+	namespace := ns.namespaceFor(ctx, key)
+	if err := ns.store.Store(ctx, key, encodeNamespaced(namespace, data), replication, expiration, publisher); err != nil {
+		return err
+	}
+	ns.add(key, namespace, len(data), expiration)
+	return nil
+}
+
+// StoreVersion stores key/value pair under namespace of ctx unless newer version of it is stored already.
+// Version is ignored if wrapped store doesn't keep versions.
+func (ns *namespacedStore) StoreVersion(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, version Version) (bool, error) {
+	versioned, ok := ns.store.(Versioned)
+	if !ok {
+		return true, ns.Store(ctx, key, data, replication, expiration, true)
+	}
+
+	ns.mutex.Lock()
+	defer ns.mutex.Unlock()
+
+	if err := ns.admit(ctx, []Entry{{Key: key, Data: data}}); err != nil {
+		return false, err
+	}
+	namespace := ns.namespaceFor(ctx, key)
+	ok, err := versioned.StoreVersion(ctx, key, encodeNamespaced(namespace, data), replication, expiration, version)
+	if ok && err == nil {
+		ns.add(key, namespace, len(data), expiration)
+	}
+	return ok, err
+}
+
+// Version returns version of stored value
+func (ns *namespacedStore) Version(ctx context.Context, key Key) (Version, bool, error) {
+	if versioned, ok := ns.store.(Versioned); ok {
+		return versioned.Version(ctx, key)
+	}
+	_, found, err := ns.store.Retrieve(ctx, key)
+	return Version{}, found, err
+}
+
+// Retrieve will return the local key/value if it exists
+func (ns *namespacedStore) Retrieve(ctx context.Context, key Key) ([]byte, bool, error) {
+	encoded, found, err := ns.store.Retrieve(ctx, key)
+	if err != nil || !found {
+		return nil, found, err
+	}
+	_, data, err := decodeNamespaced(encoded)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// StoreBatch stores entries, new keys are stored under namespace of ctx
+func (ns *namespacedStore) StoreBatch(ctx context.Context, entries []Entry, publisher bool) error {
+	ns.mutex.Lock()
+	defer ns.mutex.Unlock()
+
+	if err := ns.admit(ctx, entries); err != nil {
+		return err
+	}
+	encoded := make([]Entry, 0, len(entries))
+	namespaces := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		namespace := ns.namespaceFor(ctx, entry.Key)
+		namespaces = append(namespaces, namespace)
+		entry.Data = encodeNamespaced(namespace, entry.Data)
+		encoded = append(encoded, entry)
+	}
+	if err := ns.store.StoreBatch(ctx, encoded, publisher); err != nil {
+		return err
+	}
+	for i, entry := range entries {
+		ns.add(entry.Key, namespaces[i], len(entry.Data), entry.Expiration)
+	}
+	return nil
+}
+
+// RetrieveBatch returns entries of keys which exist in wrapped store, values which can't be decoded are skipped
+func (ns *namespacedStore) RetrieveBatch(ctx context.Context, keys []Key) ([]Entry, error) {
+	entries, err := ns.store.RetrieveBatch(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	return decodeNamespacedEntries(entries), nil
+}
+
+// decodeNamespacedEntries strips namespaces from values of entries, entries which can't be decoded are skipped
+func decodeNamespacedEntries(entries []Entry) []Entry {
+	decoded := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		_, data, err := decodeNamespaced(entry.Data)
+		if err != nil {
+			continue
+		}
+		entry.Data = data
+		decoded = append(decoded, entry)
+	}
+	return decoded
+}
+
+// Delete deletes a key/value pair from wrapped store
+func (ns *namespacedStore) Delete(ctx context.Context, key Key) error {
+	ns.mutex.Lock()
+	defer ns.mutex.Unlock()
+
+	if err := ns.store.Delete(ctx, key); err != nil {
+		return err
+	}
+	ns.remove(key)
+	return nil
+}
+
+// GetKeysReadyToReplicate should return the keys of all data to be
+// replicated across the network. Typically all data should be
+// replicated every tReplicate seconds.
+func (ns *namespacedStore) GetKeysReadyToReplicate(ctx context.Context) ([]Key, error) {
+	return ns.store.GetKeysReadyToReplicate(ctx)
+}
+
+// ExpireKeys should expire all key/values due for expiration.
+func (ns *namespacedStore) ExpireKeys(ctx context.Context) error {
+	ns.mutex.Lock()
+	defer ns.mutex.Unlock()
+
+	if err := ns.store.ExpireKeys(ctx); err != nil {
+		return err
+	}
+	now := ns.clock.Now()
+	for k, entry := range ns.entries {
+		if !now.After(entry.expiration) {
+			continue
+		}
+		// Wrapped store may check expiration with its own clock
+		_, found, err := ns.store.Retrieve(ctx, Key(k))
+		if err != nil {
+			return err
+		}
+		if !found {
+			ns.remove(Key(k))
+		}
+	}
+	return nil
+}
+
+// Stats returns stats of wrapped store
+func (ns *namespacedStore) Stats() Stats {
+	if reporter, ok := ns.store.(StatsReporter); ok {
+		return reporter.Stats()
+	}
+	return Stats{}
+}
+
+// Entries returns all stored key/value pairs, nil if wrapped store can't list them
+func (ns *namespacedStore) Entries() []Entry {
+	if enumerator, ok := ns.store.(Enumerator); ok {
+		return decodeNamespacedEntries(enumerator.Entries())
+	}
+	return nil
+}
+
+// Namespace returns namespace key was stored under
+func (ns *namespacedStore) Namespace(key Key) (string, bool) {
+	ns.mutex.Lock()
+	defer ns.mutex.Unlock()
+
+	entry, ok := ns.entries[key.String()]
+	if !ok {
+		return "", false
+	}
+	return entry.namespace, true
+}
+
+// Keys returns keys stored under namespace
+func (ns *namespacedStore) Keys(namespace string) []Key {
+	ns.mutex.Lock()
+	defer ns.mutex.Unlock()
+
+	var keys []Key
+	for k, entry := range ns.entries {
+		if entry.namespace == namespace {
+			keys = append(keys, Key(k))
+		}
+	}
+	return keys
+}
+
+// NamespaceStats returns number of keys and bytes stored under namespace with limits of its quota
+func (ns *namespacedStore) NamespaceStats(namespace string) Stats {
+	ns.mutex.Lock()
+	defer ns.mutex.Unlock()
+
+	var stats Stats
+	if current, ok := ns.stats[namespace]; ok {
+		stats = *current
+	}
+	stats.MaxKeys = ns.quotas[namespace].MaxKeys
+	stats.MaxBytes = ns.quotas[namespace].MaxBytes
+	return stats
+}
+
+type namespacedStoreFactory struct {
+	Factory
+	quotas map[string]Quota
+}
+
+// NewNamespacedStoreFactory creates factory of storages created by given factory which keep namespaces apart
+func NewNamespacedStoreFactory(factory Factory, quotas map[string]Quota) Factory {
+	return &namespacedStoreFactory{Factory: factory, quotas: quotas}
+}
+
+// Create returns new namespaced storage
+func (namespacedStoreFactory *namespacedStoreFactory) Create() Store {
+	return NewNamespacedStore(namespacedStoreFactory.Factory.Create(), namespacedStoreFactory.quotas)
+}
+
+// Close closes wrapped factory if it holds resources
+func (namespacedStoreFactory *namespacedStoreFactory) Close() error {
+	if closer, ok := namespacedStoreFactory.Factory.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// KeyHash returns hash keys of wrapped factory are derived with
+func (namespacedStoreFactory *namespacedStoreFactory) KeyHash() KeyHash {
+	return KeyHashOf(namespacedStoreFactory.Factory)
+}
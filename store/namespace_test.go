@@ -0,0 +1,88 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespacedKey(t *testing.T) {
+	data := []byte("data")
+	assert.Equal(t, NewKey(data), NamespacedKey(NewKey, "", data))
+	assert.NotEqual(t, NewKey(data), NamespacedKey(NewKey, "app", data))
+	assert.NotEqual(t, NamespacedKey(NewKey, "app", data), NamespacedKey(NewKey, "other", data))
+	assert.Equal(t, "app", NamespaceOf(WithNamespace(context.Background(), "app")))
+}
+
+func TestNamespacedStore(t *testing.T) {
+	wrapped := NewMemoryStore()
+	s := NewNamespacedStore(wrapped, map[string]Quota{"app": {MaxKeys: 1}})
+	ctx := context.Background()
+	app := WithNamespace(ctx, "app")
+	expiration := time.Now().Add(time.Hour)
+
+	first, second := []byte("first"), []byte("second")
+	assert.NoError(t, s.Store(app, NewKey(first), first, expiration, expiration, true))
+	assert.NoError(t, s.Store(ctx, NewKey(second), second, expiration, expiration, true))
+
+	data, found, err := s.Retrieve(ctx, NewKey(first))
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, first, data)
+
+	namespaced := s.(Namespaced)
+	namespace, ok := namespaced.Namespace(NewKey(first))
+	assert.True(t, ok)
+	assert.Equal(t, "app", namespace)
+	assert.Equal(t, []Key{NewKey(first)}, namespaced.Keys("app"))
+	assert.Equal(t, []Key{NewKey(second)}, namespaced.Keys(""))
+	assert.Equal(t, Stats{Keys: 1, Bytes: len(first), MaxKeys: 1}, namespaced.NamespaceStats("app"))
+
+	// Quota of namespace doesn't limit other namespaces
+	third := []byte("third")
+	assert.Equal(t, ErrFull, s.Store(app, NewKey(third), third, expiration, expiration, true))
+	assert.NoError(t, s.Store(ctx, NewKey(third), third, expiration, expiration, true))
+
+	// Key stays in its namespace when it is stored again, e.g. on replication
+	assert.NoError(t, s.StoreBatch(ctx, []Entry{{Key: NewKey(first), Data: first, Expiration: expiration}}, false))
+	namespace, _ = namespaced.Namespace(NewKey(first))
+	assert.Equal(t, "app", namespace)
+
+	// Namespaces are restored from wrapped store
+	restored := NewNamespacedStore(wrapped, nil).(Namespaced)
+	assert.Equal(t, []Key{NewKey(first)}, restored.Keys("app"))
+
+	assert.NoError(t, s.Delete(ctx, NewKey(first)))
+	assert.Empty(t, namespaced.Keys("app"))
+	assert.Equal(t, Stats{MaxKeys: 1}, namespaced.NamespaceStats("app"))
+}
+
+func TestDecodeNamespaced(t *testing.T) {
+	namespace, data, err := decodeNamespaced(encodeNamespaced("app", []byte("data")))
+	assert.NoError(t, err)
+	assert.Equal(t, "app", namespace)
+	assert.Equal(t, []byte("data"), data)
+
+	_, _, err = decodeNamespaced([]byte{0, 0, 1})
+	assert.Equal(t, ErrCorrupted, err)
+	_, _, err = decodeNamespaced([]byte{0, 0, 0, 5, 1})
+	assert.Equal(t, ErrCorrupted, err)
+}
@@ -0,0 +1,132 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/clock"
+)
+
+// replicationNotifier sends keys to subscribers as replication times of the keys pass.
+// Zero value is ready to use.
+type replicationNotifier struct {
+	mutex   sync.Mutex
+	waiters map[*replicationWaiter]struct{}
+}
+
+// replicationWaiter is a state of one subscriber
+type replicationWaiter struct {
+	notified map[string]time.Time
+	deadline time.Time
+	wakeup   chan struct{}
+}
+
+// stored wakes subscribers which would sleep past replication time of just stored key.
+// Store must call it under the same lock it holds while subscribers call due.
+func (rn *replicationNotifier) stored(replication time.Time) {
+	rn.mutex.Lock()
+	defer rn.mutex.Unlock()
+
+	for w := range rn.waiters {
+		if w.wakeup != nil && (w.deadline.IsZero() || replication.Before(w.deadline)) {
+			close(w.wakeup)
+			w.wakeup = nil
+		}
+	}
+}
+
+// due returns keys which replication times passed by now and which were not sent to waiter
+// for these times yet. If there are none, waiter should sleep until returned deadline
+// (zero if nothing is scheduled) or until returned channel is closed by stored.
+func (rn *replicationNotifier) due(w *replicationWaiter, replicateMap map[string]time.Time, now time.Time) ([]Key, <-chan struct{}, time.Time) {
+	var keys []Key
+	var deadline time.Time
+	for k, replication := range replicateMap {
+		if notified, ok := w.notified[k]; ok && notified.Equal(replication) {
+			continue
+		}
+		if !now.Before(replication) {
+			w.notified[k] = replication
+			keys = append(keys, Key(k))
+		} else if deadline.IsZero() || replication.Before(deadline) {
+			deadline = replication
+		}
+	}
+	for k := range w.notified {
+		if _, exists := replicateMap[k]; !exists {
+			delete(w.notified, k)
+		}
+	}
+	if len(keys) > 0 {
+		return keys, nil, time.Time{}
+	}
+
+	rn.mutex.Lock()
+	defer rn.mutex.Unlock()
+
+	w.wakeup = make(chan struct{})
+	w.deadline = deadline
+	return nil, w.wakeup, deadline
+}
+
+// notify starts subscriber which gets due keys with scan until ctx is done
+func (rn *replicationNotifier) notify(ctx context.Context, c clock.Clock, scan func(w *replicationWaiter) ([]Key, <-chan struct{}, time.Time)) <-chan []Key {
+	w := &replicationWaiter{notified: make(map[string]time.Time)}
+	rn.mutex.Lock()
+	if rn.waiters == nil {
+		rn.waiters = make(map[*replicationWaiter]struct{})
+	}
+	rn.waiters[w] = struct{}{}
+	rn.mutex.Unlock()
+
+	keys := make(chan []Key)
+	go func() {
+		defer close(keys)
+		defer func() {
+			rn.mutex.Lock()
+			delete(rn.waiters, w)
+			rn.mutex.Unlock()
+		}()
+
+		for {
+			due, wakeup, deadline := scan(w)
+			if len(due) > 0 {
+				select {
+				case keys <- due:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
+			var timer <-chan time.Time
+			if !deadline.IsZero() {
+				timer = c.After(deadline.Sub(c.Now()))
+			}
+			select {
+			case <-timer:
+			case <-wakeup:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return keys
+}
@@ -0,0 +1,64 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"time"
+)
+
+// Ownership is publisher's signed claim of value, holders let only its key delete value.
+// It is sent with the value when it is published and replicated, so holders don't trust
+// whoever stores value first.
+type Ownership struct {
+	Key        Key
+	Expiration time.Time
+	PublicKey  ed25519.PublicKey
+	Signature  []byte
+}
+
+// NewOwnership creates ownership of key until expiration signed with publisher's private key
+func NewOwnership(key Key, expiration time.Time, privateKey ed25519.PrivateKey) *Ownership {
+	ownership := &Ownership{
+		Key:        key,
+		Expiration: expiration,
+		PublicKey:  privateKey.Public().(ed25519.PublicKey),
+	}
+	ownership.Signature = ed25519.Sign(privateKey, ownership.payload())
+	return ownership
+}
+
+// Verify checks ownership signature
+func (o *Ownership) Verify() bool {
+	if len(o.PublicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(o.PublicKey, o.payload(), o.Signature)
+}
+
+func (o *Ownership) payload() []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("own")
+	writeChunk(&buffer, o.Key)
+	writeChunk(&buffer, o.PublicKey)
+	var expiration [8]byte
+	binary.BigEndian.PutUint64(expiration[:], uint64(o.Expiration.UnixNano()))
+	buffer.Write(expiration[:])
+	return buffer.Bytes()
+}
@@ -0,0 +1,78 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"time"
+
+	"github.com/insolar/network/node"
+)
+
+// ProviderRecord is a provider's signed announcement that it can serve content of key
+type ProviderRecord struct {
+	Key        Key
+	Provider   node.ID
+	Address    string
+	Expiration time.Time
+	PublicKey  ed25519.PublicKey
+	Signature  []byte
+}
+
+// NewProviderRecord creates provider record signed with provider's private key
+func NewProviderRecord(key Key, provider node.ID, address string, expiration time.Time, privateKey ed25519.PrivateKey) *ProviderRecord {
+	record := &ProviderRecord{
+		Key:        key,
+		Provider:   provider,
+		Address:    address,
+		Expiration: expiration,
+		PublicKey:  privateKey.Public().(ed25519.PublicKey),
+	}
+	record.Signature = ed25519.Sign(privateKey, record.payload())
+	return record
+}
+
+// Verify checks record signature
+func (r *ProviderRecord) Verify() bool {
+	if len(r.PublicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(r.PublicKey, r.payload(), r.Signature)
+}
+
+// Node returns provider node, nil if its address is invalid
+func (r *ProviderRecord) Node() *node.Node {
+	address, err := node.NewAddress(r.Address)
+	if err != nil {
+		return nil
+	}
+	return &node.Node{ID: r.Provider, Address: address}
+}
+
+func (r *ProviderRecord) payload() []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("provider")
+	writeChunk(&buffer, r.Key)
+	writeChunk(&buffer, r.Provider)
+	writeChunk(&buffer, []byte(r.Address))
+	var expiration [8]byte
+	binary.BigEndian.PutUint64(expiration[:], uint64(r.Expiration.UnixNano()))
+	buffer.Write(expiration[:])
+	return buffer.Bytes()
+}
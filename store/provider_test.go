@@ -0,0 +1,48 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderRecord_Verify(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	key := NewKey([]byte("content"))
+	record := NewProviderRecord(key, []byte("provider"), "127.0.0.1:3000", time.Now().Add(time.Minute), privateKey)
+	assert.True(t, record.Verify())
+	assert.Equal(t, "127.0.0.1:3000", record.Node().Address.String())
+
+	forged := *record
+	forged.Key = NewKey([]byte("other"))
+	assert.False(t, forged.Verify())
+
+	forged = *record
+	forged.Address = "127.0.0.1:3001"
+	assert.False(t, forged.Verify())
+
+	forged = *record
+	forged.Address = "invalid"
+	assert.Nil(t, forged.Node())
+}
@@ -0,0 +1,413 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/clock"
+)
+
+// EvictionOrder decides which values are evicted first when store exceeds its quota
+type EvictionOrder int
+
+const (
+	// EvictLeastRecentlyUsed evicts values stored or retrieved the longest time ago
+	EvictLeastRecentlyUsed = EvictionOrder(iota)
+
+	// EvictExpiringFirst evicts values closest to their expiration
+	EvictExpiringFirst
+)
+
+// Quota limits size of store, zero limit means unlimited
+type Quota struct {
+	MaxKeys  int
+	MaxBytes int
+	Eviction EvictionOrder
+}
+
+// quotaEntry is size and expiration of stored value kept in order of use
+type quotaEntry struct {
+	key        Key
+	size       int
+	expiration time.Time
+}
+
+// quotaStore wraps store evicting values to stay within quota
+type quotaStore struct {
+	store   Store
+	quota   Quota
+	mutex   *sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	stats   Stats
+	clock   clock.Clock
+	// evict removes victim from wrapped store, called under mutex
+	evict func(ctx context.Context, key Key) error
+}
+
+// notifyingQuotaStore is quotaStore around store which notifies about keys ready to replicate
+type notifyingQuotaStore struct {
+	*quotaStore
+	notifier ReplicationNotifier
+}
+
+// NotifyReplication returns channel receiving keys as their replication times pass
+func (ns *notifyingQuotaStore) NotifyReplication(ctx context.Context) <-chan []Key {
+	return ns.notifier.NotifyReplication(ctx)
+}
+
+// NewQuotaStore wraps store to keep its size within quota. Values are evicted to make room for new ones,
+// ErrTooLarge is returned for value exceeding MaxBytes. Values already kept in store are counted
+// if it implements Enumerator, so quota should wrap store before it is used by network.
+func NewQuotaStore(store Store, quota Quota) Store {
+	qs := newQuotaStore(store, quota)
+	if notifier, ok := store.(ReplicationNotifier); ok {
+		return &notifyingQuotaStore{quotaStore: qs, notifier: notifier}
+	}
+	return qs
+}
+
+// NewMemoryStoreWithQuota creates new memory store limited by quota
+func NewMemoryStoreWithQuota(quota Quota) Store {
+	return NewQuotaStore(newMemoryStore(), quota)
+}
+
+func newQuotaStore(store Store, quota Quota) *quotaStore {
+	qs := &quotaStore{
+		store:   store,
+		quota:   quota,
+		mutex:   &sync.Mutex{},
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		clock:   clock.New(),
+	}
+	qs.evict = store.Delete
+	if enumerator, ok := store.(Enumerator); ok {
+		for _, entry := range enumerator.Entries() {
+			qs.add(entry.Key, len(entry.Data), entry.Expiration)
+		}
+	}
+	return qs
+}
+
+// add records value as the most recently used one, must be called under mutex
+func (qs *quotaStore) add(key Key, size int, expiration time.Time) {
+	qs.remove(key)
+	qs.entries[key.String()] = qs.lru.PushBack(&quotaEntry{key: key, size: size, expiration: expiration})
+	qs.stats.Keys++
+	qs.stats.Bytes += size
+}
+
+// remove forgets value, must be called under mutex
+func (qs *quotaStore) remove(key Key) {
+	element, ok := qs.entries[key.String()]
+	if !ok {
+		return
+	}
+	entry := qs.lru.Remove(element).(*quotaEntry)
+	delete(qs.entries, key.String())
+	qs.stats.Keys--
+	qs.stats.Bytes -= entry.size
+}
+
+// victim returns value to evict next, nil if there is none. Values of kept keys are not evicted.
+func (qs *quotaStore) victim(key Key, keep map[string]bool) *quotaEntry {
+	var victim *quotaEntry
+	for element := qs.lru.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*quotaEntry)
+		if bytes.Equal(entry.key, key) || keep[entry.key.String()] {
+			continue
+		}
+		if qs.quota.Eviction == EvictLeastRecentlyUsed {
+			return entry
+		}
+		if victim == nil || entry.expiration.Before(victim.expiration) {
+			victim = entry
+		}
+	}
+	return victim
+}
+
+// exceeds checks if storing value of given size under key would exceed quota, must be called under mutex
+func (qs *quotaStore) exceeds(key Key, size int) bool {
+	keys, bytes := qs.stats.Keys+1, qs.stats.Bytes+size
+	if element, ok := qs.entries[key.String()]; ok {
+		keys--
+		bytes -= element.Value.(*quotaEntry).size
+	}
+	return (qs.quota.MaxKeys > 0 && keys > qs.quota.MaxKeys) || (qs.quota.MaxBytes > 0 && bytes > qs.quota.MaxBytes)
+}
+
+// makeRoom evicts values until value of given size fits into quota, must be called under mutex
+func (qs *quotaStore) makeRoom(ctx context.Context, key Key, size int, keep map[string]bool) error {
+	if qs.quota.MaxBytes > 0 && size > qs.quota.MaxBytes {
+		return ErrTooLarge
+	}
+	for qs.exceeds(key, size) {
+		victim := qs.victim(key, keep)
+		if victim == nil {
+			return ErrFull
+		}
+		if err := qs.evict(ctx, victim.key); err != nil {
+			return err
+		}
+		qs.remove(victim.key)
+	}
+	return nil
+}
+
+// Store will store a key/value pair for the local node with the given
+// replication and expiration times.
+func (qs *quotaStore) Store(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, publisher bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	qs.mutex.Lock()
+	defer qs.mutex.Unlock()
+
+	if err := qs.makeRoom(ctx, key, len(data), nil); err != nil {
+		return err
+	}
+	if err := qs.store.Store(ctx, key, data, replication, expiration, publisher); err != nil {
+		return err
+	}
+	qs.add(key, len(data), expiration)
+	return nil
+}
+
+// StoreVersion stores key/value pair unless newer version of it is stored already.
+// Version is ignored if wrapped store doesn't keep versions.
+func (qs *quotaStore) StoreVersion(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, version Version) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	versioned, ok := qs.store.(Versioned)
+	if !ok {
+		return true, qs.Store(ctx, key, data, replication, expiration, true)
+	}
+
+	qs.mutex.Lock()
+	defer qs.mutex.Unlock()
+
+	// Values are not evicted for version which is going to be rejected
+	stored, found, err := versioned.Version(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if found && stored.Newer(version) {
+		return false, nil
+	}
+	if err := qs.makeRoom(ctx, key, len(data), nil); err != nil {
+		return false, err
+	}
+	ok, err = versioned.StoreVersion(ctx, key, data, replication, expiration, version)
+	if ok && err == nil {
+		qs.add(key, len(data), expiration)
+	}
+	return ok, err
+}
+
+// Version returns version of stored value
+func (qs *quotaStore) Version(ctx context.Context, key Key) (Version, bool, error) {
+	if versioned, ok := qs.store.(Versioned); ok {
+		return versioned.Version(ctx, key)
+	}
+	_, found, err := qs.store.Retrieve(ctx, key)
+	return Version{}, found, err
+}
+
+// Retrieve will return the local key/value if it exists, marking it as recently used
+func (qs *quotaStore) Retrieve(ctx context.Context, key Key) ([]byte, bool, error) {
+	data, found, err := qs.store.Retrieve(ctx, key)
+	if found && err == nil {
+		qs.mutex.Lock()
+		if element, ok := qs.entries[key.String()]; ok {
+			qs.lru.MoveToBack(element)
+		}
+		qs.mutex.Unlock()
+	}
+	return data, found, err
+}
+
+// StoreBatch stores entries evicting values to make room for them, values of the batch aren't evicted
+func (qs *quotaStore) StoreBatch(ctx context.Context, entries []Entry, publisher bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	qs.mutex.Lock()
+	defer qs.mutex.Unlock()
+
+	keep := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		keep[entry.Key.String()] = true
+	}
+	for i, entry := range entries {
+		if err := qs.makeRoom(ctx, entry.Key, len(entry.Data), keep); err != nil {
+			qs.recount(ctx, entries[:i])
+			return err
+		}
+		qs.add(entry.Key, len(entry.Data), entry.Expiration)
+	}
+	if err := qs.store.StoreBatch(ctx, entries, publisher); err != nil {
+		qs.recount(ctx, entries)
+		return err
+	}
+	return nil
+}
+
+// recount counts values of batch which failed to be stored as they are kept in wrapped store,
+// must be called under mutex
+func (qs *quotaStore) recount(ctx context.Context, entries []Entry) {
+	keys := make([]Key, 0, len(entries))
+	for _, entry := range entries {
+		qs.remove(entry.Key)
+		keys = append(keys, entry.Key)
+	}
+	stored, err := qs.store.RetrieveBatch(ctx, keys)
+	if err != nil {
+		return
+	}
+	for _, entry := range stored {
+		qs.add(entry.Key, len(entry.Data), entry.Expiration)
+	}
+}
+
+// RetrieveBatch returns entries of keys which exist in wrapped store, marking them as recently used
+func (qs *quotaStore) RetrieveBatch(ctx context.Context, keys []Key) ([]Entry, error) {
+	entries, err := qs.store.RetrieveBatch(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	qs.mutex.Lock()
+	defer qs.mutex.Unlock()
+
+	for _, entry := range entries {
+		if element, ok := qs.entries[entry.Key.String()]; ok {
+			qs.lru.MoveToBack(element)
+		}
+	}
+	return entries, nil
+}
+
+// Delete deletes a key/value pair from wrapped store
+func (qs *quotaStore) Delete(ctx context.Context, key Key) error {
+	qs.mutex.Lock()
+	defer qs.mutex.Unlock()
+
+	if err := qs.store.Delete(ctx, key); err != nil {
+		return err
+	}
+	qs.remove(key)
+	return nil
+}
+
+// GetKeysReadyToReplicate should return the keys of all data to be
+// replicated across the network. Typically all data should be
+// replicated every tReplicate seconds.
+func (qs *quotaStore) GetKeysReadyToReplicate(ctx context.Context) ([]Key, error) {
+	return qs.store.GetKeysReadyToReplicate(ctx)
+}
+
+// ExpireKeys should expire all key/values due for expiration.
+func (qs *quotaStore) ExpireKeys(ctx context.Context) error {
+	qs.mutex.Lock()
+	defer qs.mutex.Unlock()
+
+	if err := qs.store.ExpireKeys(ctx); err != nil {
+		return err
+	}
+	now := qs.clock.Now()
+	for element := qs.lru.Front(); element != nil; {
+		entry := element.Value.(*quotaEntry)
+		element = element.Next()
+		if !now.After(entry.expiration) {
+			continue
+		}
+		// Wrapped store may check expiration with its own clock
+		_, found, err := qs.store.Retrieve(ctx, entry.key)
+		if err != nil {
+			return err
+		}
+		if !found {
+			qs.remove(entry.key)
+		}
+	}
+	return nil
+}
+
+// Stats returns number of stored keys and total size of values with limits of quota.
+// Expirations and keys pending replication are reported by wrapped store.
+func (qs *quotaStore) Stats() Stats {
+	qs.mutex.Lock()
+	stats := qs.stats
+	qs.mutex.Unlock()
+
+	if reporter, ok := qs.store.(StatsReporter); ok {
+		wrapped := reporter.Stats()
+		stats.Expirations = wrapped.Expirations
+		stats.PendingReplication = wrapped.PendingReplication
+	}
+	stats.MaxKeys = qs.quota.MaxKeys
+	stats.MaxBytes = qs.quota.MaxBytes
+	return stats
+}
+
+// Entries returns all stored key/value pairs, nil if wrapped store can't list them
+func (qs *quotaStore) Entries() []Entry {
+	if enumerator, ok := qs.store.(Enumerator); ok {
+		return enumerator.Entries()
+	}
+	return nil
+}
+
+type quotaStoreFactory struct {
+	Factory
+	quota Quota
+}
+
+// NewQuotaStoreFactory creates factory of storages created by given factory and limited by quota
+func NewQuotaStoreFactory(factory Factory, quota Quota) Factory {
+	return &quotaStoreFactory{Factory: factory, quota: quota}
+}
+
+// Create returns new storage limited by quota
+func (quotaStoreFactory *quotaStoreFactory) Create() Store {
+	return NewQuotaStore(quotaStoreFactory.Factory.Create(), quotaStoreFactory.quota)
+}
+
+// Close closes wrapped factory if it holds resources
+func (quotaStoreFactory *quotaStoreFactory) Close() error {
+	if closer, ok := quotaStoreFactory.Factory.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// KeyHash returns hash keys of wrapped factory are derived with
+func (quotaStoreFactory *quotaStoreFactory) KeyHash() KeyHash {
+	return KeyHashOf(quotaStoreFactory.Factory)
+}
@@ -0,0 +1,135 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/clock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotaStore_EvictLeastRecentlyUsed(t *testing.T) {
+	s := NewMemoryStoreWithQuota(Quota{MaxKeys: 2})
+	ctx := context.Background()
+	expiration := time.Now().Add(time.Hour)
+
+	first, second, third := []byte("first"), []byte("second"), []byte("third")
+	assert.NoError(t, s.Store(ctx, NewKey(first), first, expiration, expiration, true))
+	assert.NoError(t, s.Store(ctx, NewKey(second), second, expiration, expiration, true))
+	// First value becomes the most recently used one
+	_, found, err := s.Retrieve(ctx, NewKey(first))
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	assert.NoError(t, s.Store(ctx, NewKey(third), third, expiration, expiration, true))
+	_, found, _ = s.Retrieve(ctx, NewKey(first))
+	assert.True(t, found)
+	_, found, _ = s.Retrieve(ctx, NewKey(second))
+	assert.False(t, found)
+	_, found, _ = s.Retrieve(ctx, NewKey(third))
+	assert.True(t, found)
+	assert.Equal(t, Stats{Keys: 2, Bytes: len(first) + len(third), MaxKeys: 2}, s.(StatsReporter).Stats())
+	assert.Implements(t, (*ReplicationNotifier)(nil), s)
+}
+
+func TestQuotaStore_StoreBatch(t *testing.T) {
+	s := NewMemoryStoreWithQuota(Quota{MaxKeys: 2})
+	ctx := context.Background()
+	expiration := time.Now().Add(time.Hour)
+
+	old, first, second := []byte("old"), []byte("first"), []byte("second")
+	assert.NoError(t, s.Store(ctx, NewKey(old), old, expiration, expiration, true))
+	// Values of batch don't evict each other
+	err := s.StoreBatch(ctx, []Entry{
+		{Key: NewKey(first), Data: first, Replication: expiration, Expiration: expiration},
+		{Key: NewKey(second), Data: second, Replication: expiration, Expiration: expiration},
+	}, true)
+	assert.NoError(t, err)
+
+	entries, err := s.RetrieveBatch(ctx, []Key{NewKey(old), NewKey(first), NewKey(second)})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, Stats{Keys: 2, Bytes: len(first) + len(second), MaxKeys: 2}, s.(StatsReporter).Stats())
+
+	err = s.StoreBatch(ctx, []Entry{
+		{Key: NewKey(old), Data: old, Replication: expiration, Expiration: expiration},
+		{Key: NewKey(first), Data: first, Replication: expiration, Expiration: expiration},
+		{Key: NewKey(second), Data: second, Replication: expiration, Expiration: expiration},
+	}, true)
+	assert.Equal(t, ErrFull, err)
+	assert.Equal(t, Stats{Keys: 2, Bytes: len(first) + len(second), MaxKeys: 2}, s.(StatsReporter).Stats())
+}
+
+func TestQuotaStore_EvictExpiringFirst(t *testing.T) {
+	s := NewMemoryStoreWithQuota(Quota{MaxBytes: 10, Eviction: EvictExpiringFirst})
+	ctx := context.Background()
+	now := time.Now()
+
+	late, soon, next := []byte("late"), []byte("soon"), []byte("next")
+	assert.NoError(t, s.Store(ctx, NewKey(late), late, now, now.Add(2*time.Hour), true))
+	assert.NoError(t, s.Store(ctx, NewKey(soon), soon, now, now.Add(time.Hour), true))
+	assert.NoError(t, s.Store(ctx, NewKey(next), next, now, now.Add(3*time.Hour), true))
+
+	_, found, _ := s.Retrieve(ctx, NewKey(soon))
+	assert.False(t, found)
+	_, found, _ = s.Retrieve(ctx, NewKey(late))
+	assert.True(t, found)
+
+	assert.Equal(t, ErrTooLarge, s.Store(ctx, NewKey([]byte("too large value")), []byte("too large value"), now, now, true))
+}
+
+func TestQuotaStore_StoreVersion(t *testing.T) {
+	s := NewMemoryStoreWithQuota(Quota{MaxKeys: 1})
+	ctx := context.Background()
+	expiration := time.Now().Add(time.Hour)
+
+	data, other := []byte("some data"), []byte("other data")
+	older := NewVersion(time.Now(), []byte("a"))
+	newer := NewVersion(older.Timestamp.Add(time.Second), []byte("a"))
+
+	stored, err := s.(Versioned).StoreVersion(ctx, NewKey(data), data, expiration, expiration, newer)
+	assert.NoError(t, err)
+	assert.True(t, stored)
+
+	// Rejected version doesn't evict anything
+	stored, err = s.(Versioned).StoreVersion(ctx, NewKey(data), other, expiration, expiration, older)
+	assert.NoError(t, err)
+	assert.False(t, stored)
+	assert.Equal(t, Stats{Keys: 1, Bytes: len(data), MaxKeys: 1}, s.(StatsReporter).Stats())
+}
+
+func TestQuotaStore_CountsStoredValues(t *testing.T) {
+	ms := newMemoryStore()
+	virtual := clock.NewVirtual(time.Now())
+	ms.clock = virtual
+	ctx := context.Background()
+
+	data := []byte("some data")
+	assert.NoError(t, ms.Store(ctx, NewKey(data), data, virtual.Now(), virtual.Now().Add(time.Hour), true))
+
+	s := newQuotaStore(ms, Quota{MaxKeys: 1})
+	s.clock = virtual
+	assert.Equal(t, Stats{Keys: 1, Bytes: len(data), MaxKeys: 1}, s.Stats())
+
+	virtual.Advance(2 * time.Hour)
+	assert.NoError(t, s.ExpireKeys(ctx))
+	assert.Equal(t, Stats{Expirations: 1, MaxKeys: 1}, s.Stats())
+}
@@ -0,0 +1,100 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"time"
+
+	"github.com/insolar/network/node"
+)
+
+// Receipt is a holder's signed confirmation of accepted Store operation.
+// Publisher can retain it as a proof of placement.
+type Receipt struct {
+	Key        Key
+	Holder     node.ID
+	Expiration time.Time
+	PublicKey  ed25519.PublicKey
+	Signature  []byte
+}
+
+// NewReceipt creates receipt signed with holder's private key
+func NewReceipt(key Key, holder node.ID, expiration time.Time, privateKey ed25519.PrivateKey) *Receipt {
+	receipt := &Receipt{
+		Key:        key,
+		Holder:     holder,
+		Expiration: expiration,
+		PublicKey:  privateKey.Public().(ed25519.PublicKey),
+	}
+	receipt.Signature = ed25519.Sign(privateKey, receipt.payload())
+	return receipt
+}
+
+// Verify checks receipt signature
+func (r *Receipt) Verify() bool {
+	if len(r.PublicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(r.PublicKey, r.payload(), r.Signature)
+}
+
+// VerifyChallenge checks that challenge response is signed by the receipt holder
+func (r *Receipt) VerifyChallenge(nonce []byte, holds bool, signature []byte) bool {
+	if len(r.PublicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(r.PublicKey, challengePayload(r.Key, nonce, holds), signature)
+}
+
+// SignChallenge signs holder's answer to a receipt challenge
+func SignChallenge(key Key, nonce []byte, holds bool, privateKey ed25519.PrivateKey) []byte {
+	return ed25519.Sign(privateKey, challengePayload(key, nonce, holds))
+}
+
+func (r *Receipt) payload() []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("receipt")
+	writeChunk(&buffer, r.Key)
+	writeChunk(&buffer, r.Holder)
+	var expiration [8]byte
+	binary.BigEndian.PutUint64(expiration[:], uint64(r.Expiration.UnixNano()))
+	buffer.Write(expiration[:])
+	return buffer.Bytes()
+}
+
+func challengePayload(key Key, nonce []byte, holds bool) []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("challenge")
+	writeChunk(&buffer, key)
+	writeChunk(&buffer, nonce)
+	if holds {
+		buffer.WriteByte(1)
+	} else {
+		buffer.WriteByte(0)
+	}
+	return buffer.Bytes()
+}
+
+func writeChunk(buffer *bytes.Buffer, chunk []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(chunk)))
+	buffer.Write(length[:])
+	buffer.Write(chunk)
+}
@@ -0,0 +1,73 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReceipt(t *testing.T) {
+	_, privateKey, _ := ed25519.GenerateKey(nil)
+	holder, _ := node.NewID()
+	key := NewKey([]byte("foo"))
+	expiration := time.Now().Add(time.Hour)
+
+	receipt := NewReceipt(key, holder, expiration, privateKey)
+
+	assert.Equal(t, key, receipt.Key)
+	assert.Equal(t, holder, receipt.Holder)
+	assert.Equal(t, privateKey.Public(), receipt.PublicKey)
+	assert.True(t, receipt.Verify())
+}
+
+func TestReceipt_Verify_Tampered(t *testing.T) {
+	_, privateKey, _ := ed25519.GenerateKey(nil)
+	holder, _ := node.NewID()
+
+	receipt := NewReceipt(NewKey([]byte("foo")), holder, time.Now(), privateKey)
+	receipt.Expiration = receipt.Expiration.Add(time.Hour)
+	assert.False(t, receipt.Verify())
+
+	receipt = NewReceipt(NewKey([]byte("foo")), holder, time.Now(), privateKey)
+	receipt.Key = NewKey([]byte("bar"))
+	assert.False(t, receipt.Verify())
+
+	receipt = NewReceipt(NewKey([]byte("foo")), holder, time.Now(), privateKey)
+	receipt.PublicKey = nil
+	assert.False(t, receipt.Verify())
+}
+
+func TestReceipt_VerifyChallenge(t *testing.T) {
+	_, privateKey, _ := ed25519.GenerateKey(nil)
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+	holder, _ := node.NewID()
+	key := NewKey([]byte("foo"))
+	nonce := []byte("nonce")
+
+	receipt := NewReceipt(key, holder, time.Now(), privateKey)
+
+	assert.True(t, receipt.VerifyChallenge(nonce, true, SignChallenge(key, nonce, true, privateKey)))
+	assert.False(t, receipt.VerifyChallenge(nonce, true, SignChallenge(key, nonce, false, privateKey)))
+	assert.False(t, receipt.VerifyChallenge([]byte("other"), true, SignChallenge(key, nonce, true, privateKey)))
+	assert.False(t, receipt.VerifyChallenge(nonce, true, SignChallenge(key, nonce, true, otherKey)))
+}
@@ -0,0 +1,66 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"encoding/gob"
+	"time"
+)
+
+// storedRecord is a stored value with its metadata as it is persisted by disk-backed stores
+type storedRecord struct {
+	Data        []byte
+	Replication time.Time
+	Expiration  time.Time
+	Version     Version
+}
+
+func encodeRecord(record *storedRecord) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(record); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func decodeRecord(raw []byte) (*storedRecord, error) {
+	record := &storedRecord{}
+	if err := gob.NewDecoder(bytes.NewReader(raw)).Decode(record); err != nil {
+		return nil, ErrCorrupted
+	}
+	return record, nil
+}
+
+func recordOf(entry Entry) *storedRecord {
+	return &storedRecord{
+		Data:        entry.Data,
+		Replication: entry.Replication,
+		Expiration:  entry.Expiration,
+		Version:     entry.Version,
+	}
+}
+
+func entryOf(key Key, record *storedRecord) Entry {
+	return Entry{
+		Key:         key,
+		Data:        record.Data,
+		Replication: record.Replication,
+		Expiration:  record.Expiration,
+		Version:     record.Version,
+	}
+}
@@ -0,0 +1,355 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/insolar/network/clock"
+)
+
+// Key layout of Redis store. Every value is kept with its metadata under namespace+"v:"+key
+// and expires in Redis itself, replication and expiration times are indexed in sorted sets
+// scored by milliseconds, so due keys are read with range queries.
+const (
+	redisValuePrefix       = "v:"
+	redisReplicationSuffix = "replication"
+	redisExpirationSuffix  = "expiration"
+)
+
+// RedisOptions are connection options of Redis store
+type RedisOptions struct {
+	// Address is host:port of Redis server
+	Address string
+	// Password is optional password of Redis server
+	Password string
+	// DB is number of Redis database
+	DB int
+	// Namespace prefixes all keys of store, so several networks can share one database
+	Namespace string
+}
+
+// redisStore is a key/value store kept in Redis, so several stateless nodes can share one storage
+type redisStore struct {
+	client    *redis.Client
+	namespace string
+	clock     clock.Clock
+}
+
+// NewRedisStore connects to Redis server and returns store kept in it
+func NewRedisStore(options *RedisOptions) (Store, error) {
+	client, err := openRedis(options)
+	if err != nil {
+		return nil, err
+	}
+	return newRedisStore(client, options.Namespace), nil
+}
+
+func openRedis(options *RedisOptions) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     options.Address,
+		Password: options.Password,
+		DB:       options.DB,
+	})
+	if err := client.Ping().Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+func newRedisStore(client *redis.Client, namespace string) *redisStore {
+	return &redisStore{
+		client:    client,
+		namespace: namespace,
+		clock:     clock.New(),
+	}
+}
+
+func (rs *redisStore) valueKey(key Key) string {
+	return rs.namespace + redisValuePrefix + string(key)
+}
+
+func (rs *redisStore) indexKey(suffix string) string {
+	return rs.namespace + suffix
+}
+
+func score(t time.Time) float64 {
+	return float64(t.UnixNano() / int64(time.Millisecond))
+}
+
+// get returns stored record of key, nil if there is none
+func (rs *redisStore) get(getter func(string) *redis.StringCmd, key Key) (*storedRecord, error) {
+	raw, err := getter(rs.valueKey(key)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeRecord(raw)
+}
+
+// put queues writes of record and its index entries to pipe
+func (rs *redisStore) put(pipe redis.Pipeliner, key Key, record *storedRecord) error {
+	raw, err := encodeRecord(record)
+	if err != nil {
+		return err
+	}
+	pipe.Set(rs.valueKey(key), raw, 0)
+	pipe.PExpireAt(rs.valueKey(key), record.Expiration)
+	pipe.ZAdd(rs.indexKey(redisReplicationSuffix), redis.Z{Score: score(record.Replication), Member: string(key)})
+	pipe.ZAdd(rs.indexKey(redisExpirationSuffix), redis.Z{Score: score(record.Expiration), Member: string(key)})
+	return nil
+}
+
+// remove queues deletion of key and its index entries to pipe
+func (rs *redisStore) remove(pipe redis.Pipeliner, key Key) {
+	pipe.Del(rs.valueKey(key))
+	pipe.ZRem(rs.indexKey(redisReplicationSuffix), string(key))
+	pipe.ZRem(rs.indexKey(redisExpirationSuffix), string(key))
+}
+
+// watch runs fn in transaction watching value of key, retrying it if value was changed by other node
+func (rs *redisStore) watch(key Key, fn func(tx *redis.Tx) error) error {
+	for {
+		err := rs.client.Watch(fn, rs.valueKey(key))
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+}
+
+// Store will store a key/value pair for the local node with the given
+// replication and expiration times.
+func (rs *redisStore) Store(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, publisher bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	_, err := rs.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		return rs.put(pipe, key, &storedRecord{
+			Data:        data,
+			Replication: replication,
+			Expiration:  expiration,
+		})
+	})
+	return err
+}
+
+// StoreVersion stores key/value pair unless newer version of it is stored already
+func (rs *redisStore) StoreVersion(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, version Version) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	stored := false
+	err := rs.watch(key, func(tx *redis.Tx) error {
+		stored = false
+		old, err := rs.get(tx.Get, key)
+		if err != nil && err != ErrCorrupted {
+			return err
+		}
+		if old != nil && old.Version.Newer(version) {
+			return nil
+		}
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			return rs.put(pipe, key, &storedRecord{
+				Data:        data,
+				Replication: replication,
+				Expiration:  expiration,
+				Version:     version,
+			})
+		})
+		stored = err == nil
+		return err
+	})
+	return stored, err
+}
+
+// Version returns version of stored value
+func (rs *redisStore) Version(ctx context.Context, key Key) (Version, bool, error) {
+	if ctx.Err() != nil {
+		return Version{}, false, ctx.Err()
+	}
+
+	record, err := rs.get(rs.client.Get, key)
+	if err != nil || record == nil {
+		return Version{}, false, err
+	}
+	return record.Version, true, nil
+}
+
+// Retrieve will return the local key/value if it exists
+func (rs *redisStore) Retrieve(ctx context.Context, key Key) ([]byte, bool, error) {
+	if ctx.Err() != nil {
+		return nil, false, ctx.Err()
+	}
+
+	record, err := rs.get(rs.client.Get, key)
+	if err != nil || record == nil {
+		return nil, false, err
+	}
+	return record.Data, true, nil
+}
+
+// StoreBatch stores entries with their replication and expiration times and versions in one transaction
+func (rs *redisStore) StoreBatch(ctx context.Context, entries []Entry, publisher bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	_, err := rs.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		for _, entry := range entries {
+			if err := rs.put(pipe, entry.Key, recordOf(entry)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// RetrieveBatch returns entries of keys which exist in the redisStore, records which can't be read are skipped
+func (rs *redisStore) RetrieveBatch(ctx context.Context, keys []Key) ([]Entry, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	valueKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		valueKeys = append(valueKeys, rs.valueKey(key))
+	}
+	values, err := rs.client.MGet(valueKeys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(keys))
+	for i, value := range values {
+		raw, ok := value.(string)
+		if !ok {
+			continue
+		}
+		record, err := decodeRecord([]byte(raw))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entryOf(keys[i], record))
+	}
+	return entries, nil
+}
+
+// Delete deletes a key/value pair from the redisStore
+func (rs *redisStore) Delete(ctx context.Context, key Key) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	_, err := rs.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		rs.remove(pipe, key)
+		return nil
+	})
+	return err
+}
+
+// due returns up to maxBatchSize keys of index with times before now
+func (rs *redisStore) due(suffix string, now time.Time) ([]string, error) {
+	return rs.client.ZRangeByScore(rs.indexKey(suffix), redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   "(" + strconv.FormatFloat(score(now), 'f', -1, 64),
+		Count: maxBatchSize,
+	}).Result()
+}
+
+// GetKeysReadyToReplicate should return the keys of all data to be
+// replicated across the network. Typically all data should be
+// replicated every tReplicate seconds.
+func (rs *redisStore) GetKeysReadyToReplicate(ctx context.Context) ([]Key, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	members, err := rs.due(redisReplicationSuffix, rs.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]Key, 0, len(members))
+	for _, member := range members {
+		keys = append(keys, Key(member))
+	}
+	return keys, nil
+}
+
+// ExpireKeys should expire all key/values due for expiration.
+// Redis drops expired values itself, so mostly their index entries are removed here.
+func (rs *redisStore) ExpireKeys(ctx context.Context) error {
+	_, err := rs.ExpireKeysBatch(ctx, 0)
+	return err
+}
+
+// ExpireKeysBatch expires at most max key/values due for expiration, all of them if max is not positive
+func (rs *redisStore) ExpireKeysBatch(ctx context.Context, max int) (bool, error) {
+	expired := 0
+	for {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+
+		now := rs.clock.Now()
+		members, err := rs.due(redisExpirationSuffix, now)
+		if err != nil || len(members) == 0 {
+			return false, err
+		}
+		if max > 0 && expired >= max {
+			return true, nil
+		}
+		if max > 0 && len(members) > max-expired {
+			members = members[:max-expired]
+		}
+		for _, member := range members {
+			if err := rs.expire(Key(member), now); err != nil {
+				return false, err
+			}
+		}
+		expired += len(members)
+	}
+}
+
+// expire removes key unless other node stored it again with later expiration
+func (rs *redisStore) expire(key Key, now time.Time) error {
+	return rs.watch(key, func(tx *redis.Tx) error {
+		record, err := rs.get(tx.Get, key)
+		if err != nil && err != ErrCorrupted {
+			return err
+		}
+		if record != nil && !now.After(record.Expiration) {
+			return nil
+		}
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			rs.remove(pipe, key)
+			return nil
+		})
+		return err
+	})
+}
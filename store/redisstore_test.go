@@ -0,0 +1,171 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/insolar/network/clock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createRedisStore(t *testing.T, server *miniredis.Miniredis, namespace string) (*redisStore, func()) {
+	client, err := openRedis(&RedisOptions{Address: server.Addr()})
+	assert.NoError(t, err)
+	return newRedisStore(client, namespace), func() { client.Close() }
+}
+
+func TestNewRedisStore(t *testing.T) {
+	_, err := NewRedisStore(&RedisOptions{Address: "127.0.0.1:1"})
+	assert.Error(t, err)
+}
+
+func TestRedisStore_StoreRetrieve(t *testing.T) {
+	server, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer server.Close()
+
+	s, closeStore := createRedisStore(t, server, "")
+	defer closeStore()
+	ctx := context.Background()
+
+	data := []byte("some data")
+	key := NewKey(data)
+
+	err = s.Store(ctx, key, data, time.Now().Add(time.Hour), time.Now().Add(time.Hour), true)
+	assert.NoError(t, err)
+
+	retrieved, found, err := s.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, data, retrieved)
+
+	// Value expires in Redis itself
+	server.FastForward(2 * time.Hour)
+	_, found, err = s.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	err = s.Store(ctx, key, data, time.Now().Add(time.Hour), time.Now().Add(time.Hour), true)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Delete(ctx, key))
+	_, found, err = s.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestRedisStore_Shared(t *testing.T) {
+	server, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer server.Close()
+
+	s1, closeStore1 := createRedisStore(t, server, "network:")
+	defer closeStore1()
+	s2, closeStore2 := createRedisStore(t, server, "network:")
+	defer closeStore2()
+	other, closeOther := createRedisStore(t, server, "other:")
+	defer closeOther()
+	ctx := context.Background()
+
+	data := []byte("some data")
+	key := NewKey(data)
+	assert.NoError(t, s1.Store(ctx, key, data, time.Now().Add(time.Hour), time.Now().Add(time.Hour), true))
+	assert.True(t, server.Exists("network:v:"+string(key)))
+
+	retrieved, found, err := s2.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, data, retrieved)
+
+	_, found, err = other.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestRedisStore_StoreVersion(t *testing.T) {
+	server, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer server.Close()
+
+	s, closeStore := createRedisStore(t, server, "")
+	defer closeStore()
+	ctx := context.Background()
+
+	data := []byte("some data")
+	key := NewKey(data)
+	older := NewVersion(time.Now(), []byte("a"))
+	newer := NewVersion(older.Timestamp.Add(time.Second), []byte("a"))
+
+	stored, err := s.StoreVersion(ctx, key, data, time.Now(), time.Now().Add(time.Hour), newer)
+	assert.NoError(t, err)
+	assert.True(t, stored)
+
+	stored, err = s.StoreVersion(ctx, key, data, time.Now(), time.Now().Add(time.Hour), older)
+	assert.NoError(t, err)
+	assert.False(t, stored)
+
+	version, found, err := s.Version(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.True(t, newer.Timestamp.Equal(version.Timestamp))
+}
+
+func TestRedisStore_ExpireKeys(t *testing.T) {
+	server, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer server.Close()
+
+	s, closeStore := createRedisStore(t, server, "")
+	defer closeStore()
+	virtual := clock.NewVirtual(time.Now())
+	s.clock = virtual
+	ctx := context.Background()
+
+	data := []byte("some data")
+	key := NewKey(data)
+	other := []byte("other data")
+	otherKey := NewKey(other)
+	s.Store(ctx, key, data, virtual.Now().Add(time.Minute), virtual.Now().Add(time.Hour), true)
+	s.Store(ctx, otherKey, other, virtual.Now().Add(time.Hour), virtual.Now().Add(3*time.Hour), true)
+
+	keys, err := s.GetKeysReadyToReplicate(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	virtual.Advance(2 * time.Minute)
+	keys, err = s.GetKeysReadyToReplicate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []Key{key}, keys)
+
+	virtual.Advance(2 * time.Hour)
+	assert.NoError(t, s.ExpireKeys(ctx))
+	_, found, err := s.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+	_, found, err = s.Retrieve(ctx, otherKey)
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	// Index entries of expired key are removed with it
+	keys, err = s.GetKeysReadyToReplicate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []Key{otherKey}, keys)
+}
@@ -0,0 +1,79 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"time"
+
+	"github.com/insolar/network/node"
+)
+
+// ServiceRecord is a publisher's signed announcement of address of service instance
+type ServiceRecord struct {
+	Name       string
+	Address    string
+	Publisher  node.ID
+	Expiration time.Time
+	PublicKey  ed25519.PublicKey
+	Signature  []byte
+}
+
+// NewServiceRecord creates service record signed with publisher's private key
+func NewServiceRecord(name, address string, publisher node.ID, expiration time.Time, privateKey ed25519.PrivateKey) *ServiceRecord {
+	record := &ServiceRecord{
+		Name:       name,
+		Address:    address,
+		Publisher:  publisher,
+		Expiration: expiration,
+		PublicKey:  privateKey.Public().(ed25519.PublicKey),
+	}
+	record.Signature = ed25519.Sign(privateKey, record.payload())
+	return record
+}
+
+// Verify checks record signature
+func (r *ServiceRecord) Verify() bool {
+	if len(r.PublicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(r.PublicKey, r.payload(), r.Signature)
+}
+
+// Instance identifies service instance, records of the same instance replace each other
+func (r *ServiceRecord) Instance() string {
+	return string(r.PublicKey) + r.Address
+}
+
+// ServiceKey returns key records of named service are stored under in network deriving keys with hash
+func ServiceKey(hash KeyHash, name string) Key {
+	return hash([]byte("service:" + name))
+}
+
+func (r *ServiceRecord) payload() []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("service")
+	writeChunk(&buffer, []byte(r.Name))
+	writeChunk(&buffer, []byte(r.Address))
+	writeChunk(&buffer, r.Publisher)
+	var expiration [8]byte
+	binary.BigEndian.PutUint64(expiration[:], uint64(r.Expiration.UnixNano()))
+	buffer.Write(expiration[:])
+	return buffer.Bytes()
+}
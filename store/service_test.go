@@ -0,0 +1,49 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceRecord_Verify(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	record := NewServiceRecord("db", "127.0.0.1:5432", []byte("publisher"), time.Now().Add(time.Minute), privateKey)
+	assert.True(t, record.Verify())
+
+	forged := *record
+	forged.Address = "127.0.0.1:6543"
+	assert.False(t, forged.Verify())
+
+	forged = *record
+	forged.PublicKey = nil
+	assert.False(t, forged.Verify())
+}
+
+func TestServiceKey(t *testing.T) {
+	assert.Equal(t, ServiceKey(NewKey, "db"), ServiceKey(NewKey, "db"))
+	assert.NotEqual(t, ServiceKey(NewKey, "db"), ServiceKey(NewKey, "cache"))
+	assert.NotEqual(t, NewKey([]byte("db")), ServiceKey(NewKey, "db"))
+	assert.Equal(t, SHA256([]byte("service:db")), ServiceKey(SHA256, "db"))
+}
@@ -0,0 +1,347 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/insolar/network/clock"
+	// Registers sqlite3 driver of database/sql
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteSchema creates table of entries. Replication and expiration times are kept
+// as Unix nanoseconds in indexed columns, so due keys are found without full scans.
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS entries (
+		key BLOB PRIMARY KEY,
+		data BLOB NOT NULL,
+		replication INTEGER NOT NULL,
+		expiration INTEGER NOT NULL,
+		version_timestamp INTEGER NOT NULL,
+		version_publisher BLOB
+	)`,
+	`CREATE INDEX IF NOT EXISTS entries_replication ON entries (replication)`,
+	`CREATE INDEX IF NOT EXISTS entries_expiration ON entries (expiration)`,
+}
+
+const (
+	sqliteInsert          = `INSERT OR REPLACE INTO entries (key, data, replication, expiration, version_timestamp, version_publisher) VALUES (?, ?, ?, ?, ?, ?)`
+	sqliteSelectData      = `SELECT data FROM entries WHERE key = ?`
+	sqliteSelectVersion   = `SELECT version_timestamp, version_publisher FROM entries WHERE key = ?`
+	sqliteDelete          = `DELETE FROM entries WHERE key = ?`
+	sqliteSelectReplicate = `SELECT key FROM entries WHERE replication < ?`
+	sqliteDeleteExpired   = `DELETE FROM entries WHERE expiration < ?`
+	sqliteDeleteExpiredN  = `DELETE FROM entries WHERE key IN (SELECT key FROM entries WHERE expiration < ? LIMIT ?)`
+	sqliteSelectStats     = `SELECT COUNT(*), COALESCE(SUM(LENGTH(data)), 0) FROM entries`
+	sqliteSelectEntries   = `SELECT key, data, replication, expiration, version_timestamp, version_publisher FROM entries`
+	sqliteSelectEntry     = `SELECT data, replication, expiration, version_timestamp, version_publisher FROM entries WHERE key = ?`
+)
+
+// sqliteStore is a key/value store kept in single SQLite file, suited for desktop and embedded nodes
+type sqliteStore struct {
+	db          *sql.DB
+	clock       clock.Clock
+	expirations *expirationMeter
+}
+
+// NewSQLiteStore opens SQLite database file at path, creating it if needed, and returns store kept in it
+func NewSQLiteStore(path string) (Store, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	return newSQLiteStore(db), nil
+}
+
+func openSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows single writer, so writes are serialized here instead of failing with busy database
+	db.SetMaxOpenConns(1)
+	for _, statement := range sqliteSchema {
+		if _, err := db.Exec(statement); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+func newSQLiteStore(db *sql.DB) *sqliteStore {
+	return &sqliteStore{
+		db:          db,
+		clock:       clock.New(),
+		expirations: newExpirationMeter(),
+	}
+}
+
+// unixNano converts t to column value, zero time is kept as 0
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// Store will store a key/value pair for the local node with the given
+// replication and expiration times.
+func (ss *sqliteStore) Store(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, publisher bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	_, err := ss.db.ExecContext(ctx, sqliteInsert, []byte(key), data, unixNano(replication), unixNano(expiration), int64(0), nil)
+	return err
+}
+
+// StoreVersion stores key/value pair unless newer version of it is stored already
+func (ss *sqliteStore) StoreVersion(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, version Version) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	old, found, err := selectVersion(tx.QueryRowContext(ctx, sqliteSelectVersion, []byte(key)))
+	if err != nil {
+		return false, err
+	}
+	if found && old.Newer(version) {
+		return false, nil
+	}
+	_, err = tx.ExecContext(ctx, sqliteInsert, []byte(key), data, unixNano(replication), unixNano(expiration),
+		unixNano(version.Timestamp), []byte(version.Publisher))
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func selectVersion(row *sql.Row) (Version, bool, error) {
+	var timestamp int64
+	var publisher []byte
+	err := row.Scan(&timestamp, &publisher)
+	if err == sql.ErrNoRows {
+		return Version{}, false, nil
+	}
+	if err != nil {
+		return Version{}, false, err
+	}
+	return NewVersion(fromUnixNano(timestamp), publisher), true, nil
+}
+
+// Version returns version of stored value
+func (ss *sqliteStore) Version(ctx context.Context, key Key) (Version, bool, error) {
+	if ctx.Err() != nil {
+		return Version{}, false, ctx.Err()
+	}
+
+	return selectVersion(ss.db.QueryRowContext(ctx, sqliteSelectVersion, []byte(key)))
+}
+
+// Retrieve will return the local key/value if it exists
+func (ss *sqliteStore) Retrieve(ctx context.Context, key Key) ([]byte, bool, error) {
+	if ctx.Err() != nil {
+		return nil, false, ctx.Err()
+	}
+
+	var data []byte
+	err := ss.db.QueryRowContext(ctx, sqliteSelectData, []byte(key)).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// StoreBatch stores entries with their replication and expiration times and versions in one transaction
+func (ss *sqliteStore) StoreBatch(ctx context.Context, entries []Entry, publisher bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insert, err := tx.PrepareContext(ctx, sqliteInsert)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	for _, entry := range entries {
+		_, err := insert.ExecContext(ctx, []byte(entry.Key), entry.Data, unixNano(entry.Replication), unixNano(entry.Expiration),
+			unixNano(entry.Version.Timestamp), []byte(entry.Version.Publisher))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RetrieveBatch returns entries of keys which exist in the sqliteStore
+func (ss *sqliteStore) RetrieveBatch(ctx context.Context, keys []Key) ([]Entry, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	entries := make([]Entry, 0, len(keys))
+	for _, key := range keys {
+		var data, publisher []byte
+		var replication, expiration, timestamp int64
+		err := ss.db.QueryRowContext(ctx, sqliteSelectEntry, []byte(key)).Scan(&data, &replication, &expiration, &timestamp, &publisher)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{
+			Key:         key,
+			Data:        data,
+			Replication: fromUnixNano(replication),
+			Expiration:  fromUnixNano(expiration),
+			Version:     NewVersion(fromUnixNano(timestamp), publisher),
+		})
+	}
+	return entries, nil
+}
+
+// Delete deletes a key/value pair from the sqliteStore
+func (ss *sqliteStore) Delete(ctx context.Context, key Key) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	_, err := ss.db.ExecContext(ctx, sqliteDelete, []byte(key))
+	return err
+}
+
+// GetKeysReadyToReplicate should return the keys of all data to be
+// replicated across the network. Typically all data should be
+// replicated every tReplicate seconds.
+func (ss *sqliteStore) GetKeysReadyToReplicate(ctx context.Context) ([]Key, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	rows, err := ss.db.QueryContext(ctx, sqliteSelectReplicate, ss.clock.Now().UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []Key
+	for rows.Next() {
+		var key []byte
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, Key(key))
+	}
+	return keys, rows.Err()
+}
+
+// ExpireKeys should expire all key/values due for expiration.
+func (ss *sqliteStore) ExpireKeys(ctx context.Context) error {
+	_, err := ss.ExpireKeysBatch(ctx, 0)
+	return err
+}
+
+// ExpireKeysBatch expires at most max key/values due for expiration, all of them if max is not positive.
+// More keys are reported to be due when exactly max ones were expired.
+func (ss *sqliteStore) ExpireKeysBatch(ctx context.Context, max int) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	now := ss.clock.Now()
+	var result sql.Result
+	var err error
+	if max > 0 {
+		result, err = ss.db.ExecContext(ctx, sqliteDeleteExpiredN, now.UnixNano(), max)
+	} else {
+		result, err = ss.db.ExecContext(ctx, sqliteDeleteExpired, now.UnixNano())
+	}
+	if err != nil {
+		return false, err
+	}
+	expired, err := result.RowsAffected()
+	if err != nil {
+		return false, nil
+	}
+	ss.expirations.expired(now, int(expired))
+	return max > 0 && int(expired) == max, nil
+}
+
+// Stats returns number of stored keys, total size of values, expirations and keys pending replication
+func (ss *sqliteStore) Stats() Stats {
+	var stats Stats
+	if err := ss.db.QueryRow(sqliteSelectStats).Scan(&stats.Keys, &stats.Bytes); err != nil {
+		return Stats{}
+	}
+	stats.Expirations = ss.expirations.count(ss.clock.Now())
+	stats.PendingReplication = pendingReplication(ss)
+	return stats
+}
+
+// Entries returns all stored key/value pairs
+func (ss *sqliteStore) Entries() []Entry {
+	rows, err := ss.db.Query(sqliteSelectEntries)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var key, data, publisher []byte
+		var replication, expiration, timestamp int64
+		if err := rows.Scan(&key, &data, &replication, &expiration, &timestamp, &publisher); err != nil {
+			continue
+		}
+		entries = append(entries, Entry{
+			Key:         Key(key),
+			Data:        data,
+			Replication: fromUnixNano(replication),
+			Expiration:  fromUnixNano(expiration),
+			Version:     NewVersion(fromUnixNano(timestamp), publisher),
+		})
+	}
+	return entries
+}
@@ -0,0 +1,167 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/clock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createSQLiteStore(t *testing.T, path string) (*sqliteStore, func()) {
+	db, err := openSQLite(path)
+	assert.NoError(t, err)
+	return newSQLiteStore(db), func() { db.Close() }
+}
+
+func TestSQLiteStore_StoreRetrieve(t *testing.T) {
+	directory, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	s, closeStore := createSQLiteStore(t, filepath.Join(directory, "store.sqlite"))
+	defer closeStore()
+	ctx := context.Background()
+
+	data := []byte("some data")
+	key := NewKey(data)
+
+	err = s.Store(ctx, key, data, time.Now().Add(time.Hour), time.Now().Add(time.Hour), true)
+	assert.NoError(t, err)
+	err = s.Store(ctx, key, data, time.Now().Add(2*time.Hour), time.Now().Add(2*time.Hour), true)
+	assert.NoError(t, err)
+
+	retrieved, found, err := s.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, data, retrieved)
+	assert.Equal(t, Stats{Keys: 1, Bytes: len(data)}, s.Stats())
+
+	assert.NoError(t, s.Delete(ctx, key))
+	_, found, err = s.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, Stats{}, s.Stats())
+}
+
+func TestSQLiteStore_Reopen(t *testing.T) {
+	directory, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "store.sqlite")
+	ctx := context.Background()
+
+	data := []byte("some data")
+	key := NewKey(data)
+	replication := time.Now().Add(time.Hour)
+	expiration := time.Now().Add(2 * time.Hour)
+	version := NewVersion(time.Now(), []byte("publisher"))
+
+	s, closeStore := createSQLiteStore(t, path)
+	_, err = s.StoreVersion(ctx, key, data, replication, expiration, version)
+	assert.NoError(t, err)
+	closeStore()
+
+	s, closeStore = createSQLiteStore(t, path)
+	defer closeStore()
+
+	entries := s.Entries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, key, entries[0].Key)
+	assert.Equal(t, data, entries[0].Data)
+	assert.True(t, replication.Equal(entries[0].Replication))
+	assert.True(t, expiration.Equal(entries[0].Expiration))
+	assert.True(t, version.Timestamp.Equal(entries[0].Version.Timestamp))
+	assert.Equal(t, version.Publisher, entries[0].Version.Publisher)
+}
+
+func TestSQLiteStore_StoreVersion(t *testing.T) {
+	directory, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	s, closeStore := createSQLiteStore(t, filepath.Join(directory, "store.sqlite"))
+	defer closeStore()
+	ctx := context.Background()
+
+	data := []byte("some data")
+	key := NewKey(data)
+	older := NewVersion(time.Now(), []byte("a"))
+	newer := NewVersion(older.Timestamp.Add(time.Second), []byte("a"))
+
+	_, found, err := s.Version(ctx, key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	stored, err := s.StoreVersion(ctx, key, data, time.Now(), time.Now().Add(time.Hour), newer)
+	assert.NoError(t, err)
+	assert.True(t, stored)
+
+	stored, err = s.StoreVersion(ctx, key, data, time.Now(), time.Now().Add(time.Hour), older)
+	assert.NoError(t, err)
+	assert.False(t, stored)
+
+	version, found, err := s.Version(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.True(t, newer.Timestamp.Equal(version.Timestamp))
+}
+
+func TestSQLiteStore_ExpireKeys(t *testing.T) {
+	directory, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	s, closeStore := createSQLiteStore(t, filepath.Join(directory, "store.sqlite"))
+	defer closeStore()
+	virtual := clock.NewVirtual(time.Now())
+	s.clock = virtual
+	ctx := context.Background()
+
+	data := []byte("some data")
+	key := NewKey(data)
+	other := []byte("other data")
+	otherKey := NewKey(other)
+	s.Store(ctx, key, data, virtual.Now().Add(time.Minute), virtual.Now().Add(time.Hour), true)
+	s.Store(ctx, otherKey, other, virtual.Now().Add(time.Hour), virtual.Now().Add(3*time.Hour), true)
+
+	keys, err := s.GetKeysReadyToReplicate(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	virtual.Advance(2 * time.Minute)
+	keys, err = s.GetKeysReadyToReplicate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []Key{key}, keys)
+
+	virtual.Advance(2 * time.Hour)
+	assert.NoError(t, s.ExpireKeys(ctx))
+	_, found, err := s.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+	_, found, err = s.Retrieve(ctx, otherKey)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, Stats{Keys: 1, Bytes: len(other), Expirations: 1, PendingReplication: 1}, s.Stats())
+}
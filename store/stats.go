@@ -0,0 +1,87 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// expirationWindow is period Stats.Expirations are counted over
+const expirationWindow = time.Minute
+
+// expirationMeter counts values expired during the last expirationWindow
+type expirationMeter struct {
+	mutex  *sync.Mutex
+	events []expirationEvent
+}
+
+// expirationEvent is number of values expired at once
+type expirationEvent struct {
+	time  time.Time
+	count int
+}
+
+func newExpirationMeter() *expirationMeter {
+	return &expirationMeter{mutex: &sync.Mutex{}}
+}
+
+// expired counts values expired at now
+func (em *expirationMeter) expired(now time.Time, count int) {
+	if count == 0 {
+		return
+	}
+
+	em.mutex.Lock()
+	defer em.mutex.Unlock()
+
+	em.prune(now)
+	em.events = append(em.events, expirationEvent{time: now, count: count})
+}
+
+// count returns number of values expired during the last expirationWindow before now
+func (em *expirationMeter) count(now time.Time) int {
+	em.mutex.Lock()
+	defer em.mutex.Unlock()
+
+	em.prune(now)
+	count := 0
+	for _, event := range em.events {
+		count += event.count
+	}
+	return count
+}
+
+// prune forgets events out of window, must be called under mutex
+func (em *expirationMeter) prune(now time.Time) {
+	start := now.Add(-expirationWindow)
+	i := 0
+	for i < len(em.events) && !em.events[i].time.After(start) {
+		i++
+	}
+	em.events = em.events[i:]
+}
+
+// pendingReplication returns number of keys due for replication, 0 if store fails to list them
+func pendingReplication(store Store) int {
+	keys, err := store.GetKeysReadyToReplicate(context.Background())
+	if err != nil {
+		return 0
+	}
+	return len(keys)
+}
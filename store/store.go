@@ -17,29 +17,87 @@
 package store
 
 import (
+	"context"
 	"time"
 )
 
 // Store is the interface for implementing the storage mechanism for the
-// DHT.
+// DHT. Methods should give up and return ctx.Err() once ctx is done.
+// Errors specific to storage are ErrFull, ErrTooLarge and ErrCorrupted.
 type Store interface {
 	// Store should store a key/value pair for the local node with the
 	// given replication and expiration times.
-	Store(key Key, data []byte, replication time.Time, expiration time.Time, publisher bool) error
+	Store(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, publisher bool) error
 
 	// Retrieve should return the local key/value if it exists.
-	Retrieve(key Key) (data []byte, found bool)
+	Retrieve(ctx context.Context, key Key) (data []byte, found bool, err error)
+
+	// StoreBatch should store entries with their replication and expiration
+	// times and versions, writing them at once where storage allows it.
+	StoreBatch(ctx context.Context, entries []Entry, publisher bool) error
+
+	// RetrieveBatch should return entries of keys which exist locally,
+	// keys which are not found are skipped.
+	RetrieveBatch(ctx context.Context, keys []Key) ([]Entry, error)
 
 	// Delete should delete a key/value pair from the Store
-	Delete(key Key)
+	Delete(ctx context.Context, key Key) error
 
 	// GetKeysReadyToReplicate should return the keys of all data to be
 	// replicated across the network. Typically all data should be
 	// replicated every tReplicate seconds.
-	GetKeysReadyToReplicate() []Key
+	GetKeysReadyToReplicate(ctx context.Context) ([]Key, error)
 
 	// ExpireKeys should expire all key/values due for expiration.
-	ExpireKeys()
+	ExpireKeys(ctx context.Context) error
+}
+
+// Stats contains size of store and its activity
+type Stats struct {
+	Keys  int
+	Bytes int
+	// Expirations is number of values expired during the last minute
+	Expirations int
+	// PendingReplication is number of keys due for replication
+	PendingReplication int
+	// MaxKeys and MaxBytes are limits of store size, zero if store is not limited
+	MaxKeys  int
+	MaxBytes int
+}
+
+// StatsReporter is implemented by stores able to report their size and activity
+type StatsReporter interface {
+	Stats() Stats
+}
+
+// Entry is a stored key/value pair with its replication and expiration times
+type Entry struct {
+	Key         Key
+	Data        []byte
+	Replication time.Time
+	Expiration  time.Time
+	Version     Version
+}
+
+// BatchExpirer is implemented by stores able to expire keys incrementally,
+// so large stores don't pause to purge all expired keys at once
+type BatchExpirer interface {
+	// ExpireKeysBatch should expire at most max key/values due for expiration
+	// and tell if more of them may be due
+	ExpireKeysBatch(ctx context.Context, max int) (more bool, err error)
+}
+
+// Enumerator is implemented by stores able to list all their entries
+type Enumerator interface {
+	Entries() []Entry
+}
+
+// ReplicationNotifier is implemented by stores able to tell when keys become ready to replicate,
+// so they don't have to be polled with GetKeysReadyToReplicate
+type ReplicationNotifier interface {
+	// NotifyReplication returns channel receiving keys as their replication times pass.
+	// Every key is sent once per replication time it was stored with. Channel is closed once ctx is done.
+	NotifyReplication(ctx context.Context) <-chan []Key
 }
 
 // NewStore creates new memory store
@@ -0,0 +1,313 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// tieredStore keeps recently used values in memory and spills the rest to cold store.
+// Every value is kept in one of tiers only.
+type tieredStore struct {
+	hot   *quotaStore
+	cold  Store
+	mutex *sync.Mutex
+}
+
+// NewTieredStore creates store keeping values in memory within quota. When quota is reached,
+// values are spilled to cold store in eviction order of quota instead of being dropped, and
+// values retrieved from cold store are promoted back to memory. Values larger than MaxBytes of quota
+// are kept in cold store only. Values held in memory are not persisted.
+func NewTieredStore(cold Store, quota Quota) Store {
+	ts := &tieredStore{
+		hot:   newQuotaStore(newMemoryStore(), quota),
+		cold:  cold,
+		mutex: &sync.Mutex{},
+	}
+	ts.hot.evict = ts.spill
+	return ts
+}
+
+// spill moves value from memory to cold store, called by quota under its mutex
+func (ts *tieredStore) spill(ctx context.Context, key Key) error {
+	entries, err := ts.hot.store.RetrieveBatch(ctx, []Key{key})
+	if err != nil {
+		return err
+	}
+	if err := ts.cold.StoreBatch(ctx, entries, false); err != nil {
+		return err
+	}
+	return ts.hot.store.Delete(ctx, key)
+}
+
+// put stores entries in memory, or in cold store if they don't fit into quota, and removes
+// their previous values from the other tier. Must be called under mutex.
+func (ts *tieredStore) put(ctx context.Context, entries []Entry, publisher bool) error {
+	var cold []Entry
+	for _, entry := range entries {
+		// Quota never spills value being stored, so it is removed from cold store afterwards
+		err := ts.hot.StoreBatch(ctx, []Entry{entry}, publisher)
+		if err == ErrTooLarge {
+			if err := ts.hot.Delete(ctx, entry.Key); err != nil {
+				return err
+			}
+			cold = append(cold, entry)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := ts.cold.Delete(ctx, entry.Key); err != nil {
+			return err
+		}
+	}
+	if len(cold) == 0 {
+		return nil
+	}
+	return ts.cold.StoreBatch(ctx, cold, publisher)
+}
+
+// Store will store a key/value pair for the local node with the given
+// replication and expiration times.
+func (ts *tieredStore) Store(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, publisher bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	return ts.put(ctx, []Entry{{Key: key, Data: data, Replication: replication, Expiration: expiration}}, publisher)
+}
+
+// StoreVersion stores key/value pair unless newer version of it is stored in any of tiers.
+func (ts *tieredStore) StoreVersion(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, version Version) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	stored, found, err := ts.version(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if found && stored.Newer(version) {
+		return false, nil
+	}
+	entry := Entry{Key: key, Data: data, Replication: replication, Expiration: expiration, Version: version}
+	if err := ts.put(ctx, []Entry{entry}, true); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Version returns version of stored value
+func (ts *tieredStore) Version(ctx context.Context, key Key) (Version, bool, error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	return ts.version(ctx, key)
+}
+
+// version returns version of value from the tier holding it, must be called under mutex
+func (ts *tieredStore) version(ctx context.Context, key Key) (Version, bool, error) {
+	version, found, err := ts.hot.Version(ctx, key)
+	if found || err != nil {
+		return version, found, err
+	}
+	if versioned, ok := ts.cold.(Versioned); ok {
+		return versioned.Version(ctx, key)
+	}
+	_, found, err = ts.cold.Retrieve(ctx, key)
+	return Version{}, found, err
+}
+
+// Retrieve will return the local key/value if it exists. Value found in cold store is promoted to memory.
+func (ts *tieredStore) Retrieve(ctx context.Context, key Key) ([]byte, bool, error) {
+	data, found, err := ts.hot.Retrieve(ctx, key)
+	if found || err != nil {
+		return data, found, err
+	}
+
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	// Value could be promoted or stored since memory was checked
+	entries, err := ts.hot.RetrieveBatch(ctx, []Key{key})
+	if err != nil || len(entries) > 0 {
+		return entryData(entries), len(entries) > 0, err
+	}
+	entries, err = ts.cold.RetrieveBatch(ctx, []Key{key})
+	if err != nil || len(entries) == 0 {
+		return nil, false, err
+	}
+	if err := ts.put(ctx, entries, false); err != nil {
+		return nil, false, err
+	}
+	return entries[0].Data, true, nil
+}
+
+func entryData(entries []Entry) []byte {
+	if len(entries) == 0 {
+		return nil
+	}
+	return entries[0].Data
+}
+
+// RetrieveBatch returns entries of keys from both tiers. Batches are read for replication,
+// so values are not promoted.
+func (ts *tieredStore) RetrieveBatch(ctx context.Context, keys []Key) ([]Entry, error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	entries, err := ts.hot.RetrieveBatch(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		found[entry.Key.String()] = true
+	}
+	missing := make([]Key, 0, len(keys)-len(entries))
+	for _, key := range keys {
+		if !found[key.String()] {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return entries, nil
+	}
+	cold, err := ts.cold.RetrieveBatch(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	return append(entries, cold...), nil
+}
+
+// StoreBatch stores entries in memory, spilling the least recently used values to make room for them
+func (ts *tieredStore) StoreBatch(ctx context.Context, entries []Entry, publisher bool) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	return ts.put(ctx, entries, publisher)
+}
+
+// Delete deletes a key/value pair from both tiers
+func (ts *tieredStore) Delete(ctx context.Context, key Key) error {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if err := ts.hot.Delete(ctx, key); err != nil {
+		return err
+	}
+	return ts.cold.Delete(ctx, key)
+}
+
+// GetKeysReadyToReplicate should return the keys of all data to be
+// replicated across the network. Typically all data should be
+// replicated every tReplicate seconds.
+func (ts *tieredStore) GetKeysReadyToReplicate(ctx context.Context) ([]Key, error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	keys, err := ts.hot.GetKeysReadyToReplicate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cold, err := ts.cold.GetKeysReadyToReplicate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(keys, cold...), nil
+}
+
+// ExpireKeys should expire all key/values due for expiration.
+func (ts *tieredStore) ExpireKeys(ctx context.Context) error {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if err := ts.hot.ExpireKeys(ctx); err != nil {
+		return err
+	}
+	return ts.cold.ExpireKeys(ctx)
+}
+
+// Stats returns number of keys and size of values of both tiers. Limits of store are the ones of cold store.
+func (ts *tieredStore) Stats() Stats {
+	stats := ts.hot.Stats()
+	stats.MaxKeys, stats.MaxBytes = 0, 0
+	if reporter, ok := ts.cold.(StatsReporter); ok {
+		cold := reporter.Stats()
+		stats.Keys += cold.Keys
+		stats.Bytes += cold.Bytes
+		stats.Expirations += cold.Expirations
+		stats.PendingReplication += cold.PendingReplication
+		stats.MaxKeys, stats.MaxBytes = cold.MaxKeys, cold.MaxBytes
+	}
+	return stats
+}
+
+// Entries returns all stored key/value pairs, nil if cold store can't list them
+func (ts *tieredStore) Entries() []Entry {
+	enumerator, ok := ts.cold.(Enumerator)
+	if !ok {
+		return nil
+	}
+
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	return append(ts.hot.Entries(), enumerator.Entries()...)
+}
+
+type tieredStoreFactory struct {
+	Factory
+	quota Quota
+}
+
+// NewTieredStoreFactory creates factory of storages keeping values in memory within quota
+// and spilling the rest to storages created by given factory
+func NewTieredStoreFactory(factory Factory, quota Quota) Factory {
+	return &tieredStoreFactory{Factory: factory, quota: quota}
+}
+
+// Create returns new tiered storage
+func (tieredStoreFactory *tieredStoreFactory) Create() Store {
+	return NewTieredStore(tieredStoreFactory.Factory.Create(), tieredStoreFactory.quota)
+}
+
+// Close closes wrapped factory if it holds resources
+func (tieredStoreFactory *tieredStoreFactory) Close() error {
+	if closer, ok := tieredStoreFactory.Factory.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// KeyHash returns hash keys of wrapped factory are derived with
+func (tieredStoreFactory *tieredStoreFactory) KeyHash() KeyHash {
+	return KeyHashOf(tieredStoreFactory.Factory)
+}
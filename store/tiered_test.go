@@ -0,0 +1,120 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTieredStore_SpillAndPromote(t *testing.T) {
+	cold := NewMemoryStore()
+	s := NewTieredStore(cold, Quota{MaxKeys: 2})
+	ctx := context.Background()
+	expiration := time.Now().Add(time.Hour)
+
+	first, second, third := []byte("first"), []byte("second"), []byte("third")
+	assert.NoError(t, s.Store(ctx, NewKey(first), first, expiration, expiration, true))
+	assert.NoError(t, s.Store(ctx, NewKey(second), second, expiration, expiration, true))
+	assert.NoError(t, s.Store(ctx, NewKey(third), third, expiration, expiration, true))
+
+	// The least recently used value is spilled to cold store
+	entries, err := cold.RetrieveBatch(ctx, []Key{NewKey(first)})
+	assert.NoError(t, err)
+	assert.Equal(t, []Entry{{Key: NewKey(first), Data: first, Replication: expiration, Expiration: expiration}}, entries)
+	assert.Equal(t, 3, s.(StatsReporter).Stats().Keys)
+
+	// Retrieved value is promoted to memory, spilling the next one
+	data, found, err := s.Retrieve(ctx, NewKey(first))
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, first, data)
+	_, found, _ = cold.Retrieve(ctx, NewKey(first))
+	assert.False(t, found)
+	_, found, _ = cold.Retrieve(ctx, NewKey(second))
+	assert.True(t, found)
+
+	// Batches are read from both tiers without promotion
+	entries, err = s.RetrieveBatch(ctx, []Key{NewKey(first), NewKey(second), NewKey(third)})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+	_, found, _ = cold.Retrieve(ctx, NewKey(second))
+	assert.True(t, found)
+	assert.Len(t, s.(Enumerator).Entries(), 3)
+
+	assert.NoError(t, s.Delete(ctx, NewKey(second)))
+	_, found, _ = s.Retrieve(ctx, NewKey(second))
+	assert.False(t, found)
+	assert.Equal(t, 2, s.(StatsReporter).Stats().Keys)
+}
+
+func TestTieredStore_TooLarge(t *testing.T) {
+	cold := NewMemoryStore()
+	s := NewTieredStore(cold, Quota{MaxBytes: 4})
+	ctx := context.Background()
+	expiration := time.Now().Add(time.Hour)
+	key := NewKey([]byte("large"))
+
+	assert.NoError(t, s.Store(ctx, key, []byte("tiny"), expiration, expiration, true))
+	// Value exceeding memory quota is kept in cold store only
+	assert.NoError(t, s.Store(ctx, key, []byte("large"), expiration, expiration, true))
+	data, found, err := cold.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("large"), data)
+
+	data, found, err = s.Retrieve(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("large"), data)
+	assert.Equal(t, Stats{Keys: 1, Bytes: 5}, s.(StatsReporter).Stats())
+}
+
+func TestTieredStore_StoreVersion(t *testing.T) {
+	cold := NewMemoryStore()
+	s := NewTieredStore(cold, Quota{MaxKeys: 1})
+	ctx := context.Background()
+	expiration := time.Now().Add(time.Hour)
+	now := time.Now()
+	older, newer := NewVersion(now, node.ID{1}), NewVersion(now.Add(time.Second), node.ID{1})
+
+	key, other := NewKey([]byte("key")), NewKey([]byte("other"))
+	versioned := s.(Versioned)
+	ok, err := versioned.StoreVersion(ctx, key, []byte("newer"), expiration, expiration, newer)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, s.Store(ctx, other, []byte("other"), expiration, expiration, true))
+
+	// Version of value spilled to cold store is checked as well
+	ok, err = versioned.StoreVersion(ctx, key, []byte("older"), expiration, expiration, older)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	version, found, err := versioned.Version(ctx, key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, newer, version)
+}
+
+func TestTieredStoreFactory_Create(t *testing.T) {
+	factory := NewTieredStoreFactory(NewMemoryStoreFactory(), Quota{MaxKeys: 1})
+	assert.Implements(t, (*Versioned)(nil), factory.Create())
+}
@@ -0,0 +1,50 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+)
+
+// Validator checks records before node stores them or returns them from lookups, like record validators
+// of libp2p do, so malformed or unauthorized records are rejected instead of spreading over network.
+// Namespace value is stored in is available with NamespaceOf(ctx).
+type Validator interface {
+	// Validate returns error if data is not a valid value of key
+	Validate(ctx context.Context, key Key, data []byte) error
+}
+
+// ValidatorFunc is an adapter to use ordinary function as Validator
+type ValidatorFunc func(ctx context.Context, key Key, data []byte) error
+
+// Validate calls f(ctx, key, data)
+func (f ValidatorFunc) Validate(ctx context.Context, key Key, data []byte) error {
+	return f(ctx, key, data)
+}
+
+// NamespacedValidator validates values with validator of their namespace, default namespace
+// is keyed by empty string. Values of namespaces without validator are rejected with ErrInvalidRecord.
+type NamespacedValidator map[string]Validator
+
+// Validate checks value with validator of namespace of ctx
+func (nv NamespacedValidator) Validate(ctx context.Context, key Key, data []byte) error {
+	validator, ok := nv[NamespaceOf(ctx)]
+	if !ok {
+		return ErrInvalidRecord
+	}
+	return validator.Validate(ctx, key, data)
+}
@@ -0,0 +1,42 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespacedValidator(t *testing.T) {
+	json := ValidatorFunc(func(ctx context.Context, key Key, data []byte) error {
+		if !bytes.HasPrefix(data, []byte("{")) {
+			return ErrInvalidRecord
+		}
+		return nil
+	})
+	validator := NamespacedValidator{"json": json}
+
+	ctx := WithNamespace(context.Background(), "json")
+	assert.NoError(t, validator.Validate(ctx, NewKey([]byte("{}")), []byte("{}")))
+	assert.Equal(t, ErrInvalidRecord, validator.Validate(ctx, NewKey([]byte("foo")), []byte("foo")))
+
+	// Namespaces without validator are rejected
+	assert.Equal(t, ErrInvalidRecord, validator.Validate(context.Background(), NewKey([]byte("{}")), []byte("{}")))
+}
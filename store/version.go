@@ -0,0 +1,71 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/insolar/network/node"
+)
+
+// Version identifies publication of value. When replicas of value conflict, newer version wins.
+type Version struct {
+	Timestamp time.Time
+	Publisher node.ID
+}
+
+// Versioned is implemented by stores keeping versions of values
+type Versioned interface {
+	// StoreVersion stores key/value pair unless newer version of it is stored already.
+	// It returns false if stored version is kept.
+	StoreVersion(ctx context.Context, key Key, data []byte, replication time.Time, expiration time.Time, version Version) (bool, error)
+
+	// Version returns version of stored value, zero Version is returned for values stored without version
+	Version(ctx context.Context, key Key) (Version, bool, error)
+}
+
+// ConflictResolver decides if candidate replica of value under key replaces current one
+type ConflictResolver func(key Key, current, candidate Entry) bool
+
+// LastWriteWins keeps replica of newer version, see Version.Newer
+func LastWriteWins(key Key, current, candidate Entry) bool {
+	return candidate.Version.Newer(current.Version)
+}
+
+// NewVersion creates new Version
+func NewVersion(timestamp time.Time, publisher node.ID) Version {
+	return Version{
+		Timestamp: timestamp,
+		Publisher: publisher,
+	}
+}
+
+// Newer checks if version wins over other. Later timestamp wins, ties are broken by greater publisher ID,
+// so every node resolves conflict the same way.
+func (v Version) Newer(other Version) bool {
+	if !v.Timestamp.Equal(other.Timestamp) {
+		return v.Timestamp.After(other.Timestamp)
+	}
+	return bytes.Compare(v.Publisher, other.Publisher) > 0
+}
+
+// IsZero checks if version is unknown
+func (v Version) IsZero() bool {
+	return v.Timestamp.IsZero() && len(v.Publisher) == 0
+}
@@ -0,0 +1,48 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersion_Newer(t *testing.T) {
+	now := time.Now()
+	first := NewVersion(now, node.ID{1})
+	second := NewVersion(now, node.ID{2})
+	later := NewVersion(now.Add(time.Second), node.ID{1})
+
+	assert.True(t, later.Newer(second))
+	assert.False(t, second.Newer(later))
+
+	// Ties are broken by publisher
+	assert.True(t, second.Newer(first))
+	assert.False(t, first.Newer(second))
+	assert.False(t, first.Newer(first))
+
+	assert.True(t, first.Newer(Version{}))
+}
+
+func TestVersion_IsZero(t *testing.T) {
+	assert.True(t, Version{}.IsZero())
+	assert.False(t, NewVersion(time.Now(), node.ID{1}).IsZero())
+}
@@ -0,0 +1,104 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// writeTokens issues and validates short-lived write tokens bound to requester's IP.
+// Secret is rotated every lifetime, tokens issued with previous secret are still accepted.
+type writeTokens struct {
+	mutex    *sync.Mutex
+	lifetime time.Duration
+	secret   []byte
+	previous []byte
+	rotated  time.Time
+}
+
+func newWriteTokens(lifetime time.Duration) (*writeTokens, error) {
+	secret, err := newTokenSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return &writeTokens{
+		mutex:    &sync.Mutex{},
+		lifetime: lifetime,
+		secret:   secret,
+		rotated:  time.Now(),
+	}, nil
+}
+
+// issue returns write token for given IP
+func (wt *writeTokens) issue(ip net.IP) []byte {
+	wt.mutex.Lock()
+	defer wt.mutex.Unlock()
+
+	wt.rotate()
+	return tokenFor(wt.secret, ip)
+}
+
+// valid checks if token was issued for given IP recently
+func (wt *writeTokens) valid(ip net.IP, token []byte) bool {
+	wt.mutex.Lock()
+	defer wt.mutex.Unlock()
+
+	wt.rotate()
+	if hmac.Equal(token, tokenFor(wt.secret, ip)) {
+		return true
+	}
+	return wt.previous != nil && hmac.Equal(token, tokenFor(wt.previous, ip))
+}
+
+func (wt *writeTokens) rotate() {
+	if time.Since(wt.rotated) < wt.lifetime {
+		return
+	}
+
+	secret, err := newTokenSecret()
+	if err != nil {
+		log.Println("Failed to rotate write token secret:", err.Error())
+		return
+	}
+
+	if time.Since(wt.rotated) < wt.lifetime*2 {
+		wt.previous = wt.secret
+	} else {
+		wt.previous = nil
+	}
+	wt.secret = secret
+	wt.rotated = time.Now()
+}
+
+func newTokenSecret() ([]byte, error) {
+	secret := make([]byte, 20)
+	_, err := rand.Read(secret)
+	return secret, err
+}
+
+func tokenFor(secret []byte, ip net.IP) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(ip.To16())
+	return mac.Sum(nil)
+}
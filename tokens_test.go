@@ -0,0 +1,66 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteTokens_Valid(t *testing.T) {
+	wt, err := newWriteTokens(time.Minute)
+	assert.NoError(t, err)
+
+	ip := net.ParseIP("127.0.0.1")
+	token := wt.issue(ip)
+
+	assert.True(t, wt.valid(ip, token))
+	assert.True(t, wt.valid(net.ParseIP("::ffff:127.0.0.1"), token))
+	assert.False(t, wt.valid(net.ParseIP("127.0.0.2"), token))
+	assert.False(t, wt.valid(ip, nil))
+	assert.False(t, wt.valid(ip, []byte("token")))
+}
+
+func TestWriteTokens_Rotation(t *testing.T) {
+	wt, err := newWriteTokens(time.Millisecond * 50)
+	assert.NoError(t, err)
+
+	ip := net.ParseIP("127.0.0.1")
+	token := wt.issue(ip)
+
+	time.Sleep(time.Millisecond * 60)
+	// Token issued with previous secret is still valid
+	assert.True(t, wt.valid(ip, token))
+	assert.NotEqual(t, token, wt.issue(ip))
+
+	time.Sleep(time.Millisecond * 60)
+	assert.False(t, wt.valid(ip, token))
+}
+
+func TestWriteTokens_Expired(t *testing.T) {
+	wt, err := newWriteTokens(time.Millisecond * 20)
+	assert.NoError(t, err)
+
+	ip := net.ParseIP("127.0.0.1")
+	token := wt.issue(ip)
+
+	time.Sleep(time.Millisecond * 50)
+	assert.False(t, wt.valid(ip, token))
+}
@@ -0,0 +1,97 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTooManyRequests is returned by SendRequest when limit of pending requests is reached
+var ErrTooManyRequests = errors.New("too many pending requests")
+
+// pendingLimit caps number of futures waiting for response
+type pendingLimit struct {
+	slots  chan struct{}
+	wait   time.Duration
+	expiry time.Duration
+}
+
+func newPendingLimit(max int, wait time.Duration, expiry time.Duration) *pendingLimit {
+	return &pendingLimit{
+		slots:  make(chan struct{}, max),
+		wait:   wait,
+		expiry: expiry,
+	}
+}
+
+// SetMaxPendingRequests limits number of sent requests waiting for response to max.
+// When limit is reached SendRequest waits up to wait for a pending request to complete
+// and returns ErrTooManyRequests if none did. Requests not answered within expiry are timed out
+// by transport, so requests abandoned by callers don't hold their slots forever; they never expire
+// if expiry is not positive. It must be called before transport is started.
+func SetMaxPendingRequests(transport Transport, max int, wait time.Duration, expiry time.Duration) error {
+	if max <= 0 {
+		return errors.New("limit of pending requests must be positive")
+	}
+	limit := newPendingLimit(max, wait, expiry)
+
+	switch t := transport.(type) {
+	case *streamTransport:
+		t.pending = limit
+	case *muxTransport:
+		// Futures are shared by all transports, so is the limit
+		for _, st := range t.transports {
+			st.pending = limit
+		}
+	default:
+		return errors.New("transport does not support limiting pending requests")
+	}
+
+	return nil
+}
+
+// acquire takes a slot for new request
+func (l *pendingLimit) acquire() error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if l.wait <= 0 {
+		return ErrTooManyRequests
+	}
+
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrTooManyRequests
+	}
+}
+
+// release frees slot of completed request
+func (l *pendingLimit) release() {
+	select {
+	case <-l.slots:
+	default:
+	}
+}
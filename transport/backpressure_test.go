@@ -0,0 +1,125 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPendingLimit(t *testing.T) {
+	limit := newPendingLimit(1, time.Millisecond*50, 0)
+
+	assert.NoError(t, limit.acquire())
+	start := time.Now()
+	assert.Equal(t, ErrTooManyRequests, limit.acquire())
+	assert.True(t, time.Since(start) >= time.Millisecond*50)
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		limit.release()
+	}()
+	assert.NoError(t, limit.acquire())
+}
+
+func TestSetMaxPendingRequests(t *testing.T) {
+	// Requests are lost so they stay pending until cancelled
+	network := NewInMemoryNetwork(0, 1)
+	first, firstNode := createInMemoryTransport(t, network, "127.0.0.1:31337")
+	second, secondNode := createInMemoryTransport(t, network, "127.0.0.2:31338")
+
+	err := SetMaxPendingRequests(first, 1, 0, 0)
+	assert.NoError(t, err)
+	done := startTransports(first, second)
+
+	future, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+
+	_, err = first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.Equal(t, ErrTooManyRequests, err)
+	assert.Len(t, first.PendingRequests(), 1)
+
+	future.Cancel()
+	future, err = first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+	future.Cancel()
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestSetMaxPendingRequests_Expiry(t *testing.T) {
+	// Requests are lost and callers never time them out
+	network := NewInMemoryNetwork(0, 1)
+	first, firstNode := createInMemoryTransport(t, network, "127.0.0.1:31337")
+	second, secondNode := createInMemoryTransport(t, network, "127.0.0.2:31338")
+
+	err := SetMaxPendingRequests(first, 2, 0, time.Millisecond*20)
+	assert.NoError(t, err)
+	done := startTransports(first, second)
+
+	// Limit is filled again and again, slots are freed as transport times requests out
+	for i := 0; i < 3; i++ {
+		var futures []Future
+		for j := 0; j < 2; j++ {
+			future, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+			assert.NoError(t, err)
+			futures = append(futures, future)
+		}
+		_, err = first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+		assert.Equal(t, ErrTooManyRequests, err)
+
+		for _, future := range futures {
+			_, ok := <-future.Result()
+			assert.False(t, ok)
+			assert.Equal(t, ErrFutureTimeout, future.Err())
+		}
+		assert.Empty(t, first.PendingRequests())
+	}
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestSetMaxPendingRequests_Invalid(t *testing.T) {
+	network := NewInMemoryNetwork(0, 0)
+	tp, _ := createInMemoryTransport(t, network, "127.0.0.1:31337")
+
+	err := SetMaxPendingRequests(tp, 0, 0, 0)
+	assert.EqualError(t, err, "limit of pending requests must be positive")
+
+	err = SetMaxPendingRequests(nil, 1, 0, 0)
+	assert.EqualError(t, err, "transport does not support limiting pending requests")
+}
+
+func TestSetMaxPendingRequests_Mux(t *testing.T) {
+	network := NewInMemoryNetwork(0, 0)
+	first, _ := createInMemoryTransport(t, network, "127.0.0.1:31340")
+	second, _ := createInMemoryTransport(t, network, "127.0.0.2:31340")
+
+	mux, err := NewMuxTransport([]string{"first", "second"}, []Transport{first, second})
+	assert.NoError(t, err)
+
+	err = SetMaxPendingRequests(mux, 10, time.Second, 0)
+	assert.NoError(t, err)
+	assert.NotNil(t, first.(*streamTransport).pending)
+	assert.Equal(t, first.(*streamTransport).pending, second.(*streamTransport).pending)
+}
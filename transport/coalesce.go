@@ -0,0 +1,125 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/message"
+)
+
+// frameWriter writes frames of messages sent to address in a single write
+type frameWriter func(address string, msgs []*message.Message, sizes []int, data []byte) error
+
+// coalescer collects small frames sent to the same peer within delay and writes them at once,
+// so packet-oriented sockets send them in a single datagram. Frames keep their own headers,
+// so receivers read batch as a sequence of frames and need no support for it.
+type coalescer struct {
+	delay   time.Duration
+	maxSize int
+
+	mutex   *sync.Mutex
+	batches map[string]*frameBatch
+}
+
+// frameBatch is a sequence of frames waiting to be written to one peer
+type frameBatch struct {
+	msgs  []*message.Message
+	sizes []int
+	data  []byte
+	done  chan bool
+	err   error
+}
+
+func newCoalescer(delay time.Duration, maxSize int) *coalescer {
+	return &coalescer{
+		delay:   delay,
+		maxSize: maxSize,
+		mutex:   &sync.Mutex{},
+		batches: make(map[string]*frameBatch),
+	}
+}
+
+// SetCoalescing makes transport hold frames smaller than maxSize bytes for up to delay and write frames
+// sent to the same peer meanwhile together, up to maxSize bytes at once. It reduces number of packets
+// sent by chatty nodes, e.g. pings and FindNode requests of bootstrap nodes, at cost of added latency.
+// Sending of message returns once its batch is written. It must be called before transport is started.
+func SetCoalescing(transport Transport, delay time.Duration, maxSize int) error {
+	switch t := transport.(type) {
+	case *streamTransport:
+		t.coalescer = newCoalescer(delay, maxSize)
+	case *muxTransport:
+		for _, st := range t.transports {
+			st.coalescer = newCoalescer(delay, maxSize)
+		}
+	default:
+		return errors.New("transport does not support coalescing")
+	}
+
+	return nil
+}
+
+// fits checks if frame of given size is coalesced with others
+func (c *coalescer) fits(size int) bool {
+	return size < c.maxSize
+}
+
+// write adds frame of message to batch of address and waits until batch is written with write.
+// Batch which would exceed maxSize is written at once and frame starts a new one.
+func (c *coalescer) write(address string, msg *message.Message, frame []byte, write frameWriter) error {
+	c.mutex.Lock()
+	b, ok := c.batches[address]
+	if ok && len(b.data)+len(frame) > c.maxSize {
+		delete(c.batches, address)
+		go c.send(address, b, write)
+		ok = false
+	}
+	if !ok {
+		b = &frameBatch{done: make(chan bool)}
+		c.batches[address] = b
+		time.AfterFunc(c.delay, func() {
+			c.flush(address, b, write)
+		})
+	}
+	b.msgs = append(b.msgs, msg)
+	b.sizes = append(b.sizes, len(frame))
+	b.data = append(b.data, frame...)
+	c.mutex.Unlock()
+
+	<-b.done
+	return b.err
+}
+
+// flush writes batch unless it was written already
+func (c *coalescer) flush(address string, b *frameBatch, write frameWriter) {
+	c.mutex.Lock()
+	if c.batches[address] != b {
+		c.mutex.Unlock()
+		return
+	}
+	delete(c.batches, address)
+	c.mutex.Unlock()
+
+	c.send(address, b, write)
+}
+
+func (c *coalescer) send(address string, b *frameBatch, write frameWriter) {
+	b.err = write(address, b.msgs, b.sizes, b.data)
+	close(b.done)
+}
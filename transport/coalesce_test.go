@@ -0,0 +1,83 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalescer_Write(t *testing.T) {
+	c := newCoalescer(50*time.Millisecond, 10)
+	mutex := &sync.Mutex{}
+	var writes [][]byte
+	write := func(address string, msgs []*message.Message, sizes []int, data []byte) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		assert.Len(t, sizes, len(msgs))
+		writes = append(writes, data)
+		return errors.New("write failed")
+	}
+
+	wg := &sync.WaitGroup{}
+	for _, frame := range []string{"aaa", "bbb", "ccc", "ddd"} {
+		wg.Add(1)
+		go func(frame string) {
+			defer wg.Done()
+			// Error of batch is returned to every sender
+			assert.EqualError(t, c.write("127.0.0.1:1", &message.Message{}, []byte(frame), write), "write failed")
+		}(frame)
+		time.Sleep(time.Millisecond)
+	}
+	wg.Wait()
+
+	// The fourth frame would exceed batch size, so it starts the next batch
+	assert.Len(t, writes, 2)
+	assert.Len(t, writes[0], 9)
+	assert.Len(t, writes[1], 3)
+	assert.True(t, c.fits(9))
+	assert.False(t, c.fits(10))
+}
+
+func TestStreamTransport_Coalescing(t *testing.T) {
+	first, firstNode := createTCPTransport(t, "127.0.0.1:8155")
+	second, secondNode := createTCPTransport(t, "127.0.0.1:8156")
+	assert.NoError(t, SetCoalescing(first, 10*time.Millisecond, 1200))
+	done := startTransports(first, second)
+	defer stopTransport(first, done)
+	defer stopTransport(second, done)
+
+	for i := 0; i < 3; i++ {
+		go first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case request := <-second.Messages():
+			assert.Equal(t, firstNode.ID, request.Sender.ID)
+		case <-time.After(time.Second):
+			assert.Fail(t, "coalesced message is not received")
+		}
+	}
+
+	assert.EqualError(t, SetCoalescing(nil, time.Millisecond, 1200), "transport does not support coalescing")
+}
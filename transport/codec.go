@@ -0,0 +1,98 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/insolar/network/message"
+)
+
+// codecs selects codec of messages sent to every peer. Message is encoded with codec
+// receiver prefers if it is registered here too, otherwise gob is used.
+// Peer prefers codec it advertised in the last message it sent to us.
+type codecs struct {
+	preferred message.CodecID
+
+	mutex      *sync.RWMutex
+	preferring map[string]message.CodecID
+}
+
+func newCodecs() *codecs {
+	return &codecs{
+		preferred:  message.CodecGob,
+		mutex:      &sync.RWMutex{},
+		preferring: make(map[string]message.CodecID),
+	}
+}
+
+// SetCodec sets codec which given transport asks peers to send messages in.
+// Codec must be registered with message.RegisterCodec, messages in any registered codec are accepted.
+// It must be called before transport is started.
+func SetCodec(transport Transport, name string) error {
+	codec, ok := message.GetCodecByName(name)
+	if !ok {
+		return errors.New("unknown codec")
+	}
+
+	switch t := transport.(type) {
+	case *streamTransport:
+		t.codecs.preferred = codec.ID()
+	case *muxTransport:
+		for _, st := range t.transports {
+			st.codecs.preferred = codec.ID()
+		}
+	default:
+		return errors.New("transport does not support codecs")
+	}
+
+	return nil
+}
+
+// codecFor returns codec of messages sent to address
+func (c *codecs) codecFor(address string) message.Codec {
+	c.mutex.RLock()
+	id := c.preferring[address]
+	c.mutex.RUnlock()
+
+	codec, ok := message.GetCodec(id)
+	if !ok {
+		codec, _ = message.GetCodec(message.CodecGob)
+	}
+	return codec
+}
+
+// markPreferred remembers codec sender of message prefers
+func (c *codecs) markPreferred(msg *message.Message, network string, id message.CodecID) {
+	if msg.Sender == nil {
+		return
+	}
+	address := msg.Sender.AddressFor(network)
+	if address == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if id == message.CodecGob {
+		delete(c.preferring, address.String())
+	} else {
+		c.preferring[address.String()] = id
+	}
+}
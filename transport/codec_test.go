@@ -0,0 +1,120 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingCodec is gob prefixed with marker byte, it counts decoded messages
+type countingCodec struct {
+	decoded *int32
+}
+
+func (c countingCodec) ID() message.CodecID {
+	return message.CodecCBOR
+}
+
+func (c countingCodec) Name() string {
+	return "counting"
+}
+
+func (c countingCodec) Encode(buffer *bytes.Buffer, q *message.Message) error {
+	buffer.WriteByte(0xff)
+	return message.EncodeMessageTo(buffer, q)
+}
+
+func (c countingCodec) Decode(body []byte) (*message.Message, error) {
+	if len(body) == 0 || body[0] != 0xff {
+		return nil, errors.New("marker not found")
+	}
+	atomic.AddInt32(c.decoded, 1)
+	return message.DecodeMessage(body[1:])
+}
+
+var (
+	testCodec     = countingCodec{decoded: new(int32)}
+	testCodecOnce = &sync.Once{}
+)
+
+func registerTestCodec(t *testing.T) {
+	testCodecOnce.Do(func() {
+		assert.NoError(t, message.RegisterCodec(testCodec))
+	})
+}
+
+func TestSetCodec(t *testing.T) {
+	registerTestCodec(t)
+
+	err := SetCodec(nil, "counting")
+	assert.EqualError(t, err, "transport does not support codecs")
+
+	network := NewInMemoryNetwork(0, 0)
+	tp, _ := createInMemoryTransport(t, network, "127.0.0.1:31337")
+	err = SetCodec(tp, "unknown")
+	assert.EqualError(t, err, "unknown codec")
+	err = SetCodec(tp, "counting")
+	assert.NoError(t, err)
+	assert.Equal(t, message.CodecCBOR, tp.(*streamTransport).codecs.preferred)
+}
+
+func TestCodecs_Negotiation(t *testing.T) {
+	registerTestCodec(t)
+
+	network := NewInMemoryNetwork(time.Millisecond, 0)
+	first, firstNode := createInMemoryTransport(t, network, "127.0.0.1:31337")
+	second, secondNode := createInMemoryTransport(t, network, "127.0.0.2:31338")
+
+	// Only second node asks for the new codec, first one keeps using gob
+	err := SetCodec(second, "counting")
+	assert.NoError(t, err)
+	done := startTransports(first, second)
+
+	exchange := func() {
+		future, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+		assert.NoError(t, err)
+
+		request := <-second.Messages()
+		response := message.NewBuilder().Sender(secondNode).Receiver(firstNode).Type(message.TypePing).Response(nil).Build()
+		err = second.SendResponse(request.RequestID, response)
+		assert.NoError(t, err)
+
+		<-future.Result()
+	}
+
+	decoded := atomic.LoadInt32(testCodec.decoded)
+
+	// Preference of peer is unknown before it sends anything
+	exchange()
+	assert.Equal(t, decoded, atomic.LoadInt32(testCodec.decoded))
+
+	// Request is encoded with codec second node prefers, response is still gob
+	exchange()
+	assert.Equal(t, decoded+1, atomic.LoadInt32(testCodec.decoded))
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
@@ -0,0 +1,105 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/insolar/network/message"
+)
+
+// compression compresses messages with snappy for peers which accept compressed messages.
+// Peer accepts them if it set FlagAcceptsCompressed in any message it sent to us.
+type compression struct {
+	threshold int
+
+	mutex     *sync.RWMutex
+	accepting map[string]bool
+}
+
+func newCompression(threshold int) *compression {
+	return &compression{
+		threshold: threshold,
+		mutex:     &sync.RWMutex{},
+		accepting: make(map[string]bool),
+	}
+}
+
+// SetCompression enables compression of outgoing messages larger than threshold bytes
+// for peers which enabled compression too. Compressed messages are accepted regardless of it.
+// It must be called before transport is started.
+func SetCompression(transport Transport, threshold int) error {
+	c := newCompression(threshold)
+
+	switch t := transport.(type) {
+	case *streamTransport:
+		t.compression = c
+	case *muxTransport:
+		for _, st := range t.transports {
+			st.compression = c
+		}
+	default:
+		return errors.New("transport does not support compression")
+	}
+
+	return nil
+}
+
+// compress compresses message body sent to address if peer accepts compressed messages
+func (c *compression) compress(address string, body []byte) ([]byte, message.FrameFlags) {
+	flags := message.FlagAcceptsCompressed
+
+	c.mutex.RLock()
+	accepting := c.accepting[address]
+	c.mutex.RUnlock()
+
+	if !accepting || len(body) < c.threshold {
+		return body, flags
+	}
+
+	compressed := snappy.Encode(nil, body)
+	if len(compressed) >= len(body) {
+		return body, flags
+	}
+	return compressed, flags | message.FlagCompressed
+}
+
+// markAccepting remembers that sender of message accepts compressed messages
+func (c *compression) markAccepting(msg *message.Message, network string) {
+	if msg.Sender == nil {
+		return
+	}
+	address := msg.Sender.AddressFor(network)
+	if address == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.accepting[address.String()] = true
+}
+
+// decompress returns body of received message
+func decompress(body []byte, flags message.FrameFlags) ([]byte, error) {
+	if flags&message.FlagCompressed == 0 {
+		return body, nil
+	}
+	return snappy.Decode(nil, body)
+}
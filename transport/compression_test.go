@@ -0,0 +1,86 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompression_Compress(t *testing.T) {
+	c := newCompression(100)
+	large := bytes.Repeat([]byte("foo"), 100)
+
+	// Peer didn't tell it accepts compressed messages yet
+	body, flags := c.compress("127.0.0.1:31337", large)
+	assert.Equal(t, large, body)
+	assert.Equal(t, message.FlagAcceptsCompressed, flags)
+
+	addr, _ := node.NewAddress("127.0.0.1:31337")
+	c.markAccepting(&message.Message{Sender: &node.Node{Address: addr}}, "")
+
+	body, flags = c.compress("127.0.0.1:31337", []byte("foo"))
+	assert.Equal(t, []byte("foo"), body)
+	assert.Equal(t, message.FlagAcceptsCompressed, flags)
+
+	body, flags = c.compress("127.0.0.1:31337", large)
+	assert.True(t, len(body) < len(large))
+	assert.Equal(t, message.FlagAcceptsCompressed|message.FlagCompressed, flags)
+
+	decompressed, err := decompress(body, flags)
+	assert.NoError(t, err)
+	assert.Equal(t, large, decompressed)
+}
+
+func TestSetCompression_NotSupported(t *testing.T) {
+	err := SetCompression(nil, 100)
+	assert.EqualError(t, err, "transport does not support compression")
+}
+
+func TestTCPTransport_Compression(t *testing.T) {
+	first, firstNode := createTCPTransport(t, "127.0.0.1:8105")
+	second, secondNode := createTCPTransport(t, "127.0.0.1:8106")
+	assert.NoError(t, SetCompression(first, 100))
+	assert.NoError(t, SetCompression(second, 100))
+	done := startTransports(first, second)
+
+	args := [][]byte{bytes.Repeat([]byte("foo"), 100)}
+	request := message.NewBuilder().Sender(firstNode).Receiver(secondNode).Type(message.TypeRPC).Request(
+		&message.RequestDataRPC{Method: "echo", Args: args}).Build()
+	future, err := first.SendRequest(request)
+	assert.NoError(t, err)
+
+	received := <-second.Messages()
+	assert.Equal(t, args, received.Data.(*message.RequestDataRPC).Args)
+
+	// Response is compressed as first node told it accepts compressed messages
+	response := message.NewBuilder().Sender(secondNode).Receiver(firstNode).Type(message.TypeRPC).Response(
+		&message.ResponseDataRPC{Success: true, Result: args[0]}).Build()
+	assert.True(t, second.(*streamTransport).compression.accepting[firstNode.Address.String()])
+	err = second.SendResponse(received.RequestID, response)
+	assert.NoError(t, err)
+
+	result := <-future.Result()
+	assert.Equal(t, args[0], result.Data.(*message.ResponseDataRPC).Result)
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
@@ -0,0 +1,91 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// SetDeadlines limits duration of writing a message to connection to write and duration of reading
+// a message to read, reading starts when the first byte of message arrives so idle connections are kept.
+// Connection is dropped when peer stalls longer. Zero means unlimited. It must be called before transport is started.
+func SetDeadlines(transport Transport, read, write time.Duration) error {
+	switch t := transport.(type) {
+	case *streamTransport:
+		t.readTimeout, t.writeTimeout = read, write
+	case *muxTransport:
+		for _, st := range t.transports {
+			st.readTimeout, st.writeTimeout = read, write
+		}
+	default:
+		return errors.New("transport does not support deadlines")
+	}
+
+	return nil
+}
+
+// writeWithDeadline writes data to connection failing if it takes longer than timeout
+func writeWithDeadline(conn net.Conn, data []byte, timeout time.Duration) error {
+	if timeout > 0 {
+		err := conn.SetWriteDeadline(time.Now().Add(timeout))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := conn.Write(data)
+	return err
+}
+
+// frameReader reads connection setting read deadline once message starts to arrive
+type frameReader struct {
+	conn    net.Conn
+	timeout time.Duration
+	started bool
+}
+
+func newFrameReader(conn net.Conn, timeout time.Duration) *frameReader {
+	return &frameReader{
+		conn:    conn,
+		timeout: timeout,
+	}
+}
+
+// Read reads data from connection
+func (r *frameReader) Read(b []byte) (int, error) {
+	n, err := r.conn.Read(b)
+	if n > 0 && !r.started && r.timeout > 0 {
+		r.started = true
+		deadlineErr := r.conn.SetReadDeadline(time.Now().Add(r.timeout))
+		if err == nil {
+			err = deadlineErr
+		}
+	}
+	return n, err
+}
+
+// next removes read deadline before waiting for the next message
+func (r *frameReader) next() error {
+	if !r.started {
+		return nil
+	}
+
+	r.started = false
+	return r.conn.SetReadDeadline(time.Time{})
+}
@@ -0,0 +1,109 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDeadlines(t *testing.T) {
+	tp, _ := createTCPTransport(t, "127.0.0.1:8115")
+	defer tp.(*streamTransport).socket.Close()
+
+	err := SetDeadlines(tp, time.Second, time.Second*2)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, tp.(*streamTransport).readTimeout)
+	assert.Equal(t, time.Second*2, tp.(*streamTransport).writeTimeout)
+
+	err = SetDeadlines(nil, time.Second, time.Second)
+	assert.EqualError(t, err, "transport does not support deadlines")
+}
+
+func TestStreamTransport_WriteDeadline(t *testing.T) {
+	tp, n := createTCPTransport(t, "127.0.0.1:8116")
+	err := SetDeadlines(tp, 0, time.Millisecond*100)
+	assert.NoError(t, err)
+	done := make(chan bool)
+	go func() {
+		tp.Start()
+		done <- true
+	}()
+	defer stopTransport(tp, done)
+
+	// Peer accepts connection but never reads from it
+	listener, err := net.Listen("tcp", "127.0.0.1:8117")
+	assert.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second * 2)
+		}
+	}()
+
+	addr, _ := node.NewAddress("127.0.0.1:8117")
+	request := message.NewBuilder().Sender(n).Receiver(node.NewNode(addr)).Type(message.TypeRPC).Request(
+		&message.RequestDataRPC{Args: [][]byte{make([]byte, 32<<20)}}).Build()
+
+	started := time.Now()
+	_, err = tp.SendRequest(request)
+	assert.Error(t, err)
+	assert.True(t, time.Since(started) < time.Second)
+}
+
+func TestStreamTransport_ReadDeadline(t *testing.T) {
+	tp, n := createTCPTransport(t, "127.0.0.1:8118")
+	err := SetDeadlines(tp, time.Millisecond*100, 0)
+	assert.NoError(t, err)
+	done := make(chan bool)
+	go func() {
+		tp.Start()
+		done <- true
+	}()
+	defer stopTransport(tp, done)
+
+	conn, err := net.Dial("tcp", "127.0.0.1:8118")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// Idle connection is kept
+	time.Sleep(time.Millisecond * 200)
+	data, err := message.EncodeMessage(message.NewPingMessage(n, n))
+	assert.NoError(t, err)
+	_, err = conn.Write(message.NewFrame(data, 0))
+	assert.NoError(t, err)
+	request := <-tp.Messages()
+	assert.Equal(t, message.TypePing, request.Type)
+
+	// Peer stalls in the middle of message
+	_, err = conn.Write(message.NewFrame(data, 0)[:message.FrameHeaderSize+1])
+	assert.NoError(t, err)
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.Error(t, err)
+	netErr, ok := err.(net.Error)
+	assert.False(t, ok && netErr.Timeout(), "connection must be closed by transport")
+}
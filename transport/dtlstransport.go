@@ -0,0 +1,304 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"log"
+	"net"
+	"time"
+
+	"github.com/insolar/network/node"
+
+	"github.com/pion/dtls"
+)
+
+const (
+	dtlsHandshakeTimeout = time.Second * 5
+
+	// Maximum plaintext size of DTLS record, every message is sent in a single record
+	dtlsMaxRecordSize = 16384
+	// dtlsRecordOverhead is size of DTLS record header, explicit nonce or IV, tag or MAC and padding
+	dtlsRecordOverhead = 64
+)
+
+// DTLSConfig is configuration of DTLS transport
+type DTLSConfig struct {
+	// Certificate of node, its public key determines node identity (see CertificateID)
+	Certificate tls.Certificate
+
+	// Identities of peers which are accepted, any peer presenting certificate is accepted if empty
+	PinnedIDs []node.ID
+
+	// PathMTUDiscovery limits records sent to every peer host to the largest UDP payload which is delivered
+	// to it without loss, larger messages are refused instead of being fragmented. Peers must enable it too
+	// to answer probes
+	PathMTUDiscovery bool
+}
+
+type dtlsSocket struct {
+	listener *packetListener
+	config   *DTLSConfig
+	accepted chan net.Conn
+	faults   *faults
+	pmtu     *pathMTUConn
+}
+
+// NewDTLSTransport creates transport sending every message as a single DTLS record over conn.
+// Messages are not retransmitted, lost ones time out as requests to unreachable node do,
+// messages larger than DTLS record are refused. Peers authenticate with certificates,
+// identity of peer is derived from its certificate and must be one of PinnedIDs if they are set,
+// nodes dialed by ID must present certificate of that ID and messages not sent by peer itself are dropped.
+func NewDTLSTransport(conn net.PacketConn, config *DTLSConfig) (Transport, error) {
+	if len(config.Certificate.Certificate) == 0 {
+		return nil, errors.New("certificate is not set")
+	}
+
+	var pmtu *pathMTUConn
+	if config.PathMTUDiscovery {
+		pmtu = newPathMTUConn(conn)
+		conn = pmtu
+	}
+
+	socket := &dtlsSocket{
+		listener: newPacketListener(conn),
+		config:   config,
+		accepted: make(chan net.Conn),
+		pmtu:     pmtu,
+	}
+
+	st := newStreamTransport(socket)
+	socket.faults = st.faults
+	go socket.handshakeAccepted()
+
+	return st, nil
+}
+
+// CertificateID returns node identity bound to certificate, it is a hash of certificate public key.
+// Node using DTLS transport is expected to use it as its ID.
+func CertificateID(certificate []byte) (node.ID, error) {
+	cert, err := x509.ParseCertificate(certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	id := sha1.Sum(cert.RawSubjectPublicKeyInfo)
+	return id[:], nil
+}
+
+// verifyPeerCertificate returns identity of peer presenting rawCerts, it must match expected one if it is set
+// and must be pinned if pinned identities are set
+func verifyPeerCertificate(rawCerts [][]byte, expected node.ID, pinned []node.ID) (node.ID, error) {
+	if len(rawCerts) == 0 {
+		return nil, errors.New("peer did not present certificate")
+	}
+
+	id, err := CertificateID(rawCerts[0])
+	if err != nil {
+		return nil, err
+	}
+	if expected != nil && !expected.Equal(id) {
+		return nil, errors.New("peer certificate does not match node ID")
+	}
+	if len(pinned) == 0 {
+		return id, nil
+	}
+
+	for _, pinnedID := range pinned {
+		if pinnedID.Equal(id) {
+			return id, nil
+		}
+	}
+	return nil, errors.New("peer certificate is not pinned")
+}
+
+// handshakeConfig returns DTLS configuration of a single handshake, identity of verified peer is stored to peer
+func (s *dtlsSocket) handshakeConfig(expected node.ID, peer *node.ID) *dtls.Config {
+	return &dtls.Config{
+		Certificates: []tls.Certificate{s.config.Certificate},
+		// Peers are authenticated with pinned identities instead of certificate chains
+		InsecureSkipVerify: true,
+		ClientAuth:         dtls.RequireAnyClientCert,
+		VerifyPeerCertificate: func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			id, err := verifyPeerCertificate(rawCerts, expected, s.config.PinnedIDs)
+			*peer = id
+			return err
+		},
+	}
+}
+
+// handshakeAccepted performs handshakes of accepted associations concurrently,
+// so that slow peer can not hold Accept
+func (s *dtlsSocket) handshakeAccepted() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			close(s.accepted)
+			return
+		}
+
+		go func(conn net.Conn) {
+			limit := dtlsMaxRecordSize
+			if s.pmtu != nil {
+				// MTU discovered when dialing peer's host is used
+				limit = dtlsRecordLimit(s.pmtu.lookup(conn.RemoteAddr().String(), 0, 0))
+			}
+
+			dtlsConn, err := s.handshake(conn, dtls.Server, nil, limit)
+			if err != nil {
+				log.Println("Failed to accept DTLS connection:", err.Error())
+				s.faults.report(FaultHandshake, nil, conn.RemoteAddr().String(), err)
+				return
+			}
+
+			select {
+			case s.accepted <- dtlsConn:
+			case <-s.listener.closed:
+				dtlsConn.Close()
+			}
+		}(conn)
+	}
+}
+
+func (s *dtlsSocket) handshake(conn net.Conn, handshake func(net.Conn, *dtls.Config) (*dtls.Conn, error), expected node.ID, limit int) (net.Conn, error) {
+	err := conn.SetDeadline(time.Now().Add(dtlsHandshakeTimeout))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	var peer node.ID
+	dtlsConn, err := handshake(conn, s.handshakeConfig(expected, &peer))
+	if err != nil {
+		conn.Close()
+		return nil, handshakeFailed(err)
+	}
+
+	err = conn.SetDeadline(time.Time{})
+	if err != nil {
+		dtlsConn.Close()
+		return nil, err
+	}
+
+	record := newRecordConn(dtlsConn, limit)
+	record.peer = peer
+	return record, nil
+}
+
+// dtlsRecordLimit returns the largest record plaintext which fits datagram of given size, zero size means unknown path MTU
+func dtlsRecordLimit(mtu int) int {
+	if mtu == 0 || mtu-packetHeaderSize-dtlsRecordOverhead > dtlsMaxRecordSize {
+		return dtlsMaxRecordSize
+	}
+	return mtu - packetHeaderSize - dtlsRecordOverhead
+}
+
+// Accept waits for the next incoming connection which completed handshake
+func (s *dtlsSocket) Accept() (net.Conn, error) {
+	conn, ok := <-s.accepted
+	if !ok {
+		return nil, errors.New("socket closed")
+	}
+	return conn, nil
+}
+
+// Dial opens association with given address and performs DTLS handshake
+func (s *dtlsSocket) Dial(address string) (net.Conn, error) {
+	return s.DialNode(address, nil)
+}
+
+// DialNode opens association with node with given id and performs DTLS handshake, association is refused
+// if certificate of peer does not match id
+func (s *dtlsSocket) DialNode(address string, id node.ID) (net.Conn, error) {
+	udpAddress, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := dtlsMaxRecordSize
+	if s.pmtu != nil {
+		limit = dtlsRecordLimit(s.pmtu.discover(address, 0, 0))
+	}
+
+	conn, err := s.listener.Dial(udpAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.handshake(conn, dtls.Client, id, limit)
+}
+
+// Addr returns address of packet connection
+func (s *dtlsSocket) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Close closes packet connection
+func (s *dtlsSocket) Close() error {
+	return s.listener.Close()
+}
+
+// recordConn reads whole DTLS records and serves them as a stream, every write is a single record.
+// Frame written in one record is never mixed with other frames, so lost records do not break framing.
+type recordConn struct {
+	net.Conn
+	record []byte
+	unread []byte
+	// limit is the largest record written
+	limit int
+	// peer is identity derived from certificate of peer
+	peer node.ID
+}
+
+func newRecordConn(conn net.Conn, limit int) *recordConn {
+	return &recordConn{
+		Conn:   conn,
+		record: make([]byte, dtlsMaxRecordSize),
+		limit:  limit,
+	}
+}
+
+// PeerID returns identity derived from certificate of peer
+func (c *recordConn) PeerID() node.ID {
+	return c.peer
+}
+
+// Read reads the rest of current record or the next one
+func (c *recordConn) Read(b []byte) (int, error) {
+	if len(c.unread) == 0 {
+		n, err := c.Conn.Read(c.record)
+		if err != nil {
+			return 0, err
+		}
+		c.unread = c.record[:n]
+	}
+
+	n := copy(b, c.unread)
+	c.unread = c.unread[n:]
+	return n, nil
+}
+
+// Write sends b as a single record
+func (c *recordConn) Write(b []byte) (int, error) {
+	if len(b) > c.limit {
+		return 0, errors.New("message is too large for DTLS record")
+	}
+	return c.Conn.Write(b)
+}
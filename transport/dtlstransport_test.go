@@ -0,0 +1,204 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/insolar/network/connection"
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createDTLSCertificate(t *testing.T) (tls.Certificate, node.ID) {
+	certificate := createTLSConfig(t).Certificates[0]
+	id, err := CertificateID(certificate.Certificate[0])
+	assert.NoError(t, err)
+	return certificate, id
+}
+
+func createDTLSTransport(t *testing.T, address string, config *DTLSConfig) (Transport, *node.Node) {
+	conn, err := connection.NewConnectionFactory().Create(address)
+	assert.NoError(t, err)
+
+	tp, err := NewDTLSTransport(conn, config)
+	assert.NoError(t, err)
+
+	addr, _ := node.NewAddress(address)
+	n := node.NewNode(addr)
+	n.ID, _ = CertificateID(config.Certificate.Certificate[0])
+
+	return tp, n
+}
+
+func TestNewDTLSTransport_NoCertificate(t *testing.T) {
+	_, err := NewDTLSTransport(nil, &DTLSConfig{})
+	assert.EqualError(t, err, "certificate is not set")
+}
+
+func TestCertificateID(t *testing.T) {
+	certificate, id := createDTLSCertificate(t)
+	assert.Len(t, id, 20)
+
+	other, err := CertificateID(certificate.Certificate[0])
+	assert.NoError(t, err)
+	assert.Equal(t, id, other)
+
+	_, err = CertificateID([]byte("foo"))
+	assert.Error(t, err)
+}
+
+func TestDTLSTransport_SendRequest(t *testing.T) {
+	firstCertificate, firstID := createDTLSCertificate(t)
+	secondCertificate, secondID := createDTLSCertificate(t)
+
+	first, firstNode := createDTLSTransport(t, "127.0.0.1:8146", &DTLSConfig{
+		Certificate: firstCertificate,
+		PinnedIDs:   []node.ID{secondID},
+	})
+	second, secondNode := createDTLSTransport(t, "127.0.0.1:8147", &DTLSConfig{
+		Certificate: secondCertificate,
+		PinnedIDs:   []node.ID{firstID},
+	})
+	done := startTransports(first, second)
+
+	// Both peers dial each other over the same sockets
+	for _, pair := range [][]Transport{{first, second}, {second, first}} {
+		sender, receiver := pair[0], pair[1]
+		senderNode, receiverNode := firstNode, secondNode
+		if sender == second {
+			senderNode, receiverNode = secondNode, firstNode
+		}
+
+		future, err := sender.SendRequest(message.NewPingMessage(senderNode, receiverNode))
+		assert.NoError(t, err)
+
+		request := <-receiver.Messages()
+		assert.Equal(t, message.TypePing, request.Type)
+		assert.Equal(t, senderNode.ID, request.Sender.ID)
+
+		response := message.NewBuilder().Sender(receiverNode).Receiver(senderNode).Type(message.TypePing).Response(nil).Build()
+		err = receiver.SendResponse(request.RequestID, response)
+		assert.NoError(t, err)
+
+		result := <-future.Result()
+		assert.Equal(t, receiverNode.ID, result.Sender.ID)
+	}
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestDTLSTransport_NotPinned(t *testing.T) {
+	firstCertificate, _ := createDTLSCertificate(t)
+	secondCertificate, _ := createDTLSCertificate(t)
+	_, otherID := createDTLSCertificate(t)
+
+	first, firstNode := createDTLSTransport(t, "127.0.0.1:8148", &DTLSConfig{Certificate: firstCertificate})
+	second, secondNode := createDTLSTransport(t, "127.0.0.1:8149", &DTLSConfig{
+		Certificate: secondCertificate,
+		PinnedIDs:   []node.ID{otherID},
+	})
+	done := startTransports(first, second)
+
+	_, err := second.SendRequest(message.NewPingMessage(secondNode, firstNode))
+	assert.EqualError(t, err, "peer certificate is not pinned")
+	assert.Empty(t, second.PendingRequests())
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestRecordConn_TooLarge(t *testing.T) {
+	conn := newRecordConn(nil, dtlsMaxRecordSize)
+	_, err := conn.Write(make([]byte, dtlsMaxRecordSize+1))
+	assert.EqualError(t, err, "message is too large for DTLS record")
+
+	// Records are limited to path MTU
+	conn = newRecordConn(nil, dtlsRecordLimit(1472))
+	_, err = conn.Write(make([]byte, 1472))
+	assert.EqualError(t, err, "message is too large for DTLS record")
+}
+
+func TestDTLSRecordLimit(t *testing.T) {
+	assert.Equal(t, dtlsMaxRecordSize, dtlsRecordLimit(0))
+	assert.Equal(t, 1472-packetHeaderSize-dtlsRecordOverhead, dtlsRecordLimit(1472))
+	assert.Equal(t, dtlsMaxRecordSize, dtlsRecordLimit(65507))
+}
+
+func TestVerifyPeerCertificate(t *testing.T) {
+	certificate, id := createDTLSCertificate(t)
+	_, otherID := createDTLSCertificate(t)
+
+	peer, err := verifyPeerCertificate(certificate.Certificate, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, id, peer)
+
+	peer, err = verifyPeerCertificate(certificate.Certificate, id, []node.ID{otherID, id})
+	assert.NoError(t, err)
+	assert.Equal(t, id, peer)
+
+	_, err = verifyPeerCertificate(certificate.Certificate, otherID, nil)
+	assert.EqualError(t, err, "peer certificate does not match node ID")
+	_, err = verifyPeerCertificate(certificate.Certificate, nil, []node.ID{otherID})
+	assert.EqualError(t, err, "peer certificate is not pinned")
+	_, err = verifyPeerCertificate(nil, nil, nil)
+	assert.EqualError(t, err, "peer did not present certificate")
+}
+
+func TestDTLSTransport_WrongID(t *testing.T) {
+	firstCertificate, _ := createDTLSCertificate(t)
+	secondCertificate, _ := createDTLSCertificate(t)
+
+	first, firstNode := createDTLSTransport(t, "127.0.0.1:8167", &DTLSConfig{Certificate: firstCertificate})
+	second, secondNode := createDTLSTransport(t, "127.0.0.1:8168", &DTLSConfig{Certificate: secondCertificate})
+	done := startTransports(first, second)
+
+	impostor := *firstNode
+	impostor.ID, _ = node.NewID()
+	_, err := second.SendRequest(message.NewPingMessage(secondNode, &impostor))
+	assert.EqualError(t, err, "peer certificate does not match node ID")
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestDTLSTransport_SpoofedSender(t *testing.T) {
+	firstCertificate, _ := createDTLSCertificate(t)
+	secondCertificate, _ := createDTLSCertificate(t)
+
+	first, firstNode := createDTLSTransport(t, "127.0.0.1:8169", &DTLSConfig{Certificate: firstCertificate})
+	second, secondNode := createDTLSTransport(t, "127.0.0.1:8170", &DTLSConfig{Certificate: secondCertificate})
+	done := startTransports(first, second)
+
+	spoofed := *firstNode
+	spoofed.ID, _ = node.NewID()
+	_, err := first.SendRequest(message.NewPingMessage(&spoofed, secondNode))
+	assert.NoError(t, err)
+	_, err = first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+
+	// Message of spoofed sender is dropped, the next one from the same association is received
+	request := <-second.Messages()
+	assert.Equal(t, firstNode.ID, request.Sender.ID)
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
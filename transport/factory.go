@@ -17,6 +17,7 @@
 package transport
 
 import (
+	"crypto/tls"
 	"net"
 )
 
@@ -25,14 +26,221 @@ type Factory interface {
 	Create(conn net.PacketConn) (Transport, error)
 }
 
-type utpTransportFactory struct{}
+type utpTransportFactory struct {
+	config *UTPConfig
+}
 
 // NewUTPTransportFactory creates new Factory of utpTransport
 func NewUTPTransportFactory() Factory {
 	return &utpTransportFactory{}
 }
 
+// NewUTPTransportFactoryWithConfig creates new Factory of utpTransport tuned with config. Defaults are used if config is nil
+func NewUTPTransportFactoryWithConfig(config *UTPConfig) Factory {
+	return &utpTransportFactory{
+		config: config,
+	}
+}
+
 // Create creates new Transport
 func (utpTransportFactory *utpTransportFactory) Create(conn net.PacketConn) (Transport, error) {
-	return NewUTPTransport(conn)
+	return NewUTPTransportWithConfig(conn, utpTransportFactory.config)
+}
+
+type tcpTransportFactory struct{}
+
+// NewTCPTransportFactory creates new Factory of tcpTransport
+func NewTCPTransportFactory() Factory {
+	return &tcpTransportFactory{}
+}
+
+// Create creates new Transport
+func (tcpTransportFactory *tcpTransportFactory) Create(conn net.PacketConn) (Transport, error) {
+	return NewTCPTransport(conn)
+}
+
+type kcpTransportFactory struct {
+	config *KCPConfig
+}
+
+// NewKCPTransportFactory creates new Factory of kcpTransport. Default configuration is used if config is nil
+func NewKCPTransportFactory(config *KCPConfig) Factory {
+	return &kcpTransportFactory{
+		config: config,
+	}
+}
+
+// Create creates new Transport
+func (kcpTransportFactory *kcpTransportFactory) Create(conn net.PacketConn) (Transport, error) {
+	return NewKCPTransport(conn, kcpTransportFactory.config)
+}
+
+type dtlsTransportFactory struct {
+	config *DTLSConfig
+}
+
+// NewDTLSTransportFactory creates new Factory of dtlsTransport
+func NewDTLSTransportFactory(config *DTLSConfig) Factory {
+	return &dtlsTransportFactory{
+		config: config,
+	}
+}
+
+// Create creates new Transport
+func (dtlsTransportFactory *dtlsTransportFactory) Create(conn net.PacketConn) (Transport, error) {
+	return NewDTLSTransport(conn, dtlsTransportFactory.config)
+}
+
+type tlsTransportFactory struct {
+	factory Factory
+	config  *tls.Config
+}
+
+// NewTLSTransportFactory creates new Factory of transports produced by given factory and wrapped in TLS
+func NewTLSTransportFactory(factory Factory, config *tls.Config) Factory {
+	return &tlsTransportFactory{
+		factory: factory,
+		config:  config,
+	}
+}
+
+// Create creates new Transport
+func (tlsTransportFactory *tlsTransportFactory) Create(conn net.PacketConn) (Transport, error) {
+	transport, err := tlsTransportFactory.factory.Create(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTLSTransport(transport, tlsTransportFactory.config)
+}
+
+type noiseTransportFactory struct {
+	factory Factory
+	config  *NoiseConfig
+}
+
+// NewNoiseTransportFactory creates new Factory of transports produced by given factory and secured with Noise
+func NewNoiseTransportFactory(factory Factory, config *NoiseConfig) Factory {
+	return &noiseTransportFactory{
+		factory: factory,
+		config:  config,
+	}
+}
+
+// Create creates new Transport
+func (noiseTransportFactory *noiseTransportFactory) Create(conn net.PacketConn) (Transport, error) {
+	transport, err := noiseTransportFactory.factory.Create(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewNoiseTransport(transport, noiseTransportFactory.config)
+}
+
+type handshakeTransportFactory struct {
+	factory Factory
+	config  *HandshakeConfig
+}
+
+// NewHandshakeTransportFactory creates new Factory of transports produced by given factory with protocol handshake
+func NewHandshakeTransportFactory(factory Factory, config *HandshakeConfig) Factory {
+	return &handshakeTransportFactory{
+		factory: factory,
+		config:  config,
+	}
+}
+
+// Create creates new Transport
+func (handshakeTransportFactory *handshakeTransportFactory) Create(conn net.PacketConn) (Transport, error) {
+	transport, err := handshakeTransportFactory.factory.Create(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewHandshakeTransport(transport, handshakeTransportFactory.config)
+}
+
+type inMemoryTransportFactory struct {
+	network *InMemoryNetwork
+}
+
+// NewInMemoryTransportFactory creates new Factory of transports attached to given network.
+// Transport address is taken from conn, which is not used otherwise.
+func NewInMemoryTransportFactory(network *InMemoryNetwork) Factory {
+	return &inMemoryTransportFactory{
+		network: network,
+	}
+}
+
+// Create creates new Transport
+func (inMemoryTransportFactory *inMemoryTransportFactory) Create(conn net.PacketConn) (Transport, error) {
+	return NewInMemoryTransport(inMemoryTransportFactory.network, conn.LocalAddr().String())
+}
+
+type unixTransportFactory struct {
+	directory string
+}
+
+// NewUnixTransportFactory creates new Factory of transports listening on unix domain sockets in given directory.
+// Transport address is taken from conn, which is not used otherwise.
+func NewUnixTransportFactory(directory string) Factory {
+	return &unixTransportFactory{
+		directory: directory,
+	}
+}
+
+// Create creates new Transport
+func (unixTransportFactory *unixTransportFactory) Create(conn net.PacketConn) (Transport, error) {
+	return NewUnixTransport(unixTransportFactory.directory, conn.LocalAddr().String())
+}
+
+type muxTransportFactory struct {
+	names     []string
+	factories []Factory
+}
+
+// NewMuxTransportFactory creates new Factory of transports listening on transports produced by all given factories.
+// See NewMuxTransport for names
+func NewMuxTransportFactory(names []string, factories []Factory) Factory {
+	return &muxTransportFactory{
+		names:     names,
+		factories: factories,
+	}
+}
+
+// Create creates new Transport
+func (muxTransportFactory *muxTransportFactory) Create(conn net.PacketConn) (Transport, error) {
+	var transports []Transport
+	for _, factory := range muxTransportFactory.factories {
+		transport, err := factory.Create(conn)
+		if err != nil {
+			return nil, err
+		}
+		transports = append(transports, transport)
+	}
+
+	return NewMuxTransport(muxTransportFactory.names, transports)
+}
+
+type socks5TransportFactory struct {
+	factory Factory
+	config  *SOCKS5Config
+}
+
+// NewSOCKS5TransportFactory creates new Factory of transports produced by given factory and dialing through SOCKS5 proxy
+func NewSOCKS5TransportFactory(factory Factory, config *SOCKS5Config) Factory {
+	return &socks5TransportFactory{
+		factory: factory,
+		config:  config,
+	}
+}
+
+// Create creates new Transport
+func (socks5TransportFactory *socks5TransportFactory) Create(conn net.PacketConn) (Transport, error) {
+	transport, err := socks5TransportFactory.factory.Create(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSOCKS5Transport(transport, socks5TransportFactory.config)
 }
@@ -17,6 +17,9 @@
 package transport
 
 import (
+	"crypto/tls"
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/insolar/network/connection"
@@ -40,3 +43,171 @@ func TestMemoryStoreFactory_Create(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Implements(t, (*Transport)(nil), transport)
 }
+
+func TestNewTCPTransportFactory(t *testing.T) {
+	expectedFactory := &tcpTransportFactory{}
+	actualFactory := NewTCPTransportFactory()
+
+	assert.Equal(t, expectedFactory, actualFactory)
+}
+
+func TestTCPTransportFactory_Create(t *testing.T) {
+	conn, err := connection.NewConnectionFactory().Create("127.0.0.1:8081")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	transport, err := NewTCPTransportFactory().Create(conn)
+
+	assert.NoError(t, err)
+	assert.Implements(t, (*Transport)(nil), transport)
+}
+
+func TestNewKCPTransportFactory(t *testing.T) {
+	config := DefaultKCPConfig()
+	expectedFactory := &kcpTransportFactory{config: config}
+	actualFactory := NewKCPTransportFactory(config)
+
+	assert.Equal(t, expectedFactory, actualFactory)
+}
+
+func TestKCPTransportFactory_Create(t *testing.T) {
+	conn, err := connection.NewConnectionFactory().Create("127.0.0.1:8095")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	transport, err := NewKCPTransportFactory(nil).Create(conn)
+
+	assert.NoError(t, err)
+	assert.Implements(t, (*Transport)(nil), transport)
+}
+
+func TestNewDTLSTransportFactory(t *testing.T) {
+	config := &DTLSConfig{}
+	expectedFactory := &dtlsTransportFactory{config: config}
+	actualFactory := NewDTLSTransportFactory(config)
+
+	assert.Equal(t, expectedFactory, actualFactory)
+}
+
+func TestDTLSTransportFactory_Create(t *testing.T) {
+	conn, err := connection.NewConnectionFactory().Create("127.0.0.1:8150")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	certificate, _ := createDTLSCertificate(t)
+	transport, err := NewDTLSTransportFactory(&DTLSConfig{Certificate: certificate}).Create(conn)
+
+	assert.NoError(t, err)
+	assert.Implements(t, (*Transport)(nil), transport)
+}
+
+func TestNewTLSTransportFactory(t *testing.T) {
+	config := &tls.Config{}
+	expectedFactory := &tlsTransportFactory{factory: NewTCPTransportFactory(), config: config}
+	actualFactory := NewTLSTransportFactory(NewTCPTransportFactory(), config)
+
+	assert.Equal(t, expectedFactory, actualFactory)
+}
+
+func TestTLSTransportFactory_Create(t *testing.T) {
+	conn, err := connection.NewConnectionFactory().Create("127.0.0.1:8088")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	transport, err := NewTLSTransportFactory(NewTCPTransportFactory(), &tls.Config{}).Create(conn)
+
+	assert.NoError(t, err)
+	assert.Implements(t, (*Transport)(nil), transport)
+}
+
+func TestNewNoiseTransportFactory(t *testing.T) {
+	config := &NoiseConfig{}
+	expectedFactory := &noiseTransportFactory{factory: NewTCPTransportFactory(), config: config}
+	actualFactory := NewNoiseTransportFactory(NewTCPTransportFactory(), config)
+
+	assert.Equal(t, expectedFactory, actualFactory)
+}
+
+func TestNoiseTransportFactory_Create(t *testing.T) {
+	conn, err := connection.NewConnectionFactory().Create("127.0.0.1:8093")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	transport, err := NewNoiseTransportFactory(NewTCPTransportFactory(), createNoiseConfig(t)).Create(conn)
+
+	assert.NoError(t, err)
+	assert.Implements(t, (*Transport)(nil), transport)
+}
+
+func TestNewInMemoryTransportFactory(t *testing.T) {
+	network := NewInMemoryNetwork(0, 0)
+	expectedFactory := &inMemoryTransportFactory{network: network}
+	actualFactory := NewInMemoryTransportFactory(network)
+
+	assert.Equal(t, expectedFactory, actualFactory)
+}
+
+func TestInMemoryTransportFactory_Create(t *testing.T) {
+	conn, err := connection.NewConnectionFactory().Create("127.0.0.1:8094")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	transport, err := NewInMemoryTransportFactory(NewInMemoryNetwork(0, 0)).Create(conn)
+
+	assert.NoError(t, err)
+	assert.Implements(t, (*Transport)(nil), transport)
+}
+
+func TestNewUnixTransportFactory(t *testing.T) {
+	expectedFactory := &unixTransportFactory{directory: "/tmp"}
+	actualFactory := NewUnixTransportFactory("/tmp")
+
+	assert.Equal(t, expectedFactory, actualFactory)
+}
+
+func TestUnixTransportFactory_Create(t *testing.T) {
+	directory, err := ioutil.TempDir("", "network")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	conn, err := connection.NewConnectionFactory().Create("127.0.0.1:8111")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	transport, err := NewUnixTransportFactory(directory).Create(conn)
+
+	assert.NoError(t, err)
+	assert.Implements(t, (*Transport)(nil), transport)
+	assert.Equal(t, "unix", transport.LocalAddr().Network())
+}
+
+func TestNewMuxTransportFactory(t *testing.T) {
+	names := []string{"utp", "tcp"}
+	factories := []Factory{NewUTPTransportFactory(), NewTCPTransportFactory()}
+	expectedFactory := &muxTransportFactory{names: names, factories: factories}
+	actualFactory := NewMuxTransportFactory(names, factories)
+
+	assert.Equal(t, expectedFactory, actualFactory)
+}
+
+func TestMuxTransportFactory_Create(t *testing.T) {
+	conn, err := connection.NewConnectionFactory().Create("127.0.0.1:8098")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	transport, err := NewMuxTransportFactory(
+		[]string{"tcp", "memory"},
+		[]Factory{NewTCPTransportFactory(), NewInMemoryTransportFactory(NewInMemoryNetwork(0, 0))},
+	).Create(conn)
+
+	assert.NoError(t, err)
+	assert.Implements(t, (*Transport)(nil), transport)
+}
+
+func TestNewSOCKS5TransportFactory(t *testing.T) {
+	config := &SOCKS5Config{Address: "127.0.0.1:9050"}
+	expectedFactory := &socks5TransportFactory{factory: NewTCPTransportFactory(), config: config}
+	actualFactory := NewSOCKS5TransportFactory(NewTCPTransportFactory(), config)
+
+	assert.Equal(t, expectedFactory, actualFactory)
+}
@@ -0,0 +1,96 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// Faults describes network faults injected into outgoing packets of PacketConn
+type Faults struct {
+	// DropRate is fraction of packets which are silently dropped, from 0 to 1
+	DropRate float64
+
+	// Delay is latency added to every packet
+	Delay time.Duration
+
+	// ReorderRate is fraction of packets which are held back for ReorderDelay
+	// more, so packets sent after them arrive first, from 0 to 1
+	ReorderRate float64
+
+	// ReorderDelay is extra delay of reordered packets
+	ReorderDelay time.Duration
+
+	// Bandwidth limits outgoing bytes per second, writing blocks until packet fits. Unlimited if not set
+	Bandwidth int
+}
+
+// faultyPacketConn is a PacketConn injecting faults into outgoing packets
+type faultyPacketConn struct {
+	net.PacketConn
+	faults Faults
+
+	mutex     *sync.Mutex
+	bandwidth *tokenBucket
+}
+
+// WrapPacketConn returns PacketConn which drops, delays, reorders and throttles packets written to conn
+// according to faults. It is intended for chaos testing of packet based transports, e.g. uTP.
+func WrapPacketConn(conn net.PacketConn, faults Faults) net.PacketConn {
+	fc := &faultyPacketConn{
+		PacketConn: conn,
+		faults:     faults,
+		mutex:      &sync.Mutex{},
+	}
+	if faults.Bandwidth > 0 {
+		fc.bandwidth = newTokenBucket(faults.Bandwidth, time.Now())
+	}
+	return fc
+}
+
+// WriteTo writes packet to addr unless it is dropped, delayed packets are written in background
+func (c *faultyPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if c.bandwidth != nil {
+		c.mutex.Lock()
+		wait := c.bandwidth.take(len(p), time.Now())
+		c.mutex.Unlock()
+		time.Sleep(wait)
+	}
+
+	if c.faults.DropRate > 0 && rand.Float64() < c.faults.DropRate {
+		return len(p), nil
+	}
+
+	delay := c.faults.Delay
+	if c.faults.ReorderRate > 0 && rand.Float64() < c.faults.ReorderRate {
+		delay += c.faults.ReorderDelay
+	}
+	if delay == 0 {
+		return c.PacketConn.WriteTo(p, addr)
+	}
+
+	// Caller is free to reuse buffer once write returns
+	packet := append([]byte{}, p...)
+	time.AfterFunc(delay, func() {
+		// Packet is lost silently if connection is closed meanwhile
+		c.PacketConn.WriteTo(packet, addr)
+	})
+	return len(p), nil
+}
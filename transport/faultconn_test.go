@@ -0,0 +1,122 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createPacketConns(t *testing.T) (net.PacketConn, net.PacketConn) {
+	sender, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	receiver, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	return sender, receiver
+}
+
+func readPacket(conn net.PacketConn, timeout time.Duration) (string, error) {
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	n, _, err := conn.ReadFrom(buf)
+	return string(buf[:n]), err
+}
+
+func TestWrapPacketConn(t *testing.T) {
+	sender, receiver := createPacketConns(t)
+	defer sender.Close()
+	defer receiver.Close()
+
+	conn := WrapPacketConn(sender, Faults{})
+	assert.Equal(t, sender.LocalAddr(), conn.LocalAddr())
+
+	_, err := conn.WriteTo([]byte("packet"), receiver.LocalAddr())
+	assert.NoError(t, err)
+	packet, err := readPacket(receiver, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "packet", packet)
+}
+
+func TestWrapPacketConn_Drop(t *testing.T) {
+	sender, receiver := createPacketConns(t)
+	defer sender.Close()
+	defer receiver.Close()
+
+	conn := WrapPacketConn(sender, Faults{DropRate: 1})
+	n, err := conn.WriteTo([]byte("packet"), receiver.LocalAddr())
+	assert.NoError(t, err)
+	assert.Equal(t, 6, n)
+
+	_, err = readPacket(receiver, time.Millisecond*100)
+	assert.Error(t, err)
+}
+
+func TestWrapPacketConn_Delay(t *testing.T) {
+	sender, receiver := createPacketConns(t)
+	defer sender.Close()
+	defer receiver.Close()
+
+	conn := WrapPacketConn(sender, Faults{Delay: time.Millisecond * 100})
+	started := time.Now()
+	data := []byte("packet")
+	_, err := conn.WriteTo(data, receiver.LocalAddr())
+	assert.NoError(t, err)
+	// Buffer is copied and can be reused right away
+	copy(data, "reused")
+
+	packet, err := readPacket(receiver, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "packet", packet)
+	assert.True(t, time.Since(started) >= time.Millisecond*100)
+}
+
+func TestWrapPacketConn_Reorder(t *testing.T) {
+	sender, receiver := createPacketConns(t)
+	defer sender.Close()
+	defer receiver.Close()
+
+	conn := WrapPacketConn(sender, Faults{ReorderRate: 1, ReorderDelay: time.Millisecond * 100})
+	_, err := conn.WriteTo([]byte("first"), receiver.LocalAddr())
+	assert.NoError(t, err)
+	_, err = sender.WriteTo([]byte("second"), receiver.LocalAddr())
+	assert.NoError(t, err)
+
+	packet, err := readPacket(receiver, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", packet)
+	packet, err = readPacket(receiver, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", packet)
+}
+
+func TestWrapPacketConn_Bandwidth(t *testing.T) {
+	sender, receiver := createPacketConns(t)
+	defer sender.Close()
+	defer receiver.Close()
+
+	conn := WrapPacketConn(sender, Faults{Bandwidth: 10000})
+	started := time.Now()
+	// The first packet fits into one second burst, the second one waits for bandwidth
+	for i := 0; i < 2; i++ {
+		_, err := conn.WriteTo(make([]byte, 10000), receiver.LocalAddr())
+		assert.NoError(t, err)
+	}
+	assert.True(t, time.Since(started) >= time.Millisecond*900)
+}
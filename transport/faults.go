@@ -0,0 +1,186 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/insolar/network/node"
+)
+
+// FaultKind tells what failed on connection
+type FaultKind int
+
+const (
+	// FaultUnreachable means peer could not be connected because host or port is unreachable or connection was refused
+	FaultUnreachable = FaultKind(iota + 1)
+	// FaultDial means connection to peer could not be established for other reason
+	FaultDial
+	// FaultHandshake means connection was established but security or protocol handshake failed
+	FaultHandshake
+	// FaultWrite means message could not be written to established connection
+	FaultWrite
+	// FaultRead means established connection failed while message was read or its frame could not be decoded
+	FaultRead
+)
+
+// String returns name of fault kind
+func (k FaultKind) String() string {
+	switch k {
+	case FaultUnreachable:
+		return "unreachable"
+	case FaultDial:
+		return "dial"
+	case FaultHandshake:
+		return "handshake"
+	case FaultWrite:
+		return "write"
+	case FaultRead:
+		return "read"
+	default:
+		return "unknown"
+	}
+}
+
+// Fault is an error of connection with peer
+type Fault struct {
+	Kind FaultKind
+	// Peer is node message was sent to, nil for faults of accepted connections
+	Peer *node.Node
+	// Address is remote address of connection
+	Address string
+	Err     error
+	Time    time.Time
+}
+
+// faults passes faults of transport to handler. Zero handler drops them.
+type faults struct {
+	handler func(Fault)
+}
+
+// SetFaultHandler makes transport report faults of connections to handler, so systematic connectivity
+// problems can be told from single failed requests. Handler is called in its own goroutine.
+// It must be called before transport is started.
+func SetFaultHandler(transport Transport, handler func(Fault)) error {
+	switch t := transport.(type) {
+	case *streamTransport:
+		t.faults.handler = handler
+	case *muxTransport:
+		for _, st := range t.transports {
+			st.faults.handler = handler
+		}
+	default:
+		return errors.New("transport does not support fault handler")
+	}
+
+	return nil
+}
+
+func (f *faults) report(kind FaultKind, peer *node.Node, address string, err error) {
+	if f.handler == nil {
+		return
+	}
+	go f.handler(Fault{
+		Kind:    kind,
+		Peer:    peer,
+		Address: address,
+		Err:     err,
+		Time:    time.Now(),
+	})
+}
+
+// dialFailed reports error of connecting to peer
+func (f *faults) dialFailed(peer *node.Node, address string, err error) {
+	switch {
+	case isHandshakeError(nil, err):
+		f.report(FaultHandshake, peer, address, err)
+	case isUnreachable(err):
+		f.report(FaultUnreachable, peer, address, err)
+	default:
+		f.report(FaultDial, peer, address, err)
+	}
+}
+
+// readFailed reports error of reading accepted connection, connections closed by either side are not faults
+func (f *faults) readFailed(conn net.Conn, err error) {
+	if isClosed(err) {
+		return
+	}
+	address := conn.RemoteAddr().String()
+	switch {
+	case isHandshakeError(conn, err):
+		f.report(FaultHandshake, nil, address, err)
+	case isUnreachable(err):
+		f.report(FaultUnreachable, nil, address, err)
+	default:
+		f.report(FaultRead, nil, address, err)
+	}
+}
+
+// handshakeError is an error of security or protocol handshake
+type handshakeError struct {
+	err error
+}
+
+func (e *handshakeError) Error() string {
+	return e.err.Error()
+}
+
+// handshakeFailed marks err as handshake error
+func handshakeFailed(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*handshakeError); ok {
+		return err
+	}
+	return &handshakeError{err: err}
+}
+
+// isHandshakeError checks if err is handshake error. TLS connection, which reports handshake error
+// on first read, is checked for completed handshake.
+func isHandshakeError(conn net.Conn, err error) bool {
+	if _, ok := err.(*handshakeError); ok {
+		return true
+	}
+	tlsConn, ok := conn.(*tls.Conn)
+	return ok && !tlsConn.ConnectionState().HandshakeComplete
+}
+
+// isUnreachable checks if err is caused by refused connection or ICMP unreachable message
+func isUnreachable(err error) bool {
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+	if sysErr, ok := err.(*os.SyscallError); ok {
+		err = sysErr.Err
+	}
+	errno, ok := err.(syscall.Errno)
+	return ok && (errno == syscall.ECONNREFUSED || errno == syscall.EHOSTUNREACH || errno == syscall.ENETUNREACH)
+}
+
+// isClosed checks if err is caused by connection closed by peer or by transport itself
+func isClosed(err error) bool {
+	return err == io.EOF || strings.Contains(err.Error(), "use of closed network connection")
+}
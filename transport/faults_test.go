@@ -0,0 +1,90 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetFaultHandler_NotSupported(t *testing.T) {
+	err := SetFaultHandler(nil, func(Fault) {})
+	assert.EqualError(t, err, "transport does not support fault handler")
+}
+
+func TestStreamTransport_FaultUnreachable(t *testing.T) {
+	tp, tpNode := createTCPTransport(t, "127.0.0.1:8151")
+	faults := make(chan Fault, 1)
+	assert.NoError(t, SetFaultHandler(tp, func(fault Fault) {
+		faults <- fault
+	}))
+	done := startTransports(tp)
+	defer stopTransport(tp, done)
+
+	// Nothing listens on receiver address
+	address, _ := node.NewAddress("127.0.0.1:8152")
+	receiver := node.NewNode(address)
+	_, err := tp.SendRequest(message.NewPingMessage(tpNode, receiver))
+	assert.Error(t, err)
+
+	select {
+	case fault := <-faults:
+		assert.Equal(t, FaultUnreachable, fault.Kind)
+		assert.Equal(t, receiver, fault.Peer)
+		assert.Equal(t, "127.0.0.1:8152", fault.Address)
+	case <-time.After(time.Second):
+		assert.Fail(t, "fault is not reported")
+	}
+}
+
+func TestStreamTransport_FaultHandshake(t *testing.T) {
+	first, firstNode := createTCPTransport(t, "127.0.0.1:8153")
+	second, secondNode := createTCPTransport(t, "127.0.0.1:8154")
+
+	first, err := NewHandshakeTransport(first, &HandshakeConfig{})
+	assert.NoError(t, err)
+	second, err = NewHandshakeTransport(second, &HandshakeConfig{MinVersion: ProtocolVersion + 1})
+	assert.NoError(t, err)
+
+	firstFaults, secondFaults := make(chan Fault, 1), make(chan Fault, 1)
+	assert.NoError(t, SetFaultHandler(first, func(fault Fault) {
+		firstFaults <- fault
+	}))
+	assert.NoError(t, SetFaultHandler(second, func(fault Fault) {
+		secondFaults <- fault
+	}))
+	done := startTransports(first, second)
+	defer stopTransport(first, done)
+	defer stopTransport(second, done)
+
+	_, err = first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.Error(t, err)
+
+	for _, faults := range []chan Fault{firstFaults, secondFaults} {
+		select {
+		case fault := <-faults:
+			assert.Equal(t, FaultHandshake, fault.Kind)
+		case <-time.After(time.Second):
+			assert.Fail(t, "fault is not reported")
+		}
+	}
+}
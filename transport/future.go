@@ -17,20 +17,43 @@
 package transport
 
 import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/insolar/network/message"
 	"github.com/insolar/network/node"
 )
 
+var (
+	// ErrFutureCancelled is returned for Future which was cancelled before response came
+	ErrFutureCancelled = errors.New("request cancelled")
+	// ErrFutureTimeout is returned for Future which did not get response in time
+	ErrFutureTimeout = errors.New("request timed out")
+)
+
 // Future is network response future
 type Future interface {
 	ID() message.RequestID
 	Actor() *node.Node
 	Request() *message.Message
+	StartTime() time.Time
 
+	// Result returns channel response is sent to, it is closed once Future is cancelled
 	Result() <-chan *message.Message
 	SetResult(*message.Message)
 
+	// Wait waits for response until Future or ctx is done. Future is cancelled if ctx is done first,
+	// it is timed out if ctx deadline is exceeded.
+	Wait(ctx context.Context) (*message.Message, error)
+	// Err returns ErrFutureCancelled or ErrFutureTimeout if Future is done without response, nil otherwise
+	Err() error
+
 	Cancel()
+	Timeout()
+	TimedOut() bool
 }
 
 // CancelCallback is a callback function executed when cancelling Future
@@ -42,16 +65,25 @@ type future struct {
 	request        *message.Message
 	requestID      message.RequestID
 	cancelCallback CancelCallback
+	startTime      time.Time
+	cancelOnce     *sync.Once
+	resultMutex    *sync.Mutex
+	timedOut       int32
+	hasResult      int32
+	cancelled      int32
 }
 
 // NewFuture creates new Future
 func NewFuture(requestID message.RequestID, actor *node.Node, msg *message.Message, cancelCallback CancelCallback) Future {
 	return &future{
-		result:         make(chan *message.Message),
+		result:         make(chan *message.Message, 1),
 		actor:          actor,
 		request:        msg,
 		requestID:      requestID,
 		cancelCallback: cancelCallback,
+		startTime:      time.Now(),
+		cancelOnce:     &sync.Once{},
+		resultMutex:    &sync.Mutex{},
 	}
 }
 
@@ -75,13 +107,76 @@ func (future *future) Result() <-chan *message.Message {
 	return future.result
 }
 
-// SetResult write message to the result channel
+// SetResult write message to the result channel, it does not block.
+// Message is dropped if Future is already cancelled or has result.
 func (future *future) SetResult(msg *message.Message) {
+	future.resultMutex.Lock()
+	defer future.resultMutex.Unlock()
+
+	if atomic.LoadInt32(&future.cancelled) == 1 || atomic.LoadInt32(&future.hasResult) == 1 {
+		return
+	}
+	atomic.StoreInt32(&future.hasResult, 1)
 	future.result <- msg
 }
 
+// Wait waits for response until Future or ctx is done
+func (future *future) Wait(ctx context.Context) (*message.Message, error) {
+	select {
+	case msg, ok := <-future.result:
+		if !ok {
+			return nil, future.Err()
+		}
+		return msg, nil
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			future.Timeout()
+		} else {
+			future.Cancel()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Err returns reason Future is done without response
+func (future *future) Err() error {
+	switch {
+	case atomic.LoadInt32(&future.hasResult) == 1 || atomic.LoadInt32(&future.cancelled) == 0:
+		return nil
+	case future.TimedOut():
+		return ErrFutureTimeout
+	default:
+		return ErrFutureCancelled
+	}
+}
+
+// StartTime returns time when request was sent
+func (future *future) StartTime() time.Time {
+	return future.startTime
+}
+
 // Cancel allows to cancel Future processing
+// It is safe to call Cancel multiple times
 func (future *future) Cancel() {
-	close(future.result)
-	future.cancelCallback(future)
+	future.cancelOnce.Do(func() {
+		// Result channel is closed under the same lock SetResult sends with,
+		// so cancelling concurrently with response does not send on closed channel
+		future.resultMutex.Lock()
+		atomic.StoreInt32(&future.cancelled, 1)
+		close(future.result)
+		future.resultMutex.Unlock()
+
+		future.cancelCallback(future)
+	})
+}
+
+// Timeout cancels Future which did not get result in time
+func (future *future) Timeout() {
+	atomic.StoreInt32(&future.timedOut, 1)
+	future.Cancel()
+}
+
+// TimedOut checks if Future was cancelled with Timeout
+func (future *future) TimedOut() bool {
+	return atomic.LoadInt32(&future.timedOut) == 1
 }
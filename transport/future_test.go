@@ -17,7 +17,10 @@
 package transport
 
 import (
+	"context"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/insolar/network/message"
 	"github.com/insolar/network/node"
@@ -108,3 +111,138 @@ func TestFuture_Cancel(t *testing.T) {
 	assert.False(t, closed)
 	assert.True(t, cbCalled)
 }
+
+func TestFuture_StartTime(t *testing.T) {
+	addr, _ := node.NewAddress("127.0.0.1:8080")
+	n := node.NewNode(addr)
+	cb := func(f Future) {}
+	m := &message.Message{}
+	before := time.Now()
+	f := NewFuture(message.RequestID(1), n, m, cb)
+	assert.False(t, f.StartTime().Before(before))
+	assert.False(t, f.StartTime().After(time.Now()))
+}
+
+func TestFuture_Cancel_Twice(t *testing.T) {
+	addr, _ := node.NewAddress("127.0.0.1:8080")
+	n := node.NewNode(addr)
+	cbCalled := 0
+	cb := func(f Future) { cbCalled++ }
+	m := &message.Message{}
+	f := NewFuture(message.RequestID(1), n, m, cb)
+	f.Cancel()
+	f.Cancel()
+	assert.Equal(t, 1, cbCalled)
+}
+
+func TestFuture_Timeout(t *testing.T) {
+	addr, _ := node.NewAddress("127.0.0.1:8080")
+	n := node.NewNode(addr)
+	var timedOut bool
+	cb := func(f Future) { timedOut = f.TimedOut() }
+	m := &message.Message{}
+	f := NewFuture(message.RequestID(1), n, m, cb)
+
+	assert.False(t, f.TimedOut())
+	f.Timeout()
+
+	_, closed := <-f.Result()
+	assert.False(t, closed)
+	assert.True(t, timedOut)
+}
+
+func TestFuture_Wait(t *testing.T) {
+	addr, _ := node.NewAddress("127.0.0.1:8080")
+	n := node.NewNode(addr)
+	cb := func(f Future) {}
+	m := &message.Message{}
+	f := NewFuture(message.RequestID(1), n, m, cb)
+
+	go func() {
+		f.SetResult(m)
+		f.Cancel()
+	}()
+
+	result, err := f.Wait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, m, result)
+	assert.NoError(t, f.Err())
+}
+
+func TestFuture_Wait_Cancelled(t *testing.T) {
+	addr, _ := node.NewAddress("127.0.0.1:8080")
+	n := node.NewNode(addr)
+	cb := func(f Future) {}
+	m := &message.Message{}
+
+	f := NewFuture(message.RequestID(1), n, m, cb)
+	assert.NoError(t, f.Err())
+	f.Cancel()
+	_, err := f.Wait(context.Background())
+	assert.Equal(t, ErrFutureCancelled, err)
+
+	f = NewFuture(message.RequestID(2), n, m, cb)
+	f.Timeout()
+	_, err = f.Wait(context.Background())
+	assert.Equal(t, ErrFutureTimeout, err)
+}
+
+func TestFuture_Wait_Context(t *testing.T) {
+	addr, _ := node.NewAddress("127.0.0.1:8080")
+	n := node.NewNode(addr)
+	cb := func(f Future) {}
+	m := &message.Message{}
+
+	f := NewFuture(message.RequestID(1), n, m, cb)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err := f.Wait(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, f.TimedOut())
+	assert.Equal(t, ErrFutureTimeout, f.Err())
+
+	f = NewFuture(message.RequestID(2), n, m, cb)
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = f.Wait(ctx)
+	assert.Equal(t, context.Canceled, err)
+	assert.False(t, f.TimedOut())
+	assert.Equal(t, ErrFutureCancelled, f.Err())
+}
+
+func TestFuture_SetResult_Cancelled(t *testing.T) {
+	addr, _ := node.NewAddress("127.0.0.1:8080")
+	n := node.NewNode(addr)
+	cb := func(f Future) {}
+	m := &message.Message{}
+
+	for i := 0; i < 100; i++ {
+		f := NewFuture(message.RequestID(i), n, m, cb)
+
+		wg := &sync.WaitGroup{}
+		wg.Add(2)
+		go func() {
+			f.SetResult(m)
+			wg.Done()
+		}()
+		go func() {
+			f.Cancel()
+			wg.Done()
+		}()
+		wg.Wait()
+
+		result, ok := <-f.Result()
+		if ok {
+			assert.Equal(t, m, result)
+			assert.NoError(t, f.Err())
+		} else {
+			assert.Equal(t, ErrFutureCancelled, f.Err())
+		}
+	}
+
+	f := NewFuture(message.RequestID(0), n, m, cb)
+	f.Cancel()
+	f.SetResult(m)
+	_, ok := <-f.Result()
+	assert.False(t, ok)
+}
@@ -0,0 +1,361 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+)
+
+const (
+	// ProtocolVersion is version of wire protocol spoken by this release
+	ProtocolVersion = 1
+
+	// CodecGob is codec of messages encoded with encoding/gob
+	CodecGob = "gob"
+
+	// CapabilityCompression is advertised by transports with compression enabled
+	CapabilityCompression = "compression"
+	// CapabilityKeepalive is advertised by transports with keepalive probes enabled
+	CapabilityKeepalive = "keepalive"
+
+	handshakeTimeout = time.Second * 5
+)
+
+var handshakeMagic = []byte("insHELO")
+
+// HandshakeConfig configures protocol handshake
+type HandshakeConfig struct {
+	// MinVersion is the lowest protocol version accepted from peers, zero accepts any
+	MinVersion int
+
+	// Codecs are supported message codecs in order of preference. If empty, codecs registered
+	// with message.RegisterCodec are supported, the one set with SetCodec is preferred
+	Codecs []string
+
+	// Capabilities are optional features advertised in addition to ones enabled on transport
+	Capabilities []string
+
+	// OnHandshake is called with peer address and negotiated parameters after successful handshake if not nil
+	OnHandshake func(address string, negotiated Negotiated)
+}
+
+// Negotiated is wire format agreed on by peers during handshake
+type Negotiated struct {
+	Version      int
+	Codec        string
+	Capabilities []string
+}
+
+type handshakeHello struct {
+	Version      int
+	MinVersion   int
+	Codecs       []string
+	Capabilities []string
+}
+
+type handshakeReply struct {
+	Negotiated
+	Error string
+}
+
+type handshakeSocket struct {
+	socket    socket
+	transport *streamTransport
+	config    *HandshakeConfig
+}
+
+type handshakeConn struct {
+	net.Conn
+
+	socket    *handshakeSocket
+	initiator bool
+
+	handshakeOnce *sync.Once
+	handshakeErr  error
+}
+
+// NewHandshakeTransport makes peers exchange protocol version, codecs and capabilities
+// before any message is sent over connections of given transport.
+// Connection is refused if peers have no compatible wire format.
+// It must be called before transport is started.
+func NewHandshakeTransport(transport Transport, config *HandshakeConfig) (Transport, error) {
+	st, ok := transport.(*streamTransport)
+	if !ok {
+		return nil, errors.New("transport does not support handshake")
+	}
+
+	st.socket = &handshakeSocket{
+		socket:    st.socket,
+		transport: st,
+		config:    config,
+	}
+
+	return st, nil
+}
+
+// Accept waits for the next incoming connection, handshake is performed on first read
+func (s *handshakeSocket) Accept() (net.Conn, error) {
+	conn, err := s.socket.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return newHandshakeConn(conn, s, false), nil
+}
+
+// Dial connects to given address and performs handshake
+func (s *handshakeSocket) Dial(address string) (net.Conn, error) {
+	return s.DialNode(address, nil)
+}
+
+// DialNode connects to node with given id and performs handshake
+func (s *handshakeSocket) DialNode(address string, id node.ID) (net.Conn, error) {
+	conn, err := dialNode(s.socket, address, id)
+	if err != nil {
+		return nil, err
+	}
+
+	hc := newHandshakeConn(conn, s, true)
+	err = hc.Handshake()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return hc, nil
+}
+
+// Addr returns underlying socket address
+func (s *handshakeSocket) Addr() net.Addr {
+	return s.socket.Addr()
+}
+
+// Close closes underlying socket
+func (s *handshakeSocket) Close() error {
+	return s.socket.Close()
+}
+
+// hello returns versions, codecs and capabilities supported by this node
+func (s *handshakeSocket) hello() *handshakeHello {
+	hello := &handshakeHello{
+		Version:      ProtocolVersion,
+		MinVersion:   s.config.MinVersion,
+		Codecs:       s.config.Codecs,
+		Capabilities: append([]string{}, s.config.Capabilities...),
+	}
+	if len(hello.Codecs) == 0 {
+		if preferred, ok := message.GetCodec(s.transport.codecs.preferred); ok {
+			hello.Codecs = []string{preferred.Name()}
+		}
+		for _, codec := range message.CodecNames() {
+			hello.Codecs = appendMissing(hello.Codecs, codec)
+		}
+	}
+	if s.transport.compression != nil {
+		hello.Capabilities = appendMissing(hello.Capabilities, CapabilityCompression)
+	}
+	if s.transport.keepalive != nil {
+		hello.Capabilities = appendMissing(hello.Capabilities, CapabilityKeepalive)
+	}
+	return hello
+}
+
+func newHandshakeConn(conn net.Conn, socket *handshakeSocket, initiator bool) *handshakeConn {
+	return &handshakeConn{
+		Conn:          conn,
+		socket:        socket,
+		initiator:     initiator,
+		handshakeOnce: &sync.Once{},
+	}
+}
+
+// Handshake runs protocol handshake if it has not been run yet
+func (c *handshakeConn) Handshake() error {
+	c.handshakeOnce.Do(func() {
+		c.handshakeErr = handshakeFailed(c.handshake())
+	})
+	return c.handshakeErr
+}
+
+// PeerID returns identity of remote node authenticated by underlying connection
+func (c *handshakeConn) PeerID() node.ID {
+	return peerID(c.Conn)
+}
+
+// Read reads data from connection after handshake
+func (c *handshakeConn) Read(b []byte) (int, error) {
+	err := c.Handshake()
+	if err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+// Write writes data to connection after handshake
+func (c *handshakeConn) Write(b []byte) (int, error) {
+	err := c.Handshake()
+	if err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
+
+func (c *handshakeConn) handshake() error {
+	err := c.Conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	if err != nil {
+		return err
+	}
+
+	local := c.socket.hello()
+	var negotiated *Negotiated
+	if c.initiator {
+		negotiated, err = c.initiate(local)
+	} else {
+		negotiated, err = c.respond(local)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.socket.config.OnHandshake != nil {
+		c.socket.config.OnHandshake(c.Conn.RemoteAddr().String(), *negotiated)
+	}
+
+	return c.Conn.SetDeadline(time.Time{})
+}
+
+// initiate sends hello to peer and checks wire format it chose
+func (c *handshakeConn) initiate(local *handshakeHello) (*Negotiated, error) {
+	err := writeHandshakeFrame(c.Conn, local)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &handshakeReply{}
+	err = readHandshakeFrame(c.Conn, reply)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Error != "" {
+		return nil, errors.New("peer refused handshake: " + reply.Error)
+	}
+	if reply.Version > local.Version || reply.Version < local.MinVersion {
+		return nil, errors.New("incompatible protocol version")
+	}
+	if !contains(local.Codecs, reply.Codec) {
+		return nil, errors.New("no common codec")
+	}
+
+	return &reply.Negotiated, nil
+}
+
+// respond receives hello from peer and replies with negotiated wire format or refusal
+func (c *handshakeConn) respond(local *handshakeHello) (*Negotiated, error) {
+	remote := &handshakeHello{}
+	err := readHandshakeFrame(c.Conn, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	negotiated, err := negotiate(local, remote)
+	reply := &handshakeReply{}
+	if err != nil {
+		reply.Error = err.Error()
+	} else {
+		reply.Negotiated = *negotiated
+	}
+
+	writeErr := writeHandshakeFrame(c.Conn, reply)
+	if err != nil {
+		return nil, err
+	}
+	return negotiated, writeErr
+}
+
+// negotiate picks the highest common protocol version, codec preferred by remote peer
+// and capabilities supported by both peers
+func negotiate(local, remote *handshakeHello) (*Negotiated, error) {
+	version := local.Version
+	if remote.Version < version {
+		version = remote.Version
+	}
+	if version < local.MinVersion || version < remote.MinVersion {
+		return nil, errors.New("incompatible protocol version")
+	}
+
+	negotiated := &Negotiated{Version: version}
+	for _, codec := range remote.Codecs {
+		if contains(local.Codecs, codec) {
+			negotiated.Codec = codec
+			break
+		}
+	}
+	if negotiated.Codec == "" {
+		return nil, errors.New("no common codec")
+	}
+
+	for _, capability := range remote.Capabilities {
+		if contains(local.Capabilities, capability) {
+			negotiated.Capabilities = append(negotiated.Capabilities, capability)
+		}
+	}
+
+	return negotiated, nil
+}
+
+func writeHandshakeFrame(conn net.Conn, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeNoiseFrame(conn, append(append([]byte{}, handshakeMagic...), data...))
+}
+
+func readHandshakeFrame(conn net.Conn, v interface{}) error {
+	frame, err := readNoiseFrame(conn)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(frame, handshakeMagic) {
+		return errors.New("peer does not support handshake")
+	}
+	return json.Unmarshal(frame[len(handshakeMagic):], v)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func appendMissing(values []string, value string) []string {
+	if contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
@@ -0,0 +1,131 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"testing"
+
+	"github.com/insolar/network/message"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHandshakeTransport_NotSupported(t *testing.T) {
+	_, err := NewHandshakeTransport(nil, &HandshakeConfig{})
+	assert.EqualError(t, err, "transport does not support handshake")
+}
+
+func TestNegotiate(t *testing.T) {
+	local := &handshakeHello{Version: 2, Codecs: []string{"gob", "json"}, Capabilities: []string{"compression", "keepalive"}}
+	remote := &handshakeHello{Version: 1, Codecs: []string{"json", "gob"}, Capabilities: []string{"keepalive", "other"}}
+
+	negotiated, err := negotiate(local, remote)
+	assert.NoError(t, err)
+	assert.Equal(t, &Negotiated{Version: 1, Codec: "json", Capabilities: []string{"keepalive"}}, negotiated)
+}
+
+func TestNegotiate_IncompatibleVersion(t *testing.T) {
+	local := &handshakeHello{Version: 2, MinVersion: 2, Codecs: []string{"gob"}}
+	remote := &handshakeHello{Version: 1, Codecs: []string{"gob"}}
+
+	_, err := negotiate(local, remote)
+	assert.EqualError(t, err, "incompatible protocol version")
+
+	_, err = negotiate(remote, local)
+	assert.EqualError(t, err, "incompatible protocol version")
+}
+
+func TestNegotiate_NoCommonCodec(t *testing.T) {
+	local := &handshakeHello{Version: 1, Codecs: []string{"gob"}}
+	remote := &handshakeHello{Version: 1, Codecs: []string{"json"}}
+
+	_, err := negotiate(local, remote)
+	assert.EqualError(t, err, "no common codec")
+}
+
+func TestHandshakeTransport_SendRequest(t *testing.T) {
+	var negotiated Negotiated
+	firstConfig := &HandshakeConfig{
+		Capabilities: []string{"custom"},
+		OnHandshake: func(address string, n Negotiated) {
+			negotiated = n
+		},
+	}
+
+	first, firstNode := createTCPTransport(t, "127.0.0.1:8128")
+	second, secondNode := createTCPTransport(t, "127.0.0.1:8129")
+
+	err := SetCompression(second, 1024)
+	assert.NoError(t, err)
+	err = SetCompression(first, 1024)
+	assert.NoError(t, err)
+
+	first, err = NewHandshakeTransport(first, firstConfig)
+	assert.NoError(t, err)
+	second, err = NewHandshakeTransport(second, &HandshakeConfig{})
+	assert.NoError(t, err)
+
+	done := make(chan bool)
+	for _, tp := range []Transport{first, second} {
+		go func(tp Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+	}
+
+	future, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+	assert.Equal(t, Negotiated{Version: ProtocolVersion, Codec: CodecGob, Capabilities: []string{CapabilityCompression}}, negotiated)
+
+	request := <-second.Messages()
+	assert.Equal(t, firstNode.ID, request.Sender.ID)
+
+	response := message.NewBuilder().Sender(secondNode).Receiver(firstNode).Type(message.TypePing).Response(nil).Build()
+	err = second.SendResponse(request.RequestID, response)
+	assert.NoError(t, err)
+
+	result := <-future.Result()
+	assert.Equal(t, secondNode.ID, result.Sender.ID)
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestHandshakeTransport_SendRequest_Refused(t *testing.T) {
+	first, firstNode := createTCPTransport(t, "127.0.0.1:8130")
+	second, secondNode := createTCPTransport(t, "127.0.0.1:8131")
+
+	first, err := NewHandshakeTransport(first, &HandshakeConfig{})
+	assert.NoError(t, err)
+	second, err = NewHandshakeTransport(second, &HandshakeConfig{MinVersion: ProtocolVersion + 1})
+	assert.NoError(t, err)
+
+	done := make(chan bool)
+	for _, tp := range []Transport{first, second} {
+		go func(tp Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+	}
+
+	_, err = first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.EqualError(t, err, "peer refused handshake: incompatible protocol version")
+	assert.Empty(t, first.PendingRequests())
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
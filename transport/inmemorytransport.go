@@ -0,0 +1,223 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/clock"
+)
+
+// InMemoryNetwork routes messages between in-memory transports of the same process
+type InMemoryNetwork struct {
+	latency time.Duration
+	loss    float64
+	clock   clock.Clock
+
+	mutex   *sync.RWMutex
+	sockets map[string]*inMemorySocket
+}
+
+// NewInMemoryNetwork creates new InMemoryNetwork. Every message is delivered after latency
+// and is dropped with probability loss (from 0 to 1).
+func NewInMemoryNetwork(latency time.Duration, loss float64) *InMemoryNetwork {
+	return &InMemoryNetwork{
+		latency: latency,
+		loss:    loss,
+		clock:   clock.New(),
+		mutex:   &sync.RWMutex{},
+		sockets: make(map[string]*inMemorySocket),
+	}
+}
+
+// SetClock sets clock latency is measured by, e.g. clock.Virtual shared with simulated DHTs.
+// It must be called before any message is sent.
+func (network *InMemoryNetwork) SetClock(c clock.Clock) {
+	network.clock = c
+}
+
+// NewInMemoryTransport creates new Transport attached to network at given address.
+// It does not bind any real sockets and can't be wrapped in TLS or Noise.
+func NewInMemoryTransport(network *InMemoryNetwork, address string) (Transport, error) {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+
+	if _, exists := network.sockets[address]; exists {
+		return nil, errors.New("address already in use")
+	}
+
+	socket := &inMemorySocket{
+		network:   network,
+		address:   inMemoryAddr(address),
+		accepted:  make(chan net.Conn),
+		closed:    make(chan bool),
+		closeOnce: &sync.Once{},
+	}
+	network.sockets[address] = socket
+
+	return newStreamTransport(socket), nil
+}
+
+func (network *InMemoryNetwork) getSocket(address string) *inMemorySocket {
+	network.mutex.RLock()
+	defer network.mutex.RUnlock()
+
+	return network.sockets[address]
+}
+
+func (network *InMemoryNetwork) removeSocket(address string) {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+
+	delete(network.sockets, address)
+}
+
+func (network *InMemoryNetwork) deliver(receiver *inMemorySocket, conn *inMemoryConn) {
+	network.clock.Sleep(network.latency)
+
+	if network.loss > 0 && rand.Float64() < network.loss {
+		return
+	}
+
+	select {
+	case receiver.accepted <- conn:
+	case <-receiver.closed:
+	}
+}
+
+type inMemoryAddr string
+
+// Network returns name of the network
+func (addr inMemoryAddr) Network() string {
+	return "memory"
+}
+
+// String returns address
+func (addr inMemoryAddr) String() string {
+	return string(addr)
+}
+
+type inMemorySocket struct {
+	network *InMemoryNetwork
+	address inMemoryAddr
+
+	accepted  chan net.Conn
+	closed    chan bool
+	closeOnce *sync.Once
+}
+
+// Accept waits for the next delivered connection
+func (s *inMemorySocket) Accept() (net.Conn, error) {
+	select {
+	case conn := <-s.accepted:
+		return conn, nil
+	case <-s.closed:
+		return nil, errors.New("closed")
+	}
+}
+
+// Dial creates connection to given address, every write is delivered separately
+func (s *inMemorySocket) Dial(address string) (net.Conn, error) {
+	receiver := s.network.getSocket(address)
+	if receiver == nil {
+		return nil, errors.New("address unreachable")
+	}
+
+	conn := &inMemoryConn{local: s.address, remote: inMemoryAddr(address)}
+	conn.onWrite = func(data []byte) {
+		buffer := bytes.NewBuffer(append([]byte{}, data...))
+		go s.network.deliver(receiver, &inMemoryConn{local: conn.remote, remote: conn.local, buffer: buffer})
+	}
+
+	return conn, nil
+}
+
+// Addr returns socket address
+func (s *inMemorySocket) Addr() net.Addr {
+	return s.address
+}
+
+// Close detaches socket from network
+func (s *inMemorySocket) Close() error {
+	s.closeOnce.Do(func() {
+		s.network.removeSocket(s.address.String())
+		close(s.closed)
+	})
+	return nil
+}
+
+// inMemoryConn is a one-way connection, data written to it is read by the other side
+type inMemoryConn struct {
+	local  inMemoryAddr
+	remote inMemoryAddr
+
+	buffer  *bytes.Buffer
+	onWrite func([]byte)
+}
+
+// Read reads data from connection
+func (c *inMemoryConn) Read(b []byte) (int, error) {
+	if c.buffer == nil {
+		return 0, io.EOF
+	}
+	return c.buffer.Read(b)
+}
+
+// Write delivers data to the other side
+func (c *inMemoryConn) Write(b []byte) (int, error) {
+	if c.onWrite == nil {
+		return 0, errors.New("connection is read-only")
+	}
+	c.onWrite(b)
+	return len(b), nil
+}
+
+// Close does nothing as written data is already delivered
+func (c *inMemoryConn) Close() error {
+	return nil
+}
+
+// LocalAddr returns local address
+func (c *inMemoryConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// RemoteAddr returns remote address
+func (c *inMemoryConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// SetDeadline does nothing as in-memory connection never blocks
+func (c *inMemoryConn) SetDeadline(t time.Time) error {
+	return nil
+}
+
+// SetReadDeadline does nothing as in-memory connection never blocks
+func (c *inMemoryConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+// SetWriteDeadline does nothing as in-memory connection never blocks
+func (c *inMemoryConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
@@ -0,0 +1,117 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createInMemoryTransport(t *testing.T, network *InMemoryNetwork, address string) (Transport, *node.Node) {
+	tp, err := NewInMemoryTransport(network, address)
+	assert.NoError(t, err)
+
+	addr, _ := node.NewAddress(address)
+	n := node.NewNode(addr)
+	n.ID, _ = node.NewID()
+
+	return tp, n
+}
+
+func startTransports(transports ...Transport) chan bool {
+	done := make(chan bool)
+	for _, tp := range transports {
+		go func(tp Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+	}
+	return done
+}
+
+func TestNewInMemoryTransport_AddressInUse(t *testing.T) {
+	network := NewInMemoryNetwork(0, 0)
+
+	_, err := NewInMemoryTransport(network, "127.0.0.1:31337")
+	assert.NoError(t, err)
+	_, err = NewInMemoryTransport(network, "127.0.0.1:31337")
+	assert.EqualError(t, err, "address already in use")
+}
+
+func TestInMemoryTransport_SendRequest(t *testing.T) {
+	network := NewInMemoryNetwork(time.Millisecond*50, 0)
+	first, firstNode := createInMemoryTransport(t, network, "127.0.0.1:31337")
+	second, secondNode := createInMemoryTransport(t, network, "127.0.0.2:31338")
+	done := startTransports(first, second)
+
+	future, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+
+	request := <-second.Messages()
+	assert.Equal(t, firstNode.ID, request.Sender.ID)
+	assert.Equal(t, "127.0.0.1:31337", request.RemoteAddress())
+
+	response := message.NewBuilder().Sender(secondNode).Receiver(firstNode).Type(message.TypePing).Response(nil).Build()
+	err = second.SendResponse(request.RequestID, response)
+	assert.NoError(t, err)
+
+	result := <-future.Result()
+	assert.Equal(t, secondNode.ID, result.Sender.ID)
+	assert.True(t, time.Since(future.StartTime()) >= time.Millisecond*100)
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestInMemoryTransport_SendRequest_Loss(t *testing.T) {
+	network := NewInMemoryNetwork(0, 1)
+	first, firstNode := createInMemoryTransport(t, network, "127.0.0.1:31337")
+	second, secondNode := createInMemoryTransport(t, network, "127.0.0.2:31338")
+	done := startTransports(first, second)
+
+	_, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+
+	select {
+	case <-second.Messages():
+		assert.Fail(t, "message should be dropped")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestInMemoryTransport_SendRequest_Unreachable(t *testing.T) {
+	network := NewInMemoryNetwork(0, 0)
+	first, firstNode := createInMemoryTransport(t, network, "127.0.0.1:31337")
+	second, secondNode := createInMemoryTransport(t, network, "127.0.0.2:31338")
+	done := startTransports(first, second)
+
+	stopTransport(second, done)
+
+	_, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.EqualError(t, err, "address unreachable")
+	assert.Empty(t, first.PendingRequests())
+
+	stopTransport(first, done)
+}
@@ -0,0 +1,99 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package utp
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// Config tunes packet size and congestion control of connections of a Socket.
+// Zero Config keeps upstream behaviour: packets fill minimum MTU and only receive
+// window advertised by peer limits bytes in flight.
+type Config struct {
+	// MaxPacketSize caps size of datagrams sent including uTP header
+	MaxPacketSize int
+
+	// PacketSize returns packet size for connection to given address, e.g. discovered
+	// path MTU. It is capped by MaxPacketSize, zero result means MaxPacketSize.
+	// It is called with Socket lock held and must not block
+	PacketSize func(addr net.Addr) int
+
+	// MinWindow and MaxWindow bound congestion window, that is bytes in flight to peer.
+	// Without TargetDelay window is fixed at MaxWindow
+	MinWindow int
+	MaxWindow int
+
+	// TargetDelay enables LEDBAT congestion control (BEP 29): congestion window grows
+	// while queuing delay measured by peer is below target and shrinks above it
+	TargetDelay time.Duration
+}
+
+func (config Config) validate() error {
+	if config.MaxPacketSize < 0 || config.MinWindow < 0 || config.MaxWindow < 0 || config.TargetDelay < 0 {
+		return errors.New("config values must not be negative")
+	}
+	if config.MaxPacketSize > 0 && config.MaxPacketSize <= maxHeaderSize {
+		return errors.New("max packet size doesn't fit uTP header")
+	}
+	if config.MaxPacketSize > maxRecvSize {
+		return errors.New("max packet size exceeds size of packets read by peers")
+	}
+	if config.MaxWindow > 0 && config.MinWindow > config.MaxWindow {
+		return errors.New("min window exceeds max window")
+	}
+	return nil
+}
+
+// maxPayload returns payload size of packets sent to given address
+func (config Config) maxPayload(addr net.Addr) int {
+	size := config.MaxPacketSize
+	if config.PacketSize != nil {
+		if n := config.PacketSize(addr); n > maxHeaderSize && (size == 0 || n < size) {
+			size = n
+		}
+	}
+	if size == 0 {
+		return maxPayloadSize
+	}
+	return size - maxHeaderSize
+}
+
+// congestion returns congestion control of a new connection, nil if window isn't limited
+func (config Config) congestion(payload int) *congestion {
+	if config.TargetDelay == 0 && config.MaxWindow == 0 {
+		return nil
+	}
+
+	min := uint32(config.MinWindow)
+	if min < uint32(payload) {
+		min = uint32(payload)
+	}
+	max := uint32(config.MaxWindow)
+	if max == 0 {
+		max = readBufferLen
+	}
+	if min > max {
+		min = max
+	}
+
+	if config.TargetDelay == 0 {
+		return &congestion{window: max, min: max, max: max}
+	}
+	return newCongestion(min, max, uint32(payload), config.TargetDelay)
+}
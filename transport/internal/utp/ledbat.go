@@ -0,0 +1,148 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package utp
+
+import (
+	"time"
+)
+
+const (
+	// ledbatGain is window growth per round trip in packets when there is no queuing delay
+	ledbatGain = 1
+	// ledbatInitialPackets is initial congestion window in packets
+	ledbatInitialPackets = 2
+	// baseDelayInterval is period base delay minimum is kept for, base delay is the lowest
+	// of current and previous period, so that it follows route changes
+	baseDelayInterval = time.Minute
+)
+
+// congestion is congestion window of a connection. Delays are one-way delays of packets
+// sent to peer in microseconds, as measured by peer and echoed in TimestampDiff. They include
+// clock offset of peers which cancels out when base delay is subtracted.
+type congestion struct {
+	window   uint32
+	min, max uint32
+	mss      uint32
+	// target is target queuing delay in microseconds, zero disables LEDBAT and window stays fixed
+	target uint32
+
+	sampled      bool
+	delay        uint32
+	currentBase  uint32
+	previousBase uint32
+	baseStarted  time.Time
+
+	lastDecrease time.Time
+}
+
+func newCongestion(min, max, mss uint32, target time.Duration) *congestion {
+	window := ledbatInitialPackets * mss
+	if window < min {
+		window = min
+	}
+	if window > max {
+		window = max
+	}
+	return &congestion{
+		window: window,
+		min:    min,
+		max:    max,
+		mss:    mss,
+		target: uint32(target / time.Microsecond),
+	}
+}
+
+// delayLess compares delays modulo 2^32 as uTP timestamps wrap
+func delayLess(a, b uint32) bool {
+	return int32(a-b) < 0
+}
+
+// allows reports if more data can be sent with given bytes in flight
+func (cc *congestion) allows(inFlight uint32) bool {
+	return cc == nil || inFlight < cc.window
+}
+
+// sample records one-way delay echoed by peer, zero means peer has no measurement yet
+func (cc *congestion) sample(delay uint32, now time.Time) {
+	if cc == nil || cc.target == 0 || delay == 0 {
+		return
+	}
+
+	switch {
+	case !cc.sampled:
+		cc.currentBase, cc.previousBase = delay, delay
+		cc.baseStarted = now
+		cc.sampled = true
+	case now.Sub(cc.baseStarted) >= baseDelayInterval:
+		cc.previousBase, cc.currentBase = cc.currentBase, delay
+		cc.baseStarted = now
+	case delayLess(delay, cc.currentBase):
+		cc.currentBase = delay
+	}
+	cc.delay = delay
+}
+
+// queuingDelay returns last delay above base delay
+func (cc *congestion) queuingDelay() uint32 {
+	base := cc.currentBase
+	if delayLess(cc.previousBase, base) {
+		base = cc.previousBase
+	}
+	return cc.delay - base
+}
+
+// acked grows or shrinks window in proportion to distance of queuing delay from target
+func (cc *congestion) acked(bytes uint32) {
+	if cc == nil || cc.target == 0 || !cc.sampled {
+		return
+	}
+
+	offTarget := float64(int64(cc.target)-int64(cc.queuingDelay())) / float64(cc.target)
+	if offTarget < -1 {
+		offTarget = -1
+	}
+	window := float64(cc.window) + ledbatGain*offTarget*float64(bytes)*float64(cc.mss)/float64(cc.window)
+	cc.clamp(window)
+}
+
+// lost halves window once per round trip when peer acks packets sent after a missing one
+func (cc *congestion) lost(now time.Time, rtt time.Duration) {
+	if cc == nil || now.Sub(cc.lastDecrease) < rtt {
+		return
+	}
+	cc.lastDecrease = now
+	cc.clamp(float64(cc.window) / 2)
+}
+
+// timedOut drops window to minimum when resend timer fires
+func (cc *congestion) timedOut() {
+	if cc == nil {
+		return
+	}
+	cc.window = cc.min
+}
+
+func (cc *congestion) clamp(window float64) {
+	switch {
+	case window < float64(cc.min):
+		cc.window = cc.min
+	case window > float64(cc.max):
+		cc.window = cc.max
+	default:
+		cc.window = uint32(window)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package utp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCongestion_Acked(t *testing.T) {
+	now := time.Now()
+	cc := newCongestion(1000, 100000, 1000, time.Millisecond*100)
+	assert.Equal(t, uint32(2000), cc.window)
+
+	// No queuing delay, window grows by gain * acked * mss / window
+	cc.sample(50000, now)
+	cc.acked(1000)
+	assert.Equal(t, uint32(2500), cc.window)
+
+	// Queuing delay of half the target grows window by half as much
+	cc.sample(100000, now)
+	cc.acked(1000)
+	assert.Equal(t, uint32(2700), cc.window)
+
+	// Queuing delay above target shrinks window
+	cc.sample(350000, now)
+	cc.acked(1000)
+	assert.True(t, cc.window < 2700)
+}
+
+func TestCongestion_BaseDelay(t *testing.T) {
+	now := time.Now()
+	cc := newCongestion(1000, 100000, 1000, time.Millisecond*100)
+
+	cc.sample(50000, now)
+	cc.sample(80000, now)
+	assert.Equal(t, uint32(30000), cc.queuingDelay())
+
+	// Base delay of previous interval is kept for one more interval
+	cc.sample(90000, now.Add(baseDelayInterval))
+	assert.Equal(t, uint32(40000), cc.queuingDelay())
+
+	// Route change is picked up after it
+	cc.sample(90000, now.Add(baseDelayInterval*2))
+	assert.Equal(t, uint32(0), cc.queuingDelay())
+
+	// Delays wrap around with uTP timestamps
+	cc = newCongestion(1000, 100000, 1000, time.Millisecond*100)
+	cc.sample(0xFFFFFF00, now)
+	cc.sample(0x00000100, now)
+	assert.Equal(t, uint32(0x200), cc.queuingDelay())
+}
+
+func TestCongestion_Loss(t *testing.T) {
+	now := time.Now()
+	cc := newCongestion(1000, 100000, 1000, time.Millisecond*100)
+	cc.window = 8000
+
+	cc.lost(now, time.Millisecond*50)
+	assert.Equal(t, uint32(4000), cc.window)
+
+	// Window is halved once per round trip
+	cc.lost(now.Add(time.Millisecond*10), time.Millisecond*50)
+	assert.Equal(t, uint32(4000), cc.window)
+	cc.lost(now.Add(time.Millisecond*60), time.Millisecond*50)
+	assert.Equal(t, uint32(2000), cc.window)
+
+	cc.timedOut()
+	assert.Equal(t, uint32(1000), cc.window)
+	assert.True(t, cc.allows(999))
+	assert.False(t, cc.allows(1000))
+}
+
+func TestConfig_Congestion(t *testing.T) {
+	assert.Nil(t, Config{}.congestion(maxPayloadSize))
+	assert.True(t, (*congestion)(nil).allows(readBufferLen))
+
+	// Without target delay window is fixed at max window
+	cc := Config{MaxWindow: 4000}.congestion(1000)
+	cc.lost(time.Now(), 0)
+	cc.timedOut()
+	assert.Equal(t, uint32(4000), cc.window)
+
+	// Window never drops below a packet
+	cc = Config{MaxWindow: 100000, TargetDelay: time.Millisecond}.congestion(1000)
+	cc.timedOut()
+	assert.Equal(t, uint32(1000), cc.window)
+}
+
+func TestConfig_MaxPayload(t *testing.T) {
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 31337}
+	assert.Equal(t, maxPayloadSize, Config{}.maxPayload(addr))
+	assert.Equal(t, 1000-maxHeaderSize, Config{MaxPacketSize: 1000}.maxPayload(addr))
+
+	discovered := func(net.Addr) int { return 548 }
+	assert.Equal(t, 548-maxHeaderSize, Config{PacketSize: discovered}.maxPayload(addr))
+	assert.Equal(t, 548-maxHeaderSize, Config{MaxPacketSize: 1000, PacketSize: discovered}.maxPayload(addr))
+	assert.Equal(t, 500-maxHeaderSize, Config{MaxPacketSize: 500, PacketSize: discovered}.maxPayload(addr))
+
+	unknown := func(net.Addr) int { return 0 }
+	assert.Equal(t, 1000-maxHeaderSize, Config{MaxPacketSize: 1000, PacketSize: unknown}.maxPayload(addr))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, Config{}.validate())
+	assert.NoError(t, Config{MaxPacketSize: 1200, MinWindow: 2000, MaxWindow: 64000, TargetDelay: time.Millisecond * 100}.validate())
+	assert.Error(t, Config{MaxPacketSize: maxHeaderSize}.validate())
+	assert.Error(t, Config{MaxPacketSize: maxRecvSize + 1}.validate())
+	assert.Error(t, Config{MinWindow: 8000, MaxWindow: 4000}.validate())
+	assert.Error(t, Config{TargetDelay: -time.Second}.validate())
+}
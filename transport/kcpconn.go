@@ -0,0 +1,207 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// KCP segments start with conversation id chosen by dialer
+	kcpConvSize = 4
+
+	kcpQueueSize = 256
+)
+
+type kcpRoute struct {
+	address string
+	conv    uint32
+}
+
+type kcpPacket struct {
+	data    []byte
+	address net.Addr
+}
+
+// kcpPacketConn shares packet connection between KCP listener and sessions dialed by node, so that
+// every peer is reached from the listening socket. KCP sessions read from packet connection they are
+// created on, so datagrams are dispatched by conversation id: ones of dialed sessions go to them,
+// all others to listener.
+type kcpPacketConn struct {
+	conn net.PacketConn
+
+	mutex    *sync.Mutex
+	sessions map[kcpRoute]*kcpPacketQueue
+	listener *kcpPacketQueue
+}
+
+func newKCPPacketConn(conn net.PacketConn) *kcpPacketConn {
+	c := &kcpPacketConn{
+		conn:     conn,
+		mutex:    &sync.Mutex{},
+		sessions: make(map[kcpRoute]*kcpPacketQueue),
+	}
+	c.listener = c.newQueue()
+
+	go c.receive()
+
+	return c
+}
+
+// newQueue creates view of packet connection datagrams can be dispatched to
+func (c *kcpPacketConn) newQueue() *kcpPacketQueue {
+	return &kcpPacketQueue{
+		conn:    c.conn,
+		packets: make(chan kcpPacket, kcpQueueSize),
+		closed:  make(chan bool),
+		once:    &sync.Once{},
+	}
+}
+
+// register dispatches datagrams of conversation with address to queue until it is closed
+func (c *kcpPacketConn) register(queue *kcpPacketQueue, address string, conv uint32) {
+	route := kcpRoute{address: address, conv: conv}
+
+	c.mutex.Lock()
+	c.sessions[route] = queue
+	c.mutex.Unlock()
+
+	queue.onClose = func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+
+		if c.sessions[route] == queue {
+			delete(c.sessions, route)
+		}
+	}
+}
+
+// Close closes packet connection and all queues
+func (c *kcpPacketConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *kcpPacketConn) receive() {
+	buffer := make([]byte, maxPacketSize)
+	for {
+		n, address, err := c.conn.ReadFrom(buffer)
+		if err != nil {
+			c.closeAll()
+			return
+		}
+
+		packet := kcpPacket{data: make([]byte, n), address: address}
+		copy(packet.data, buffer[:n])
+		c.queue(packet).enqueue(packet)
+	}
+}
+
+// queue returns queue datagram is dispatched to
+func (c *kcpPacketConn) queue(packet kcpPacket) *kcpPacketQueue {
+	if len(packet.data) < kcpConvSize {
+		return c.listener
+	}
+	route := kcpRoute{address: packet.address.String(), conv: binary.LittleEndian.Uint32(packet.data)}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if queue, ok := c.sessions[route]; ok {
+		return queue
+	}
+	return c.listener
+}
+
+func (c *kcpPacketConn) closeAll() {
+	c.mutex.Lock()
+	queues := make([]*kcpPacketQueue, 0, len(c.sessions)+1)
+	for _, queue := range c.sessions {
+		queues = append(queues, queue)
+	}
+	c.mutex.Unlock()
+
+	for _, queue := range append(queues, c.listener) {
+		queue.Close()
+	}
+}
+
+// kcpPacketQueue is view of shared packet connection, it reads datagrams dispatched to it
+// and writes directly to connection
+type kcpPacketQueue struct {
+	conn    net.PacketConn
+	packets chan kcpPacket
+	closed  chan bool
+	once    *sync.Once
+	onClose func()
+}
+
+func (q *kcpPacketQueue) enqueue(packet kcpPacket) {
+	select {
+	case q.packets <- packet:
+	default:
+	}
+}
+
+// ReadFrom reads the next datagram dispatched to queue, it is truncated if p is too small
+func (q *kcpPacketQueue) ReadFrom(p []byte) (int, net.Addr, error) {
+	select {
+	case packet := <-q.packets:
+		return copy(p, packet.data), packet.address, nil
+	case <-q.closed:
+		return 0, nil, errors.New("connection closed")
+	}
+}
+
+// WriteTo writes datagram to shared packet connection
+func (q *kcpPacketQueue) WriteTo(p []byte, addr net.Addr) (int, error) {
+	return q.conn.WriteTo(p, addr)
+}
+
+// Close stops dispatching datagrams to queue, packet connection is left open
+func (q *kcpPacketQueue) Close() error {
+	q.once.Do(func() {
+		close(q.closed)
+		if q.onClose != nil {
+			q.onClose()
+		}
+	})
+	return nil
+}
+
+// LocalAddr returns address of shared packet connection
+func (q *kcpPacketQueue) LocalAddr() net.Addr {
+	return q.conn.LocalAddr()
+}
+
+// SetDeadline does nothing, deadlines of shared packet connection are not changed
+func (q *kcpPacketQueue) SetDeadline(t time.Time) error {
+	return nil
+}
+
+// SetReadDeadline does nothing, deadlines of shared packet connection are not changed
+func (q *kcpPacketQueue) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+// SetWriteDeadline does nothing, deadlines of shared packet connection are not changed
+func (q *kcpPacketQueue) SetWriteDeadline(t time.Time) error {
+	return nil
+}
@@ -0,0 +1,67 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func kcpSegment(conv uint32, payload string) []byte {
+	segment := make([]byte, kcpConvSize)
+	binary.LittleEndian.PutUint32(segment, conv)
+	return append(segment, payload...)
+}
+
+func TestKCPPacketConn_Dispatch(t *testing.T) {
+	first, second := createPacketConns(t)
+	defer second.Close()
+	shared := newKCPPacketConn(first)
+
+	session := shared.newQueue()
+	shared.register(session, second.LocalAddr().String(), 7)
+	listenerPackets := readPackets(shared.listener)
+	sessionPackets := readPackets(session)
+
+	// Datagrams of dialed conversation go to session, others to listener
+	_, err := second.WriteTo(kcpSegment(7, "dialed"), first.LocalAddr())
+	assert.NoError(t, err)
+	assert.Equal(t, string(kcpSegment(7, "dialed")), <-sessionPackets)
+	_, err = second.WriteTo(kcpSegment(8, "accepted"), first.LocalAddr())
+	assert.NoError(t, err)
+	assert.Equal(t, string(kcpSegment(8, "accepted")), <-listenerPackets)
+
+	// Writes go to shared connection
+	peerPackets := readPackets(second)
+	_, err = session.WriteTo([]byte("reply"), second.LocalAddr())
+	assert.NoError(t, err)
+	assert.Equal(t, "reply", <-peerPackets)
+
+	// Closed session is not dispatched to anymore
+	session.Close()
+	_, ok := <-sessionPackets
+	assert.False(t, ok)
+	_, err = second.WriteTo(kcpSegment(7, "late"), first.LocalAddr())
+	assert.NoError(t, err)
+	assert.Equal(t, string(kcpSegment(7, "late")), <-listenerPackets)
+
+	shared.Close()
+	_, ok = <-listenerPackets
+	assert.False(t, ok)
+}
@@ -0,0 +1,190 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"net"
+	"time"
+
+	"github.com/xtaci/kcp-go"
+)
+
+// KCP doesn't signal connection close, so accepted connections are closed after idle timeout
+const kcpIdleTimeout = time.Second * 10
+
+// KCPConfig contains tunable parameters of KCP protocol, see https://github.com/skywind3000/kcp
+type KCPConfig struct {
+	// Send and receive window sizes in packets
+	SendWindow    int
+	ReceiveWindow int
+
+	// NoDelay disables exponential backoff of retransmission timeout
+	NoDelay bool
+
+	// Interval of internal protocol updates
+	Interval time.Duration
+
+	// Resend triggers fast retransmission after given number of skipped ACKs, 0 disables it
+	Resend int
+
+	// NoCongestion disables congestion control
+	NoCongestion bool
+
+	// Maximum transmission unit
+	MTU int
+
+	// PathMTUDiscovery lowers MTU for every peer host to the largest UDP payload which is delivered to it
+	// without loss. Peers must enable it too to answer probes, MTU is used for peers which don't
+	PathMTUDiscovery bool
+}
+
+// DefaultKCPConfig returns KCP configuration tuned for low latency on lossy links
+func DefaultKCPConfig() *KCPConfig {
+	return &KCPConfig{
+		SendWindow:    128,
+		ReceiveWindow: 512,
+		NoDelay:       true,
+		Interval:      time.Millisecond * 10,
+		Resend:        2,
+		NoCongestion:  true,
+		MTU:           1400,
+	}
+}
+
+type kcpSocket struct {
+	listener *kcp.Listener
+	conn     *kcpPacketConn
+	config   *KCPConfig
+	pmtu     *pathMTUConn
+}
+
+type kcpConn struct {
+	*kcp.UDPSession
+}
+
+// kcpDialedConn is session dialed over packet connection shared with listener
+type kcpDialedConn struct {
+	*kcp.UDPSession
+	queue *kcpPacketQueue
+}
+
+// NewKCPTransport creates KCP transport on given PacketConn, peers are dialed from it too.
+// Default configuration is used if config is nil
+func NewKCPTransport(conn net.PacketConn, config *KCPConfig) (Transport, error) {
+	if config == nil {
+		config = DefaultKCPConfig()
+	}
+
+	var pmtu *pathMTUConn
+	if config.PathMTUDiscovery {
+		pmtu = newPathMTUConn(conn)
+		conn = pmtu
+	}
+
+	shared := newKCPPacketConn(conn)
+	listener, err := kcp.ServeConn(nil, 0, 0, shared.listener)
+	if err != nil {
+		shared.Close()
+		return nil, err
+	}
+
+	return newStreamTransport(&kcpSocket{listener: listener, conn: shared, config: config, pmtu: pmtu}), nil
+}
+
+// Accept waits for the next incoming connection
+func (s *kcpSocket) Accept() (net.Conn, error) {
+	session, err := s.listener.AcceptKCP()
+	if err != nil {
+		return nil, err
+	}
+	mtu := s.config.MTU
+	if s.pmtu != nil {
+		// MTU discovered when dialing peer's host is used
+		mtu = s.pmtu.lookup(session.RemoteAddr().String(), s.config.MTU, s.config.MTU)
+	}
+	s.configure(session, mtu)
+
+	return &kcpConn{UDPSession: session}, nil
+}
+
+// Dial connects to given address
+func (s *kcpSocket) Dial(address string) (net.Conn, error) {
+	mtu := s.config.MTU
+	if s.pmtu != nil {
+		mtu = s.pmtu.discover(address, s.config.MTU, s.config.MTU)
+	}
+
+	queue := s.conn.newQueue()
+	session, err := kcp.NewConn(address, nil, 0, 0, queue)
+	if err != nil {
+		queue.Close()
+		return nil, err
+	}
+	s.conn.register(queue, session.RemoteAddr().String(), session.GetConv())
+	s.configure(session, mtu)
+
+	return &kcpDialedConn{UDPSession: session, queue: queue}, nil
+}
+
+// Addr returns listener address
+func (s *kcpSocket) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Close stops listening and closes packet connection
+func (s *kcpSocket) Close() error {
+	err := s.listener.Close()
+	s.conn.Close()
+	return err
+}
+
+func (s *kcpSocket) configure(session *kcp.UDPSession, mtu int) {
+	session.SetStreamMode(true)
+	session.SetWindowSize(s.config.SendWindow, s.config.ReceiveWindow)
+	session.SetNoDelay(boolToInt(s.config.NoDelay), int(s.config.Interval/time.Millisecond), s.config.Resend, boolToInt(s.config.NoCongestion))
+	if mtu > 0 {
+		session.SetMtu(mtu)
+	}
+}
+
+// Read reads data from connection, connection is closed if nothing is received during idle timeout
+func (c *kcpConn) Read(b []byte) (int, error) {
+	err := c.UDPSession.SetReadDeadline(time.Now().Add(kcpIdleTimeout))
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := c.UDPSession.Read(b)
+	if err != nil {
+		c.UDPSession.Close()
+	}
+	return n, err
+}
+
+// Close closes session and stops dispatching its datagrams
+func (c *kcpDialedConn) Close() error {
+	err := c.UDPSession.Close()
+	c.queue.Close()
+	return err
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"testing"
+
+	"github.com/insolar/network/connection"
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createKCPTransport(t *testing.T, address string) (Transport, *node.Node) {
+	conn, err := connection.NewConnectionFactory().Create(address)
+	assert.NoError(t, err)
+
+	tp, err := NewKCPTransport(conn, nil)
+	assert.NoError(t, err)
+
+	addr, _ := node.NewAddress(address)
+	n := node.NewNode(addr)
+	n.ID, _ = node.NewID()
+
+	return tp, n
+}
+
+func TestKCPTransport_SendRequest(t *testing.T) {
+	first, firstNode := createKCPTransport(t, "127.0.0.1:8096")
+	second, secondNode := createKCPTransport(t, "127.0.0.1:8097")
+	done := startTransports(first, second)
+
+	future, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+
+	request := <-second.Messages()
+	assert.Equal(t, message.TypePing, request.Type)
+	assert.Equal(t, firstNode.ID, request.Sender.ID)
+
+	response := message.NewBuilder().Sender(secondNode).Receiver(firstNode).Type(message.TypePing).Response(nil).Build()
+	err = second.SendResponse(request.RequestID, response)
+	assert.NoError(t, err)
+
+	result := <-future.Result()
+	assert.Equal(t, secondNode.ID, result.Sender.ID)
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestKCPTransport_PathMTUDiscovery(t *testing.T) {
+	config := DefaultKCPConfig()
+	config.PathMTUDiscovery = true
+
+	var transports []Transport
+	var nodes []*node.Node
+	for _, address := range []string{"127.0.0.1:8124", "127.0.0.1:8125"} {
+		conn, err := connection.NewConnectionFactory().Create(address)
+		assert.NoError(t, err)
+		tp, err := NewKCPTransport(conn, config)
+		assert.NoError(t, err)
+		assert.NotNil(t, tp.(*streamTransport).socket.(*kcpSocket).pmtu)
+
+		addr, _ := node.NewAddress(address)
+		n := node.NewNode(addr)
+		n.ID, _ = node.NewID()
+		transports = append(transports, tp)
+		nodes = append(nodes, n)
+	}
+	done := startTransports(transports[0], transports[1])
+
+	_, err := transports[0].SendRequest(message.NewPingMessage(nodes[0], nodes[1]))
+	assert.NoError(t, err)
+	request := <-transports[1].Messages()
+	assert.Equal(t, message.TypePing, request.Type)
+
+	stopTransport(transports[0], done)
+	stopTransport(transports[1], done)
+}
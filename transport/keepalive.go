@@ -0,0 +1,145 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/message"
+)
+
+// keepaliveRounds is number of keepalive intervals peer is probed after the last message sent to it
+const keepaliveRounds = 10
+
+// keepalive tracks peers which are probed to keep connections and NAT bindings open
+type keepalive struct {
+	mutex    *sync.Mutex
+	interval time.Duration
+	onDead   func(address string)
+	peers    map[string]time.Time
+	stop     chan bool
+}
+
+func newKeepalive(interval time.Duration, onDead func(address string)) *keepalive {
+	return &keepalive{
+		mutex:    &sync.Mutex{},
+		interval: interval,
+		onDead:   onDead,
+		peers:    make(map[string]time.Time),
+		stop:     make(chan bool),
+	}
+}
+
+// SetKeepalive makes transport probe peers it recently sent messages to every interval with empty frames.
+// Peer which can't be reached is reported to onDead with its address. It must be called before transport is started.
+func SetKeepalive(transport Transport, interval time.Duration, onDead func(address string)) error {
+	switch t := transport.(type) {
+	case *streamTransport:
+		t.keepalive = newKeepalive(interval, onDead)
+	case *muxTransport:
+		for _, st := range t.transports {
+			st.keepalive = newKeepalive(interval, onDead)
+		}
+	default:
+		return errors.New("transport does not support keepalive")
+	}
+
+	return nil
+}
+
+// touch remembers that message was sent to address
+func (k *keepalive) touch(address string) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	k.peers[address] = time.Now()
+}
+
+// forget stops probing address
+func (k *keepalive) forget(address string) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	delete(k.peers, address)
+}
+
+// due returns addresses which should be probed, peers without recent messages are forgotten
+func (k *keepalive) due() []string {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	var addresses []string
+	for address, lastSent := range k.peers {
+		if time.Since(lastSent) > k.interval*keepaliveRounds {
+			delete(k.peers, address)
+			continue
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
+
+func (t *streamTransport) probePeers() {
+	ticker := time.NewTicker(t.keepalive.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, address := range t.keepalive.due() {
+				t.probe(address)
+			}
+		case <-t.keepalive.stop:
+			return
+		}
+	}
+}
+
+// probe writes keepalive frame to address, fresh connection is dialed if pooled one is broken
+func (t *streamTransport) probe(address string) {
+	data := message.NewFrame(nil, message.FlagKeepalive)
+
+	if conn := t.pool.get(address); conn != nil {
+		if writeWithDeadline(conn, data, t.writeTimeout) == nil {
+			t.pool.put(address, conn)
+			return
+		}
+		conn.Close()
+	}
+
+	conn, err := t.socket.Dial(address)
+	if err == nil {
+		err = writeWithDeadline(conn, data, t.writeTimeout)
+		if err == nil {
+			t.pool.put(address, conn)
+			return
+		}
+		conn.Close()
+	}
+
+	select {
+	case <-t.keepalive.stop:
+		// Connections are closed by transport itself
+		return
+	default:
+	}
+
+	t.keepalive.forget(address)
+	t.keepalive.onDead(address)
+}
@@ -0,0 +1,126 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetKeepalive(t *testing.T) {
+	tp, _ := createTCPTransport(t, "127.0.0.1:8121")
+	defer tp.(*streamTransport).socket.Close()
+
+	err := SetKeepalive(tp, time.Second, func(string) {})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, tp.(*streamTransport).keepalive.interval)
+
+	err = SetKeepalive(nil, time.Second, func(string) {})
+	assert.EqualError(t, err, "transport does not support keepalive")
+}
+
+func TestKeepalive_Due(t *testing.T) {
+	k := newKeepalive(time.Millisecond*10, func(string) {})
+	k.touch("127.0.0.1:31337")
+	assert.Equal(t, []string{"127.0.0.1:31337"}, k.due())
+
+	k.forget("127.0.0.1:31337")
+	assert.Empty(t, k.due())
+
+	// Peer which did not get messages for keepaliveRounds intervals is not probed anymore
+	k.touch("127.0.0.1:31338")
+	time.Sleep(time.Millisecond * 10 * (keepaliveRounds + 1))
+	assert.Empty(t, k.due())
+	assert.Empty(t, k.peers)
+}
+
+func TestStreamTransport_Keepalive(t *testing.T) {
+	first, firstNode := createTCPTransport(t, "127.0.0.1:8119")
+	second, secondNode := createTCPTransport(t, "127.0.0.1:8120")
+
+	err := SetKeepalive(first, time.Millisecond*20, func(address string) {
+		assert.Fail(t, "alive peer is reported as dead", address)
+	})
+	assert.NoError(t, err)
+
+	done := make(chan bool)
+	for _, tp := range []Transport{first, second} {
+		go func(tp Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+		defer stopTransport(tp, done)
+	}
+
+	_, err = first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+	request := <-second.Messages()
+	assert.Equal(t, message.TypePing, request.Type)
+
+	// Keepalive frames are not delivered as messages
+	select {
+	case msg := <-second.Messages():
+		assert.Fail(t, "unexpected message", msg)
+	case <-time.After(time.Millisecond * 100):
+	}
+	assert.Equal(t, 1, second.Stats().Received["ping"])
+}
+
+func TestStreamTransport_KeepaliveDeadPeer(t *testing.T) {
+	tp, n := createTCPTransport(t, "127.0.0.1:8122")
+	dead := make(chan string, 1)
+	err := SetKeepalive(tp, time.Millisecond*20, func(address string) {
+		dead <- address
+	})
+	assert.NoError(t, err)
+
+	done := make(chan bool)
+	go func() {
+		tp.Start()
+		done <- true
+	}()
+	defer stopTransport(tp, done)
+
+	// Peer reads the first message and goes away
+	listener, err := net.Listen("tcp", "127.0.0.1:8123")
+	assert.NoError(t, err)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			message.DeserializeMessage(conn)
+			conn.Close()
+		}
+		listener.Close()
+	}()
+
+	addr, _ := node.NewAddress("127.0.0.1:8123")
+	_, err = tp.SendRequest(message.NewPingMessage(n, node.NewNode(addr)))
+	assert.NoError(t, err)
+
+	select {
+	case address := <-dead:
+		assert.Equal(t, "127.0.0.1:8123", address)
+	case <-time.After(time.Second):
+		assert.Fail(t, "dead peer is not reported")
+	}
+}
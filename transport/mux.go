@@ -0,0 +1,181 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+)
+
+// muxTransport listens on several transports at once
+type muxTransport struct {
+	names      []string
+	transports []*streamTransport
+
+	received chan *message.Message
+
+	disconnectStarted chan bool
+	forwarders        *sync.WaitGroup
+}
+
+// NewMuxTransport creates Transport listening on all given transports at once.
+// Names identify transports in node addresses (see node.Node.Addresses). Message is sent
+// with the first transport receiver advertises an address for, or with the first transport
+// to receiver's primary address. Responses may arrive via any of transports.
+func NewMuxTransport(names []string, transports []Transport) (Transport, error) {
+	if len(names) == 0 || len(names) != len(transports) {
+		return nil, errors.New("transport names mismatch")
+	}
+
+	t := &muxTransport{
+		names:             names,
+		received:          make(chan *message.Message),
+		disconnectStarted: make(chan bool),
+		forwarders:        &sync.WaitGroup{},
+	}
+
+	// Futures are shared so that response can be accepted by any of transports
+	mutex := &sync.RWMutex{}
+	futures := make(map[message.RequestID]Future)
+	sequence := new(uint64)
+
+	for i, transport := range transports {
+		st, ok := transport.(*streamTransport)
+		if !ok {
+			return nil, errors.New("transport does not support multiplexing")
+		}
+
+		st.network = names[i]
+		st.mutex = mutex
+		st.futures = futures
+		st.sequence = sequence
+
+		t.transports = append(t.transports, st)
+	}
+
+	return t, nil
+}
+
+// SendRequest sends request message with transport selected for receiver and returns future
+func (t *muxTransport) SendRequest(msg *message.Message) (Future, error) {
+	return t.transportFor(msg.Receiver).SendRequest(msg)
+}
+
+// SendResponse sends response message with transport selected for receiver
+func (t *muxTransport) SendResponse(requestID message.RequestID, msg *message.Message) error {
+	return t.transportFor(msg.Receiver).SendResponse(requestID, msg)
+}
+
+// Start starts all transports and returns when all of them are stopped
+func (t *muxTransport) Start() error {
+	errs := make(chan error, len(t.transports))
+
+	for _, st := range t.transports {
+		t.forwarders.Add(1)
+		go t.forward(st)
+
+		go func(st *streamTransport) {
+			errs <- st.Start()
+		}(st)
+	}
+
+	var err error
+	for range t.transports {
+		startErr := <-errs
+		if err == nil {
+			err = startErr
+		}
+	}
+
+	return err
+}
+
+// Stop stops all transports
+func (t *muxTransport) Stop() {
+	t.disconnectStarted <- true
+	close(t.disconnectStarted)
+
+	for _, st := range t.transports {
+		go func(st *streamTransport) {
+			<-st.Stopped()
+		}(st)
+		st.Stop()
+	}
+}
+
+// Close closes message channels of all transports
+func (t *muxTransport) Close() {
+	for _, st := range t.transports {
+		st.Close()
+	}
+
+	t.forwarders.Wait()
+	close(t.received)
+}
+
+// Messages returns incoming messages channel of all transports
+func (t *muxTransport) Messages() chan *message.Message {
+	return t.received
+}
+
+// Stopped checks if networking is stopped already
+func (t *muxTransport) Stopped() chan bool {
+	return t.disconnectStarted
+}
+
+// PendingRequests returns futures of sent requests which are still waiting for response
+func (t *muxTransport) PendingRequests() []Future {
+	return t.transports[0].PendingRequests()
+}
+
+// LocalAddr returns address of the first transport
+func (t *muxTransport) LocalAddr() net.Addr {
+	return t.transports[0].LocalAddr()
+}
+
+// Stats returns I/O counters summed over all transports
+func (t *muxTransport) Stats() Stats {
+	stats := newStats()
+	for _, st := range t.transports {
+		stats.add(st.Stats())
+	}
+	return stats
+}
+
+func (t *muxTransport) transportFor(receiver *node.Node) *streamTransport {
+	for i, name := range t.names {
+		if _, ok := receiver.Addresses[name]; ok {
+			return t.transports[i]
+		}
+	}
+	return t.transports[0]
+}
+
+func (t *muxTransport) forward(st *streamTransport) {
+	defer t.forwarders.Done()
+
+	for msg := range st.Messages() {
+		select {
+		case t.received <- msg:
+		case <-t.disconnectStarted:
+		}
+	}
+}
@@ -0,0 +1,78 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"testing"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMuxTransport_Errors(t *testing.T) {
+	_, err := NewMuxTransport([]string{"a", "b"}, []Transport{nil})
+	assert.EqualError(t, err, "transport names mismatch")
+
+	_, err = NewMuxTransport([]string{"a"}, []Transport{nil})
+	assert.EqualError(t, err, "transport does not support multiplexing")
+}
+
+func TestMuxTransport_SendRequest(t *testing.T) {
+	networkA := NewInMemoryNetwork(0, 0)
+	networkB := NewInMemoryNetwork(0, 0)
+
+	// First node listens on both networks, second one only on network "b"
+	firstA, firstNode := createInMemoryTransport(t, networkA, "127.0.0.1:31337")
+	firstB, _ := createInMemoryTransport(t, networkB, "127.0.0.1:31338")
+	firstNode.Addresses = map[string]*node.Address{"b": addressOf(firstB)}
+	first, err := NewMuxTransport([]string{"a", "b"}, []Transport{firstA, firstB})
+	assert.NoError(t, err)
+
+	secondB, secondNode := createInMemoryTransport(t, networkB, "127.0.0.2:31338")
+	secondNode.Addresses = map[string]*node.Address{"b": secondNode.Address}
+	second, err := NewMuxTransport([]string{"b"}, []Transport{secondB})
+	assert.NoError(t, err)
+
+	done := startTransports(first, second)
+
+	future, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+	assert.Len(t, first.PendingRequests(), 1)
+
+	request := <-second.Messages()
+	assert.Equal(t, firstNode.ID, request.Sender.ID)
+	assert.Equal(t, "127.0.0.1:31338", request.RemoteAddress())
+
+	// Response is sent to first node's address on network "b"
+	response := message.NewBuilder().Sender(secondNode).Receiver(request.Sender).Type(message.TypePing).Response(nil).Build()
+	err = second.SendResponse(request.RequestID, response)
+	assert.NoError(t, err)
+
+	result := <-future.Result()
+	assert.Equal(t, secondNode.ID, result.Sender.ID)
+	assert.Empty(t, first.PendingRequests())
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func addressOf(tp Transport) *node.Address {
+	addr, _ := node.NewAddress(tp.LocalAddr().String())
+	return addr
+}
@@ -0,0 +1,367 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// Implementation of Noise_XX_25519_AESGCM_SHA256 handshake (-> e; <- e, ee, s, es; -> s, se),
+// see https://noiseprotocol.org/noise.html
+const (
+	noiseProtocolName   = "Noise_XX_25519_AESGCM_SHA256"
+	noiseMaxMessageSize = 65535
+	noiseTagSize        = 16
+	noiseKeySize        = 32
+)
+
+var errNoiseNonceExhausted = errors.New("noise nonce is exhausted")
+
+type noiseCipherState struct {
+	aead  cipher.AEAD
+	nonce uint64
+}
+
+func newNoiseCipherState(key []byte) (*noiseCipherState, error) {
+	block, err := aes.NewCipher(key[:noiseKeySize])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &noiseCipherState{aead: aead}, nil
+}
+
+func (cs *noiseCipherState) encrypt(ad, plaintext []byte) ([]byte, error) {
+	nonce, err := cs.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+	return cs.aead.Seal(nil, nonce, plaintext, ad), nil
+}
+
+func (cs *noiseCipherState) decrypt(ad, ciphertext []byte) ([]byte, error) {
+	nonce, err := cs.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+	return cs.aead.Open(nil, nonce, ciphertext, ad)
+}
+
+// nextNonce returns nonce for the next message, the maximum value is reserved by Noise
+// so cipher state can not be used any more once it is reached
+func (cs *noiseCipherState) nextNonce() ([]byte, error) {
+	if cs.nonce == math.MaxUint64 {
+		return nil, errNoiseNonceExhausted
+	}
+
+	nonce := make([]byte, cs.aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[4:], cs.nonce)
+	cs.nonce++
+	return nonce, nil
+}
+
+type noiseHandshake struct {
+	ck     []byte
+	h      []byte
+	cipher *noiseCipherState
+
+	s  *ecdh.PrivateKey
+	e  *ecdh.PrivateKey
+	rs *ecdh.PublicKey
+	re *ecdh.PublicKey
+}
+
+func newNoiseHandshake(s *ecdh.PrivateKey) *noiseHandshake {
+	h := make([]byte, sha256.Size)
+	copy(h, noiseProtocolName)
+
+	hs := &noiseHandshake{
+		ck: h,
+		h:  h,
+		s:  s,
+	}
+	// Empty prologue
+	hs.mixHash(nil)
+
+	return hs
+}
+
+// initiate performs handshake as initiator
+func (hs *noiseHandshake) initiate(rw io.ReadWriter) (err error) {
+	// -> e
+	err = hs.generateEphemeral()
+	if err != nil {
+		return err
+	}
+	msg := hs.writeEphemeral(nil)
+	msg, err = hs.appendEncryptAndHash(msg, nil)
+	if err != nil {
+		return err
+	}
+	err = writeNoiseFrame(rw, msg)
+	if err != nil {
+		return err
+	}
+
+	// <- e, ee, s, es
+	msg, err = readNoiseFrame(rw)
+	if err != nil {
+		return err
+	}
+	if len(msg) != 2*noiseKeySize+2*noiseTagSize {
+		return errors.New("invalid handshake message")
+	}
+	err = hs.readEphemeral(msg[:noiseKeySize])
+	if err == nil {
+		err = hs.mixDH(hs.e, hs.re)
+	}
+	if err == nil {
+		err = hs.readStatic(msg[noiseKeySize : 2*noiseKeySize+noiseTagSize])
+	}
+	if err == nil {
+		err = hs.mixDH(hs.e, hs.rs)
+	}
+	if err == nil {
+		_, err = hs.decryptAndHash(msg[2*noiseKeySize+noiseTagSize:])
+	}
+	if err != nil {
+		return err
+	}
+
+	// -> s, se
+	msg, err = hs.appendEncryptAndHash(nil, hs.s.PublicKey().Bytes())
+	if err == nil {
+		err = hs.mixDH(hs.s, hs.re)
+	}
+	if err == nil {
+		msg, err = hs.appendEncryptAndHash(msg, nil)
+	}
+	if err != nil {
+		return err
+	}
+	return writeNoiseFrame(rw, msg)
+}
+
+// respond performs handshake as responder
+func (hs *noiseHandshake) respond(rw io.ReadWriter) (err error) {
+	// -> e
+	msg, err := readNoiseFrame(rw)
+	if err != nil {
+		return err
+	}
+	if len(msg) != noiseKeySize {
+		return errors.New("invalid handshake message")
+	}
+	err = hs.readEphemeral(msg)
+	if err != nil {
+		return err
+	}
+	_, err = hs.decryptAndHash(nil)
+	if err != nil {
+		return err
+	}
+
+	// <- e, ee, s, es
+	err = hs.generateEphemeral()
+	if err != nil {
+		return err
+	}
+	msg = hs.writeEphemeral(nil)
+	err = hs.mixDH(hs.e, hs.re)
+	if err == nil {
+		msg, err = hs.appendEncryptAndHash(msg, hs.s.PublicKey().Bytes())
+	}
+	if err == nil {
+		err = hs.mixDH(hs.s, hs.re)
+	}
+	if err == nil {
+		msg, err = hs.appendEncryptAndHash(msg, nil)
+	}
+	if err != nil {
+		return err
+	}
+	err = writeNoiseFrame(rw, msg)
+	if err != nil {
+		return err
+	}
+
+	// -> s, se
+	msg, err = readNoiseFrame(rw)
+	if err != nil {
+		return err
+	}
+	if len(msg) != noiseKeySize+2*noiseTagSize {
+		return errors.New("invalid handshake message")
+	}
+	err = hs.readStatic(msg[:noiseKeySize+noiseTagSize])
+	if err == nil {
+		err = hs.mixDH(hs.e, hs.rs)
+	}
+	if err == nil {
+		_, err = hs.decryptAndHash(msg[noiseKeySize+noiseTagSize:])
+	}
+	return err
+}
+
+// split returns initiator's and responder's sending cipher states
+func (hs *noiseHandshake) split() (*noiseCipherState, *noiseCipherState, error) {
+	k1, k2 := noiseHKDF(hs.ck, nil)
+
+	c1, err := newNoiseCipherState(k1)
+	if err != nil {
+		return nil, nil, err
+	}
+	c2, err := newNoiseCipherState(k2)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return c1, c2, nil
+}
+
+// generateEphemeral generates ephemeral key unless it is already set, e.g. by test vectors
+func (hs *noiseHandshake) generateEphemeral() (err error) {
+	if hs.e == nil {
+		hs.e, err = ecdh.X25519().GenerateKey(rand.Reader)
+	}
+	return err
+}
+
+func (hs *noiseHandshake) writeEphemeral(msg []byte) []byte {
+	e := hs.e.PublicKey().Bytes()
+	hs.mixHash(e)
+	return append(msg, e...)
+}
+
+func (hs *noiseHandshake) readEphemeral(data []byte) (err error) {
+	hs.re, err = ecdh.X25519().NewPublicKey(data)
+	if err != nil {
+		return err
+	}
+	hs.mixHash(data)
+	return nil
+}
+
+func (hs *noiseHandshake) readStatic(data []byte) error {
+	s, err := hs.decryptAndHash(data)
+	if err != nil {
+		return err
+	}
+	hs.rs, err = ecdh.X25519().NewPublicKey(s)
+	return err
+}
+
+func (hs *noiseHandshake) mixHash(data []byte) {
+	hash := sha256.New()
+	hash.Write(hs.h)
+	hash.Write(data)
+	hs.h = hash.Sum(nil)
+}
+
+func (hs *noiseHandshake) mixDH(private *ecdh.PrivateKey, public *ecdh.PublicKey) (err error) {
+	secret, err := private.ECDH(public)
+	if err != nil {
+		return err
+	}
+
+	var key []byte
+	hs.ck, key = noiseHKDF(hs.ck, secret)
+	hs.cipher, err = newNoiseCipherState(key)
+	return err
+}
+
+// appendEncryptAndHash appends encrypted plaintext to msg
+func (hs *noiseHandshake) appendEncryptAndHash(msg, plaintext []byte) ([]byte, error) {
+	ciphertext := plaintext
+	if hs.cipher != nil {
+		var err error
+		ciphertext, err = hs.cipher.encrypt(hs.h, plaintext)
+		if err != nil {
+			return nil, err
+		}
+	}
+	hs.mixHash(ciphertext)
+	return append(msg, ciphertext...), nil
+}
+
+func (hs *noiseHandshake) decryptAndHash(ciphertext []byte) (plaintext []byte, err error) {
+	plaintext = ciphertext
+	if hs.cipher != nil {
+		plaintext, err = hs.cipher.decrypt(hs.h, ciphertext)
+		if err != nil {
+			return nil, err
+		}
+	}
+	hs.mixHash(ciphertext)
+	return plaintext, nil
+}
+
+func noiseHKDF(ck, ikm []byte) ([]byte, []byte) {
+	temp := noiseHMAC(ck, ikm)
+	out1 := noiseHMAC(temp, []byte{1})
+	out2 := noiseHMAC(temp, append(append([]byte{}, out1...), 2))
+	return out1, out2
+}
+
+func noiseHMAC(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func writeNoiseFrame(w io.Writer, data []byte) error {
+	if len(data) > noiseMaxMessageSize {
+		return errors.New("noise message is too large")
+	}
+
+	frame := make([]byte, 2+len(data))
+	binary.BigEndian.PutUint16(frame, uint16(len(data)))
+	copy(frame[2:], data)
+
+	_, err := w.Write(frame)
+	return err
+}
+
+func readNoiseFrame(r io.Reader) ([]byte, error) {
+	var length [2]byte
+	_, err := io.ReadFull(r, length[:])
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, binary.BigEndian.Uint16(length[:]))
+	_, err = io.ReadFull(r, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
@@ -0,0 +1,213 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+	"net"
+	"testing"
+
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createNoiseConfig(t *testing.T) *NoiseConfig {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	return &NoiseConfig{StaticKey: key}
+}
+
+func TestNoiseHandshake(t *testing.T) {
+	initiatorConfig := createNoiseConfig(t)
+	responderConfig := createNoiseConfig(t)
+	initiatorConn, responderConn := net.Pipe()
+
+	initiator := newNoiseHandshake(initiatorConfig.StaticKey)
+	responder := newNoiseHandshake(responderConfig.StaticKey)
+
+	done := make(chan error)
+	go func() {
+		done <- responder.respond(responderConn)
+	}()
+	assert.NoError(t, initiator.initiate(initiatorConn))
+	assert.NoError(t, <-done)
+
+	assert.Equal(t, responderConfig.StaticKey.PublicKey().Bytes(), initiator.rs.Bytes())
+	assert.Equal(t, initiatorConfig.StaticKey.PublicKey().Bytes(), responder.rs.Bytes())
+	assert.Equal(t, initiator.h, responder.h)
+
+	initiatorSend, initiatorReceive, err := initiator.split()
+	assert.NoError(t, err)
+	responderReceive, responderSend, err := responder.split()
+	assert.NoError(t, err)
+
+	ciphertext, err := initiatorSend.encrypt(nil, []byte("ping"))
+	assert.NoError(t, err)
+	plaintext, err := responderReceive.decrypt(nil, ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("ping"), plaintext)
+
+	ciphertext, err = responderSend.encrypt(nil, []byte("pong"))
+	assert.NoError(t, err)
+	plaintext, err = initiatorReceive.decrypt(nil, ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("pong"), plaintext)
+
+	ciphertext, err = initiatorSend.encrypt(nil, []byte("ping"))
+	assert.NoError(t, err)
+	ciphertext[0] ^= 1
+	_, err = responderReceive.decrypt(nil, ciphertext)
+	assert.Error(t, err)
+}
+
+// noiseVector is Noise_XX_25519_AESGCM_SHA256 vector with empty prologue and payloads
+// from vectors.txt of github.com/flynn/noise
+var noiseVector = struct {
+	initStatic, respStatic, initEphemeral, respEphemeral string
+	messages                                             []string
+	payloads                                             []string
+}{
+	initStatic:    "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+	respStatic:    "0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20",
+	initEphemeral: "202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f",
+	respEphemeral: "4142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f60",
+	messages: []string{
+		"358072d6365880d1aeea329adf9121383851ed21a28e3b75e965d0d2cd166254",
+		"64b101b1d0be5a8704bd078f9895001fc03e8e9f9522f188dd128d9846d484665393019dbd6f438795da206db0886610b26108e424142c2e9b5fd1f7ea70cde8767ce62d7e3c0e9bcefe4ab872c0505b9e824df091b74ffe10a2b32809cab21f",
+		"e610eadc4b00c17708bf223f29a66f02342fbedf6c0044736544b9271821ae40e70144cecd9d265dffdc5bb8e051c3f83db32a425e04d8f510c58a43325fbc56",
+		"9ea1da1ec3bfecfffab213e537ed1791bfa887dd9c631351b3f63d6315ab9a",
+		"217c5111fad7afde33bd28abaff3def88a57ab50515115d23a10f28621f842",
+	},
+	payloads: []string{"", "", "", "79656c6c6f777375626d6172696e65", "7375626d6172696e6579656c6c6f77"},
+}
+
+func noiseVectorKey(t *testing.T, key string) *ecdh.PrivateKey {
+	data, err := hex.DecodeString(key)
+	assert.NoError(t, err)
+
+	private, err := ecdh.X25519().NewPrivateKey(data)
+	assert.NoError(t, err)
+	return private
+}
+
+func noiseVectorFrames(t *testing.T, indexes ...int) []byte {
+	frames := &bytes.Buffer{}
+	for _, i := range indexes {
+		data, err := hex.DecodeString(noiseVector.messages[i])
+		assert.NoError(t, err)
+		assert.NoError(t, writeNoiseFrame(frames, data))
+	}
+	return frames.Bytes()
+}
+
+type noiseVectorPeer struct {
+	*bytes.Reader
+	*bytes.Buffer
+}
+
+func (p *noiseVectorPeer) Read(b []byte) (int, error) {
+	return p.Reader.Read(b)
+}
+
+func (p *noiseVectorPeer) Write(b []byte) (int, error) {
+	return p.Buffer.Write(b)
+}
+
+func TestNoiseHandshake_Vector(t *testing.T) {
+	initiator := newNoiseHandshake(noiseVectorKey(t, noiseVector.initStatic))
+	initiator.e = noiseVectorKey(t, noiseVector.initEphemeral)
+	responder := newNoiseHandshake(noiseVectorKey(t, noiseVector.respStatic))
+	responder.e = noiseVectorKey(t, noiseVector.respEphemeral)
+
+	initiatorPeer := &noiseVectorPeer{bytes.NewReader(noiseVectorFrames(t, 1)), &bytes.Buffer{}}
+	assert.NoError(t, initiator.initiate(initiatorPeer))
+	assert.Equal(t, noiseVectorFrames(t, 0, 2), initiatorPeer.Buffer.Bytes())
+
+	responderPeer := &noiseVectorPeer{bytes.NewReader(noiseVectorFrames(t, 0, 2)), &bytes.Buffer{}}
+	assert.NoError(t, responder.respond(responderPeer))
+	assert.Equal(t, noiseVectorFrames(t, 1), responderPeer.Buffer.Bytes())
+
+	initiatorSend, _, err := initiator.split()
+	assert.NoError(t, err)
+	_, responderSend, err := responder.split()
+	assert.NoError(t, err)
+
+	for i, cs := range map[int]*noiseCipherState{3: initiatorSend, 4: responderSend} {
+		payload, err := hex.DecodeString(noiseVector.payloads[i])
+		assert.NoError(t, err)
+
+		ciphertext, err := cs.encrypt(nil, payload)
+		assert.NoError(t, err)
+		assert.Equal(t, noiseVector.messages[i], hex.EncodeToString(ciphertext))
+	}
+}
+
+func TestNoiseCipherState_NonceExhausted(t *testing.T) {
+	cs, err := newNoiseCipherState(make([]byte, noiseKeySize))
+	assert.NoError(t, err)
+
+	cs.nonce = math.MaxUint64 - 1
+	ciphertext, err := cs.encrypt(nil, []byte("ping"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ciphertext)
+
+	_, err = cs.encrypt(nil, []byte("ping"))
+	assert.Equal(t, errNoiseNonceExhausted, err)
+	_, err = cs.decrypt(nil, ciphertext)
+	assert.Equal(t, errNoiseNonceExhausted, err)
+}
+
+func TestVerifyNoisePeer(t *testing.T) {
+	key := createNoiseConfig(t).StaticKey.PublicKey().Bytes()
+	id := NoiseID(key)
+	other, _ := node.NewID()
+
+	assert.NoError(t, verifyNoisePeer(key, nil, nil))
+	assert.NoError(t, verifyNoisePeer(key, id, nil))
+	assert.NoError(t, verifyNoisePeer(key, id, []node.ID{other, id}))
+	assert.EqualError(t, verifyNoisePeer(key, other, nil), "peer static key does not match node ID")
+	assert.EqualError(t, verifyNoisePeer(key, nil, []node.ID{other}), "peer static key is not pinned")
+}
+
+func TestNoiseConn_LargeMessage(t *testing.T) {
+	initiatorConn, responderConn := net.Pipe()
+	initiator := newNoiseConn(initiatorConn, createNoiseConfig(t), true, nil)
+	responder := newNoiseConn(responderConn, createNoiseConfig(t), false, nil)
+
+	data := make([]byte, noiseMaxMessageSize*2)
+	_, err := rand.Read(data)
+	assert.NoError(t, err)
+
+	go func() {
+		initiator.Write(data)
+	}()
+
+	received := make([]byte, len(data))
+	read := 0
+	for read < len(received) {
+		n, err := responder.Read(received[read:])
+		assert.NoError(t, err)
+		read += n
+	}
+	assert.Equal(t, data, received)
+}
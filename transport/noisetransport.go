@@ -0,0 +1,270 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"crypto/ecdh"
+	"crypto/sha1"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/node"
+)
+
+const noiseHandshakeTimeout = time.Second * 5
+
+// NoiseConfig configures Noise transport
+type NoiseConfig struct {
+	// StaticKey is a long-term X25519 key, its public key determines node identity (see NoiseID)
+	StaticKey *ecdh.PrivateKey
+
+	// Identities of peers which are accepted, any peer is accepted if empty
+	PinnedIDs []node.ID
+
+	// VerifyPeer replaces default peer verification if not nil. It is called after handshake with
+	// remote static public key and ID of node which is dialed, ID is nil if it is not known,
+	// e.g. for accepted connections. Connection is closed if it returns an error.
+	//
+	// By default identity derived from remote static key must be equal to ID of dialed node
+	// and be one of PinnedIDs if they are set.
+	VerifyPeer func(remoteStatic []byte, expected node.ID) error
+}
+
+type noiseSocket struct {
+	socket socket
+	config *NoiseConfig
+}
+
+type noiseConn struct {
+	net.Conn
+
+	config    *NoiseConfig
+	initiator bool
+	expected  node.ID
+	peer      node.ID
+
+	handshakeOnce *sync.Once
+	handshakeErr  error
+
+	send    *noiseCipherState
+	receive *noiseCipherState
+	buffer  []byte
+}
+
+// NewNoiseTransport secures connections of given transport with Noise XX handshake.
+// Both peers are authenticated by their static keys, identity of peer is derived from its static key
+// and must match ID of node it is dialed as, see NoiseConfig. Messages received from peer are dropped
+// if they are not sent by node with that identity.
+// It must be called before transport is started.
+func NewNoiseTransport(transport Transport, config *NoiseConfig) (Transport, error) {
+	st, ok := transport.(*streamTransport)
+	if !ok {
+		return nil, errors.New("transport does not support Noise")
+	}
+	if config.StaticKey == nil {
+		return nil, errors.New("static key is required")
+	}
+
+	st.socket = &noiseSocket{
+		socket: st.socket,
+		config: config,
+	}
+
+	return st, nil
+}
+
+// Accept waits for the next incoming connection, handshake is performed on first read
+func (s *noiseSocket) Accept() (net.Conn, error) {
+	conn, err := s.socket.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return newNoiseConn(conn, s.config, false, nil), nil
+}
+
+// Dial connects to given address and performs Noise handshake
+func (s *noiseSocket) Dial(address string) (net.Conn, error) {
+	return s.DialNode(address, nil)
+}
+
+// DialNode connects to node with given id and performs Noise handshake, connection is refused
+// if peer static key does not match id
+func (s *noiseSocket) DialNode(address string, id node.ID) (net.Conn, error) {
+	conn, err := dialNode(s.socket, address, id)
+	if err != nil {
+		return nil, err
+	}
+
+	noise := newNoiseConn(conn, s.config, true, id)
+	err = noise.Handshake()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return noise, nil
+}
+
+// Addr returns underlying socket address
+func (s *noiseSocket) Addr() net.Addr {
+	return s.socket.Addr()
+}
+
+// Close closes underlying socket
+func (s *noiseSocket) Close() error {
+	return s.socket.Close()
+}
+
+// NoiseID returns node identity bound to Noise static public key, it is a hash of the key.
+// Node using Noise transport is expected to use it as its ID.
+func NoiseID(staticKey []byte) node.ID {
+	id := sha1.Sum(staticKey)
+	return id[:]
+}
+
+func verifyNoisePeer(remoteStatic []byte, expected node.ID, pinned []node.ID) error {
+	id := NoiseID(remoteStatic)
+	if expected != nil && !expected.Equal(id) {
+		return errors.New("peer static key does not match node ID")
+	}
+	if len(pinned) == 0 {
+		return nil
+	}
+
+	for _, pinnedID := range pinned {
+		if pinnedID.Equal(id) {
+			return nil
+		}
+	}
+	return errors.New("peer static key is not pinned")
+}
+
+func newNoiseConn(conn net.Conn, config *NoiseConfig, initiator bool, expected node.ID) *noiseConn {
+	return &noiseConn{
+		Conn:          conn,
+		config:        config,
+		initiator:     initiator,
+		expected:      expected,
+		handshakeOnce: &sync.Once{},
+	}
+}
+
+// Handshake runs Noise handshake if it has not been run yet
+func (c *noiseConn) Handshake() error {
+	c.handshakeOnce.Do(func() {
+		c.handshakeErr = handshakeFailed(c.handshake())
+	})
+	return c.handshakeErr
+}
+
+// PeerID returns identity derived from remote static key, it is nil until handshake is done
+func (c *noiseConn) PeerID() node.ID {
+	return c.peer
+}
+
+// Read reads and decrypts data from connection
+func (c *noiseConn) Read(b []byte) (int, error) {
+	err := c.Handshake()
+	if err != nil {
+		return 0, err
+	}
+
+	for len(c.buffer) == 0 {
+		frame, err := readNoiseFrame(c.Conn)
+		if err != nil {
+			return 0, err
+		}
+		c.buffer, err = c.receive.decrypt(nil, frame)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(b, c.buffer)
+	c.buffer = c.buffer[n:]
+	return n, nil
+}
+
+// Write encrypts and writes data to connection
+func (c *noiseConn) Write(b []byte) (int, error) {
+	err := c.Handshake()
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for written < len(b) {
+		chunk := b[written:]
+		if len(chunk) > noiseMaxMessageSize-noiseTagSize {
+			chunk = chunk[:noiseMaxMessageSize-noiseTagSize]
+		}
+
+		frame, err := c.send.encrypt(nil, chunk)
+		if err != nil {
+			return written, err
+		}
+		err = writeNoiseFrame(c.Conn, frame)
+		if err != nil {
+			return written, err
+		}
+		written += len(chunk)
+	}
+
+	return written, nil
+}
+
+func (c *noiseConn) handshake() error {
+	err := c.Conn.SetDeadline(time.Now().Add(noiseHandshakeTimeout))
+	if err != nil {
+		return err
+	}
+
+	hs := newNoiseHandshake(c.config.StaticKey)
+	if c.initiator {
+		err = hs.initiate(c.Conn)
+	} else {
+		err = hs.respond(c.Conn)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.config.VerifyPeer != nil {
+		err = c.config.VerifyPeer(hs.rs.Bytes(), c.expected)
+	} else {
+		err = verifyNoisePeer(hs.rs.Bytes(), c.expected, c.config.PinnedIDs)
+	}
+	if err != nil {
+		return err
+	}
+	c.peer = NoiseID(hs.rs.Bytes())
+
+	initiatorCipher, responderCipher, err := hs.split()
+	if err != nil {
+		return err
+	}
+	if c.initiator {
+		c.send, c.receive = initiatorCipher, responderCipher
+	} else {
+		c.send, c.receive = responderCipher, initiatorCipher
+	}
+
+	return c.Conn.SetDeadline(time.Time{})
+}
@@ -0,0 +1,150 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createNoiseTransport(t *testing.T, address string, config *NoiseConfig) (Transport, *node.Node) {
+	tp, n := createTCPTransport(t, address)
+	n.ID = NoiseID(config.StaticKey.PublicKey().Bytes())
+
+	tp, err := NewNoiseTransport(tp, config)
+	assert.NoError(t, err)
+
+	return tp, n
+}
+
+func TestNewNoiseTransport_NotSupported(t *testing.T) {
+	_, err := NewNoiseTransport(nil, &NoiseConfig{})
+	assert.EqualError(t, err, "transport does not support Noise")
+}
+
+func TestNoiseTransport_SendRequest(t *testing.T) {
+	first, firstNode := createNoiseTransport(t, "127.0.0.1:8089", createNoiseConfig(t))
+	second, secondNode := createNoiseTransport(t, "127.0.0.1:8090", createNoiseConfig(t))
+
+	done := make(chan bool)
+	for _, tp := range []Transport{first, second} {
+		go func(tp Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+	}
+
+	future, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+
+	request := <-second.Messages()
+	assert.Equal(t, firstNode.ID, request.Sender.ID)
+
+	response := message.NewBuilder().Sender(secondNode).Receiver(firstNode).Type(message.TypePing).Response(nil).Build()
+	err = second.SendResponse(request.RequestID, response)
+	assert.NoError(t, err)
+
+	result := <-future.Result()
+	assert.Equal(t, secondNode.ID, result.Sender.ID)
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestNoiseTransport_SendRequest_IDMismatch(t *testing.T) {
+	first, firstNode := createNoiseTransport(t, "127.0.0.1:8091", createNoiseConfig(t))
+	second, secondNode := createNoiseTransport(t, "127.0.0.1:8092", createNoiseConfig(t))
+	secondNode.ID, _ = node.NewID()
+
+	done := make(chan bool)
+	for _, tp := range []Transport{first, second} {
+		go func(tp Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+	}
+
+	_, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.EqualError(t, err, "peer static key does not match node ID")
+	assert.Empty(t, first.PendingRequests())
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestNoiseTransport_SendRequest_RejectedPeer(t *testing.T) {
+	config := createNoiseConfig(t)
+	var verified []byte
+	var expected node.ID
+	config.VerifyPeer = func(remoteStatic []byte, id node.ID) error {
+		verified, expected = remoteStatic, id
+		return errors.New("unknown peer")
+	}
+	secondConfig := createNoiseConfig(t)
+
+	first, firstNode := createNoiseTransport(t, "127.0.0.1:8187", config)
+	second, secondNode := createNoiseTransport(t, "127.0.0.1:8188", secondConfig)
+
+	done := make(chan bool)
+	for _, tp := range []Transport{first, second} {
+		go func(tp Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+	}
+
+	_, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.EqualError(t, err, "unknown peer")
+	assert.Equal(t, secondConfig.StaticKey.PublicKey().Bytes(), verified)
+	assert.Equal(t, secondNode.ID, expected)
+	assert.Empty(t, first.PendingRequests())
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestNoiseTransport_SpoofedSender(t *testing.T) {
+	first, firstNode := createNoiseTransport(t, "127.0.0.1:8189", createNoiseConfig(t))
+	second, secondNode := createNoiseTransport(t, "127.0.0.1:8190", createNoiseConfig(t))
+
+	done := make(chan bool)
+	for _, tp := range []Transport{first, second} {
+		go func(tp Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+	}
+
+	spoofed := *firstNode
+	spoofed.ID, _ = node.NewID()
+	_, err := first.SendRequest(message.NewPingMessage(&spoofed, secondNode))
+	assert.NoError(t, err)
+	_, err = first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+
+	// Message of spoofed sender is dropped, the next one from the same connection is received
+	request := <-second.Messages()
+	assert.Equal(t, firstNode.ID, request.Sender.ID)
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
@@ -0,0 +1,319 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// Datagrams are prefixed with role of sender and id of association chosen by dialer
+	packetHeaderSize = 5
+
+	packetFromDialer   = byte(0)
+	packetFromAcceptor = byte(1)
+
+	packetQueueSize   = 64
+	packetAcceptQueue = 64
+	maxPacketSize     = 65535
+)
+
+type packetKey struct {
+	address string
+	id      uint32
+	dialed  bool
+}
+
+// packetListener splits datagrams of packet connection into associations with remote peers,
+// so that datagram protocols can run over a single shared socket. Both peers may dial each other,
+// associations are told apart by id chosen by dialer.
+type packetListener struct {
+	conn     net.PacketConn
+	sequence uint32
+
+	mutex *sync.Mutex
+	conns map[packetKey]*packetConn
+
+	accepted chan *packetConn
+	closed   chan bool
+	once     *sync.Once
+}
+
+func newPacketListener(conn net.PacketConn) *packetListener {
+	l := &packetListener{
+		conn:     conn,
+		sequence: rand.Uint32(),
+		mutex:    &sync.Mutex{},
+		conns:    make(map[packetKey]*packetConn),
+		accepted: make(chan *packetConn, packetAcceptQueue),
+		closed:   make(chan bool),
+		once:     &sync.Once{},
+	}
+
+	go l.receive()
+
+	return l
+}
+
+// Accept waits for the next association dialed by remote peer
+func (l *packetListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.accepted:
+		return conn, nil
+	case <-l.closed:
+		return nil, errors.New("listener closed")
+	}
+}
+
+// Dial opens new association with remote address
+func (l *packetListener) Dial(address net.Addr) (net.Conn, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	select {
+	case <-l.closed:
+		return nil, errors.New("listener closed")
+	default:
+	}
+
+	l.sequence++
+	key := packetKey{address: address.String(), id: l.sequence, dialed: true}
+	conn := newPacketConn(l, key, address)
+	l.conns[key] = conn
+
+	return conn, nil
+}
+
+// Addr returns address of packet connection
+func (l *packetListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+// Close closes packet connection and all associations
+func (l *packetListener) Close() error {
+	var err error
+	l.once.Do(func() {
+		close(l.closed)
+		err = l.conn.Close()
+	})
+	return err
+}
+
+func (l *packetListener) receive() {
+	buffer := make([]byte, maxPacketSize)
+	for {
+		n, address, err := l.conn.ReadFrom(buffer)
+		if err != nil {
+			l.Close()
+			l.closeAll()
+			return
+		}
+		if n < packetHeaderSize {
+			continue
+		}
+
+		// Datagram from dialer belongs to association accepted by us and vice versa
+		key := packetKey{
+			address: address.String(),
+			id:      binary.BigEndian.Uint32(buffer[1:packetHeaderSize]),
+			dialed:  buffer[0] == packetFromAcceptor,
+		}
+		conn := l.association(key, address)
+		if conn == nil {
+			continue
+		}
+
+		packet := make([]byte, n-packetHeaderSize)
+		copy(packet, buffer[packetHeaderSize:n])
+		conn.enqueue(packet)
+	}
+}
+
+// association returns association datagram belongs to, new association is accepted
+// for the first datagram from dialer
+func (l *packetListener) association(key packetKey, address net.Addr) *packetConn {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	conn, ok := l.conns[key]
+	if ok || key.dialed {
+		return conn
+	}
+
+	conn = newPacketConn(l, key, address)
+	select {
+	case l.accepted <- conn:
+		l.conns[key] = conn
+		return conn
+	default:
+		// Too many associations are waiting for Accept
+		return nil
+	}
+}
+
+func (l *packetListener) remove(key packetKey) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.conns, key)
+}
+
+func (l *packetListener) closeAll() {
+	l.mutex.Lock()
+	conns := make([]*packetConn, 0, len(l.conns))
+	for _, conn := range l.conns {
+		conns = append(conns, conn)
+	}
+	l.mutex.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
+
+// packetConn is association with remote peer, every Read returns a single datagram.
+// Datagrams which are not read in time are dropped.
+type packetConn struct {
+	listener *packetListener
+	key      packetKey
+	address  net.Addr
+	header   []byte
+
+	packets chan []byte
+	closed  chan bool
+	once    *sync.Once
+
+	mutex        *sync.Mutex
+	readDeadline time.Time
+}
+
+func newPacketConn(listener *packetListener, key packetKey, address net.Addr) *packetConn {
+	header := make([]byte, packetHeaderSize)
+	header[0] = packetFromAcceptor
+	if key.dialed {
+		header[0] = packetFromDialer
+	}
+	binary.BigEndian.PutUint32(header[1:], key.id)
+
+	return &packetConn{
+		listener: listener,
+		key:      key,
+		address:  address,
+		header:   header,
+		packets:  make(chan []byte, packetQueueSize),
+		closed:   make(chan bool),
+		once:     &sync.Once{},
+		mutex:    &sync.Mutex{},
+	}
+}
+
+func (c *packetConn) enqueue(packet []byte) {
+	select {
+	case c.packets <- packet:
+	default:
+	}
+}
+
+// Read reads the next datagram, it is truncated if b is too small
+func (c *packetConn) Read(b []byte) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, errors.New("connection closed")
+	default:
+	}
+
+	c.mutex.Lock()
+	deadline := c.readDeadline
+	c.mutex.Unlock()
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case packet := <-c.packets:
+		return copy(b, packet), nil
+	case <-c.closed:
+		return 0, errors.New("connection closed")
+	case <-timeout:
+		return 0, errors.New("i/o timeout")
+	}
+}
+
+// Write sends b as a single datagram
+func (c *packetConn) Write(b []byte) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, errors.New("connection closed")
+	default:
+	}
+
+	packet := make([]byte, 0, packetHeaderSize+len(b))
+	packet = append(packet, c.header...)
+	packet = append(packet, b...)
+	_, err := c.listener.conn.WriteTo(packet, c.address)
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close closes association, packet connection is left open
+func (c *packetConn) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+		c.listener.remove(c.key)
+	})
+	return nil
+}
+
+// LocalAddr returns address of packet connection
+func (c *packetConn) LocalAddr() net.Addr {
+	return c.listener.conn.LocalAddr()
+}
+
+// RemoteAddr returns address of remote peer
+func (c *packetConn) RemoteAddr() net.Addr {
+	return c.address
+}
+
+// SetDeadline sets read deadline, writes of datagrams do not block
+func (c *packetConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+// SetReadDeadline sets deadline of the following reads
+func (c *packetConn) SetReadDeadline(t time.Time) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.readDeadline = t
+	return nil
+}
+
+// SetWriteDeadline does nothing, writes of datagrams do not block
+func (c *packetConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
@@ -0,0 +1,105 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createPacketListener(t *testing.T) *packetListener {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	return newPacketListener(conn)
+}
+
+func TestPacketListener(t *testing.T) {
+	first := createPacketListener(t)
+	second := createPacketListener(t)
+	defer first.Close()
+	defer second.Close()
+
+	// Associations dialed by both sides do not mix
+	dialed, err := first.Dial(second.Addr())
+	assert.NoError(t, err)
+	reverse, err := second.Dial(first.Addr())
+	assert.NoError(t, err)
+
+	_, err = dialed.Write([]byte("foo"))
+	assert.NoError(t, err)
+	_, err = reverse.Write([]byte("bar"))
+	assert.NoError(t, err)
+
+	accepted, err := second.Accept()
+	assert.NoError(t, err)
+	assert.Equal(t, first.Addr().String(), accepted.RemoteAddr().String())
+	buffer := make([]byte, 16)
+	n, err := accepted.Read(buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(buffer[:n]))
+
+	_, err = accepted.Write([]byte("baz"))
+	assert.NoError(t, err)
+	n, err = dialed.Read(buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", string(buffer[:n]))
+
+	acceptedReverse, err := first.Accept()
+	assert.NoError(t, err)
+	n, err = acceptedReverse.Read(buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(buffer[:n]))
+}
+
+func TestPacketConn_Deadline(t *testing.T) {
+	listener := createPacketListener(t)
+	defer listener.Close()
+
+	conn, err := listener.Dial(listener.Addr())
+	assert.NoError(t, err)
+
+	err = conn.SetDeadline(time.Now().Add(time.Millisecond * 10))
+	assert.NoError(t, err)
+	_, err = conn.Read(make([]byte, 16))
+	assert.EqualError(t, err, "i/o timeout")
+
+	conn.Close()
+	_, err = conn.Read(make([]byte, 16))
+	assert.EqualError(t, err, "connection closed")
+	_, err = conn.Write([]byte("foo"))
+	assert.EqualError(t, err, "connection closed")
+}
+
+func TestPacketListener_Close(t *testing.T) {
+	listener := createPacketListener(t)
+
+	conn, err := listener.Dial(listener.Addr())
+	assert.NoError(t, err)
+
+	listener.Close()
+	_, err = listener.Accept()
+	assert.EqualError(t, err, "listener closed")
+	_, err = listener.Dial(listener.Addr())
+	assert.EqualError(t, err, "listener closed")
+
+	// Associations are closed once packet connection is closed
+	_, err = conn.Read(make([]byte, 16))
+	assert.EqualError(t, err, "connection closed")
+}
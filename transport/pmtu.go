@@ -0,0 +1,225 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// pmtuHeaderSize is size of magic, kind and probed size of probe packet
+	pmtuHeaderSize = 10
+
+	pmtuProbe = byte(1)
+	pmtuAck   = byte(2)
+
+	// pmtuProbeTimeout is time to wait for acks of probes sent at once
+	pmtuProbeTimeout = time.Millisecond * 200
+	// pmtuProbeRounds is number of times probes are sent before peer is considered not answering them
+	pmtuProbeRounds = 2
+	// pmtuTTL is time after which path MTU is discovered again
+	pmtuTTL = time.Minute * 10
+)
+
+// pmtuMagic starts probe packets, it can't be mistaken for a packet of transport protocol in practice
+var pmtuMagic = []byte("insPMTU")
+
+// pmtuCandidates are UDP payload sizes probed: Ethernet with IPv4 and IPv6 headers,
+// common tunnels and IPv6 minimum MTU, down to IPv4 minimum reassembly size
+var pmtuCandidates = []int{1472, 1452, 1400, 1280, 1232, 1024, 548}
+
+type pathMTU struct {
+	size       int
+	discovered time.Time
+}
+
+// pathMTUConn is a PacketConn which discovers the largest UDP payload delivered to every peer host.
+// Peer answers probes only if it uses pathMTUConn too. Packets are not marked as non-fragmentable,
+// so probes which are fragmented are assumed lost as fragments are dropped on many networks.
+// Probes and acks are never returned by ReadFrom.
+type pathMTUConn struct {
+	net.PacketConn
+
+	mutex *sync.Mutex
+	mtus  map[string]pathMTU
+	acks  map[string]chan int
+
+	// probing allows only one discovery at once
+	probing *sync.Mutex
+}
+
+func newPathMTUConn(conn net.PacketConn) *pathMTUConn {
+	return &pathMTUConn{
+		PacketConn: conn,
+		mutex:      &sync.Mutex{},
+		mtus:       make(map[string]pathMTU),
+		acks:       make(map[string]chan int),
+		probing:    &sync.Mutex{},
+	}
+}
+
+// ReadFrom reads the next packet which is not a probe, probes are answered meanwhile
+func (c *pathMTUConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	for {
+		n, addr, err := c.PacketConn.ReadFrom(p)
+		if err != nil || !isPMTUPacket(p[:n]) {
+			return n, addr, err
+		}
+		c.handlePacket(p[:n], addr)
+	}
+}
+
+func isPMTUPacket(packet []byte) bool {
+	return len(packet) >= pmtuHeaderSize && bytes.Equal(packet[:len(pmtuMagic)], pmtuMagic)
+}
+
+func newPMTUPacket(kind byte, size int, length int) []byte {
+	packet := make([]byte, length)
+	copy(packet, pmtuMagic)
+	packet[len(pmtuMagic)] = kind
+	binary.BigEndian.PutUint16(packet[len(pmtuMagic)+1:], uint16(size))
+	return packet
+}
+
+func (c *pathMTUConn) handlePacket(packet []byte, addr net.Addr) {
+	kind := packet[len(pmtuMagic)]
+	size := int(binary.BigEndian.Uint16(packet[len(pmtuMagic)+1:]))
+
+	switch kind {
+	case pmtuProbe:
+		// Probe truncated by reader buffer is not acked, smaller one is
+		if len(packet) == size {
+			c.PacketConn.WriteTo(newPMTUPacket(pmtuAck, size, pmtuHeaderSize), addr)
+		}
+	case pmtuAck:
+		c.mutex.Lock()
+		acks, ok := c.acks[hostOf(addr.String())]
+		c.mutex.Unlock()
+		if ok {
+			select {
+			case acks <- size:
+			default:
+			}
+		}
+	}
+}
+
+// discover returns the largest UDP payload up to max delivered to host of address,
+// fallback is returned if peer doesn't answer probes
+func (c *pathMTUConn) discover(address string, max, fallback int) int {
+	c.probing.Lock()
+	defer c.probing.Unlock()
+
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return fallback
+	}
+	host := hostOf(addr.String())
+
+	if size, ok := c.known(host); ok {
+		return limitMTU(size, max, fallback)
+	}
+
+	acks := make(chan int, len(pmtuCandidates))
+	c.mutex.Lock()
+	c.acks[host] = acks
+	c.mutex.Unlock()
+	defer func() {
+		c.mutex.Lock()
+		delete(c.acks, host)
+		c.mutex.Unlock()
+	}()
+
+	size := 0
+	for round := 0; round < pmtuProbeRounds && size == 0; round++ {
+		// All sizes are probed at once, the largest acked one wins
+		largest := 0
+		for _, candidate := range pmtuCandidates {
+			if max == 0 || candidate <= max {
+				c.PacketConn.WriteTo(newPMTUPacket(pmtuProbe, candidate, candidate), addr)
+				if candidate > largest {
+					largest = candidate
+				}
+			}
+		}
+		size = waitLargestAck(acks, largest, pmtuProbeTimeout)
+	}
+
+	c.mutex.Lock()
+	c.mtus[host] = pathMTU{size: size, discovered: time.Now()}
+	c.mutex.Unlock()
+
+	return limitMTU(size, max, fallback)
+}
+
+// known returns path MTU discovered recently for host, zero size means host doesn't answer probes
+func (c *pathMTUConn) known(host string) (int, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	mtu, ok := c.mtus[host]
+	if !ok || time.Since(mtu.discovered) > pmtuTTL {
+		return 0, false
+	}
+	return mtu.size, true
+}
+
+// lookup returns path MTU discovered for host of address without probing
+func (c *pathMTUConn) lookup(address string, max, fallback int) int {
+	size, _ := c.known(hostOf(address))
+	return limitMTU(size, max, fallback)
+}
+
+// waitLargestAck waits for acks until timeout or until the largest probe is acked
+func waitLargestAck(acks chan int, probed int, timeout time.Duration) int {
+	largest := 0
+	deadline := time.After(timeout)
+	for largest < probed {
+		select {
+		case size := <-acks:
+			if size > largest {
+				largest = size
+			}
+		case <-deadline:
+			return largest
+		}
+	}
+	return largest
+}
+
+func limitMTU(size, max, fallback int) int {
+	if size == 0 {
+		return fallback
+	}
+	if max > 0 && size > max {
+		return max
+	}
+	return size
+}
+
+// hostOf returns host part of address, path MTU is the same for all ports of host
+func hostOf(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return host
+}
@@ -0,0 +1,115 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// limitedPacketConn drops packets larger than limit like a link with small MTU
+type limitedPacketConn struct {
+	net.PacketConn
+	limit int
+}
+
+func (c *limitedPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if len(p) > c.limit {
+		return len(p), nil
+	}
+	return c.PacketConn.WriteTo(p, addr)
+}
+
+// readPackets reads conn like transport would do and passes packets to returned channel
+func readPackets(conn net.PacketConn) chan string {
+	packets := make(chan string, 16)
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				close(packets)
+				return
+			}
+			packets <- string(buf[:n])
+		}
+	}()
+	return packets
+}
+
+func TestPathMTUConn_Discover(t *testing.T) {
+	first, second := createPacketConns(t)
+	prober := newPathMTUConn(first)
+	peer := newPathMTUConn(second)
+	defer prober.Close()
+	defer peer.Close()
+
+	proberPackets := readPackets(prober)
+	peerPackets := readPackets(peer)
+
+	address := peer.LocalAddr().String()
+	assert.Equal(t, 1472, prober.discover(address, 0, 1400))
+	assert.Equal(t, 1472, prober.lookup("127.0.0.1:1", 0, 1400))
+	assert.Equal(t, 1400, prober.discover(address, 1400, 1400))
+
+	// Probes are not returned to reader
+	_, err := prober.WriteTo([]byte("packet"), peer.LocalAddr())
+	assert.NoError(t, err)
+	assert.Equal(t, "packet", <-peerPackets)
+	assert.Empty(t, proberPackets)
+}
+
+func TestPathMTUConn_DiscoverLimitedPath(t *testing.T) {
+	first, second := createPacketConns(t)
+	prober := newPathMTUConn(&limitedPacketConn{PacketConn: first, limit: 1300})
+	peer := newPathMTUConn(second)
+	defer prober.Close()
+	defer peer.Close()
+
+	readPackets(prober)
+	readPackets(peer)
+
+	assert.Equal(t, 1280, prober.discover(peer.LocalAddr().String(), 0, 1400))
+}
+
+func TestPathMTUConn_DiscoverNotAnswering(t *testing.T) {
+	first, second := createPacketConns(t)
+	prober := newPathMTUConn(first)
+	defer prober.Close()
+	defer second.Close()
+
+	readPackets(prober)
+
+	address := second.LocalAddr().String()
+	assert.Equal(t, 1400, prober.discover(address, 0, 1400))
+	assert.Equal(t, 1400, prober.lookup(address, 0, 1400))
+
+	// Result is cached, peer is not probed again
+	started := time.Now()
+	assert.Equal(t, 1400, prober.discover(address, 0, 1400))
+	assert.True(t, time.Since(started) < pmtuProbeTimeout)
+}
+
+func TestLimitMTU(t *testing.T) {
+	assert.Equal(t, 1400, limitMTU(0, 0, 1400))
+	assert.Equal(t, 1280, limitMTU(1280, 1400, 1400))
+	assert.Equal(t, 1400, limitMTU(1472, 1400, 1400))
+	assert.Equal(t, 1472, limitMTU(1472, 0, 1400))
+}
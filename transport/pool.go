@@ -0,0 +1,133 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPoolIdleTimeout = time.Second * 5
+	defaultPoolMaxPerPeer  = 2
+)
+
+type pooledConn struct {
+	conn     net.Conn
+	lastUsed time.Time
+}
+
+// connectionPool keeps idle outgoing connections keyed by remote address for reuse
+type connectionPool struct {
+	mutex       *sync.Mutex
+	idleTimeout time.Duration
+	maxPerPeer  int
+	idle        map[string][]pooledConn
+}
+
+func newConnectionPool(idleTimeout time.Duration, maxPerPeer int) *connectionPool {
+	return &connectionPool{
+		mutex:       &sync.Mutex{},
+		idleTimeout: idleTimeout,
+		maxPerPeer:  maxPerPeer,
+		idle:        make(map[string][]pooledConn),
+	}
+}
+
+// SetConnectionPool configures reuse of outgoing connections of given transport.
+// At most maxPerPeer idle connections are kept for each peer and closed after idleTimeout.
+// Zero maxPerPeer disables pooling. It must be called before transport is started.
+func SetConnectionPool(transport Transport, idleTimeout time.Duration, maxPerPeer int) error {
+	st, ok := transport.(*streamTransport)
+	if !ok {
+		return errors.New("transport does not support connection pooling")
+	}
+
+	st.pool = newConnectionPool(idleTimeout, maxPerPeer)
+	return nil
+}
+
+// get returns idle connection to address if there is one
+func (p *connectionPool) get(address string) net.Conn {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	conns := p.idle[address]
+	for len(conns) > 0 {
+		pc := conns[len(conns)-1]
+		conns = conns[:len(conns)-1]
+		if time.Since(pc.lastUsed) < p.idleTimeout {
+			p.setIdle(address, conns)
+			return pc.conn
+		}
+		pc.conn.Close()
+	}
+
+	p.setIdle(address, conns)
+	return nil
+}
+
+// put returns connection to the pool or closes it if pool is full
+func (p *connectionPool) put(address string, conn net.Conn) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.closeExpired()
+
+	if len(p.idle[address]) >= p.maxPerPeer {
+		conn.Close()
+		return
+	}
+	p.idle[address] = append(p.idle[address], pooledConn{conn: conn, lastUsed: time.Now()})
+}
+
+// closeAll closes all idle connections
+func (p *connectionPool) closeAll() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for address, conns := range p.idle {
+		for _, pc := range conns {
+			pc.conn.Close()
+		}
+		delete(p.idle, address)
+	}
+}
+
+func (p *connectionPool) closeExpired() {
+	for address, conns := range p.idle {
+		var alive []pooledConn
+		for _, pc := range conns {
+			if time.Since(pc.lastUsed) < p.idleTimeout {
+				alive = append(alive, pc)
+			} else {
+				pc.conn.Close()
+			}
+		}
+		p.setIdle(address, alive)
+	}
+}
+
+func (p *connectionPool) setIdle(address string, conns []pooledConn) {
+	if len(conns) == 0 {
+		delete(p.idle, address)
+		return
+	}
+	p.idle[address] = conns
+}
@@ -0,0 +1,102 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/stretchr/testify/assert"
+)
+
+type closeTrackingConn struct {
+	net.Conn
+	closed bool
+}
+
+func (c *closeTrackingConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestConnectionPool_GetPut(t *testing.T) {
+	pool := newConnectionPool(time.Minute, 1)
+	assert.Nil(t, pool.get("127.0.0.1:31337"))
+
+	first := &closeTrackingConn{}
+	second := &closeTrackingConn{}
+	pool.put("127.0.0.1:31337", first)
+	pool.put("127.0.0.1:31337", second)
+	assert.True(t, second.closed)
+
+	assert.Equal(t, first, pool.get("127.0.0.1:31337"))
+	assert.Nil(t, pool.get("127.0.0.1:31337"))
+	assert.False(t, first.closed)
+}
+
+func TestConnectionPool_IdleTimeout(t *testing.T) {
+	pool := newConnectionPool(time.Millisecond*10, 2)
+
+	conn := &closeTrackingConn{}
+	pool.put("127.0.0.1:31337", conn)
+	time.Sleep(time.Millisecond * 20)
+
+	assert.Nil(t, pool.get("127.0.0.1:31337"))
+	assert.True(t, conn.closed)
+}
+
+func TestConnectionPool_CloseAll(t *testing.T) {
+	pool := newConnectionPool(time.Minute, 2)
+
+	first := &closeTrackingConn{}
+	second := &closeTrackingConn{}
+	pool.put("127.0.0.1:31337", first)
+	pool.put("127.0.0.2:31337", second)
+	pool.closeAll()
+
+	assert.True(t, first.closed)
+	assert.True(t, second.closed)
+	assert.Nil(t, pool.get("127.0.0.1:31337"))
+}
+
+func TestSetConnectionPool_NotSupported(t *testing.T) {
+	err := SetConnectionPool(nil, time.Second, 1)
+	assert.EqualError(t, err, "transport does not support connection pooling")
+}
+
+func TestTCPTransport_ReusesConnection(t *testing.T) {
+	first, firstNode := createTCPTransport(t, "127.0.0.1:8103")
+	second, secondNode := createTCPTransport(t, "127.0.0.1:8104")
+	done := startTransports(first, second)
+
+	for i := 0; i < 3; i++ {
+		_, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+		assert.NoError(t, err)
+
+		request := <-second.Messages()
+		assert.Equal(t, message.TypePing, request.Type)
+	}
+
+	pool := first.(*streamTransport).pool
+	assert.Len(t, pool.idle[secondNode.Address.String()], 1)
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+	assert.Empty(t, pool.idle)
+}
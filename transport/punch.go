@@ -0,0 +1,177 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"log"
+	"net"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+)
+
+const (
+	punchAttempts = 3
+	punchInterval = time.Millisecond * 100
+)
+
+// SetHolePunching makes transport reach peers which advertise relay directly when possible.
+// If peer can't be dialed, its relay introduces both peers to each other with endpoints it observed,
+// and peers dial each other at once to open mappings of their NATs. Messages are sent over relay
+// if it fails. It must be called before transport is started.
+func SetHolePunching(transport Transport) error {
+	switch t := transport.(type) {
+	case *streamTransport:
+		t.holePunching = true
+	case *muxTransport:
+		for _, st := range t.transports {
+			st.holePunching = true
+		}
+	default:
+		return errors.New("transport does not support hole punching")
+	}
+
+	return nil
+}
+
+// dialKeys returns addresses connections to receiver can be pooled by
+func (t *streamTransport) dialKeys(receiver *node.Node, address string) []string {
+	switch {
+	case receiver.Relay == nil:
+		return []string{address}
+	case t.holePunching:
+		return []string{address, receiver.Relay.String()}
+	default:
+		return []string{receiver.Relay.String()}
+	}
+}
+
+// dial connects to receiver of msg, it returns connection and address it is pooled by.
+// Peers which advertise relay are reached over it unless direct connection succeeds.
+func (t *streamTransport) dial(msg *message.Message, address string) (net.Conn, string, error) {
+	if msg.Receiver.Relay == nil {
+		conn, err := dialNode(t.socket, address, msg.Receiver.ID)
+		return conn, address, err
+	}
+
+	relayAddress := msg.Receiver.Relay.String()
+	if t.holePunching {
+		conn, err := dialNode(t.socket, address, msg.Receiver.ID)
+		if err == nil {
+			return conn, address, nil
+		}
+
+		conn, err = t.punch(msg, address, relayAddress)
+		if err == nil {
+			return conn, address, nil
+		}
+		log.Println("Failed to punch hole to", address, ":", err.Error())
+	}
+
+	conn, err := t.socket.Dial(relayAddress)
+	return conn, relayAddress, err
+}
+
+// punch asks relay to introduce us to receiver of msg and dials endpoint of receiver relay observed
+func (t *streamTransport) punch(msg *message.Message, address string, relayAddress string) (net.Conn, error) {
+	conn, err := t.socket.Dial(relayAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	request := message.NewBuilder().Sender(msg.Sender).Receiver(msg.Receiver).Type(message.TypePunch).Request(
+		&message.RequestDataPunch{Address: address}).Build()
+	request.RequestID = t.generateID()
+
+	response, err := exchange(conn, request)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := response.Data.(*message.ResponseDataPunch)
+	if !ok {
+		return nil, errors.New("unexpected relay response")
+	}
+	if !data.Success {
+		return nil, errors.New("relay has no circuit to peer")
+	}
+
+	// Peer dials us at the same time, so attempts are repeated until mappings are open
+	for i := 0; i < punchAttempts; i++ {
+		var punched net.Conn
+		punched, err = dialNode(t.socket, data.Endpoint, msg.Receiver.ID)
+		if err == nil {
+			return punched, nil
+		}
+		time.Sleep(punchInterval)
+	}
+	return nil, err
+}
+
+// introduce forwards punch request received over conn to peer's circuit and replies with peer's endpoint
+func (t *streamTransport) introduce(conn net.Conn, msg *message.Message) {
+	data := msg.Data.(*message.RequestDataPunch)
+	response := &message.ResponseDataPunch{}
+
+	if c := t.relay.circuit(data.Address); c != nil {
+		introduction := message.NewBuilder().Sender(msg.Sender).Receiver(msg.Receiver).Type(message.TypePunch).Request(
+			&message.RequestDataPunch{Address: data.Address, Endpoint: conn.RemoteAddr().String()}).Build()
+
+		body, err := message.EncodeMessage(introduction)
+		if err == nil {
+			err = c.write(message.NewFrame(body, 0), t.writeTimeout)
+		}
+		if err != nil {
+			log.Println("Failed to introduce peer:", err.Error())
+		} else {
+			response.Success = true
+			response.Endpoint = c.conn.RemoteAddr().String()
+		}
+	}
+
+	frame, err := replyFrame(msg, response)
+	if err == nil {
+		err = writeWithDeadline(conn, frame, t.writeTimeout)
+	}
+	if err != nil {
+		log.Println("Failed to send punch response:", err.Error())
+	}
+}
+
+// punchBack dials endpoint of peer introduced by relay, connection is pooled for responses to peer
+func (t *streamTransport) punchBack(msg *message.Message) {
+	data := msg.Data.(*message.RequestDataPunch)
+	address := msg.Sender.AddressFor(t.network).String()
+
+	conn, err := dialNode(t.socket, data.Endpoint, msg.Sender.ID)
+	if err != nil {
+		log.Println("Failed to punch hole to", address, ":", err.Error())
+		return
+	}
+
+	err = writeWithDeadline(conn, message.NewFrame(nil, message.FlagKeepalive), t.writeTimeout)
+	if err != nil {
+		log.Println("Failed to punch hole to", address, ":", err.Error())
+		conn.Close()
+		return
+	}
+
+	t.pool.put(address, conn)
+}
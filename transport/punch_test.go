@@ -0,0 +1,135 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"net"
+	"testing"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetHolePunching_NotSupported(t *testing.T) {
+	err := SetHolePunching(nil)
+	assert.EqualError(t, err, "transport does not support hole punching")
+}
+
+func TestHolePunching_FallbackToRelay(t *testing.T) {
+	relay, relayNode := createTCPTransport(t, "127.0.0.1:8138")
+	hidden, _ := createTCPTransport(t, "127.0.0.1:8139")
+	first, firstNode := createTCPTransport(t, "127.0.0.1:8141")
+
+	assert.NoError(t, SetRelay(relay, 1))
+	assert.NoError(t, SetHolePunching(first))
+
+	done := make(chan bool)
+	for _, tp := range []Transport{relay, hidden, first} {
+		go func(tp Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+	}
+
+	hiddenAddress, _ := node.NewAddress("127.0.0.1:8140")
+	hiddenNode := node.NewNode(hiddenAddress)
+	hiddenNode.ID, _ = node.NewID()
+	hiddenNode.Relay = relayNode.Address
+
+	err := RegisterRelay(hidden, hiddenNode, relayNode.Address.String())
+	assert.NoError(t, err)
+
+	// TCP endpoints observed by relay are ephemeral ports nobody listens on, so hole punching fails
+	future, err := first.SendRequest(message.NewPingMessage(firstNode, hiddenNode))
+	assert.NoError(t, err)
+
+	request := <-hidden.Messages()
+	assert.Equal(t, firstNode.ID, request.Sender.ID)
+	assert.Equal(t, relayNode.Address.String(), request.RemoteAddress())
+
+	response := message.NewBuilder().Sender(hiddenNode).Receiver(firstNode).Type(message.TypePing).Response(nil).Build()
+	err = hidden.SendResponse(request.RequestID, response)
+	assert.NoError(t, err)
+
+	result := <-future.Result()
+	assert.Equal(t, hiddenNode.ID, result.Sender.ID)
+
+	stopTransport(first, done)
+	stopTransport(hidden, done)
+	stopTransport(relay, done)
+}
+
+func TestHolePunching_Direct(t *testing.T) {
+	hidden, _ := createTCPTransport(t, "127.0.0.1:8143")
+	first, firstNode := createTCPTransport(t, "127.0.0.1:8145")
+	assert.NoError(t, SetHolePunching(first))
+
+	// Relay reports endpoint hidden node actually listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:8142")
+	assert.NoError(t, err)
+	punches := make(chan *message.RequestDataPunch, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		body, _, err := message.ReadFrame(conn)
+		if err != nil {
+			return
+		}
+		request, err := message.DecodeMessage(body)
+		if err != nil {
+			return
+		}
+		punches <- request.Data.(*message.RequestDataPunch)
+
+		frame, _ := replyFrame(request, &message.ResponseDataPunch{Success: true, Endpoint: "127.0.0.1:8143"})
+		conn.Write(frame)
+	}()
+
+	done := make(chan bool)
+	for _, tp := range []Transport{hidden, first} {
+		go func(tp Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+	}
+
+	hiddenAddress, _ := node.NewAddress("127.0.0.1:8144")
+	relayAddress, _ := node.NewAddress("127.0.0.1:8142")
+	hiddenNode := node.NewNode(hiddenAddress)
+	hiddenNode.ID, _ = node.NewID()
+	hiddenNode.Relay = relayAddress
+
+	_, err = first.SendRequest(message.NewPingMessage(firstNode, hiddenNode))
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:8144", (<-punches).Address)
+
+	request := <-hidden.Messages()
+	assert.Equal(t, firstNode.ID, request.Sender.ID)
+
+	// Punched connection is reused for next messages
+	assert.NotNil(t, first.(*streamTransport).pool.get("127.0.0.1:8144"))
+
+	listener.Close()
+	stopTransport(first, done)
+	stopTransport(hidden, done)
+}
@@ -0,0 +1,144 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// tokenBucket allows rate bytes per second with bursts of up to one second worth of bytes.
+// Message larger than the burst is sent when bucket is full and drains it into debt.
+type tokenBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(rate),
+		tokens: float64(rate),
+		last:   now,
+	}
+}
+
+// take takes size tokens and returns time to wait until they are actually available
+func (b *tokenBucket) take(size int, now time.Time) time.Duration {
+	b.fill(now)
+	b.tokens -= float64(size)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) fill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+}
+
+func (b *tokenBucket) full(now time.Time) bool {
+	b.fill(now)
+	return b.tokens >= b.rate
+}
+
+// rateLimiter limits outgoing bytes per second in total and to every peer.
+// Zero limit means unlimited.
+type rateLimiter struct {
+	mutex   *sync.Mutex
+	global  *tokenBucket
+	perPeer int
+	peers   map[string]*tokenBucket
+}
+
+func newRateLimiter(global, perPeer int) *rateLimiter {
+	limiter := &rateLimiter{
+		mutex:   &sync.Mutex{},
+		perPeer: perPeer,
+		peers:   make(map[string]*tokenBucket),
+	}
+	if global > 0 {
+		limiter.global = newTokenBucket(global, time.Now())
+	}
+	return limiter
+}
+
+// SetRateLimit limits outgoing traffic of given transport to global bytes per second
+// in total and perPeer bytes per second to every remote address. Zero limit means unlimited.
+// Sending blocks until message fits into both limits. It must be called before transport is started.
+func SetRateLimit(transport Transport, global, perPeer int) error {
+	limiter := newRateLimiter(global, perPeer)
+
+	switch t := transport.(type) {
+	case *streamTransport:
+		t.limiter = limiter
+	case *muxTransport:
+		// Limits are shared by all transports
+		for _, st := range t.transports {
+			st.limiter = limiter
+		}
+	default:
+		return errors.New("transport does not support rate limiting")
+	}
+
+	return nil
+}
+
+// wait blocks until message of given size can be sent to address
+func (l *rateLimiter) wait(address string, size int) {
+	time.Sleep(l.reserve(address, size, time.Now()))
+}
+
+// reserve takes tokens from global and peer buckets and returns time to wait for them
+func (l *rateLimiter) reserve(address string, size int, now time.Time) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var wait time.Duration
+	if l.global != nil {
+		wait = l.global.take(size, now)
+	}
+
+	if l.perPeer > 0 {
+		l.forgetIdlePeers(now)
+
+		peer, ok := l.peers[address]
+		if !ok {
+			peer = newTokenBucket(l.perPeer, now)
+			l.peers[address] = peer
+		}
+		if peerWait := peer.take(size, now); peerWait > wait {
+			wait = peerWait
+		}
+	}
+
+	return wait
+}
+
+// forgetIdlePeers removes buckets of peers which are full, they are recreated full on demand
+func (l *rateLimiter) forgetIdlePeers(now time.Time) {
+	for address, peer := range l.peers {
+		if peer.full(now) {
+			delete(l.peers, address)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_Global(t *testing.T) {
+	limiter := newRateLimiter(1000, 0)
+	now := time.Now()
+
+	// Burst of one second worth of bytes is allowed
+	assert.Equal(t, time.Duration(0), limiter.reserve("127.0.0.1:31337", 600, now))
+	assert.Equal(t, time.Duration(0), limiter.reserve("127.0.0.2:31337", 400, now))
+	assert.Equal(t, time.Millisecond*500, limiter.reserve("127.0.0.1:31337", 500, now))
+
+	// Debt is paid off over time
+	assert.Equal(t, time.Duration(0), limiter.reserve("127.0.0.1:31337", 100, now.Add(time.Millisecond*700)))
+}
+
+func TestRateLimiter_PerPeer(t *testing.T) {
+	limiter := newRateLimiter(0, 1000)
+	now := time.Now()
+
+	assert.Equal(t, time.Duration(0), limiter.reserve("127.0.0.1:31337", 1000, now))
+	assert.Equal(t, time.Duration(0), limiter.reserve("127.0.0.2:31337", 1000, now))
+	assert.Equal(t, time.Second*2, limiter.reserve("127.0.0.1:31337", 2000, now))
+	assert.Len(t, limiter.peers, 2)
+
+	// Idle peers are forgotten
+	limiter.reserve("127.0.0.3:31337", 1, now.Add(time.Second*4))
+	assert.Len(t, limiter.peers, 1)
+}
+
+func TestRateLimiter_Both(t *testing.T) {
+	limiter := newRateLimiter(1000, 500)
+	now := time.Now()
+
+	assert.Equal(t, time.Second, limiter.reserve("127.0.0.1:31337", 1000, now))
+	assert.Equal(t, time.Millisecond*500, limiter.reserve("127.0.0.2:31337", 500, now))
+}
+
+func TestSetRateLimit_NotSupported(t *testing.T) {
+	err := SetRateLimit(nil, 1000, 1000)
+	assert.EqualError(t, err, "transport does not support rate limiting")
+}
+
+func TestSetRateLimit_Mux(t *testing.T) {
+	network := NewInMemoryNetwork(0, 0)
+	first, _ := createInMemoryTransport(t, network, "127.0.0.1:31340")
+	second, _ := createInMemoryTransport(t, network, "127.0.0.2:31340")
+
+	mux, err := NewMuxTransport([]string{"first", "second"}, []Transport{first, second})
+	assert.NoError(t, err)
+
+	err = SetRateLimit(mux, 1000, 0)
+	assert.NoError(t, err)
+	assert.NotNil(t, first.(*streamTransport).limiter)
+	assert.Equal(t, first.(*streamTransport).limiter, second.(*streamTransport).limiter)
+}
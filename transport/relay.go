@@ -0,0 +1,334 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+)
+
+const (
+	relayRegisterTimeout = time.Second * 5
+	relayRetryInterval   = time.Second
+)
+
+// relay forwards requests to nodes which registered circuits on it
+type relay struct {
+	maxCircuits int
+
+	mutex    *sync.Mutex
+	circuits map[string]*circuit
+}
+
+// circuit is a connection opened by node behind NAT to relay
+type circuit struct {
+	conn  net.Conn
+	mutex *sync.Mutex
+}
+
+// relayClient keeps circuits registered by transport on relays
+type relayClient struct {
+	stop chan bool
+
+	mutex *sync.Mutex
+	conns map[net.Conn]bool
+}
+
+func newRelay(maxCircuits int) *relay {
+	return &relay{
+		maxCircuits: maxCircuits,
+		mutex:       &sync.Mutex{},
+		circuits:    make(map[string]*circuit),
+	}
+}
+
+// SetRelay makes transport relay requests for up to maxCircuits nodes which are not reachable directly.
+// Such nodes register on relay with RegisterRelay. It must be called before transport is started.
+func SetRelay(transport Transport, maxCircuits int) error {
+	switch t := transport.(type) {
+	case *streamTransport:
+		t.relay = newRelay(maxCircuits)
+	case *muxTransport:
+		for _, st := range t.transports {
+			st.relay = newRelay(maxCircuits)
+		}
+	default:
+		return errors.New("transport does not support relay")
+	}
+
+	return nil
+}
+
+// RegisterRelay opens circuit to relay at given address, requests to sender are forwarded over it
+// by relay then. Sender should advertise relay address in node.Node.Relay. Circuit is reopened
+// if it breaks until transport is stopped. It must be called before transport is stopped.
+func RegisterRelay(transport Transport, sender *node.Node, relayAddress string) error {
+	st, ok := transport.(*streamTransport)
+	if !ok {
+		return errors.New("transport does not support relay")
+	}
+
+	st.mutex.Lock()
+	if st.relayClient == nil {
+		st.relayClient = &relayClient{
+			stop:  make(chan bool),
+			mutex: &sync.Mutex{},
+			conns: make(map[net.Conn]bool),
+		}
+	}
+	st.mutex.Unlock()
+
+	conn, err := st.openCircuit(sender, relayAddress)
+	if err != nil {
+		return err
+	}
+
+	go st.serveCircuit(conn, sender, relayAddress)
+	return nil
+}
+
+// register remembers circuit to address, it fails if relay has no free circuits
+func (r *relay) register(address string, conn net.Conn) (*circuit, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.circuits[address]; !ok && len(r.circuits) >= r.maxCircuits {
+		return nil, false
+	}
+
+	c := &circuit{conn: conn, mutex: &sync.Mutex{}}
+	r.circuits[address] = c
+	return c, true
+}
+
+// unregister forgets circuit to address unless it was replaced by newer one
+func (r *relay) unregister(address string, c *circuit) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.circuits[address] == c {
+		delete(r.circuits, address)
+	}
+}
+
+func (r *relay) circuit(address string) *circuit {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.circuits[address]
+}
+
+func (c *circuit) write(data []byte, timeout time.Duration) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return writeWithDeadline(c.conn, data, timeout)
+}
+
+func (rc *relayClient) add(conn net.Conn) bool {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	select {
+	case <-rc.stop:
+		return false
+	default:
+	}
+	rc.conns[conn] = true
+	return true
+}
+
+// has checks if conn is a circuit opened by transport
+func (rc *relayClient) has(conn net.Conn) bool {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	return rc.conns[conn]
+}
+
+func (rc *relayClient) remove(conn net.Conn) {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	delete(rc.conns, conn)
+}
+
+func (rc *relayClient) close() {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	close(rc.stop)
+	for conn := range rc.conns {
+		conn.Close()
+	}
+}
+
+// openCircuit dials relay and registers sender address on it
+func (t *streamTransport) openCircuit(sender *node.Node, relayAddress string) (net.Conn, error) {
+	address, err := node.NewAddress(relayAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := t.socket.Dial(relayAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.registerCircuit(conn, sender, address)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if !t.relayClient.add(conn) {
+		conn.Close()
+		return nil, errors.New("transport is stopped")
+	}
+	return conn, nil
+}
+
+func (t *streamTransport) registerCircuit(conn net.Conn, sender *node.Node, relayAddress *node.Address) error {
+	request := message.NewBuilder().Sender(sender).Receiver(node.NewNode(relayAddress)).Type(message.TypeRelay).Request(
+		&message.RequestDataRelay{Address: sender.AddressFor(t.network).String()}).Build()
+	request.RequestID = t.generateID()
+
+	response, err := exchange(conn, request)
+	if err != nil {
+		return err
+	}
+
+	data, ok := response.Data.(*message.ResponseDataRelay)
+	if !ok {
+		return errors.New("unexpected relay response")
+	}
+	if !data.Success {
+		return errors.New("relay refused circuit")
+	}
+	return nil
+}
+
+// exchange writes request to relay and reads its response from the same connection
+func exchange(conn net.Conn, request *message.Message) (*message.Message, error) {
+	body, err := message.EncodeMessage(request)
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.SetDeadline(time.Now().Add(relayRegisterTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.Write(message.NewFrame(body, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	body, _, err = message.ReadFrame(conn)
+	if err != nil {
+		return nil, err
+	}
+	response, err := message.DecodeMessage(body)
+	if err != nil {
+		return nil, err
+	}
+	if response.RequestID != request.RequestID || response.Type != request.Type {
+		return nil, errors.New("unexpected relay response")
+	}
+
+	return response, conn.SetDeadline(time.Time{})
+}
+
+// replyFrame returns frame of response to request received by relay
+func replyFrame(request *message.Message, data interface{}) ([]byte, error) {
+	response := message.NewBuilder().Sender(request.Receiver).Receiver(request.Sender).Type(request.Type).Response(data).Build()
+	response.RequestID = request.RequestID
+
+	body, err := message.EncodeMessage(response)
+	if err != nil {
+		return nil, err
+	}
+	return message.NewFrame(body, 0), nil
+}
+
+// serveCircuit receives requests forwarded by relay, circuit is reopened if it breaks
+func (t *streamTransport) serveCircuit(conn net.Conn, sender *node.Node, relayAddress string) {
+	for {
+		t.handleAcceptedConnection(conn)
+		t.relayClient.remove(conn)
+
+		for {
+			select {
+			case <-t.relayClient.stop:
+				return
+			case <-time.After(relayRetryInterval):
+			}
+
+			var err error
+			conn, err = t.openCircuit(sender, relayAddress)
+			if err == nil {
+				break
+			}
+			log.Println("Failed to register on relay:", err.Error())
+		}
+	}
+}
+
+// acceptCircuit registers circuit requested by msg received over conn
+func (t *streamTransport) acceptCircuit(conn net.Conn, msg *message.Message) (string, *circuit) {
+	address := msg.Data.(*message.RequestDataRelay).Address
+	c, ok := t.relay.register(address, conn)
+
+	frame, err := replyFrame(msg, &message.ResponseDataRelay{Success: ok})
+	if err == nil && ok {
+		err = c.write(frame, t.writeTimeout)
+	} else if err == nil {
+		err = writeWithDeadline(conn, frame, t.writeTimeout)
+	}
+	if err != nil {
+		log.Println("Failed to send relay response:", err.Error())
+	}
+
+	if !ok {
+		return "", nil
+	}
+	return address, c
+}
+
+// forward writes frame to circuit of message receiver, it returns false if receiver has no circuit
+func (t *streamTransport) forward(msg *message.Message, frame []byte) bool {
+	address := msg.Receiver.AddressFor(t.network).String()
+	c := t.relay.circuit(address)
+	if c == nil {
+		return false
+	}
+
+	err := c.write(frame, t.writeTimeout)
+	if err != nil {
+		log.Println("Failed to forward message over relay:", err.Error())
+		c.conn.Close()
+		t.relay.unregister(address, c)
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"testing"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetRelay_NotSupported(t *testing.T) {
+	err := SetRelay(nil, 1)
+	assert.EqualError(t, err, "transport does not support relay")
+
+	err = RegisterRelay(nil, &node.Node{}, "127.0.0.1:8132")
+	assert.EqualError(t, err, "transport does not support relay")
+}
+
+func TestRelay_SendRequest(t *testing.T) {
+	relay, relayNode := createTCPTransport(t, "127.0.0.1:8132")
+	hidden, _ := createTCPTransport(t, "127.0.0.1:8133")
+	first, firstNode := createTCPTransport(t, "127.0.0.1:8134")
+
+	err := SetRelay(relay, 1)
+	assert.NoError(t, err)
+
+	done := make(chan bool)
+	for _, tp := range []Transport{relay, hidden, first} {
+		go func(tp Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+	}
+
+	// Nobody listens at advertised address of hidden node, it is reachable over relay only
+	hiddenAddress, _ := node.NewAddress("127.0.0.1:8135")
+	hiddenNode := node.NewNode(hiddenAddress)
+	hiddenNode.ID, _ = node.NewID()
+	hiddenNode.Relay = relayNode.Address
+
+	err = RegisterRelay(hidden, hiddenNode, relayNode.Address.String())
+	assert.NoError(t, err)
+
+	future, err := first.SendRequest(message.NewPingMessage(firstNode, hiddenNode))
+	assert.NoError(t, err)
+
+	request := <-hidden.Messages()
+	assert.Equal(t, firstNode.ID, request.Sender.ID)
+	assert.Equal(t, relayNode.Address.String(), request.RemoteAddress())
+
+	response := message.NewBuilder().Sender(hiddenNode).Receiver(firstNode).Type(message.TypePing).Response(nil).Build()
+	err = hidden.SendResponse(request.RequestID, response)
+	assert.NoError(t, err)
+
+	result := <-future.Result()
+	assert.Equal(t, hiddenNode.ID, result.Sender.ID)
+
+	stopTransport(first, done)
+	stopTransport(hidden, done)
+	stopTransport(relay, done)
+}
+
+func TestRelay_Full(t *testing.T) {
+	relay, relayNode := createTCPTransport(t, "127.0.0.1:8136")
+	hidden, hiddenNode := createTCPTransport(t, "127.0.0.1:8137")
+
+	err := SetRelay(relay, 0)
+	assert.NoError(t, err)
+
+	done := make(chan bool)
+	for _, tp := range []Transport{relay, hidden} {
+		go func(tp Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+	}
+
+	err = RegisterRelay(hidden, hiddenNode, relayNode.Address.String())
+	assert.EqualError(t, err, "relay refused circuit")
+
+	stopTransport(hidden, done)
+	stopTransport(relay, done)
+}
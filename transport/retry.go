@@ -0,0 +1,96 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/insolar/network/message"
+)
+
+// RetryPolicy defines how requests which fail to send or time out are retried
+type RetryPolicy struct {
+	// Attempts is the maximum number of times request is sent. Requests are not retried if it is less than 2
+	Attempts int
+
+	// BaseDelay is delay before the first retry, it is doubled for every next retry
+	BaseDelay time.Duration
+
+	// Jitter is fraction of delay which is randomized to spread retries of many requests, from 0 to 1
+	Jitter float64
+
+	// Timeout is time after which request without response is sent again.
+	// RPC requests are never resent after timeout as remote procedure might be executed twice.
+	// Requests are not resent after timeout if it is not set
+	Timeout time.Duration
+}
+
+// SetRetryPolicy makes transport retry requests according to given policy.
+// It must be called before transport is started.
+func SetRetryPolicy(transport Transport, policy RetryPolicy) error {
+	switch t := transport.(type) {
+	case *streamTransport:
+		t.retry = policy
+	case *muxTransport:
+		for _, st := range t.transports {
+			st.retry = policy
+		}
+	default:
+		return errors.New("transport does not support retries")
+	}
+
+	return nil
+}
+
+// delay returns delay before given retry, the first retry is 1
+func (p RetryPolicy) delay(retry int) time.Duration {
+	delay := p.BaseDelay << uint(retry-1)
+	if p.Jitter > 0 {
+		delay -= time.Duration(p.Jitter * rand.Float64() * float64(delay))
+	}
+	return delay
+}
+
+// sendWithRetry sends message retrying failed attempts, it returns error of the last attempt
+func (t *streamTransport) sendWithRetry(msg *message.Message) error {
+	err := t.sendMessage(msg)
+	for retry := 1; err != nil && retry < t.retry.Attempts; retry++ {
+		time.Sleep(t.retry.delay(retry))
+		err = t.sendMessage(msg)
+	}
+	return err
+}
+
+// resendOnTimeout sends request again while its future is waiting for response and attempts are left.
+// Request ID is kept, so response to any of attempts resolves the future.
+func (t *streamTransport) resendOnTimeout(msg *message.Message) {
+	for attempt := 1; attempt < t.retry.Attempts; attempt++ {
+		time.Sleep(t.retry.Timeout + t.retry.delay(attempt))
+
+		if t.getFuture(msg) == nil {
+			// Response came or request was cancelled
+			return
+		}
+
+		err := t.sendMessage(msg)
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Millisecond * 10}
+	assert.Equal(t, time.Millisecond*10, policy.delay(1))
+	assert.Equal(t, time.Millisecond*20, policy.delay(2))
+	assert.Equal(t, time.Millisecond*40, policy.delay(3))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := policy.delay(2)
+		assert.True(t, delay > time.Millisecond*10 && delay <= time.Millisecond*20)
+	}
+}
+
+func TestSetRetryPolicy(t *testing.T) {
+	err := SetRetryPolicy(nil, RetryPolicy{Attempts: 3})
+	assert.EqualError(t, err, "transport does not support retries")
+}
+
+func TestStreamTransport_RetrySend(t *testing.T) {
+	network := NewInMemoryNetwork(0, 0)
+	first, firstNode := createInMemoryTransport(t, network, "127.0.0.1:31337")
+	err := SetRetryPolicy(first, RetryPolicy{Attempts: 5, BaseDelay: time.Millisecond * 50})
+	assert.NoError(t, err)
+
+	// Receiver appears after the first attempt failed
+	var second Transport
+	created := make(chan bool)
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		second, _ = createInMemoryTransport(t, network, "127.0.0.2:31338")
+		close(created)
+	}()
+	addr, _ := node.NewAddress("127.0.0.2:31338")
+	secondNode := node.NewNode(addr)
+
+	_, err = first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+	assert.True(t, first.Stats().SendFailures > 0)
+
+	<-created
+	done := startTransports(first, second)
+	request := <-second.Messages()
+	assert.Equal(t, firstNode.ID, request.Sender.ID)
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestStreamTransport_ResendOnTimeout(t *testing.T) {
+	network := NewInMemoryNetwork(0, 0)
+	first, firstNode := createInMemoryTransport(t, network, "127.0.0.1:31337")
+	second, secondNode := createInMemoryTransport(t, network, "127.0.0.2:31338")
+	err := SetRetryPolicy(first, RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond * 10, Timeout: time.Millisecond * 50})
+	assert.NoError(t, err)
+	done := startTransports(first, second)
+
+	future, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+
+	// The first attempt is lost, response is sent to the second one
+	lost := <-second.Messages()
+	request := <-second.Messages()
+	assert.Equal(t, lost.RequestID, request.RequestID)
+
+	response := message.NewBuilder().Sender(secondNode).Receiver(firstNode).Type(message.TypePing).Response(nil).Build()
+	err = second.SendResponse(request.RequestID, response)
+	assert.NoError(t, err)
+
+	result := <-future.Result()
+	assert.Equal(t, secondNode.ID, result.Sender.ID)
+
+	// No more attempts after response
+	select {
+	case <-second.Messages():
+		t.Fatal("request must not be resent after response")
+	case <-time.After(time.Millisecond * 150):
+	}
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestStreamTransport_ResendOnTimeout_RPC(t *testing.T) {
+	network := NewInMemoryNetwork(0, 0)
+	first, firstNode := createInMemoryTransport(t, network, "127.0.0.1:31337")
+	second, secondNode := createInMemoryTransport(t, network, "127.0.0.2:31338")
+	err := SetRetryPolicy(first, RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond * 10, Timeout: time.Millisecond * 20})
+	assert.NoError(t, err)
+	done := startTransports(first, second)
+
+	request := message.NewBuilder().Sender(firstNode).Receiver(secondNode).Type(message.TypeRPC).Request(
+		&message.RequestDataRPC{Method: "test"}).Build()
+	future, err := first.SendRequest(request)
+	assert.NoError(t, err)
+	<-second.Messages()
+
+	select {
+	case <-second.Messages():
+		t.Fatal("RPC request must not be resent")
+	case <-time.After(time.Millisecond * 150):
+	}
+	future.Cancel()
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
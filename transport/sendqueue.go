@@ -0,0 +1,117 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrQueueFull is returned when send queue of receiver is full
+var ErrQueueFull = errors.New("send queue of peer is full")
+
+// queueSaturationDrops is number of messages dropped in a row after which peer's queue is reported saturated
+const queueSaturationDrops = 16
+
+// sendQueues bounds number of messages being sent to every peer,
+// so one unresponsive peer can't hold up all senders
+type sendQueues struct {
+	mutex       *sync.Mutex
+	size        int
+	onSaturated func(address string)
+	peers       map[string]*peerQueue
+}
+
+// peerQueue is a state of queue of one peer
+type peerQueue struct {
+	length   int
+	drops    int
+	reported bool
+}
+
+func newSendQueues(size int, onSaturated func(address string)) *sendQueues {
+	return &sendQueues{
+		mutex:       &sync.Mutex{},
+		size:        size,
+		onSaturated: onSaturated,
+		peers:       make(map[string]*peerQueue),
+	}
+}
+
+// SetSendQueue limits number of messages being sent to every peer at once to size, further messages
+// are dropped with ErrQueueFull and counted in Stats.QueueDrops. Peer which queue stays full is reported
+// to onSaturated with its address, once until its queue drains. It must be called before transport is started.
+func SetSendQueue(transport Transport, size int, onSaturated func(address string)) error {
+	if size <= 0 {
+		return errors.New("size of send queue must be positive")
+	}
+
+	switch t := transport.(type) {
+	case *streamTransport:
+		t.queues = newSendQueues(size, onSaturated)
+	case *muxTransport:
+		for _, st := range t.transports {
+			st.queues = newSendQueues(size, onSaturated)
+		}
+	default:
+		return errors.New("transport does not support send queues")
+	}
+
+	return nil
+}
+
+// enter takes place in queue of address, it returns false if queue is full
+func (sq *sendQueues) enter(address string) bool {
+	sq.mutex.Lock()
+	defer sq.mutex.Unlock()
+
+	q, ok := sq.peers[address]
+	if !ok {
+		q = &peerQueue{}
+		sq.peers[address] = q
+	}
+
+	if q.length >= sq.size {
+		q.drops++
+		if q.drops >= queueSaturationDrops && !q.reported {
+			q.reported = true
+			if sq.onSaturated != nil {
+				go sq.onSaturated(address)
+			}
+		}
+		return false
+	}
+
+	q.length++
+	q.drops = 0
+	return true
+}
+
+// leave frees place in queue of address, drained queues are forgotten
+func (sq *sendQueues) leave(address string) {
+	sq.mutex.Lock()
+	defer sq.mutex.Unlock()
+
+	q, ok := sq.peers[address]
+	if !ok {
+		return
+	}
+	q.length--
+	if q.length <= 0 {
+		delete(sq.peers, address)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendQueues(t *testing.T) {
+	saturated := make(chan string, 1)
+	queues := newSendQueues(2, func(address string) {
+		saturated <- address
+	})
+
+	assert.True(t, queues.enter("foo"))
+	assert.True(t, queues.enter("foo"))
+	assert.False(t, queues.enter("foo"))
+	// Queues are per peer
+	assert.True(t, queues.enter("bar"))
+
+	for i := 1; i < queueSaturationDrops; i++ {
+		assert.False(t, queues.enter("foo"))
+	}
+	select {
+	case address := <-saturated:
+		assert.Equal(t, "foo", address)
+	case <-time.After(time.Second):
+		assert.Fail(t, "saturated queue is not reported")
+	}
+
+	// Peer is reported once until its queue drains
+	assert.False(t, queues.enter("foo"))
+	queues.leave("foo")
+	queues.leave("foo")
+	queues.leave("bar")
+	assert.Empty(t, queues.peers)
+	assert.Empty(t, saturated)
+}
+
+func TestSetSendQueue(t *testing.T) {
+	network := NewInMemoryNetwork(0, 0)
+	first, firstNode := createInMemoryTransport(t, network, "127.0.0.1:31337")
+	second, secondNode := createInMemoryTransport(t, network, "127.0.0.2:31338")
+
+	err := SetSendQueue(first, 1, nil)
+	assert.NoError(t, err)
+	done := startTransports(first, second)
+
+	// Message to second node is being sent already
+	address := secondNode.Address.String()
+	first.(*streamTransport).queues.enter(address)
+
+	_, err = first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.Equal(t, ErrQueueFull, err)
+	assert.Equal(t, map[string]int{address: 1}, first.Stats().QueueDrops)
+
+	first.(*streamTransport).queues.leave(address)
+	future, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+	future.Cancel()
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestSetSendQueue_Invalid(t *testing.T) {
+	network := NewInMemoryNetwork(0, 0)
+	tp, _ := createInMemoryTransport(t, network, "127.0.0.1:31337")
+
+	err := SetSendQueue(tp, 0, nil)
+	assert.EqualError(t, err, "size of send queue must be positive")
+
+	err = SetSendQueue(nil, 1, nil)
+	assert.EqualError(t, err, "transport does not support send queues")
+}
@@ -0,0 +1,256 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+const socks5HandshakeTimeout = time.Second * 5
+
+const (
+	socks5Version      = 5
+	socks5AuthNone     = 0
+	socks5AuthPassword = 2
+	socks5AuthNoMethod = 0xff
+	socks5Connect      = 1
+	socks5AddrIPv4     = 1
+	socks5AddrDomain   = 3
+	socks5AddrIPv6     = 4
+)
+
+var socks5Errors = map[byte]string{
+	1: "general SOCKS server failure",
+	2: "connection not allowed by ruleset",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
+
+// SOCKS5Config configures SOCKS5 proxy used for outbound connections
+type SOCKS5Config struct {
+	// Address of proxy, e.g. 127.0.0.1:9050 for Tor
+	Address string
+
+	// Username and Password are used if Username is not empty
+	Username string
+	Password string
+}
+
+type socks5Socket struct {
+	socket socket
+	config *SOCKS5Config
+}
+
+// NewSOCKS5Transport makes given TCP transport dial peers through SOCKS5 proxy.
+// Incoming connections are accepted as usual. It must be called before transport is started
+// and before transport is wrapped in TLS or Noise.
+func NewSOCKS5Transport(transport Transport, config *SOCKS5Config) (Transport, error) {
+	st, ok := transport.(*streamTransport)
+	if !ok {
+		return nil, errors.New("transport does not support SOCKS5")
+	}
+	if _, ok := st.socket.(*tcpSocket); !ok {
+		return nil, errors.New("transport does not support SOCKS5")
+	}
+
+	st.socket = &socks5Socket{
+		socket: st.socket,
+		config: config,
+	}
+
+	return st, nil
+}
+
+// Accept waits for the next incoming connection
+func (s *socks5Socket) Accept() (net.Conn, error) {
+	return s.socket.Accept()
+}
+
+// Dial connects to given address through proxy
+func (s *socks5Socket) Dial(address string) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", s.config.Address, time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.SetDeadline(time.Now().Add(socks5HandshakeTimeout))
+	if err == nil {
+		err = s.handshake(conn, address)
+	}
+	if err == nil {
+		err = conn.SetDeadline(time.Time{})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// Addr returns underlying socket address
+func (s *socks5Socket) Addr() net.Addr {
+	return s.socket.Addr()
+}
+
+// Close closes underlying socket
+func (s *socks5Socket) Close() error {
+	return s.socket.Close()
+}
+
+func (s *socks5Socket) handshake(conn net.Conn, address string) error {
+	err := s.authenticate(conn)
+	if err != nil {
+		return err
+	}
+
+	request, err := socks5ConnectRequest(address)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(request)
+	if err != nil {
+		return err
+	}
+
+	// Reply is version, status, reserved byte and bound address which is not used
+	reply := make([]byte, 4)
+	_, err = io.ReadFull(conn, reply)
+	if err != nil {
+		return err
+	}
+	if reply[0] != socks5Version {
+		return errors.New("invalid SOCKS5 reply")
+	}
+	if reply[1] != 0 {
+		if message, ok := socks5Errors[reply[1]]; ok {
+			return errors.New(message)
+		}
+		return errors.New("unknown SOCKS5 error")
+	}
+
+	var length int
+	switch reply[3] {
+	case socks5AddrIPv4:
+		length = net.IPv4len
+	case socks5AddrIPv6:
+		length = net.IPv6len
+	case socks5AddrDomain:
+		size := make([]byte, 1)
+		_, err = io.ReadFull(conn, size)
+		if err != nil {
+			return err
+		}
+		length = int(size[0])
+	default:
+		return errors.New("invalid SOCKS5 reply")
+	}
+
+	_, err = io.ReadFull(conn, make([]byte, length+2))
+	return err
+}
+
+func (s *socks5Socket) authenticate(conn net.Conn) error {
+	method := byte(socks5AuthNone)
+	if s.config.Username != "" {
+		method = socks5AuthPassword
+	}
+
+	_, err := conn.Write([]byte{socks5Version, 1, method})
+	if err != nil {
+		return err
+	}
+
+	reply := make([]byte, 2)
+	_, err = io.ReadFull(conn, reply)
+	if err != nil {
+		return err
+	}
+	if reply[0] != socks5Version {
+		return errors.New("invalid SOCKS5 reply")
+	}
+	if reply[1] == socks5AuthNoMethod || reply[1] != method {
+		return errors.New("no acceptable SOCKS5 authentication methods")
+	}
+
+	if method != socks5AuthPassword {
+		return nil
+	}
+
+	if len(s.config.Username) > 255 || len(s.config.Password) > 255 {
+		return errors.New("SOCKS5 credentials are too long")
+	}
+	request := []byte{1, byte(len(s.config.Username))}
+	request = append(request, s.config.Username...)
+	request = append(request, byte(len(s.config.Password)))
+	request = append(request, s.config.Password...)
+	_, err = conn.Write(request)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.ReadFull(conn, reply)
+	if err != nil {
+		return err
+	}
+	if reply[1] != 0 {
+		return errors.New("SOCKS5 authentication failed")
+	}
+
+	return nil
+}
+
+func socks5ConnectRequest(address string) ([]byte, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	request := []byte{socks5Version, socks5Connect, 0}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			request = append(request, socks5AddrIPv4)
+			request = append(request, ip4...)
+		} else {
+			request = append(request, socks5AddrIPv6)
+			request = append(request, ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return nil, errors.New("host name is too long")
+		}
+		request = append(request, socks5AddrDomain, byte(len(host)))
+		request = append(request, host...)
+	}
+
+	portBytes := make([]byte, 2)
+	binary.BigEndian.PutUint16(portBytes, uint16(port))
+	return append(request, portBytes...), nil
+}
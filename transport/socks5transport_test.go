@@ -0,0 +1,170 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startSOCKS5Proxy starts minimal SOCKS5 proxy supporting CONNECT to IPv4 addresses
+func startSOCKS5Proxy(t *testing.T, username, password string) (net.Listener, chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	targets := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSOCKS5(conn, username, password, targets)
+		}
+	}()
+
+	return listener, targets
+}
+
+func serveSOCKS5(conn net.Conn, username, password string, targets chan string) {
+	defer conn.Close()
+
+	greeting := make([]byte, 3)
+	if _, err := io.ReadFull(conn, greeting); err != nil {
+		return
+	}
+	if username == "" {
+		conn.Write([]byte{socks5Version, socks5AuthNone})
+	} else {
+		conn.Write([]byte{socks5Version, socks5AuthPassword})
+		header := make([]byte, 2)
+		io.ReadFull(conn, header)
+		user := make([]byte, header[1])
+		io.ReadFull(conn, user)
+		io.ReadFull(conn, header[:1])
+		pass := make([]byte, header[0])
+		io.ReadFull(conn, pass)
+		if string(user) != username || string(pass) != password {
+			conn.Write([]byte{1, 1})
+			return
+		}
+		conn.Write([]byte{1, 0})
+	}
+
+	request := make([]byte, 10)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return
+	}
+	address := net.JoinHostPort(net.IP(request[4:8]).String(), strconv.Itoa(int(binary.BigEndian.Uint16(request[8:]))))
+	targets <- address
+
+	target, err := net.Dial("tcp", address)
+	if err != nil {
+		conn.Write([]byte{socks5Version, 5, 0, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	conn.Write([]byte{socks5Version, 0, 0, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
+
+func TestNewSOCKS5Transport_NotSupported(t *testing.T) {
+	_, err := NewSOCKS5Transport(nil, &SOCKS5Config{})
+	assert.EqualError(t, err, "transport does not support SOCKS5")
+
+	tp, err := NewInMemoryTransport(NewInMemoryNetwork(0, 0), "127.0.0.1:31337")
+	assert.NoError(t, err)
+	_, err = NewSOCKS5Transport(tp, &SOCKS5Config{})
+	assert.EqualError(t, err, "transport does not support SOCKS5")
+}
+
+func TestSOCKS5Transport_SendRequest(t *testing.T) {
+	proxy, targets := startSOCKS5Proxy(t, "user", "secret")
+	defer proxy.Close()
+
+	first, firstNode := createTCPTransport(t, "127.0.0.1:8099")
+	second, secondNode := createTCPTransport(t, "127.0.0.1:8100")
+
+	first, err := NewSOCKS5Transport(first, &SOCKS5Config{Address: proxy.Addr().String(), Username: "user", Password: "secret"})
+	assert.NoError(t, err)
+
+	done := startTransports(first, second)
+
+	future, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:8100", <-targets)
+
+	request := <-second.Messages()
+	assert.Equal(t, firstNode.ID, request.Sender.ID)
+
+	response := message.NewBuilder().Sender(secondNode).Receiver(firstNode).Type(message.TypePing).Response(nil).Build()
+	err = second.SendResponse(request.RequestID, response)
+	assert.NoError(t, err)
+
+	result := <-future.Result()
+	assert.Equal(t, secondNode.ID, result.Sender.ID)
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestSOCKS5Transport_SendRequest_Errors(t *testing.T) {
+	proxy, _ := startSOCKS5Proxy(t, "user", "secret")
+	defer proxy.Close()
+
+	first, firstNode := createTCPTransport(t, "127.0.0.1:8101")
+	first, err := NewSOCKS5Transport(first, &SOCKS5Config{Address: proxy.Addr().String(), Username: "user", Password: "wrong"})
+	assert.NoError(t, err)
+
+	// Nothing listens on receiver's address
+	receiverAddress, _ := node.NewAddress("127.0.0.1:8102")
+	receiver := node.NewNode(receiverAddress)
+
+	_, err = first.SendRequest(message.NewPingMessage(firstNode, receiver))
+	assert.EqualError(t, err, "SOCKS5 authentication failed")
+
+	first.(*streamTransport).socket.(*socks5Socket).config.Password = "secret"
+	_, err = first.SendRequest(message.NewPingMessage(firstNode, receiver))
+	assert.EqualError(t, err, "connection refused")
+	assert.Empty(t, first.PendingRequests())
+
+	done := startTransports(first)
+	stopTransport(first, done)
+}
+
+func TestSOCKS5ConnectRequest(t *testing.T) {
+	request, err := socks5ConnectRequest("127.0.0.1:80")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{5, 1, 0, 1, 127, 0, 0, 1, 0, 80}, request)
+
+	request, err = socks5ConnectRequest("example.onion:443")
+	assert.NoError(t, err)
+	assert.Equal(t, append(append([]byte{5, 1, 0, 3, 13}, "example.onion"...), 1, 187), request)
+
+	_, err = socks5ConnectRequest("127.0.0.1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,133 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"sync"
+
+	"github.com/insolar/network/message"
+)
+
+// Stats contains counters of transport I/O since transport was created
+type Stats struct {
+	// Sent and Received count messages by message type name
+	Sent     map[string]int
+	Received map[string]int
+
+	BytesIn  int
+	BytesOut int
+
+	// SendFailures counts messages which could not be delivered to connection
+	SendFailures int
+	// Cancelled counts requests cancelled before response came, timed out requests are not included
+	Cancelled int
+	// Timeouts counts requests which did not get response in time
+	Timeouts int
+	// QueueDrops counts messages dropped because send queue of peer was full, by peer address
+	QueueDrops map[string]int
+}
+
+func newStats() Stats {
+	return Stats{
+		Sent:       make(map[string]int),
+		Received:   make(map[string]int),
+		QueueDrops: make(map[string]int),
+	}
+}
+
+// add adds counters of other Stats
+func (s *Stats) add(other Stats) {
+	for name, count := range other.Sent {
+		s.Sent[name] += count
+	}
+	for name, count := range other.Received {
+		s.Received[name] += count
+	}
+	for address, count := range other.QueueDrops {
+		s.QueueDrops[address] += count
+	}
+	s.BytesIn += other.BytesIn
+	s.BytesOut += other.BytesOut
+	s.SendFailures += other.SendFailures
+	s.Cancelled += other.Cancelled
+	s.Timeouts += other.Timeouts
+}
+
+// transportStats collects Stats of a transport
+type transportStats struct {
+	mutex *sync.Mutex
+	stats Stats
+}
+
+func newTransportStats() *transportStats {
+	return &transportStats{
+		mutex: &sync.Mutex{},
+		stats: newStats(),
+	}
+}
+
+func (ts *transportStats) sent(msg *message.Message, bytes int) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.stats.Sent[msg.Type.String()]++
+	ts.stats.BytesOut += bytes
+}
+
+func (ts *transportStats) received(msg *message.Message, bytes int) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.stats.Received[msg.Type.String()]++
+	ts.stats.BytesIn += bytes
+}
+
+func (ts *transportStats) sendFailed() {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.stats.SendFailures++
+}
+
+func (ts *transportStats) queueDropped(address string) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.stats.QueueDrops[address]++
+}
+
+// cancelled counts pending request future which was cancelled or timed out
+func (ts *transportStats) cancelled(future Future) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if future.TimedOut() {
+		ts.stats.Timeouts++
+	} else {
+		ts.stats.Cancelled++
+	}
+}
+
+// snapshot returns copy of collected Stats
+func (ts *transportStats) snapshot() Stats {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	stats := newStats()
+	stats.add(ts.stats)
+	return stats
+}
@@ -0,0 +1,89 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"testing"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats_Add(t *testing.T) {
+	stats := newStats()
+	stats.add(Stats{Sent: map[string]int{"ping": 1}, BytesOut: 10, Timeouts: 1})
+	stats.add(Stats{Sent: map[string]int{"ping": 2, "store": 1}, Received: map[string]int{"ping": 1}, BytesIn: 5})
+
+	assert.Equal(t, map[string]int{"ping": 3, "store": 1}, stats.Sent)
+	assert.Equal(t, map[string]int{"ping": 1}, stats.Received)
+	assert.Equal(t, 10, stats.BytesOut)
+	assert.Equal(t, 5, stats.BytesIn)
+	assert.Equal(t, 1, stats.Timeouts)
+}
+
+func TestStreamTransport_Stats(t *testing.T) {
+	first, firstNode := createTCPTransport(t, "127.0.0.1:8112")
+	second, secondNode := createTCPTransport(t, "127.0.0.1:8113")
+
+	done := make(chan bool)
+	for _, tp := range []Transport{first, second} {
+		go func(tp Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+	}
+
+	future, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+
+	request := <-second.Messages()
+	response := message.NewBuilder().Sender(secondNode).Receiver(firstNode).Type(message.TypePing).Response(nil).Build()
+	err = second.SendResponse(request.RequestID, response)
+	assert.NoError(t, err)
+	<-future.Result()
+
+	cancelled, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+	<-second.Messages()
+	cancelled.Cancel()
+
+	timedOut, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+	<-second.Messages()
+	timedOut.Timeout()
+
+	addr, _ := node.NewAddress("127.0.0.1:8114")
+	_, err = first.SendRequest(message.NewPingMessage(firstNode, node.NewNode(addr)))
+	assert.Error(t, err)
+
+	firstStats := first.Stats()
+	secondStats := second.Stats()
+	assert.Equal(t, map[string]int{"ping": 3}, firstStats.Sent)
+	assert.Equal(t, map[string]int{"ping": 1}, firstStats.Received)
+	assert.Equal(t, map[string]int{"ping": 1}, secondStats.Sent)
+	assert.Equal(t, map[string]int{"ping": 3}, secondStats.Received)
+	assert.Equal(t, firstStats.BytesOut, secondStats.BytesIn)
+	assert.Equal(t, secondStats.BytesOut, firstStats.BytesIn)
+	assert.Equal(t, 1, firstStats.SendFailures)
+	assert.Equal(t, 1, firstStats.Cancelled)
+	assert.Equal(t, 1, firstStats.Timeouts)
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
@@ -0,0 +1,546 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+)
+
+// socket is a stream-oriented network endpoint used by streamTransport
+type socket interface {
+	Accept() (net.Conn, error)
+	Dial(address string) (net.Conn, error)
+	Close() error
+	Addr() net.Addr
+}
+
+// nodeDialer is implemented by sockets which are able to verify identity of node they connect to
+type nodeDialer interface {
+	DialNode(address string, id node.ID) (net.Conn, error)
+}
+
+// authenticatedConn is implemented by connections which authenticate node on the other side
+type authenticatedConn interface {
+	// PeerID returns identity of remote node, it is nil if connection does not authenticate peers
+	PeerID() node.ID
+}
+
+// peerID returns identity of node conn is authenticated with, nil if it is not known
+func peerID(conn net.Conn) node.ID {
+	if ac, ok := conn.(authenticatedConn); ok {
+		return ac.PeerID()
+	}
+	return nil
+}
+
+// dialNode connects to node with given id listening on address,
+// identity is verified if socket supports it
+func dialNode(s socket, address string, id node.ID) (net.Conn, error) {
+	if dialer, ok := s.(nodeDialer); ok {
+		return dialer.DialNode(address, id)
+	}
+	return s.Dial(address)
+}
+
+// streamTransport is a Transport sending messages over pooled stream connections
+type streamTransport struct {
+	socket socket
+	// network is a name of transport used to select receiver's address
+	network string
+
+	received chan *message.Message
+	sequence *uint64
+
+	// receiving is held by handlers passing requests to received, so that it is closed
+	// only after they are unblocked by closed
+	receiving *sync.RWMutex
+	closed    chan bool
+
+	disconnectStarted  chan bool
+	disconnectFinished chan bool
+
+	mutex   *sync.RWMutex
+	futures map[message.RequestID]Future
+
+	pool        *connectionPool
+	limiter     *rateLimiter
+	compression *compression
+	codecs      *codecs
+	stats       *transportStats
+	faults      *faults
+	pending     *pendingLimit
+	queues      *sendQueues
+	coalescer   *coalescer
+
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	retry        RetryPolicy
+	keepalive    *keepalive
+	relay        *relay
+	relayClient  *relayClient
+	holePunching bool
+}
+
+func newStreamTransport(socket socket) *streamTransport {
+	return &streamTransport{
+		socket: socket,
+
+		received: make(chan *message.Message),
+		sequence: new(uint64),
+
+		receiving: &sync.RWMutex{},
+		closed:    make(chan bool),
+
+		disconnectStarted:  make(chan bool),
+		disconnectFinished: make(chan bool),
+
+		mutex:   &sync.RWMutex{},
+		futures: make(map[message.RequestID]Future),
+
+		pool:   newConnectionPool(defaultPoolIdleTimeout, defaultPoolMaxPerPeer),
+		codecs: newCodecs(),
+		stats:  newTransportStats(),
+		faults: &faults{},
+	}
+}
+
+// SendRequest sends request message and returns future
+func (t *streamTransport) SendRequest(msg *message.Message) (Future, error) {
+	if t.pending != nil {
+		err := t.pending.acquire()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	msg.RequestID = t.generateID()
+
+	future := t.createFuture(msg)
+	if t.pending != nil && t.pending.expiry > 0 {
+		time.AfterFunc(t.pending.expiry, func() {
+			t.expireFuture(future)
+		})
+	}
+
+	err := t.sendWithRetry(msg)
+	if err != nil {
+		// Failed request is counted as send failure only
+		t.removeFuture(future)
+		future.Cancel()
+		return nil, err
+	}
+
+	if t.retry.Attempts > 1 && t.retry.Timeout > 0 && msg.Type != message.TypeRPC {
+		go t.resendOnTimeout(msg)
+	}
+
+	return future, nil
+}
+
+// SendResponse sends response message
+func (t *streamTransport) SendResponse(requestID message.RequestID, msg *message.Message) error {
+	msg.RequestID = requestID
+
+	return t.sendMessage(msg)
+}
+
+// Start starts networking
+func (t *streamTransport) Start() error {
+	if t.keepalive != nil {
+		go t.probePeers()
+	}
+
+	for {
+		conn, err := t.socket.Accept()
+
+		if err != nil {
+			<-t.disconnectFinished
+			return err
+		}
+
+		go t.handleAcceptedConnection(conn)
+	}
+}
+
+// Stop stops networking
+func (t *streamTransport) Stop() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.disconnectStarted <- true
+	close(t.disconnectStarted)
+
+	if t.keepalive != nil {
+		close(t.keepalive.stop)
+	}
+	if t.relayClient != nil {
+		t.relayClient.close()
+	}
+
+	err := t.socket.Close()
+	if err != nil {
+		log.Println("Failed to close socket:", err.Error())
+	}
+
+	t.pool.closeAll()
+}
+
+// Close closes message channels, requests which are still being read are dropped
+func (t *streamTransport) Close() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	close(t.closed)
+	t.receiving.Lock()
+	close(t.received)
+	t.receiving.Unlock()
+
+	close(t.disconnectFinished)
+}
+
+// Messages returns incoming messages channel
+func (t *streamTransport) Messages() chan *message.Message {
+	return t.received
+}
+
+// Stopped checks if networking is stopped already
+func (t *streamTransport) Stopped() chan bool {
+	return t.disconnectStarted
+}
+
+// PendingRequests returns futures of sent requests which are still waiting for response
+func (t *streamTransport) PendingRequests() []Future {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	futures := make([]Future, 0, len(t.futures))
+	for _, f := range t.futures {
+		futures = append(futures, f)
+	}
+
+	return futures
+}
+
+// LocalAddr returns address transport is actually bound to
+func (t *streamTransport) LocalAddr() net.Addr {
+	return t.socket.Addr()
+}
+
+// Stats returns I/O counters of transport
+func (t *streamTransport) Stats() Stats {
+	return t.stats.snapshot()
+}
+
+func (t *streamTransport) generateID() message.RequestID {
+	id := AtomicLoadAndIncrementUint64(t.sequence)
+	return message.RequestID(id)
+}
+
+func (t *streamTransport) createFuture(msg *message.Message) Future {
+	newFuture := NewFuture(msg.RequestID, msg.Receiver, msg, func(f Future) {
+		// Future is still pending only if it is cancelled before response came
+		if t.removeFuture(f) {
+			t.stats.cancelled(f)
+		}
+	})
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.futures[msg.RequestID] = newFuture
+
+	return newFuture
+}
+
+func (t *streamTransport) getFuture(msg *message.Message) Future {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.futures[msg.RequestID]
+}
+
+// expireFuture times out future which is still waiting for response
+func (t *streamTransport) expireFuture(f Future) {
+	if t.getFuture(f.Request()) == f {
+		f.Timeout()
+	}
+}
+
+// removeFuture removes future from pending ones, returns false if it was removed already
+func (t *streamTransport) removeFuture(f Future) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	_, ok := t.futures[f.ID()]
+	delete(t.futures, f.ID())
+	if ok && t.pending != nil {
+		t.pending.release()
+	}
+	return ok
+}
+
+func (t *streamTransport) sendMessage(msg *message.Message) error {
+	if t.queues != nil {
+		address := msg.Receiver.AddressFor(t.network).String()
+		if !t.queues.enter(address) {
+			t.stats.queueDropped(address)
+			return ErrQueueFull
+		}
+		defer t.queues.leave(address)
+	}
+
+	err := t.writeMessage(msg)
+	if err != nil {
+		t.stats.sendFailed()
+	}
+	return err
+}
+
+func (t *streamTransport) writeMessage(msg *message.Message) error {
+	// Frame is encoded into pooled buffer, it is released once frame is written
+	buffer := message.GetBuffer()
+	defer message.PutBuffer(buffer)
+
+	address := msg.Receiver.AddressFor(t.network).String()
+
+	codec := t.codecs.codecFor(address)
+	err := message.EncodeFrameWith(buffer, msg, codec)
+	if err != nil {
+		return err
+	}
+	data := buffer.Bytes()
+
+	flags := message.FrameFlags(0).WithCodecs(codec.ID(), t.codecs.preferred)
+	if t.compression != nil {
+		body, compressionFlags := t.compression.compress(address, message.FrameBody(data))
+		flags |= compressionFlags
+		if flags&message.FlagCompressed != 0 {
+			data = message.NewFrame(body, flags)
+		}
+	}
+	message.SetFrameFlags(data, flags)
+
+	if t.limiter != nil {
+		t.limiter.wait(address, len(data))
+	}
+
+	if t.coalescer != nil && t.coalescer.fits(len(data)) {
+		return t.coalescer.write(address, msg, data, t.writeFrames)
+	}
+	return t.writeFrames(address, []*message.Message{msg}, []int{len(data)}, data)
+}
+
+// writeFrames writes frames of messages sent to address in a single write
+func (t *streamTransport) writeFrames(address string, msgs []*message.Message, sizes []int, data []byte) error {
+	receiver := msgs[0].Receiver
+
+	// Pooled connection might be closed by remote side already, fresh one is dialed then
+	for _, key := range t.dialKeys(receiver, address) {
+		if conn := t.pool.get(key); conn != nil {
+			err := writeWithDeadline(conn, data, t.writeTimeout)
+			if err == nil {
+				t.pool.put(key, conn)
+				t.sent(msgs, key, sizes)
+				return nil
+			}
+			conn.Close()
+		}
+	}
+
+	conn, dialAddress, err := t.dial(msgs[0], address)
+	if err != nil {
+		t.faults.dialFailed(receiver, dialAddress, err)
+		return err
+	}
+
+	err = writeWithDeadline(conn, data, t.writeTimeout)
+	if err != nil {
+		conn.Close()
+		t.faults.report(FaultWrite, receiver, dialAddress, err)
+		return err
+	}
+
+	t.pool.put(dialAddress, conn)
+	t.sent(msgs, dialAddress, sizes)
+	return nil
+}
+
+func (t *streamTransport) sent(msgs []*message.Message, address string, sizes []int) {
+	for i, msg := range msgs {
+		t.stats.sent(msg, sizes[i])
+	}
+	if t.keepalive != nil {
+		t.keepalive.touch(address)
+	}
+}
+
+func (t *streamTransport) handleAcceptedConnection(conn net.Conn) {
+	defer conn.Close()
+
+	// Accepted connection becomes a circuit if node behind NAT registers over it
+	var circuitAddress string
+	var registered *circuit
+	defer func() {
+		if registered != nil {
+			t.relay.unregister(circuitAddress, registered)
+		}
+	}()
+
+	// Frames are read into the same pooled buffer and decoded from it without copying
+	buffer := message.GetBuffer()
+	defer message.PutBuffer(buffer)
+
+	reader := newFrameReader(conn, t.readTimeout)
+	for {
+		err := reader.next()
+		if err != nil {
+			t.faults.readFailed(conn, err)
+			return
+		}
+
+		// Wait for Messages
+		body, flags, err := message.ReadFrameTo(reader, buffer)
+		if err != nil {
+			// TODO should we penalize this Node somehow ? Ban it ?
+			t.faults.readFailed(conn, err)
+			return
+		}
+
+		if flags&message.FlagKeepalive != 0 {
+			continue
+		}
+
+		size := message.FrameHeaderSize + len(body)
+		frame := body
+
+		body, err = decompress(body, flags)
+		if err != nil {
+			log.Println("Failed to decompress message:", err.Error())
+			t.faults.readFailed(conn, err)
+			return
+		}
+
+		msg, err := message.DecodeFrame(body, flags)
+		if err != nil {
+			t.faults.readFailed(conn, err)
+			return
+		}
+
+		if !t.sentByPeer(conn, msg) {
+			log.Println("Dropped message from", conn.RemoteAddr().String(), ": sender is not authenticated peer")
+			continue
+		}
+
+		if t.relay != nil && msg.Type == message.TypeRelay && !msg.IsResponse && msg.IsValid() {
+			circuitAddress, registered = t.acceptCircuit(conn, msg)
+			continue
+		}
+		if msg.Type == message.TypePunch && !msg.IsResponse && msg.IsValid() {
+			switch {
+			case t.relayClient != nil && t.relayClient.has(conn):
+				// Only relays we registered on may introduce peers
+				go t.punchBack(msg)
+			case t.relay != nil:
+				t.introduce(conn, msg)
+			}
+			continue
+		}
+		if t.relay != nil && msg.Receiver != nil && t.forward(msg, message.NewFrame(frame, flags)) {
+			continue
+		}
+
+		if t.compression != nil && flags&message.FlagAcceptsCompressed != 0 {
+			t.compression.markAccepting(msg, t.network)
+		}
+		t.codecs.markPreferred(msg, t.network, flags.PreferredCodec())
+
+		t.stats.received(msg, size)
+		msg.SetRemoteAddress(conn.RemoteAddr().String())
+		t.handleMessage(msg)
+	}
+}
+
+// sentByPeer checks that msg received over authenticated conn is sent by the peer itself.
+// Relays forward messages of other nodes over circuits, so messages read from them are not checked.
+func (t *streamTransport) sentByPeer(conn net.Conn, msg *message.Message) bool {
+	peer := peerID(conn)
+	if peer == nil || t.relayClient != nil && t.relayClient.has(conn) {
+		return true
+	}
+	return msg.Sender != nil && peer.Equal(msg.Sender.ID)
+}
+
+func (t *streamTransport) handleMessage(msg *message.Message) {
+	if msg.IsResponse {
+		t.processResponse(msg)
+	} else {
+		t.processRequest(msg)
+	}
+}
+
+func (t *streamTransport) processResponse(msg *message.Message) {
+	future := t.getFuture(msg)
+	if future == nil {
+		// Request was already cancelled or timed out
+		return
+	}
+	t.removeFuture(future)
+	if !shouldProcessMessage(future, msg) {
+		future.SetResult(msg)
+	}
+	future.Cancel()
+}
+
+func (t *streamTransport) processRequest(msg *message.Message) {
+	if !msg.IsValid() {
+		return
+	}
+
+	t.receiving.RLock()
+	defer t.receiving.RUnlock()
+
+	select {
+	case <-t.closed:
+		return
+	default:
+	}
+
+	select {
+	case t.received <- msg:
+	case <-t.closed:
+	}
+}
+
+func shouldProcessMessage(future Future, msg *message.Message) bool {
+	return !future.Actor().Equal(*msg.Sender) && msg.Type != message.TypePing || msg.Type != future.Request().Type
+}
+
+// AtomicLoadAndIncrementUint64 performs CAS loop, increments counter and returns old value
+func AtomicLoadAndIncrementUint64(addr *uint64) uint64 {
+	for {
+		val := atomic.LoadUint64(addr)
+		if atomic.CompareAndSwapUint64(addr, val, val+1) {
+			return val
+		}
+	}
+}
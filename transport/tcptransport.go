@@ -0,0 +1,66 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+type tcpSocket struct {
+	listener net.Listener
+}
+
+// NewTCPTransport creates TCP transport listening on the same address as given PacketConn.
+// TCP doesn't use packet connection, so it is closed once listener is opened or fails to open.
+// Listener uses the same port as packet connection, creation fails if TCP port is taken by other process.
+func NewTCPTransport(conn net.PacketConn) (Transport, error) {
+	address := conn.LocalAddr().String()
+	listener, err := net.Listen("tcp", address)
+	conn.Close()
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return nil, fmt.Errorf("TCP port of %s is already in use: %w", address, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newStreamTransport(&tcpSocket{listener: listener}), nil
+}
+
+// Accept waits for the next incoming connection
+func (s *tcpSocket) Accept() (net.Conn, error) {
+	return s.listener.Accept()
+}
+
+// Dial connects to given address
+func (s *tcpSocket) Dial(address string) (net.Conn, error) {
+	return net.DialTimeout("tcp", address, time.Second)
+}
+
+// Addr returns listener address
+func (s *tcpSocket) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Close stops listening
+func (s *tcpSocket) Close() error {
+	return s.listener.Close()
+}
@@ -0,0 +1,174 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/connection"
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTCPTransport(t *testing.T, address string) (Transport, *node.Node) {
+	conn, err := connection.NewConnectionFactory().Create(address)
+	assert.NoError(t, err)
+
+	tp, err := NewTCPTransport(conn)
+	assert.NoError(t, err)
+
+	addr, _ := node.NewAddress(address)
+	n := node.NewNode(addr)
+	n.ID, _ = node.NewID()
+
+	return tp, n
+}
+
+func stopTransport(tp Transport, done chan bool) {
+	go func() {
+		<-tp.Stopped()
+		tp.Close()
+	}()
+	tp.Stop()
+	<-done
+}
+
+func TestTCPTransport_SendRequest(t *testing.T) {
+	first, firstNode := createTCPTransport(t, "127.0.0.1:8082")
+	second, secondNode := createTCPTransport(t, "127.0.0.1:8083")
+
+	done := make(chan bool)
+	for _, tp := range []Transport{first, second} {
+		go func(tp Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+	}
+
+	future, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+	assert.Len(t, first.PendingRequests(), 1)
+
+	request := <-second.Messages()
+	assert.Equal(t, message.TypePing, request.Type)
+	assert.Equal(t, firstNode.ID, request.Sender.ID)
+
+	response := message.NewBuilder().Sender(secondNode).Receiver(firstNode).Type(message.TypePing).Response(nil).Build()
+	err = second.SendResponse(request.RequestID, response)
+	assert.NoError(t, err)
+
+	result := <-future.Result()
+	assert.Equal(t, secondNode.ID, result.Sender.ID)
+	assert.True(t, result.IsResponse)
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestTCPTransport_LocalAddr(t *testing.T) {
+	conn, err := connection.NewConnectionFactory().Create("127.0.0.1:0")
+	assert.NoError(t, err)
+
+	tp, err := NewTCPTransport(conn)
+	assert.NoError(t, err)
+
+	assert.Equal(t, conn.LocalAddr().String(), tp.LocalAddr().String())
+	// Packet connection is not used by TCP transport
+	_, err = conn.WriteTo([]byte("ping"), conn.LocalAddr())
+	assert.Error(t, err)
+
+	done := startTransports(tp)
+	stopTransport(tp, done)
+}
+
+func TestTCPTransport_PortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	conn, err := connection.NewConnectionFactory().Create(listener.Addr().String())
+	assert.NoError(t, err)
+
+	_, err = NewTCPTransport(conn)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "TCP port of "+listener.Addr().String()+" is already in use")
+}
+
+func TestTCPTransport_IPv6(t *testing.T) {
+	first, firstNode := createTCPTransport(t, "[::1]:8107")
+	second, secondNode := createTCPTransport(t, "[::1]:8108")
+	done := startTransports(first, second)
+
+	_, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+
+	request := <-second.Messages()
+	assert.Equal(t, firstNode.ID, request.Sender.ID)
+	assert.Equal(t, "[::1]:8107", request.Sender.Address.String())
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+}
+
+func TestTCPTransport_DualStack(t *testing.T) {
+	dualStack, dualStackNode := createTCPTransport(t, "[::]:8109")
+	ipv4, ipv4Node := createTCPTransport(t, "127.0.0.1:8110")
+	ipv6, ipv6Node := createTCPTransport(t, "[::1]:8110")
+	done := startTransports(dualStack, ipv4, ipv6)
+
+	for _, address := range []string{"127.0.0.1:8109", "[::1]:8109"} {
+		addr, _ := node.NewAddress(address)
+		receiver := &node.Node{ID: dualStackNode.ID, Address: addr}
+
+		for _, sender := range []struct {
+			transport Transport
+			node      *node.Node
+		}{{ipv4, ipv4Node}, {ipv6, ipv6Node}} {
+			_, err := sender.transport.SendRequest(message.NewPingMessage(sender.node, receiver))
+			assert.NoError(t, err)
+
+			request := <-dualStack.Messages()
+			assert.Equal(t, sender.node.ID, request.Sender.ID)
+		}
+	}
+
+	stopTransport(dualStack, done)
+	stopTransport(ipv4, done)
+	stopTransport(ipv6, done)
+}
+
+func TestTCPTransport_CloseWhileReceiving(t *testing.T) {
+	first, firstNode := createTCPTransport(t, "127.0.0.1:8171")
+	second, secondNode := createTCPTransport(t, "127.0.0.1:8172")
+	done := startTransports(first, second)
+
+	// Request is read but nobody takes it from Messages
+	_, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+	for second.Stats().Received[message.TypePing.String()] == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(time.Millisecond * 10)
+
+	// Handler waiting to pass request is released instead of sending to closed channel
+	stopTransport(second, done)
+	stopTransport(first, done)
+}
@@ -0,0 +1,110 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/insolar/network/node"
+)
+
+const tlsHandshakeTimeout = time.Second * 5
+
+type tlsSocket struct {
+	socket socket
+	config *tls.Config
+}
+
+// NewTLSTransport wraps connections of given transport in TLS configured with config.
+// Certificates and peer verification are set up in config the same way as for crypto/tls.
+// It must be called before transport is started.
+func NewTLSTransport(transport Transport, config *tls.Config) (Transport, error) {
+	st, ok := transport.(*streamTransport)
+	if !ok {
+		return nil, errors.New("transport does not support TLS")
+	}
+
+	st.socket = &tlsSocket{
+		socket: st.socket,
+		config: config,
+	}
+
+	return st, nil
+}
+
+// Accept waits for the next incoming connection and wraps it in TLS
+func (s *tlsSocket) Accept() (net.Conn, error) {
+	conn, err := s.socket.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return tls.Server(conn, s.config), nil
+}
+
+// Dial connects to given address and performs TLS handshake
+func (s *tlsSocket) Dial(address string) (net.Conn, error) {
+	return s.DialNode(address, nil)
+}
+
+// DialNode connects to node with given id and performs TLS handshake
+func (s *tlsSocket) DialNode(address string, id node.ID) (net.Conn, error) {
+	conn, err := dialNode(s.socket, address, id)
+	if err != nil {
+		return nil, err
+	}
+
+	config := s.config
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		config = config.Clone()
+		config.ServerName = host
+	}
+
+	tlsConn := tls.Client(conn, config)
+
+	err = tlsConn.SetDeadline(time.Now().Add(tlsHandshakeTimeout))
+	if err == nil {
+		err = tlsConn.Handshake()
+	}
+	if err == nil {
+		err = tlsConn.SetDeadline(time.Time{})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, handshakeFailed(err)
+	}
+
+	return tlsConn, nil
+}
+
+// Addr returns underlying socket address
+func (s *tlsSocket) Addr() net.Addr {
+	return s.socket.Addr()
+}
+
+// Close closes underlying socket
+func (s *tlsSocket) Close() error {
+	return s.socket.Close()
+}
@@ -17,6 +17,8 @@
 package transport
 
 import (
+	"net"
+
 	"github.com/insolar/network/message"
 )
 
@@ -31,4 +33,7 @@ type Transport interface {
 
 	Messages() chan *message.Message
 	Stopped() chan bool
+	PendingRequests() []Future
+	LocalAddr() net.Addr
+	Stats() Stats
 }
@@ -0,0 +1,87 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type unixSocket struct {
+	directory string
+	listener  net.Listener
+}
+
+// NewUnixTransport creates transport listening on unix domain socket in given directory.
+// Node addresses are mapped to socket files in the directory, so nodes sharing it can reach each other locally.
+func NewUnixTransport(directory string, address string) (Transport, error) {
+	path := unixSocketPath(directory, address)
+
+	err := removeStaleSocket(path)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newStreamTransport(&unixSocket{directory: directory, listener: listener}), nil
+}
+
+// Accept waits for the next incoming connection
+func (s *unixSocket) Accept() (net.Conn, error) {
+	return s.listener.Accept()
+}
+
+// Dial connects to socket of given address
+func (s *unixSocket) Dial(address string) (net.Conn, error) {
+	return net.DialTimeout("unix", unixSocketPath(s.directory, address), time.Second)
+}
+
+// Addr returns listener address
+func (s *unixSocket) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Close stops listening and removes socket file
+func (s *unixSocket) Close() error {
+	return s.listener.Close()
+}
+
+func unixSocketPath(directory string, address string) string {
+	return filepath.Join(directory, address+".sock")
+}
+
+// removeStaleSocket removes socket file left by process which did not stop properly
+func removeStaleSocket(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return errors.New("socket " + path + " is already in use")
+	}
+
+	return os.Remove(path)
+}
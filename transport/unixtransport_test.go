@@ -0,0 +1,112 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createUnixTransport(t *testing.T, directory string, address string) (Transport, *node.Node) {
+	tp, err := NewUnixTransport(directory, address)
+	assert.NoError(t, err)
+
+	addr, _ := node.NewAddress(address)
+	n := node.NewNode(addr)
+	n.ID, _ = node.NewID()
+
+	return tp, n
+}
+
+func TestUnixTransport_SendRequest(t *testing.T) {
+	directory, err := ioutil.TempDir("", "network")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	first, firstNode := createUnixTransport(t, directory, "127.0.0.1:31337")
+	second, secondNode := createUnixTransport(t, directory, "127.0.0.1:31338")
+
+	done := make(chan bool)
+	for _, tp := range []Transport{first, second} {
+		go func(tp Transport) {
+			tp.Start()
+			done <- true
+		}(tp)
+	}
+
+	future, err := first.SendRequest(message.NewPingMessage(firstNode, secondNode))
+	assert.NoError(t, err)
+
+	request := <-second.Messages()
+	assert.Equal(t, message.TypePing, request.Type)
+	assert.Equal(t, firstNode.ID, request.Sender.ID)
+
+	response := message.NewBuilder().Sender(secondNode).Receiver(firstNode).Type(message.TypePing).Response(nil).Build()
+	err = second.SendResponse(request.RequestID, response)
+	assert.NoError(t, err)
+
+	result := <-future.Result()
+	assert.Equal(t, secondNode.ID, result.Sender.ID)
+	assert.True(t, result.IsResponse)
+
+	stopTransport(first, done)
+	stopTransport(second, done)
+
+	_, err = os.Stat(unixSocketPath(directory, "127.0.0.1:31337"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNewUnixTransport_StaleSocket(t *testing.T) {
+	directory, err := ioutil.TempDir("", "network")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	path := unixSocketPath(directory, "127.0.0.1:31337")
+	err = ioutil.WriteFile(path, nil, 0600)
+	assert.NoError(t, err)
+
+	tp, err := NewUnixTransport(directory, "127.0.0.1:31337")
+	assert.NoError(t, err)
+
+	done := make(chan bool)
+	go func() {
+		tp.Start()
+		done <- true
+	}()
+	stopTransport(tp, done)
+}
+
+func TestNewUnixTransport_SocketInUse(t *testing.T) {
+	directory, err := ioutil.TempDir("", "network")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	listener, err := net.Listen("unix", filepath.Join(directory, "127.0.0.1:31337.sock"))
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	_, err = NewUnixTransport(directory, "127.0.0.1:31337")
+	assert.Error(t, err)
+}
@@ -18,222 +18,99 @@ package transport
 
 import (
 	"context"
-	"log"
 	"net"
-	"sync"
-	"sync/atomic"
 	"time"
 
-	"github.com/insolar/network/message"
-
-	"github.com/anacrolix/utp"
+	"github.com/insolar/network/transport/internal/utp"
 )
 
-type utpTransport struct {
-	socket *utp.Socket
+// UTPConfig contains tunable parameters of uTP transport, zero values keep defaults of github.com/anacrolix/utp
+// which transport is forked from.
+type UTPConfig struct {
+	// MaxPacketSize caps size of datagrams sent including uTP header
+	MaxPacketSize int
 
-	received chan *message.Message
-	sequence *uint64
+	// MinWindow and MaxWindow bound congestion window, that is bytes in flight to every peer.
+	// Without TargetDelay window is fixed at MaxWindow, without both only peer receive window limits it
+	MinWindow int
+	MaxWindow int
 
-	disconnectStarted  chan bool
-	disconnectFinished chan bool
+	// TargetDelay enables LEDBAT congestion control: window grows while queuing delay is below target
+	// and shrinks above it. Links with high base latency, like satellite ones, keep full window as only
+	// delay above the lowest one observed counts
+	TargetDelay time.Duration
 
-	mutex   *sync.RWMutex
-	futures map[message.RequestID]Future
-}
-
-// NewUTPTransport creates utpTransport
-func NewUTPTransport(conn net.PacketConn) (Transport, error) {
-	return newUTPTransport(conn)
+	// PathMTUDiscovery lowers packet size for every peer host to the largest UDP payload which is delivered
+	// to it without loss. Peers must enable it too to answer probes, MaxPacketSize is used for peers which don't
+	PathMTUDiscovery bool
 }
 
-func newUTPTransport(conn net.PacketConn) (*utpTransport, error) {
-	socket, err := utp.NewSocketFromPacketConn(conn)
-	if err != nil {
-		return nil, err
-	}
-
-	transport := &utpTransport{
-		socket: socket,
-
-		received: make(chan *message.Message),
-		sequence: new(uint64),
-
-		disconnectStarted:  make(chan bool),
-		disconnectFinished: make(chan bool),
-
-		mutex:   &sync.RWMutex{},
-		futures: make(map[message.RequestID]Future),
-	}
-
-	return transport, nil
-}
-
-// SendRequest sends request message and returns future
-func (t *utpTransport) SendRequest(msg *message.Message) (Future, error) {
-	msg.RequestID = t.generateID()
-
-	future := t.createFuture(msg)
-
-	err := t.sendMessage(msg)
-	if err != nil {
-		future.Cancel()
-		return nil, err
-	}
-
-	return future, nil
+type utpSocket struct {
+	socket *utp.Socket
+	config *UTPConfig
+	pmtu   *pathMTUConn
 }
 
-// SendResponse sends response message
-func (t *utpTransport) SendResponse(requestID message.RequestID, msg *message.Message) error {
-	msg.RequestID = requestID
-
-	return t.sendMessage(msg)
+// NewUTPTransport creates uTP transport on given PacketConn
+func NewUTPTransport(conn net.PacketConn) (Transport, error) {
+	return NewUTPTransportWithConfig(conn, nil)
 }
 
-// Start starts networking
-func (t *utpTransport) Start() error {
-	for {
-		conn, err := t.socket.Accept()
-
-		if err != nil {
-			<-t.disconnectFinished
-			return err
-		}
-
-		go t.handleAcceptedConnection(conn)
+// NewUTPTransportWithConfig creates uTP transport on given PacketConn tuned with config. Defaults are used if config is nil
+func NewUTPTransportWithConfig(conn net.PacketConn, config *UTPConfig) (Transport, error) {
+	if config == nil {
+		config = &UTPConfig{}
 	}
-}
-
-// Stop stops networking
-func (t *utpTransport) Stop() {
-	t.mutex.Lock()
-	defer t.mutex.Unlock()
 
-	t.disconnectStarted <- true
-	close(t.disconnectStarted)
-
-	err := t.socket.CloseNow()
-	if err != nil {
-		log.Println("Failed to close socket:", err.Error())
+	socketConfig := utp.Config{
+		MaxPacketSize: config.MaxPacketSize,
+		MinWindow:     config.MinWindow,
+		MaxWindow:     config.MaxWindow,
+		TargetDelay:   config.TargetDelay,
 	}
-}
-
-// Close closes message channels
-func (t *utpTransport) Close() {
-	t.mutex.Lock()
-	defer t.mutex.Unlock()
 
-	close(t.received)
-	close(t.disconnectFinished)
-}
-
-// Messages returns incoming messages channel
-func (t *utpTransport) Messages() chan *message.Message {
-	return t.received
-}
-
-// Stopped checks if networking is stopped already
-func (t *utpTransport) Stopped() chan bool {
-	return t.disconnectStarted
-}
-
-func (t *utpTransport) socketDialTimeout(addr string, timeout time.Duration) (net.Conn, error) {
-	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(timeout))
-	defer cancel()
-
-	return t.socket.DialContext(ctx, "", addr)
-}
-
-func (t *utpTransport) generateID() message.RequestID {
-	id := AtomicLoadAndIncrementUint64(t.sequence)
-	return message.RequestID(id)
-}
-
-func (t *utpTransport) createFuture(msg *message.Message) Future {
-	newFuture := NewFuture(msg.RequestID, msg.Receiver, msg, func(f Future) {
-		t.mutex.Lock()
-		defer t.mutex.Unlock()
-
-		delete(t.futures, f.Request().RequestID)
-	})
-
-	t.mutex.Lock()
-	defer t.mutex.Unlock()
-	t.futures[msg.RequestID] = newFuture
-
-	return newFuture
-}
-
-func (t *utpTransport) getFuture(msg *message.Message) Future {
-	t.mutex.RLock()
-	defer t.mutex.RUnlock()
-
-	return t.futures[msg.RequestID]
-}
-
-func (t *utpTransport) sendMessage(msg *message.Message) error {
-	conn, err := t.socketDialTimeout(msg.Receiver.Address.String(), time.Second)
-	if err != nil {
-		return err
+	var pmtu *pathMTUConn
+	if config.PathMTUDiscovery {
+		pmtu = newPathMTUConn(conn)
+		conn = pmtu
+		// MTU discovered when dialing peer's host is used for connections accepted from it too
+		socketConfig.PacketSize = func(addr net.Addr) int {
+			return pmtu.lookup(addr.String(), 0, 0)
+		}
 	}
 
-	data, err := message.SerializeMessage(msg)
+	socket, err := utp.NewSocketFromPacketConnConfig(conn, socketConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = conn.Write(data)
-	return err
+	return newStreamTransport(&utpSocket{socket: socket, config: config, pmtu: pmtu}), nil
 }
 
-func (t *utpTransport) handleAcceptedConnection(conn net.Conn) {
-	for {
-		// Wait for Messages
-		msg, err := message.DeserializeMessage(conn)
-		if err != nil {
-			// TODO should we penalize this Node somehow ? Ban it ?
-			// if err.Error() != "EOF" {
-			// }
-			return
-		}
-
-		t.handleMessage(msg)
-	}
+// Accept waits for the next incoming connection
+func (s *utpSocket) Accept() (net.Conn, error) {
+	return s.socket.Accept()
 }
 
-func (t *utpTransport) handleMessage(msg *message.Message) {
-	if msg.IsResponse {
-		t.processResponse(msg)
-	} else {
-		t.processRequest(msg)
+// Dial connects to given address
+func (s *utpSocket) Dial(address string) (net.Conn, error) {
+	if s.pmtu != nil {
+		// Connection picks discovered size up when it is created
+		s.pmtu.discover(address, s.config.MaxPacketSize, 0)
 	}
-}
 
-func (t *utpTransport) processResponse(msg *message.Message) {
-	future := t.getFuture(msg)
-	if future != nil && !shouldProcessMessage(future, msg) {
-		future.SetResult(msg)
-	}
-	future.Cancel()
-}
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Second))
+	defer cancel()
 
-func (t *utpTransport) processRequest(msg *message.Message) {
-	if msg.IsValid() {
-		t.received <- msg
-	}
+	return s.socket.DialContext(ctx, "", address)
 }
 
-func shouldProcessMessage(future Future, msg *message.Message) bool {
-	return !future.Actor().Equal(*msg.Sender) && msg.Type != message.TypePing || msg.Type != future.Request().Type
+// Addr returns socket address
+func (s *utpSocket) Addr() net.Addr {
+	return s.socket.Addr()
 }
 
-// AtomicLoadAndIncrementUint64 performs CAS loop, increments counter and returns old value
-func AtomicLoadAndIncrementUint64(addr *uint64) uint64 {
-	for {
-		val := atomic.LoadUint64(addr)
-		if atomic.CompareAndSwapUint64(addr, val, val+1) {
-			return val
-		}
-	}
+// Close closes socket immediately
+func (s *utpSocket) Close() error {
+	return s.socket.CloseNow()
 }
@@ -0,0 +1,111 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package transport
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/connection"
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUTPTransport_Config(t *testing.T) {
+	config := &UTPConfig{
+		MaxPacketSize: 600,
+		MinWindow:     1200,
+		MaxWindow:     16000,
+		TargetDelay:   time.Millisecond * 100,
+	}
+
+	var transports []Transport
+	var nodes []*node.Node
+	for _, address := range []string{"127.0.0.1:8162", "127.0.0.1:8163"} {
+		conn, err := connection.NewConnectionFactory().Create(address)
+		assert.NoError(t, err)
+		tp, err := NewUTPTransportWithConfig(conn, config)
+		assert.NoError(t, err)
+
+		addr, _ := node.NewAddress(address)
+		n := node.NewNode(addr)
+		n.ID, _ = node.NewID()
+		transports = append(transports, tp)
+		nodes = append(nodes, n)
+	}
+	done := startTransports(transports[0], transports[1])
+
+	// Message spans many packets and windows
+	data := bytes.Repeat([]byte("insolar"), 20000)
+	msg := message.NewBuilder().Sender(nodes[0]).Receiver(nodes[1]).Type(message.TypeStore).
+		Request(&message.RequestDataStore{Data: data}).Build()
+	_, err := transports[0].SendRequest(msg)
+	assert.NoError(t, err)
+
+	request := <-transports[1].Messages()
+	assert.Equal(t, message.TypeStore, request.Type)
+	assert.Equal(t, data, request.Data.(*message.RequestDataStore).Data)
+
+	stopTransport(transports[0], done)
+	stopTransport(transports[1], done)
+}
+
+func TestNewUTPTransportWithConfig_Invalid(t *testing.T) {
+	conn, err := connection.NewConnectionFactory().Create("127.0.0.1:8164")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	_, err = NewUTPTransportWithConfig(conn, &UTPConfig{MaxPacketSize: 20})
+	assert.Error(t, err)
+	_, err = NewUTPTransportWithConfig(conn, &UTPConfig{MinWindow: 8000, MaxWindow: 4000})
+	assert.Error(t, err)
+}
+
+func TestUTPTransport_PathMTUDiscovery(t *testing.T) {
+	config := &UTPConfig{PathMTUDiscovery: true}
+
+	var transports []Transport
+	var nodes []*node.Node
+	for _, address := range []string{"127.0.0.1:8165", "127.0.0.1:8166"} {
+		conn, err := connection.NewConnectionFactory().Create(address)
+		assert.NoError(t, err)
+		tp, err := NewUTPTransportWithConfig(conn, config)
+		assert.NoError(t, err)
+		assert.NotNil(t, tp.(*streamTransport).socket.(*utpSocket).pmtu)
+
+		addr, _ := node.NewAddress(address)
+		n := node.NewNode(addr)
+		n.ID, _ = node.NewID()
+		transports = append(transports, tp)
+		nodes = append(nodes, n)
+	}
+	done := startTransports(transports[0], transports[1])
+
+	_, err := transports[0].SendRequest(message.NewPingMessage(nodes[0], nodes[1]))
+	assert.NoError(t, err)
+	request := <-transports[1].Messages()
+	assert.Equal(t, message.TypePing, request.Type)
+
+	pmtu := transports[0].(*streamTransport).socket.(*utpSocket).pmtu
+	assert.Equal(t, 1472, pmtu.lookup("127.0.0.1:8166", 0, 0))
+
+	stopTransport(transports[0], done)
+	stopTransport(transports[1], done)
+}
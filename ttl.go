@@ -0,0 +1,99 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/store"
+)
+
+// lifetimes keeps expiration times of values stored with TTL chosen by publisher,
+// so their replicas expire together instead of getting default expiration
+type lifetimes struct {
+	mutex       *sync.Mutex
+	expirations map[string]time.Time
+}
+
+func newLifetimes() *lifetimes {
+	return &lifetimes{
+		mutex:       &sync.Mutex{},
+		expirations: make(map[string]time.Time),
+	}
+}
+
+// set records expiration of value stored with TTL
+func (l *lifetimes) set(key store.Key, expiration, now time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.expire(now)
+	l.expirations[key.String()] = expiration
+}
+
+// remaining returns time left to expiration of value stored with TTL,
+// it returns false if value was stored with default expiration
+func (l *lifetimes) remaining(key store.Key, now time.Time) (time.Duration, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	expiration, ok := l.expirations[key.String()]
+	if !ok {
+		return 0, false
+	}
+	return expiration.Sub(now), true
+}
+
+// expire forgets expired values, must be called under mutex
+func (l *lifetimes) expire(now time.Time) {
+	for k, expiration := range l.expirations {
+		if now.After(expiration) {
+			delete(l.expirations, k)
+		}
+	}
+}
+
+// StoreWithTTL stores data on the network the same way Store does, but value expires after ttl
+// instead of default expiration time. Holders shorten ttl to their MaxTTL, replicas expire together
+// with the original value and republishing does not extend it.
+func (dht *DHT) StoreWithTTL(ctx Context, data []byte, ttl time.Duration) (id string, err error) {
+	if ttl <= 0 {
+		return "", errors.New("ttl must be positive")
+	}
+	id, _, err = dht.storeWithTTL(ctx, data, ttl)
+	return id, err
+}
+
+// limitTTL shortens ttl to MaxTTL
+func (dht *DHT) limitTTL(ttl time.Duration) time.Duration {
+	if ttl > dht.options.MaxTTL {
+		return dht.options.MaxTTL
+	}
+	return ttl
+}
+
+// storeAgain publishes value held by node again, values stored with TTL keep their remaining lifetime
+func (dht *DHT) storeAgain(ctx Context, key store.Key, data []byte) error {
+	ttl, limited := dht.lifetimes.remaining(key, dht.options.Clock.Now())
+	if limited && ttl <= 0 {
+		return nil
+	}
+	_, _, err := dht.storeWithTTL(dht.withNamespaceOf(ctx, key), data, ttl)
+	return err
+}
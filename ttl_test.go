@@ -0,0 +1,107 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifetimes(t *testing.T) {
+	l := newLifetimes()
+	now := time.Now()
+	key := store.NewKey([]byte("foo"))
+
+	_, limited := l.remaining(key, now)
+	assert.False(t, limited)
+
+	l.set(key, now.Add(time.Minute), now)
+	ttl, limited := l.remaining(key, now.Add(time.Second))
+	assert.True(t, limited)
+	assert.Equal(t, time.Minute-time.Second, ttl)
+
+	// Expired values are forgotten
+	l.set(store.NewKey([]byte("bar")), now.Add(time.Hour), now.Add(2*time.Minute))
+	_, limited = l.remaining(key, now)
+	assert.False(t, limited)
+}
+
+func TestDHT_StoreWithTTL(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{MaxTTL: time.Hour})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+		MaxTTL:         time.Hour * 48,
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+	ctx := context.Background()
+
+	_, err = dht2.StoreWithTTL(getDefaultCtx(dht2), []byte("foo"), 0)
+	assert.EqualError(t, err, "ttl must be positive")
+
+	key := store.NewKey([]byte("foo"))
+	_, err = dht2.StoreWithTTL(getDefaultCtx(dht2), []byte("foo"), time.Minute)
+	assert.NoError(t, err)
+	entries, err := st1.RetrieveBatch(ctx, []store.Key{key})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), entries[0].Expiration, time.Second)
+
+	// Holder shortens TTL to its MaxTTL
+	long := store.NewKey([]byte("bar"))
+	_, err = dht2.StoreWithTTL(getDefaultCtx(dht2), []byte("bar"), time.Hour*24)
+	assert.NoError(t, err)
+	entries, err = st1.RetrieveBatch(ctx, []store.Key{long})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), entries[0].Expiration, time.Second)
+
+	// Replicas expire together with the value
+	assert.NoError(t, st2.Delete(ctx, key))
+	dht1.replicate(getDefaultCtx(dht1), []store.Key{key})
+	entries, err = st2.RetrieveBatch(ctx, []store.Key{key})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), entries[0].Expiration, time.Second)
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"sync"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+)
+
+// Version is a version of network package reported to other nodes in ping exchange
+var Version = "0.1.0"
+
+// Build is a build metadata reported along with Version, e.g. commit hash.
+// It can be set at build time with -ldflags "-X github.com/insolar/network.Build=..."
+var Build = ""
+
+type peerVersion struct {
+	version string
+	build   string
+}
+
+// peerVersions keeps versions reported by other nodes
+type peerVersions struct {
+	mutex    *sync.Mutex
+	versions map[string]peerVersion
+}
+
+func newPeerVersions() *peerVersions {
+	return &peerVersions{
+		mutex:    &sync.Mutex{},
+		versions: make(map[string]peerVersion),
+	}
+}
+
+// record remembers version reported in ping request or response
+func (pv *peerVersions) record(msg *message.Message) {
+	if msg == nil || msg.Sender == nil || msg.Sender.ID == nil {
+		return
+	}
+
+	var version peerVersion
+	switch data := msg.Data.(type) {
+	case *message.RequestDataPing:
+		version = peerVersion{version: data.Version, build: data.Build}
+	case *message.ResponseDataPing:
+		version = peerVersion{version: data.Version, build: data.Build}
+	default:
+		return
+	}
+
+	pv.mutex.Lock()
+	defer pv.mutex.Unlock()
+
+	pv.versions[string(msg.Sender.ID)] = version
+}
+
+// distribution counts versions of given nodes and forgets versions of other nodes
+func (pv *peerVersions) distribution(ids map[string]bool) map[string]int {
+	pv.mutex.Lock()
+	defer pv.mutex.Unlock()
+
+	for id := range pv.versions {
+		if !ids[id] {
+			delete(pv.versions, id)
+		}
+	}
+
+	stats := make(map[string]int)
+	for id := range ids {
+		stats[pv.versions[id].version]++
+	}
+	return stats
+}
+
+// newPingMessage creates ping message carrying our version
+func newPingMessage(sender, receiver *node.Node) *message.Message {
+	msg := message.NewPingMessage(sender, receiver)
+	msg.Data = &message.RequestDataPing{Version: Version, Build: Build}
+	return msg
+}
+
+// VersionStats returns number of known nodes per reported version.
+// Nodes which have not reported their version are counted under empty version.
+func (dht *DHT) VersionStats() map[string]int {
+	ids := make(map[string]bool)
+	for _, ht := range dht.tables {
+		ht.Lock()
+		for _, bucket := range ht.RoutingTable {
+			for _, n := range bucket {
+				ids[string(n.ID)] = true
+			}
+		}
+		ht.Unlock()
+	}
+
+	return dht.versions.distribution(ids)
+}
@@ -0,0 +1,84 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerVersions_Distribution(t *testing.T) {
+	pv := newPeerVersions()
+	addr, _ := node.NewAddress("127.0.0.1:3000")
+	first := &node.Node{ID: getIDWithValues(1), Address: addr}
+	second := &node.Node{ID: getIDWithValues(2), Address: addr}
+	gone := &node.Node{ID: getIDWithValues(3), Address: addr}
+
+	pv.record(&message.Message{Sender: first, Data: &message.RequestDataPing{Version: "0.1.0"}})
+	pv.record(&message.Message{Sender: second, Data: &message.ResponseDataPing{Version: "0.2.0", Build: "abc"}})
+	pv.record(&message.Message{Sender: gone, Data: &message.RequestDataPing{Version: "0.1.0"}})
+	// Ping from older node without version
+	pv.record(&message.Message{Sender: second})
+
+	ids := map[string]bool{
+		string(first.ID):           true,
+		string(second.ID):          true,
+		string(getIDWithValues(4)): true,
+	}
+	assert.Equal(t, map[string]int{"0.1.0": 1, "0.2.0": 1, "": 1}, pv.distribution(ids))
+	assert.Len(t, pv.versions, 2)
+}
+
+func TestVersionStats(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		// Bootstrap node without ID is pinged first
+		BootstrapNodes: []*node.Node{{Address: dht1.origin.Address}},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	err = dht2.Bootstrap()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{Version: 1}, dht2.VersionStats())
+	assert.Equal(t, map[string]int{Version: 1}, dht1.VersionStats())
+
+	dht1.Disconnect()
+	dht2.Disconnect()
+
+	<-done
+	<-done
+}
@@ -0,0 +1,451 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/insolar/network/message"
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+
+	"github.com/jbenet/go-base58"
+)
+
+// Updates which are not read by watcher in time are dropped once buffer is full
+const watchUpdatesBuffer = 16
+
+// WatchUpdate is a new value or version under watched key pushed by its holder
+type WatchUpdate struct {
+	Key     string
+	Value   []byte
+	Version store.Version
+	Holder  *node.Node
+}
+
+// Watcher receives updates of value under key until it is stopped
+type Watcher struct {
+	key     string
+	updates chan WatchUpdate
+	stop    chan bool
+	done    chan bool
+
+	mutex   *sync.Mutex
+	stopped bool
+}
+
+func newWatcher(key string) *Watcher {
+	return &Watcher{
+		key:     key,
+		updates: make(chan WatchUpdate, watchUpdatesBuffer),
+		stop:    make(chan bool),
+		done:    make(chan bool),
+		mutex:   &sync.Mutex{},
+	}
+}
+
+// Updates returns channel of pushed updates, it is closed once watcher is stopped
+func (w *Watcher) Updates() <-chan WatchUpdate {
+	return w.updates
+}
+
+// Stop cancels watch on holders of value
+func (w *Watcher) Stop() {
+	w.close(w.stop)
+}
+
+// close closes given channel of watcher once, later calls are no-op
+func (w *Watcher) close(c chan bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.stopped {
+		w.stopped = true
+		close(c)
+	}
+}
+
+// deliver passes update to watcher without blocking, returns false if update is dropped
+func (w *Watcher) deliver(update WatchUpdate) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.stopped {
+		return false
+	}
+
+	select {
+	case w.updates <- update:
+		return true
+	default:
+		return false
+	}
+}
+
+// remoteWatch is a watch registered on this node by other node
+type remoteWatch struct {
+	watcher *node.Node
+	origin  *node.Node // Our identity watch was registered with
+	expires time.Time
+}
+
+// watches keeps watches registered on this node and watchers of this node
+type watches struct {
+	mutex    *sync.Mutex
+	remote   map[string]map[string]*remoteWatch
+	count    int
+	watchers map[string][]*Watcher
+}
+
+func newWatches() *watches {
+	return &watches{
+		mutex:    &sync.Mutex{},
+		remote:   make(map[string]map[string]*remoteWatch),
+		watchers: make(map[string][]*Watcher),
+	}
+}
+
+// register registers or renews watch of other node, returns false if max watches are registered already
+func (ws *watches) register(key string, watch *remoteWatch, max int, now time.Time) bool {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	ws.prune(now)
+
+	id := string(watch.watcher.ID)
+	if _, ok := ws.remote[key][id]; !ok {
+		if max > 0 && ws.count >= max {
+			return false
+		}
+		if ws.remote[key] == nil {
+			ws.remote[key] = make(map[string]*remoteWatch)
+		}
+		ws.count++
+	}
+	ws.remote[key][id] = watch
+	return true
+}
+
+// unregister removes watch of other node
+func (ws *watches) unregister(key string, id node.ID) {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	if _, ok := ws.remote[key][string(id)]; ok {
+		ws.remove(key, string(id))
+	}
+}
+
+// registered returns watches of other nodes on key which did not expire
+func (ws *watches) registered(key string, now time.Time) []*remoteWatch {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	ws.prune(now)
+
+	result := make([]*remoteWatch, 0, len(ws.remote[key]))
+	for _, watch := range ws.remote[key] {
+		result = append(result, watch)
+	}
+	return result
+}
+
+// watched checks if anyone watches key
+func (ws *watches) watched(key string, now time.Time) bool {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	ws.prune(now)
+
+	return len(ws.remote[key]) > 0 || len(ws.watchers[key]) > 0
+}
+
+func (ws *watches) prune(now time.Time) {
+	for key, watches := range ws.remote {
+		for id, watch := range watches {
+			if now.After(watch.expires) {
+				ws.remove(key, id)
+			}
+		}
+	}
+}
+
+func (ws *watches) remove(key string, id string) {
+	delete(ws.remote[key], id)
+	if len(ws.remote[key]) == 0 {
+		delete(ws.remote, key)
+	}
+	ws.count--
+}
+
+func (ws *watches) subscribe(w *Watcher) {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	ws.watchers[w.key] = append(ws.watchers[w.key], w)
+}
+
+func (ws *watches) unsubscribe(w *Watcher) {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	watchers := ws.watchers[w.key]
+	for i, other := range watchers {
+		if other == w {
+			ws.watchers[w.key] = append(watchers[:i], watchers[i+1:]...)
+			break
+		}
+	}
+	if len(ws.watchers[w.key]) == 0 {
+		delete(ws.watchers, w.key)
+	}
+}
+
+// deliver passes update to watchers of its key, returns false if there are no watchers
+func (ws *watches) deliver(update WatchUpdate) bool {
+	ws.mutex.Lock()
+	watchers := append([]*Watcher(nil), ws.watchers[update.Key]...)
+	ws.mutex.Unlock()
+
+	for _, w := range watchers {
+		if !w.deliver(update) {
+			log.Println("Dropped update of watched value:", update.Key)
+		}
+	}
+	return len(watchers) > 0
+}
+
+// closeAll stops watchers without cancelling their watches, used on disconnect
+func (ws *watches) closeAll() {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	for _, watchers := range ws.watchers {
+		for _, w := range watchers {
+			w.close(w.done)
+		}
+	}
+}
+
+// Watch registers interest in value under key at the closest nodes, they push an update
+// whenever they get new value or version under key. Watch is renewed every half of WatchLease
+// option, so holders follow the closest nodes as network changes. Key is the base58 encoded
+// identifier of the data.
+func (dht *DHT) Watch(ctx Context, key string) (*Watcher, error) {
+	keyBytes := base58.Decode(key)
+	if len(keyBytes) != store.KeySize {
+		return nil, errors.New("invalid key")
+	}
+
+	w := newWatcher(key)
+	dht.watches.subscribe(w)
+
+	holders, err := dht.renewWatch(ctx, keyBytes, nil)
+	if err != nil {
+		dht.watches.unsubscribe(w)
+		return nil, err
+	}
+
+	go dht.handleWatch(ctx, w, keyBytes, holders)
+
+	return w, nil
+}
+
+// handleWatch renews watch until watcher is stopped
+func (dht *DHT) handleWatch(ctx Context, w *Watcher, key []byte, holders []*node.Node) {
+	defer close(w.updates)
+	defer dht.watches.unsubscribe(w)
+
+	ticker := dht.options.Clock.NewTicker(dht.options.WatchLease / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			renewed, err := dht.renewWatch(ctx, key, holders)
+			if err != nil {
+				log.Println("Failed to renew watch:", err.Error())
+				continue
+			}
+			holders = renewed
+		case <-w.stop:
+			dht.sendWatch(ctx, key, holders, 0)
+			return
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// renewWatch registers watch at the closest nodes to key and cancels it at previous holders
+// which are not the closest anymore. It returns nodes which accepted the watch.
+func (dht *DHT) renewWatch(ctx Context, key []byte, previous []*node.Node) ([]*node.Node, error) {
+	_, closest, err := dht.iterate(ctx, routing.IterateFindNode, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	holders := dht.sendWatch(ctx, key, closest, dht.options.WatchLease)
+
+	var gone []*node.Node
+	for _, n := range previous {
+		if !containsNode(closest, n) {
+			gone = append(gone, n)
+		}
+	}
+	dht.sendWatch(ctx, key, gone, 0)
+
+	return holders, nil
+}
+
+// sendWatch sends watch requests with given lease to nodes and returns nodes which accepted them
+func (dht *DHT) sendWatch(ctx Context, key []byte, nodes []*node.Node, lease time.Duration) []*node.Node {
+	ht := dht.htFromCtx(ctx)
+	results := make(chan *node.Node, len(nodes))
+	wg := &sync.WaitGroup{}
+
+	for _, receiver := range nodes {
+		request := message.NewBuilder().Sender(ht.Origin).Receiver(receiver).Type(message.TypeWatch).Request(
+			&message.RequestDataWatch{
+				Key:   key,
+				Lease: lease,
+			}).Build()
+
+		future, err := dht.sendRequest(ctx, request)
+		if err != nil {
+			log.Println("Failed to send watch request:", err.Error())
+			continue
+		}
+
+		wg.Add(1)
+		go func(future transport.Future) {
+			defer wg.Done()
+			result, err := dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+			if err != nil {
+				return
+			}
+			response, ok := result.Data.(*message.ResponseDataWatch)
+			if ok && response.Success {
+				results <- future.Actor()
+			}
+		}(future)
+	}
+
+	wg.Wait()
+	close(results)
+
+	holders := make([]*node.Node, 0, len(nodes))
+	for n := range results {
+		holders = append(holders, n)
+	}
+	return holders
+}
+
+// notifyWatchers pushes new value or version under key to its watchers
+func (dht *DHT) notifyWatchers(ctx Context, key store.Key, value []byte, version store.Version) {
+	str := base58.Encode(key)
+	dht.watches.deliver(WatchUpdate{
+		Key:     str,
+		Value:   value,
+		Version: version,
+		Holder:  dht.htFromCtx(ctx).Origin,
+	})
+
+	for _, watch := range dht.watches.registered(str, dht.options.Clock.Now()) {
+		request := message.NewBuilder().Sender(watch.origin).Receiver(watch.watcher).Type(message.TypeNotify).Request(
+			&message.RequestDataNotify{
+				Key:       key,
+				Value:     value,
+				Version:   version,
+				Namespace: store.NamespaceOf(ctx),
+			}).Build()
+
+		future, err := dht.transport.SendRequest(request)
+		if err != nil {
+			log.Println("Failed to notify watcher:", err.Error())
+			continue
+		}
+
+		go dht.waitResponse(ctx, future, dht.messageTimeout(ctx))
+	}
+}
+
+func (dht *DHT) processWatch(ctx Context, msg *message.Message, messageBuilder message.Builder) {
+	data := msg.Data.(*message.RequestDataWatch)
+	dht.addNode(ctx, routing.NewRouteNode(msg.Sender))
+	key := base58.Encode(data.Key)
+	response := &message.ResponseDataWatch{}
+
+	if data.Lease <= 0 {
+		dht.watches.unregister(key, msg.Sender.ID)
+		response.Success = true
+	} else {
+		lease := data.Lease
+		if lease > dht.options.WatchLease {
+			lease = dht.options.WatchLease
+		}
+		watch := &remoteWatch{
+			watcher: msg.Sender,
+			origin:  dht.htFromCtx(ctx).Origin,
+			expires: dht.options.Clock.Now().Add(lease),
+		}
+		if dht.watches.register(key, watch, dht.options.MaxWatches, dht.options.Clock.Now()) {
+			response.Success = true
+			response.Lease = lease
+		}
+	}
+
+	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
+	if err != nil {
+		log.Println("Failed to send response:", err.Error())
+	}
+}
+
+func (dht *DHT) processNotify(ctx Context, msg *message.Message, messageBuilder message.Builder) {
+	data := msg.Data.(*message.RequestDataNotify)
+	response := &message.ResponseDataNotify{}
+
+	// Values are content addressed, so holder can not push forged value
+	if bytes.Equal(dht.keyOf(store.WithNamespace(ctx, data.Namespace), data.Value), data.Key) {
+		response.Success = dht.watches.deliver(WatchUpdate{
+			Key:     base58.Encode(data.Key),
+			Value:   data.Value,
+			Version: data.Version,
+			Holder:  msg.Sender,
+		})
+	}
+
+	err := dht.transport.SendResponse(msg.RequestID, messageBuilder.Response(response).Build())
+	if err != nil {
+		log.Println("Failed to send response:", err.Error())
+	}
+}
+
+func containsNode(nodes []*node.Node, n *node.Node) bool {
+	for _, other := range nodes {
+		if other.ID.Equal(n.ID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/store"
+	"github.com/insolar/network/transport"
+
+	"github.com/jbenet/go-base58"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatches_Register(t *testing.T) {
+	ws := newWatches()
+	ids, _ := node.NewIDs(3)
+	now := time.Now()
+
+	watch := func(id node.ID, expires time.Time) *remoteWatch {
+		return &remoteWatch{watcher: &node.Node{ID: id}, expires: expires}
+	}
+
+	assert.True(t, ws.register("foo", watch(ids[0], now.Add(time.Minute)), 2, now))
+	assert.True(t, ws.register("bar", watch(ids[1], now.Add(time.Second)), 2, now))
+	// Renewal is not limited
+	assert.True(t, ws.register("foo", watch(ids[0], now.Add(time.Hour)), 2, now))
+	assert.False(t, ws.register("foo", watch(ids[2], now.Add(time.Minute)), 2, now))
+	assert.True(t, ws.watched("foo", now))
+
+	// Expired watches are forgotten
+	assert.Empty(t, ws.registered("bar", now.Add(time.Minute)))
+	assert.True(t, ws.register("foo", watch(ids[2], now.Add(time.Hour)), 2, now.Add(time.Minute)))
+	assert.Len(t, ws.registered("foo", now.Add(time.Minute)), 2)
+
+	ws.unregister("foo", ids[0])
+	ws.unregister("foo", ids[2])
+	assert.False(t, ws.watched("foo", now))
+	assert.Equal(t, 0, ws.count)
+}
+
+func TestWatches_Deliver(t *testing.T) {
+	ws := newWatches()
+	assert.False(t, ws.deliver(WatchUpdate{Key: "foo"}))
+
+	w := newWatcher("foo")
+	ws.subscribe(w)
+	assert.True(t, ws.watched("foo", time.Now()))
+
+	// Updates are dropped once buffer is full
+	for i := 0; i < watchUpdatesBuffer+1; i++ {
+		assert.True(t, ws.deliver(WatchUpdate{Key: "foo"}))
+	}
+	assert.Len(t, w.updates, watchUpdatesBuffer)
+
+	w.Stop()
+	w.Stop()
+	assert.False(t, w.deliver(WatchUpdate{Key: "foo"}))
+
+	ws.unsubscribe(w)
+	assert.False(t, ws.watched("foo", time.Now()))
+}
+
+func TestDHT_Watch(t *testing.T) {
+	done := make(chan bool)
+	network := transport.NewInMemoryNetwork(time.Millisecond, 0)
+
+	id1, _ := node.NewIDs(1)
+	st1, s1, tp1, r1, err := inMemoryDhtParams(network, id1, "127.0.0.1:3000")
+	assert.NoError(t, err)
+	dht1, _ := NewDHT(st1, s1, tp1, r1, &Options{})
+
+	st2, s2, tp2, r2, err := inMemoryDhtParams(network, nil, "127.0.0.1:3001")
+	assert.NoError(t, err)
+	dht2, _ := NewDHT(st2, s2, tp2, r2, &Options{
+		BootstrapNodes: []*node.Node{{ID: id1[0], Address: dht1.origin.Address}},
+	})
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		go func(dht *DHT) {
+			dht.Listen()
+			done <- true
+		}(dht)
+	}
+
+	assert.NoError(t, dht2.Bootstrap())
+
+	data := []byte("foo")
+	key := base58.Encode(store.NewKey(data))
+
+	_, err = dht2.Watch(getDefaultCtx(dht2), "invalid")
+	assert.EqualError(t, err, "invalid key")
+
+	watcher, err := dht2.Watch(getDefaultCtx(dht2), key)
+	assert.NoError(t, err)
+	assert.Len(t, dht1.watches.registered(key, time.Now()), 1)
+
+	_, err = dht1.Store(getDefaultCtx(dht1), data)
+	assert.NoError(t, err)
+
+	select {
+	case update := <-watcher.Updates():
+		assert.Equal(t, key, update.Key)
+		assert.Equal(t, data, update.Value)
+		assert.False(t, update.Version.IsZero())
+	case <-time.After(time.Second):
+		assert.Fail(t, "update is not pushed")
+	}
+
+	// Watch is cancelled on holder
+	watcher.Stop()
+	for range watcher.Updates() {
+	}
+	assert.Empty(t, dht1.watches.registered(key, time.Now()))
+
+	for _, dht := range []*DHT{dht1, dht2} {
+		dht.Disconnect()
+		<-done
+	}
+}
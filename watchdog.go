@@ -0,0 +1,157 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"errors"
+	"log"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrOverloaded is returned when lookup is started while watched resource is over its ceiling
+// and ShedLoad option is set
+var ErrOverloaded = errors.New("node is overloaded")
+
+// WatchdogResource is a resource which usage is checked by watchdog
+type WatchdogResource string
+
+// Resources checked by watchdog
+const (
+	ResourceGoroutines       WatchdogResource = "goroutines"
+	ResourceHeap             WatchdogResource = "heap"
+	ResourcePendingRequests  WatchdogResource = "pending requests"
+	ResourceIncomingRequests WatchdogResource = "incoming requests"
+)
+
+// WatchdogEvent reports resource which usage crossed its ceiling
+type WatchdogEvent struct {
+	Resource WatchdogResource
+	Usage    int
+	Ceiling  int
+	// Exceeded is true when usage went over ceiling and false when it fell back
+	Exceeded bool
+	// Shedding tells if lookups are rejected after the event
+	Shedding bool
+}
+
+// watchdog compares resource usage with ceilings and remembers which resources are over them
+type watchdog struct {
+	mutex    *sync.Mutex
+	ceilings map[WatchdogResource]int
+	exceeded map[WatchdogResource]bool
+	shed     bool
+	shedding int32
+}
+
+func newWatchdog(ceilings map[WatchdogResource]int, shed bool) *watchdog {
+	w := &watchdog{
+		mutex:    &sync.Mutex{},
+		ceilings: make(map[WatchdogResource]int),
+		exceeded: make(map[WatchdogResource]bool),
+		shed:     shed,
+	}
+	for resource, ceiling := range ceilings {
+		if ceiling > 0 {
+			w.ceilings[resource] = ceiling
+		}
+	}
+	return w
+}
+
+// enabled tells if any resource has ceiling
+func (w *watchdog) enabled() bool {
+	return len(w.ceilings) > 0
+}
+
+// check compares usage with ceilings and returns events for resources which crossed them since last check
+func (w *watchdog) check(usage map[WatchdogResource]int) []WatchdogEvent {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	var events []WatchdogEvent
+	for resource, ceiling := range w.ceilings {
+		exceeded := usage[resource] > ceiling
+		if exceeded == w.exceeded[resource] {
+			continue
+		}
+		if exceeded {
+			w.exceeded[resource] = true
+		} else {
+			delete(w.exceeded, resource)
+		}
+		events = append(events, WatchdogEvent{Resource: resource, Usage: usage[resource], Ceiling: ceiling, Exceeded: exceeded})
+	}
+
+	shedding := w.shed && len(w.exceeded) > 0
+	if shedding {
+		atomic.StoreInt32(&w.shedding, 1)
+	} else {
+		atomic.StoreInt32(&w.shedding, 0)
+	}
+	for i := range events {
+		events[i].Shedding = shedding
+	}
+	return events
+}
+
+// overloaded tells if lookups are rejected
+func (w *watchdog) overloaded() bool {
+	return atomic.LoadInt32(&w.shedding) == 1
+}
+
+// resourceUsage returns current usage of resources checked by watchdog
+func (dht *DHT) resourceUsage() map[WatchdogResource]int {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return map[WatchdogResource]int{
+		ResourceGoroutines:       runtime.NumGoroutine(),
+		ResourceHeap:             int(mem.HeapAlloc),
+		ResourcePendingRequests:  len(dht.transport.PendingRequests()),
+		ResourceIncomingRequests: dht.incoming.count(),
+	}
+}
+
+// checkWatchdog reports resources which crossed their ceilings
+func (dht *DHT) checkWatchdog() {
+	for _, event := range dht.watchdog.check(dht.resourceUsage()) {
+		if event.Exceeded {
+			log.Printf("Watchdog: %s %d over ceiling %d", event.Resource, event.Usage, event.Ceiling)
+		} else {
+			log.Printf("Watchdog: %s %d back under ceiling %d", event.Resource, event.Usage, event.Ceiling)
+		}
+		if dht.options.OnWatchdog != nil {
+			dht.options.OnWatchdog(event)
+		}
+	}
+}
+
+func (dht *DHT) handleWatchdog(start, stop chan bool) {
+	start <- true
+
+	ticker := dht.options.Clock.NewTicker(dht.options.WatchdogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C():
+			dht.checkWatchdog()
+		case <-stop:
+			return
+		}
+	}
+}
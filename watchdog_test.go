@@ -0,0 +1,80 @@
+/*
+ *    Copyright 2018 INS Ecosystem
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+
+	"github.com/insolar/network/node"
+	"github.com/insolar/network/routing"
+	"github.com/jbenet/go-base58"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchdog_Check(t *testing.T) {
+	w := newWatchdog(map[WatchdogResource]int{ResourceGoroutines: 10, ResourceHeap: 0}, true)
+	assert.True(t, w.enabled())
+	assert.Empty(t, w.check(map[WatchdogResource]int{ResourceGoroutines: 5, ResourceHeap: 1 << 30}))
+	assert.False(t, w.overloaded())
+
+	// Crossing ceiling is reported once
+	events := w.check(map[WatchdogResource]int{ResourceGoroutines: 11})
+	assert.Equal(t, []WatchdogEvent{{Resource: ResourceGoroutines, Usage: 11, Ceiling: 10, Exceeded: true, Shedding: true}}, events)
+	assert.True(t, w.overloaded())
+	assert.Empty(t, w.check(map[WatchdogResource]int{ResourceGoroutines: 12}))
+
+	// Recovery is reported too
+	events = w.check(map[WatchdogResource]int{ResourceGoroutines: 10})
+	assert.Equal(t, []WatchdogEvent{{Resource: ResourceGoroutines, Usage: 10, Ceiling: 10}}, events)
+	assert.False(t, w.overloaded())
+}
+
+func TestWatchdog_NoShedding(t *testing.T) {
+	w := newWatchdog(map[WatchdogResource]int{ResourcePendingRequests: 1}, false)
+	events := w.check(map[WatchdogResource]int{ResourcePendingRequests: 2})
+	assert.Len(t, events, 1)
+	assert.False(t, events[0].Shedding)
+	assert.False(t, w.overloaded())
+
+	assert.False(t, newWatchdog(nil, true).enabled())
+}
+
+func TestGet_Overloaded(t *testing.T) {
+	st, s, tp, r, err := dhtParams([]node.ID{getIDWithValues(0)}, "0.0.0.0:3000")
+	assert.NoError(t, err)
+	var events []WatchdogEvent
+	dht, _ := NewDHT(st, s, tp, r, &Options{
+		WatchdogGoroutines: 1,
+		ShedLoad:           true,
+		OnWatchdog: func(event WatchdogEvent) {
+			events = append(events, event)
+		},
+	})
+	ctx := getDefaultCtx(dht)
+
+	dht.checkWatchdog()
+	assert.Len(t, events, 1)
+	assert.Equal(t, ResourceGoroutines, events[0].Resource)
+	assert.True(t, events[0].Exceeded)
+
+	_, _, err = dht.Get(ctx, base58.Encode(getZerodIDWithNthByte(1, byte(1))))
+	assert.Equal(t, ErrOverloaded, err)
+
+	// Maintenance lookups still run
+	_, _, err = dht.iterate(withMaintenance(ctx), routing.IterateFindNode, getZerodIDWithNthByte(1, byte(1)), nil, nil)
+	assert.NotEqual(t, ErrOverloaded, err)
+}